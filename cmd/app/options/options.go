@@ -3,10 +3,15 @@ package options
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	cliflag "k8s.io/component-base/cli/flag"
 
+	"cola.io/koffee/pkg/server"
 	"cola.io/koffee/pkg/version"
 )
 
@@ -17,33 +22,115 @@ const (
 
 // Options defines all options for the koffee.
 type Options struct {
-	Transport  string
-	Port       int
-	Kubeconfig string
-	Verbose    int
-	Version    bool
+	Transport      string
+	Port           int
+	Kubeconfig     []string
+	ColumnsConfig  string
+	MaxLogTail     int
+	MaxLogBytes    int64
+	MaxExecBytes   int64
+	MaxResultBytes int
+	MaxConcurrent  int
+	RateLimit      float64
+	RateBurst      int
+	ToolTimeout    time.Duration
+	ToolTimeouts   map[string]string
+	ScrubFields    []string
+	GitHosts       []string
+	GitMaxBytes    int64
+	GitFetchBytes  int64
+	SnapshotDir    string
+	LocalFileDir   string
+	PriceTable     string
+	CacheTTL       time.Duration
+	CacheEntries   int
+	// SSEResumeWindow is how long the sse sessions are kept for the clients to resume them.
+	SSEResumeWindow time.Duration
+	// SessionLeaseNamespace enables coordinating the sessions of the replicas by the leases in the namespace.
+	SessionLeaseNamespace string
+	SessionLeaseDuration  time.Duration
+	ReplicaID             string
+	AdvertiseAddress      string
+	ForwardPort           int
+	// ServerName and ServerInstructions are reported to the clients on the initialization.
+	ServerName             string
+	ServerInstructions     string
+	ServerInstructionsFile string
+	Verbose                int
+	Version                bool
 }
 
 // NewOptions returns a new Options object.
 func NewOptions() *Options {
 	return &Options{
-		Transport: StdioTransport,
-		Verbose:   0,
-		Port:      8888,
+		Transport:            StdioTransport,
+		ServerName:           server.DefaultServerName,
+		Verbose:              0,
+		Port:                 8888,
+		MaxLogTail:           1000,
+		MaxLogBytes:          1 << 20,
+		MaxExecBytes:         4 << 20,
+		MaxResultBytes:       256 << 10,
+		MaxConcurrent:        8,
+		RateLimit:            10,
+		RateBurst:            20,
+		ToolTimeout:          time.Minute,
+		ScrubFields:          server.DefaultScrubFields,
+		GitHosts:             server.DefaultGitAllowedHosts,
+		GitMaxBytes:          4 << 20,
+		GitFetchBytes:        64 << 20,
+		CacheEntries:         1000,
+		SSEResumeWindow:      2 * time.Minute,
+		SessionLeaseDuration: 30 * time.Second,
+		ForwardPort:          8889,
 	}
 }
 
 func (o *Options) AddFlags() (fss cliflag.NamedFlagSets) {
 	fs := fss.FlagSet("koffee")
-	fs.StringVarP(&o.Kubeconfig, "kubeconfig", "k", "", "Path to Kubernetes configuration file (uses default config if not specified)")
+	fs.StringArrayVarP(&o.Kubeconfig, "kubeconfig", "k", o.Kubeconfig, "Path to Kubernetes configuration file, repeat the flag or use a path list like KUBECONFIG to merge multiple files (uses default config if not specified)")
+	fs.StringVar(&o.ServerName, "server-name", o.ServerName, "Name of the server reported to the clients, e.g. to tell the servers of the clusters apart")
+	fs.StringVar(&o.ServerInstructions, "server-instructions", o.ServerInstructions, "Instructions reported to the clients to guide the agents, e.g. the safe usage, the naming conventions of the clusters and the allowed namespaces")
+	fs.StringVar(&o.ServerInstructionsFile, "server-instructions-file", o.ServerInstructionsFile, "Path to the file of the instructions reported to the clients, used instead of --server-instructions for the long instructions")
 	fs.StringVarP(&o.Transport, "transport", "t", o.Transport, "Transport protocol to use (stdio, sse)")
 	fs.IntVarP(&o.Port, "port", "p", o.Port, "Port to use for communicating with server, required when using --transport=sse and must be between 1 and 65535")
+	fs.StringVar(&o.ColumnsConfig, "columns-config", o.ColumnsConfig, "Path to the YAML file of custom columns used to print the custom resources in list_resources")
+	fs.IntVar(&o.MaxLogTail, "max-log-tail", o.MaxLogTail, "Maximum lines of the pod logs returned by get_pod_logs")
+	fs.Int64Var(&o.MaxLogBytes, "max-log-bytes", o.MaxLogBytes, "Maximum bytes of the pod logs returned by get_pod_logs for each container")
+	fs.Int64Var(&o.MaxExecBytes, "max-exec-output-bytes", o.MaxExecBytes, "Maximum bytes of the stdout and the stderr kept for each command run in a container, e.g. by run_in_container, the rest is truncated")
+	fs.IntVar(&o.MaxResultBytes, "max-result-bytes", o.MaxResultBytes, "Maximum bytes of each tool result, the larger results are truncated with a summary of the omitted items, 0 means no limit")
+	fs.IntVar(&o.MaxConcurrent, "max-concurrent-tools", o.MaxConcurrent, "Maximum concurrent tool executions of the server, 0 means no limit")
+	fs.Float64Var(&o.RateLimit, "rate-limit", o.RateLimit, "Maximum tool calls per second of each session, 0 means no limit")
+	fs.IntVar(&o.RateBurst, "rate-burst", o.RateBurst, "Maximum burst of the tool calls of each session, used with --rate-limit")
+	fs.DurationVar(&o.ToolTimeout, "tool-timeout", o.ToolTimeout, "Default timeout of each tool call, 0 means no timeout")
+	fs.StringToStringVar(&o.ToolTimeouts, "tool-timeouts", o.ToolTimeouts, "Timeouts of the specified tools overriding --tool-timeout, e.g. get_pod_logs=2m,net_debug=3m")
+	fs.StringSliceVar(&o.ScrubFields, "scrub-fields", o.ScrubFields, "JSON pointers of the noisy fields dropped from the objects returned by get_resource_detail unless raw is set, ~1 escapes the / in the keys")
+	fs.StringSliceVar(&o.GitHosts, "git-allowed-hosts", o.GitHosts, "Hosts of the git repositories the manifests are fetched from by apply_from_git and kustomize_build, including the remote bases of the kustomizations, only the https URLs are supported")
+	fs.Int64Var(&o.GitMaxBytes, "git-max-manifest-bytes", o.GitMaxBytes, "Maximum bytes of the manifests fetched from a git repository by apply_from_git")
+	fs.Int64Var(&o.GitFetchBytes, "git-max-fetch-bytes", o.GitFetchBytes, "Maximum bytes of a git repository fetched by apply_from_git and kustomize_build, including the remote bases of the kustomizations and the checked out files, the larger fetches are canceled")
+	fs.StringVar(&o.SnapshotDir, "snapshot-dir", o.SnapshotDir, "Directory the namespace snapshots taken by snapshot_namespace are saved in by the session, the snapshots are only kept in memory of the session if not specified")
+	fs.StringVar(&o.LocalFileDir, "local-file-dir", o.LocalFileDir, "Directory the local files of create_configmap and create_secret are read from, the local files are rejected if not specified, the content must be passed inline")
+	fs.StringVar(&o.PriceTable, "price-table", o.PriceTable, "Path to the YAML file of the node prices used by estimate_cost, the typical on-demand prices of a vCPU and a GiB of memory are used if not specified")
+	fs.DurationVar(&o.CacheTTL, "cache-ttl", o.CacheTTL, "Expiry of the cached results of the read-only tools, which are revalidated by the resource versions before reuse, 0 disables the cache")
+	fs.IntVar(&o.CacheEntries, "cache-max-entries", o.CacheEntries, "Maximum cached results of the read-only tools, used with --cache-ttl")
+	fs.DurationVar(&o.SSEResumeWindow, "sse-resume-window", o.SSEResumeWindow, "How long the sse sessions are kept after the clients disconnect, the clients reconnecting with the Last-Event-ID within it resume the sessions and receive the missed events, 0 disables the resumption")
+	fs.StringVar(&o.SessionLeaseNamespace, "session-lease-namespace", o.SessionLeaseNamespace, "Namespace of the leases coordinating the sessions of the replicas behind a load balancer in the sse mode, the messages of a session received by a replica not holding it are forwarded to the holder, disabled if empty")
+	fs.DurationVar(&o.SessionLeaseDuration, "session-lease-duration", o.SessionLeaseDuration, "Duration of the session leases, the sessions of a replica gone are taken over after it, used with --session-lease-namespace")
+	fs.StringVar(&o.ReplicaID, "replica-id", o.ReplicaID, "Identity of the replica holding the session leases, defaults to the hostname, used with --session-lease-namespace")
+	fs.StringVar(&o.AdvertiseAddress, "advertise-address", o.AdvertiseAddress, "Address in the form of host:port the other replicas forward the messages of the sessions to, served by the listener of --forward-port and must be reachable only inside the cluster, defaults to $POD_IP and --forward-port, used with --session-lease-namespace")
+	fs.IntVar(&o.ForwardPort, "forward-port", o.ForwardPort, "Port of the listener of the messages forwarded by the other replicas, separate from --port and never exposed by the load balancer, used with --session-lease-namespace")
 	fs.IntVarP(&o.Verbose, "v", "v", o.Verbose, "Setting the slog level, default is info level")
 	fs.BoolVarP(&o.Version, "version", "V", o.Version, "Print version information and quits")
 	return
 }
 
 func (o *Options) Validate() error {
+	if len(strings.TrimSpace(o.ServerName)) == 0 {
+		return errors.New("--server-name must not be empty")
+	}
+	if len(o.ServerInstructions) > 0 && len(o.ServerInstructionsFile) > 0 {
+		return errors.New("--server-instructions and --server-instructions-file are mutually exclusive")
+	}
 	if o.Transport != StdioTransport && o.Transport != SSETransport {
 		return errors.New("--transport must be one of (stdio, sse)")
 	}
@@ -51,9 +138,118 @@ func (o *Options) Validate() error {
 	if o.Transport == "sse" && (o.Port < 1 || o.Port > 65535) {
 		return errors.New("--port is required when using --transport=sse and must be between 1 and 65535")
 	}
+	if o.MaxLogTail < 1 {
+		return errors.New("--max-log-tail must be a positive number")
+	}
+	if o.MaxLogBytes < 1 {
+		return errors.New("--max-log-bytes must be a positive number")
+	}
+	if o.MaxExecBytes < 1 {
+		return errors.New("--max-exec-output-bytes must be a positive number")
+	}
+	if o.MaxResultBytes < 0 {
+		return errors.New("--max-result-bytes must not be negative")
+	}
+	if o.MaxConcurrent < 0 {
+		return errors.New("--max-concurrent-tools must not be negative")
+	}
+	if o.RateLimit < 0 {
+		return errors.New("--rate-limit must not be negative")
+	}
+	if o.RateLimit > 0 && o.RateBurst < 1 {
+		return errors.New("--rate-burst must be a positive number when --rate-limit is set")
+	}
+	if o.ToolTimeout < 0 {
+		return errors.New("--tool-timeout must not be negative")
+	}
+	if _, err := o.ParseToolTimeouts(); err != nil {
+		return err
+	}
+	if o.CacheTTL < 0 {
+		return errors.New("--cache-ttl must not be negative")
+	}
+	if o.CacheTTL > 0 && o.CacheEntries < 1 {
+		return errors.New("--cache-max-entries must be a positive number when --cache-ttl is set")
+	}
+	if o.SSEResumeWindow < 0 {
+		return errors.New("--sse-resume-window must not be negative")
+	}
+	if len(o.SessionLeaseNamespace) > 0 {
+		if o.Transport != SSETransport {
+			return errors.New("--session-lease-namespace is only supported when using --transport=sse")
+		}
+		if o.SessionLeaseDuration < 3*time.Second {
+			return errors.New("--session-lease-duration must be at least 3s")
+		}
+		if o.ForwardPort < 1 || o.ForwardPort > 65535 || o.ForwardPort == o.Port {
+			return errors.New("--forward-port must be between 1 and 65535 and differ from --port")
+		}
+		if len(o.AdvertiseAddress) == 0 && len(os.Getenv("POD_IP")) == 0 {
+			return errors.New("--advertise-address is required when using --session-lease-namespace without $POD_IP")
+		}
+	}
+	if o.GitMaxBytes < 1 {
+		return errors.New("--git-max-manifest-bytes must be a positive number")
+	}
+	if o.GitFetchBytes < 1 {
+		return errors.New("--git-max-fetch-bytes must be a positive number")
+	}
+	for _, field := range o.ScrubFields {
+		if !strings.HasPrefix(field, "/") {
+			return fmt.Errorf("--scrub-fields has an invalid JSON pointer %q, it must start with /", field)
+		}
+	}
 	return nil
 }
 
+// ParseToolTimeouts parses the timeouts of the specified tools.
+func (o *Options) ParseToolTimeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(o.ToolTimeouts))
+	for name, value := range o.ToolTimeouts {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("--tool-timeouts has an invalid timeout %q for tool %q", value, name)
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
+
+// LoadServerInstructions returns the instructions of the server, read from the file if specified.
+func (o *Options) LoadServerInstructions() (string, error) {
+	if len(o.ServerInstructionsFile) == 0 {
+		return o.ServerInstructions, nil
+	}
+	content, err := os.ReadFile(o.ServerInstructionsFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the server instructions file %q: %w", o.ServerInstructionsFile, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// SessionLeaseOptions returns the options of the session leases with the defaults of the replica identity and
+// the advertised address.
+func (o *Options) SessionLeaseOptions() (server.SessionLeaseOptions, error) {
+	options := server.SessionLeaseOptions{
+		Namespace:   o.SessionLeaseNamespace,
+		Identity:    o.ReplicaID,
+		Address:     o.AdvertiseAddress,
+		ForwardPort: o.ForwardPort,
+		Duration:    o.SessionLeaseDuration,
+	}
+	if len(options.Identity) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return options, fmt.Errorf("failed to get the hostname as the replica id, specify --replica-id: %w", err)
+		}
+		options.Identity = hostname
+	}
+	if len(options.Address) == 0 {
+		options.Address = net.JoinHostPort(os.Getenv("POD_IP"), strconv.Itoa(o.ForwardPort))
+	}
+	return options, nil
+}
+
 func (o *Options) PrintAndExitIfRequested() {
 	if o.Version {
 		_, _ = fmt.Fprintf(os.Stdout, "%s\n", version.Get().Pretty())
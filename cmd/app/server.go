@@ -16,6 +16,7 @@ import (
 	"k8s.io/component-base/term"
 
 	"cola.io/koffee/cmd/app/options"
+	"cola.io/koffee/pkg/definition"
 	"cola.io/koffee/pkg/server"
 	"cola.io/koffee/pkg/signals"
 	"cola.io/koffee/pkg/version"
@@ -59,11 +60,56 @@ func NewCommand() *cobra.Command {
 }
 
 func runCommand(ctx context.Context, opts *options.Options) error {
-	svr := server.NewServer(
-		opts.Kubeconfig,
+	toolTimeouts, err := opts.ParseToolTimeouts()
+	if err != nil {
+		return err
+	}
+	instructions, err := opts.LoadServerInstructions()
+	if err != nil {
+		return err
+	}
+
+	serverOpts := []server.ServerOption{
+		server.WithServerIdentity(opts.ServerName, instructions),
 		server.WithTransport(opts.Transport),
 		server.WithPort(opts.Port),
-	)
+		server.WithMaxLogTailLines(opts.MaxLogTail),
+		server.WithMaxLogBytes(opts.MaxLogBytes),
+		server.WithMaxExecOutputBytes(opts.MaxExecBytes),
+		server.WithMaxResultBytes(opts.MaxResultBytes),
+		server.WithMaxConcurrentTools(opts.MaxConcurrent),
+		server.WithRateLimit(opts.RateLimit, opts.RateBurst),
+		server.WithToolTimeout(opts.ToolTimeout, toolTimeouts),
+		server.WithScrubFields(opts.ScrubFields),
+		server.WithGitSource(opts.GitHosts, opts.GitMaxBytes, opts.GitFetchBytes),
+		server.WithSnapshotDir(opts.SnapshotDir),
+		server.WithLocalFileDir(opts.LocalFileDir),
+		server.WithResultCache(opts.CacheTTL, opts.CacheEntries),
+		server.WithSSEResumeWindow(opts.SSEResumeWindow),
+	}
+	if len(opts.ColumnsConfig) > 0 {
+		columnsConfig, err := definition.LoadColumnsConfig(opts.ColumnsConfig)
+		if err != nil {
+			return err
+		}
+		serverOpts = append(serverOpts, server.WithPrintHandlers(columnsConfig.AddHandlers))
+	}
+	if len(opts.SessionLeaseNamespace) > 0 {
+		sessionLeaseOptions, err := opts.SessionLeaseOptions()
+		if err != nil {
+			return err
+		}
+		serverOpts = append(serverOpts, server.WithSessionLeases(sessionLeaseOptions))
+	}
+	if len(opts.PriceTable) > 0 {
+		priceTable, err := server.LoadPriceTable(opts.PriceTable)
+		if err != nil {
+			return err
+		}
+		serverOpts = append(serverOpts, server.WithNodePricer(priceTable))
+	}
+
+	svr := server.NewServer(opts.Kubeconfig, serverOpts...)
 	return svr.Start(ctx)
 }
 
@@ -0,0 +1,267 @@
+// Package audit evaluates the security rules over the pod specs and scores the findings.
+package audit
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+// Severity is the severity of a finding, the higher severities cost more of the score.
+type Severity string
+
+const (
+	SeverityCritical Severity = "Critical"
+	SeverityHigh     Severity = "High"
+	SeverityMedium   Severity = "Medium"
+	SeverityLow      Severity = "Low"
+)
+
+var severityWeights = map[Severity]int{
+	SeverityCritical: 40,
+	SeverityHigh:     20,
+	SeverityMedium:   10,
+	SeverityLow:      3,
+}
+
+// Weight returns the score cost of the severity.
+func (s Severity) Weight() int {
+	return severityWeights[s]
+}
+
+// AtLeast reports whether the severity is at least the specified one, the unknown severities are ranked lowest.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.Weight() >= other.Weight()
+}
+
+// ParseSeverity parses the case-sensitive severity name.
+func ParseSeverity(s string) (Severity, error) {
+	if _, ok := severityWeights[Severity(s)]; !ok {
+		return "", fmt.Errorf("invalid severity %q, must be one of Critical, High, Medium or Low", s)
+	}
+	return Severity(s), nil
+}
+
+// Finding is a violation of a rule, the container is empty for the pod level rules.
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Container string   `json:"container,omitempty"`
+	Message   string   `json:"message"`
+	// Fields are the violating fields of the pod spec, only reported by the field rules.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Rule checks the pod spec and returns the findings.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	Check       func(spec *corev1.PodSpec) []Finding
+}
+
+// PodRule returns the rule checking the pod level settings, the check returns the message of the violation and
+// whether the pod violates the rule.
+func PodRule(id string, severity Severity, description string, check func(spec *corev1.PodSpec) (string, bool)) Rule {
+	return Rule{
+		ID:          id,
+		Severity:    severity,
+		Description: description,
+		Check: func(spec *corev1.PodSpec) []Finding {
+			if message, violated := check(spec); violated {
+				return []Finding{{Rule: id, Severity: severity, Message: message}}
+			}
+			return nil
+		},
+	}
+}
+
+// ContainerRule returns the rule checking each init, regular and ephemeral container of the pod.
+func ContainerRule(id string, severity Severity, description string, check func(spec *corev1.PodSpec, c *Container) (string, bool)) Rule {
+	return Rule{
+		ID:          id,
+		Severity:    severity,
+		Description: description,
+		Check: func(spec *corev1.PodSpec) []Finding {
+			var findings []Finding
+			for _, c := range Containers(spec) {
+				if message, violated := check(spec, &c); violated {
+					findings = append(findings, Finding{Rule: id, Severity: severity, Container: c.Name, Message: message})
+				}
+			}
+			return findings
+		},
+	}
+}
+
+// Container is the common fields of the containers and the ephemeral containers.
+type Container struct {
+	Name string
+	// Path is the field path of the container in the pod spec, e.g. spec.initContainers[0].
+	Path            string
+	Init            bool
+	SecurityContext *corev1.SecurityContext
+	Resources       corev1.ResourceRequirements
+	Ports           []corev1.ContainerPort
+}
+
+// Containers returns the init, regular and ephemeral containers of the pod.
+func Containers(spec *corev1.PodSpec) []Container {
+	containers := make([]Container, 0, len(spec.InitContainers)+len(spec.Containers)+len(spec.EphemeralContainers))
+	for i, c := range spec.InitContainers {
+		containers = append(containers, Container{Name: c.Name, Path: fmt.Sprintf("spec.initContainers[%d]", i), Init: true,
+			SecurityContext: c.SecurityContext, Resources: c.Resources, Ports: c.Ports})
+	}
+	for i, c := range spec.Containers {
+		containers = append(containers, Container{Name: c.Name, Path: fmt.Sprintf("spec.containers[%d]", i),
+			SecurityContext: c.SecurityContext, Resources: c.Resources, Ports: c.Ports})
+	}
+	for i, c := range spec.EphemeralContainers {
+		containers = append(containers, Container{Name: c.Name, Path: fmt.Sprintf("spec.ephemeralContainers[%d]", i),
+			SecurityContext: c.SecurityContext, Resources: c.Resources, Ports: c.Ports})
+	}
+	return containers
+}
+
+// Engine evaluates the rules over the pod specs.
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine returns the engine evaluating the rules, the default rules are used if none is specified.
+func NewEngine(rules ...Rule) *Engine {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Engine{rules: rules}
+}
+
+// Rules returns the rules of the engine.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Audit evaluates the rules over the pod spec and returns the findings of at least the minimum severity, the empty
+// minimum severity means all findings.
+func (e *Engine) Audit(spec *corev1.PodSpec, minSeverity Severity) []Finding {
+	var findings []Finding
+	for _, rule := range e.rules {
+		if len(minSeverity) > 0 && !rule.Severity.AtLeast(minSeverity) {
+			continue
+		}
+		findings = append(findings, rule.Check(spec)...)
+	}
+	return findings
+}
+
+// Score returns the score from 0 to 100 of the findings, each finding costs the weight of its severity.
+func Score(findings []Finding) int {
+	score := 100
+	for _, f := range findings {
+		score -= f.Severity.Weight()
+	}
+	return max(score, 0)
+}
+
+// dangerousCapabilities are the capabilities which practically grant the root of the node.
+var dangerousCapabilities = []corev1.Capability{"ALL", "SYS_ADMIN", "NET_ADMIN", "SYS_PTRACE", "SYS_MODULE", "DAC_READ_SEARCH", "BPF"}
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		ContainerRule("privileged", SeverityCritical, "Containers must not run in privileged mode",
+			func(_ *corev1.PodSpec, c *Container) (string, bool) {
+				return "the container runs in privileged mode", c.SecurityContext != nil && ptr.Deref(c.SecurityContext.Privileged, false)
+			}),
+		PodRule("host-namespaces", SeverityHigh, "Pods must not share the host network, PID or IPC namespaces",
+			func(spec *corev1.PodSpec) (string, bool) {
+				var shared []string
+				if spec.HostNetwork {
+					shared = append(shared, "hostNetwork")
+				}
+				if spec.HostPID {
+					shared = append(shared, "hostPID")
+				}
+				if spec.HostIPC {
+					shared = append(shared, "hostIPC")
+				}
+				return fmt.Sprintf("the pod shares the host namespaces %v", shared), len(shared) > 0
+			}),
+		PodRule("host-path", SeverityHigh, "Pods must not mount the hostPath volumes",
+			func(spec *corev1.PodSpec) (string, bool) {
+				var paths []string
+				for _, v := range spec.Volumes {
+					if v.HostPath != nil {
+						paths = append(paths, v.HostPath.Path)
+					}
+				}
+				return fmt.Sprintf("the pod mounts the host paths %v", paths), len(paths) > 0
+			}),
+		ContainerRule("dangerous-capabilities", SeverityHigh, "Containers must not add the capabilities granting the node root",
+			func(_ *corev1.PodSpec, c *Container) (string, bool) {
+				if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil {
+					return "", false
+				}
+				var added []corev1.Capability
+				for _, capability := range c.SecurityContext.Capabilities.Add {
+					if slices.Contains(dangerousCapabilities, capability) {
+						added = append(added, capability)
+					}
+				}
+				return fmt.Sprintf("the container adds the capabilities %v", added), len(added) > 0
+			}),
+		ContainerRule("run-as-root", SeverityMedium, "Containers must run as a non-root user",
+			func(spec *corev1.PodSpec, c *Container) (string, bool) {
+				runAsUser, runAsNonRoot := effectiveUser(spec, c)
+				if runAsUser != nil && *runAsUser == 0 {
+					return "the container runs as the root user explicitly", true
+				}
+				return "the container may run as root, runAsNonRoot is not set", !ptr.Deref(runAsNonRoot, false) && runAsUser == nil
+			}),
+		ContainerRule("privilege-escalation", SeverityMedium, "Containers must disallow the privilege escalation",
+			func(_ *corev1.PodSpec, c *Container) (string, bool) {
+				allowed := c.SecurityContext == nil || ptr.Deref(c.SecurityContext.AllowPrivilegeEscalation, true)
+				return "allowPrivilegeEscalation is not set to false", allowed
+			}),
+		ContainerRule("missing-limits", SeverityMedium, "Containers must set the CPU and memory limits",
+			func(_ *corev1.PodSpec, c *Container) (string, bool) {
+				var missing []string
+				if _, ok := c.Resources.Limits[corev1.ResourceCPU]; !ok {
+					missing = append(missing, "cpu")
+				}
+				if _, ok := c.Resources.Limits[corev1.ResourceMemory]; !ok {
+					missing = append(missing, "memory")
+				}
+				return fmt.Sprintf("the container has no %v limits", missing), len(missing) > 0
+			}),
+		ContainerRule("missing-security-context", SeverityLow, "Containers should set the security context",
+			func(spec *corev1.PodSpec, c *Container) (string, bool) {
+				return "neither the container nor the pod sets the security context", c.SecurityContext == nil && spec.SecurityContext == nil
+			}),
+		ContainerRule("writable-root-filesystem", SeverityLow, "Containers should use a read-only root filesystem",
+			func(_ *corev1.PodSpec, c *Container) (string, bool) {
+				readOnly := c.SecurityContext != nil && ptr.Deref(c.SecurityContext.ReadOnlyRootFilesystem, false)
+				return "readOnlyRootFilesystem is not set to true", !readOnly
+			}),
+	}
+}
+
+// effectiveUser returns the runAsUser and runAsNonRoot of the container, the container settings override the pod.
+func effectiveUser(spec *corev1.PodSpec, c *Container) (*int64, *bool) {
+	var runAsUser *int64
+	var runAsNonRoot *bool
+	if spec.SecurityContext != nil {
+		runAsUser, runAsNonRoot = spec.SecurityContext.RunAsUser, spec.SecurityContext.RunAsNonRoot
+	}
+	if c.SecurityContext != nil {
+		if c.SecurityContext.RunAsUser != nil {
+			runAsUser = c.SecurityContext.RunAsUser
+		}
+		if c.SecurityContext.RunAsNonRoot != nil {
+			runAsNonRoot = c.SecurityContext.RunAsNonRoot
+		}
+	}
+	return runAsUser, runAsNonRoot
+}
@@ -0,0 +1,374 @@
+package audit
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+// Level is a level of the Pod Security Standards, each level is more restrictive than the previous one.
+type Level string
+
+const (
+	LevelPrivileged Level = "privileged"
+	LevelBaseline   Level = "baseline"
+	LevelRestricted Level = "restricted"
+)
+
+// ParseLevel parses the level of the Pod Security Standards, the empty level is privileged like the PodSecurity
+// admission without the namespace labels.
+func ParseLevel(s string) (Level, error) {
+	switch Level(s) {
+	case "", LevelPrivileged:
+		return LevelPrivileged, nil
+	case LevelBaseline, LevelRestricted:
+		return Level(s), nil
+	}
+	return "", fmt.Errorf("invalid pod security level %q, must be one of privileged, baseline or restricted", s)
+}
+
+var (
+	// baselineCapabilities are the capabilities the baseline level allows to add.
+	baselineCapabilities = []corev1.Capability{"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+		"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+	// baselineSELinuxTypes are the SELinux types the baseline level allows.
+	baselineSELinuxTypes = []string{"", "container_t", "container_init_t", "container_kvm_t", "container_engine_t"}
+	// safeSysctls are the sysctls the baseline level allows.
+	safeSysctls = []string{"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start",
+		"net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range", "net.ipv4.ip_local_reserved_ports", "net.ipv4.tcp_keepalive_time",
+		"net.ipv4.tcp_fin_timeout", "net.ipv4.tcp_keepalive_intvl", "net.ipv4.tcp_keepalive_probes"}
+	// restrictedVolumeTypes are the volume types the restricted level allows.
+	restrictedVolumeTypes = []string{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+)
+
+// LevelRules returns the rules of the checks of the level, the restricted level includes the baseline checks. The
+// IDs of the rules are the IDs of the checks of the PodSecurity admission, and the findings list the violating
+// fields of the pod spec.
+func LevelRules(level Level) []Rule {
+	switch level {
+	case LevelBaseline:
+		return baselineRules()
+	case LevelRestricted:
+		return append(baselineRules(), restrictedRules()...)
+	}
+	return nil
+}
+
+// FieldRule returns the rule reporting the violating fields of the pod spec in a single finding, like the
+// PodSecurity admission reports each check.
+func FieldRule(id string, severity Severity, description string, check func(spec *corev1.PodSpec) []string) Rule {
+	return Rule{
+		ID:          id,
+		Severity:    severity,
+		Description: description,
+		Check: func(spec *corev1.PodSpec) []Finding {
+			fields := check(spec)
+			if len(fields) == 0 {
+				return nil
+			}
+			return []Finding{{Rule: id, Severity: severity, Message: description, Fields: fields}}
+		},
+	}
+}
+
+func baselineRules() []Rule {
+	return []Rule{
+		FieldRule("hostProcess", SeverityCritical, "Windows HostProcess containers are not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.SecurityContext != nil && spec.SecurityContext.WindowsOptions != nil && ptr.Deref(spec.SecurityContext.WindowsOptions.HostProcess, false) {
+					fields = append(fields, "spec.securityContext.windowsOptions.hostProcess=true")
+				}
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil && c.SecurityContext.WindowsOptions != nil && ptr.Deref(c.SecurityContext.WindowsOptions.HostProcess, false) {
+						fields = append(fields, c.Path+".securityContext.windowsOptions.hostProcess=true")
+					}
+				}
+				return fields
+			}),
+		FieldRule("hostNamespaces", SeverityHigh, "Sharing the host network, PID or IPC namespaces is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.HostNetwork {
+					fields = append(fields, "spec.hostNetwork=true")
+				}
+				if spec.HostPID {
+					fields = append(fields, "spec.hostPID=true")
+				}
+				if spec.HostIPC {
+					fields = append(fields, "spec.hostIPC=true")
+				}
+				return fields
+			}),
+		FieldRule("privileged", SeverityCritical, "Privileged containers are not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil && ptr.Deref(c.SecurityContext.Privileged, false) {
+						fields = append(fields, c.Path+".securityContext.privileged=true")
+					}
+				}
+				return fields
+			}),
+		FieldRule("capabilities_baseline", SeverityHigh, "Adding the capabilities beyond the default set is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				for _, c := range Containers(spec) {
+					if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil {
+						continue
+					}
+					for i, capability := range c.SecurityContext.Capabilities.Add {
+						if !slices.Contains(baselineCapabilities, capability) {
+							fields = append(fields, fmt.Sprintf("%s.securityContext.capabilities.add[%d]=%s", c.Path, i, capability))
+						}
+					}
+				}
+				return fields
+			}),
+		FieldRule("hostPathVolumes", SeverityHigh, "HostPath volumes are not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				for i, v := range spec.Volumes {
+					if v.HostPath != nil {
+						fields = append(fields, fmt.Sprintf("spec.volumes[%d].hostPath.path=%s", i, v.HostPath.Path))
+					}
+				}
+				return fields
+			}),
+		FieldRule("hostPorts", SeverityHigh, "Host ports are not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				for _, c := range Containers(spec) {
+					for i, port := range c.Ports {
+						if port.HostPort != 0 {
+							fields = append(fields, fmt.Sprintf("%s.ports[%d].hostPort=%d", c.Path, i, port.HostPort))
+						}
+					}
+				}
+				return fields
+			}),
+		FieldRule("appArmorProfile", SeverityHigh, "Overriding the AppArmor profile to Unconfined is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.SecurityContext != nil && spec.SecurityContext.AppArmorProfile != nil && spec.SecurityContext.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+					fields = append(fields, "spec.securityContext.appArmorProfile.type=Unconfined")
+				}
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil && c.SecurityContext.AppArmorProfile != nil && c.SecurityContext.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+						fields = append(fields, c.Path+".securityContext.appArmorProfile.type=Unconfined")
+					}
+				}
+				return fields
+			}),
+		FieldRule("seLinuxOptions", SeverityHigh, "Setting the SELinux user or role, or a custom SELinux type is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.SecurityContext != nil {
+					fields = append(fields, seLinuxFields("spec.securityContext", spec.SecurityContext.SELinuxOptions)...)
+				}
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil {
+						fields = append(fields, seLinuxFields(c.Path+".securityContext", c.SecurityContext.SELinuxOptions)...)
+					}
+				}
+				return fields
+			}),
+		FieldRule("procMount", SeverityHigh, "Unmasking the /proc mount is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil && c.SecurityContext.ProcMount != nil && *c.SecurityContext.ProcMount != corev1.DefaultProcMount {
+						fields = append(fields, fmt.Sprintf("%s.securityContext.procMount=%s", c.Path, *c.SecurityContext.ProcMount))
+					}
+				}
+				return fields
+			}),
+		FieldRule("seccompProfile_baseline", SeverityHigh, "Setting the seccomp profile to Unconfined is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.SecurityContext != nil && spec.SecurityContext.SeccompProfile != nil && spec.SecurityContext.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+					fields = append(fields, "spec.securityContext.seccompProfile.type=Unconfined")
+				}
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil && c.SecurityContext.SeccompProfile != nil && c.SecurityContext.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+						fields = append(fields, c.Path+".securityContext.seccompProfile.type=Unconfined")
+					}
+				}
+				return fields
+			}),
+		FieldRule("sysctls", SeverityHigh, "Setting the sysctls beyond the safe set is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.SecurityContext == nil {
+					return nil
+				}
+				for i, sysctl := range spec.SecurityContext.Sysctls {
+					if !slices.Contains(safeSysctls, sysctl.Name) {
+						fields = append(fields, fmt.Sprintf("spec.securityContext.sysctls[%d].name=%s", i, sysctl.Name))
+					}
+				}
+				return fields
+			}),
+	}
+}
+
+func restrictedRules() []Rule {
+	return []Rule{
+		FieldRule("restrictedVolumes", SeverityMedium, "Only the configMap, csi, downwardAPI, emptyDir, ephemeral, persistentVolumeClaim, projected and secret volumes are allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				for i := range spec.Volumes {
+					source, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec.Volumes[i].VolumeSource)
+					if err != nil {
+						continue
+					}
+					types := make([]string, 0, len(source))
+					for volumeType := range source {
+						if !slices.Contains(restrictedVolumeTypes, volumeType) {
+							types = append(types, volumeType)
+						}
+					}
+					sort.Strings(types)
+					for _, volumeType := range types {
+						fields = append(fields, fmt.Sprintf("spec.volumes[%d].%s", i, volumeType))
+					}
+				}
+				return fields
+			}),
+		FieldRule("allowPrivilegeEscalation", SeverityMedium, "Containers must set allowPrivilegeEscalation to false",
+			func(spec *corev1.PodSpec) []string {
+				if windowsPod(spec) {
+					return nil
+				}
+				var fields []string
+				for _, c := range Containers(spec) {
+					if c.SecurityContext == nil || c.SecurityContext.AllowPrivilegeEscalation == nil {
+						fields = append(fields, c.Path+".securityContext.allowPrivilegeEscalation is not set")
+					} else if *c.SecurityContext.AllowPrivilegeEscalation {
+						fields = append(fields, c.Path+".securityContext.allowPrivilegeEscalation=true")
+					}
+				}
+				return fields
+			}),
+		FieldRule("runAsNonRoot", SeverityMedium, "Containers must set runAsNonRoot to true in the pod or container security context",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				var podNonRoot *bool
+				if spec.SecurityContext != nil {
+					podNonRoot = spec.SecurityContext.RunAsNonRoot
+				}
+				if podNonRoot != nil && !*podNonRoot {
+					fields = append(fields, "spec.securityContext.runAsNonRoot=false")
+				}
+				for _, c := range Containers(spec) {
+					var nonRoot *bool
+					if c.SecurityContext != nil {
+						nonRoot = c.SecurityContext.RunAsNonRoot
+					}
+					switch {
+					case nonRoot != nil && !*nonRoot:
+						fields = append(fields, c.Path+".securityContext.runAsNonRoot=false")
+					case nonRoot == nil && !ptr.Deref(podNonRoot, false):
+						fields = append(fields, c.Path+".securityContext.runAsNonRoot is not set in the container or the pod")
+					}
+				}
+				return fields
+			}),
+		FieldRule("runAsUser", SeverityMedium, "Running as the root user is not allowed",
+			func(spec *corev1.PodSpec) []string {
+				var fields []string
+				if spec.SecurityContext != nil && ptr.Deref(spec.SecurityContext.RunAsUser, -1) == 0 {
+					fields = append(fields, "spec.securityContext.runAsUser=0")
+				}
+				for _, c := range Containers(spec) {
+					if c.SecurityContext != nil && ptr.Deref(c.SecurityContext.RunAsUser, -1) == 0 {
+						fields = append(fields, c.Path+".securityContext.runAsUser=0")
+					}
+				}
+				return fields
+			}),
+		FieldRule("seccompProfile_restricted", SeverityMedium, "The seccomp profile must be RuntimeDefault or Localhost in the pod or container security context",
+			func(spec *corev1.PodSpec) []string {
+				if windowsPod(spec) {
+					return nil
+				}
+				var podProfile *corev1.SeccompProfile
+				if spec.SecurityContext != nil {
+					podProfile = spec.SecurityContext.SeccompProfile
+				}
+				var fields []string
+				if podProfile != nil && !allowedSeccompProfile(podProfile) {
+					fields = append(fields, fmt.Sprintf("spec.securityContext.seccompProfile.type=%s", podProfile.Type))
+				}
+				for _, c := range Containers(spec) {
+					var profile *corev1.SeccompProfile
+					if c.SecurityContext != nil {
+						profile = c.SecurityContext.SeccompProfile
+					}
+					switch {
+					case profile != nil && !allowedSeccompProfile(profile):
+						fields = append(fields, fmt.Sprintf("%s.securityContext.seccompProfile.type=%s", c.Path, profile.Type))
+					case profile == nil && podProfile == nil:
+						fields = append(fields, c.Path+".securityContext.seccompProfile is not set in the container or the pod")
+					}
+				}
+				return fields
+			}),
+		FieldRule("capabilities_restricted", SeverityMedium, "Containers must drop ALL capabilities and may only add NET_BIND_SERVICE",
+			func(spec *corev1.PodSpec) []string {
+				if windowsPod(spec) {
+					return nil
+				}
+				var fields []string
+				for _, c := range Containers(spec) {
+					var capabilities *corev1.Capabilities
+					if c.SecurityContext != nil {
+						capabilities = c.SecurityContext.Capabilities
+					}
+					if capabilities == nil || !slices.Contains(capabilities.Drop, "ALL") {
+						fields = append(fields, c.Path+".securityContext.capabilities.drop does not include ALL")
+					}
+					if capabilities == nil {
+						continue
+					}
+					for i, capability := range capabilities.Add {
+						if capability != "NET_BIND_SERVICE" {
+							fields = append(fields, fmt.Sprintf("%s.securityContext.capabilities.add[%d]=%s", c.Path, i, capability))
+						}
+					}
+				}
+				return fields
+			}),
+	}
+}
+
+func seLinuxFields(path string, options *corev1.SELinuxOptions) []string {
+	if options == nil {
+		return nil
+	}
+	var fields []string
+	if !slices.Contains(baselineSELinuxTypes, options.Type) {
+		fields = append(fields, fmt.Sprintf("%s.seLinuxOptions.type=%s", path, options.Type))
+	}
+	if len(options.User) > 0 {
+		fields = append(fields, fmt.Sprintf("%s.seLinuxOptions.user=%s", path, options.User))
+	}
+	if len(options.Role) > 0 {
+		fields = append(fields, fmt.Sprintf("%s.seLinuxOptions.role=%s", path, options.Role))
+	}
+	return fields
+}
+
+func allowedSeccompProfile(profile *corev1.SeccompProfile) bool {
+	return profile.Type == corev1.SeccompProfileTypeRuntimeDefault || profile.Type == corev1.SeccompProfileTypeLocalhost
+}
+
+// windowsPod returns true if the pod is scheduled to the Windows nodes, the Linux only checks are skipped for them.
+func windowsPod(spec *corev1.PodSpec) bool {
+	return spec.OS != nil && strings.EqualFold(string(spec.OS.Name), string(corev1.Windows))
+}
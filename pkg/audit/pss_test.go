@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level     string
+		want      Level
+		wantError bool
+	}{
+		{level: "", want: LevelPrivileged},
+		{level: "privileged", want: LevelPrivileged},
+		{level: "baseline", want: LevelBaseline},
+		{level: "restricted", want: LevelRestricted},
+		{level: "strict", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := ParseLevel(tt.level)
+			if (err != nil) != tt.wantError || level != tt.want {
+				t.Fatalf("got level %q with error %v, want %q", level, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelRules(t *testing.T) {
+	// restricted is the pod spec passing the restricted level.
+	restricted := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   ptr.To(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name: "app",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: ptr.To(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+			Volumes: []corev1.Volume{{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		level  Level
+		mutate func(spec *corev1.PodSpec)
+		// want are the violating fields by the rule ids.
+		want map[string][]string
+	}{
+		{name: "privileged level has no rules", level: LevelPrivileged, mutate: func(spec *corev1.PodSpec) { spec.HostNetwork = true }},
+		{name: "restricted spec", level: LevelRestricted},
+		{
+			name:  "host namespaces",
+			level: LevelBaseline,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.HostNetwork, spec.HostPID = true, true
+			},
+			want: map[string][]string{"hostNamespaces": {"spec.hostNetwork=true", "spec.hostPID=true"}},
+		},
+		{
+			name:  "capabilities beyond the baseline",
+			level: LevelBaseline,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"CHOWN", "SYS_ADMIN"}
+			},
+			want: map[string][]string{"capabilities_baseline": {"spec.containers[0].securityContext.capabilities.add[1]=SYS_ADMIN"}},
+		},
+		{
+			name:  "capabilities beyond the restricted",
+			level: LevelRestricted,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"CHOWN", "NET_BIND_SERVICE"}
+			},
+			want: map[string][]string{"capabilities_restricted": {"spec.containers[0].securityContext.capabilities.add[0]=CHOWN"}},
+		},
+		{
+			name:  "sysctls beyond the safe set",
+			level: LevelBaseline,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.SecurityContext.Sysctls = []corev1.Sysctl{{Name: "net.ipv4.tcp_syncookies"}, {Name: "kernel.msgmax"}}
+			},
+			want: map[string][]string{"sysctls": {"spec.securityContext.sysctls[1].name=kernel.msgmax"}},
+		},
+		{
+			name:  "custom SELinux type",
+			level: LevelBaseline,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{Type: "spc_t", User: "system_u"}
+			},
+			want: map[string][]string{"seLinuxOptions": {"spec.securityContext.seLinuxOptions.type=spc_t", "spec.securityContext.seLinuxOptions.user=system_u"}},
+		},
+		{
+			name:  "hostPath volume",
+			level: LevelRestricted,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.Volumes = append(spec.Volumes, corev1.Volume{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}}})
+			},
+			want: map[string][]string{
+				"hostPathVolumes":   {"spec.volumes[1].hostPath.path=/var/run"},
+				"restrictedVolumes": {"spec.volumes[1].hostPath"},
+			},
+		},
+		{
+			name:  "root of the init container",
+			level: LevelRestricted,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.InitContainers = []corev1.Container{{
+					Name: "init",
+					SecurityContext: &corev1.SecurityContext{
+						RunAsNonRoot:             ptr.To(false),
+						RunAsUser:                ptr.To[int64](0),
+						AllowPrivilegeEscalation: ptr.To(false),
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					},
+				}}
+			},
+			want: map[string][]string{
+				"runAsNonRoot": {"spec.initContainers[0].securityContext.runAsNonRoot=false"},
+				"runAsUser":    {"spec.initContainers[0].securityContext.runAsUser=0"},
+			},
+		},
+		{
+			name:  "security context not set",
+			level: LevelRestricted,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.SecurityContext, spec.Containers[0].SecurityContext = nil, nil
+			},
+			want: map[string][]string{
+				"allowPrivilegeEscalation":  {"spec.containers[0].securityContext.allowPrivilegeEscalation is not set"},
+				"runAsNonRoot":              {"spec.containers[0].securityContext.runAsNonRoot is not set in the container or the pod"},
+				"seccompProfile_restricted": {"spec.containers[0].securityContext.seccompProfile is not set in the container or the pod"},
+				"capabilities_restricted":   {"spec.containers[0].securityContext.capabilities.drop does not include ALL"},
+			},
+		},
+		{
+			name:  "linux checks skipped on windows",
+			level: LevelRestricted,
+			mutate: func(spec *corev1.PodSpec) {
+				spec.OS = &corev1.PodOS{Name: corev1.Windows}
+				spec.SecurityContext.SeccompProfile, spec.Containers[0].SecurityContext = nil, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := restricted()
+			if tt.mutate != nil {
+				tt.mutate(spec)
+			}
+			got := make(map[string][]string)
+			for _, rule := range LevelRules(tt.level) {
+				for _, finding := range rule.Check(spec) {
+					got[finding.Rule] = append(got[finding.Rule], finding.Fields...)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got findings %v, want %v", got, tt.want)
+			}
+			for rule, fields := range tt.want {
+				if !slices.Equal(got[rule], fields) {
+					t.Fatalf("got fields %v of %s, want %v", got[rule], rule, fields)
+				}
+			}
+		})
+	}
+}
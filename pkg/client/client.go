@@ -6,7 +6,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -26,16 +26,44 @@ type ClientBuilder interface {
 	LoadRawConfig() (*clientcmdapi.Config, error)
 	LoadRESTConfig() (*rest.Config, error)
 	WriteToFile(config clientcmdapi.Config) error
+	ModifyConfig(modify func(config *clientcmdapi.Config) error) error
+	WithContext(context string) ClientBuilder
+	Namespace() (string, error)
+	InCluster() bool
 }
 
+// serviceAccountNamespaceFile is the namespace file of the ServiceAccount mounted into the pod.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
 type builder struct {
-	kubeconfig string
+	kubeconfigs []string
+	context     string
+}
+
+// NewClientBuilder creates a new ClientBuilder with the specified kubeconfig files. Each of them may be a path list
+// like KUBECONFIG, the files are merged like kubectl, the first file to set a value wins.
+func NewClientBuilder(kubeconfigs ...string) ClientBuilder {
+	files := make([]string, 0, len(kubeconfigs))
+	seen := make(map[string]bool)
+	for _, kubeconfig := range kubeconfigs {
+		for _, file := range filepath.SplitList(kubeconfig) {
+			if len(file) > 0 && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return &builder{
+		kubeconfigs: files,
+	}
 }
 
-// NewClientBuilder creates a new ClientBuilder with the specified kubeconfig file.
-func NewClientBuilder(kubeconfig string) ClientBuilder {
+// WithContext returns a ClientBuilder which builds the clients of the specified kube context instead of the
+// current context, the in-cluster config is not used when the context is specified.
+func (b *builder) WithContext(context string) ClientBuilder {
 	return &builder{
-		kubeconfig: kubeconfig,
+		kubeconfigs: b.kubeconfigs,
+		context:     context,
 	}
 }
 
@@ -75,12 +103,9 @@ func (b *builder) GetDiscoveryClient() (discovery.DiscoveryInterface, error) {
 	return discovery.NewDiscoveryClientForConfig(cfg)
 }
 
-// LoadApiConfig loads the Kubernetes raw configuration from the specified kubeconfig file or default locations.
+// LoadApiConfig loads the Kubernetes raw configuration from the specified kubeconfig files or default locations.
 func (b *builder) LoadRawConfig() (*clientcmdapi.Config, error) {
-	if len(b.kubeconfig) > 0 {
-		return clientcmd.LoadFromFile(b.kubeconfig)
-	}
-	return clientcmd.NewDefaultPathOptions().GetStartingConfig()
+	return b.configAccess().GetStartingConfig()
 }
 
 // LoadRESTConfig loads the Kubernetes configuration from the specified kubeconfig
@@ -88,12 +113,81 @@ func (b *builder) LoadRESTConfig() (*rest.Config, error) {
 	return b.loadConfig()
 }
 
-// WriteToFile writes the provided Kubernetes raw configuration to the kubeconfig file.
+// WriteToFile writes the provided Kubernetes raw configuration to the kubeconfig files, each change is written to
+// the file which defines the modified entry like `kubectl config`.
 func (b *builder) WriteToFile(config clientcmdapi.Config) error {
-	if len(b.kubeconfig) > 0 {
-		return clientcmd.WriteToFile(config, b.kubeconfig)
+	if len(b.kubeconfigs) == 1 {
+		return clientcmd.WriteToFile(config, b.kubeconfigs[0])
+	}
+	return clientcmd.ModifyConfig(b.configAccess(), config, false)
+}
+
+// ModifyConfig loads the raw configuration, applies the modification and writes it back while holding the lock of
+// the kubeconfig file, so that the concurrent edits are not clobbered. Nothing is written if the modification fails.
+func (b *builder) ModifyConfig(modify func(config *clientcmdapi.Config) error) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	// the existing files are locked in the loading order as any of them may be written, the new entries go to the
+	// default file.
+	access := b.configAccess()
+	filenames := []string{access.GetDefaultFilename()}
+	if !access.IsExplicitFile() {
+		filenames = filenames[:0]
+		for _, filename := range access.GetLoadingPrecedence() {
+			if _, err := os.Stat(filename); err == nil || filename == access.GetDefaultFilename() {
+				filenames = append(filenames, filename)
+			}
+		}
+	}
+	for _, filename := range filenames {
+		unlock, err := lockConfigFile(filename)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	config, err := b.LoadRawConfig()
+	if err != nil {
+		return err
+	}
+	if err = modify(config); err != nil {
+		return err
+	}
+	return b.WriteToFile(*config)
+}
+
+// Namespace returns the default namespace of the namespace-scoped calls, it is the namespace of the mounted
+// ServiceAccount with the in-cluster config, otherwise the namespace of the kube context.
+func (b *builder) Namespace() (string, error) {
+	if b.InCluster() {
+		if ns := os.Getenv("POD_NAMESPACE"); len(ns) > 0 {
+			return ns, nil
+		}
+		data, err := os.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the namespace of the ServiceAccount: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	loadingRules, err := b.loadingRules()
+	if err != nil {
+		return "", err
 	}
-	return clientcmd.ModifyConfig(clientcmd.NewDefaultPathOptions(), config, false)
+	namespace, _, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: b.context}).Namespace()
+	return namespace, err
+}
+
+// InCluster returns true if the clients are built with the in-cluster config, that is neither the kubeconfig nor
+// the context is specified and the process runs in a pod.
+func (b *builder) InCluster() bool {
+	if len(b.kubeconfigs) > 0 || len(b.context) > 0 || len(os.Getenv(clientcmd.RecommendedConfigPathEnvVar)) > 0 {
+		return false
+	}
+	_, err := rest.InClusterConfig()
+	return err == nil
 }
 
 // copy from sigs.k8s.io/controller-runtime/pkg/client/config/config.go
@@ -105,19 +199,15 @@ func (b *builder) loadConfig() (config *rest.Config, configErr error) {
 		if config != nil {
 			config.QPS = float32(20)
 			config.Burst = 30
-			config.Timeout = 30 * time.Second
+			config.Wrap(newRetryTransport)
+			config.Wrap(newTimeoutTransport)
+			config.Wrap(newVersionTransport)
 		}
 	}()
 
-	// If a flag is specified with the config location, use that
-	if len(b.kubeconfig) > 0 {
-		return loadConfigWithContext(&clientcmd.ClientConfigLoadingRules{ExplicitPath: b.kubeconfig})
-	}
-
-	// If the recommended kubeconfig env variable is not specified,
-	// try the in-cluster config.
-	kubeconfigPath := os.Getenv(clientcmd.RecommendedConfigPathEnvVar)
-	if len(kubeconfigPath) == 0 {
+	// If neither the flag nor the recommended kubeconfig env variable is
+	// specified, try the in-cluster config.
+	if len(b.kubeconfigs) == 0 && len(b.context) == 0 && len(os.Getenv(clientcmd.RecommendedConfigPathEnvVar)) == 0 {
 		c, err := rest.InClusterConfig()
 		if err == nil {
 			return c, nil
@@ -130,6 +220,25 @@ func (b *builder) loadConfig() (config *rest.Config, configErr error) {
 		}()
 	}
 
+	loadingRules, err := b.loadingRules()
+	if err != nil {
+		return nil, err
+	}
+	return loadConfigWithContext(loadingRules, b.context)
+}
+
+// loadingRules returns the loading rules of the specified kubeconfig files, or the default recommended locations
+// if no file is specified.
+func (b *builder) loadingRules() (*clientcmd.ClientConfigLoadingRules, error) {
+	// If a flag is specified with the config location, use that
+	switch len(b.kubeconfigs) {
+	case 0:
+	case 1:
+		return &clientcmd.ClientConfigLoadingRules{ExplicitPath: b.kubeconfigs[0]}, nil
+	default:
+		return &clientcmd.ClientConfigLoadingRules{Precedence: b.kubeconfigs}, nil
+	}
+
 	// If the recommended kubeconfig env variable is set, or there
 	// is no in-cluster config, try the default recommended locations.
 	//
@@ -144,9 +253,54 @@ func (b *builder) loadConfig() (config *rest.Config, configErr error) {
 		}
 		loadingRules.Precedence = append(loadingRules.Precedence, filepath.Join(u.HomeDir, clientcmd.RecommendedHomeDir, clientcmd.RecommendedFileName))
 	}
-	return loadConfigWithContext(loadingRules)
+	return loadingRules, nil
+}
+
+func loadConfigWithContext(loader clientcmd.ClientConfigLoader, context string) (*rest.Config, error) {
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &clientcmd.ConfigOverrides{CurrentContext: context}).ClientConfig()
+}
+
+// configAccess returns the access of the raw kubeconfig, the single file is accessed as the explicit file and the
+// multiple files are merged like the KUBECONFIG path list.
+func (b *builder) configAccess() clientcmd.ConfigAccess {
+	switch len(b.kubeconfigs) {
+	case 0:
+		return clientcmd.NewDefaultPathOptions()
+	case 1:
+		options := clientcmd.NewDefaultPathOptions()
+		options.LoadingRules.ExplicitPath = b.kubeconfigs[0]
+		return options
+	default:
+		return configFiles(b.kubeconfigs)
+	}
+}
+
+// configFiles is the ConfigAccess of the kubeconfig path list specified by the flags.
+type configFiles []string
+
+func (f configFiles) GetLoadingPrecedence() []string {
+	return f
+}
+
+func (f configFiles) GetStartingConfig() (*clientcmdapi.Config, error) {
+	// the paths are not resolved so that the relative paths are kept when the config is written back.
+	return (&clientcmd.ClientConfigLoadingRules{Precedence: f, DoNotResolvePaths: true}).Load()
+}
+
+// GetDefaultFilename returns the first existing file like kubectl, the new entries are written to it.
+func (f configFiles) GetDefaultFilename() string {
+	for _, filename := range f {
+		if _, err := os.Stat(filename); err == nil {
+			return filename
+		}
+	}
+	return f[len(f)-1]
+}
+
+func (f configFiles) IsExplicitFile() bool {
+	return false
 }
 
-func loadConfigWithContext(loader clientcmd.ClientConfigLoader) (*rest.Config, error) {
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &clientcmd.ConfigOverrides{}).ClientConfig()
+func (f configFiles) GetExplicitFile() string {
+	return ""
 }
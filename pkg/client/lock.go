@@ -0,0 +1,53 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	configLockSuffix   = ".koffee.lock"
+	configLockTimeout  = 10 * time.Second
+	configLockInterval = 50 * time.Millisecond
+	// staleConfigLockAge is the age after which the lock file is considered left by a crashed process.
+	staleConfigLockAge = time.Minute
+)
+
+// configMu serializes the modifications of the kubeconfig in the process, the lock file only guards against the
+// other processes.
+var configMu sync.Mutex
+
+// lockConfigFile creates the lock file next to the kubeconfig file, it waits until the lock file is released by
+// the other process or the timeout. The lock file differs from the one of clientcmd, which is taken while writing.
+func lockConfigFile(filename string) (func(), error) {
+	lockFile := filename + configLockSuffix
+	deadline := time.Now().Add(configLockTimeout)
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_ = f.Close()
+			return func() {
+				if err := os.Remove(lockFile); err != nil {
+					slog.Error("Failed to remove the kubeconfig lock file", "file", lockFile, "err", err)
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock the kubeconfig %s: %w", filename, err)
+		}
+
+		if info, err := os.Stat(lockFile); err == nil && time.Since(info.ModTime()) > staleConfigLockAge {
+			slog.Warn("Removing the stale kubeconfig lock file", "file", lockFile)
+			_ = os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the kubeconfig lock %s", lockFile)
+		}
+		time.Sleep(configLockInterval)
+	}
+}
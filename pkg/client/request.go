@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultRequestTimeout is the timeout of a request to the API server including its retries, unless overridden by
+// the context.
+const defaultRequestTimeout = 30 * time.Second
+
+type requestTimeoutKey struct{}
+
+type maxRetriesKey struct{}
+
+// WithRequestTimeout returns the context overriding the timeout of each request to the API server made with it.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// RequestTimeoutFromContext returns the timeout of the requests overridden by the context.
+func RequestTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(requestTimeoutKey{}).(time.Duration)
+	return timeout, ok
+}
+
+// WithMaxRetries returns the context overriding the maximum retries of each request to the API server made with
+// it, 0 disables the retries.
+func WithMaxRetries(ctx context.Context, retries int) context.Context {
+	return context.WithValue(ctx, maxRetriesKey{}, retries)
+}
+
+// maxRetries returns the maximum retries of the request, overridden by the context or maxRequestRetries.
+func maxRetries(ctx context.Context) int {
+	if retries, ok := ctx.Value(maxRetriesKey{}).(int); ok {
+		return retries
+	}
+	return maxRequestRetries
+}
+
+// timeoutTransport bounds each request to the API server including its retries by the timeout of the context or
+// defaultRequestTimeout, like the timeout of rest.Config but overridable per call. The deadline also covers the
+// reading of the response body, so it's released once the body is closed.
+type timeoutTransport struct {
+	rt http.RoundTripper
+}
+
+func newTimeoutTransport(rt http.RoundTripper) http.RoundTripper {
+	return &timeoutTransport{rt: rt}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the upgraded connections of exec and port-forward live as long as the session.
+	if req.Header.Get("Connection") == "Upgrade" {
+		return t.rt.RoundTrip(req)
+	}
+	timeout, ok := RequestTimeoutFromContext(req.Context())
+	if !ok {
+		timeout = defaultRequestTimeout
+	}
+	if timeout <= 0 {
+		return t.rt.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose cancels the context of the request once the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// maxRequestRetries is the default maximum retries of a request to the API server.
+	maxRequestRetries = 4
+	// maxRetryAfter caps the delay suggested by the Retry-After header of the API server.
+	maxRetryAfter = 10 * time.Second
+)
+
+// retryBackoff is the jittered exponential backoff between the retries without the Retry-After header.
+var retryBackoff = wait.Backoff{Duration: 200 * time.Millisecond, Factor: 2, Jitter: 0.5, Steps: maxRequestRetries, Cap: 5 * time.Second}
+
+// RetryStatus is the retries of the requests to the API server made in the tracked context.
+type RetryStatus struct {
+	Retries int `json:"retries"`
+	// Throttled is the number of the requests rejected by the API server with 429 Too Many Requests.
+	Throttled int    `json:"throttled"`
+	Waited    string `json:"waited"`
+}
+
+type retryTracker struct {
+	mu        sync.Mutex
+	retries   int
+	throttled int
+	waited    time.Duration
+}
+
+type retryTrackerKey struct{}
+
+// WithRetryTracking returns the context tracking the retries of the requests made with it.
+func WithRetryTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryTrackerKey{}, &retryTracker{})
+}
+
+// RetryStatusFromContext returns the retries tracked by the context, false if the context is not tracked or no
+// request has been retried.
+func RetryStatusFromContext(ctx context.Context) (RetryStatus, bool) {
+	tracker, ok := ctx.Value(retryTrackerKey{}).(*retryTracker)
+	if !ok {
+		return RetryStatus{}, false
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if tracker.retries == 0 {
+		return RetryStatus{}, false
+	}
+	return RetryStatus{Retries: tracker.retries, Throttled: tracker.throttled, Waited: tracker.waited.Round(time.Millisecond).String()}, true
+}
+
+func trackRetry(ctx context.Context, throttled bool, delay time.Duration) {
+	tracker, ok := ctx.Value(retryTrackerKey{}).(*retryTracker)
+	if !ok {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.retries++
+	if throttled {
+		tracker.throttled++
+	}
+	tracker.waited += delay
+}
+
+// retryTransport retries the requests failed by the transient errors of the API server. The throttled requests
+// are retried regardless of the method since the API server has not handled them, while the server errors are only
+// retried for the idempotent methods.
+//
+// The rest client of client-go retries the throttling and the server errors if they carry the Retry-After header,
+// so the header is dropped from the final response to retry them in this layer only. The connection resets of the GET requests are
+// left to the rest client, which retries them by itself regardless of the maximum retries of the context.
+type retryTransport struct {
+	rt http.RoundTripper
+}
+
+func newRetryTransport(rt http.RoundTripper) http.RoundTripper {
+	return &retryTransport{rt: rt}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(req)
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError) {
+		resp.Header.Del("Retry-After")
+	}
+	return resp, err
+}
+
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	retries := maxRetries(req.Context())
+	backoff := retryBackoff
+	backoff.Steps = retries
+	for attempt := 1; ; attempt++ {
+		resp, err := t.rt.RoundTrip(req)
+		if attempt > retries {
+			return resp, err
+		}
+		delay, throttled, retry := shouldRetry(req, resp, err)
+		if !retry {
+			return resp, err
+		}
+		if step := backoff.Step(); delay <= 0 {
+			delay = step
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+
+		next := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			next = req.Clone(req.Context())
+			next.Body = body
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		slog.Debug("Retrying the request to the API server", "method", req.Method, "url", req.URL.Path, "attempt", attempt, "delay", delay, "throttled", throttled, "err", err)
+		trackRetry(req.Context(), throttled, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		req = next
+	}
+}
+
+// shouldRetry returns whether the request should be retried, with the delay suggested by the API server and
+// whether the request is throttled.
+func shouldRetry(req *http.Request, resp *http.Response, err error) (time.Duration, bool, bool) {
+	// the upgraded connections of exec and port-forward are never retried.
+	if req.Header.Get("Connection") == "Upgrade" || req.Context().Err() != nil {
+		return 0, false, false
+	}
+	// the connection errors are retried by the rest client.
+	if err != nil || !retryableStatus(resp.StatusCode) {
+		return 0, false, false
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp), true, true
+	}
+	return retryAfter(resp), false, req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// retryableStatus returns whether the status of the response is transient, i.e. the throttling or the server errors.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay of the Retry-After header in seconds, capped by maxRetryAfter.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeRoundTripper returns the statuses in order, a zero status returns the error.
+type fakeRoundTripper struct {
+	statuses   []int
+	retryAfter string
+	err        error
+	attempts   int
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := f.statuses[min(f.attempts, len(f.statuses)-1)]
+	f.attempts++
+	if status == 0 {
+		return nil, f.err
+	}
+	header := http.Header{}
+	if len(f.retryAfter) > 0 {
+		header.Set("Retry-After", f.retryAfter)
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	backoff := retryBackoff
+	retryBackoff.Duration, retryBackoff.Cap = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { retryBackoff = backoff })
+	connectionReset := &fakeRoundTripper{statuses: []int{0}, err: syscall.ECONNRESET}
+
+	tests := []struct {
+		name          string
+		method        string
+		upgrade       bool
+		retries       int
+		rt            *fakeRoundTripper
+		wantStatus    int
+		wantAttempts  int
+		wantThrottled int
+	}{
+		{name: "success", method: http.MethodGet, retries: -1, rt: &fakeRoundTripper{statuses: []int{200}}, wantStatus: 200, wantAttempts: 1},
+		{name: "throttled write", method: http.MethodPost, retries: -1, rt: &fakeRoundTripper{statuses: []int{429, 429, 201}}, wantStatus: 201, wantAttempts: 3, wantThrottled: 2},
+		{name: "server error of read", method: http.MethodGet, retries: -1, rt: &fakeRoundTripper{statuses: []int{503, 200}}, wantStatus: 200, wantAttempts: 2},
+		{name: "server error of write", method: http.MethodPost, retries: -1, rt: &fakeRoundTripper{statuses: []int{503, 200}, retryAfter: "1"}, wantStatus: 503, wantAttempts: 1},
+		{name: "retries exhausted", method: http.MethodGet, retries: 2, rt: &fakeRoundTripper{statuses: []int{500}}, wantStatus: 500, wantAttempts: 3},
+		{name: "retries disabled", method: http.MethodGet, retries: 0, rt: &fakeRoundTripper{statuses: []int{429, 200}, retryAfter: "1"}, wantStatus: 429, wantAttempts: 1},
+		{name: "unretried server error", method: http.MethodGet, retries: -1, rt: &fakeRoundTripper{statuses: []int{501}, retryAfter: "1"}, wantStatus: 501, wantAttempts: 1},
+		{name: "client error", method: http.MethodGet, retries: -1, rt: &fakeRoundTripper{statuses: []int{404}}, wantStatus: 404, wantAttempts: 1},
+		{name: "upgrade", method: http.MethodGet, upgrade: true, retries: -1, rt: &fakeRoundTripper{statuses: []int{503, 101}}, wantStatus: 503, wantAttempts: 1},
+		{name: "connection reset left to the rest client", method: http.MethodGet, retries: -1, rt: connectionReset, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithRetryTracking(context.Background())
+			if tt.retries >= 0 {
+				ctx = WithMaxRetries(ctx, tt.retries)
+			}
+			req, err := http.NewRequestWithContext(ctx, tt.method, "https://kubernetes.default/api/v1/pods", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+			}
+
+			resp, err := newRetryTransport(tt.rt).RoundTrip(req)
+			if tt.rt.attempts != tt.wantAttempts {
+				t.Fatalf("got %d attempts, want %d", tt.rt.attempts, tt.wantAttempts)
+			}
+			if tt.wantStatus == 0 {
+				if !errors.Is(err, syscall.ECONNRESET) {
+					t.Fatalf("got error %v, want the connection reset", err)
+				}
+				return
+			}
+			if err != nil || resp.StatusCode != tt.wantStatus {
+				t.Fatalf("got status %v error %v, want status %d", resp, err, tt.wantStatus)
+			}
+			// the rest client must not retry the final response again.
+			if retryAfter := resp.Header.Get("Retry-After"); tt.wantStatus >= 429 && len(retryAfter) > 0 {
+				t.Fatalf("got Retry-After %s of the final response, want it dropped", retryAfter)
+			}
+			status, _ := RetryStatusFromContext(ctx)
+			if status.Retries != tt.wantAttempts-1 || status.Throttled != tt.wantThrottled {
+				t.Fatalf("got %d retries %d throttled, want %d retries %d throttled", status.Retries, status.Throttled, tt.wantAttempts-1, tt.wantThrottled)
+			}
+		})
+	}
+}
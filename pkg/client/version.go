@@ -0,0 +1,200 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// maxTrackedRequests is the maximum reads tracked per context, the calls reading more are not worth revalidating.
+	maxTrackedRequests = 20
+	// maxVersionPrefixBytes is the maximum bytes of the response scanned for the resource version, which
+	// precede the items of the lists and the spec of the objects.
+	maxVersionPrefixBytes = 64 << 10
+	// metadataAccept requests the metadata of the objects and lists only, so that the revalidation is cheap.
+	metadataAccept = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1,application/json;as=PartialObjectMetadata;g=meta.k8s.io;v=v1,application/json"
+)
+
+// resourceVersionPattern matches the resource version field, the escaped fields in the annotations are not matched.
+var resourceVersionPattern = regexp.MustCompile(`"resourceVersion":"([^"]*)"`)
+
+// TrackedRequest is a read of the API server made with the tracked context and the resource version of the object
+// or list it returned.
+type TrackedRequest struct {
+	URL             string
+	ResourceVersion string
+}
+
+type versionTracker struct {
+	mu        sync.Mutex
+	requests  []TrackedRequest
+	untracked bool
+}
+
+func (t *versionTracker) add(req TrackedRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests = append(t.requests, req)
+	if len(t.requests) > maxTrackedRequests {
+		t.untracked = true
+	}
+}
+
+func (t *versionTracker) fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.untracked = true
+}
+
+type versionTrackerKey struct{}
+
+// WithVersionTracking returns the context tracking the resource versions of the objects and lists read with it.
+func WithVersionTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, versionTrackerKey{}, &versionTracker{})
+}
+
+// TrackedRequests returns the reads tracked by the context, false if the context is not tracked or any request
+// can't be revalidated, e.g. a write, a watch, the logs or a response without the resource version. The discovery
+// requests are not tracked.
+func TrackedRequests(ctx context.Context) ([]TrackedRequest, bool) {
+	tracker, ok := ctx.Value(versionTrackerKey{}).(*versionTracker)
+	if !ok {
+		return nil, false
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if tracker.untracked {
+		return nil, false
+	}
+	return append([]TrackedRequest(nil), tracker.requests...), true
+}
+
+// ResourceVersionsUnchanged reads the metadata of the tracked requests again and returns true if none of their
+// resource versions has changed. The resource version of a list changes with any write to the cluster, so the
+// lists are only unchanged if the cluster is idle.
+func ResourceVersionsUnchanged(ctx context.Context, config *rest.Config, requests []TrackedRequest) (bool, error) {
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return false, err
+	}
+	for _, tracked := range requests {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tracked.URL, nil)
+		if err != nil {
+			return false, err
+		}
+		// the list is read from the storage to get the latest resource version, the limit is ignored by the reads of
+		// a single object.
+		query := req.URL.Query()
+		query.Del("resourceVersion")
+		query.Del("resourceVersionMatch")
+		query.Del("continue")
+		query.Set("limit", "1")
+		req.URL.RawQuery = query.Encode()
+		req.Header.Set("Accept", metadataAccept)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		prefix, err := io.ReadAll(io.LimitReader(resp.Body, maxVersionPrefixBytes))
+		_ = resp.Body.Close()
+		if err != nil {
+			return false, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("failed to revalidate %s: %s", req.URL.Path, resp.Status)
+		}
+		if version, ok := parseVersionPrefix(prefix); !ok || version != tracked.ResourceVersion {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// versionTransport tracks the resource versions of the responses of the requests made with the tracked context.
+type versionTransport struct {
+	rt http.RoundTripper
+}
+
+func newVersionTransport(rt http.RoundTripper) http.RoundTripper {
+	return &versionTransport{rt: rt}
+}
+
+func (t *versionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracker, ok := req.Context().Value(versionTrackerKey{}).(*versionTracker)
+	if !ok || isDiscoveryPath(req.URL.Path) {
+		return t.rt.RoundTrip(req)
+	}
+	if req.Method != http.MethodGet || req.Header.Get("Connection") == "Upgrade" || req.URL.Query().Get("watch") == "true" {
+		tracker.fail()
+		return t.rt.RoundTrip(req)
+	}
+
+	resp, err := t.rt.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		tracker.fail()
+		return resp, err
+	}
+	resp.Body = &versionReader{ReadCloser: resp.Body, tracker: tracker, url: req.URL.String()}
+	return resp, nil
+}
+
+// versionReader keeps the prefix of the response body, the resource version is tracked once the body is closed.
+type versionReader struct {
+	io.ReadCloser
+	tracker *versionTracker
+	url     string
+	prefix  bytes.Buffer
+	closed  bool
+}
+
+func (r *versionReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if remaining := maxVersionPrefixBytes - r.prefix.Len(); remaining > 0 {
+		r.prefix.Write(p[:min(n, remaining)])
+	}
+	return n, err
+}
+
+func (r *versionReader) Close() error {
+	if !r.closed {
+		r.closed = true
+		if version, ok := parseVersionPrefix(r.prefix.Bytes()); ok {
+			r.tracker.add(TrackedRequest{URL: r.url, ResourceVersion: version})
+		} else {
+			r.tracker.fail()
+		}
+	}
+	return r.ReadCloser.Close()
+}
+
+// parseVersionPrefix returns the first resource version in the prefix of the response, which is the version of the
+// list or the object since the metadata precedes the items and the spec.
+func parseVersionPrefix(prefix []byte) (string, bool) {
+	version := resourceVersionPattern.FindSubmatch(prefix)
+	if version == nil || len(version[1]) == 0 {
+		return "", false
+	}
+	return string(version[1]), true
+}
+
+// isDiscoveryPath returns true for the discovery requests, which only change with the served APIs.
+func isDiscoveryPath(path string) bool {
+	switch {
+	case path == "/api" || path == "/apis" || path == "/version" || path == "/api/v1":
+		return true
+	case strings.HasPrefix(path, "/openapi/"):
+		return true
+	case strings.HasPrefix(path, "/apis/"):
+		return strings.Count(strings.Trim(path, "/"), "/") <= 2
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+// Package cron parses the standard 5-field cron expressions used by the Kubernetes CronJobs and computes the
+// upcoming schedule times.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is the parsed cron expression, each field is a bitmask of the allowed values.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record whether the day fields are unrestricted, when both of them are restricted a
+	// day matches either of them like the standard cron.
+	domStar, dowStar bool
+	location         *time.Location
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dows = bounds{0, 6, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses the cron expression in the location, the CRON_TZ= or TZ= prefix of the expression overrides the
+// location. The nil location means UTC, which is the default of the CronJobs without the time zone.
+func Parse(expr string, location *time.Location) (*Schedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+		}
+		location, spec = loc, strings.TrimSpace(rest)
+	}
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields but got %d", expr, len(fields))
+	}
+
+	s := &Schedule{location: location}
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], doms); err != nil {
+		return nil, fmt.Errorf("invalid day of month field: %w", err)
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	// 7 is an alias of Sunday.
+	dowBounds := dows
+	dowBounds.max = 7
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, fmt.Errorf("invalid day of week field: %w", err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+// parseField parses the comma separated list of `*`, `a`, `a-b` with the optional `/step`.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := uint(1)
+		if hasStep {
+			n, err := strconv.ParseUint(stepPart, 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = uint(n)
+		}
+
+		var start, end uint
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(lo, b); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(hi, b); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangePart, b); err != nil {
+				return 0, err
+			}
+			// `a/step` means from a to the max like the standard cron.
+			end = start
+			if hasStep {
+				end = b.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q, the start is beyond the end", rangePart)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, b bounds) (uint, error) {
+	if n, ok := b.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if uint(n) < b.min || uint(n) > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", n, b.min, b.max)
+	}
+	return uint(n), nil
+}
+
+// Location returns the location in which the schedule is evaluated.
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// Next returns the first schedule time after t, the zero time is returned if there is no such time within five
+// years, e.g. for `0 0 30 2 *`.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.location).Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
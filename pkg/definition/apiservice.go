@@ -0,0 +1,139 @@
+package definition
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// APIServiceGroupVersion is the group version of the APIService served by the kube-aggregator.
+var APIServiceGroupVersion = schema.GroupVersion{Group: "apiregistration.k8s.io", Version: "v1"}
+
+// APIService is a minimal copy of the apiregistration.k8s.io/v1 APIService, only the printed fields are
+// kept to avoid depending on the kube-aggregator module.
+type APIService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   APIServiceSpec   `json:"spec,omitempty"`
+	Status APIServiceStatus `json:"status,omitempty"`
+}
+
+// APIServiceSpec contains information for locating and communicating with a server.
+type APIServiceSpec struct {
+	Service *APIServiceReference `json:"service,omitempty"`
+	Group   string               `json:"group,omitempty"`
+	Version string               `json:"version,omitempty"`
+}
+
+// APIServiceReference holds a reference to Service.legacy.k8s.io.
+type APIServiceReference struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Port      *int32 `json:"port,omitempty"`
+}
+
+// APIServiceStatus contains derived information about an API server.
+type APIServiceStatus struct {
+	Conditions []APIServiceCondition `json:"conditions,omitempty"`
+}
+
+// APIServiceCondition describes the state of an APIService at a particular point.
+type APIServiceCondition struct {
+	Type               string      `json:"type"`
+	Status             string      `json:"status"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// APIServiceList is a list of APIService objects.
+type APIServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []APIService `json:"items"`
+}
+
+// DeepCopyInto copies the receiver into out.
+func (in *APIService) DeepCopyInto(out *APIService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Spec.Service != nil {
+		service := *in.Spec.Service
+		if in.Spec.Service.Port != nil {
+			port := *in.Spec.Service.Port
+			service.Port = &port
+		}
+		out.Spec.Service = &service
+	}
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]APIServiceCondition, len(in.Status.Conditions))
+		for i := range in.Status.Conditions {
+			out.Status.Conditions[i] = in.Status.Conditions[i]
+			in.Status.Conditions[i].LastTransitionTime.DeepCopyInto(&out.Status.Conditions[i].LastTransitionTime)
+		}
+	}
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *APIService) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(APIService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *APIServiceList) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServiceList)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]APIService, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+	return out
+}
+
+func printAPIService(obj *APIService, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+
+	service := "Local"
+	if obj.Spec.Service != nil {
+		service = obj.Spec.Service.Namespace + "/" + obj.Spec.Service.Name
+	}
+	available := "Unknown"
+	for _, condition := range obj.Status.Conditions {
+		if condition.Type != "Available" {
+			continue
+		}
+		available = condition.Status
+		if condition.Status != "True" && len(condition.Reason) > 0 {
+			available = condition.Status + " (" + condition.Reason + ")"
+		}
+	}
+	row.Cells = append(row.Cells, obj.Name, service, available, translateTimestampSince(obj.CreationTimestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+// Prints the APIService in a human-friendly format.
+func printAPIServiceList(list *APIServiceList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printAPIService(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
@@ -0,0 +1,216 @@
+package definition
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// UnstructuredPrintFunc prints the unstructured object of the custom kinds, e.g. the custom resources which have no
+// Go types, into the table rows.
+type UnstructuredPrintFunc func(obj *unstructured.Unstructured, options GenerateOptions) ([]metav1.TableRow, error)
+
+type unstructuredHandlerEntry struct {
+	columnDefinitions []metav1.TableColumnDefinition
+	printFunc         UnstructuredPrintFunc
+}
+
+// RegisterKind registers the list object of the kind, so that the objects of the kind are converted into the list
+// object and printed by the table handler registered for the list type.
+func RegisterKind(gvk schema.GroupVersionKind, list runtime.Object) {
+	registry.Register(gvk, list)
+}
+
+// UnstructuredTableHandler adds a print handler with a given set of columns for the unstructured objects of the
+// group kind, the objects are printed regardless of the served version.
+func (h *HumanReadableGenerator) UnstructuredTableHandler(gk schema.GroupKind, columnDefinitions []metav1.TableColumnDefinition, printFunc UnstructuredPrintFunc) error {
+	if _, ok := h.unstructuredHandlerMap[gk]; ok {
+		return fmt.Errorf("registered duplicate printer for %v", gk)
+	}
+	h.unstructuredHandlerMap[gk] = &unstructuredHandlerEntry{
+		columnDefinitions: columnDefinitions,
+		printFunc:         printFunc,
+	}
+	return nil
+}
+
+// HasUnstructuredHandler returns true if a print handler is registered for the group kind.
+func (h *HumanReadableGenerator) HasUnstructuredHandler(gk schema.GroupKind) bool {
+	_, ok := h.unstructuredHandlerMap[gk]
+	return ok
+}
+
+// GenerateUnstructuredTable returns a table for the unstructured list of the group kind, using the printer
+// registered for that group kind.
+func (h *HumanReadableGenerator) GenerateUnstructuredTable(gk schema.GroupKind, list *unstructured.UnstructuredList, options GenerateOptions) (*metav1.Table, error) {
+	handler, ok := h.unstructuredHandlerMap[gk]
+	if !ok {
+		return nil, fmt.Errorf("no table handler registered for %v", gk)
+	}
+
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := handler.printFunc(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+
+	table := &metav1.Table{}
+	table.ColumnDefinitions, table.Rows = applyOptions(handler.columnDefinitions, rows, options)
+	setObjectReferences(table.Rows)
+	table.ResourceVersion = list.GetResourceVersion()
+	table.Continue = list.GetContinue()
+	table.RemainingItemCount = list.GetRemainingItemCount()
+	return table, nil
+}
+
+// ColumnsConfig is the configuration of the custom columns, which is loaded at the server startup to print the
+// custom resources, e.g.
+//
+//	kinds:
+//	- group: argoproj.io
+//	  kind: Application
+//	  columns:
+//	  - name: Sync
+//	    jsonPath: .status.sync.status
+//	  - name: Health
+//	    jsonPath: .status.health.status
+type ColumnsConfig struct {
+	Kinds []KindColumns `json:"kinds"`
+}
+
+// KindColumns defines the columns of the group kind, the Name and Age columns are always printed around them.
+type KindColumns struct {
+	Group   string         `json:"group"`
+	Kind    string         `json:"kind"`
+	Columns []CustomColumn `json:"columns"`
+}
+
+// CustomColumn defines a column printed from the JSONPath of the object, like `kubectl get -o custom-columns`.
+type CustomColumn struct {
+	Name        string `json:"name"`
+	JSONPath    string `json:"jsonPath"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    int32  `json:"priority,omitempty"`
+}
+
+// LoadColumnsConfig loads and validates the custom columns configuration from the YAML file.
+func LoadColumnsConfig(path string) (*ColumnsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns config: %w", err)
+	}
+
+	config := &ColumnsConfig{}
+	if err = yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse columns config: %w", err)
+	}
+
+	for _, kind := range config.Kinds {
+		if len(kind.Kind) == 0 {
+			return nil, fmt.Errorf("kind is required for the custom columns of group %q", kind.Group)
+		}
+		for _, column := range kind.Columns {
+			if len(column.Name) == 0 {
+				return nil, fmt.Errorf("column name is required for kind %q", kind.Kind)
+			}
+			if _, err = parseJSONPath(column.Name, column.JSONPath); err != nil {
+				return nil, fmt.Errorf("invalid jsonPath of column %q for kind %q: %w", column.Name, kind.Kind, err)
+			}
+		}
+	}
+	return config, nil
+}
+
+// AddHandlers adds the print handlers of the configured kinds.
+func (c *ColumnsConfig) AddHandlers(h PrintHandler) {
+	for _, kind := range c.Kinds {
+		columns, printFunc := kind.printer()
+		_ = h.UnstructuredTableHandler(schema.GroupKind{Group: kind.Group, Kind: kind.Kind}, columns, printFunc)
+	}
+}
+
+func (k KindColumns) printer() ([]metav1.TableColumnDefinition, UnstructuredPrintFunc) {
+	columnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+	}
+	parsers := make([]*jsonpath.JSONPath, 0, len(k.Columns))
+	for _, column := range k.Columns {
+		columnType := column.Type
+		if len(columnType) == 0 {
+			columnType = "string"
+		}
+		columnDefinitions = append(columnDefinitions, metav1.TableColumnDefinition{
+			Name:        column.Name,
+			Type:        columnType,
+			Description: column.Description,
+			Priority:    column.Priority,
+		})
+		// the json paths have been validated when loading the config.
+		parser, _ := parseJSONPath(column.Name, column.JSONPath)
+		parsers = append(parsers, parser)
+	}
+	columnDefinitions = append(columnDefinitions, metav1.TableColumnDefinition{
+		Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"],
+	})
+
+	return columnDefinitions, func(obj *unstructured.Unstructured, _ GenerateOptions) ([]metav1.TableRow, error) {
+		row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+		row.Cells = append(row.Cells, obj.GetName())
+		for _, parser := range parsers {
+			row.Cells = append(row.Cells, printJSONPath(parser, obj))
+		}
+		row.Cells = append(row.Cells, translateTimestampSince(obj.GetCreationTimestamp()))
+		return []metav1.TableRow{row}, nil
+	}
+}
+
+// parseJSONPath parses the relaxed JSONPath expression, the `{}` and the leading dot are optional.
+func parseJSONPath(name, path string) (*jsonpath.JSONPath, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "{") {
+		if !strings.HasPrefix(path, ".") {
+			path = "." + path
+		}
+		path = "{" + path + "}"
+	}
+	parser := jsonpath.New(name).AllowMissingKeys(true)
+	if err := parser.Parse(path); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func printJSONPath(parser *jsonpath.JSONPath, obj *unstructured.Unstructured) string {
+	results, err := parser.FindResults(obj.UnstructuredContent())
+	if err != nil {
+		return "<error>"
+	}
+
+	values := make([]string, 0)
+	for _, result := range results {
+		for _, value := range result {
+			out := bytes.NewBuffer(make([]byte, 0))
+			if err = parser.PrintResults(out, []reflect.Value{value}); err != nil {
+				continue
+			}
+			values = append(values, out.String())
+		}
+	}
+	if len(values) == 0 {
+		return "<none>"
+	}
+	return strings.Join(values, ",")
+}
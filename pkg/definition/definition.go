@@ -33,7 +33,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/util/certificate/csr"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	"k8s.io/utils/ptr"
 )
 
@@ -46,50 +48,107 @@ const (
 	nodeLabelRole = "kubernetes.io/role"
 )
 
-var mapping = map[string]runtime.Object{
-	"Pod":                            &corev1.PodList{},
-	"PodDisruptionBudget":            &policyv1.PodDisruptionBudgetList{},
-	"ReplicaSet":                     &appsv1.ReplicaSetList{},
-	"DaemonSet":                      &appsv1.DaemonSetList{},
-	"Deployment":                     &appsv1.DeploymentList{},
-	"StatefulSet":                    &appsv1.StatefulSetList{},
-	"Job":                            &batchv1.JobList{},
-	"CronJob":                        &batchv1beta1.CronJobList{},
-	"Ingress":                        &networkingv1.IngressList{},
-	"Service":                        &corev1.ServiceList{},
-	"Endpoints":                      &corev1.EndpointsList{},
-	"Node":                           &corev1.NodeList{},
-	"Namespace":                      &corev1.NamespaceList{},
-	"Secret":                         &corev1.SecretList{},
-	"ConfigMap":                      &corev1.ConfigMapList{},
-	"PersistentVolume":               &corev1.PersistentVolumeList{},
-	"PersistentVolumeClaim":          &corev1.PersistentVolumeClaimList{},
-	"StorageClass":                   &storagev1.StorageClassList{},
-	"PriorityClass":                  &schedulingv1.PriorityClassList{},
-	"ServiceAccount":                 &corev1.ServiceAccountList{},
-	"Role":                           &rbacv1.RoleList{},
-	"ClusterRole":                    &rbacv1.ClusterRoleList{},
-	"RoleBinding":                    &rbacv1.RoleBindingList{},
-	"ClusterRoleBinding":             &rbacv1.ClusterRoleBindingList{},
-	"HorizontalPodAutoscaler":        &autoscalingv2.HorizontalPodAutoscalerList{},
-	"PriorityLevelConfiguration":     &flowcontrolv1.PriorityLevelConfigurationList{},
-	"CSR":                            &certificatesv1.CertificateSigningRequestList{},
-	"Lease":                          &coordinationv1.LeaseList{},
-	"EndpointSlice":                  &discoveryv1.EndpointSliceList{},
-	"LimitRange":                     &corev1.LimitRangeList{},
-	"ResourceQuota":                  &corev1.ResourceQuotaList{},
-	"Event":                          &corev1.EventList{},
-	"MutatingWebhookConfiguration":   &admissionregistrationv1.MutatingWebhookConfigurationList{},
-	"ValidatingWebhookConfiguration": &admissionregistrationv1.ValidatingWebhookConfigurationList{},
-	"ResourceClaim":                  &resourcev1beta1.ResourceClaimList{},
-	"ResourceSlice":                  &resourcev1beta1.ResourceSliceList{},
-}
-
-func IsSupportedKind(kind string) (runtime.Object, bool) {
-	if _, ok := mapping[kind]; !ok {
-		return nil, false
-	}
-	return mapping[kind], true
+var registry = NewKindRegistry()
+
+func init() {
+	registerKinds(registry)
+}
+
+// registerKinds registers the list objects of the kinds which have table handlers. The group versions of a kind are
+// registered in the preferred order, the older group versions which share the compatible schema are decoded into
+// the list object of the newer one.
+func registerKinds(r *KindRegistry) {
+	r.Register(corev1.SchemeGroupVersion.WithKind("Pod"), &corev1.PodList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("Service"), &corev1.ServiceList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("Endpoints"), &corev1.EndpointsList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("Node"), &corev1.NodeList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("Namespace"), &corev1.NamespaceList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("Secret"), &corev1.SecretList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("ConfigMap"), &corev1.ConfigMapList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("PersistentVolume"), &corev1.PersistentVolumeList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"), &corev1.PersistentVolumeClaimList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("ServiceAccount"), &corev1.ServiceAccountList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("LimitRange"), &corev1.LimitRangeList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("ResourceQuota"), &corev1.ResourceQuotaList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("Event"), &corev1.EventList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("ReplicationController"), &corev1.ReplicationControllerList{})
+	r.Register(corev1.SchemeGroupVersion.WithKind("PodTemplate"), &corev1.PodTemplateList{})
+
+	r.Register(appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), &appsv1.ReplicaSetList{})
+	r.Register(appsv1.SchemeGroupVersion.WithKind("DaemonSet"), &appsv1.DaemonSetList{})
+	r.Register(appsv1.SchemeGroupVersion.WithKind("Deployment"), &appsv1.DeploymentList{})
+	r.Register(appsv1.SchemeGroupVersion.WithKind("StatefulSet"), &appsv1.StatefulSetList{})
+	r.Register(appsv1.SchemeGroupVersion.WithKind("ControllerRevision"), &appsv1.ControllerRevisionList{})
+
+	r.Register(batchv1.SchemeGroupVersion.WithKind("Job"), &batchv1.JobList{})
+	r.Register(batchv1.SchemeGroupVersion.WithKind("CronJob"), &batchv1.CronJobList{})
+	// batch/v1beta1 CronJob is served until Kubernetes 1.25.
+	r.Register(batchv1beta1.SchemeGroupVersion.WithKind("CronJob"), &batchv1beta1.CronJobList{})
+
+	r.Register(policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget"), &policyv1.PodDisruptionBudgetList{})
+	r.Register(schema.GroupVersion{Group: policyv1.GroupName, Version: "v1beta1"}.WithKind("PodDisruptionBudget"), &policyv1.PodDisruptionBudgetList{})
+
+	r.Register(autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler"), &autoscalingv2.HorizontalPodAutoscalerList{})
+	r.Register(schema.GroupVersion{Group: autoscalingv2.GroupName, Version: "v2beta2"}.WithKind("HorizontalPodAutoscaler"), &autoscalingv2.HorizontalPodAutoscalerList{})
+
+	r.Register(networkingv1.SchemeGroupVersion.WithKind("Ingress"), &networkingv1.IngressList{})
+	r.Register(networkingv1.SchemeGroupVersion.WithKind("IngressClass"), &networkingv1.IngressClassList{})
+	r.Register(networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"), &networkingv1.NetworkPolicyList{})
+	r.Register(discoveryv1.SchemeGroupVersion.WithKind("EndpointSlice"), &discoveryv1.EndpointSliceList{})
+	r.Register(storagev1.SchemeGroupVersion.WithKind("StorageClass"), &storagev1.StorageClassList{})
+	r.Register(storagev1.SchemeGroupVersion.WithKind("CSINode"), &storagev1.CSINodeList{})
+	r.Register(storagev1.SchemeGroupVersion.WithKind("CSIDriver"), &storagev1.CSIDriverList{})
+	r.Register(storagev1.SchemeGroupVersion.WithKind("CSIStorageCapacity"), &storagev1.CSIStorageCapacityList{})
+	r.Register(storagev1.SchemeGroupVersion.WithKind("VolumeAttachment"), &storagev1.VolumeAttachmentList{})
+	r.Register(nodev1.SchemeGroupVersion.WithKind("RuntimeClass"), &nodev1.RuntimeClassList{})
+	r.Register(APIServiceGroupVersion.WithKind("APIService"), &APIServiceList{})
+	r.Register(schedulingv1.SchemeGroupVersion.WithKind("PriorityClass"), &schedulingv1.PriorityClassList{})
+	r.Register(coordinationv1.SchemeGroupVersion.WithKind("Lease"), &coordinationv1.LeaseList{})
+	r.Register(certificatesv1.SchemeGroupVersion.WithKind("CertificateSigningRequest"), &certificatesv1.CertificateSigningRequestList{})
+
+	r.Register(rbacv1.SchemeGroupVersion.WithKind("Role"), &rbacv1.RoleList{})
+	r.Register(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), &rbacv1.ClusterRoleList{})
+	r.Register(rbacv1.SchemeGroupVersion.WithKind("RoleBinding"), &rbacv1.RoleBindingList{})
+	r.Register(rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), &rbacv1.ClusterRoleBindingList{})
+
+	r.Register(admissionregistrationv1.SchemeGroupVersion.WithKind("MutatingWebhookConfiguration"), &admissionregistrationv1.MutatingWebhookConfigurationList{})
+	r.Register(admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration"), &admissionregistrationv1.ValidatingWebhookConfigurationList{})
+	// admissionregistration.k8s.io/v1beta1 ValidatingAdmissionPolicy is served by Kubernetes 1.28 and 1.29 and shares the
+	// printed fields with v1.
+	for _, version := range []string{admissionregistrationv1.SchemeGroupVersion.Version, "v1beta1"} {
+		gv := schema.GroupVersion{Group: admissionregistrationv1.GroupName, Version: version}
+		r.Register(gv.WithKind("ValidatingAdmissionPolicy"), &admissionregistrationv1.ValidatingAdmissionPolicyList{})
+		r.Register(gv.WithKind("ValidatingAdmissionPolicyBinding"), &admissionregistrationv1.ValidatingAdmissionPolicyBindingList{})
+	}
+
+	// flowcontrol v1beta3 is served until Kubernetes 1.32 and shares the schema with v1.
+	for _, version := range []string{flowcontrolv1.SchemeGroupVersion.Version, "v1beta3"} {
+		gv := schema.GroupVersion{Group: flowcontrolv1.GroupName, Version: version}
+		r.Register(gv.WithKind("FlowSchema"), &flowcontrolv1.FlowSchemaList{})
+		r.Register(gv.WithKind("PriorityLevelConfiguration"), &flowcontrolv1.PriorityLevelConfigurationList{})
+	}
+
+	r.Register(metricsv1beta1.SchemeGroupVersion.WithKind("PodMetrics"), &metricsv1beta1.PodMetricsList{})
+	r.Register(metricsv1beta1.SchemeGroupVersion.WithKind("NodeMetrics"), &metricsv1beta1.NodeMetricsList{})
+
+	// resource.k8s.io is still evolving, v1alpha3 is served by Kubernetes 1.31 and v1beta2 by 1.33,
+	// the printed fields are the same across the versions.
+	for _, version := range []string{resourcev1beta1.SchemeGroupVersion.Version, "v1beta2", "v1alpha3"} {
+		gv := schema.GroupVersion{Group: resourcev1beta1.GroupName, Version: version}
+		r.Register(gv.WithKind("ResourceClaim"), &resourcev1beta1.ResourceClaimList{})
+		r.Register(gv.WithKind("ResourceSlice"), &resourcev1beta1.ResourceSliceList{})
+	}
+}
+
+// IsSupportedKind returns a new list object of the kind served in the group version.
+func IsSupportedKind(gvk schema.GroupVersionKind) (runtime.Object, bool) {
+	return registry.Lookup(gvk)
+}
+
+// NegotiateKind chooses the best group version of the kind which is both served by the cluster and has the
+// table handler, it returns false if the kind has no table handler in any served group version.
+func NegotiateKind(discoveryClient discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, runtime.Object, bool) {
+	return registry.Negotiate(discoveryClient, kind)
 }
 
 // AddHandlers adds print handlers for default Kubernetes types dealing with internal versions.
@@ -143,6 +202,26 @@ func AddHandlers(h *HumanReadableGenerator) {
 	}
 	_ = h.TableHandler(daemonSetColumnDefinitions, printDaemonSetList)
 
+	replicationControllerColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Desired", Type: "integer", Description: corev1.ReplicationControllerSpec{}.SwaggerDoc()["replicas"]},
+		{Name: "Current", Type: "integer", Description: corev1.ReplicationControllerStatus{}.SwaggerDoc()["replicas"]},
+		{Name: "Ready", Type: "integer", Description: corev1.ReplicationControllerStatus{}.SwaggerDoc()["readyReplicas"]},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+		{Name: "Containers", Type: "string", Priority: 1, Description: "Names of each container in the template."},
+		{Name: "Images", Type: "string", Priority: 1, Description: "Images referenced by each container in the template."},
+		{Name: "Selector", Type: "string", Priority: 1, Description: corev1.ReplicationControllerSpec{}.SwaggerDoc()["selector"]},
+	}
+	_ = h.TableHandler(replicationControllerColumnDefinitions, printReplicationControllerList)
+
+	podTemplateColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Containers", Type: "string", Description: "Names of each container in the template."},
+		{Name: "Images", Type: "string", Description: "Images referenced by each container in the template."},
+		{Name: "Pod Labels", Type: "string", Description: "The labels for the pod template."},
+	}
+	_ = h.TableHandler(podTemplateColumnDefinitions, printPodTemplateList)
+
 	jobColumnDefinitions := []metav1.TableColumnDefinition{
 		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
 		{Name: "Status", Type: "string", Description: "Status of the job."},
@@ -157,17 +236,18 @@ func AddHandlers(h *HumanReadableGenerator) {
 
 	cronJobColumnDefinitions := []metav1.TableColumnDefinition{
 		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
-		{Name: "Schedule", Type: "string", Description: batchv1beta1.CronJobSpec{}.SwaggerDoc()["schedule"]},
-		{Name: "Timezone", Type: "string", Description: batchv1beta1.CronJobSpec{}.SwaggerDoc()["timeZone"]},
-		{Name: "Suspend", Type: "boolean", Description: batchv1beta1.CronJobSpec{}.SwaggerDoc()["suspend"]},
-		{Name: "Active", Type: "integer", Description: batchv1beta1.CronJobStatus{}.SwaggerDoc()["active"]},
-		{Name: "Last Schedule", Type: "string", Description: batchv1beta1.CronJobStatus{}.SwaggerDoc()["lastScheduleTime"]},
+		{Name: "Schedule", Type: "string", Description: batchv1.CronJobSpec{}.SwaggerDoc()["schedule"]},
+		{Name: "Timezone", Type: "string", Description: batchv1.CronJobSpec{}.SwaggerDoc()["timeZone"]},
+		{Name: "Suspend", Type: "boolean", Description: batchv1.CronJobSpec{}.SwaggerDoc()["suspend"]},
+		{Name: "Active", Type: "integer", Description: batchv1.CronJobStatus{}.SwaggerDoc()["active"]},
+		{Name: "Last Schedule", Type: "string", Description: batchv1.CronJobStatus{}.SwaggerDoc()["lastScheduleTime"]},
 		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
 		{Name: "Containers", Type: "string", Priority: 1, Description: "Names of each container in the template."},
 		{Name: "Images", Type: "string", Priority: 1, Description: "Images referenced by each container in the template."},
 		{Name: "Selector", Type: "string", Priority: 1, Description: batchv1.JobSpec{}.SwaggerDoc()["selector"]},
 	}
 	_ = h.TableHandler(cronJobColumnDefinitions, printCronJobList)
+	_ = h.TableHandler(cronJobColumnDefinitions, printCronJobV1beta1List)
 
 	serviceColumnDefinitions := []metav1.TableColumnDefinition{
 		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
@@ -329,6 +409,21 @@ func AddHandlers(h *HumanReadableGenerator) {
 	}
 	_ = h.TableHandler(networkPolicyColumnDefinitioins, printNetworkPolicyList)
 
+	roleColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Rules", Type: "integer", Description: "Rules indicates the number of policy rules in the role"},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	}
+	_ = h.TableHandler(roleColumnDefinitions, printRoleList)
+
+	clusterRoleColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Rules", Type: "integer", Description: "Rules indicates the number of policy rules in the clusterRole"},
+		{Name: "Aggregated", Type: "boolean", Description: rbacv1.ClusterRole{}.SwaggerDoc()["aggregationRule"]},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	}
+	_ = h.TableHandler(clusterRoleColumnDefinitions, printClusterRoleList)
+
 	roleBindingsColumnDefinitions := []metav1.TableColumnDefinition{
 		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
 		{Name: "Role", Type: "string", Description: rbacv1beta1.RoleBinding{}.SwaggerDoc()["roleRef"]},
@@ -467,6 +562,22 @@ func AddHandlers(h *HumanReadableGenerator) {
 	}
 	_ = h.TableHandler(validatingWebhookColumnDefinitions, printValidatingWebhookList)
 
+	validatingAdmissionPolicyColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Validations", Type: "integer", Description: "Validations indicates the number of validation rules defined in this configuration"},
+		{Name: "ParamKind", Type: "string", Description: "ParamKind specifies the kind of resources used to parameterize this policy"},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	}
+	_ = h.TableHandler(validatingAdmissionPolicyColumnDefinitions, printValidatingAdmissionPolicyList)
+
+	validatingAdmissionPolicyBindingColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "PolicyName", Type: "string", Description: "PolicyName indicates the policy definition which the policy binding binded to"},
+		{Name: "ParamRef", Type: "string", Description: "ParamRef indicates the param resource which sets the configration param"},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	}
+	_ = h.TableHandler(validatingAdmissionPolicyBindingColumnDefinitions, printValidatingAdmissionPolicyBindingList)
+
 	flowSchemaColumnDefinitions := []metav1.TableColumnDefinition{
 		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
 		{Name: "PriorityLevel", Type: "string", Description: flowcontrolv1.PriorityLevelConfigurationReference{}.SwaggerDoc()["name"]},
@@ -488,6 +599,14 @@ func AddHandlers(h *HumanReadableGenerator) {
 	}
 	_ = h.TableHandler(priorityLevelColumnDefinitions, printPriorityLevelConfigurationList)
 
+	apiServiceColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Service", Type: "string", Description: "The reference to the service that hosts this API endpoint."},
+		{Name: "Available", Type: "string", Description: "Whether this service is available."},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	}
+	_ = h.TableHandler(apiServiceColumnDefinitions, printAPIServiceList)
+
 	resourceClaimColumnDefinitions := []metav1.TableColumnDefinition{
 		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
 		{Name: "State", Type: "string", Description: "A summary of the current state (allocated, pending, reserved, etc.)."},
@@ -503,6 +622,10 @@ func AddHandlers(h *HumanReadableGenerator) {
 		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
 	}
 	_ = h.TableHandler(nodeResourceSliceColumnDefinitions, printResourceSliceList)
+
+	addMetricsHandlers(h)
+	gatewayColumns.AddHandlers(h)
+	snapshotColumns.AddHandlers(h)
 }
 
 // Pass ports=nil for all ports.
@@ -633,10 +756,10 @@ var (
 	podFailedConditions  = []metav1.TableRowCondition{{Type: metav1.RowCompleted, Status: metav1.ConditionTrue, Reason: string(corev1.PodFailed), Message: "The pod failed."}}
 )
 
-func printPodList(podList *corev1.PodList) ([]metav1.TableRow, error) {
+func printPodList(podList *corev1.PodList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(podList.Items))
 	for i := range podList.Items {
-		r, err := printPod(&podList.Items[i])
+		r, err := printPod(&podList.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -645,13 +768,9 @@ func printPodList(podList *corev1.PodList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printPod(pod *corev1.Pod) ([]metav1.TableRow, error) {
-	restarts := 0
-	restartableInitContainerRestarts := 0
+func printPod(pod *corev1.Pod, options GenerateOptions) ([]metav1.TableRow, error) {
 	totalContainers := len(pod.Spec.Containers)
 	readyContainers := 0
-	lastRestartDate := metav1.NewTime(time.Time{})
-	lastRestartableInitContainerRestartDate := metav1.NewTime(time.Time{})
 
 	podPhase := pod.Status.Phase
 	reason := string(podPhase)
@@ -666,7 +785,7 @@ func printPod(pod *corev1.Pod) ([]metav1.TableRow, error) {
 		}
 	}
 
-	row := metav1.TableRow{}
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: pod}}
 
 	switch pod.Status.Phase {
 	case corev1.PodSucceeded:
@@ -686,22 +805,6 @@ func printPod(pod *corev1.Pod) ([]metav1.TableRow, error) {
 	initializing := false
 	for i := range pod.Status.InitContainerStatuses {
 		container := pod.Status.InitContainerStatuses[i]
-		restarts += int(container.RestartCount)
-		if container.LastTerminationState.Terminated != nil {
-			terminatedDate := container.LastTerminationState.Terminated.FinishedAt
-			if lastRestartDate.Before(&terminatedDate) {
-				lastRestartDate = terminatedDate
-			}
-		}
-		if IsRestartableInitContainer(initContainers[container.Name]) {
-			restartableInitContainerRestarts += int(container.RestartCount)
-			if container.LastTerminationState.Terminated != nil {
-				terminatedDate := container.LastTerminationState.Terminated.FinishedAt
-				if lastRestartableInitContainerRestartDate.Before(&terminatedDate) {
-					lastRestartableInitContainerRestartDate = terminatedDate
-				}
-			}
-		}
 		switch {
 		case container.State.Terminated != nil && container.State.Terminated.ExitCode == 0:
 			continue
@@ -734,19 +837,9 @@ func printPod(pod *corev1.Pod) ([]metav1.TableRow, error) {
 	}
 
 	if !initializing || isPodInitializedConditionTrue(&pod.Status) {
-		restarts = restartableInitContainerRestarts
-		lastRestartDate = lastRestartableInitContainerRestartDate
 		hasRunning := false
 		for i := len(pod.Status.ContainerStatuses) - 1; i >= 0; i-- {
 			container := pod.Status.ContainerStatuses[i]
-
-			restarts += int(container.RestartCount)
-			if container.LastTerminationState.Terminated != nil {
-				terminatedDate := container.LastTerminationState.Terminated.FinishedAt
-				if lastRestartDate.Before(&terminatedDate) {
-					lastRestartDate = terminatedDate
-				}
-			}
 			if container.State.Waiting != nil && container.State.Waiting.Reason != "" {
 				reason = container.State.Waiting.Reason
 			} else if container.State.Terminated != nil && container.State.Terminated.Reason != "" {
@@ -779,6 +872,7 @@ func printPod(pod *corev1.Pod) ([]metav1.TableRow, error) {
 		reason = "Terminating"
 	}
 
+	restarts, lastRestartDate := PodRestarts(pod)
 	restartsStr := strconv.Itoa(restarts)
 	if restarts != 0 && !lastRestartDate.IsZero() {
 		restartsStr = fmt.Sprintf("%d (%s ago)", restarts, translateTimestampSince(lastRestartDate))
@@ -823,6 +917,52 @@ func printPod(pod *corev1.Pod) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
+// PodRestarts returns the restarts and the last restart time of the pod printed in the Restarts column, the
+// restarts of the init containers are only counted while the pod is initializing, except the restartable ones.
+func PodRestarts(pod *corev1.Pod) (int, metav1.Time) {
+	restarts, restartableInitContainerRestarts := 0, 0
+	lastRestartDate, lastRestartableInitContainerRestartDate := metav1.NewTime(time.Time{}), metav1.NewTime(time.Time{})
+
+	initContainers := make(map[string]*corev1.Container)
+	for i := range pod.Spec.InitContainers {
+		initContainers[pod.Spec.InitContainers[i].Name] = &pod.Spec.InitContainers[i]
+	}
+
+	initializing := false
+	for _, container := range pod.Status.InitContainerStatuses {
+		restarts += int(container.RestartCount)
+		if container.LastTerminationState.Terminated != nil && lastRestartDate.Before(&container.LastTerminationState.Terminated.FinishedAt) {
+			lastRestartDate = container.LastTerminationState.Terminated.FinishedAt
+		}
+		restartable := IsRestartableInitContainer(initContainers[container.Name])
+		if restartable {
+			restartableInitContainerRestarts += int(container.RestartCount)
+			if container.LastTerminationState.Terminated != nil && lastRestartableInitContainerRestartDate.Before(&container.LastTerminationState.Terminated.FinishedAt) {
+				lastRestartableInitContainerRestartDate = container.LastTerminationState.Terminated.FinishedAt
+			}
+		}
+		if container.State.Terminated != nil && container.State.Terminated.ExitCode == 0 {
+			continue
+		}
+		if restartable && container.Started != nil && *container.Started {
+			continue
+		}
+		initializing = true
+		break
+	}
+
+	if !initializing || isPodInitializedConditionTrue(&pod.Status) {
+		restarts, lastRestartDate = restartableInitContainerRestarts, lastRestartableInitContainerRestartDate
+		for _, container := range pod.Status.ContainerStatuses {
+			restarts += int(container.RestartCount)
+			if container.LastTerminationState.Terminated != nil && lastRestartDate.Before(&container.LastTerminationState.Terminated.FinishedAt) {
+				lastRestartDate = container.LastTerminationState.Terminated.FinishedAt
+			}
+		}
+	}
+	return restarts, lastRestartDate
+}
+
 func hasPodReadyCondition(conditions []corev1.PodCondition) bool {
 	for _, condition := range conditions {
 		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
@@ -841,8 +981,8 @@ func hasJobCondition(conditions []batchv1.JobCondition, conditionType batchv1.Jo
 	return false
 }
 
-func printPodDisruptionBudget(obj *policyv1.PodDisruptionBudget) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printPodDisruptionBudget(obj *policyv1.PodDisruptionBudget, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	var minAvailable string
 	var maxUnavailable string
@@ -862,10 +1002,60 @@ func printPodDisruptionBudget(obj *policyv1.PodDisruptionBudget) ([]metav1.Table
 	return []metav1.TableRow{row}, nil
 }
 
-func printPodDisruptionBudgetList(list *policyv1.PodDisruptionBudgetList) ([]metav1.TableRow, error) {
+func printPodDisruptionBudgetList(list *policyv1.PodDisruptionBudgetList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printPodDisruptionBudget(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printReplicationController(obj *corev1.ReplicationController, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+
+	desiredReplicas := obj.Spec.Replicas
+	currentReplicas := obj.Status.Replicas
+	readyReplicas := obj.Status.ReadyReplicas
+
+	row.Cells = append(row.Cells, obj.Name, int64(ptr.Deref(desiredReplicas, 0)), int64(currentReplicas), int64(readyReplicas), translateTimestampSince(obj.CreationTimestamp))
+	var names, images string
+	if obj.Spec.Template != nil {
+		names, images = layoutContainerCells(obj.Spec.Template.Spec.Containers)
+	}
+	row.Cells = append(row.Cells, names, images, labels.FormatLabels(obj.Spec.Selector))
+	return []metav1.TableRow{row}, nil
+}
+
+// Prints the ReplicationController in a human-friendly format.
+func printReplicationControllerList(list *corev1.ReplicationControllerList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printReplicationController(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printPodTemplate(obj *corev1.PodTemplate, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+
+	names, images := layoutContainerCells(obj.Template.Spec.Containers)
+	row.Cells = append(row.Cells, obj.Name, names, images, labels.FormatLabels(obj.Template.Labels))
+	return []metav1.TableRow{row}, nil
+}
+
+// Prints the PodTemplate in a human-friendly format.
+func printPodTemplateList(list *corev1.PodTemplateList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printPodDisruptionBudget(&list.Items[i])
+		r, err := printPodTemplate(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -874,8 +1064,8 @@ func printPodDisruptionBudgetList(list *policyv1.PodDisruptionBudgetList) ([]met
 	return rows, nil
 }
 
-func printReplicaSet(obj *appsv1.ReplicaSet) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printReplicaSet(obj *appsv1.ReplicaSet, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	desiredReplicas := obj.Spec.Replicas
 	currentReplicas := obj.Status.Replicas
@@ -887,10 +1077,10 @@ func printReplicaSet(obj *appsv1.ReplicaSet) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printReplicaSetList(list *appsv1.ReplicaSetList) ([]metav1.TableRow, error) {
+func printReplicaSetList(list *appsv1.ReplicaSetList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printReplicaSet(&list.Items[i])
+		r, err := printReplicaSet(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -899,8 +1089,8 @@ func printReplicaSetList(list *appsv1.ReplicaSetList) ([]metav1.TableRow, error)
 	return rows, nil
 }
 
-func printJob(obj *batchv1.Job) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printJob(obj *batchv1.Job, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	var completions string
 	if obj.Spec.Completions != nil {
@@ -945,10 +1135,10 @@ func printJob(obj *batchv1.Job) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printJobList(list *batchv1.JobList) ([]metav1.TableRow, error) {
+func printJobList(list *batchv1.JobList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printJob(&list.Items[i])
+		r, err := printJob(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -957,8 +1147,8 @@ func printJobList(list *batchv1.JobList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printCronJob(obj *batchv1.CronJob) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printCronJob(obj *batchv1.CronJob, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	lastScheduleTime := "<none>"
 	if obj.Status.LastScheduleTime != nil {
@@ -976,10 +1166,41 @@ func printCronJob(obj *batchv1.CronJob) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printCronJobList(list *batchv1.CronJobList) ([]metav1.TableRow, error) {
+func printCronJobList(list *batchv1.CronJobList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printCronJob(&list.Items[i])
+		r, err := printCronJob(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printCronJobV1beta1(obj *batchv1beta1.CronJob, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+
+	lastScheduleTime := "<none>"
+	if obj.Status.LastScheduleTime != nil {
+		lastScheduleTime = translateTimestampSince(*obj.Status.LastScheduleTime)
+	}
+
+	timeZone := "<none>"
+	if obj.Spec.TimeZone != nil {
+		timeZone = *obj.Spec.TimeZone
+	}
+
+	row.Cells = append(row.Cells, obj.Name, obj.Spec.Schedule, timeZone, printBoolPtr(obj.Spec.Suspend), int64(len(obj.Status.Active)), lastScheduleTime, translateTimestampSince(obj.CreationTimestamp))
+	names, images := layoutContainerCells(obj.Spec.JobTemplate.Spec.Template.Spec.Containers)
+	row.Cells = append(row.Cells, names, images, metav1.FormatLabelSelector(obj.Spec.JobTemplate.Spec.Selector))
+	return []metav1.TableRow{row}, nil
+}
+
+func printCronJobV1beta1List(list *batchv1beta1.CronJobList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printCronJobV1beta1(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1052,8 +1273,8 @@ func makePortString(ports []corev1.ServicePort) string {
 	return strings.Join(pieces, ",")
 }
 
-func printService(obj *corev1.Service) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printService(obj *corev1.Service, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	svcType := obj.Spec.Type
 	internalIP := "<none>"
 	if len(obj.Spec.ClusterIPs) > 0 {
@@ -1072,10 +1293,10 @@ func printService(obj *corev1.Service) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printServiceList(list *corev1.ServiceList) ([]metav1.TableRow, error) {
+func printServiceList(list *corev1.ServiceList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printService(&list.Items[i])
+		r, err := printService(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1113,8 +1334,8 @@ func formatPorts(tls []networkingv1.IngressTLS) string {
 	return "80"
 }
 
-func printIngress(obj *networkingv1.Ingress) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printIngress(obj *networkingv1.Ingress, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	className := "<none>"
 	if obj.Spec.IngressClassName != nil {
 		className = *obj.Spec.IngressClassName
@@ -1147,10 +1368,10 @@ func ingressLoadBalancerStatusStringer(s networkingv1.IngressLoadBalancerStatus,
 	return r
 }
 
-func printIngressList(list *networkingv1.IngressList) ([]metav1.TableRow, error) {
+func printIngressList(list *networkingv1.IngressList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printIngress(&list.Items[i])
+		r, err := printIngress(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1159,8 +1380,8 @@ func printIngressList(list *networkingv1.IngressList) ([]metav1.TableRow, error)
 	return rows, nil
 }
 
-func printIngressClass(obj *networkingv1.IngressClass) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printIngressClass(obj *networkingv1.IngressClass, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	parameters := "<none>"
 	if obj.Spec.Parameters != nil {
 		parameters = obj.Spec.Parameters.Kind
@@ -1174,10 +1395,10 @@ func printIngressClass(obj *networkingv1.IngressClass) ([]metav1.TableRow, error
 	return []metav1.TableRow{row}, nil
 }
 
-func printIngressClassList(list *networkingv1.IngressClassList) ([]metav1.TableRow, error) {
+func printIngressClassList(list *networkingv1.IngressClassList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printIngressClass(&list.Items[i])
+		r, err := printIngressClass(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1186,8 +1407,8 @@ func printIngressClassList(list *networkingv1.IngressClassList) ([]metav1.TableR
 	return rows, nil
 }
 
-func printStatefulSet(obj *appsv1.StatefulSet) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printStatefulSet(obj *appsv1.StatefulSet, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	desiredReplicas := obj.Spec.Replicas
 	readyReplicas := obj.Status.ReadyReplicas
 	createTime := translateTimestampSince(obj.CreationTimestamp)
@@ -1197,10 +1418,10 @@ func printStatefulSet(obj *appsv1.StatefulSet) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printStatefulSetList(list *appsv1.StatefulSetList) ([]metav1.TableRow, error) {
+func printStatefulSetList(list *appsv1.StatefulSetList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printStatefulSet(&list.Items[i])
+		r, err := printStatefulSet(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1209,8 +1430,8 @@ func printStatefulSetList(list *appsv1.StatefulSetList) ([]metav1.TableRow, erro
 	return rows, nil
 }
 
-func printDaemonSet(obj *appsv1.DaemonSet) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printDaemonSet(obj *appsv1.DaemonSet, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	desiredScheduled := obj.Status.DesiredNumberScheduled
 	currentScheduled := obj.Status.CurrentNumberScheduled
@@ -1224,10 +1445,10 @@ func printDaemonSet(obj *appsv1.DaemonSet) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printDaemonSetList(list *appsv1.DaemonSetList) ([]metav1.TableRow, error) {
+func printDaemonSetList(list *appsv1.DaemonSetList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printDaemonSet(&list.Items[i])
+		r, err := printDaemonSet(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1236,16 +1457,16 @@ func printDaemonSetList(list *appsv1.DaemonSetList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printEndpoints(obj *corev1.Endpoints) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printEndpoints(obj *corev1.Endpoints, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, formatEndpoints(obj, nil), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printEndpointsList(list *corev1.EndpointsList) ([]metav1.TableRow, error) {
+func printEndpointsList(list *corev1.EndpointsList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printEndpoints(&list.Items[i])
+		r, err := printEndpoints(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1254,16 +1475,16 @@ func printEndpointsList(list *corev1.EndpointsList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printEndpointSlice(obj *discoveryv1.EndpointSlice) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printEndpointSlice(obj *discoveryv1.EndpointSlice, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, string(obj.AddressType), formatDiscoveryPorts(obj.Ports), formatDiscoveryEndpoints(obj.Endpoints), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printEndpointSliceList(list *discoveryv1.EndpointSliceList) ([]metav1.TableRow, error) {
+func printEndpointSliceList(list *discoveryv1.EndpointSliceList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printEndpointSlice(&list.Items[i])
+		r, err := printEndpointSlice(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1272,16 +1493,16 @@ func printEndpointSliceList(list *discoveryv1.EndpointSliceList) ([]metav1.Table
 	return rows, nil
 }
 
-func printCSINode(obj *storagev1.CSINode) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printCSINode(obj *storagev1.CSINode, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Spec.Drivers)), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printCSINodeList(list *storagev1.CSINodeList) ([]metav1.TableRow, error) {
+func printCSINodeList(list *storagev1.CSINodeList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printCSINode(&list.Items[i])
+		r, err := printCSINode(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1290,8 +1511,8 @@ func printCSINodeList(list *storagev1.CSINodeList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printCSIDriver(obj *storagev1.CSIDriver) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printCSIDriver(obj *storagev1.CSIDriver, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	attachRequired := true
 	if obj.Spec.AttachRequired != nil {
 		attachRequired = *obj.Spec.AttachRequired
@@ -1334,10 +1555,10 @@ func printCSIDriver(obj *storagev1.CSIDriver) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printCSIDriverList(list *storagev1.CSIDriverList) ([]metav1.TableRow, error) {
+func printCSIDriverList(list *storagev1.CSIDriverList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printCSIDriver(&list.Items[i])
+		r, err := printCSIDriver(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1346,8 +1567,8 @@ func printCSIDriverList(list *storagev1.CSIDriverList) ([]metav1.TableRow, error
 	return rows, nil
 }
 
-func printCSIStorageCapacity(obj *storagev1.CSIStorageCapacity) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printCSIStorageCapacity(obj *storagev1.CSIStorageCapacity, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	capacity := "<unset>"
 	if obj.Capacity != nil {
@@ -1358,10 +1579,10 @@ func printCSIStorageCapacity(obj *storagev1.CSIStorageCapacity) ([]metav1.TableR
 	return []metav1.TableRow{row}, nil
 }
 
-func printCSIStorageCapacityList(list *storagev1.CSIStorageCapacityList) ([]metav1.TableRow, error) {
+func printCSIStorageCapacityList(list *storagev1.CSIStorageCapacityList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printCSIStorageCapacity(&list.Items[i])
+		r, err := printCSIStorageCapacity(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1370,16 +1591,16 @@ func printCSIStorageCapacityList(list *storagev1.CSIStorageCapacityList) ([]meta
 	return rows, nil
 }
 
-func printMutatingWebhook(obj *admissionregistrationv1.MutatingWebhookConfiguration) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printMutatingWebhook(obj *admissionregistrationv1.MutatingWebhookConfiguration, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Webhooks)), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printMutatingWebhookList(list *admissionregistrationv1.MutatingWebhookConfigurationList) ([]metav1.TableRow, error) {
+func printMutatingWebhookList(list *admissionregistrationv1.MutatingWebhookConfigurationList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printMutatingWebhook(&list.Items[i])
+		r, err := printMutatingWebhook(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1388,16 +1609,38 @@ func printMutatingWebhookList(list *admissionregistrationv1.MutatingWebhookConfi
 	return rows, nil
 }
 
-func printValidatingWebhook(obj *admissionregistrationv1.ValidatingWebhookConfiguration) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printValidatingWebhook(obj *admissionregistrationv1.ValidatingWebhookConfiguration, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Webhooks)), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printValidatingWebhookList(list *admissionregistrationv1.ValidatingWebhookConfigurationList) ([]metav1.TableRow, error) {
+func printValidatingWebhookList(list *admissionregistrationv1.ValidatingWebhookConfigurationList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printValidatingWebhook(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printValidatingAdmissionPolicy(obj *admissionregistrationv1.ValidatingAdmissionPolicy, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	paramKind := "<unset>"
+	if obj.Spec.ParamKind != nil {
+		paramKind = obj.Spec.ParamKind.APIVersion + "/" + obj.Spec.ParamKind.Kind
+	}
+	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Spec.Validations)), paramKind, translateTimestampSince(obj.CreationTimestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+func printValidatingAdmissionPolicyList(list *admissionregistrationv1.ValidatingAdmissionPolicyList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printValidatingWebhook(&list.Items[i])
+		r, err := printValidatingAdmissionPolicy(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1406,16 +1649,45 @@ func printValidatingWebhookList(list *admissionregistrationv1.ValidatingWebhookC
 	return rows, nil
 }
 
-func printNamespace(obj *corev1.Namespace) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printValidatingAdmissionPolicyBinding(obj *admissionregistrationv1.ValidatingAdmissionPolicyBinding, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	paramName := "<unset>"
+	if pr := obj.Spec.ParamRef; pr != nil {
+		if len(pr.Name) > 0 {
+			paramName = pr.Name
+		} else {
+			paramName = "*"
+		}
+		if len(pr.Namespace) > 0 {
+			paramName = pr.Namespace + "/" + paramName
+		}
+	}
+	row.Cells = append(row.Cells, obj.Name, obj.Spec.PolicyName, paramName, translateTimestampSince(obj.CreationTimestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+func printValidatingAdmissionPolicyBindingList(list *admissionregistrationv1.ValidatingAdmissionPolicyBindingList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printValidatingAdmissionPolicyBinding(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printNamespace(obj *corev1.Namespace, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, string(obj.Status.Phase), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printNamespaceList(list *corev1.NamespaceList) ([]metav1.TableRow, error) {
+func printNamespaceList(list *corev1.NamespaceList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printNamespace(&list.Items[i])
+		r, err := printNamespace(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1424,16 +1696,16 @@ func printNamespaceList(list *corev1.NamespaceList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printSecret(obj *corev1.Secret) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printSecret(obj *corev1.Secret, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, string(obj.Type), int64(len(obj.Data)), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printSecretList(list *corev1.SecretList) ([]metav1.TableRow, error) {
+func printSecretList(list *corev1.SecretList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printSecret(&list.Items[i])
+		r, err := printSecret(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1442,16 +1714,16 @@ func printSecretList(list *corev1.SecretList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printServiceAccount(obj *corev1.ServiceAccount) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printServiceAccount(obj *corev1.ServiceAccount, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Secrets)), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printServiceAccountList(list *corev1.ServiceAccountList) ([]metav1.TableRow, error) {
+func printServiceAccountList(list *corev1.ServiceAccountList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printServiceAccount(&list.Items[i])
+		r, err := printServiceAccount(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1460,8 +1732,8 @@ func printServiceAccountList(list *corev1.ServiceAccountList) ([]metav1.TableRow
 	return rows, nil
 }
 
-func printNode(obj *corev1.Node) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printNode(obj *corev1.Node, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	conditionMap := make(map[corev1.NodeConditionType]*corev1.NodeCondition)
 	NodeAllConditions := []corev1.NodeConditionType{corev1.NodeReady}
@@ -1549,10 +1821,10 @@ func findNodeRoles(node *corev1.Node) []string {
 	return roles.UnsortedList()
 }
 
-func printNodeList(list *corev1.NodeList) ([]metav1.TableRow, error) {
+func printNodeList(list *corev1.NodeList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printNode(&list.Items[i])
+		r, err := printNode(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1561,8 +1833,8 @@ func printNodeList(list *corev1.NodeList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printPersistentVolume(obj *corev1.PersistentVolume) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printPersistentVolume(obj *corev1.PersistentVolume, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	claimRefUID := ""
 	if obj.Spec.ClaimRef != nil {
@@ -1597,10 +1869,10 @@ func printPersistentVolume(obj *corev1.PersistentVolume) ([]metav1.TableRow, err
 	return []metav1.TableRow{row}, nil
 }
 
-func printPersistentVolumeList(list *corev1.PersistentVolumeList) ([]metav1.TableRow, error) {
+func printPersistentVolumeList(list *corev1.PersistentVolumeList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printPersistentVolume(&list.Items[i])
+		r, err := printPersistentVolume(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1609,8 +1881,8 @@ func printPersistentVolumeList(list *corev1.PersistentVolumeList) ([]metav1.Tabl
 	return rows, nil
 }
 
-func printPersistentVolumeClaim(obj *corev1.PersistentVolumeClaim) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printPersistentVolumeClaim(obj *corev1.PersistentVolumeClaim, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	phase := obj.Status.Phase
 	if !obj.ObjectMeta.DeletionTimestamp.IsZero() {
@@ -1642,10 +1914,10 @@ func printPersistentVolumeClaim(obj *corev1.PersistentVolumeClaim) ([]metav1.Tab
 	return []metav1.TableRow{row}, nil
 }
 
-func printPersistentVolumeClaimList(list *corev1.PersistentVolumeClaimList) ([]metav1.TableRow, error) {
+func printPersistentVolumeClaimList(list *corev1.PersistentVolumeClaimList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printPersistentVolumeClaim(&list.Items[i])
+		r, err := printPersistentVolumeClaim(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1654,8 +1926,8 @@ func printPersistentVolumeClaimList(list *corev1.PersistentVolumeClaimList) ([]m
 	return rows, nil
 }
 
-func printEvent(obj *corev1.Event) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printEvent(obj *corev1.Event, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	firstTimestamp := translateTimestampSince(obj.FirstTimestamp)
 	if obj.FirstTimestamp.IsZero() {
@@ -1699,10 +1971,29 @@ func printEvent(obj *corev1.Event) ([]metav1.TableRow, error) {
 }
 
 // Sorts and prints the EventList in a human-friendly format.
-func printEventList(list *corev1.EventList) ([]metav1.TableRow, error) {
+func printEventList(list *corev1.EventList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printEvent(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printRole(obj *rbacv1.Role, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Rules)), translateTimestampSince(obj.CreationTimestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+// Prints the Role in a human-friendly format.
+func printRoleList(list *rbacv1.RoleList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printEvent(&list.Items[i])
+		r, err := printRole(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1711,8 +2002,27 @@ func printEventList(list *corev1.EventList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printRoleBinding(obj *rbacv1.RoleBinding) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printClusterRole(obj *rbacv1.ClusterRole, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Rules)), obj.AggregationRule != nil, translateTimestampSince(obj.CreationTimestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+// Prints the ClusterRole in a human-friendly format.
+func printClusterRoleList(list *rbacv1.ClusterRoleList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printClusterRole(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printRoleBinding(obj *rbacv1.RoleBinding, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	roleRef := fmt.Sprintf("%s/%s", obj.RoleRef.Kind, obj.RoleRef.Name)
 	row.Cells = append(row.Cells, obj.Name, roleRef, translateTimestampSince(obj.CreationTimestamp))
@@ -1722,10 +2032,10 @@ func printRoleBinding(obj *rbacv1.RoleBinding) ([]metav1.TableRow, error) {
 }
 
 // Prints the RoleBinding in a human-friendly format.
-func printRoleBindingList(list *rbacv1.RoleBindingList) ([]metav1.TableRow, error) {
+func printRoleBindingList(list *rbacv1.RoleBindingList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printRoleBinding(&list.Items[i])
+		r, err := printRoleBinding(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1734,8 +2044,8 @@ func printRoleBindingList(list *rbacv1.RoleBindingList) ([]metav1.TableRow, erro
 	return rows, nil
 }
 
-func printClusterRoleBinding(obj *rbacv1.ClusterRoleBinding) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printClusterRoleBinding(obj *rbacv1.ClusterRoleBinding, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	roleRef := fmt.Sprintf("%s/%s", obj.RoleRef.Kind, obj.RoleRef.Name)
 	row.Cells = append(row.Cells, obj.Name, roleRef, translateTimestampSince(obj.CreationTimestamp))
@@ -1745,10 +2055,10 @@ func printClusterRoleBinding(obj *rbacv1.ClusterRoleBinding) ([]metav1.TableRow,
 }
 
 // Prints the ClusterRoleBinding in a human-friendly format.
-func printClusterRoleBindingList(list *rbacv1.ClusterRoleBindingList) ([]metav1.TableRow, error) {
+func printClusterRoleBindingList(list *rbacv1.ClusterRoleBindingList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printClusterRoleBinding(&list.Items[i])
+		r, err := printClusterRoleBinding(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1757,8 +2067,8 @@ func printClusterRoleBindingList(list *rbacv1.ClusterRoleBindingList) ([]metav1.
 	return rows, nil
 }
 
-func printCertificateSigningRequest(obj *certificatesv1.CertificateSigningRequest) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printCertificateSigningRequest(obj *certificatesv1.CertificateSigningRequest, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	status := extractCSRStatus(obj)
 	signerName := "<none>"
 	if obj.Spec.SignerName != "" {
@@ -1802,10 +2112,10 @@ func extractCSRStatus(csr *certificatesv1.CertificateSigningRequest) string {
 	return status
 }
 
-func printCertificateSigningRequestList(list *certificatesv1.CertificateSigningRequestList) ([]metav1.TableRow, error) {
+func printCertificateSigningRequestList(list *certificatesv1.CertificateSigningRequestList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printCertificateSigningRequest(&list.Items[i])
+		r, err := printCertificateSigningRequest(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1814,8 +2124,8 @@ func printCertificateSigningRequestList(list *certificatesv1.CertificateSigningR
 	return rows, nil
 }
 
-func printDeployment(obj *appsv1.Deployment) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printDeployment(obj *appsv1.Deployment, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	desiredReplicas := obj.Spec.Replicas
 	updatedReplicas := obj.Status.UpdatedReplicas
 	readyReplicas := obj.Status.ReadyReplicas
@@ -1835,10 +2145,10 @@ func printDeployment(obj *appsv1.Deployment) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printDeploymentList(list *appsv1.DeploymentList) ([]metav1.TableRow, error) {
+func printDeploymentList(list *appsv1.DeploymentList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printDeployment(&list.Items[i])
+		r, err := printDeployment(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1847,92 +2157,128 @@ func printDeploymentList(list *appsv1.DeploymentList) ([]metav1.TableRow, error)
 	return rows, nil
 }
 
-func formatHPAMetrics(specs []autoscalingv2.MetricSpec, statuses []autoscalingv2.MetricStatus) string {
-	if len(specs) == 0 {
-		return "<none>"
+// HPAMetric is the current value and the target of a metric of the HorizontalPodAutoscaler.
+type HPAMetric struct {
+	Type string `json:"type"`
+	// Name is the resource name of the Resource and ContainerResource metrics, otherwise the metric name.
+	Name      string `json:"name,omitempty"`
+	Container string `json:"container,omitempty"`
+	Current   string `json:"current"`
+	Target    string `json:"target"`
+	// Average is true if the target is the average value across the pods.
+	Average bool `json:"average,omitempty"`
+}
+
+// String formats the metric like the TARGETS column of `kubectl get hpa`.
+func (m HPAMetric) String() string {
+	switch autoscalingv2.MetricSourceType(m.Type) {
+	case autoscalingv2.ResourceMetricSourceType, autoscalingv2.ContainerResourceMetricSourceType:
+		return fmt.Sprintf("%s: %s/%s", m.Name, m.Current, m.Target)
+	case autoscalingv2.ExternalMetricSourceType, autoscalingv2.ObjectMetricSourceType:
+		if m.Average {
+			return fmt.Sprintf("%s/%s (avg)", m.Current, m.Target)
+		}
+		return fmt.Sprintf("%s/%s", m.Current, m.Target)
+	case autoscalingv2.PodsMetricSourceType:
+		return fmt.Sprintf("%s/%s", m.Current, m.Target)
+	default:
+		return "<unknown type>"
 	}
-	var list []string
-	maximum := 2
-	more := false
-	count := 0
+}
+
+// HPAMetrics returns the current values and the targets of the metrics, the statuses are matched to the specs
+// by index like kubectl.
+func HPAMetrics(specs []autoscalingv2.MetricSpec, statuses []autoscalingv2.MetricStatus) []HPAMetric {
+	metrics := make([]HPAMetric, 0, len(specs))
 	for i, spec := range specs {
+		m := HPAMetric{Type: string(spec.Type), Current: "<unknown>"}
 		switch spec.Type {
 		case autoscalingv2.ExternalMetricSourceType:
+			m.Name = spec.External.Metric.Name
 			if spec.External.Target.AverageValue != nil {
-				current := "<unknown>"
+				m.Average = true
 				if len(statuses) > i && statuses[i].External != nil && statuses[i].External.Current.AverageValue != nil {
-					current = statuses[i].External.Current.AverageValue.String()
+					m.Current = statuses[i].External.Current.AverageValue.String()
 				}
-				list = append(list, fmt.Sprintf("%s/%s (avg)", current, spec.External.Target.AverageValue.String()))
+				m.Target = spec.External.Target.AverageValue.String()
 			} else {
-				current := "<unknown>"
 				if len(statuses) > i && statuses[i].External != nil {
-					current = statuses[i].External.Current.Value.String()
+					m.Current = statuses[i].External.Current.Value.String()
 				}
-				list = append(list, fmt.Sprintf("%s/%s", current, spec.External.Target.Value.String()))
+				m.Target = spec.External.Target.Value.String()
 			}
 		case autoscalingv2.PodsMetricSourceType:
-			current := "<unknown>"
+			m.Name = spec.Pods.Metric.Name
+			m.Average = true
 			if len(statuses) > i && statuses[i].Pods != nil {
-				current = statuses[i].Pods.Current.AverageValue.String()
+				m.Current = statuses[i].Pods.Current.AverageValue.String()
 			}
-			list = append(list, fmt.Sprintf("%s/%s", current, spec.Pods.Target.AverageValue.String()))
+			m.Target = spec.Pods.Target.AverageValue.String()
 		case autoscalingv2.ObjectMetricSourceType:
+			m.Name = spec.Object.Metric.Name
 			if spec.Object.Target.AverageValue != nil {
-				current := "<unknown>"
+				m.Average = true
 				if len(statuses) > i && statuses[i].Object != nil && statuses[i].Object.Current.AverageValue != nil {
-					current = statuses[i].Object.Current.AverageValue.String()
+					m.Current = statuses[i].Object.Current.AverageValue.String()
 				}
-				list = append(list, fmt.Sprintf("%s/%s (avg)", current, spec.Object.Target.AverageValue.String()))
+				m.Target = spec.Object.Target.AverageValue.String()
 			} else {
-				current := "<unknown>"
 				if len(statuses) > i && statuses[i].Object != nil {
-					current = statuses[i].Object.Current.Value.String()
+					m.Current = statuses[i].Object.Current.Value.String()
 				}
-				list = append(list, fmt.Sprintf("%s/%s", current, spec.Object.Target.Value.String()))
+				m.Target = spec.Object.Target.Value.String()
 			}
 		case autoscalingv2.ResourceMetricSourceType:
+			m.Name = spec.Resource.Name.String()
+			m.Average = true
 			if spec.Resource.Target.AverageValue != nil {
-				current := "<unknown>"
 				if len(statuses) > i && statuses[i].Resource != nil {
-					current = statuses[i].Resource.Current.AverageValue.String()
+					m.Current = statuses[i].Resource.Current.AverageValue.String()
 				}
-				list = append(list, fmt.Sprintf("%s: %s/%s", spec.Resource.Name.String(), current, spec.Resource.Target.AverageValue.String()))
+				m.Target = spec.Resource.Target.AverageValue.String()
 			} else {
-				current := "<unknown>"
 				if len(statuses) > i && statuses[i].Resource != nil && statuses[i].Resource.Current.AverageUtilization != nil {
-					current = fmt.Sprintf("%d%%", *statuses[i].Resource.Current.AverageUtilization)
+					m.Current = fmt.Sprintf("%d%%", *statuses[i].Resource.Current.AverageUtilization)
 				}
-
-				target := "<auto>"
+				m.Target = "<auto>"
 				if spec.Resource.Target.AverageUtilization != nil {
-					target = fmt.Sprintf("%d%%", *spec.Resource.Target.AverageUtilization)
+					m.Target = fmt.Sprintf("%d%%", *spec.Resource.Target.AverageUtilization)
 				}
-				list = append(list, fmt.Sprintf("%s: %s/%s", spec.Resource.Name.String(), current, target))
 			}
 		case autoscalingv2.ContainerResourceMetricSourceType:
+			m.Name = spec.ContainerResource.Name.String()
+			m.Container = spec.ContainerResource.Container
+			m.Average = true
 			if spec.ContainerResource.Target.AverageValue != nil {
-				current := "<unknown>"
 				if len(statuses) > i && statuses[i].ContainerResource != nil {
-					current = statuses[i].ContainerResource.Current.AverageValue.String()
+					m.Current = statuses[i].ContainerResource.Current.AverageValue.String()
 				}
-				list = append(list, fmt.Sprintf("%s: %s/%s", spec.ContainerResource.Name.String(), current, spec.ContainerResource.Target.AverageValue.String()))
+				m.Target = spec.ContainerResource.Target.AverageValue.String()
 			} else {
-				current := "<unknown>"
 				if len(statuses) > i && statuses[i].ContainerResource != nil && statuses[i].ContainerResource.Current.AverageUtilization != nil {
-					current = fmt.Sprintf("%d%%", *statuses[i].ContainerResource.Current.AverageUtilization)
+					m.Current = fmt.Sprintf("%d%%", *statuses[i].ContainerResource.Current.AverageUtilization)
 				}
-
-				target := "<auto>"
+				m.Target = "<auto>"
 				if spec.ContainerResource.Target.AverageUtilization != nil {
-					target = fmt.Sprintf("%d%%", *spec.ContainerResource.Target.AverageUtilization)
+					m.Target = fmt.Sprintf("%d%%", *spec.ContainerResource.Target.AverageUtilization)
 				}
-				list = append(list, fmt.Sprintf("%s: %s/%s", spec.ContainerResource.Name.String(), current, target))
 			}
-		default:
-			list = append(list, "<unknown type>")
 		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
 
+func formatHPAMetrics(specs []autoscalingv2.MetricSpec, statuses []autoscalingv2.MetricStatus) string {
+	if len(specs) == 0 {
+		return "<none>"
+	}
+	var list []string
+	maximum := 2
+	more := false
+	count := 0
+	for _, m := range HPAMetrics(specs, statuses) {
+		list = append(list, m.String())
 		count++
 	}
 
@@ -1948,8 +2294,8 @@ func formatHPAMetrics(specs []autoscalingv2.MetricSpec, statuses []autoscalingv2
 	return ret
 }
 
-func printHorizontalPodAutoscaler(obj *autoscalingv2.HorizontalPodAutoscaler) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printHorizontalPodAutoscaler(obj *autoscalingv2.HorizontalPodAutoscaler, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	reference := fmt.Sprintf("%s/%s",
 		obj.Spec.ScaleTargetRef.Kind,
@@ -1965,10 +2311,10 @@ func printHorizontalPodAutoscaler(obj *autoscalingv2.HorizontalPodAutoscaler) ([
 	return []metav1.TableRow{row}, nil
 }
 
-func printHorizontalPodAutoscalerList(list *autoscalingv2.HorizontalPodAutoscalerList) ([]metav1.TableRow, error) {
+func printHorizontalPodAutoscalerList(list *autoscalingv2.HorizontalPodAutoscalerList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printHorizontalPodAutoscaler(&list.Items[i])
+		r, err := printHorizontalPodAutoscaler(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1977,16 +2323,16 @@ func printHorizontalPodAutoscalerList(list *autoscalingv2.HorizontalPodAutoscale
 	return rows, nil
 }
 
-func printConfigMap(obj *corev1.ConfigMap) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printConfigMap(obj *corev1.ConfigMap, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, int64(len(obj.Data)+len(obj.BinaryData)), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printConfigMapList(list *corev1.ConfigMapList) ([]metav1.TableRow, error) {
+func printConfigMapList(list *corev1.ConfigMapList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printConfigMap(&list.Items[i])
+		r, err := printConfigMap(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -1995,16 +2341,16 @@ func printConfigMapList(list *corev1.ConfigMapList) ([]metav1.TableRow, error) {
 	return rows, nil
 }
 
-func printNetworkPolicy(obj *networkingv1.NetworkPolicy) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printNetworkPolicy(obj *networkingv1.NetworkPolicy, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, metav1.FormatLabelSelector(&obj.Spec.PodSelector), translateTimestampSince(obj.CreationTimestamp))
 	return []metav1.TableRow{row}, nil
 }
 
-func printNetworkPolicyList(list *networkingv1.NetworkPolicyList) ([]metav1.TableRow, error) {
+func printNetworkPolicyList(list *networkingv1.NetworkPolicyList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printNetworkPolicy(&list.Items[i])
+		r, err := printNetworkPolicy(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2013,8 +2359,8 @@ func printNetworkPolicyList(list *networkingv1.NetworkPolicyList) ([]metav1.Tabl
 	return rows, nil
 }
 
-func printStorageClass(obj *storagev1.StorageClass) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printStorageClass(obj *storagev1.StorageClass, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	name := obj.Name
 	if IsDefaultAnnotation(obj.ObjectMeta) {
@@ -2043,10 +2389,10 @@ func printStorageClass(obj *storagev1.StorageClass) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printStorageClassList(list *storagev1.StorageClassList) ([]metav1.TableRow, error) {
+func printStorageClassList(list *storagev1.StorageClassList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printStorageClass(&list.Items[i])
+		r, err := printStorageClass(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2055,8 +2401,8 @@ func printStorageClassList(list *storagev1.StorageClassList) ([]metav1.TableRow,
 	return rows, nil
 }
 
-func printLease(obj *coordinationv1.Lease) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printLease(obj *coordinationv1.Lease, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	var holderIdentity string
 	if obj.Spec.HolderIdentity != nil {
@@ -2066,10 +2412,10 @@ func printLease(obj *coordinationv1.Lease) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printLeaseList(list *coordinationv1.LeaseList) ([]metav1.TableRow, error) {
+func printLeaseList(list *coordinationv1.LeaseList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printLease(&list.Items[i])
+		r, err := printLease(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2119,8 +2465,8 @@ func formatEventSourceComponentInstance(component, instance string) string {
 	return component + ", " + instance
 }
 
-func printControllerRevision(obj *appsv1.ControllerRevision) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printControllerRevision(obj *appsv1.ControllerRevision, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	controllerRef := metav1.GetControllerOf(obj)
 	controllerName := "<none>"
@@ -2138,10 +2484,10 @@ func printControllerRevision(obj *appsv1.ControllerRevision) ([]metav1.TableRow,
 	return []metav1.TableRow{row}, nil
 }
 
-func printControllerRevisionList(list *appsv1.ControllerRevisionList) ([]metav1.TableRow, error) {
+func printControllerRevisionList(list *appsv1.ControllerRevisionList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printControllerRevision(&list.Items[i])
+		r, err := printControllerRevision(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2160,8 +2506,8 @@ func formatResourceName(kind schema.GroupKind, name string, withKind bool) strin
 	return strings.ToLower(kind.String()) + "/" + name
 }
 
-func printResourceQuota(resourceQuota *corev1.ResourceQuota) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printResourceQuota(resourceQuota *corev1.ResourceQuota, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: resourceQuota}}
 
 	resources := make([]corev1.ResourceName, 0, len(resourceQuota.Status.Hard))
 	for resource := range resourceQuota.Status.Hard {
@@ -2190,10 +2536,10 @@ func printResourceQuota(resourceQuota *corev1.ResourceQuota) ([]metav1.TableRow,
 	return []metav1.TableRow{row}, nil
 }
 
-func printResourceQuotaList(list *corev1.ResourceQuotaList) ([]metav1.TableRow, error) {
+func printResourceQuotaList(list *corev1.ResourceQuotaList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printResourceQuota(&list.Items[i])
+		r, err := printResourceQuota(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2202,8 +2548,8 @@ func printResourceQuotaList(list *corev1.ResourceQuotaList) ([]metav1.TableRow,
 	return rows, nil
 }
 
-func printPriorityClass(obj *schedulingv1.PriorityClass) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printPriorityClass(obj *schedulingv1.PriorityClass, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	name := obj.Name
 	value := obj.Value
@@ -2217,10 +2563,10 @@ func printPriorityClass(obj *schedulingv1.PriorityClass) ([]metav1.TableRow, err
 	return []metav1.TableRow{row}, nil
 }
 
-func printPriorityClassList(list *schedulingv1.PriorityClassList) ([]metav1.TableRow, error) {
+func printPriorityClassList(list *schedulingv1.PriorityClassList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printPriorityClass(&list.Items[i])
+		r, err := printPriorityClass(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2229,8 +2575,8 @@ func printPriorityClassList(list *schedulingv1.PriorityClassList) ([]metav1.Tabl
 	return rows, nil
 }
 
-func printRuntimeClass(obj *nodev1.RuntimeClass) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printRuntimeClass(obj *nodev1.RuntimeClass, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	name := obj.Name
 	handler := obj.Handler
@@ -2239,10 +2585,10 @@ func printRuntimeClass(obj *nodev1.RuntimeClass) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printRuntimeClassList(list *nodev1.RuntimeClassList) ([]metav1.TableRow, error) {
+func printRuntimeClassList(list *nodev1.RuntimeClassList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printRuntimeClass(&list.Items[i])
+		r, err := printRuntimeClass(&list.Items[i], options)
 
 		if err != nil {
 			return nil, err
@@ -2252,8 +2598,8 @@ func printRuntimeClassList(list *nodev1.RuntimeClassList) ([]metav1.TableRow, er
 	return rows, nil
 }
 
-func printVolumeAttachment(obj *storagev1.VolumeAttachment) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printVolumeAttachment(obj *storagev1.VolumeAttachment, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	name := obj.Name
 	pvName := ""
@@ -2265,10 +2611,10 @@ func printVolumeAttachment(obj *storagev1.VolumeAttachment) ([]metav1.TableRow,
 	return []metav1.TableRow{row}, nil
 }
 
-func printVolumeAttachmentList(list *storagev1.VolumeAttachmentList) ([]metav1.TableRow, error) {
+func printVolumeAttachmentList(list *storagev1.VolumeAttachmentList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printVolumeAttachment(&list.Items[i])
+		r, err := printVolumeAttachment(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2277,8 +2623,8 @@ func printVolumeAttachmentList(list *storagev1.VolumeAttachmentList) ([]metav1.T
 	return rows, nil
 }
 
-func printFlowSchema(obj *flowcontrolv1.FlowSchema) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printFlowSchema(obj *flowcontrolv1.FlowSchema, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 
 	name := obj.Name
 	plName := obj.Spec.PriorityLevelConfiguration.Name
@@ -2298,7 +2644,7 @@ func printFlowSchema(obj *flowcontrolv1.FlowSchema) ([]metav1.TableRow, error) {
 	return []metav1.TableRow{row}, nil
 }
 
-func printFlowSchemaList(list *flowcontrolv1.FlowSchemaList) ([]metav1.TableRow, error) {
+func printFlowSchemaList(list *flowcontrolv1.FlowSchemaList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	fsSeq := make(FlowSchemaSequence, len(list.Items))
 	for i := range list.Items {
@@ -2306,7 +2652,7 @@ func printFlowSchemaList(list *flowcontrolv1.FlowSchemaList) ([]metav1.TableRow,
 	}
 	sort.Sort(fsSeq)
 	for i := range fsSeq {
-		r, err := printFlowSchema(fsSeq[i])
+		r, err := printFlowSchema(fsSeq[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2315,8 +2661,8 @@ func printFlowSchemaList(list *flowcontrolv1.FlowSchemaList) ([]metav1.TableRow,
 	return rows, nil
 }
 
-func printPriorityLevelConfiguration(obj *flowcontrolv1.PriorityLevelConfiguration) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printPriorityLevelConfiguration(obj *flowcontrolv1.PriorityLevelConfiguration, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	name := obj.Name
 	ncs := interface{}("<none>")
 	queues := interface{}("<none>")
@@ -2335,10 +2681,10 @@ func printPriorityLevelConfiguration(obj *flowcontrolv1.PriorityLevelConfigurati
 	return []metav1.TableRow{row}, nil
 }
 
-func printPriorityLevelConfigurationList(list *flowcontrolv1.PriorityLevelConfigurationList) ([]metav1.TableRow, error) {
+func printPriorityLevelConfigurationList(list *flowcontrolv1.PriorityLevelConfigurationList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printPriorityLevelConfiguration(&list.Items[i])
+		r, err := printPriorityLevelConfiguration(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2347,8 +2693,8 @@ func printPriorityLevelConfigurationList(list *flowcontrolv1.PriorityLevelConfig
 	return rows, nil
 }
 
-func printResourceClaim(obj *resourcev1beta1.ResourceClaim) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printResourceClaim(obj *resourcev1beta1.ResourceClaim, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, resourceClaimState(obj), translateTimestampSince(obj.CreationTimestamp))
 
 	return []metav1.TableRow{row}, nil
@@ -2372,10 +2718,10 @@ func resourceClaimState(obj *resourcev1beta1.ResourceClaim) string {
 	return strings.Join(states, ",")
 }
 
-func printResourceClaimList(list *resourcev1beta1.ResourceClaimList) ([]metav1.TableRow, error) {
+func printResourceClaimList(list *resourcev1beta1.ResourceClaimList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printResourceClaim(&list.Items[i])
+		r, err := printResourceClaim(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
@@ -2384,17 +2730,17 @@ func printResourceClaimList(list *resourcev1beta1.ResourceClaimList) ([]metav1.T
 	return rows, nil
 }
 
-func printResourceSlice(obj *resourcev1beta1.ResourceSlice) ([]metav1.TableRow, error) {
-	row := metav1.TableRow{}
+func printResourceSlice(obj *resourcev1beta1.ResourceSlice, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
 	row.Cells = append(row.Cells, obj.Name, obj.Spec.NodeName, obj.Spec.Driver, obj.Spec.Pool.Name, translateTimestampSince(obj.CreationTimestamp))
 
 	return []metav1.TableRow{row}, nil
 }
 
-func printResourceSliceList(list *resourcev1beta1.ResourceSliceList) ([]metav1.TableRow, error) {
+func printResourceSliceList(list *resourcev1beta1.ResourceSliceList, options GenerateOptions) ([]metav1.TableRow, error) {
 	rows := make([]metav1.TableRow, 0, len(list.Items))
 	for i := range list.Items {
-		r, err := printResourceSlice(&list.Items[i])
+		r, err := printResourceSlice(&list.Items[i], options)
 		if err != nil {
 			return nil, err
 		}
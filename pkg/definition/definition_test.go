@@ -0,0 +1,126 @@
+package definition
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestPodRestarts(t *testing.T) {
+	earlier, later := metav1.NewTime(time.Now().Add(-2*time.Hour)), metav1.NewTime(time.Now().Add(-time.Hour))
+	restarted := func(name string, count int32, finishedAt metav1.Time, state corev1.ContainerState) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:                 name,
+			RestartCount:         count,
+			State:                state,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: finishedAt}},
+		}
+	}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	completed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	waiting := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+
+	tests := []struct {
+		name         string
+		pod          *corev1.Pod
+		wantRestarts int
+		wantLast     metav1.Time
+		wantCell     string
+	}{
+		{
+			name: "no restarts",
+			pod: &corev1.Pod{
+				Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "app", State: running}}},
+			},
+			wantCell: "0",
+		},
+		{
+			name: "containers",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "proxy"}}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+					restarted("app", 2, earlier, running),
+					restarted("proxy", 1, later, running),
+				}},
+			},
+			wantRestarts: 3,
+			wantLast:     later,
+			wantCell:     "3 (60m ago)",
+		},
+		{
+			name: "completed init containers are not counted",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "migrate"}},
+					Containers:     []corev1.Container{{Name: "app"}},
+				},
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{restarted("migrate", 4, later, completed)},
+					ContainerStatuses:     []corev1.ContainerStatus{restarted("app", 1, earlier, running)},
+				},
+			},
+			wantRestarts: 1,
+			wantLast:     earlier,
+			wantCell:     "1 (120m ago)",
+		},
+		{
+			name: "init containers while initializing",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "migrate"}, {Name: "seed"}},
+					Containers:     []corev1.Container{{Name: "app"}},
+				},
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{
+						restarted("migrate", 3, later, waiting),
+						restarted("seed", 5, later, corev1.ContainerState{}),
+					},
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "app"}},
+				},
+			},
+			wantRestarts: 3,
+			wantLast:     later,
+			wantCell:     "3 (60m ago)",
+		},
+		{
+			name: "restartable init containers",
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "sidecar", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)}},
+					Containers:     []corev1.Container{{Name: "app"}},
+				},
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{func() corev1.ContainerStatus {
+						status := restarted("sidecar", 2, later, running)
+						status.Started = ptr.To(true)
+						return status
+					}()},
+					ContainerStatuses: []corev1.ContainerStatus{restarted("app", 1, earlier, running)},
+				},
+			},
+			wantRestarts: 3,
+			wantLast:     later,
+			wantCell:     "3 (60m ago)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restarts, last := PodRestarts(tt.pod)
+			if restarts != tt.wantRestarts || !last.Equal(&tt.wantLast) {
+				t.Fatalf("got restarts %d at %s, want %d at %s", restarts, last, tt.wantRestarts, tt.wantLast)
+			}
+			// the Restarts column of the table is counted the same way.
+			rows, err := printPod(tt.pod, GenerateOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cell := rows[0].Cells[3]; cell != tt.wantCell {
+				t.Fatalf("got Restarts cell %q, want %q", cell, tt.wantCell)
+			}
+		})
+	}
+}
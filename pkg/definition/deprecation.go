@@ -0,0 +1,134 @@
+package definition
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DeprecatedAPI describes a group version of a kind which is deprecated or removed in Kubernetes.
+type DeprecatedAPI struct {
+	GroupVersionKind schema.GroupVersionKind
+	// DeprecatedIn is the minor version of Kubernetes 1.x in which the API is deprecated.
+	DeprecatedIn int
+	// RemovedIn is the minor version of Kubernetes 1.x in which the API is no longer served.
+	RemovedIn int
+	// Replacement is the group version to migrate to, it's empty if there is no replacement.
+	Replacement schema.GroupVersion
+}
+
+func newGVK(group, version, kind string) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: group, Version: version, Kind: kind}
+}
+
+// deprecatedAPIs is the deprecation rules table keyed by the minor version in which the APIs are removed,
+// see https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var deprecatedAPIs = map[int][]DeprecatedAPI{
+	16: {
+		{GroupVersionKind: newGVK("extensions", "v1beta1", "Deployment"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("extensions", "v1beta1", "DaemonSet"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("extensions", "v1beta1", "ReplicaSet"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("extensions", "v1beta1", "NetworkPolicy"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("apps", "v1beta1", "Deployment"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("apps", "v1beta1", "StatefulSet"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("apps", "v1beta2", "Deployment"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("apps", "v1beta2", "StatefulSet"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("apps", "v1beta2", "DaemonSet"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+		{GroupVersionKind: newGVK("apps", "v1beta2", "ReplicaSet"), DeprecatedIn: 9, RemovedIn: 16, Replacement: schema.GroupVersion{Group: "apps", Version: "v1"}},
+	},
+	22: {
+		{GroupVersionKind: newGVK("extensions", "v1beta1", "Ingress"), DeprecatedIn: 14, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("networking.k8s.io", "v1beta1", "Ingress"), DeprecatedIn: 19, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("networking.k8s.io", "v1beta1", "IngressClass"), DeprecatedIn: 19, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("admissionregistration.k8s.io", "v1beta1", "MutatingWebhookConfiguration"), DeprecatedIn: 16, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "admissionregistration.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("admissionregistration.k8s.io", "v1beta1", "ValidatingWebhookConfiguration"), DeprecatedIn: 16, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "admissionregistration.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("apiextensions.k8s.io", "v1beta1", "CustomResourceDefinition"), DeprecatedIn: 16, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "apiextensions.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("apiregistration.k8s.io", "v1beta1", "APIService"), DeprecatedIn: 19, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "apiregistration.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("certificates.k8s.io", "v1beta1", "CertificateSigningRequest"), DeprecatedIn: 19, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "certificates.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("coordination.k8s.io", "v1beta1", "Lease"), DeprecatedIn: 19, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "coordination.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("rbac.authorization.k8s.io", "v1beta1", "Role"), DeprecatedIn: 17, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("rbac.authorization.k8s.io", "v1beta1", "ClusterRole"), DeprecatedIn: 17, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("rbac.authorization.k8s.io", "v1beta1", "RoleBinding"), DeprecatedIn: 17, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("rbac.authorization.k8s.io", "v1beta1", "ClusterRoleBinding"), DeprecatedIn: 17, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("scheduling.k8s.io", "v1beta1", "PriorityClass"), DeprecatedIn: 14, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "scheduling.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("storage.k8s.io", "v1beta1", "CSIDriver"), DeprecatedIn: 19, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("storage.k8s.io", "v1beta1", "CSINode"), DeprecatedIn: 17, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("storage.k8s.io", "v1beta1", "StorageClass"), DeprecatedIn: 6, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("storage.k8s.io", "v1beta1", "VolumeAttachment"), DeprecatedIn: 13, RemovedIn: 22, Replacement: schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}},
+	},
+	25: {
+		{GroupVersionKind: newGVK("batch", "v1beta1", "CronJob"), DeprecatedIn: 21, RemovedIn: 25, Replacement: schema.GroupVersion{Group: "batch", Version: "v1"}},
+		{GroupVersionKind: newGVK("discovery.k8s.io", "v1beta1", "EndpointSlice"), DeprecatedIn: 21, RemovedIn: 25, Replacement: schema.GroupVersion{Group: "discovery.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("autoscaling", "v2beta1", "HorizontalPodAutoscaler"), DeprecatedIn: 22, RemovedIn: 25, Replacement: schema.GroupVersion{Group: "autoscaling", Version: "v2"}},
+		{GroupVersionKind: newGVK("policy", "v1beta1", "PodDisruptionBudget"), DeprecatedIn: 21, RemovedIn: 25, Replacement: schema.GroupVersion{Group: "policy", Version: "v1"}},
+		{GroupVersionKind: newGVK("policy", "v1beta1", "PodSecurityPolicy"), DeprecatedIn: 21, RemovedIn: 25},
+		{GroupVersionKind: newGVK("node.k8s.io", "v1beta1", "RuntimeClass"), DeprecatedIn: 20, RemovedIn: 25, Replacement: schema.GroupVersion{Group: "node.k8s.io", Version: "v1"}},
+	},
+	26: {
+		{GroupVersionKind: newGVK("autoscaling", "v2beta2", "HorizontalPodAutoscaler"), DeprecatedIn: 23, RemovedIn: 26, Replacement: schema.GroupVersion{Group: "autoscaling", Version: "v2"}},
+		{GroupVersionKind: newGVK("flowcontrol.apiserver.k8s.io", "v1beta1", "FlowSchema"), DeprecatedIn: 23, RemovedIn: 26, Replacement: schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("flowcontrol.apiserver.k8s.io", "v1beta1", "PriorityLevelConfiguration"), DeprecatedIn: 23, RemovedIn: 26, Replacement: schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1"}},
+	},
+	27: {
+		{GroupVersionKind: newGVK("storage.k8s.io", "v1beta1", "CSIStorageCapacity"), DeprecatedIn: 24, RemovedIn: 27, Replacement: schema.GroupVersion{Group: "storage.k8s.io", Version: "v1"}},
+	},
+	29: {
+		{GroupVersionKind: newGVK("flowcontrol.apiserver.k8s.io", "v1beta2", "FlowSchema"), DeprecatedIn: 26, RemovedIn: 29, Replacement: schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("flowcontrol.apiserver.k8s.io", "v1beta2", "PriorityLevelConfiguration"), DeprecatedIn: 26, RemovedIn: 29, Replacement: schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1"}},
+	},
+	32: {
+		{GroupVersionKind: newGVK("flowcontrol.apiserver.k8s.io", "v1beta3", "FlowSchema"), DeprecatedIn: 29, RemovedIn: 32, Replacement: schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1"}},
+		{GroupVersionKind: newGVK("flowcontrol.apiserver.k8s.io", "v1beta3", "PriorityLevelConfiguration"), DeprecatedIn: 29, RemovedIn: 32, Replacement: schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1"}},
+	},
+}
+
+// DeprecatedAPIs returns the rules of the APIs which are deprecated as of the minor version of Kubernetes 1.x,
+// ordered by the removal version.
+func DeprecatedAPIs(minor int) []DeprecatedAPI {
+	removals := make([]int, 0, len(deprecatedAPIs))
+	for removedIn := range deprecatedAPIs {
+		removals = append(removals, removedIn)
+	}
+	sort.Ints(removals)
+
+	apis := make([]DeprecatedAPI, 0)
+	for _, removedIn := range removals {
+		for _, api := range deprecatedAPIs[removedIn] {
+			if api.DeprecatedIn <= minor {
+				apis = append(apis, api)
+			}
+		}
+	}
+	return apis
+}
+
+// LookupDeprecatedAPI returns the deprecation rule of the group version kind, if any.
+func LookupDeprecatedAPI(gvk schema.GroupVersionKind) (DeprecatedAPI, bool) {
+	for _, apis := range deprecatedAPIs {
+		for _, api := range apis {
+			if api.GroupVersionKind == gvk {
+				return api, true
+			}
+		}
+	}
+	return DeprecatedAPI{}, false
+}
+
+// ParseMinorVersion parses the minor version from the Kubernetes version like v1.32.1, 1.32 or the
+// minor field of the version info like 32+.
+func ParseMinorVersion(version string) (int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	parts := strings.Split(version, ".")
+	minor := parts[0]
+	if len(parts) > 1 {
+		if parts[0] != "1" {
+			return 0, fmt.Errorf("unsupported major version in %q", version)
+		}
+		minor = parts[1]
+	}
+	minor = strings.TrimRightFunc(minor, func(r rune) bool { return r < '0' || r > '9' })
+	return strconv.Atoi(minor)
+}
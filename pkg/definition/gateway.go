@@ -0,0 +1,43 @@
+package definition
+
+// GatewayGroup is the group of the Gateway API resources.
+const GatewayGroup = "gateway.networking.k8s.io"
+
+// gatewayColumns are the columns of the Gateway API kinds, the same as the additional printer columns of their CRDs.
+// The kinds are printed from the unstructured objects to avoid depending on the gateway-api module.
+var gatewayColumns = ColumnsConfig{
+	Kinds: []KindColumns{
+		{
+			Group: GatewayGroup,
+			Kind:  "GatewayClass",
+			Columns: []CustomColumn{
+				{Name: "Controller", JSONPath: ".spec.controllerName"},
+				{Name: "Accepted", JSONPath: `.status.conditions[?(@.type=="Accepted")].status`},
+				{Name: "Description", JSONPath: ".spec.description", Priority: 1},
+			},
+		},
+		{
+			Group: GatewayGroup,
+			Kind:  "Gateway",
+			Columns: []CustomColumn{
+				{Name: "Class", JSONPath: ".spec.gatewayClassName"},
+				{Name: "Address", JSONPath: ".status.addresses[*].value"},
+				{Name: "Programmed", JSONPath: `.status.conditions[?(@.type=="Programmed")].status`},
+			},
+		},
+		{
+			Group:   GatewayGroup,
+			Kind:    "HTTPRoute",
+			Columns: []CustomColumn{{Name: "Hostnames", JSONPath: ".spec.hostnames"}},
+		},
+		{
+			Group:   GatewayGroup,
+			Kind:    "GRPCRoute",
+			Columns: []CustomColumn{{Name: "Hostnames", JSONPath: ".spec.hostnames"}},
+		},
+		{
+			Group: GatewayGroup,
+			Kind:  "ReferenceGrant",
+		},
+	},
+}
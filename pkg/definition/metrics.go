@@ -0,0 +1,192 @@
+package definition
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	custommetricsv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	externalmetricsv1beta1 "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+const (
+	// MetricValueKind is the kind of the values of the custom metrics API, the metrics are not listed by the kind
+	// but by the described resource and the metric name, e.g. pods/http_requests.
+	MetricValueKind = "MetricValue"
+	// ExternalMetricValueKind is the kind of the values of the external metrics API, listed by the metric name.
+	ExternalMetricValueKind = "ExternalMetricValue"
+)
+
+// IsMetricValueKind returns true if the kind is served by the custom or external metrics API.
+func IsMetricValueKind(kind string) bool {
+	return kind == MetricValueKind || kind == ExternalMetricValueKind
+}
+
+// NewMetricValueList returns a new list object of the custom or external metric values.
+func NewMetricValueList(kind string) (runtime.Object, string) {
+	if kind == ExternalMetricValueKind {
+		return &externalmetricsv1beta1.ExternalMetricValueList{}, externalmetricsv1beta1.SchemeGroupVersion.Group
+	}
+	return &custommetricsv1beta2.MetricValueList{}, custommetricsv1beta2.SchemeGroupVersion.Group
+}
+
+func addMetricsHandlers(h *HumanReadableGenerator) {
+	podMetricsColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "CPU(cores)", Type: "string", Description: "The CPU usage of the containers in this pod."},
+		{Name: "Memory(bytes)", Type: "string", Description: "The memory usage of the containers in this pod."},
+		{Name: "Containers", Type: "string", Priority: 1, Description: "The usage of each container in this pod."},
+		{Name: "Window", Type: "string", Description: "The interval the metrics were collected over, ending at the timestamp."},
+		{Name: "Timestamp", Type: "string", Description: "The time since the metrics were collected."},
+	}
+	_ = h.TableHandler(podMetricsColumnDefinitions, printPodMetricsList)
+
+	nodeMetricsColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "CPU(cores)", Type: "string", Description: "The CPU usage of this node."},
+		{Name: "Memory(bytes)", Type: "string", Description: "The memory usage of this node."},
+		{Name: "Window", Type: "string", Description: "The interval the metrics were collected over, ending at the timestamp."},
+		{Name: "Timestamp", Type: "string", Description: "The time since the metrics were collected."},
+	}
+	_ = h.TableHandler(nodeMetricsColumnDefinitions, printNodeMetricsList)
+
+	metricValueColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Object", Type: "string", Description: "The object described by the metric."},
+		{Name: "Metric", Type: "string", Description: "The name of the metric."},
+		{Name: "Value", Type: "string", Description: "The value of the metric for this object."},
+		{Name: "Selector", Type: "string", Priority: 1, Description: "The label selector of the metric."},
+		{Name: "Window", Type: "string", Description: "The window the metric was calculated over."},
+		{Name: "Timestamp", Type: "string", Description: "The time since the metric was collected."},
+	}
+	_ = h.TableHandler(metricValueColumnDefinitions, printMetricValueList)
+
+	externalMetricValueColumnDefinitions := []metav1.TableColumnDefinition{
+		{Name: "Metric", Type: "string", Description: "The name of the metric."},
+		{Name: "Labels", Type: "string", Description: "The labels of the metric series."},
+		{Name: "Value", Type: "string", Description: "The value of the metric series."},
+		{Name: "Window", Type: "string", Description: "The window the metric was calculated over."},
+		{Name: "Timestamp", Type: "string", Description: "The time since the metric was collected."},
+	}
+	_ = h.TableHandler(externalMetricValueColumnDefinitions, printExternalMetricValueList)
+}
+
+func printPodMetrics(obj *metricsv1beta1.PodMetrics, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	usage := corev1.ResourceList{}
+	containers := make([]string, 0, len(obj.Containers))
+	for _, c := range obj.Containers {
+		for name, q := range c.Usage {
+			total := usage[name]
+			total.Add(q)
+			usage[name] = total
+		}
+		containers = append(containers, fmt.Sprintf("%s(%dm,%dMi)", c.Name, c.Usage.Cpu().MilliValue(), c.Usage.Memory().Value()/(1024*1024)))
+	}
+	row.Cells = append(row.Cells, obj.Name, formatCPU(usage), formatMemory(usage), strings.Join(containers, ","),
+		obj.Window.Duration.String(), translateTimestampSince(obj.Timestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+func printPodMetricsList(list *metricsv1beta1.PodMetricsList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printPodMetrics(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printNodeMetrics(obj *metricsv1beta1.NodeMetrics, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	row.Cells = append(row.Cells, obj.Name, formatCPU(obj.Usage), formatMemory(obj.Usage), obj.Window.Duration.String(), translateTimestampSince(obj.Timestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+func printNodeMetricsList(list *metricsv1beta1.NodeMetricsList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printNodeMetrics(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printMetricValue(obj *custommetricsv1beta2.MetricValue, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	object := obj.DescribedObject.Kind + "/" + obj.DescribedObject.Name
+	if len(obj.DescribedObject.Namespace) > 0 {
+		object = obj.DescribedObject.Kind + "/" + obj.DescribedObject.Namespace + "/" + obj.DescribedObject.Name
+	}
+	selector := "<none>"
+	if obj.Metric.Selector != nil {
+		selector = metav1.FormatLabelSelector(obj.Metric.Selector)
+	}
+	row.Cells = append(row.Cells, object, obj.Metric.Name, obj.Value.String(), selector, formatWindowSeconds(obj.WindowSeconds), translateTimestampSince(obj.Timestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+func printMetricValueList(list *custommetricsv1beta2.MetricValueList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printMetricValue(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+func printExternalMetricValue(obj *externalmetricsv1beta1.ExternalMetricValue, options GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{Object: runtime.RawExtension{Object: obj}}
+	labels := make([]string, 0, len(obj.MetricLabels))
+	for key, value := range obj.MetricLabels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	formatted := "<none>"
+	if len(labels) > 0 {
+		formatted = strings.Join(labels, ",")
+	}
+	row.Cells = append(row.Cells, obj.MetricName, formatted, obj.Value.String(), formatWindowSeconds(obj.WindowSeconds), translateTimestampSince(obj.Timestamp))
+	return []metav1.TableRow{row}, nil
+}
+
+func printExternalMetricValueList(list *externalmetricsv1beta1.ExternalMetricValueList, options GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printExternalMetricValue(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+// formatCPU formats the CPU usage in millicores like kubectl top.
+func formatCPU(usage corev1.ResourceList) string {
+	return fmt.Sprintf("%dm", usage.Cpu().MilliValue())
+}
+
+// formatMemory formats the memory usage in mebibytes like kubectl top.
+func formatMemory(usage corev1.ResourceList) string {
+	return fmt.Sprintf("%dMi", usage.Memory().Value()/(1024*1024))
+}
+
+func formatWindowSeconds(seconds *int64) string {
+	if seconds == nil {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%ds", *seconds)
+}
@@ -0,0 +1,104 @@
+package definition
+
+import (
+	"fmt"
+	"strings"
+)
+
+// kindAliases maps the kubectl short names, the singular and the plural resource names to the kinds.
+var kindAliases = map[string]string{
+	"po": "Pod", "pod": "Pod", "pods": "Pod",
+	"svc": "Service", "service": "Service", "services": "Service",
+	"ep": "Endpoints", "endpoints": "Endpoints",
+	"no": "Node", "node": "Node", "nodes": "Node",
+	"ns": "Namespace", "namespace": "Namespace", "namespaces": "Namespace",
+	"cm": "ConfigMap", "configmap": "ConfigMap", "configmaps": "ConfigMap",
+	"secret": "Secret", "secrets": "Secret",
+	"sa": "ServiceAccount", "serviceaccount": "ServiceAccount", "serviceaccounts": "ServiceAccount",
+	"pv": "PersistentVolume", "persistentvolume": "PersistentVolume", "persistentvolumes": "PersistentVolume",
+	"pvc": "PersistentVolumeClaim", "persistentvolumeclaim": "PersistentVolumeClaim", "persistentvolumeclaims": "PersistentVolumeClaim",
+	"ev": "Event", "event": "Event", "events": "Event",
+	"limits": "LimitRange", "limitrange": "LimitRange", "limitranges": "LimitRange",
+	"quota": "ResourceQuota", "resourcequota": "ResourceQuota", "resourcequotas": "ResourceQuota",
+	"rc": "ReplicationController", "replicationcontroller": "ReplicationController", "replicationcontrollers": "ReplicationController",
+	"podtemplate": "PodTemplate", "podtemplates": "PodTemplate",
+	"deploy": "Deployment", "deployment": "Deployment", "deployments": "Deployment",
+	"rs": "ReplicaSet", "replicaset": "ReplicaSet", "replicasets": "ReplicaSet",
+	"sts": "StatefulSet", "statefulset": "StatefulSet", "statefulsets": "StatefulSet",
+	"ds": "DaemonSet", "daemonset": "DaemonSet", "daemonsets": "DaemonSet",
+	"controllerrevision": "ControllerRevision", "controllerrevisions": "ControllerRevision",
+	"job": "Job", "jobs": "Job",
+	"cj": "CronJob", "cronjob": "CronJob", "cronjobs": "CronJob",
+	"hpa": "HorizontalPodAutoscaler", "horizontalpodautoscaler": "HorizontalPodAutoscaler", "horizontalpodautoscalers": "HorizontalPodAutoscaler",
+	"pdb": "PodDisruptionBudget", "poddisruptionbudget": "PodDisruptionBudget", "poddisruptionbudgets": "PodDisruptionBudget",
+	"ing": "Ingress", "ingress": "Ingress", "ingresses": "Ingress",
+	"ingressclass": "IngressClass", "ingressclasses": "IngressClass",
+	"netpol": "NetworkPolicy", "networkpolicy": "NetworkPolicy", "networkpolicies": "NetworkPolicy",
+	"endpointslice": "EndpointSlice", "endpointslices": "EndpointSlice",
+	"sc": "StorageClass", "storageclass": "StorageClass", "storageclasses": "StorageClass",
+	"csinode": "CSINode", "csinodes": "CSINode",
+	"csidriver": "CSIDriver", "csidrivers": "CSIDriver",
+	"volumeattachment": "VolumeAttachment", "volumeattachments": "VolumeAttachment",
+	"pc": "PriorityClass", "priorityclass": "PriorityClass", "priorityclasses": "PriorityClass",
+	"runtimeclass": "RuntimeClass", "runtimeclasses": "RuntimeClass",
+	"lease": "Lease", "leases": "Lease",
+	"csr": "CertificateSigningRequest", "certificatesigningrequest": "CertificateSigningRequest", "certificatesigningrequests": "CertificateSigningRequest",
+	"role": "Role", "roles": "Role",
+	"clusterrole": "ClusterRole", "clusterroles": "ClusterRole",
+	"rolebinding": "RoleBinding", "rolebindings": "RoleBinding",
+	"clusterrolebinding": "ClusterRoleBinding", "clusterrolebindings": "ClusterRoleBinding",
+	"crd": "CustomResourceDefinition", "crds": "CustomResourceDefinition", "customresourcedefinition": "CustomResourceDefinition", "customresourcedefinitions": "CustomResourceDefinition",
+	"apiservice": "APIService", "apiservices": "APIService",
+	"mutatingwebhookconfiguration": "MutatingWebhookConfiguration", "mutatingwebhookconfigurations": "MutatingWebhookConfiguration",
+	"validatingwebhookconfiguration": "ValidatingWebhookConfiguration", "validatingwebhookconfigurations": "ValidatingWebhookConfiguration",
+	"validatingadmissionpolicy": "ValidatingAdmissionPolicy", "validatingadmissionpolicies": "ValidatingAdmissionPolicy",
+	"validatingadmissionpolicybinding": "ValidatingAdmissionPolicyBinding", "validatingadmissionpolicybindings": "ValidatingAdmissionPolicyBinding",
+	"gc": "GatewayClass", "gatewayclass": "GatewayClass", "gatewayclasses": "GatewayClass",
+	"gtw": "Gateway", "gateway": "Gateway", "gateways": "Gateway",
+	"httproute": "HTTPRoute", "httproutes": "HTTPRoute",
+	"grpcroute": "GRPCRoute", "grpcroutes": "GRPCRoute",
+	"refgrant": "ReferenceGrant", "referencegrant": "ReferenceGrant", "referencegrants": "ReferenceGrant",
+	"vs": "VolumeSnapshot", "volumesnapshot": "VolumeSnapshot", "volumesnapshots": "VolumeSnapshot",
+	"vsclass": "VolumeSnapshotClass", "vsclasses": "VolumeSnapshotClass", "volumesnapshotclass": "VolumeSnapshotClass", "volumesnapshotclasses": "VolumeSnapshotClass",
+	"vsc": "VolumeSnapshotContent", "vscs": "VolumeSnapshotContent", "volumesnapshotcontent": "VolumeSnapshotContent", "volumesnapshotcontents": "VolumeSnapshotContent",
+	"podmetrics": "PodMetrics", "nodemetrics": "NodeMetrics",
+	"metricvalue": "MetricValue", "metricvalues": "MetricValue",
+	"externalmetricvalue": "ExternalMetricValue", "externalmetricvalues": "ExternalMetricValue",
+	"flowschema": "FlowSchema", "flowschemas": "FlowSchema",
+	"prioritylevelconfiguration": "PriorityLevelConfiguration", "prioritylevelconfigurations": "PriorityLevelConfiguration",
+}
+
+// ResolveKind resolves the kubectl style resource name to the kind, e.g. deploy, deployment, deployments and
+// deployments.apps are resolved to Deployment. The unknown names are returned as is, e.g. the kinds of the CRDs.
+func ResolveKind(name string) string {
+	resource, _, _ := strings.Cut(name, ".")
+	if kind, ok := kindAliases[strings.ToLower(resource)]; ok {
+		return kind
+	}
+	return name
+}
+
+// ParseReference parses the kubectl style `kind/name` reference, e.g. deploy/nginx, po/foo-123 and svc/bar, the
+// kind is resolved by ResolveKind.
+func ParseReference(ref string) (string, string, error) {
+	kind, name, found := strings.Cut(ref, "/")
+	if !found || len(kind) == 0 || len(name) == 0 || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid reference %q, must be in the form of kind/name", ref)
+	}
+	return ResolveKind(kind), name, nil
+}
+
+// ParseKindName returns the kind and name of the object, the name may be a `kind/name` reference which takes
+// precedence over the kind.
+func ParseKindName(kind, name string) (string, string, error) {
+	if strings.Contains(name, "/") {
+		return ParseReference(name)
+	}
+	if len(kind) == 0 {
+		return "", "", fmt.Errorf("kind is required unless the name is in the form of kind/name, got %q", name)
+	}
+	if len(name) == 0 {
+		return "", "", fmt.Errorf("the name of %s is required", kind)
+	}
+	return ResolveKind(kind), name, nil
+}
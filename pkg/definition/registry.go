@@ -0,0 +1,98 @@
+package definition
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// KindRegistry holds the list objects of the kinds which have table handlers, keyed by the GroupVersionKind.
+// A kind may be registered with multiple group versions, e.g. flowcontrol v1 and v1beta3, the earlier registered
+// group version is preferred when negotiating with the cluster.
+type KindRegistry struct {
+	mu       sync.RWMutex
+	kinds    map[schema.GroupVersionKind]reflect.Type
+	versions map[string][]schema.GroupVersionKind
+}
+
+// NewKindRegistry creates an empty KindRegistry.
+func NewKindRegistry() *KindRegistry {
+	return &KindRegistry{
+		kinds:    make(map[schema.GroupVersionKind]reflect.Type),
+		versions: make(map[string][]schema.GroupVersionKind),
+	}
+}
+
+// Register registers the list object for the GroupVersionKind. The list object of an older group version may be
+// the one of the newer group version when their schemas are compatible, the objects are decoded leniently.
+func (r *KindRegistry) Register(gvk schema.GroupVersionKind, list runtime.Object) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.kinds[gvk]; !ok {
+		r.versions[gvk.Kind] = append(r.versions[gvk.Kind], gvk)
+	}
+	r.kinds[gvk] = reflect.TypeOf(list).Elem()
+}
+
+// Lookup returns a new list object registered for the GroupVersionKind.
+func (r *KindRegistry) Lookup(gvk schema.GroupVersionKind) (runtime.Object, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.kinds[gvk]
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(t).Interface().(runtime.Object), true
+}
+
+// ItemKind returns the preferred GroupVersionKind whose registered list has the items of the type.
+func (r *KindRegistry) ItemKind(t reflect.Type) (schema.GroupVersionKind, bool) {
+	if t.Kind() != reflect.Pointer {
+		return schema.GroupVersionKind{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, versions := range r.versions {
+		for _, gvk := range versions {
+			items, ok := r.kinds[gvk].FieldByName("Items")
+			if ok && items.Type.Kind() == reflect.Slice && items.Type.Elem() == t.Elem() {
+				return gvk, true
+			}
+		}
+	}
+	return schema.GroupVersionKind{}, false
+}
+
+// Versions returns the registered GroupVersionKinds of the kind in the preferred order.
+func (r *KindRegistry) Versions(kind string) []schema.GroupVersionKind {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]schema.GroupVersionKind(nil), r.versions[kind]...)
+}
+
+// Negotiate consults the discovery to choose the best registered group version of the kind served by the cluster.
+// It returns false if none of the registered group versions is served.
+func (r *KindRegistry) Negotiate(discoveryClient discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, runtime.Object, bool) {
+	for _, gvk := range r.Versions(kind) {
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+		if err != nil {
+			continue
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Kind != kind || strings.Contains(resource.Name, "/") {
+				continue
+			}
+			obj, _ := r.Lookup(gvk)
+			return gvk.GroupVersion().WithResource(resource.Name), obj, true
+		}
+	}
+	return schema.GroupVersionResource{}, nil, false
+}
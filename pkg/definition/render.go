@@ -0,0 +1,154 @@
+package definition
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	// OutputJSON renders the table as the metav1.Table JSON.
+	OutputJSON = "json"
+	// OutputMarkdown renders the table as a GitHub-flavored Markdown table.
+	OutputMarkdown = "markdown"
+	// OutputCSV renders the table as CSV with a header row.
+	OutputCSV = "csv"
+	// OutputText renders the table as the aligned columns printed by kubectl get.
+	OutputText = "text"
+	// OutputYAML renders the object as YAML.
+	OutputYAML = "yaml"
+	// OutputTable renders the metrics as the table JSON, with the quantities in numbers.
+	OutputTable = "table"
+)
+
+// TableOutputs are the output formats of the tables.
+var TableOutputs = []string{OutputJSON, OutputMarkdown, OutputCSV, OutputText}
+
+// RenderTable renders the table in the text output format, either markdown, csv or text.
+func RenderTable(table *metav1.Table, output string) (string, error) {
+	switch output {
+	case OutputMarkdown:
+		return RenderMarkdown(table), nil
+	case OutputCSV:
+		return RenderCSV(table)
+	case OutputText:
+		return RenderText(table)
+	}
+	return "", fmt.Errorf("unsupported output %q, must be one of json, markdown, csv or text", output)
+}
+
+// RenderMarkdown renders the table as a GitHub-flavored Markdown table, the pipes and the line breaks of the cells
+// are escaped so that each row stays on a line.
+func RenderMarkdown(table *metav1.Table) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for _, column := range table.ColumnDefinitions {
+		b.WriteString(" " + markdownCell(column.Name) + " |")
+	}
+	b.WriteString("\n|")
+	for _, column := range table.ColumnDefinitions {
+		if column.Type == "integer" || column.Type == "number" {
+			b.WriteString(" ---: |")
+		} else {
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+	for _, row := range table.Rows {
+		b.WriteString("|")
+		for _, cell := range row.Cells {
+			b.WriteString(" " + markdownCell(formatCell(cell)) + " |")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// RenderCSV renders the table as CSV, the header row is omitted if the table has no column definitions.
+func RenderCSV(table *metav1.Table) (string, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if len(table.ColumnDefinitions) > 0 {
+		header := make([]string, 0, len(table.ColumnDefinitions))
+		for _, column := range table.ColumnDefinitions {
+			header = append(header, column.Name)
+		}
+		if err := w.Write(header); err != nil {
+			return "", err
+		}
+	}
+	for _, row := range table.Rows {
+		record := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			record = append(record, formatCell(cell))
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RenderText renders the table as the column-aligned plain text like kubectl get, the header row of the upper-cased
+// column names is omitted if the table has no column definitions.
+func RenderText(table *metav1.Table) (string, error) {
+	var b bytes.Buffer
+	// the same tab writer settings as kubectl.
+	w := tabwriter.NewWriter(&b, 6, 4, 3, ' ', 0)
+	if len(table.ColumnDefinitions) > 0 {
+		header := make([]string, 0, len(table.ColumnDefinitions))
+		for _, column := range table.ColumnDefinitions {
+			header = append(header, textCell(strings.ToUpper(column.Name)))
+		}
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	for _, row := range table.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			cells = append(cells, textCell(formatCell(cell)))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatCell formats the cell like kubectl prints it, the durations are printed as the human-readable ages.
+func formatCell(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Duration:
+		return duration.HumanDuration(v)
+	case metav1.Time:
+		return translateTimestampSince(v)
+	}
+	return fmt.Sprint(cell)
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func markdownCell(value string) string {
+	return markdownEscaper.Replace(value)
+}
+
+// textEscaper replaces the tabs and the line breaks which would break the alignment of the columns.
+var textEscaper = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+func textCell(value string) string {
+	return textEscaper.Replace(value)
+}
@@ -0,0 +1,47 @@
+package definition
+
+// SnapshotGroup is the group of the CSI volume snapshot resources.
+const SnapshotGroup = "snapshot.storage.k8s.io"
+
+// snapshotColumns are the columns of the volume snapshot kinds, the same as the additional printer columns of their
+// CRDs. The kinds are printed from the unstructured objects to avoid depending on the external-snapshotter module.
+var snapshotColumns = ColumnsConfig{
+	Kinds: []KindColumns{
+		{
+			Group: SnapshotGroup,
+			Kind:  "VolumeSnapshot",
+			Columns: []CustomColumn{
+				{Name: "ReadyToUse", JSONPath: ".status.readyToUse"},
+				{Name: "SourcePVC", JSONPath: ".spec.source.persistentVolumeClaimName"},
+				{Name: "SourceSnapshotContent", JSONPath: ".spec.source.volumeSnapshotContentName", Priority: 1},
+				{Name: "RestoreSize", JSONPath: ".status.restoreSize"},
+				{Name: "SnapshotClass", JSONPath: ".spec.volumeSnapshotClassName"},
+				{Name: "SnapshotContent", JSONPath: ".status.boundVolumeSnapshotContentName"},
+				{Name: "CreationTime", JSONPath: ".status.creationTime", Priority: 1},
+				{Name: "Error", JSONPath: ".status.error.message", Priority: 1},
+			},
+		},
+		{
+			Group: SnapshotGroup,
+			Kind:  "VolumeSnapshotClass",
+			Columns: []CustomColumn{
+				{Name: "Driver", JSONPath: ".driver"},
+				{Name: "DeletionPolicy", JSONPath: ".deletionPolicy"},
+				{Name: "Default", JSONPath: `.metadata.annotations.snapshot\.storage\.kubernetes\.io/is-default-class`, Priority: 1},
+			},
+		},
+		{
+			Group: SnapshotGroup,
+			Kind:  "VolumeSnapshotContent",
+			Columns: []CustomColumn{
+				{Name: "ReadyToUse", JSONPath: ".status.readyToUse"},
+				{Name: "RestoreSize", JSONPath: ".status.restoreSize"},
+				{Name: "DeletionPolicy", JSONPath: ".spec.deletionPolicy"},
+				{Name: "Driver", JSONPath: ".spec.driver"},
+				{Name: "VolumeSnapshotClass", JSONPath: ".spec.volumeSnapshotClassName"},
+				{Name: "VolumeSnapshot", JSONPath: ".spec.volumeSnapshotRef.name"},
+				{Name: "VolumeSnapshotNamespace", JSONPath: ".spec.volumeSnapshotRef.namespace"},
+			},
+		},
+	},
+}
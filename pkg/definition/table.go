@@ -3,27 +3,33 @@ package definition
 import (
 	"fmt"
 	"reflect"
+	"sort"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 )
 
 // GenerateOptions encapsulates attributes for table generation.
 type GenerateOptions struct {
+	// NoHeaders omits the column definitions of the table.
 	NoHeaders bool
-	Wide      bool
+	// Wide includes the columns with a non-zero priority, like `kubectl get -o wide`.
+	Wide bool
 }
 
 // TableGenerator - an interface for generating metav1.Table provided a runtime.Object
 type TableGenerator interface {
-	GenerateTable(obj runtime.Object) (*metav1.Table, error)
+	GenerateTable(obj runtime.Object, options GenerateOptions) (*metav1.Table, error)
 }
 
 // PrintHandler - interface to handle printing provided an array of metav1.TableColumnDefinition
 type PrintHandler interface {
 	TableHandler(columns []metav1.TableColumnDefinition, printFunc any) error
+	UnstructuredTableHandler(gk schema.GroupKind, columns []metav1.TableColumnDefinition, printFunc UnstructuredPrintFunc) error
 }
 
 type handlerEntry struct {
@@ -35,7 +41,8 @@ type handlerEntry struct {
 // a table for a specific resource. The table is printed with a TablePrinter using
 // PrintObj().
 type HumanReadableGenerator struct {
-	handlerMap map[reflect.Type]*handlerEntry
+	handlerMap             map[reflect.Type]*handlerEntry
+	unstructuredHandlerMap map[schema.GroupKind]*unstructuredHandlerEntry
 }
 
 var _ TableGenerator = &HumanReadableGenerator{}
@@ -44,7 +51,8 @@ var _ PrintHandler = &HumanReadableGenerator{}
 // NewTableGenerator creates a HumanReadableGenerator suitable for calling GenerateTable().
 func NewTableGenerator() *HumanReadableGenerator {
 	return &HumanReadableGenerator{
-		handlerMap: make(map[reflect.Type]*handlerEntry),
+		handlerMap:             make(map[reflect.Type]*handlerEntry),
+		unstructuredHandlerMap: make(map[schema.GroupKind]*unstructuredHandlerEntry),
 	}
 }
 
@@ -59,34 +67,26 @@ func (h *HumanReadableGenerator) With(fns ...func(PrintHandler)) *HumanReadableG
 // GenerateTable returns a table for the provided object, using the printer registered for that type. It returns
 // a table that includes all of the information requested by options, but will not remove rows or columns. The
 // caller is responsible for applying rules related to filtering rows or columns.
-func (h *HumanReadableGenerator) GenerateTable(obj runtime.Object) (*metav1.Table, error) {
+func (h *HumanReadableGenerator) GenerateTable(obj runtime.Object, options GenerateOptions) (*metav1.Table, error) {
 	t := reflect.TypeOf(obj)
 	handler, ok := h.handlerMap[t]
 	if !ok {
 		return nil, fmt.Errorf("no table handler registered for this type %v", t)
 	}
 
-	args := []reflect.Value{reflect.ValueOf(obj)}
+	args := []reflect.Value{reflect.ValueOf(obj), reflect.ValueOf(options)}
 	results := handler.printFunc.Call(args)
 	if !results[1].IsNil() {
 		return nil, results[1].Interface().(error)
 	}
 
-	columns := make([]metav1.TableColumnDefinition, 0, len(handler.columnDefinitions))
-	for i := range handler.columnDefinitions {
-		if handler.columnDefinitions[i].Priority != 0 {
-			continue
-		}
-		columns = append(columns, handler.columnDefinitions[i])
-	}
-
 	table := &metav1.Table{
 		ListMeta: metav1.ListMeta{
 			ResourceVersion: "",
 		},
-		ColumnDefinitions: columns,
-		Rows:              results[0].Interface().([]metav1.TableRow),
 	}
+	table.ColumnDefinitions, table.Rows = applyOptions(handler.columnDefinitions, results[0].Interface().([]metav1.TableRow), options)
+	setObjectReferences(table.Rows)
 	if m, err := meta.ListAccessor(obj); err == nil {
 		table.ResourceVersion = m.GetResourceVersion()
 		table.Continue = m.GetContinue()
@@ -99,6 +99,76 @@ func (h *HumanReadableGenerator) GenerateTable(obj runtime.Object) (*metav1.Tabl
 	return table, nil
 }
 
+// applyOptions returns the columns and the rows of the table generated with the options, the cells of the wide
+// columns are dropped from the rows unless the wide columns are requested.
+func applyOptions(columnDefinitions []metav1.TableColumnDefinition, rows []metav1.TableRow, options GenerateOptions) ([]metav1.TableColumnDefinition, []metav1.TableRow) {
+	columns := columnDefinitions
+	if !options.Wide {
+		columns = visibleColumns(columnDefinitions)
+		for i := range rows {
+			if len(rows[i].Cells) != len(columnDefinitions) {
+				continue
+			}
+			cells := make([]any, 0, len(columns))
+			for j := range columnDefinitions {
+				if columnDefinitions[j].Priority == 0 {
+					cells = append(cells, rows[i].Cells[j])
+				}
+			}
+			rows[i].Cells = cells
+		}
+	}
+	if options.NoHeaders {
+		columns = nil
+	}
+	return columns, rows
+}
+
+// setObjectReferences replaces the objects of the rows with their references, i.e. the apiVersion, kind, name,
+// namespace and uid, so that the rows can be acted on without the whole objects in the table.
+func setObjectReferences(rows []metav1.TableRow) {
+	for i := range rows {
+		if rows[i].Object.Object == nil {
+			continue
+		}
+		if ref := ObjectReference(rows[i].Object.Object); ref != nil {
+			rows[i].Object = runtime.RawExtension{Object: ref}
+		} else {
+			rows[i].Object = runtime.RawExtension{}
+		}
+	}
+}
+
+// ObjectReference returns the metadata identifying the object, nil if the object has no metadata. The apiVersion
+// and kind of the typed objects without them are the ones registered for their lists.
+func ObjectReference(obj runtime.Object) *metav1.PartialObjectMetadata {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		gvk, _ = registry.ItemKind(reflect.TypeOf(obj))
+	}
+	ref := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: m.GetName(), Namespace: m.GetNamespace(), UID: m.GetUID()},
+	}
+	ref.SetGroupVersionKind(gvk)
+	return ref
+}
+
+// visibleColumns returns the columns printed by default, the columns with a non-zero priority are hidden.
+func visibleColumns(columnDefinitions []metav1.TableColumnDefinition) []metav1.TableColumnDefinition {
+	columns := make([]metav1.TableColumnDefinition, 0, len(columnDefinitions))
+	for i := range columnDefinitions {
+		if columnDefinitions[i].Priority != 0 {
+			continue
+		}
+		columns = append(columns, columnDefinitions[i])
+	}
+	return columns
+}
+
 // TableHandler adds a print handler with a given set of columns to HumanReadableGenerator instance.
 // See ValidateRowPrintHandlerFunc for required method signature.
 func (h *HumanReadableGenerator) TableHandler(columnDefinitions []metav1.TableColumnDefinition, printFunc any) error {
@@ -122,6 +192,64 @@ func (h *HumanReadableGenerator) TableHandler(columnDefinitions []metav1.TableCo
 	return nil
 }
 
+// HasHandler returns true if a print handler is registered for the type of the object.
+func (h *HumanReadableGenerator) HasHandler(obj runtime.Object) bool {
+	_, ok := h.handlerMap[reflect.TypeOf(obj)]
+	return ok
+}
+
+// ValidateHandlers verifies that the print function of each registered type emits a cell for each defined column,
+// including the wide columns with a non-zero priority, so that the mismatches are found at the startup instead of
+// as the corrupted tables. The print functions are called with a zero object, or a list of a zero item, which they
+// must print without an error. The invalid handlers are removed, so that their types are printed by the generic
+// columns of the callers.
+func (h *HumanReadableGenerator) ValidateHandlers() error {
+	types := make([]reflect.Type, 0, len(h.handlerMap))
+	for t := range h.handlerMap {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].String() < types[j].String() })
+
+	var errs []error
+	for _, t := range types {
+		if err := validateHandler(t, h.handlerMap[t]); err != nil {
+			errs = append(errs, err)
+			delete(h.handlerMap, t)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func validateHandler(t reflect.Type, handler *handlerEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the print handler of %v panics on a zero object: %v", t, r)
+		}
+	}()
+	results := handler.printFunc.Call([]reflect.Value{sampleObject(t), reflect.ValueOf(GenerateOptions{Wide: true})})
+	if !results[1].IsNil() {
+		return fmt.Errorf("the print handler of %v fails on a zero object: %v", t, results[1].Interface())
+	}
+	for _, row := range results[0].Interface().([]metav1.TableRow) {
+		if len(row.Cells) != len(handler.columnDefinitions) {
+			return fmt.Errorf("the print handler of %v emits %d cells for %d columns of which %d are printed by default", t, len(row.Cells), len(handler.columnDefinitions), len(visibleColumns(handler.columnDefinitions)))
+		}
+	}
+	return nil
+}
+
+// sampleObject returns a zero object of the type, the lists have a zero item so that a row is printed.
+func sampleObject(t reflect.Type) reflect.Value {
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return reflect.Zero(t)
+	}
+	obj := reflect.New(t.Elem())
+	if items := obj.Elem().FieldByName("Items"); items.IsValid() && items.Kind() == reflect.Slice {
+		items.Set(reflect.MakeSlice(items.Type(), 1, 1))
+	}
+	return obj
+}
+
 // ValidateRowPrintHandlerFunc validates print handler signature.
 // printFunc is the function that will be called to print an object.
 // It must be of the following type:
@@ -136,14 +264,15 @@ func ValidateRowPrintHandlerFunc(printFunc reflect.Value) error {
 		return fmt.Errorf("invalid print handler. %#v is not a function", printFunc)
 	}
 	funcType := printFunc.Type()
-	if funcType.NumIn() != 1 || funcType.NumOut() != 2 {
+	if funcType.NumIn() != 2 || funcType.NumOut() != 2 {
 		return fmt.Errorf("invalid print handler." +
-			"Must accept 1 parameters and return 2 value")
+			"Must accept 2 parameters and return 2 value")
 	}
-	if funcType.Out(0) != reflect.TypeOf((*[]metav1.TableRow)(nil)).Elem() ||
+	if funcType.In(1) != reflect.TypeOf((*GenerateOptions)(nil)).Elem() ||
+		funcType.Out(0) != reflect.TypeOf((*[]metav1.TableRow)(nil)).Elem() ||
 		funcType.Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
 		return fmt.Errorf("invalid print handler. The expected signature is: "+
-			"func handler(obj %v) ([]metav1.TableRow, error)", funcType.In(0))
+			"func handler(obj %v, options GenerateOptions) ([]metav1.TableRow, error)", funcType.In(0))
 	}
 	return nil
 }
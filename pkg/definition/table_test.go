@@ -0,0 +1,81 @@
+package definition
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateHandlers(t *testing.T) {
+	t.Run("default handlers", func(t *testing.T) {
+		h := NewTableGenerator()
+		AddHandlers(h)
+		if err := h.ValidateHandlers(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	columns := []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string"},
+		{Name: "Data", Type: "integer"},
+		{Name: "Immutable", Type: "boolean", Priority: 1},
+	}
+	tests := []struct {
+		name      string
+		printFunc func(*corev1.ConfigMap, GenerateOptions) ([]metav1.TableRow, error)
+		wantError string
+	}{
+		{
+			name: "cell of each column",
+			printFunc: func(obj *corev1.ConfigMap, options GenerateOptions) ([]metav1.TableRow, error) {
+				return []metav1.TableRow{{Cells: []any{obj.Name, int64(len(obj.Data)), obj.Immutable != nil}}}, nil
+			},
+		},
+		{
+			name: "missing wide cell",
+			printFunc: func(obj *corev1.ConfigMap, options GenerateOptions) ([]metav1.TableRow, error) {
+				return []metav1.TableRow{{Cells: []any{obj.Name, int64(len(obj.Data))}}}, nil
+			},
+			wantError: "emits 2 cells for 3 columns",
+		},
+		{
+			name: "error on a zero object",
+			printFunc: func(obj *corev1.ConfigMap, options GenerateOptions) ([]metav1.TableRow, error) {
+				return nil, errors.New("no data")
+			},
+			wantError: "fails on a zero object: no data",
+		},
+		{
+			name: "panic on a zero object",
+			printFunc: func(obj *corev1.ConfigMap, options GenerateOptions) ([]metav1.TableRow, error) {
+				return []metav1.TableRow{{Cells: []any{obj.Name, int64(len(obj.Data)), *obj.Immutable}}}, nil
+			},
+			wantError: "panics on a zero object",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTableGenerator()
+			if err := h.TableHandler(columns, tt.printFunc); err != nil {
+				t.Fatal(err)
+			}
+			err := h.ValidateHandlers()
+			if len(tt.wantError) == 0 {
+				if err != nil || !h.HasHandler(&corev1.ConfigMap{}) {
+					t.Fatalf("got error %v, want the handler kept", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("got error %v, want %q", err, tt.wantError)
+			}
+			// the invalid handler is removed so that the kind is printed by the generic columns.
+			if h.HasHandler(&corev1.ConfigMap{}) {
+				t.Fatal("got the invalid handler kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package mcp
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// the parameters of the per-call options, overriding the server defaults of the tool call.
+const (
+	TimeoutSecondsParam = "timeoutSeconds"
+	RetriesParam        = "retries"
+	NoCacheParam        = "noCache"
+)
+
+// CallOptions returns whether the tool accepts the per-call timeout and retries, the read-only tools accept the
+// timeout and the idempotent read-only tools also accept the retries.
+func CallOptions(tool mcp.Tool) (timeout bool, retries bool) {
+	readOnly := tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+	idempotent := tool.Annotations.IdempotentHint != nil && *tool.Annotations.IdempotentHint
+	return readOnly, readOnly && idempotent
+}
+
+// WithCallOptions adds the parameters of the per-call options accepted by the tool.
+func WithCallOptions(tool mcp.Tool) mcp.Tool {
+	timeout, retries := CallOptions(tool)
+	if timeout {
+		mcp.WithNumber(TimeoutSecondsParam,
+			mcp.Description("Override the timeout of the tool call and its requests to the API server, defaults to and can't exceed the timeout of the tool configured by the server"),
+			mcp.Min(1),
+		)(&tool)
+	}
+	if retries {
+		mcp.WithNumber(RetriesParam,
+			mcp.Description("Override the maximum retries of each request to the API server throttled or failed by a server error, 0 disables the retries. The reads broken by the connection errors are still retried by the client"),
+			mcp.Min(0),
+		)(&tool)
+	}
+	return tool
+}
+
+// Cacheable returns whether the results of the tool may be cached, i.e. the tool is read-only and idempotent.
+func Cacheable(tool mcp.Tool) bool {
+	_, retries := CallOptions(tool)
+	return retries
+}
+
+// WithCacheOption adds the parameter bypassing the result cache to the cacheable tool.
+func WithCacheOption(tool mcp.Tool) mcp.Tool {
+	if Cacheable(tool) {
+		mcp.WithBoolean(NoCacheParam,
+			mcp.Description("Bypass the cached result of the previous identical call and read the cluster again"),
+		)(&tool)
+	}
+	return tool
+}
@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MakeDiagnosePodPrompt creates a prompt for diagnosing a failing pod
+func MakeDiagnosePodPrompt() mcp.Prompt {
+	return mcp.NewPrompt("diagnose_failing_pod",
+		mcp.WithPromptDescription("Diagnose why a pod is failing, crash looping or not ready, and suggest a fix"),
+		mcp.WithArgument("name",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("The pod name, or the workload name like deploy/foo"),
+		),
+		mcp.WithArgument("namespace",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("The namespace of the pod"),
+		),
+	)
+}
+
+// MakeReviewManifestPrompt creates a prompt for reviewing a manifest before applying it
+func MakeReviewManifestPrompt() mcp.Prompt {
+	return mcp.NewPrompt("review_manifest",
+		mcp.WithPromptDescription("Review a manifest for deprecated APIs, schema mistakes and risky settings before applying it"),
+		mcp.WithArgument("manifest",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("The JSON or YAML manifest to review"),
+		),
+	)
+}
+
+// MakeClusterHealthPrompt creates a prompt for reporting the cluster health
+func MakeClusterHealthPrompt() mcp.Prompt {
+	return mcp.NewPrompt("cluster_health_report",
+		mcp.WithPromptDescription("Summarize the health of the cluster, including the nodes, the failing workloads and the warning events"),
+		mcp.WithArgument("namespace",
+			mcp.ArgumentDescription("If non-empty, only report the workloads and events in this namespace"),
+		),
+	)
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ResourceScheme is the URI scheme of the cluster resources, e.g. k8s://<context>/<namespace>/<kind>/<name>.
+const ResourceScheme = "k8s"
+
+// ClusterScopedNamespace is the namespace segment of the URI addressing the cluster scoped objects.
+const ClusterScopedNamespace = "_"
+
+// SnapshotsCollection is the first segment of the URI of the namespace snapshots, e.g. k8s://snapshots/<name>.
+const SnapshotsCollection = "snapshots"
+
+// OutputsCollection is the first segment of the URI of the command outputs, e.g. k8s://outputs/<name>.
+const OutputsCollection = "outputs"
+
+// MakeNamespacesResource creates a resource for listing the namespaces of the kube context
+func MakeNamespacesResource(context string) mcp.Resource {
+	return mcp.NewResource(ResourceScheme+"://"+context+"/namespaces",
+		"Namespaces of "+context,
+		mcp.WithResourceDescription("The namespaces of the kube context "+context),
+		mcp.WithMIMEType("application/json"),
+	)
+}
+
+// MakeNamespacesResourceTemplate creates a resource template for listing the namespaces of a kube context
+func MakeNamespacesResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(ResourceScheme+"://{context}/namespaces",
+		"Namespaces",
+		mcp.WithTemplateDescription("The namespaces of the kube context"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+// MakeWorkloadsResourceTemplate creates a resource template for listing the workloads in a namespace
+func MakeWorkloadsResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(ResourceScheme+"://{context}/{namespace}/workloads",
+		"Workloads",
+		mcp.WithTemplateDescription("The Deployments, StatefulSets, DaemonSets and CronJobs in the namespace with their readiness"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+// MakeManifestResourceTemplate creates a resource template for reading the manifest of an object
+func MakeManifestResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(ResourceScheme+"://{context}/{namespace}/{kind}/{name}",
+		"Manifest",
+		mcp.WithTemplateDescription("The YAML manifest of the object without the managed fields, the namespace is '_' for the cluster scoped objects"),
+		mcp.WithTemplateMIMEType("application/yaml"),
+	)
+}
+
+// MakeSnapshotResourceTemplate creates a resource template for reading the manifests of a namespace snapshot
+func MakeSnapshotResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(ResourceScheme+"://"+SnapshotsCollection+"/{name}",
+		"Namespace snapshot",
+		mcp.WithTemplateDescription("The cleaned manifests of the namespace snapshot taken by snapshot_namespace, in the order they are restored"),
+		mcp.WithTemplateMIMEType("application/yaml"),
+	)
+}
+
+// MakeOutputResourceTemplate creates a resource template for reading the output of a command saved as a resource
+func MakeOutputResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(ResourceScheme+"://"+OutputsCollection+"/{name}",
+		"Command output",
+		mcp.WithTemplateDescription("The stdout of the command run by run_in_container with the output saved as a resource, only readable in the same session, the binary output is a blob"),
+	)
+}
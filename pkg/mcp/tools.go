@@ -1,7 +1,20 @@
 package mcp
 
 import (
+	"slices"
+
 	"github.com/mark3labs/mcp-go/mcp"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+var (
+	// ObjectOutputs are the output formats of the objects.
+	ObjectOutputs = []string{definition.OutputJSON, definition.OutputYAML}
+	// TopOutputs are the output formats of the resource usage of the nodes and the pods.
+	TopOutputs = []string{definition.OutputText, definition.OutputTable}
+	// SessionOutputs are the output formats of any tool, which the default output of the session may be set to.
+	SessionOutputs = slices.Compact(slices.Sorted(slices.Values(slices.Concat(ObjectOutputs, definition.TableOutputs, TopOutputs))))
 )
 
 // MakeListClustersTool creates a tool for listing the all Kubernetes clusters
@@ -18,17 +31,79 @@ func MakeListClustersTool() mcp.Tool {
 // MakeSwitchContextTool creates a tool for switching the Kubernetes context
 func MakeSwitchContextTool() mcp.Tool {
 	return mcp.NewTool("switch_context",
-		mcp.WithDescription("Switch the Kubernetes context"),
+		mcp.WithDescription("Switch the Kubernetes context for the subsequent calls of the session, the kubeconfig is not modified unless persist is set"),
 		mcp.WithString("name",
 			mcp.Required(),
 			mcp.Description("The name of the cluster context to switch to"),
 		),
+		mcp.WithBoolean("persist",
+			mcp.Description("Write the context as the current context of the kubeconfig, like 'kubectl config use-context <name>'"),
+			mcp.DefaultBool(false),
+		),
 		mcp.WithReadOnlyHintAnnotation(false),
 		mcp.WithDestructiveHintAnnotation(true),
 		mcp.WithOpenWorldHintAnnotation(true),
 	)
 }
 
+// MakeRenameContextTool creates a tool for renaming the Kubernetes context
+func MakeRenameContextTool() mcp.Tool {
+	return mcp.NewTool("rename_context",
+		mcp.WithDescription("Rename the Kubernetes context in the kubeconfig, like 'kubectl config rename-context <name> <newName>'"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the context to rename"),
+		),
+		mcp.WithString("newName",
+			mcp.Required(),
+			mcp.Description("The new name of the context"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(false),
+	)
+}
+
+// MakeDeleteContextTool creates a tool for deleting the Kubernetes context
+func MakeDeleteContextTool() mcp.Tool {
+	return mcp.NewTool("delete_context",
+		mcp.WithDescription(`Delete the Kubernetes context from the kubeconfig, like 'kubectl config delete-context <name>'. The cluster and
+user entries are kept`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the context to delete"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Delete the context even if it is the current context, the current context is unset"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(false),
+	)
+}
+
+// MakeSetContextNamespaceTool creates a tool for setting the default namespace of the Kubernetes context
+func MakeSetContextNamespaceTool() mcp.Tool {
+	return mcp.NewTool("set_context_namespace",
+		mcp.WithDescription(`Set the default namespace of the Kubernetes context, like 'kubectl config set-context <context> --namespace
+<namespace>'. The current context is used if the context is empty, like 'kubectl config set-context --current'`),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The default namespace"),
+		),
+		mcp.WithString("context",
+			mcp.Description("The name of the context, defaults to the current context"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(false),
+	)
+}
+
 // MakeGetClusterVersionTool creates a tool for getting the cluster version
 func MakeGetClusterVersionTool() mcp.Tool {
 	return mcp.NewTool("get_cluster_version",
@@ -61,15 +136,45 @@ func MakeGetResourceDetailTool() mcp.Tool {
 	return mcp.NewTool("get_resource_detail",
 		mcp.WithDescription("Get detailed information about a specific resource"),
 		mcp.WithString("kind",
-			mcp.Required(),
-			mcp.Description("Resource type"),
+			mcp.Description("Resource type, the kubectl short names like deploy and svc are accepted. Optional if the name is in the form of kind/name"),
 		),
 		mcp.WithString("name",
 			mcp.Required(),
-			mcp.Description("The name of the resource to get information about"),
+			mcp.Description("The name of the resource to get information about, or the kind/name reference like deploy/nginx"),
 		),
 		mcp.WithString("namespace",
-			mcp.Description("Namespace (required for namespace-scoped resources)"),
+			mcp.Description("Namespace of the namespace-scoped resources, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("jsonpath",
+			mcp.Description("A JSONPath expression like kubectl -o jsonpath to return only the matched fields instead of the whole object, e.g. .status.conditions[?(@.type==\"Ready\")]"),
+		),
+		mcp.WithBoolean("raw",
+			mcp.Description("Whether to return the object as it is, without dropping the noisy fields like managedFields, uid and the last applied configuration"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("output",
+			mcp.Description("The output format of the object"),
+			mcp.Enum(ObjectOutputs...),
+			mcp.DefaultString("json"),
+		),
+		mcp.WithBoolean("export",
+			mcp.Description("Whether to strip the fields populated by the cluster, e.g. status, uid and the allocated cluster IP, to get a re-applyable manifest"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("subresource",
+			mcp.Description("Get the subresource instead of the object, e.g. the scale of a Deployment"),
+			mcp.Enum("status", "scale"),
+		),
+		mcp.WithBoolean("fuzzy",
+			mcp.Description(`Whether to return the names of the similar objects if the exact name is not found, e.g. the pods whose names start
+with the name or share it before the hash suffix`),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("labelSelector",
+			mcp.Description("The label selector to narrow down the similar objects searched by fuzzy, e.g. app=nginx"),
+		),
+		mcp.WithString("fieldSelector",
+			mcp.Description("The field selector to narrow down the similar objects searched by fuzzy, e.g. status.phase=Running"),
 		),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithDestructiveHintAnnotation(false),
@@ -81,10 +186,12 @@ func MakeGetResourceDetailTool() mcp.Tool {
 // MakeListResourcesTool creates a tool for listing resources
 func MakeListResourcesTool() mcp.Tool {
 	return mcp.NewTool("list_resources",
-		mcp.WithDescription("List all instances of a resource type"),
+		mcp.WithDescription(`List all instances of a resource type. The PodMetrics and NodeMetrics of metrics.k8s.io are listed like the other
+kinds, the values of the custom and external metrics APIs are listed by the kind MetricValue or ExternalMetricValue and the metric.
+The object of each table row is the apiVersion, kind, name, namespace and uid of the listed object`),
 		mcp.WithString("kind",
 			mcp.Required(),
-			mcp.Description("Resource type"),
+			mcp.Description("Resource type, the kubectl short names like deploy and svc are accepted"),
 		),
 		mcp.WithString("namespace",
 			mcp.Description("The namespace of the resource, If non-empty, only list resources in this namespace"),
@@ -97,6 +204,34 @@ func MakeListResourcesTool() mcp.Tool {
 			mcp.Description(`FieldSelector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector
 				key1=value1,key2=value2). The server only supports a limited number of field queries per type`),
 		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("The maximum number of the returned items, the remaining items are paged by the continue token, 0 means no limit"),
+			mcp.Min(0),
+		),
+		mcp.WithString("continue",
+			mcp.Description("The continue token returned by the previous call with maxResults to get the next page"),
+		),
+		mcp.WithString("sinceResourceVersion",
+			mcp.Description(`Only return the objects added, modified or deleted since the resourceVersion of a previous list, the deleted
+objects are named in the returned delta with the resourceVersion to pass to the next call. maxResults caps the changed
+objects, defaults to 500`),
+		),
+		mcp.WithString("jsonpath",
+			mcp.Description("A JSONPath expression like kubectl -o jsonpath to return the matched fields of each object instead of the table, e.g. .status.phase"),
+		),
+		mcp.WithBoolean("wide",
+			mcp.Description("Include the additional columns of the table like kubectl get -o wide, e.g. the node and IP of the pods"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("output",
+			mcp.Description("The format of the returned table, markdown, csv and text render the rows as text which is easier to read than the table JSON, text aligns the columns like kubectl get"),
+			mcp.Enum(definition.TableOutputs...),
+			mcp.DefaultString("json"),
+		),
+		mcp.WithString("metric",
+			mcp.Description(`The metric to list if the kind is MetricValue or ExternalMetricValue, the resource/metric of the custom metrics
+like pods/http_requests, or the metric name of the external metrics like queue_length`),
+		),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithDestructiveHintAnnotation(false),
 		mcp.WithIdempotentHintAnnotation(true),
@@ -108,10 +243,129 @@ func MakeListResourcesTool() mcp.Tool {
 func MakeApplyResourceTool() mcp.Tool {
 	return mcp.NewTool("apply_resource",
 		mcp.WithDescription(`Apply a configuration to a resource by file name. The resource name must be specified. This resource will be
-created if it doesn't exist yet`),
+created if it doesn't exist yet, otherwise patched with the three-way merge of the last applied configuration, the manifest
+and the live object like kubectl apply. Set preview to see the merge, the removed fields and the fields owned by the other
+field managers before any change is made`),
+		mcp.WithString("manifest",
+			mcp.Required(),
+			mcp.Description("Resource manifest, JSON and YAML formats are accepted, multiple YAML documents are applied in order"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped objects without one, defaults to the namespace of the context or the ServiceAccount in cluster. It must agree with the namespaces of the manifest if both are specified"),
+		),
+		mcp.WithBoolean("preview",
+			mcp.Description("Return the computed three-way merge like kubectl diff without changing the cluster"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("checkQuota",
+			mcp.Description(`Check the objects against the ResourceQuotas and LimitRanges of their namespaces first, nothing is applied if
+any object would exceed a quota resource like requests.cpu or violate a LimitRange`),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("overrideGitOps",
+			mcp.Description("Apply the objects even if they're managed by an Argo CD Application or a Flux Kustomization or HelmRelease, which reverts the changes made out of git"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCreateResourceTool creates a tool for creating resources, like `kubectl create -f <manifest>`
+func MakeCreateResourceTool() mcp.Tool {
+	return mcp.NewTool("create_resource",
+		mcp.WithDescription(`Create a resource with the manifest, like kubectl create. It fails if the object already exists, use
+apply_resource or update_resource to change an existing object. The objects of a multi-document YAML manifest are created
+in order and their results are returned with the indexes of the documents`),
+		mcp.WithString("kind",
+			mcp.Description("The type of the specified resource, the kubectl short names like deploy and svc are accepted. Optional, the resource is resolved by the apiVersion and kind of the manifest which must agree with it if specified"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped objects without one, defaults to the namespace of the context or the ServiceAccount in cluster. It must agree with the namespace of the manifest if both are specified"),
+		),
+		mcp.WithString("manifest",
+			mcp.Required(),
+			mcp.Description("Resource manifest, JSON and YAML formats are accepted, the YAML documents separated by --- are created in order"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeUpdateResourceTool creates a tool for updating resources, like `kubectl replace -f <manifest>`
+func MakeUpdateResourceTool() mcp.Tool {
+	return mcp.NewTool("update_resource",
+		mcp.WithDescription(`Update a resource with the manifest replacing the whole object. The manifest without resourceVersion updates the
+latest object. The manifest with resourceVersion is merged into the latest object if only the status or metadata have
+been changed since, otherwise the conflicting fields are returned unless force is set. The objects of a multi-document
+YAML manifest are updated in order and their results are returned with the indexes of the documents`),
+		mcp.WithString("kind",
+			mcp.Description("The type of the specified resource, the kubectl short names like deploy and svc are accepted. Optional, the resource is resolved by the apiVersion and kind of the manifest which must agree with it if specified"),
+		),
+		mcp.WithString("name",
+			mcp.Description("The name of the specified resource, it must match the name in the manifest of a single object if specified"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped objects without one, defaults to the namespace of the context or the ServiceAccount in cluster. It must agree with the namespace of the manifest if both are specified"),
+		),
 		mcp.WithString("manifest",
 			mcp.Required(),
-			mcp.Description("Resource manifest, JSON and YAML formats are accepted"),
+			mcp.Description("Resource manifest, JSON and YAML formats are accepted, the YAML documents separated by --- are updated in order"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Whether to overwrite the changes made by others since the resourceVersion of the manifest"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("subresource",
+			mcp.Description("Update the subresource instead of the object, e.g. the status of a custom resource, the manifest must be the whole object for status and the Scale object for scale"),
+			mcp.Enum("status", "scale"),
+		),
+		mcp.WithBoolean("overrideGitOps",
+			mcp.Description("Change the object even if it's managed by an Argo CD Application or a Flux Kustomization or HelmRelease, which reverts the changes made out of git"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakePatchResourceTool creates a tool for patching resources, like `kubectl patch`
+func MakePatchResourceTool() mcp.Tool {
+	return mcp.NewTool("patch_resource",
+		mcp.WithDescription(`Patch a resource or its status or scale subresource, like kubectl patch. Only the fields in the patch are changed,
+e.g. {"spec":{"replicas":3}} with the merge patch, or clear a stuck condition of a custom resource by patching its status`),
+		mcp.WithString("kind",
+			mcp.Description("The type of the specified resource, the kubectl short names like deploy and svc are accepted. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the specified resource, or the kind/name reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped resource, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("patch",
+			mcp.Required(),
+			mcp.Description("The patch in JSON or YAML format"),
+		),
+		mcp.WithString("patchType",
+			mcp.Description("The type of the patch, the strategic merge patch is only supported by the built-in resources"),
+			mcp.Enum("merge", "json", "strategic"),
+			mcp.DefaultString("merge"),
+		),
+		mcp.WithString("subresource",
+			mcp.Description("Patch the subresource instead of the object, e.g. the status of a custom resource"),
+			mcp.Enum("status", "scale"),
+		),
+		mcp.WithBoolean("overrideGitOps",
+			mcp.Description("Change the object even if it's managed by an Argo CD Application or a Flux Kustomization or HelmRelease, which reverts the changes made out of git"),
+			mcp.DefaultBool(false),
 		),
 		mcp.WithReadOnlyHintAnnotation(false),
 		mcp.WithDestructiveHintAnnotation(true),
@@ -125,15 +379,18 @@ func MakeDeleteResourceTool() mcp.Tool {
 	return mcp.NewTool("delete_resource",
 		mcp.WithDescription("Delete a resource with the specified name and namespace if it's namespace-scoped"),
 		mcp.WithString("kind",
-			mcp.Required(),
-			mcp.Description("The type of the specified resource"),
+			mcp.Description("The type of the specified resource, the kubectl short names like deploy and svc are accepted. Optional if the name is in the form of kind/name"),
 		),
 		mcp.WithString("name",
 			mcp.Required(),
-			mcp.Description("The name of the specified resource"),
+			mcp.Description("The name of the specified resource, or the kind/name reference like deploy/nginx"),
 		),
 		mcp.WithString("namespace",
-			mcp.Description("The namespace of the resource, (required for namespace-scoped resources)"),
+			mcp.Description("The namespace of the namespace-scoped resource, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithBoolean("overrideGitOps",
+			mcp.Description("Change the object even if it's managed by an Argo CD Application or a Flux Kustomization or HelmRelease, which reverts the changes made out of git"),
+			mcp.DefaultBool(false),
 		),
 		mcp.WithReadOnlyHintAnnotation(false),
 		mcp.WithDestructiveHintAnnotation(true),
@@ -146,23 +403,66 @@ func MakeDeleteResourceTool() mcp.Tool {
 func MakeGetPodLogsTool() mcp.Tool {
 	return mcp.NewTool("get_pod_logs",
 		mcp.WithDescription(`Get the logs for a container in a pod or specified resource. If the pod has only one container, the container name is
-optional`),
+optional. The workload name like deploy/foo is resolved to its pods, the ready and newest pod is picked unless allPods is set`),
 		mcp.WithString("name",
 			mcp.Required(),
-			mcp.Description("The specified pod name"),
+			mcp.Description("The specified pod name, or the workload name with the kind prefix, e.g. deploy/foo, sts/foo, ds/foo, job/foo, svc/foo"),
 		),
 		mcp.WithString("namespace",
 			mcp.Required(),
 			mcp.Description("The namespace of the pod"),
 		),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload, it is ignored if the name has the kind prefix"),
+			mcp.Enum("Pod", "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job"),
+			mcp.DefaultString("Pod"),
+		),
 		mcp.WithString("container",
 			mcp.Description("Get the logs of this container in the pod"),
 		),
+		mcp.WithBoolean("allPods",
+			mcp.DefaultBool(false),
+			mcp.Description("Get the logs of all pods of the workload, each prefixed with the pod name"),
+		),
+		mcp.WithBoolean("allContainers",
+			mcp.DefaultBool(false),
+			mcp.Description("Get the logs of all containers in the pod, including the init containers"),
+		),
+		mcp.WithBoolean("previous",
+			mcp.DefaultBool(false),
+			mcp.Description("Get the logs of the previous terminated container instance, e.g. the container in a crash loop"),
+		),
+		mcp.WithBoolean("timestamps",
+			mcp.DefaultBool(false),
+			mcp.Description("Include the timestamp at the beginning of every line"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Only return the lines matching the regular expression, like grep -E"),
+		),
+		mcp.WithNumber("before",
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+			mcp.Description("Lines of leading context before each matched line, only used with pattern"),
+		),
+		mcp.WithNumber("after",
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+			mcp.Description("Lines of trailing context after each matched line, only used with pattern"),
+		),
 		mcp.WithNumber("tail",
 			mcp.DefaultNumber(50),
-			mcp.Min(1.0),
-			mcp.Max(100.0),
-			mcp.Description("Lines of recent log file to display"),
+			mcp.Min(-1.0),
+			mcp.Description("Lines of recent log file to display, -1 shows all lines. It is capped by the server maximum, 1000 by default"),
+		),
+		mcp.WithNumber("headLines",
+			mcp.DefaultNumber(0),
+			mcp.Min(0),
+			mcp.Description(`Lines of the beginning of the log file to display before the tail lines with a gap marker between them, to
+see the startup errors hidden by the crash loop spam of a large log. The tail may be 0 to only display the head lines`),
+		),
+		mcp.WithNumber("limitBytes",
+			mcp.Min(0),
+			mcp.Description("Maximum bytes of logs to return for each container. It is capped by the server maximum, 1MiB by default"),
 		),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithDestructiveHintAnnotation(false),
@@ -174,9 +474,13 @@ optional`),
 func MakeRunInContainerTool() mcp.Tool {
 	return mcp.NewTool("run_in_container",
 		mcp.WithDescription(`Execute a command in a container.
-		If the container is empty, it uses the default container or the first container in the pod.`),
+		If the container is empty, it uses the default container or the first container in the pod.
+		The workloads like deploy/nginx or svc/nginx are resolved to a running pod like kubectl exec, the chosen pod is returned with the output.`),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload resolved to a pod, e.g. Deployment or Service, defaults to Pod. Optional if the name is in the form of kind/name"),
+		),
 		mcp.WithString("name",
-			mcp.Description("Name of the Pod where the command will be executed"),
+			mcp.Description("Name of the Pod where the command will be executed, or the workload reference like deploy/nginx or svc/nginx"),
 			mcp.Required(),
 		),
 		mcp.WithString("namespace",
@@ -189,6 +493,12 @@ func MakeRunInContainerTool() mcp.Tool {
 			mcp.Description("Command to execute in the Pod container."),
 			mcp.Required(),
 		),
+		mcp.WithString("output",
+			mcp.Description(`Return the stdout inline, or save it as a resource read by the returned stdoutResource URI, e.g. the large or
+binary output of tar or pg_dump. The inline output which is not valid UTF-8 is encoded in base64`),
+			mcp.Enum("inline", "resource"),
+			mcp.DefaultString("inline"),
+		),
 		mcp.WithReadOnlyHintAnnotation(false),
 		mcp.WithDestructiveHintAnnotation(true),
 		mcp.WithIdempotentHintAnnotation(false),
@@ -217,6 +527,19 @@ func MakeTopPodTool() mcp.Tool {
 			mcp.Description(`FieldSelector (field query) to filter on, supports '=', '==', and '!='.(e.g. --field-selector
 				key1=value1,key2=value2). The server only supports a limited number of field queries per type`),
 		),
+		mcp.WithString("output",
+			mcp.Description("The output format, text is the kubectl top output and table is a JSON table with the CPU in millicores and the memory in bytes"),
+			mcp.Enum(TopOutputs...),
+			mcp.DefaultString("text"),
+		),
+		mcp.WithNumber("samples",
+			mcp.Description("The number of the metric samples taken over the interval, more than 1 returns the min/avg/max and the trend of the usage per pod instead of the table, at most 20. The samples must fit into the tool timeout"),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithString("interval",
+			mcp.Description("The interval between the samples, e.g. 15s, the metrics server refreshes the metrics every 15s by default"),
+			mcp.DefaultString("15s"),
+		),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithDestructiveHintAnnotation(false),
 		mcp.WithIdempotentHintAnnotation(true),
@@ -238,7 +561,1795 @@ func MakeTopNodeTool() mcp.Tool {
 			mcp.Description(`LabelSelector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2). Matching
 				objects must satisfy all of the specified label constraints`),
 		),
+		mcp.WithString("output",
+			mcp.Description("The output format, text is the kubectl top output and table is a JSON table with the CPU in millicores and the memory in bytes and the percentages of the node capacity"),
+			mcp.Enum(TopOutputs...),
+			mcp.DefaultString("text"),
+		),
+		mcp.WithNumber("samples",
+			mcp.Description("The number of the metric samples taken over the interval, more than 1 returns the min/avg/max and the trend of the usage per node instead of the table, at most 20. The samples must fit into the tool timeout"),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithString("interval",
+			mcp.Description("The interval between the samples, e.g. 15s, the metrics server refreshes the metrics every 15s by default"),
+			mcp.DefaultString("15s"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCheckServiceTool creates a tool for checking the connectivity of a service end-to-end.
+func MakeCheckServiceTool() mcp.Tool {
+	return mcp.NewTool("check_service",
+		mcp.WithDescription(`Validate a Service end-to-end: the selector matches ready pods, the endpointslices are populated and the target ports
+match the container ports. Optionally runs an in-cluster curl probe against the service ports. Returns a pass/fail report per check`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the service"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the service"),
+		),
+		mcp.WithBoolean("probe",
+			mcp.Description("Run an in-cluster curl probe against every TCP port of the service"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithString("probePod",
+			mcp.Description("The existing pod in the service namespace to run the probe from, a temporary pod is created if empty"),
+		),
+		mcp.WithString("probeImage",
+			mcp.Description("The image of the temporary probe pod, it must contain curl and run it as a non-root user"),
+			mcp.DefaultString("curlimages/curl:latest"),
+		),
+		mcp.WithString("probePath",
+			mcp.Description("The HTTP path to request when probing"),
+			mcp.DefaultString("/"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeNetDebugTool creates a tool for running the network checks inside the cluster.
+func MakeNetDebugTool() mcp.Tool {
+	return mcp.NewTool("net_debug",
+		mcp.WithDescription(`Run DNS lookups, TCP connect and HTTP checks from inside the cluster. The checks are executed in the specified
+pod, or in a temporary netshoot pod if the pod is empty. Returns a structured result per check`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the pod to run the checks from, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("pod",
+			mcp.Description("The existing pod to run the checks from, a temporary pod is created if empty"),
+		),
+		mcp.WithString("container",
+			mcp.Description("The container of the existing pod to run the checks in"),
+		),
+		mcp.WithString("image",
+			mcp.Description("The image of the temporary pod, it must contain nslookup, nc and curl and run them as a non-root user, the pod runs as the user 65534 to pass the restricted Pod Security Standard"),
+			mcp.DefaultString("nicolaka/netshoot:v0.13"),
+		),
+		mcp.WithArray("dns",
+			mcp.Description("Hostnames to resolve, e.g. [\"kubernetes.default.svc\"]"),
+		),
+		mcp.WithArray("tcp",
+			mcp.Description("Addresses in host:port format to connect to, e.g. [\"my-svc.my-ns:5432\"]"),
+		),
+		mcp.WithArray("http",
+			mcp.Description("URLs to request, e.g. [\"http://my-svc.my-ns/healthz\"]"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeExplainResourceTool creates a tool for describing the fields of a resource, like `kubectl explain`
+func MakeExplainResourceTool() mcp.Tool {
+	return mcp.NewTool("explain_resource",
+		mcp.WithDescription(`Describe the fields and types of a resource from the cluster OpenAPI v3 schema, like 'kubectl explain'.
+It works for both built-in resources and CRDs`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("Resource type, e.g. Deployment"),
+		),
+		mcp.WithString("field",
+			mcp.Description("The dot separated field path to explain, e.g. spec.template.spec.containers. Explains the resource itself if empty"),
+		),
+		mcp.WithString("apiVersion",
+			mcp.Description("The group version of the resource, e.g. apps/v1. Uses the preferred version of the cluster if empty"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Print the fields of fields recursively"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeDetectDeprecatedAPIsTool creates a tool for detecting the deprecated or removed API versions
+func MakeDetectDeprecatedAPIsTool() mcp.Tool {
+	return mcp.NewTool("detect_deprecated_apis",
+		mcp.WithDescription(`Scan the live objects or the supplied manifests for API versions which are deprecated or removed in the target
+Kubernetes version, and suggest the replacement group version, e.g. batch/v1beta1 CronJob should be migrated to batch/v1.
+The live objects are checked against the API versions recorded in the managed fields and the last applied configuration`),
+		mcp.WithString("targetVersion",
+			mcp.Description("The Kubernetes version to check against, e.g. 1.32. Defaults to the next minor version of the cluster"),
+		),
+		mcp.WithString("manifest",
+			mcp.Description("The manifests to check instead of the live objects, JSON and multi-document YAML formats are accepted"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("If non-empty, only scan the live objects in this namespace"),
+		),
 		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeFindResourceTool creates a tool for searching the resources by name across kinds and namespaces
+func MakeFindResourceTool() mcp.Tool {
+	return mcp.NewTool("find_resource",
+		mcp.WithDescription(`Search the objects whose name matches a substring or regular expression across the kinds and namespaces, useful
+when only a partial name is known, e.g. from an error message. Returns the kind, namespace, name and age of the matched objects`),
+		mcp.WithString("pattern",
+			mcp.Required(),
+			mcp.Description("The case-insensitive substring of the name, or the regular expression if regex is true"),
+		),
+		mcp.WithBoolean("regex",
+			mcp.Description("Treat the pattern as a regular expression"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("The kinds to search, e.g. [\"Pod\", \"Service\"]. Defaults to the common workload, network, config and storage kinds"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("If non-empty, only search in this namespace, otherwise search all namespaces"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeQueryByLabelsTool creates a tool for listing the resources matching a label selector across kinds
+func MakeQueryByLabelsTool() mcp.Tool {
+	return mcp.NewTool("query_by_labels",
+		mcp.WithDescription(`List all objects matching a label selector across multiple kinds in one call, e.g. app.kubernetes.io/instance=foo,
+to reconstruct the footprint of an application. Returns the kind, namespace, name, age and labels of the matched objects`),
+		mcp.WithString("labelSelector",
+			mcp.Required(),
+			mcp.Description("The label selector, e.g. app=foo,tier!=cache"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("The kinds to query, e.g. [\"Pod\", \"Service\"]. Defaults to the workload, network, config, storage and policy kinds"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("If non-empty, only query in this namespace, otherwise query all namespaces"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCreateNamespaceTool creates a tool for creating a namespace, like `kubectl create namespace`
+func MakeCreateNamespaceTool() mcp.Tool {
+	return mcp.NewTool("create_namespace",
+		mcp.WithDescription("Create a namespace with the specified name, like 'kubectl create namespace'"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the namespace"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("The labels in key=value format, e.g. [\"team=payments\"]"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Validate the object on the server without persisting it"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCreateConfigMapTool creates a tool for creating a configmap, like `kubectl create configmap`
+func MakeCreateConfigMapTool() mcp.Tool {
+	return mcp.NewTool("create_configmap",
+		mcp.WithDescription(`Create a configmap from the literal values and the local files, like 'kubectl create configmap'. The object is built
+by the server, so no manifest is required`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the configmap"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the configmap"),
+		),
+		mcp.WithArray("fromLiteral",
+			mcp.Description("The literal values in key=value format, e.g. [\"LOG_LEVEL=debug\"]"),
+		),
+		mcp.WithArray("fromFile",
+			mcp.Description("The local files in [key=]path format, the key defaults to the file name, e.g. [\"app.yaml=config.yaml\"]. The paths are relative to the local file directory of the server, the local files are rejected unless it's configured"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("The labels in key=value format"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Validate the object on the server without persisting it"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCreateSecretTool creates a tool for creating a secret, like `kubectl create secret`
+func MakeCreateSecretTool() mcp.Tool {
+	return mcp.NewTool("create_secret",
+		mcp.WithDescription(`Create a generic, docker-registry or tls secret, like 'kubectl create secret'. The object is built by the server,
+so no manifest is required. The secret data is never returned`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the secret"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the secret"),
+		),
+		mcp.WithString("type",
+			mcp.Description("The type of the secret"),
+			mcp.Enum("generic", "docker-registry", "tls"),
+			mcp.DefaultString("generic"),
+		),
+		mcp.WithArray("fromLiteral",
+			mcp.Description("The literal values in key=value format for the generic secret"),
+		),
+		mcp.WithArray("fromFile",
+			mcp.Description("The local files in [key=]path format for the generic secret, the key defaults to the file name. The paths are relative to the local file directory of the server, the local files are rejected unless it's configured"),
+		),
+		mcp.WithString("dockerServer",
+			mcp.Description("The registry server for the docker-registry secret"),
+			mcp.DefaultString("https://index.docker.io/v1/"),
+		),
+		mcp.WithString("dockerUsername",
+			mcp.Description("The registry username for the docker-registry secret"),
+		),
+		mcp.WithString("dockerPassword",
+			mcp.Description("The registry password for the docker-registry secret"),
+		),
+		mcp.WithString("dockerEmail",
+			mcp.Description("The registry email for the docker-registry secret"),
+		),
+		mcp.WithString("cert",
+			mcp.Description("The PEM encoded certificate, or the path of the certificate file in the local file directory of the server for the tls secret"),
+		),
+		mcp.WithString("key",
+			mcp.Description("The PEM encoded private key, or the path of the key file in the local file directory of the server for the tls secret"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("The labels in key=value format"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Validate the object on the server without persisting it"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeRunJobTool creates a tool for running a one-off job, like `kubectl create job`
+func MakeRunJobTool() mcp.Tool {
+	return mcp.NewTool("run_job",
+		mcp.WithDescription(`Run a one-off job with the specified image and command, like 'kubectl create job'. Optionally wait for the job
+to finish and return the logs of its pods, the wait is bounded by the timeout of the tool`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the job"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the job"),
+		),
+		mcp.WithString("image",
+			mcp.Required(),
+			mcp.Description("The image of the job container"),
+		),
+		mcp.WithArray("command",
+			mcp.Description("The command of the job container, the image entrypoint is used if empty, e.g. [\"sh\", \"-c\", \"date\"]"),
+		),
+		mcp.WithNumber("backoffLimit",
+			mcp.Description("The number of retries before the job is marked as failed"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithNumber("ttlSecondsAfterFinished",
+			mcp.Description("The seconds after which the finished job is deleted"),
+			mcp.DefaultNumber(3600),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait for the job to finish and return the logs of its pods"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("waitTimeoutSeconds",
+			mcp.Description("The maximum seconds to wait for the job to finish, defaults to and is capped by the time left of the tool call so that the last state is returned before it times out"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeTriggerCronJobTool creates a tool for triggering a cronjob manually, like `kubectl create job --from=cronjob/<name>`
+func MakeTriggerCronJobTool() mcp.Tool {
+	return mcp.NewTool("trigger_cronjob",
+		mcp.WithDescription(`Trigger a cronjob manually by creating a job from its job template, like 'kubectl create job --from=cronjob/<name>'.
+Optionally wait for the job to finish and return the logs of its pods, the wait is bounded by the timeout of the tool`),
+		mcp.WithString("cronJob",
+			mcp.Required(),
+			mcp.Description("The name of the cronjob"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the cronjob"),
+		),
+		mcp.WithString("name",
+			mcp.Description("The name of the job, defaults to <cronJob>-manual-<timestamp>"),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait for the job to finish and return the logs of its pods"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("waitTimeoutSeconds",
+			mcp.Description("The maximum seconds to wait for the job to finish, defaults to and is capped by the time left of the tool call so that the last state is returned before it times out"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeSuspendJobTool creates a tool for suspending or resuming a cronjob or job
+func MakeSuspendJobTool() mcp.Tool {
+	return mcp.NewTool("suspend_job",
+		mcp.WithDescription(`Suspend or resume a cronjob or job by setting spec.suspend. A suspended cronjob schedules no new jobs, and a
+suspended job terminates its active pods until it is resumed, e.g. pause the noisy jobs during incidents`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the cronjob or job, may be in the form of kind/name, e.g. cj/backup"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the cronjob or job"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the object"),
+			mcp.Enum("CronJob", "Job"),
+			mcp.DefaultString("CronJob"),
+		),
+		mcp.WithBoolean("suspend",
+			mcp.Description("Suspend the object if true, otherwise resume it"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeListCronScheduleTool creates a tool for listing the upcoming schedule times of a cron expression or cronjob
+func MakeListCronScheduleTool() mcp.Tool {
+	return mcp.NewTool("list_cron_schedule",
+		mcp.WithDescription(`List the upcoming schedule times of the cron expression in the time zone, or of the specified cronjob. The
+standard 5-field expressions, the @hourly style macros and the CRON_TZ= prefix are supported`),
+		mcp.WithString("schedule",
+			mcp.Description("The cron expression, e.g. '*/15 * * * *', defaults to the schedule of the cronjob"),
+		),
+		mcp.WithString("timeZone",
+			mcp.Description("The IANA time zone, e.g. 'Asia/Shanghai', defaults to the time zone of the cronjob or UTC"),
+		),
+		mcp.WithString("cronJob",
+			mcp.Description("The name of the cronjob to read the schedule and time zone from"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the cronjob"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description("The number of the upcoming schedule times"),
+			mcp.DefaultNumber(5),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeInspectHPATool creates a tool for inspecting a HorizontalPodAutoscaler
+func MakeInspectHPATool() mcp.Tool {
+	return mcp.NewTool("inspect_hpa",
+		mcp.WithDescription(`Inspect a HorizontalPodAutoscaler, returns the current metrics against the targets, the replicas, the conditions,
+the recent scaling events and the hints of why it is not scaling`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the HorizontalPodAutoscaler"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the HorizontalPodAutoscaler"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeScaleHPATool creates a tool for patching the replica bounds of a HorizontalPodAutoscaler
+func MakeScaleHPATool() mcp.Tool {
+	return mcp.NewTool("scale_hpa",
+		mcp.WithDescription("Patch the minReplicas and/or maxReplicas of a HorizontalPodAutoscaler"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the HorizontalPodAutoscaler"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the HorizontalPodAutoscaler"),
+		),
+		mcp.WithNumber("minReplicas",
+			mcp.Description("The new minimum replicas, unchanged if omitted"),
+		),
+		mcp.WithNumber("maxReplicas",
+			mcp.Description("The new maximum replicas, unchanged if omitted"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeDiagnoseStorageTool creates a tool for troubleshooting a PersistentVolumeClaim
+func MakeDiagnoseStorageTool() mcp.Tool {
+	return mcp.NewTool("diagnose_storage",
+		mcp.WithDescription(`Diagnose a PersistentVolumeClaim, checks the binding status, the storage class and its provisioner, the matching
+volume, the volume attachments, the CSI drivers on the nodes of the pods using it and the related events, returns
+the checks with the root cause hint, e.g. why the claim is pending`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the PersistentVolumeClaim"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the PersistentVolumeClaim"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCertificateExpiryTool creates a tool for reporting the expiry of the TLS certificates
+func MakeCertificateExpiryTool() mcp.Tool {
+	return mcp.NewTool("certificate_expiry_report",
+		mcp.WithDescription(`Report the certificates of the kubernetes.io/tls secrets with the expiry dates, the issuers and the SANs sorted
+by the soonest expiry, optionally including the serving certificate of the API server`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to scan, all namespaces are scanned if empty"),
+		),
+		mcp.WithNumber("expiringWithinDays",
+			mcp.Description("Only report the certificates expiring within the days, all certificates are reported if 0"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithBoolean("includeChain",
+			mcp.Description("Report the intermediate and CA certificates of the chains besides the leaf certificates"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("includeAPIServer",
+			mcp.Description("Report the serving certificate of the API server"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeImageInventoryTool creates a tool for reporting the images running in the cluster
+func MakeImageInventoryTool() mcp.Tool {
+	return mcp.NewTool("image_inventory",
+		mcp.WithDescription(`Report the container images running in the cluster grouped by the image reference, with the container and pod
+counts, the tag or digest, the digests resolved by the kubelets and the workloads using them, e.g. for the upgrade
+planning and the CVE correlation`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to scan, all namespaces are scanned if empty"),
+		),
+		mcp.WithString("filter",
+			mcp.Description("Only report the images containing the substring, e.g. 'nginx' or 'gcr.io/'"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeSecurityAuditTool creates a tool for auditing the security posture of the workloads
+func MakeSecurityAuditTool() mcp.Tool {
+	return mcp.NewTool("security_audit",
+		mcp.WithDescription(`Audit the security posture of the running workloads, checks the privileged containers, the host namespaces and
+hostPath volumes, the dangerous capabilities, the root users, the privilege escalation, the missing resource limits
+and security contexts. Returns the findings with a score from 0 to 100 per workload and namespace`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to audit, all namespaces are audited if empty"),
+		),
+		mcp.WithString("minSeverity",
+			mcp.Description("Only report the findings of at least the severity"),
+			mcp.Enum("Critical", "High", "Medium", "Low"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeQuotaHeadroomTool creates a tool for analyzing the quota headroom and the LimitRange violations
+func MakeQuotaHeadroomTool() mcp.Tool {
+	return mcp.NewTool("quota_headroom",
+		mcp.WithDescription(`Report the ResourceQuota usage against the hard limits with the remaining headroom per namespace, and the
+workloads whose pods would be rejected by the LimitRanges or by the quotas requiring the requests and limits`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to analyze, all namespaces with quotas or limitranges are analyzed if empty"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeWhyPendingTool creates a tool for explaining why a pod is not scheduled
+func MakeWhyPendingTool() mcp.Tool {
+	return mcp.NewTool("why_pending",
+		mcp.WithDescription(`Explain why a pod is pending, evaluates every node against the taints and tolerations, the node selector and
+affinity, the resource requests against the free allocatable resources, the pod capacity and the volume node
+affinity, and checks the claims of the pod. Returns the per-node exclusion reasons with a summary like the failure
+message of the scheduler, plus the scheduler events`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the pod"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the pod"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeWhoAmITool creates a tool for reporting the identity of the server
+func MakeWhoAmITool() mcp.Tool {
+	return mcp.NewTool("whoami",
+		mcp.WithDescription(`Report the identity the server acts as, like 'kubectl auth whoami', including the username, the effective
+groups, whether the in-cluster ServiceAccount is used and the default namespace of the namespace-scoped calls`),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeInspectAdmissionTool creates a tool for inspecting the admission webhooks and policies of a resource
+func MakeInspectAdmissionTool() mcp.Tool {
+	return mcp.NewTool("inspect_admission",
+		mcp.WithDescription(`Inspect which mutating and validating admission webhooks and ValidatingAdmissionPolicies intercept the requests
+of a kind in a namespace. Returns the webhooks in the order they are called with their failurePolicy, timeout and
+the health of the backend service endpoints, the policies with their bindings and validation actions, and the
+selectors and match conditions which depend on the object`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("The kind of the resource, e.g. Pod, deploy or the kind of a custom resource"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the requests to evaluate the namespace selectors, the selectors are reported as conditions if empty"),
+		),
+		mcp.WithString("operation",
+			mcp.Description("The operation of the requests"),
+			mcp.Enum("CREATE", "UPDATE", "DELETE", "CONNECT"),
+			mcp.DefaultString("CREATE"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeTraceRouteTool creates a tool for tracing the Gateway API route of a request
+func MakeTraceRouteTool() mcp.Tool {
+	return mcp.NewTool("trace_route",
+		mcp.WithDescription(`Trace which Gateway, HTTPRoute rule and backend services handle a request of the Gateway API. Returns the
+matching rules sorted by the precedence of the Gateway API, the first match without conditions handles the request,
+with the gateway listeners the route is attached to and the ready endpoints of the backend services`),
+		mcp.WithString("hostname",
+			mcp.Required(),
+			mcp.Description("The hostname of the request, e.g. www.example.com"),
+		),
+		mcp.WithString("path",
+			mcp.Description("The path of the request"),
+			mcp.DefaultString("/"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the HTTPRoutes, all namespaces if empty"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeTraceIngressTool creates a tool for tracing which Ingress rule handles a request.
+func MakeTraceIngressTool() mcp.Tool {
+	return mcp.NewTool("trace_ingress",
+		mcp.WithDescription(`Trace which Ingress rule handles a request. Returns the matching rules sorted by the precedence, the first
+match handles the request, with the IngressClass and controller, the load balancer addresses, the backend service and
+its ready endpoints, and the validity of the TLS certificate serving the hostname`),
+		mcp.WithString("hostname",
+			mcp.Required(),
+			mcp.Description("The hostname of the request, e.g. www.example.com"),
+		),
+		mcp.WithString("path",
+			mcp.Description("The path of the request"),
+			mcp.DefaultString("/"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the Ingresses, all namespaces if empty"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeListCRDsTool creates a tool for listing the installed CustomResourceDefinitions.
+func MakeListCRDsTool() mcp.Tool {
+	return mcp.NewTool("list_crds",
+		mcp.WithDescription(`List the installed CustomResourceDefinitions to discover the operator APIs. Returns the group, kind, scope,
+the served and storage versions, the top-level spec fields of the storage version schema and the number of the
+existing custom resources of each CRD`),
+		mcp.WithString("group",
+			mcp.Description("Only list the CRDs of the API group, e.g. cert-manager.io"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("Only list the CRD of the kind or plural resource name, e.g. Certificate"),
+		),
+		mcp.WithBoolean("includeSchema",
+			mcp.Description("Whether to summarize the top-level spec fields of the schema"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithBoolean("countResources",
+			mcp.Description("Whether to count the existing custom resources of each CRD"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeDescribeNodeTool creates a tool for describing a node in detail.
+func MakeDescribeNodeTool() mcp.Tool {
+	return mcp.NewTool("describe_node",
+		mcp.WithDescription(`Describe a node like kubectl describe node. Returns the allocatable resources with the requests and limits
+allocated by the pods on the node, the conditions such as MemoryPressure, DiskPressure and PIDPressure, the taints,
+the number of pods and images, and the freshness of the kubelet heartbeat lease`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the node"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeListNodePodsTool creates a tool for listing the pods scheduled on a node.
+func MakeListNodePodsTool() mcp.Tool {
+	return mcp.NewTool("list_node_pods",
+		mcp.WithDescription(`List all the pods scheduled on a node in all namespaces, e.g. to assess the impact before draining the node`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the node"),
+		),
+		mcp.WithBoolean("includeTerminated",
+			mcp.Description("Whether to include the succeeded and failed pods"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeWorkloadPlacementTool creates a tool for showing the distribution of the pods of a workload over the nodes.
+func MakeWorkloadPlacementTool() mcp.Tool {
+	return mcp.NewTool("workload_placement",
+		mcp.WithDescription(`Show the distribution of the pods of a workload over the nodes and zones, with the node skew and the
+warnings about the nodes or zones hosting most of the pods, e.g. to assess the blast radius of a node maintenance`),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The workload name, optionally with the kind prefix, e.g. deploy/foo, sts/foo, ds/foo"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace of the workload"),
+		),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload, it is ignored if the name has the kind prefix"),
+			mcp.Enum("Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job"),
+			mcp.DefaultString("Deployment"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeFieldManagersTool creates a tool for showing the fields owned by the field managers of a resource.
+func MakeFieldManagersTool() mcp.Tool {
+	return mcp.NewTool("field_managers",
+		mcp.WithDescription(`Show which field managers own which fields of a resource, parsed from the managed fields, and the fields shared by
+more than one manager. It explains why a server-side apply is rejected with a conflict and who would be overwritten by forcing it`),
+		mcp.WithString("kind",
+			mcp.Description("Resource type, the kubectl short names like deploy and svc are accepted. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the resource, or the kind/name reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace of the namespace-scoped resources, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("field",
+			mcp.Description("Only show the owners of the field, its parents and children, e.g. .spec.replicas or .spec.template.spec.containers"),
+		),
+		mcp.WithString("manager",
+			mcp.Description("Only show the fields of the field manager, e.g. kubectl-client-side-apply or kube-controller-manager"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeKustomizeBuildTool creates a tool for rendering a kustomization, like `kubectl kustomize <dir>`
+func MakeKustomizeBuildTool() mcp.Tool {
+	return mcp.NewTool("kustomize_build",
+		mcp.WithDescription(`Render a kustomization provided inline with its files, or from a remote git URL, like kubectl kustomize.
+The remote bases are only fetched from the git repositories of the allowed hosts, and the other remote files are rejected.
+The rendered manifests can be previewed against the cluster or applied like apply_resource`),
+		mcp.WithString("kustomization",
+			mcp.Description("The inline content of the kustomization.yaml, exclusive with target"),
+		),
+		mcp.WithObject("files",
+			mcp.Description("The files referenced by the inline kustomization keyed by the relative path, e.g. {\"deployment.yaml\": \"apiVersion: apps/v1...\"}"),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("target",
+			mcp.Description("The remote git URL of the kustomization, e.g. https://github.com/org/repo//deploy/overlays/prod?ref=v1.0"),
+		),
+		mcp.WithString("action",
+			mcp.Description("Render returns the manifests, preview returns the three-way merge against the cluster and apply applies the manifests"),
+			mcp.Enum("render", "preview", "apply"),
+			mcp.DefaultString("render"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped objects without one when previewing or applying, it must agree with the namespaces of the built objects"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeApplyFromGitTool creates a tool for applying the manifests fetched from a git repository.
+func MakeApplyFromGitTool() mcp.Tool {
+	return mcp.NewTool("apply_from_git",
+		mcp.WithDescription(`Fetch the manifests from a path of a git repository and diff or apply them against the cluster, so that the
+cluster is reconciled from the source of truth without pasting the manifests. The kustomization in the path is rendered,
+otherwise the YAML and JSON files under the path are read recursively. Only the https repositories of the allowed hosts
+are supported, including the remote bases of the kustomization, and the other remote files are rejected`),
+		mcp.WithString("repo",
+			mcp.Required(),
+			mcp.Description("The https URL of the git repository, e.g. https://github.com/org/repo.git"),
+		),
+		mcp.WithString("ref",
+			mcp.Description("The branch, tag or commit of the repository"),
+			mcp.DefaultString("HEAD"),
+		),
+		mcp.WithString("path",
+			mcp.Description("The directory of the manifests relative to the repository root"),
+		),
+		mcp.WithString("action",
+			mcp.Description("Render returns the manifests, diff returns the three-way merge against the cluster and apply applies the manifests"),
+			mcp.Enum("render", "diff", "apply"),
+			mcp.DefaultString("diff"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped objects without one when diffing or applying, it must agree with the namespaces of the manifests"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeGitOpsStatusTool creates a tool for detecting the GitOps managers of a resource.
+func MakeGitOpsStatusTool() mcp.Tool {
+	return mcp.NewTool("gitops_status",
+		mcp.WithDescription(`Detect the Argo CD Applications and the Flux Kustomizations or HelmReleases governing a resource or its owners by
+the tracking labels and annotations, with their sync and health status. Check it before changing a resource, the changes made
+out of git to a GitOps-managed resource are reverted by the reconciliations`),
+		mcp.WithString("kind",
+			mcp.Description("Resource type, the kubectl short names like deploy and svc are accepted. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the resource, or the kind/name reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace of the namespace-scoped resources, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeControllerHealthTool creates a tool for checking the health of the controllers in the operator namespaces.
+func MakeControllerHealthTool() mcp.Tool {
+	return mcp.NewTool("controller_health",
+		mcp.WithDescription(`Check the health of the Deployments, StatefulSets and DaemonSets in the operator namespaces like kube-system,
+*-system and *operator*, with the readiness, the restarts and the recent warning events. It answers whether the controllers
+of the cluster are healthy, the unhealthy ones first`),
+		mcp.WithArray("namespaces",
+			mcp.Description("The namespaces to check, defaults to kube-system, the well-known operator namespaces and the namespaces named like *-system or *operator*"),
+		),
+		mcp.WithString("since",
+			mcp.Description("The window of the recent restarts and warning events, e.g. 30m or 2h"),
+			mcp.DefaultString("1h"),
+		),
+		mcp.WithBoolean("unhealthyOnly",
+			mcp.Description("Only return the unhealthy controllers"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeControlPlaneHealthTool creates a tool for checking the health of the control plane.
+func MakeControlPlaneHealthTool() mcp.Tool {
+	return mcp.NewTool("control_plane_health",
+		mcp.WithDescription(`Check the health of the control plane component by component: the verbose livez, readyz and healthz checks of the
+apiserver including etcd, and the freshness of the leader leases of kube-scheduler and kube-controller-manager`),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeRestartLeaderboardTool creates a tool for ranking the pods by the restarts.
+func MakeRestartLeaderboardTool() mcp.Tool {
+	return mcp.NewTool("restart_leaderboard",
+		mcp.WithDescription(`Rank the pods of a namespace or the cluster by the restarts counted like kubectl get pods, with the containers in
+CrashLoopBackOff, the recent OOM kills and the reasons and exit codes of the last terminations`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the pods, all namespaces if empty"),
+		),
+		mcp.WithString("labelSelector",
+			mcp.Description("The label selector of the pods, e.g. app=nginx"),
+		),
+		mcp.WithString("since",
+			mcp.Description("The window of the recent OOM kills, e.g. 1h or 24h"),
+			mcp.DefaultString("24h"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("The maximum pods returned, 0 means no limit"),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeFindOrphansTool creates a tool for finding the likely orphaned resources of a namespace.
+func MakeFindOrphansTool() mcp.Tool {
+	return mcp.NewTool("find_orphans",
+		mcp.WithDescription(`Find the likely orphaned resources of a namespace by the references between the objects: the ConfigMaps and Secrets
+not referenced by any pod, workload template, ServiceAccount or Ingress, the Services without ready endpoints, the
+PersistentVolumeClaims not mounted by any pod and the ReplicaSets scaled to zero without an owner`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to check, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("The kinds to check, defaults to all of ConfigMap, Secret, Service, PersistentVolumeClaim and ReplicaSet"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeTeardownNamespaceTool creates a tool for deleting all resources of a namespace in the dependency order.
+func MakeTeardownNamespaceTool() mcp.Tool {
+	return mcp.NewTool("teardown_namespace",
+		mcp.WithDescription(`Delete all resources of a namespace in the dependency order: the Ingresses, routes and Services first, then the
+workloads, the pods, the custom resources, the configurations and the PersistentVolumeClaims last, and delete the
+namespace at the end. The objects owned by the controllers are left to the garbage collector, the system namespaces
+are refused. It's a dry run by default, review its objects before running it with dryRun false and confirm`),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace to tear down"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Only submit the deletions with the server-side dry run, defaults to true"),
+		),
+		mcp.WithString("confirm",
+			mcp.Description("The name of the namespace again, required to tear it down with dryRun false"),
+		),
+		mcp.WithBoolean("deleteNamespace",
+			mcp.Description("Delete the namespace after its resources, defaults to true"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeDiagnoseNamespaceTerminationTool creates a tool for diagnosing a namespace stuck Terminating.
+func MakeDiagnoseNamespaceTerminationTool() mcp.Tool {
+	return mcp.NewTool("diagnose_namespace_termination",
+		mcp.WithDescription(`Diagnose a namespace stuck Terminating: the finalizers and the deletion conditions of the namespace, the remaining
+objects with their finalizers, and the unavailable APIs which block the namespace controller`),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace stuck Terminating"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeManageFinalizersTool creates a tool for listing and removing the finalizers of an object.
+func MakeManageFinalizersTool() mcp.Tool {
+	return mcp.NewTool("manage_finalizers",
+		mcp.WithDescription(`List the finalizers of an object and whether it's being deleted, or remove a specific finalizer. Removing a
+finalizer skips the cleanup of its controller, it requires force to be set and should only be done after checking the
+controller is gone or stuck`),
+		mcp.WithString("kind",
+			mcp.Description("The type of the specified resource, the kubectl short names like deploy and svc are accepted. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the specified resource, or the kind/name reference like pvc/data"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the namespace-scoped resource, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("remove",
+			mcp.Description("The finalizer to remove, the finalizers are only listed if not set"),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Confirm the removal of the finalizer"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeFindStuckDeletionsTool creates a tool for finding the objects stuck in the deletion.
+func MakeFindStuckDeletionsTool() mcp.Tool {
+	return mcp.NewTool("find_stuck_deletions",
+		mcp.WithDescription("Find the objects marked for deletion longer than the threshold and the finalizers they wait for"),
+		mcp.WithArray("kinds",
+			mcp.Description("The kinds to check, defaults to the common workload, network, configuration and storage kinds with the Namespaces and PersistentVolumes"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to check, defaults to all namespaces"),
+		),
+		mcp.WithString("olderThan",
+			mcp.Description("The duration the objects are being deleted for, like 10m or 1h, defaults to 10m"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeRolloutStatusTool creates a tool for reporting the rollout status of the workloads.
+func MakeRolloutStatusTool() mcp.Tool {
+	return mcp.NewTool("rollout_status",
+		mcp.WithDescription(`Report the rollout status of all the Deployments, StatefulSets and DaemonSets matching the label selector
+in a namespace, like 'kubectl rollout status' for each of them. Useful to check if a release finished after applying
+multiple manifests, optionally wait until all the rollouts complete or any of them stalls`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the workloads, defaults to the namespace of the current context"),
+		),
+		mcp.WithString("labelSelector",
+			mcp.Description("The label selector of the workloads, like app.kubernetes.io/instance=my-release, defaults to all workloads"),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait until all the rollouts complete or any of them stalls"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("waitTimeoutSeconds",
+			mcp.Description("The maximum seconds to wait for the rollouts to finish, defaults to and is capped by the time left of the tool call so that the last state is returned before it times out"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeDigestEventsTool creates a tool for digesting the warning events.
+func MakeDigestEventsTool() mcp.Tool {
+	return mcp.NewTool("digest_events",
+		mcp.WithDescription(`Digest the Warning events of a time window instead of paging through the raw events. The events are
+deduplicated by the reason and the involved object with the occurrences counted, and grouped by the namespace and kind
+with the noisiest first. Optionally keep watching the new events for a few seconds before the digest`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the events, defaults to all namespaces"),
+		),
+		mcp.WithString("since",
+			mcp.Description("The time window of the events to digest, like 30m or 2h, defaults to 1h"),
+		),
+		mcp.WithNumber("watchSeconds",
+			mcp.Description("The seconds to watch the new events before the digest, bounded by the timeout of the tool"),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeSwitchTrafficTool creates a tool for switching the traffic between the workload versions.
+func MakeSwitchTrafficTool() mcp.Tool {
+	return mcp.NewTool("switch_traffic",
+		mcp.WithDescription(`Switch the traffic between two workload versions for the blue/green or canary rollouts, either by setting
+the labels of a Service selector like {"version": "green"}, or by setting the backend weights of a Gateway API HTTPRoute
+like {"app-blue": 90, "app-green": 10}. The change is a single patch guarded by the resource version, and the ready
+pods or endpoints affected by the switch are previewed, use dryRun to preview without any change`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("The kind of the object to switch"),
+			mcp.Enum("Service", "HTTPRoute"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the Service or HTTPRoute"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the Service or HTTPRoute, defaults to the namespace of the current context"),
+		),
+		mcp.WithObject("selector",
+			mcp.Description("The labels set in the Service selector, an empty value removes the label, required for Service"),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		),
+		mcp.WithObject("weights",
+			mcp.Description("The weights of the HTTPRoute backends keyed by the backend name, required for HTTPRoute"),
+			mcp.AdditionalProperties(map[string]any{"type": "integer", "minimum": 0, "maximum": 1000000}),
+		),
+		mcp.WithNumber("rule",
+			mcp.Description("The index of the HTTPRoute rule to change, defaults to all the rules referencing the backends"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Preview the affected pods or backend shares without any change"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Switch even if the new selector or backends have no ready endpoint"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeSnapshotNamespaceTool creates a tool for taking a snapshot of the manifests in a namespace.
+func MakeSnapshotNamespaceTool() mcp.Tool {
+	return mcp.NewTool("snapshot_namespace",
+		mcp.WithDescription(`Take a snapshot of the objects in a namespace as a bundle of the cleaned manifests, e.g. before a risky
+change. The status, the cluster populated metadata and the objects owned by the controllers are dropped, and the
+manifests are ordered to be restored by restore_namespace. The bundle is readable by the returned resource URI in
+the same session only`),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("The namespace to snapshot"),
+		),
+		mcp.WithString("name",
+			mcp.Description("The name of the snapshot, defaults to the namespace with the current time"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("The kinds of the objects to snapshot, e.g. [\"Deployment\", \"ConfigMap\"]. Defaults to all the namespaced kinds"),
+		),
+		mcp.WithString("labelSelector",
+			mcp.Description("The label selector of the objects to snapshot"),
+		),
+		mcp.WithBoolean("includeSecrets",
+			mcp.Description("Include the Secrets in the snapshot, the data of them are kept in the bundle. The Secrets are also included if listed in kinds"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeRestoreNamespaceTool creates a tool for restoring a namespace from a snapshot.
+func MakeRestoreNamespaceTool() mcp.Tool {
+	return mcp.NewTool("restore_namespace",
+		mcp.WithDescription(`Restore the objects of a snapshot taken by snapshot_namespace by applying its manifests, to the namespace
+the snapshot is taken from or another one. The namespace is created if not found, use preview to diff the snapshot
+against the live objects without any change`),
+		mcp.WithString("snapshot",
+			mcp.Required(),
+			mcp.Description("The name of the snapshot"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to restore to, defaults to the namespace the snapshot is taken from"),
+		),
+		mcp.WithBoolean("preview",
+			mcp.Description("Preview the three-way merge of each object like kubectl diff without any change"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCreateVolumeSnapshotTool creates a tool for creating a VolumeSnapshot of a PersistentVolumeClaim.
+func MakeCreateVolumeSnapshotTool() mcp.Tool {
+	return mcp.NewTool("create_volume_snapshot",
+		mcp.WithDescription(`Create a CSI VolumeSnapshot of a bound PersistentVolumeClaim, e.g. before an upgrade of a stateful workload.
+The snapshot class defaults to the default VolumeSnapshotClass of the CSI driver of the volume, optionally wait
+until the snapshot is ready to use`),
+		mcp.WithString("pvc",
+			mcp.Required(),
+			mcp.Description("The name of the PersistentVolumeClaim to snapshot"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the PersistentVolumeClaim, defaults to the namespace of the current context"),
+		),
+		mcp.WithString("name",
+			mcp.Description("The name of the VolumeSnapshot, defaults to the claim name with the current time"),
+		),
+		mcp.WithString("snapshotClass",
+			mcp.Description("The VolumeSnapshotClass of the snapshot, defaults to the default class of the CSI driver"),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait until the snapshot is ready to use or fails"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("waitTimeoutSeconds",
+			mcp.Description("The maximum seconds to wait for the snapshot to be ready, defaults to and is capped by the time left of the tool call so that the last state is returned before it times out"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCreatePVCFromSnapshotTool creates a tool for creating a PersistentVolumeClaim from a VolumeSnapshot.
+func MakeCreatePVCFromSnapshotTool() mcp.Tool {
+	return mcp.NewTool("create_pvc_from_snapshot",
+		mcp.WithDescription(`Create a PersistentVolumeClaim restored from a VolumeSnapshot in the same namespace. The storage class and the
+access modes default to the ones of the source claim of the snapshot, and the size defaults to the restore size`),
+		mcp.WithString("snapshot",
+			mcp.Required(),
+			mcp.Description("The name of the VolumeSnapshot"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the PersistentVolumeClaim to create"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the VolumeSnapshot and the claim, defaults to the namespace of the current context"),
+		),
+		mcp.WithString("storageClass",
+			mcp.Description("The storage class of the claim, must use the same CSI driver as the snapshot"),
+		),
+		mcp.WithString("size",
+			mcp.Description("The requested storage of the claim like 20Gi, not smaller than the restore size of the snapshot"),
+		),
+		mcp.WithArray("accessModes",
+			mcp.Description("The access modes of the claim, e.g. [\"ReadWriteOnce\"]"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCheckPodSecurityTool creates a tool for evaluating the pod specs against the Pod Security Standards
+func MakeCheckPodSecurityTool() mcp.Tool {
+	return mcp.NewTool("check_pod_security",
+		mcp.WithDescription(`Evaluate the pod specs against the Pod Security Standards levels (privileged, baseline or restricted) enforced,
+audited and warned by the PodSecurity admission labels of the namespace. Evaluates the pods and workload templates
+of the manifest if specified, otherwise the running workloads in the namespace. Lists exactly which fields of the
+pod spec violate the enforced level, and the checks failing the audit and warn levels`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace whose PodSecurity labels are used, defaults to the namespace of the manifest or the current context"),
+		),
+		mcp.WithString("manifest",
+			mcp.Description("The YAML or JSON manifest of the pods or workloads to evaluate, multiple documents are supported"),
+		),
+		mcp.WithString("level",
+			mcp.Description("Evaluate against the level instead of the enforced level of the namespace"),
+			mcp.Enum("privileged", "baseline", "restricted"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeListResourcesMultiTool creates a tool for listing resources across multiple contexts
+func MakeListResourcesMultiTool() mcp.Tool {
+	return mcp.NewTool("list_resources_multi",
+		mcp.WithDescription(`List the instances of a resource type in several kube contexts concurrently, the results are grouped per context
+with the errors of the unreachable clusters reported per context. Useful to find where a workload is running across
+the fleet of clusters`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("Resource type, the kubectl short names like deploy and svc are accepted"),
+		),
+		mcp.WithArray("contexts",
+			mcp.Description("The kube contexts to list, all contexts of the kubeconfig are listed if empty"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the resource, all namespaces are listed if empty"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Only list the objects with the name"),
+		),
+		mcp.WithString("labelSelector",
+			mcp.Description("LabelSelector (label query) to filter on, e.g. app=nginx"),
+		),
+		mcp.WithString("fieldSelector",
+			mcp.Description("FieldSelector (field query) to filter on, e.g. status.phase=Running"),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("The maximum number of the returned items per context, 0 means no limit"),
+			mcp.DefaultNumber(100),
+			mcp.Min(0),
+		),
+		mcp.WithString("jsonpath",
+			mcp.Description("A JSONPath expression like kubectl -o jsonpath to return the matched fields of each object, e.g. .spec.replicas"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeCompareResourceTool creates a tool for comparing a resource across multiple contexts
+func MakeCompareResourceTool() mcp.Tool {
+	return mcp.NewTool("compare_resource",
+		mcp.WithDescription(`Compare a resource across two or more kube contexts and return the field-level differences of each context
+against the first one as the baseline, e.g. to debug the configuration drift when it works in staging but not in
+prod. The fields populated by the cluster like the uid and resourceVersion are ignored`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("Resource type, the kubectl short names like deploy and svc are accepted"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the resource"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the resource, defaults to the namespace of the current context"),
+		),
+		mcp.WithArray("contexts",
+			mcp.Description("The kube contexts to compare, the first one is the baseline. All contexts of the kubeconfig are compared if empty"),
+		),
+		mcp.WithBoolean("includeStatus",
+			mcp.Description("Also compare the status of the resource"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeServerInfoTool creates a tool for getting the version, configuration and capabilities of the server
+func MakeServerInfoTool() mcp.Tool {
+	return mcp.NewTool("server_info",
+		mcp.WithDescription(`Get the version, configuration and capabilities of this MCP server: the enabled tools with whether they modify
+the cluster, whether the server is read-only, the current context and namespace, the version of the connected
+cluster, and the limits like the maximum result bytes, the rate limit and the timeouts of the tool calls`),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// SetDefaultsToolName is the name of the tool setting the session defaults, whose parameters are not filled by
+// the session defaults themselves.
+const SetDefaultsToolName = "set_defaults"
+
+// MakeSetDefaultsTool creates a tool for setting the defaults of the parameters in the session
+func MakeSetDefaultsTool() mcp.Tool {
+	return mcp.NewTool(SetDefaultsToolName,
+		mcp.WithDescription(`Set the defaults of the session so that they are not repeated on every call: the kube context, the namespace
+filling the namespace parameter of the tools if omitted, and the output format of the tools accepting it. Only the
+specified defaults are changed, and the current defaults are returned. Pass an empty namespace explicitly to a tool
+to list all namespaces`),
+		mcp.WithString("context",
+			mcp.Description("The kube context of the session, the kubeconfig is unchanged"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The default namespace of the session"),
+		),
+		mcp.WithString("output",
+			mcp.Description("The default output format of the tools accepting it, the tools not accepting the format use their own default"),
+			mcp.Enum(SessionOutputs...),
+		),
+		mcp.WithBoolean("reset",
+			mcp.Description("Reset all defaults of the session before setting the specified ones"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(false),
+	)
+}
+
+// MakeSetImageTool creates a tool for setting the image of a container in the pod template of a workload.
+func MakeSetImageTool() mcp.Tool {
+	return mcp.NewTool("set_image",
+		podTemplateParams(
+			mcp.WithDescription(`Set the image of a container in the pod template of a Deployment, StatefulSet, DaemonSet, ReplicaSet or CronJob
+like kubectl set image. The change is a single patch guarded by the resource version, and returns the previous and
+new image, use wait to wait for the rollout to finish`),
+			mcp.WithString("image",
+				mcp.Required(),
+				mcp.Description("The new image of the container, e.g. nginx:1.27"),
+			),
+		)...,
+	)
+}
+
+// MakeSetEnvTool creates a tool for setting the environment variables of a container in the pod template of a workload.
+func MakeSetEnvTool() mcp.Tool {
+	return mcp.NewTool("set_env",
+		podTemplateParams(
+			mcp.WithDescription(`Set or remove the environment variables of a container in the pod template of a Deployment, StatefulSet,
+DaemonSet, ReplicaSet or CronJob like kubectl set env. The variables set replace the existing ones of the same name
+including those from a ConfigMap or Secret, and the previous and new values are returned`),
+			mcp.WithObject("env",
+				mcp.Description("The environment variables to set keyed by the name, e.g. {\"LOG_LEVEL\": \"debug\"}"),
+				mcp.AdditionalProperties(map[string]any{"type": "string"}),
+			),
+			mcp.WithArray("remove",
+				mcp.Description("The names of the environment variables to remove"),
+				mcp.Items(map[string]any{"type": "string"}),
+			),
+		)...,
+	)
+}
+
+// MakeSetResourcesTool creates a tool for setting the resource requests and limits of a container in the pod
+// template of a workload.
+func MakeSetResourcesTool() mcp.Tool {
+	return mcp.NewTool("set_resources",
+		podTemplateParams(
+			mcp.WithDescription(`Set the resource requests and limits of a container in the pod template of a Deployment, StatefulSet,
+DaemonSet, ReplicaSet or CronJob like kubectl set resources. Only the specified resources are changed, an empty
+quantity removes the resource, and the previous and new quantities are returned`),
+			mcp.WithObject("requests",
+				mcp.Description("The resource requests keyed by the resource name, e.g. {\"cpu\": \"100m\", \"memory\": \"128Mi\"}"),
+				mcp.AdditionalProperties(map[string]any{"type": "string"}),
+			),
+			mcp.WithObject("limits",
+				mcp.Description("The resource limits keyed by the resource name, e.g. {\"memory\": \"256Mi\"}"),
+				mcp.AdditionalProperties(map[string]any{"type": "string"}),
+			),
+		)...,
+	)
+}
+
+// podTemplateParams returns the options of the tools changing a container in the pod template of a workload, with
+// the options of the change in between the workload and the wait parameters.
+func podTemplateParams(opts ...mcp.ToolOption) []mcp.ToolOption {
+	params := []mcp.ToolOption{
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload, optional if the name is in the form of kind/name"),
+			mcp.Enum("Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "CronJob"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the workload, or a kind/name reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the workload, defaults to the namespace of the current context"),
+		),
+		mcp.WithString("container",
+			mcp.Description("The name of the container or init container, optional if the pod has a single container"),
+		),
+	}
+	params = append(params, opts...)
+	return append(params,
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Validate the change on the server without persisting it"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait until the rollout completes or stalls, only for Deployment, StatefulSet and DaemonSet"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithNumber("waitTimeoutSeconds",
+			mcp.Description("The maximum seconds to wait for the rollout to finish, defaults to and is capped by the time left of the tool call so that the last state is returned before it times out"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakePauseWorkloadTool creates a tool for pausing a workload by scaling it to zero.
+func MakePauseWorkloadTool() mcp.Tool {
+	return mcp.NewTool("pause_workload",
+		scalableWorkloadParams(
+			mcp.WithDescription(`Pause a Deployment, StatefulSet or ReplicaSet by scaling it to zero, e.g. to save cost or isolate it during an
+incident. The previous replicas are recorded in the koffee.cola.io/paused-replicas annotation to be restored by
+resume_workload, and the HPAs of the workload are marked, they stop scaling while the workload has zero replicas`),
+		)...,
+	)
+}
+
+// MakeResumeWorkloadTool creates a tool for resuming a workload paused by pause_workload.
+func MakeResumeWorkloadTool() mcp.Tool {
+	return mcp.NewTool("resume_workload",
+		scalableWorkloadParams(
+			mcp.WithDescription(`Resume a workload paused by pause_workload by restoring the recorded replicas, kept within the bounds of its HPAs
+which resume scaling the workload. Use wait to wait for the rollout to finish`),
+			mcp.WithBoolean("wait",
+				mcp.Description("Wait until the rollout completes or stalls, only for Deployment and StatefulSet"),
+				mcp.DefaultBool(false),
+			),
+			mcp.WithNumber("waitTimeoutSeconds",
+				mcp.Description("The maximum seconds to wait for the rollout to finish, defaults to and is capped by the time left of the tool call so that the last state is returned before it times out"),
+			),
+		)...,
+	)
+}
+
+// scalableWorkloadParams returns the options of the tools pausing and resuming a workload, with the options of the
+// tool after the workload parameters.
+func scalableWorkloadParams(opts ...mcp.ToolOption) []mcp.ToolOption {
+	params := []mcp.ToolOption{
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload, optional if the name is in the form of kind/name"),
+			mcp.Enum("Deployment", "StatefulSet", "ReplicaSet"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the workload, or a kind/name reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the workload, defaults to the namespace of the current context"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Validate the change on the server without persisting it"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	}
+	return append(params, opts...)
+}
+
+// MakeEstimateCostTool creates a tool for estimating the cost of the namespaces or workloads.
+func MakeEstimateCostTool() mcp.Tool {
+	return mcp.NewTool("estimate_cost",
+		mcp.WithDescription(`Estimate the hourly and monthly cost of the namespaces or workloads from the resource requests of their running
+pods and the price of the nodes they run on. The price of a node is split between its CPU, memory and GPUs, and the
+nodes of the unpriced instance types are priced per vCPU and GiB of memory. The cost of all nodes and the idle part
+not requested by any pod are reported when all namespaces are estimated`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace to estimate, defaults to all namespaces"),
+		),
+		mcp.WithString("groupBy",
+			mcp.Description("Group the cost by the namespace or the top level workload"),
+			mcp.Enum("namespace", "workload"),
+			mcp.DefaultString("namespace"),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("The maximum groups returned, sorted by the cost descending, 0 means no limit"),
+			mcp.DefaultNumber(50),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeAttachPodTool creates a tool for attaching to the output of a running container, like `kubectl attach`.
+func MakeAttachPodTool() mcp.Tool {
+	return mcp.NewTool("attach_pod",
+		mcp.WithDescription(`Attach to the stdout and stderr of the main process of a running container for a bounded duration and return
+the captured output, like kubectl attach. Unlike run_in_container no command is run, and unlike the logs only the output
+written while attached is returned, e.g. the progress of an interactive process or the banner of a restarting container`),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload resolved to a pod, e.g. Deployment or Service, defaults to Pod. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The pod name, or the workload reference like deploy/nginx or svc/nginx resolved to a running pod"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the pod, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("container",
+			mcp.Description("The container to attach to, defaults to the default container or the only container of the pod"),
+		),
+		mcp.WithNumber("durationSeconds",
+			mcp.Description("How long to capture the output, at most 60 seconds"),
+			mcp.DefaultNumber(10),
+			mcp.Min(1),
+			mcp.Max(60),
+		),
+		mcp.WithNumber("limitBytes",
+			mcp.Description("The maximum bytes of the captured stdout and stderr each, capped by the server log bytes limit"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeGenerateManifestTool creates a tool for generating the starter manifests, like `kubectl create --dry-run=client -o yaml`.
+func MakeGenerateManifestTool() mcp.Tool {
+	return mcp.NewTool("generate_manifest",
+		mcp.WithDescription(`Generate a well-formed starter manifest of a Deployment, Service, Ingress, Job or CronJob from the high-level
+parameters like kubectl create --dry-run=client -o yaml. Nothing is created, edit the returned YAML and pass it to
+apply_resource. The objects are labeled and selected by app=<name> unless the labels are specified`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("The kind of the manifest"),
+			mcp.Enum("Deployment", "Service", "Ingress", "Job", "CronJob"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the object, also the container name of the pod template"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the object, omitted from the manifest if empty"),
+		),
+		mcp.WithString("image",
+			mcp.Description("The container image, required for Deployment, Job and CronJob"),
+		),
+		mcp.WithArray("command",
+			mcp.Description("The command of the container, defaults to the entrypoint of the image"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("port",
+			mcp.Description("The container port of Deployment, the port and target port of Service, or the backend service port of Ingress"),
+		),
+		mcp.WithNumber("replicas",
+			mcp.Description("The replicas of Deployment"),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithString("schedule",
+			mcp.Description("The cron schedule of CronJob, e.g. */5 * * * *"),
+		),
+		mcp.WithString("serviceType",
+			mcp.Description("The type of Service"),
+			mcp.Enum("ClusterIP", "NodePort", "LoadBalancer"),
+			mcp.DefaultString("ClusterIP"),
+		),
+		mcp.WithString("host",
+			mcp.Description("The host of the Ingress rule, matches all hosts if empty"),
+		),
+		mcp.WithString("path",
+			mcp.Description("The path prefix of the Ingress rule"),
+			mcp.DefaultString("/"),
+		),
+		mcp.WithString("service",
+			mcp.Description("The backend service of Ingress, defaults to the name"),
+		),
+		mcp.WithArray("labels",
+			mcp.Description("The key=value labels of the object, also the selector of Deployment and Service, defaults to app=<name>"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(false),
+	)
+}
+
+// MakeDescribeContainersTool creates a tool for summarizing the containers of a pod or workload.
+func MakeDescribeContainersTool() mcp.Tool {
+	return mcp.NewTool("describe_containers",
+		mcp.WithDescription(`Summarize the containers of a pod or the pod template of a workload compactly: the image, ports, resource
+requests and limits, probes, environment variable names and their sources, mounts and security context. The values of the
+environment variables are redacted, get the ConfigMap or Secret for them`),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload, e.g. Deployment or CronJob, defaults to Pod. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The pod name, or the workload reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the workload, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeResolveEnvTool creates a tool for resolving the environment variables of a pod or workload to their sources.
+func MakeResolveEnvTool() mcp.Tool {
+	return mcp.NewTool("resolve_env",
+		mcp.WithDescription(`Resolve the env and envFrom of the containers of a pod or the pod template of a workload to the keys of their
+ConfigMaps and Secrets, and flag the dangling references to the missing ConfigMaps, Secrets or keys which fail the containers
+to start, a frequent cause of CreateContainerConfigError and CrashLoopBackOff. The values are redacted unless revealed`),
+		mcp.WithString("kind",
+			mcp.Description("The kind of the workload, e.g. Deployment or CronJob, defaults to Pod. Optional if the name is in the form of kind/name"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The pod name, or the workload reference like deploy/nginx"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the workload, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithString("container",
+			mcp.Description("Only resolve the environment variables of the container, defaults to all the containers"),
+		),
+		mcp.WithBoolean("revealValues",
+			mcp.Description("Return the values of the environment variables including the Secret values, only if asked explicitly"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeServiceAccountUsageTool creates a tool for mapping the ServiceAccounts to the workloads and roles.
+func MakeServiceAccountUsageTool() mcp.Tool {
+	return mcp.NewTool("service_account_usage",
+		mcp.WithDescription(`Map the ServiceAccounts to the workloads running as them and the Roles and ClusterRoles bound to them by the
+RoleBindings and ClusterRoleBindings, including the bindings to the groups of the ServiceAccounts. Answers what a workload
+can do and who uses an over-privileged ServiceAccount, the ServiceAccounts bound to the roles with the wildcards, reading
+secrets, exec into pods or modifying the RBAC come first`),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the ServiceAccounts, all namespaces if empty unless the name is specified"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Only map the ServiceAccount of the name, the namespace defaults to the namespace of the context"),
+		),
+		mcp.WithBoolean("privilegedOnly",
+			mcp.Description("Only return the ServiceAccounts bound to the over-privileged roles"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithBoolean("unusedOnly",
+			mcp.Description("Only return the ServiceAccounts not used by any running pod"),
+			mcp.DefaultBool(false),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	)
+}
+
+// MakeSubjectPermissionsTool creates a tool for computing the effective RBAC permissions of a subject.
+func MakeSubjectPermissionsTool() mcp.Tool {
+	return mcp.NewTool("subject_permissions",
+		mcp.WithDescription(`Compute the effective RBAC permissions of a User, Group or ServiceAccount as a matrix of the verbs allowed on
+each resource in the namespaces, * for all the namespaces, by aggregating all the RoleBindings and ClusterRoleBindings of
+the subject and its groups. The aggregated ClusterRoles are resolved by their aggregation rules. Use it to answer what a
+subject can do`),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("The kind of the subject"),
+			mcp.Enum("User", "Group", "ServiceAccount"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name of the user, group or ServiceAccount"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("The namespace of the ServiceAccount, defaults to the namespace of the context or the ServiceAccount in cluster"),
+		),
+		mcp.WithArray("groups",
+			mcp.Description("The groups of the user whose permissions are granted to the user too, e.g. system:masters"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("scope",
+			mcp.Description("Only aggregate the RoleBindings of the namespace besides the ClusterRoleBindings, all namespaces if empty"),
+		),
 		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithDestructiveHintAnnotation(false),
 		mcp.WithIdempotentHintAnnotation(true),
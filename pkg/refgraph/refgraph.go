@@ -0,0 +1,147 @@
+// Package refgraph builds the graph of the references between the objects of a namespace, e.g. the ConfigMaps and
+// the Secrets used by the pod templates, and finds the objects referenced by nothing.
+package refgraph
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// Node is an object of the graph, the objects are in the same namespace.
+type Node struct {
+	Kind string
+	Name string
+}
+
+// String returns the node in the form of kind/name.
+func (n Node) String() string {
+	return n.Kind + "/" + n.Name
+}
+
+// Graph is the objects referencing each object.
+type Graph struct {
+	referrers map[Node]map[Node]bool
+}
+
+// New creates an empty graph.
+func New() *Graph {
+	return &Graph{referrers: make(map[Node]map[Node]bool)}
+}
+
+// AddReference adds the reference from an object to another, the empty names are ignored.
+func (g *Graph) AddReference(from, to Node) {
+	if len(to.Name) == 0 {
+		return
+	}
+	if g.referrers[to] == nil {
+		g.referrers[to] = make(map[Node]bool)
+	}
+	g.referrers[to][from] = true
+}
+
+// Referenced returns whether the object is referenced by any other.
+func (g *Graph) Referenced(n Node) bool {
+	return len(g.referrers[n]) > 0
+}
+
+// Referrers returns the objects referencing the object sorted by the kind and the name.
+func (g *Graph) Referrers(n Node) []Node {
+	referrers := make([]Node, 0, len(g.referrers[n]))
+	for referrer := range g.referrers[n] {
+		referrers = append(referrers, referrer)
+	}
+	sort.Slice(referrers, func(i, j int) bool { return referrers[i].String() < referrers[j].String() })
+	return referrers
+}
+
+// AddPodSpec adds the ConfigMaps, the Secrets, the PersistentVolumeClaims and the ServiceAccount referenced by
+// the pod spec of the owner, which is a pod or a workload with the pod template.
+func (g *Graph) AddPodSpec(owner Node, spec *corev1.PodSpec) {
+	for _, secret := range spec.ImagePullSecrets {
+		g.AddReference(owner, Node{Kind: "Secret", Name: secret.Name})
+	}
+	serviceAccount := spec.ServiceAccountName
+	if len(serviceAccount) == 0 {
+		serviceAccount = "default"
+	}
+	g.AddReference(owner, Node{Kind: "ServiceAccount", Name: serviceAccount})
+
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			g.AddReference(owner, Node{Kind: "ConfigMap", Name: volume.ConfigMap.Name})
+		case volume.Secret != nil:
+			g.AddReference(owner, Node{Kind: "Secret", Name: volume.Secret.SecretName})
+		case volume.PersistentVolumeClaim != nil:
+			g.AddReference(owner, Node{Kind: "PersistentVolumeClaim", Name: volume.PersistentVolumeClaim.ClaimName})
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					g.AddReference(owner, Node{Kind: "ConfigMap", Name: source.ConfigMap.Name})
+				}
+				if source.Secret != nil {
+					g.AddReference(owner, Node{Kind: "Secret", Name: source.Secret.Name})
+				}
+			}
+		}
+	}
+
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(append(containers, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				g.AddReference(owner, Node{Kind: "ConfigMap", Name: from.ConfigMapRef.Name})
+			}
+			if from.SecretRef != nil {
+				g.AddReference(owner, Node{Kind: "Secret", Name: from.SecretRef.Name})
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				g.AddReference(owner, Node{Kind: "ConfigMap", Name: env.ValueFrom.ConfigMapKeyRef.Name})
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				g.AddReference(owner, Node{Kind: "Secret", Name: env.ValueFrom.SecretKeyRef.Name})
+			}
+		}
+	}
+}
+
+// AddServiceAccount adds the Secrets referenced by the ServiceAccount.
+func (g *Graph) AddServiceAccount(sa *corev1.ServiceAccount) {
+	owner := Node{Kind: "ServiceAccount", Name: sa.Name}
+	for _, secret := range sa.Secrets {
+		g.AddReference(owner, Node{Kind: "Secret", Name: secret.Name})
+	}
+	for _, secret := range sa.ImagePullSecrets {
+		g.AddReference(owner, Node{Kind: "Secret", Name: secret.Name})
+	}
+}
+
+// AddIngress adds the TLS Secrets and the backend Services referenced by the Ingress.
+func (g *Graph) AddIngress(ing *networkingv1.Ingress) {
+	owner := Node{Kind: "Ingress", Name: ing.Name}
+	for _, tls := range ing.Spec.TLS {
+		g.AddReference(owner, Node{Kind: "Secret", Name: tls.SecretName})
+	}
+	addBackend := func(backend *networkingv1.IngressBackend) {
+		if backend != nil && backend.Service != nil {
+			g.AddReference(owner, Node{Kind: "Service", Name: backend.Service.Name})
+		}
+	}
+	addBackend(ing.Spec.DefaultBackend)
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			addBackend(&rule.HTTP.Paths[i].Backend)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// defaultWebhookTimeoutSeconds is the timeout of the v1 webhooks without the timeout.
+const defaultWebhookTimeoutSeconds = 10
+
+// AdmissionReport is the admission webhooks and ValidatingAdmissionPolicies intercepting the requests of a
+// resource, the webhooks are sorted in the order they are called.
+type AdmissionReport struct {
+	Kind      string         `json:"kind"`
+	Resource  string         `json:"resource"`
+	Namespace string         `json:"namespace,omitempty"`
+	Operation string         `json:"operation"`
+	Webhooks  []WebhookMatch `json:"webhooks"`
+	Policies  []PolicyMatch  `json:"policies"`
+	Warnings  []string       `json:"warnings,omitempty"`
+}
+
+// WebhookMatch is the admission webhook intercepting the requests.
+type WebhookMatch struct {
+	Type           string `json:"type"`
+	Configuration  string `json:"configuration"`
+	Name           string `json:"name"`
+	FailurePolicy  string `json:"failurePolicy"`
+	TimeoutSeconds int32  `json:"timeoutSeconds"`
+	SideEffects    string `json:"sideEffects,omitempty"`
+	Backend        string `json:"backend"`
+	ReadyEndpoints *int   `json:"readyEndpoints,omitempty"`
+	// Conditions are the selectors and the match conditions which can not be evaluated without the object, the
+	// webhook intercepts the requests only if they match.
+	Conditions []string `json:"conditions,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// PolicyMatch is the ValidatingAdmissionPolicy validating the requests through its bindings.
+type PolicyMatch struct {
+	Name          string          `json:"name"`
+	FailurePolicy string          `json:"failurePolicy"`
+	Validations   int             `json:"validations"`
+	ParamKind     string          `json:"paramKind,omitempty"`
+	Bindings      []PolicyBinding `json:"bindings"`
+	Conditions    []string        `json:"conditions,omitempty"`
+	Warnings      []string        `json:"warnings,omitempty"`
+}
+
+// PolicyBinding is the ValidatingAdmissionPolicyBinding binding the policy to the requests.
+type PolicyBinding struct {
+	Name              string   `json:"name"`
+	ValidationActions []string `json:"validationActions"`
+	ParamRef          string   `json:"paramRef,omitempty"`
+	Conditions        []string `json:"conditions,omitempty"`
+}
+
+// admissionRequest is the request matched against the admission rules, the namespace is nil if it is unknown.
+type admissionRequest struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+	namespace  *corev1.Namespace
+	operation  admissionregistrationv1.OperationType
+}
+
+// webhook is the fields shared by the mutating and the validating webhooks.
+type webhook struct {
+	kind              string
+	configuration     string
+	name              string
+	rules             []admissionregistrationv1.RuleWithOperations
+	namespaceSelector *metav1.LabelSelector
+	objectSelector    *metav1.LabelSelector
+	matchPolicy       *admissionregistrationv1.MatchPolicyType
+	failurePolicy     *admissionregistrationv1.FailurePolicyType
+	timeoutSeconds    *int32
+	sideEffects       *admissionregistrationv1.SideEffectClass
+	clientConfig      admissionregistrationv1.WebhookClientConfig
+	matchConditions   int
+}
+
+func (s *Server) InspectAdmission() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		kind = definition.ResolveKind(kind)
+		namespace := req.GetString("namespace", "")
+		operation := admissionregistrationv1.OperationType(strings.ToUpper(req.GetString("operation", string(admissionregistrationv1.Create))))
+		switch operation {
+		case admissionregistrationv1.Create, admissionregistrationv1.Update, admissionregistrationv1.Delete, admissionregistrationv1.Connect:
+		default:
+			return nil, fmt.Errorf("unsupported operation %q, must be one of CREATE, UPDATE, DELETE or CONNECT", operation)
+		}
+
+		slog.Info("Inspecting admission", "kind", kind, "namespace", namespace, "operation", operation)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		gvr, namespaced, err := lookupKindResource(discoveryClient, kind)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		report := &AdmissionReport{
+			Kind:      kind,
+			Resource:  gvr.GroupResource().String(),
+			Operation: string(operation),
+			Webhooks:  make([]WebhookMatch, 0),
+			Policies:  make([]PolicyMatch, 0),
+		}
+		request := &admissionRequest{gvr: gvr, namespaced: namespaced, operation: operation}
+		if len(namespace) > 0 {
+			if namespaced {
+				if request.namespace, err = cli.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err != nil {
+					return nil, fmt.Errorf("failed to get namespace: %w", err)
+				}
+				report.Namespace = namespace
+			} else {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s is cluster-scoped, the namespace %q is ignored", kind, namespace))
+			}
+		}
+
+		webhooks, err := listWebhooks(ctx, cli)
+		if err != nil {
+			return nil, err
+		}
+		for i := range webhooks {
+			if match, ok := s.matchWebhook(ctx, &webhooks[i], request); ok {
+				report.Webhooks = append(report.Webhooks, match)
+			}
+		}
+
+		policies, err := matchPolicies(ctx, cli, request)
+		if apierrors.IsNotFound(err) {
+			report.Warnings = append(report.Warnings, "ValidatingAdmissionPolicy is not served by the cluster")
+		} else if err != nil {
+			return nil, err
+		}
+		report.Policies = append(report.Policies, policies...)
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// listWebhooks returns the mutating webhooks followed by the validating webhooks, both sorted by the names of the
+// configurations which is the order the API server calls them.
+func listWebhooks(ctx context.Context, cli kubernetes.Interface) ([]webhook, error) {
+	mutatingConfigs, err := cli.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutatingwebhookconfigurations: %w", err)
+	}
+	validatingConfigs, err := cli.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validatingwebhookconfigurations: %w", err)
+	}
+	sort.Slice(mutatingConfigs.Items, func(i, j int) bool {
+		return mutatingConfigs.Items[i].Name < mutatingConfigs.Items[j].Name
+	})
+	sort.Slice(validatingConfigs.Items, func(i, j int) bool {
+		return validatingConfigs.Items[i].Name < validatingConfigs.Items[j].Name
+	})
+
+	var webhooks []webhook
+	for _, config := range mutatingConfigs.Items {
+		for _, w := range config.Webhooks {
+			webhooks = append(webhooks, webhook{
+				kind:              "Mutating",
+				configuration:     config.Name,
+				name:              w.Name,
+				rules:             w.Rules,
+				namespaceSelector: w.NamespaceSelector,
+				objectSelector:    w.ObjectSelector,
+				matchPolicy:       w.MatchPolicy,
+				failurePolicy:     w.FailurePolicy,
+				timeoutSeconds:    w.TimeoutSeconds,
+				sideEffects:       w.SideEffects,
+				clientConfig:      w.ClientConfig,
+				matchConditions:   len(w.MatchConditions),
+			})
+		}
+	}
+	for _, config := range validatingConfigs.Items {
+		for _, w := range config.Webhooks {
+			webhooks = append(webhooks, webhook{
+				kind:              "Validating",
+				configuration:     config.Name,
+				name:              w.Name,
+				rules:             w.Rules,
+				namespaceSelector: w.NamespaceSelector,
+				objectSelector:    w.ObjectSelector,
+				matchPolicy:       w.MatchPolicy,
+				failurePolicy:     w.FailurePolicy,
+				timeoutSeconds:    w.TimeoutSeconds,
+				sideEffects:       w.SideEffects,
+				clientConfig:      w.ClientConfig,
+				matchConditions:   len(w.MatchConditions),
+			})
+		}
+	}
+	return webhooks, nil
+}
+
+// matchWebhook returns the webhook if it intercepts the request, with the health of its backend service.
+func (s *Server) matchWebhook(ctx context.Context, w *webhook, req *admissionRequest) (WebhookMatch, bool) {
+	equivalent := ptr.Deref(w.matchPolicy, admissionregistrationv1.Equivalent) == admissionregistrationv1.Equivalent
+	matched := false
+	for _, rule := range w.rules {
+		if matchRule(rule, req, equivalent) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return WebhookMatch{}, false
+	}
+	conditions, ok := matchSelectors(w.namespaceSelector, w.objectSelector, req)
+	if !ok {
+		return WebhookMatch{}, false
+	}
+	if w.matchConditions > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d match condition(s) are evaluated against the request", w.matchConditions))
+	}
+
+	match := WebhookMatch{
+		Type:           w.kind,
+		Configuration:  w.configuration,
+		Name:           w.name,
+		FailurePolicy:  string(ptr.Deref(w.failurePolicy, admissionregistrationv1.Fail)),
+		TimeoutSeconds: ptr.Deref(w.timeoutSeconds, defaultWebhookTimeoutSeconds),
+		Conditions:     conditions,
+	}
+	if w.sideEffects != nil {
+		match.SideEffects = string(*w.sideEffects)
+	}
+	if match.TimeoutSeconds > defaultWebhookTimeoutSeconds {
+		match.Warnings = append(match.Warnings, fmt.Sprintf("the webhook may hold the requests up to %ds", match.TimeoutSeconds))
+	}
+
+	svc := w.clientConfig.Service
+	if svc == nil {
+		match.Backend = ptr.Deref(w.clientConfig.URL, "")
+		return match, true
+	}
+	match.Backend = fmt.Sprintf("service %s/%s:%d%s", svc.Namespace, svc.Name, ptr.Deref(svc.Port, 443), ptr.Deref(svc.Path, ""))
+
+	cli, err := s.builder(ctx).GetClient()
+	if err == nil {
+		var ready int
+		if _, ready, _, err = serviceEndpoints(ctx, cli, svc.Namespace, svc.Name); err == nil {
+			match.ReadyEndpoints = &ready
+		}
+	}
+	switch {
+	case err != nil:
+		match.Warnings = append(match.Warnings, fmt.Sprintf("failed to check the endpoints of the backend service: %v", err))
+	case *match.ReadyEndpoints > 0:
+	case match.FailurePolicy == string(admissionregistrationv1.Fail):
+		match.Warnings = append(match.Warnings, "the backend service has no ready endpoint, the requests are rejected because the failurePolicy is Fail")
+	default:
+		match.Warnings = append(match.Warnings, "the backend service has no ready endpoint, the calls fail and are ignored because the failurePolicy is Ignore")
+	}
+	return match, true
+}
+
+// matchPolicies returns the ValidatingAdmissionPolicies which validate the request through at least one binding.
+func matchPolicies(ctx context.Context, cli kubernetes.Interface, req *admissionRequest) ([]PolicyMatch, error) {
+	policies, err := cli.AdmissionregistrationV1().ValidatingAdmissionPolicies().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to list validatingadmissionpolicies: %w", err)
+	}
+	bindings, err := cli.AdmissionregistrationV1().ValidatingAdmissionPolicyBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validatingadmissionpolicybindings: %w", err)
+	}
+	sort.Slice(policies.Items, func(i, j int) bool {
+		return policies.Items[i].Name < policies.Items[j].Name
+	})
+
+	var matches []PolicyMatch
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		conditions, ok := matchResources(policy.Spec.MatchConstraints, req, true)
+		if !ok {
+			continue
+		}
+		match := PolicyMatch{
+			Name:          policy.Name,
+			FailurePolicy: string(ptr.Deref(policy.Spec.FailurePolicy, admissionregistrationv1.Fail)),
+			Validations:   len(policy.Spec.Validations),
+			Conditions:    conditions,
+		}
+		if kind := policy.Spec.ParamKind; kind != nil {
+			match.ParamKind = kind.APIVersion + "/" + kind.Kind
+		}
+		if n := len(policy.Spec.MatchConditions); n > 0 {
+			match.Conditions = append(match.Conditions, fmt.Sprintf("%d match condition(s) are evaluated against the request", n))
+		}
+		if typeChecking := policy.Status.TypeChecking; typeChecking != nil {
+			for _, warning := range typeChecking.ExpressionWarnings {
+				match.Warnings = append(match.Warnings, fmt.Sprintf("type checking of %s: %s", warning.FieldRef, warning.Warning))
+			}
+		}
+
+		for j := range bindings.Items {
+			binding := &bindings.Items[j]
+			if binding.Spec.PolicyName != policy.Name {
+				continue
+			}
+			conditions, ok := matchResources(binding.Spec.MatchResources, req, false)
+			if !ok {
+				continue
+			}
+			pb := PolicyBinding{Name: binding.Name, Conditions: conditions}
+			for _, action := range binding.Spec.ValidationActions {
+				pb.ValidationActions = append(pb.ValidationActions, string(action))
+			}
+			if ref := binding.Spec.ParamRef; ref != nil {
+				switch {
+				case len(ref.Name) > 0:
+					pb.ParamRef = ref.Name
+				case ref.Selector != nil:
+					pb.ParamRef = metav1.FormatLabelSelector(ref.Selector)
+				}
+				if len(ref.Namespace) > 0 {
+					pb.ParamRef = ref.Namespace + "/" + pb.ParamRef
+				}
+			}
+			match.Bindings = append(match.Bindings, pb)
+		}
+		// the policy without the bindings has no effect.
+		if len(match.Bindings) > 0 {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+// matchResources matches the request against the match resources of the policy or the binding. The resource rules
+// are required by the policy, while the binding without the resource rules matches all the requests of the policy.
+func matchResources(mr *admissionregistrationv1.MatchResources, req *admissionRequest, requireRules bool) ([]string, bool) {
+	if mr == nil {
+		return nil, !requireRules
+	}
+	equivalent := ptr.Deref(mr.MatchPolicy, admissionregistrationv1.Equivalent) == admissionregistrationv1.Equivalent
+
+	var conditions []string
+	if len(mr.ResourceRules) > 0 || requireRules {
+		matched := false
+		for _, rule := range mr.ResourceRules {
+			if !matchRule(rule.RuleWithOperations, req, equivalent) {
+				continue
+			}
+			if len(rule.ResourceNames) > 0 {
+				conditions = append(conditions, fmt.Sprintf("only the objects named %s are matched", strings.Join(rule.ResourceNames, ",")))
+			}
+			matched = true
+		}
+		if !matched {
+			return nil, false
+		}
+	}
+	for _, rule := range mr.ExcludeResourceRules {
+		if !matchRule(rule.RuleWithOperations, req, equivalent) {
+			continue
+		}
+		if len(rule.ResourceNames) == 0 {
+			return nil, false
+		}
+		conditions = append(conditions, fmt.Sprintf("the objects named %s are excluded", strings.Join(rule.ResourceNames, ",")))
+	}
+
+	selectorConditions, ok := matchSelectors(mr.NamespaceSelector, mr.ObjectSelector, req)
+	if !ok {
+		return nil, false
+	}
+	return append(conditions, selectorConditions...), true
+}
+
+// matchRule matches the request against the rule, the version is ignored with the Equivalent match policy as the
+// API server converts the request to the version of the rule.
+func matchRule(rule admissionregistrationv1.RuleWithOperations, req *admissionRequest, equivalent bool) bool {
+	matchOperation := false
+	for _, op := range rule.Operations {
+		if op == admissionregistrationv1.OperationAll || op == req.operation {
+			matchOperation = true
+			break
+		}
+	}
+	matchString := func(values []string, value string) bool {
+		for _, v := range values {
+			if v == "*" || v == value {
+				return true
+			}
+		}
+		return false
+	}
+	matchResource := false
+	for _, resource := range rule.Resources {
+		if resource == "*" || resource == "*/*" || resource == req.gvr.Resource {
+			matchResource = true
+			break
+		}
+	}
+
+	switch ptr.Deref(rule.Scope, admissionregistrationv1.AllScopes) {
+	case admissionregistrationv1.ClusterScope:
+		if req.namespaced {
+			return false
+		}
+	case admissionregistrationv1.NamespacedScope:
+		if !req.namespaced {
+			return false
+		}
+	}
+	return matchOperation && matchResource && matchString(rule.APIGroups, req.gvr.Group) &&
+		(equivalent || matchString(rule.APIVersions, req.gvr.Version))
+}
+
+// matchSelectors evaluates the namespace selector against the labels of the namespace, the selectors which can not
+// be evaluated are returned as the conditions. The cluster-scoped resources except the namespaces are never excluded
+// by the namespace selector.
+func matchSelectors(namespaceSelector, objectSelector *metav1.LabelSelector, req *admissionRequest) ([]string, bool) {
+	var conditions []string
+	if selector, err := metav1.LabelSelectorAsSelector(namespaceSelector); err != nil {
+		conditions = append(conditions, fmt.Sprintf("invalid namespaceSelector: %v", err))
+	} else if !selector.Empty() && (req.namespaced || req.gvr.Resource == "namespaces") {
+		if req.namespace == nil {
+			conditions = append(conditions, fmt.Sprintf("namespaceSelector %q is evaluated against the labels of the namespace", selector.String()))
+		} else if !selector.Matches(labels.Set(req.namespace.Labels)) {
+			return nil, false
+		}
+	}
+	if selector, err := metav1.LabelSelectorAsSelector(objectSelector); err == nil && !selector.Empty() {
+		conditions = append(conditions, fmt.Sprintf("objectSelector %q is evaluated against the labels of the object", selector.String()))
+	}
+	return conditions, true
+}
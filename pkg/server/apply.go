@@ -2,10 +2,58 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
+// applyFieldManager is the field manager of the objects applied by koffee.
+const applyFieldManager = "koffee"
+
+// AppliedObject is the result of applying an object of the manifest.
+type AppliedObject struct {
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Action    string        `json:"action"`
+	Preview   *ApplyPreview `json:"preview,omitempty"`
+}
+
+// ApplyPreview is the three-way merge of the live object, the last applied configuration and the new manifest
+// computed like kubectl diff, nothing is changed in the cluster.
+type ApplyPreview struct {
+	LastApplied map[string]any `json:"lastApplied,omitempty"`
+	Patch       map[string]any `json:"patch,omitempty"`
+	PatchType   string         `json:"patchType,omitempty"`
+	// Merged is the object after the apply returned by the server-side dry run.
+	Merged  map[string]any `json:"merged,omitempty"`
+	Changes []FieldDiff    `json:"changes"`
+	// Removed are the fields of the live object dropped by the apply, mostly the fields removed from the
+	// manifest since the last apply.
+	Removed   []string        `json:"removed"`
+	Conflicts []FieldConflict `json:"conflicts"`
+}
+
+// FieldConflict is a changed field owned by another field manager, the apply overwrites it without an error
+// unlike the server-side apply, but the other manager may revert it later.
+type FieldConflict struct {
+	Path      string `json:"path"`
+	Manager   string `json:"manager"`
+	Operation string `json:"operation"`
+}
+
 // ApplyResource returns a function that applies a resource.
 func (s *Server) ApplyResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -13,9 +61,270 @@ func (s *Server) ApplyResource() func(ctx context.Context, req mcp.CallToolReque
 		if err != nil {
 			return nil, err
 		}
+		namespace := req.GetString("namespace", "")
+		preview := req.GetBool("preview", false)
+		checkQuota := req.GetBool("checkQuota", false)
+		overrideGitOps := req.GetBool("overrideGitOps", false)
+
+		slog.Info("Applying resource", "namespace", namespace, "preview", preview, "checkQuota", checkQuota, "manifest", manifest)
+
+		results, err := s.applyManifest(ctx, manifest, namespace, preview, checkQuota, overrideGitOps)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := json.Marshal(results)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// applyManifest applies the objects of the manifest in order, the namespace-scoped objects without the namespace
+// are applied to the namespace, which must agree with the namespaces of the manifest unless it's the session
+// default. If checkQuota is set, nothing is applied if any object would be rejected by the
+// ResourceQuota or LimitRanger admission. Nothing is applied either if any object is managed by Argo CD or Flux,
+// unless overrideGitOps is set.
+func (s *Server) applyManifest(ctx context.Context, manifest, namespace string, preview, checkQuota, overrideGitOps bool) ([]AppliedObject, error) {
+	if err := checkManifestSize(manifest); err != nil {
+		return nil, err
+	}
+	objs, err := decodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no object found in the manifest")
+	}
+	for _, obj := range objs {
+		if err = sanitizeManifest(obj, createIgnoredFields); err != nil {
+			return nil, err
+		}
+	}
+	discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var checker *admissionChecker
+	if checkQuota {
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		checker = newAdmissionChecker(cli)
+	}
+
+	resources := make([]dynamic.ResourceInterface, 0, len(objs))
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		if len(gvk.Kind) == 0 || len(obj.GetName()) == 0 {
+			return nil, fmt.Errorf("the object of the manifest must have the kind and the name")
+		}
+		gvr, namespaced, err := lookupGroupKindResource(discoveryClient, gvk.GroupKind())
+		if err != nil {
+			return nil, err
+		}
+		// the resource is served in the version of the manifest rather than the preferred one.
+		gvr.Version = gvk.Version
+
+		if !namespaced {
+			obj.SetNamespace("")
+		}
+		ri, err := s.documentResource(ctx, dynamicClient, documentMapping{gvr: gvr, namespaced: namespaced}, namespace, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if !preview {
+			if err = s.checkGitOps(ctx, ri, obj.GetName(), overrideGitOps); err != nil {
+				return nil, err
+			}
+		}
+		if checker != nil {
+			live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				live = nil
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, obj.GetName(), err)
+			}
+			if err = checker.check(ctx, gvr, obj, live); err != nil {
+				return nil, err
+			}
+		}
+		resources = append(resources, ri)
+	}
+	if checker != nil {
+		if err = checker.err(); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]AppliedObject, 0, len(objs))
+	for i, obj := range objs {
+		result, err := applyObject(ctx, resources[i], obj, preview)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		if result.Preview != nil && result.Preview.Merged != nil {
+			s.scrubber.scrub(result.Preview.Merged)
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// applyObject applies the object like kubectl client-side apply, the object is created with the last applied
+// configuration if not found, otherwise patched with the three-way merge of the last applied configuration, the
+// manifest and the live object.
+func applyObject(ctx context.Context, ri dynamic.ResourceInterface, obj *unstructured.Unstructured, preview bool) (*AppliedObject, error) {
+	result := &AppliedObject{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	modified, err := modifiedConfiguration(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		result.Action = "created"
+		desired := &unstructured.Unstructured{}
+		if err = desired.UnmarshalJSON(modified); err != nil {
+			return nil, err
+		}
+		if preview {
+			result.Preview = &ApplyPreview{Merged: desired.Object, Changes: make([]FieldDiff, 0), Removed: make([]string, 0), Conflicts: make([]FieldConflict, 0)}
+			return result, nil
+		}
+		if _, err = ri.Create(ctx, desired, metav1.CreateOptions{FieldManager: applyFieldManager}); err != nil {
+			return nil, err
+		}
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	current, err := live.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	original := []byte(live.GetAnnotations()[lastAppliedConfigAnnotation])
 
-		// TODO: Implement resource application logic
+	var (
+		patch     []byte
+		patchType types.PatchType
+	)
+	if versioned, err := scheme.Scheme.New(obj.GroupVersionKind()); err == nil {
+		// the built-in types are merged with the patch strategies of the fields like kubectl.
+		lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versioned)
+		if err != nil {
+			return nil, err
+		}
+		patchType = types.StrategicMergePatchType
+		patch, err = strategicpatch.CreateThreeWayMergePatch(original, modified, current, lookupPatchMeta, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create strategic merge patch: %w", err)
+		}
+	} else {
+		patchType = types.MergePatchType
+		patch, err = jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create merge patch: %w", err)
+		}
+	}
 
-		return mcp.NewToolResultText(manifest), nil
+	if string(patch) == "{}" {
+		result.Action = "unchanged"
+	} else {
+		result.Action = "configured"
+	}
+	if !preview {
+		if result.Action == "configured" {
+			if _, err = ri.Patch(ctx, obj.GetName(), patchType, patch, metav1.PatchOptions{FieldManager: applyFieldManager}); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	merged := live
+	if result.Action == "configured" {
+		// the server-side dry run runs the defaulting and the admission without persisting the change.
+		merged, err = ri.Patch(ctx, obj.GetName(), patchType, patch, metav1.PatchOptions{FieldManager: applyFieldManager, DryRun: []string{metav1.DryRunAll}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to dry run the patch: %w", err)
+		}
+	}
+	result.Preview = newApplyPreview(live, merged, original, patch, patchType)
+	return result, nil
+}
+
+// modifiedConfiguration returns the JSON of the object with the last applied configuration annotation, which is
+// the object itself without the annotation.
+func modifiedConfiguration(obj *unstructured.Unstructured) ([]byte, error) {
+	desired := obj.DeepCopy()
+	annotations := desired.GetAnnotations()
+	delete(annotations, lastAppliedConfigAnnotation)
+	desired.SetAnnotations(annotations)
+	lastApplied, err := desired.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[lastAppliedConfigAnnotation] = strings.TrimSpace(string(lastApplied))
+	desired.SetAnnotations(annotations)
+	return desired.MarshalJSON()
+}
+
+func newApplyPreview(live, merged *unstructured.Unstructured, original, patch []byte, patchType types.PatchType) *ApplyPreview {
+	preview := &ApplyPreview{
+		PatchType: string(patchType),
+		Merged:    merged.DeepCopy().Object,
+		Changes:   make([]FieldDiff, 0),
+		Removed:   make([]string, 0),
+		Conflicts: make([]FieldConflict, 0),
+	}
+	if len(original) > 0 {
+		_ = json.Unmarshal(original, &preview.LastApplied)
+	}
+	_ = json.Unmarshal(patch, &preview.Patch)
+
+	normalize := func(obj *unstructured.Unstructured) map[string]any {
+		content := obj.DeepCopy().Object
+		for _, field := range clusterPopulatedFields {
+			unstructured.RemoveNestedField(content, field...)
+		}
+		return content
+	}
+	diffFields("", normalize(live), normalize(merged), &preview.Changes)
+	sort.Slice(preview.Changes, func(i, j int) bool { return preview.Changes[i].Path < preview.Changes[j].Path })
+	for _, change := range preview.Changes {
+		if change.Desired == nil {
+			preview.Removed = append(preview.Removed, change.Path)
+		}
+	}
+
+	for _, entry := range live.GetManagedFields() {
+		if entry.Manager == applyFieldManager || entry.FieldsV1 == nil {
+			continue
+		}
+		for _, path := range managedFieldPaths(entry.FieldsV1.Raw) {
+			for _, change := range preview.Changes {
+				if overlapsFieldPath(path, change.Path) {
+					preview.Conflicts = append(preview.Conflicts, FieldConflict{Path: path, Manager: entry.Manager, Operation: string(entry.Operation)})
+					break
+				}
+			}
+		}
+	}
+	if len(preview.Changes) > maxConflictFields {
+		preview.Changes = preview.Changes[:maxConflictFields]
 	}
+	return preview
 }
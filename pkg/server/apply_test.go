@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyManifestNamespace(t *testing.T) {
+	resources := []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"create", "get", "list", "patch"}},
+			{Name: "namespaces", Kind: "Namespace", Verbs: []string{"create", "get", "list", "patch"}},
+		},
+	}}
+	configMap := func(namespace string) string {
+		manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"
+		if len(namespace) > 0 {
+			manifest += "  namespace: " + namespace + "\n"
+		}
+		return manifest
+	}
+	sessionDefault := context.WithValue(context.Background(), sessionDefaultedKey{}, []string{"namespace"})
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		manifest  string
+		namespace string
+		want      string
+		wantError bool
+	}{
+		{name: "manifest namespace", ctx: context.Background(), manifest: configMap("prod"), want: "prod"},
+		{name: "parameter for the manifest without one", ctx: context.Background(), manifest: configMap(""), namespace: "dev", want: "dev"},
+		{name: "default namespace", ctx: context.Background(), manifest: configMap(""), want: metav1.NamespaceDefault},
+		{name: "agreeing parameter", ctx: context.Background(), manifest: configMap("prod"), namespace: "prod", want: "prod"},
+		{name: "conflicting parameter", ctx: context.Background(), manifest: configMap("prod"), namespace: "dev", wantError: true},
+		{name: "session default never overrides the manifest", ctx: sessionDefault, manifest: configMap("prod"), namespace: "dev", want: "prod"},
+		{name: "cluster-scoped", ctx: context.Background(), manifest: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n", namespace: "dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newFakeServer(resources, nil)
+			results, err := s.applyManifest(tt.ctx, tt.manifest, tt.namespace, false, false, false)
+			if tt.wantError {
+				if err == nil || !strings.Contains(err.Error(), "conflicts with the namespace parameter") {
+					t.Fatalf("got error %v, want the conflicting namespace rejected", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != 1 || results[0].Namespace != tt.want {
+				t.Fatalf("got results %+v, want applied to namespace %q", results, tt.want)
+			}
+		})
+	}
+}
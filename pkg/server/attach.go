@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	// defaultAttachDuration is the default duration of capturing the output of the attached container.
+	defaultAttachDuration = 10 * time.Second
+	// maxAttachDuration bounds the duration of the attach, the longer output is read from the logs.
+	maxAttachDuration = time.Minute
+)
+
+// PodAttach is the output of the container captured while attached.
+type PodAttach struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	Duration  string `json:"duration"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr,omitempty"`
+	// Truncated is set if the output exceeds the limit, the remaining output is dropped.
+	Truncated bool `json:"truncated,omitempty"`
+	// Ended is set if the streams are closed before the duration, e.g. the container exits.
+	Ended bool `json:"ended,omitempty"`
+}
+
+// limitedBuffer keeps the output up to the limit, the output beyond the limit is dropped.
+type limitedBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - len(b.buf); remaining < len(p) {
+		b.buf = append(b.buf, p[:max(remaining, 0)]...)
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (s *Server) AttachPod() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		kind, name, err := parseWorkloadReference(req.GetString("kind", ""), resourceName)
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		containerName := req.GetString("container", "")
+		duration := time.Duration(req.GetInt("durationSeconds", int(defaultAttachDuration.Seconds()))) * time.Second
+		if duration <= 0 || duration > maxAttachDuration {
+			return nil, fmt.Errorf("invalid durationSeconds %d, must be between 1 and %d", int(duration.Seconds()), int(maxAttachDuration.Seconds()))
+		}
+		limitBytes := int64(req.GetInt("limitBytes", 0))
+		if limitBytes <= 0 || limitBytes > s.maxLogBytes {
+			limitBytes = s.maxLogBytes
+		}
+
+		slog.Info("Attaching to container", "kind", kind, "resourceName", name, "namespace", namespace, "container", containerName, "duration", duration)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pod, err := execTargetPod(ctx, cli, namespace, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		container, err := attachContainer(pod, containerName)
+		if err != nil {
+			return nil, err
+		}
+
+		// the stderr is merged into the stdout by the terminal of the tty containers.
+		executor, err := s.createExecutor(ctx, namespace, pod.Name, "attach", &corev1.PodAttachOptions{
+			Container: container.Name,
+			Stdout:    true,
+			Stderr:    !container.TTY,
+			TTY:       container.TTY,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		stdout := &limitedBuffer{limit: int(limitBytes)}
+		stderr := &limitedBuffer{limit: int(limitBytes)}
+		streamOptions := remotecommand.StreamOptions{Stdout: stdout, Tty: container.TTY}
+		if !container.TTY {
+			streamOptions.Stderr = stderr
+		}
+		attachCtx, cancel := context.WithTimeout(ctx, duration)
+		defer cancel()
+		started := time.Now()
+		err = executor.StreamWithContext(attachCtx, streamOptions)
+		if err != nil && !errors.Is(attachCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to attach to container %s: %w", container.Name, err)
+		}
+
+		resp, err := json.Marshal(&PodAttach{
+			Pod:       pod.Name,
+			Container: container.Name,
+			Duration:  time.Since(started).Round(time.Second).String(),
+			Stdout:    string(stdout.buf),
+			Stderr:    string(stderr.buf),
+			Truncated: stdout.truncated || stderr.truncated,
+			Ended:     attachCtx.Err() == nil,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// attachContainer returns the container to attach to, the default container annotated by kubectl or the only
+// container if the name is empty.
+func attachContainer(pod *corev1.Pod, name string) (*corev1.Container, error) {
+	if len(name) == 0 {
+		name = pod.Annotations["kubectl.kubernetes.io/default-container"]
+	}
+	if len(name) == 0 {
+		if len(pod.Spec.Containers) > 1 {
+			return nil, fmt.Errorf("the container is required since pod %s has %d containers", pod.Name, len(pod.Spec.Containers))
+		}
+		return &pod.Spec.Containers[0], nil
+	}
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("container %s not found in pod %s", name, pod.Name)
+}
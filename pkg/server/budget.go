@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resultBudget truncates the text of the tool results exceeding the max bytes, so that a large result doesn't blow
+// up the context window of the client. The JSON arrays are truncated by the items, e.g. the rows of the tables,
+// and a summary of the omitted items is appended to the result.
+type resultBudget struct {
+	maxBytes int
+}
+
+func (b *resultBudget) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, req)
+		if err != nil || result == nil || result.IsError || b.maxBytes <= 0 {
+			return result, err
+		}
+
+		var summaries []mcp.Content
+		for i, content := range result.Content {
+			text, ok := content.(mcp.TextContent)
+			if !ok || len(text.Text) <= b.maxBytes {
+				continue
+			}
+			truncated, summary := truncateResult(text.Text, b.maxBytes)
+			slog.Warn("Truncated the tool result exceeding the max bytes", "tool", req.Params.Name, "bytes", len(text.Text), "maxBytes", b.maxBytes)
+			text.Text = truncated
+			result.Content[i] = text
+			summaries = append(summaries, mcp.NewTextContent(summary))
+		}
+		result.Content = append(result.Content, summaries...)
+		return result, nil
+	}
+}
+
+// truncateResult truncates the text to the max bytes and returns the summary of the omitted part. The JSON array,
+// the rows of the table or the largest array of the JSON object is truncated by the items, the other texts are
+// truncated by the bytes, and the JSON truncated by the bytes is wrapped in an object with the truncated flag.
+func truncateResult(text string, maxBytes int) (string, string) {
+	decoder := json.NewDecoder(bytes.NewBufferString(text))
+	decoder.UseNumber()
+	var value any
+	isJSON := decoder.Decode(&value) == nil && !decoder.More()
+	if isJSON {
+		var items []any
+		var wrap func([]any) any
+		switch v := value.(type) {
+		case []any:
+			items, wrap = v, func(kept []any) any { return kept }
+		case map[string]any:
+			if key := largestArrayField(v); len(key) > 0 {
+				items, wrap = v[key].([]any), func(kept []any) any {
+					v[key] = kept
+					return v
+				}
+			}
+		}
+		if items != nil {
+			n := sort.Search(len(items)+1, func(n int) bool {
+				data, err := json.Marshal(wrap(items[:n]))
+				return err != nil || len(data) > maxBytes
+			}) - 1
+			// nothing is left if the first item doesn't fit, the text is truncated by the bytes instead.
+			if n > 0 {
+				if data, err := json.Marshal(wrap(items[:n])); err == nil {
+					return string(data), fmt.Sprintf("%d of %d items are omitted to fit the result in %d bytes, narrow down the query with the selectors or page the results with maxResults and continue", len(items)-n, len(items), maxBytes)
+				}
+			}
+		}
+	}
+
+	cut := runeCut(text, maxBytes)
+	truncated := text[:cut]
+	if isJSON {
+		// the JSON cut by the bytes is invalid, the cut text is wrapped so that the result is still valid JSON.
+		cut = sort.Search(maxBytes+1, func(n int) bool {
+			data, err := json.Marshal(truncatedJSON{Truncated: true, Text: text[:runeCut(text, n)]})
+			return err != nil || len(data) > maxBytes
+		}) - 1
+		cut = runeCut(text, max(cut, 0))
+		data, _ := json.Marshal(truncatedJSON{Truncated: true, Text: text[:cut]})
+		truncated = string(data)
+	}
+	return truncated, fmt.Sprintf("%d of %d bytes are omitted to fit the result in %d bytes, narrow down the query to get the complete result", len(text)-cut, len(text), maxBytes)
+}
+
+// truncatedJSON is the envelope of the JSON result truncated by the bytes.
+type truncatedJSON struct {
+	Truncated bool   `json:"truncated"`
+	Text      string `json:"text"`
+}
+
+// runeCut returns the length of the text cut to at most n bytes without splitting a rune.
+func runeCut(text string, n int) int {
+	if n >= len(text) {
+		return len(text)
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return n
+}
+
+// truncatedArrayFields are the arrays of the items truncated first, e.g. the rows of the tables and the items of
+// the lists, the column definitions of the tables are never truncated.
+var truncatedArrayFields = []string{"rows", "items"}
+
+// largestArrayField returns the key of the top-level array truncated by the items, the rows or the items if any,
+// otherwise the array with the most items except the column definitions.
+func largestArrayField(obj map[string]any) string {
+	for _, key := range truncatedArrayFields {
+		if _, ok := obj[key].([]any); ok {
+			return key
+		}
+	}
+	var key string
+	for k, v := range obj {
+		if k == "columnDefinitions" {
+			continue
+		}
+		if items, ok := v.([]any); ok {
+			if current, _ := obj[key].([]any); len(key) == 0 || len(items) > len(current) || (len(items) == len(current) && k < key) {
+				key = k
+			}
+		}
+	}
+	return key
+}
+
+// pagingSummary returns the summary of the remaining items of the paged list, empty if the list is complete.
+func pagingSummary(continueToken string, remaining *int64) string {
+	if len(continueToken) == 0 {
+		return ""
+	}
+	if remaining != nil {
+		return fmt.Sprintf("%d more items are omitted, call again with continue=%q to get the next page", *remaining, continueToken)
+	}
+	return fmt.Sprintf("more items are omitted, call again with continue=%q to get the next page", continueToken)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTruncateResult(t *testing.T) {
+	rows := make([]map[string]any, 50)
+	for i := range rows {
+		rows[i] = map[string]any{"name": strings.Repeat("x", 20), "index": i}
+	}
+	table, _ := json.Marshal(map[string]any{"columns": []string{"name", "index"}, "rows": rows})
+	list, _ := json.Marshal(rows)
+	single, _ := json.Marshal(map[string]any{"kind": "ConfigMap", "data": map[string]any{"key": strings.Repeat("é", 200)}})
+	large, _ := json.Marshal([]any{map[string]any{"log": strings.Repeat("line\n", 100)}})
+	// the wide table has more column definitions than rows.
+	columns := make([]map[string]any, 9)
+	for i := range columns {
+		columns[i] = map[string]any{"name": fmt.Sprintf("Column%d", i), "type": "string"}
+	}
+	wideRows := make([]map[string]any, 3)
+	for i := range wideRows {
+		wideRows[i] = map[string]any{"cells": []string{strings.Repeat("y", 200)}}
+	}
+	wide, _ := json.Marshal(map[string]any{"kind": "Table", "columnDefinitions": columns, "rows": wideRows})
+
+	tests := []struct {
+		name         string
+		text         string
+		maxBytes     int
+		wantEnvelope bool
+		wantJSON     bool
+		wantSummary  string
+		// wantColumns and wantRows are the column definitions and the rows kept of the table.
+		wantColumns, wantRows int
+	}{
+		{name: "rows of the table", text: string(table), maxBytes: 512, wantJSON: true, wantSummary: "items are omitted"},
+		{name: "rows of the wide table", text: string(wide), maxBytes: 600, wantJSON: true, wantSummary: "2 of 3 items are omitted", wantColumns: 9, wantRows: 1},
+		{name: "items of the array", text: string(list), maxBytes: 512, wantJSON: true, wantSummary: "items are omitted"},
+		{name: "object without arrays", text: string(single), maxBytes: 256, wantJSON: true, wantEnvelope: true, wantSummary: "bytes are omitted"},
+		{name: "single item too large", text: string(large), maxBytes: 128, wantJSON: true, wantEnvelope: true, wantSummary: "bytes are omitted"},
+		{name: "json followed by text", text: string(list) + "\nmore", maxBytes: 512, wantSummary: "bytes are omitted"},
+		{name: "plain text", text: strings.Repeat("line\n", 200), maxBytes: 256, wantSummary: "bytes are omitted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncated, summary := truncateResult(tt.text, tt.maxBytes)
+			if len(truncated) > tt.maxBytes {
+				t.Fatalf("got %d bytes, want at most %d", len(truncated), tt.maxBytes)
+			}
+			if !strings.Contains(summary, tt.wantSummary) {
+				t.Fatalf("got summary %q, want %q", summary, tt.wantSummary)
+			}
+			if json.Valid([]byte(truncated)) != tt.wantJSON {
+				t.Fatalf("got valid JSON %t of %q, want %t", json.Valid([]byte(truncated)), truncated, tt.wantJSON)
+			}
+			if tt.wantColumns > 0 {
+				var table struct {
+					ColumnDefinitions []any `json:"columnDefinitions"`
+					Rows              []any `json:"rows"`
+				}
+				if err := json.Unmarshal([]byte(truncated), &table); err != nil {
+					t.Fatal(err)
+				}
+				if len(table.ColumnDefinitions) != tt.wantColumns || len(table.Rows) != tt.wantRows {
+					t.Fatalf("got %d columns and %d rows, want %d and %d", len(table.ColumnDefinitions), len(table.Rows), tt.wantColumns, tt.wantRows)
+				}
+			}
+			var envelope truncatedJSON
+			_ = json.Unmarshal([]byte(truncated), &envelope)
+			if envelope.Truncated != tt.wantEnvelope {
+				t.Fatalf("got envelope %t of %q, want %t", envelope.Truncated, truncated, tt.wantEnvelope)
+			}
+			if tt.wantEnvelope && (len(envelope.Text) == 0 || !strings.HasPrefix(tt.text, envelope.Text)) {
+				t.Fatalf("got the cut text %q, want a prefix of the result", envelope.Text)
+			}
+		})
+	}
+}
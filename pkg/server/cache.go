@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"k8s.io/client-go/rest"
+
+	"cola.io/koffee/pkg/client"
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+// CacheStats is the counters of the result cache since the server started.
+type CacheStats struct {
+	Entries int `json:"entries"`
+	Hits    int `json:"hits"`
+	Misses  int `json:"misses"`
+	// Stale is the number of the cached results dropped since the objects or lists they read have changed.
+	Stale    int `json:"stale"`
+	Bypassed int `json:"bypassed"`
+}
+
+type cacheEntry struct {
+	result   *mcp.CallToolResult
+	requests []client.TrackedRequest
+	expires  time.Time
+}
+
+// resultCache caches the results of the read-only idempotent tools keyed by the session, the cluster, the tool and
+// its arguments. A cached result is only returned if the resource versions of the objects and lists read by the
+// tool call are unchanged, so the repeated calls cost a metadata read per request instead of the whole tool call.
+type resultCache struct {
+	ttl        time.Duration
+	maxEntries int
+	// scope returns the scope of the cache key and the config to revalidate the cached results.
+	scope     func(ctx context.Context) (string, *rest.Config, error)
+	cacheable map[string]bool
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	stats   CacheStats
+}
+
+func newResultCache(ttl time.Duration, maxEntries int, scope func(ctx context.Context) (string, *rest.Config, error)) *resultCache {
+	return &resultCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		scope:      scope,
+		cacheable:  make(map[string]bool),
+		entries:    make(map[string]*cacheEntry),
+	}
+}
+
+// register adds the parameter bypassing the cache to the cacheable tools.
+func (c *resultCache) register(tools []server.ServerTool) []server.ServerTool {
+	for i := range tools {
+		if koffeemcp.Cacheable(tools[i].Tool) {
+			c.cacheable[tools[i].Tool.Name] = true
+			tools[i].Tool = koffeemcp.WithCacheOption(tools[i].Tool)
+		}
+	}
+	return tools
+}
+
+func (c *resultCache) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+func (c *resultCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, ok
+}
+
+// put stores the result, the expired entries are dropped and the entry expiring first is evicted if the cache is
+// full.
+func (c *resultCache) put(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var oldest string
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		} else if len(oldest) == 0 || e.expires.Before(c.entries[oldest].expires) {
+			oldest = k
+		}
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries && len(oldest) > 0 {
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = entry
+}
+
+func (c *resultCache) count(counter *int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*counter++
+}
+
+func (c *resultCache) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !c.cacheable[req.Params.Name] {
+			return next(ctx, req)
+		}
+		if req.GetBool(koffeemcp.NoCacheParam, false) {
+			c.count(&c.stats.Bypassed)
+			return next(ctx, req)
+		}
+		scope, config, err := c.scope(ctx)
+		if err != nil {
+			return next(ctx, req)
+		}
+		// the per-call options don't change the result.
+		args := maps.Clone(req.GetArguments())
+		delete(args, koffeemcp.NoCacheParam)
+		delete(args, koffeemcp.TimeoutSecondsParam)
+		delete(args, koffeemcp.RetriesParam)
+		data, err := json.Marshal(args)
+		if err != nil {
+			return next(ctx, req)
+		}
+		key := scope + "\x00" + req.Params.Name + "\x00" + string(data)
+
+		if entry, ok := c.get(key); ok {
+			unchanged, err := client.ResourceVersionsUnchanged(ctx, config, entry.requests)
+			if err == nil && unchanged {
+				c.count(&c.stats.Hits)
+				result := *entry.result
+				result.Meta = maps.Clone(result.Meta)
+				if result.Meta == nil {
+					result.Meta = make(map[string]any)
+				}
+				result.Meta["cached"] = true
+				return &result, nil
+			}
+			if err != nil {
+				slog.Warn("Failed to revalidate the cached result", "tool", req.Params.Name, "err", err)
+			}
+			c.count(&c.stats.Stale)
+		}
+
+		c.count(&c.stats.Misses)
+		ctx = client.WithVersionTracking(ctx)
+		result, err := next(ctx, req)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+		// the calls reading nothing from the cluster, e.g. the kubeconfig, can't be revalidated.
+		if requests, ok := client.TrackedRequests(ctx); ok && len(requests) > 0 {
+			cached := *result
+			cached.Meta = maps.Clone(result.Meta)
+			c.put(key, &cacheEntry{result: &cached, requests: requests, expires: time.Now().Add(c.ttl)})
+		}
+		return result, nil
+	}
+}
+
+// cacheScope returns the scope of the cached results of the session, i.e. the session with its kube context and
+// cluster, and the config to revalidate them.
+func (s *Server) cacheScope(ctx context.Context) (string, *rest.Config, error) {
+	cb := s.builder(ctx)
+	config, err := cb.LoadRESTConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	id := sessionID(ctx)
+	contextName, ok := s.sessions.context(id)
+	if !ok && !cb.InCluster() {
+		raw, err := cb.LoadRawConfig()
+		if err != nil {
+			return "", nil, err
+		}
+		contextName = raw.CurrentContext
+	}
+	return id + "\x00" + contextName + "\x00" + config.Host, config, nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// maxNameCandidates is the maximum candidates returned for a name not found.
+const maxNameCandidates = 10
+
+// NameCandidates is the result of getting an object not found by its exact name, the candidates are the names of
+// the objects which likely are the one meant, e.g. the pod name with a truncated or mistyped hash suffix.
+type NameCandidates struct {
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Candidates []string `json:"candidates"`
+	Message    string   `json:"message"`
+}
+
+// nameCandidate is a listed name with its match rank, the lower rank is the better match.
+type nameCandidate struct {
+	name string
+	rank int
+}
+
+// nameCandidates lists the objects filtered by the selectors of the options, and returns the names which start
+// with the name, contain it, or share its stem before the last dash, in the order of the match.
+func nameCandidates(ctx context.Context, ri dynamic.ResourceInterface, name string, options metav1.ListOptions) ([]string, error) {
+	list, err := ri.List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	name = strings.ToLower(name)
+	stem := name
+	if i := strings.LastIndex(name, "-"); i > 0 {
+		stem = name[:i+1]
+	}
+	var matched []nameCandidate
+	for i := range list.Items {
+		candidate := strings.ToLower(list.Items[i].GetName())
+		switch {
+		case strings.HasPrefix(candidate, name):
+			matched = append(matched, nameCandidate{name: list.Items[i].GetName(), rank: 0})
+		case strings.Contains(candidate, name):
+			matched = append(matched, nameCandidate{name: list.Items[i].GetName(), rank: 1})
+		case strings.HasPrefix(candidate, stem):
+			matched = append(matched, nameCandidate{name: list.Items[i].GetName(), rank: 2})
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].rank != matched[j].rank {
+			return matched[i].rank < matched[j].rank
+		}
+		return matched[i].name < matched[j].name
+	})
+
+	names := make([]string, 0, min(len(matched), maxNameCandidates))
+	for i := 0; i < len(matched) && i < maxNameCandidates; i++ {
+		names = append(names, matched[i].name)
+	}
+	return names, nil
+}
+
+func newNameCandidatesResult(candidates *NameCandidates) (*mcp.CallToolResult, error) {
+	if len(candidates.Candidates) == 0 {
+		return nil, fmt.Errorf("%s %q not found, and no similar names are found", candidates.Kind, candidates.Name)
+	}
+	candidates.Message = fmt.Sprintf("%s %q not found, get one of the candidates by its exact name", candidates.Kind, candidates.Name)
+	resp, err := json.Marshal(candidates)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// certScanConcurrency is the number of the namespaces scanned concurrently.
+const certScanConcurrency = 8
+
+// CertificateInfo is the summary of a certificate, the chain index is 0 for the leaf certificate.
+type CertificateInfo struct {
+	Source     string   `json:"source"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Name       string   `json:"name"`
+	ChainIndex int      `json:"chainIndex"`
+	Subject    string   `json:"subject,omitempty"`
+	Issuer     string   `json:"issuer,omitempty"`
+	DNSNames   []string `json:"dnsNames,omitempty"`
+	IPs        []string `json:"ips,omitempty"`
+	NotBefore  string   `json:"notBefore,omitempty"`
+	NotAfter   string   `json:"notAfter,omitempty"`
+	DaysLeft   int      `json:"daysLeft"`
+	Expired    bool     `json:"expired"`
+	Error      string   `json:"error,omitempty"`
+
+	notAfter time.Time
+}
+
+// CertificateReport is the expiry report of the certificates, sorted by the soonest expiry.
+type CertificateReport struct {
+	Certificates []CertificateInfo `json:"certificates"`
+	Errors       map[string]string `json:"errors,omitempty"`
+}
+
+func (s *Server) CertificateExpiryReport() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		includeAPIServer := req.GetBool("includeAPIServer", false)
+		includeChain := req.GetBool("includeChain", false)
+		withinDays := req.GetInt("expiringWithinDays", 0)
+
+		slog.Info("Generating certificate expiry report", "namespace", namespace, "includeAPIServer", includeAPIServer, "expiringWithinDays", withinDays)
+
+		report := &CertificateReport{Certificates: make([]CertificateInfo, 0), Errors: make(map[string]string)}
+		now := time.Now()
+		if err := s.scanTLSSecrets(ctx, namespace, now, report); err != nil {
+			return nil, err
+		}
+		if includeAPIServer {
+			certs, err := s.apiServerCertificates(ctx, now)
+			if err != nil {
+				report.Errors["apiserver"] = err.Error()
+			}
+			report.Certificates = append(report.Certificates, certs...)
+		}
+
+		filtered := report.Certificates[:0]
+		for _, cert := range report.Certificates {
+			if cert.ChainIndex > 0 && !includeChain {
+				continue
+			}
+			if withinDays > 0 && len(cert.Error) == 0 && cert.DaysLeft > withinDays {
+				continue
+			}
+			filtered = append(filtered, cert)
+		}
+		report.Certificates = filtered
+
+		// the unparsable certificates come first as they need attention as much as the expired ones.
+		sort.SliceStable(report.Certificates, func(i, j int) bool {
+			return report.Certificates[i].notAfter.Before(report.Certificates[j].notAfter)
+		})
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// scanTLSSecrets parses the certificates of the kubernetes.io/tls secrets, the namespaces are scanned concurrently
+// and the failed namespaces are reported as errors rather than failing the whole report.
+func (s *Server) scanTLSSecrets(ctx context.Context, namespace string, now time.Time, report *CertificateReport) error {
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		return err
+	}
+
+	namespaces := []string{namespace}
+	if len(namespace) == 0 {
+		list, err := cli.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		namespaces = make([]string, 0, len(list.Items))
+		for i := range list.Items {
+			namespaces = append(namespaces, list.Items[i].Name)
+		}
+	}
+
+	options := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)).String()}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, certScanConcurrency)
+	)
+	for _, ns := range namespaces {
+		wg.Add(1)
+		go func(ns string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			secrets, err := cli.CoreV1().Secrets(ns).List(ctx, options)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Errors[ns] = err.Error()
+				return
+			}
+			for i := range secrets.Items {
+				secret := &secrets.Items[i]
+				certs := parseCertificates(secret.Data[corev1.TLSCertKey], now)
+				for j := range certs {
+					certs[j].Source, certs[j].Namespace, certs[j].Name = "secret", secret.Namespace, secret.Name
+				}
+				report.Certificates = append(report.Certificates, certs...)
+			}
+		}(ns)
+	}
+	wg.Wait()
+	return nil
+}
+
+// apiServerCertificates returns the serving certificates of the API server, the connection skips the
+// verification because the certificates are only inspected.
+func (s *Server) apiServerCertificates(ctx context.Context, now time.Time) ([]CertificateInfo, error) {
+	config, err := s.builder(ctx).LoadRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API server host %q: %w", config.Host, err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("the API server %s is not served over https", config.Host)
+	}
+	address := u.Host
+	if len(u.Port()) == 0 {
+		address = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{InsecureSkipVerify: true, ServerName: config.TLSClientConfig.ServerName}}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the API server: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	peers := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return nil, errors.New("the API server presented no certificates")
+	}
+	certs := make([]CertificateInfo, 0, len(peers))
+	for i, cert := range peers {
+		info := certificateInfo(cert, now)
+		info.Source, info.Name, info.ChainIndex = "apiserver", address, i
+		certs = append(certs, info)
+	}
+	return certs, nil
+}
+
+// parseCertificates parses the PEM encoded certificate chain, the malformed data is reported in the error of the
+// returned certificate.
+func parseCertificates(data []byte, now time.Time) []CertificateInfo {
+	var certs []CertificateInfo
+	for rest := data; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			certs = append(certs, CertificateInfo{ChainIndex: len(certs), Error: fmt.Sprintf("failed to parse certificate: %v", err)})
+			continue
+		}
+		info := certificateInfo(cert, now)
+		info.ChainIndex = len(certs)
+		certs = append(certs, info)
+	}
+	if len(certs) == 0 {
+		certs = append(certs, CertificateInfo{Error: "no PEM encoded certificate found in " + corev1.TLSCertKey})
+	}
+	return certs
+}
+
+func certificateInfo(cert *x509.Certificate, now time.Time) CertificateInfo {
+	info := CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:  cert.NotAfter.UTC().Format(time.RFC3339),
+		DaysLeft:  int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24)),
+		Expired:   now.After(cert.NotAfter),
+		notAfter:  cert.NotAfter,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPs = append(info.IPs, ip.String())
+	}
+	return info
+}
@@ -3,10 +3,14 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 type ClusterContext struct {
@@ -25,12 +29,17 @@ func (s *Server) ListClusters() func(ctx context.Context, req mcp.CallToolReques
 			return nil, err
 		}
 
+		currentContext := cfg.CurrentContext
+		if name, ok := s.sessions.context(sessionID(ctx)); ok {
+			currentContext = name
+		}
+
 		ctxs := make([]ClusterContext, 0)
 		for name, ctx := range cfg.Contexts {
 			if ctx.Namespace == "" {
 				ctx.Namespace = "default"
 			}
-			current := name == cfg.CurrentContext
+			current := name == currentContext
 			ctxs = append(ctxs, ClusterContext{
 				Name:        name,
 				Current:     current,
@@ -51,33 +60,149 @@ func (s *Server) ListClusters() func(ctx context.Context, req mcp.CallToolReques
 
 func (s *Server) SwitchContexts() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		cfg, err := s.cb.LoadRawConfig()
+		inputContext, err := req.RequireString("name")
 		if err != nil {
 			return nil, err
 		}
 
-		inputContext, err := req.RequireString("name")
+		persist := req.GetBool("persist", false)
+
+		slog.Info("Loading contexts", "inputContext", inputContext, "persist", persist)
+
+		// the context is only selected for the session unless it is persisted, so that switching the context does
+		// not affect kubectl and the other clients sharing the kubeconfig.
+		if !persist {
+			cfg, err := s.cb.LoadRawConfig()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := cfg.Contexts[inputContext]; !ok {
+				return nil, fmt.Errorf("context %q not found in the specified kuebconfig", inputContext)
+			}
+			s.sessions.setContext(sessionID(ctx), inputContext)
+			return mcp.NewToolResultText(fmt.Sprintf("switched to context %q for this session, the kubeconfig is unchanged", inputContext)), nil
+		}
+
+		err = s.cb.ModifyConfig(func(cfg *clientcmdapi.Config) error {
+			if _, ok := cfg.Contexts[inputContext]; !ok {
+				return fmt.Errorf("context %q not found in the specified kuebconfig", inputContext)
+			}
+			cfg.CurrentContext = inputContext
+			return nil
+		})
 		if err != nil {
 			return nil, err
 		}
+		s.sessions.unsetContext(sessionID(ctx))
+		return mcp.NewToolResultText("switch cluster context successful"), nil
+	}
+}
 
-		slog.Info("Loading contexts", "inputContext", inputContext)
+func (s *Server) RenameContext() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		newName, err := req.RequireString("newName")
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Renaming context", "name", name, "newName", newName)
 
-		if _, ok := cfg.Contexts[inputContext]; !ok {
-			return nil, fmt.Errorf("context %q not found in the specified kuebconfig", inputContext)
+		err = s.cb.ModifyConfig(func(cfg *clientcmdapi.Config) error {
+			kubeContext, ok := cfg.Contexts[name]
+			if !ok {
+				return fmt.Errorf("context %q not found in the specified kubeconfig", name)
+			}
+			if _, ok = cfg.Contexts[newName]; ok {
+				return fmt.Errorf("context %q already exists in the specified kubeconfig", newName)
+			}
+			delete(cfg.Contexts, name)
+			cfg.Contexts[newName] = kubeContext
+			if cfg.CurrentContext == name {
+				cfg.CurrentContext = newName
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
+		s.sessions.renameContext(name, newName)
+		return mcp.NewToolResultText(fmt.Sprintf("context %q renamed to %q", name, newName)), nil
+	}
+}
 
-		cfg.CurrentContext = inputContext
-		if err = s.cb.WriteToFile(*cfg); err != nil {
+func (s *Server) DeleteContext() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
 			return nil, err
 		}
-		return mcp.NewToolResultText("switch cluster context successful"), nil
+		force := req.GetBool("force", false)
+
+		slog.Info("Deleting context", "name", name, "force", force)
+
+		// the cluster and user entries are kept like `kubectl config delete-context`.
+		err = s.cb.ModifyConfig(func(cfg *clientcmdapi.Config) error {
+			if _, ok := cfg.Contexts[name]; !ok {
+				return fmt.Errorf("context %q not found in the specified kubeconfig", name)
+			}
+			if cfg.CurrentContext == name {
+				if !force {
+					return fmt.Errorf("context %q is the current context, switch to another context first or set force to delete it", name)
+				}
+				cfg.CurrentContext = ""
+			}
+			delete(cfg.Contexts, name)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.sessions.clearContext(name)
+		return mcp.NewToolResultText(fmt.Sprintf("context %q deleted", name)), nil
+	}
+}
+
+func (s *Server) SetContextNamespace() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		name := req.GetString("context", "")
+		if len(name) == 0 {
+			name, _ = s.sessions.context(sessionID(ctx))
+		}
+
+		slog.Info("Setting context namespace", "context", name, "namespace", namespace)
+
+		err = s.cb.ModifyConfig(func(cfg *clientcmdapi.Config) error {
+			if len(name) == 0 {
+				if len(cfg.CurrentContext) == 0 {
+					return errors.New("no current context is set in the specified kubeconfig")
+				}
+				name = cfg.CurrentContext
+			}
+			kubeContext, ok := cfg.Contexts[name]
+			if !ok {
+				return fmt.Errorf("context %q not found in the specified kubeconfig", name)
+			}
+			kubeContext.Namespace = namespace
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("namespace of context %q set to %q", name, namespace)), nil
 	}
 }
 
 func (s *Server) GetClusterVersion() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		discoveryClient, err := s.cb.GetDiscoveryClient()
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
 		if err != nil {
 			return nil, err
 		}
@@ -94,3 +219,56 @@ func (s *Server) GetClusterVersion() func(ctx context.Context, req mcp.CallToolR
 		return mcp.NewToolResultText(string(resp)), nil
 	}
 }
+
+// Identity is the identity the server acts as, reported by the SelfSubjectReview.
+type Identity struct {
+	Username  string              `json:"username"`
+	UID       string              `json:"uid,omitempty"`
+	Groups    []string            `json:"groups,omitempty"`
+	Extra     map[string][]string `json:"extra,omitempty"`
+	InCluster bool                `json:"inCluster"`
+	Context   string              `json:"context,omitempty"`
+	// Namespace is the default namespace of the namespace-scoped calls.
+	Namespace string `json:"namespace"`
+}
+
+func (s *Server) WhoAmI() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cb := s.builder(ctx)
+		cli, err := cb.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		review, err := cli.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create selfsubjectreview, it requires kubernetes 1.28 or later: %w", err)
+		}
+
+		identity := Identity{
+			Username:  review.Status.UserInfo.Username,
+			UID:       review.Status.UserInfo.UID,
+			Groups:    review.Status.UserInfo.Groups,
+			InCluster: cb.InCluster(),
+			Namespace: s.defaultNamespace(ctx),
+		}
+		if len(review.Status.UserInfo.Extra) > 0 {
+			identity.Extra = make(map[string][]string, len(review.Status.UserInfo.Extra))
+			for key, values := range review.Status.UserInfo.Extra {
+				identity.Extra[key] = values
+			}
+		}
+		if !identity.InCluster {
+			if name, ok := s.sessions.context(sessionID(ctx)); ok {
+				identity.Context = name
+			} else if cfg, err := s.cb.LoadRawConfig(); err == nil {
+				identity.Context = cfg.CurrentContext
+			}
+		}
+
+		resp, err := json.Marshal(identity)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
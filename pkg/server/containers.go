@@ -0,0 +1,376 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// WorkloadContainers is the summary of the containers of a pod or the pod template of a workload, the values of the
+// environment variables are never returned.
+type WorkloadContainers struct {
+	Kind            string             `json:"kind"`
+	Name            string             `json:"name"`
+	Namespace       string             `json:"namespace"`
+	ServiceAccount  string             `json:"serviceAccount,omitempty"`
+	SecurityContext []string           `json:"securityContext,omitempty"`
+	Volumes         map[string]string  `json:"volumes,omitempty"`
+	InitContainers  []ContainerSummary `json:"initContainers,omitempty"`
+	Containers      []ContainerSummary `json:"containers"`
+}
+
+// ContainerSummary is the summary of a container, the probes and the security context are in the form of kubectl
+// describe.
+type ContainerSummary struct {
+	Name string `json:"name"`
+	// Sidecar is set for the init containers restarted always, which keep running with the containers.
+	Sidecar         bool              `json:"sidecar,omitempty"`
+	Image           string            `json:"image"`
+	Ports           []string          `json:"ports,omitempty"`
+	Requests        map[string]string `json:"requests,omitempty"`
+	Limits          map[string]string `json:"limits,omitempty"`
+	Liveness        string            `json:"liveness,omitempty"`
+	Readiness       string            `json:"readiness,omitempty"`
+	Startup         string            `json:"startup,omitempty"`
+	Env             []EnvSummary      `json:"env,omitempty"`
+	EnvFrom         []string          `json:"envFrom,omitempty"`
+	Mounts          []string          `json:"mounts,omitempty"`
+	SecurityContext []string          `json:"securityContext,omitempty"`
+}
+
+// EnvSummary is an environment variable of a container, the source is omitted for the literal values which are
+// redacted.
+type EnvSummary struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+}
+
+func (s *Server) DescribeContainers() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		kind := req.GetString("kind", "")
+		if len(kind) == 0 {
+			kind = "Pod"
+		}
+		kind, name, err := definition.ParseKindName(kind, resourceName)
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+
+		slog.Info("Describing containers", "kind", kind, "name", name, "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		spec, err := workloadPodSpec(ctx, cli, namespace, kind, name)
+		if err != nil {
+			return nil, err
+		}
+
+		summary := &WorkloadContainers{
+			Kind:            kind,
+			Name:            name,
+			Namespace:       namespace,
+			ServiceAccount:  spec.ServiceAccountName,
+			SecurityContext: podSecurityContextSummary(spec.SecurityContext),
+			Volumes:         volumeSummaries(spec.Volumes),
+			Containers:      make([]ContainerSummary, 0, len(spec.Containers)),
+		}
+		for i := range spec.InitContainers {
+			summary.InitContainers = append(summary.InitContainers, containerSummary(&spec.InitContainers[i]))
+		}
+		for i := range spec.Containers {
+			summary.Containers = append(summary.Containers, containerSummary(&spec.Containers[i]))
+		}
+		resp, err := json.Marshal(summary)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// workloadPodSpec returns the pod spec of the pod or the pod template of the workload.
+func workloadPodSpec(ctx context.Context, cli kubernetes.Interface, namespace, kind, name string) (*corev1.PodSpec, error) {
+	switch kind {
+	case "Pod":
+		pod, err := cli.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Pod: %w", err)
+		}
+		return &pod.Spec, nil
+	case "Deployment":
+		deploy, err := cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Deployment: %w", err)
+		}
+		return &deploy.Spec.Template.Spec, nil
+	case "StatefulSet":
+		sts, err := cli.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get StatefulSet: %w", err)
+		}
+		return &sts.Spec.Template.Spec, nil
+	case "DaemonSet":
+		ds, err := cli.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get DaemonSet: %w", err)
+		}
+		return &ds.Spec.Template.Spec, nil
+	case "ReplicaSet":
+		rs, err := cli.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ReplicaSet: %w", err)
+		}
+		return &rs.Spec.Template.Spec, nil
+	case "Job":
+		job, err := cli.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Job: %w", err)
+		}
+		return &job.Spec.Template.Spec, nil
+	case "CronJob":
+		cj, err := cli.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CronJob: %w", err)
+		}
+		return &cj.Spec.JobTemplate.Spec.Template.Spec, nil
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q, must be one of Pod, Deployment, StatefulSet, DaemonSet, ReplicaSet, Job or CronJob", kind)
+	}
+}
+
+func containerSummary(c *corev1.Container) ContainerSummary {
+	summary := ContainerSummary{
+		Name:            c.Name,
+		Sidecar:         c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways,
+		Image:           c.Image,
+		Requests:        resourceListSummary(c.Resources.Requests),
+		Limits:          resourceListSummary(c.Resources.Limits),
+		Liveness:        probeSummary(c.LivenessProbe),
+		Readiness:       probeSummary(c.ReadinessProbe),
+		Startup:         probeSummary(c.StartupProbe),
+		SecurityContext: containerSecurityContextSummary(c.SecurityContext),
+	}
+	for _, port := range c.Ports {
+		p := fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol)
+		if len(port.Name) > 0 {
+			p = port.Name + " " + p
+		}
+		summary.Ports = append(summary.Ports, p)
+	}
+	for _, env := range c.Env {
+		summary.Env = append(summary.Env, EnvSummary{Name: env.Name, Source: envSourceSummary(env.ValueFrom)})
+	}
+	for _, envFrom := range c.EnvFrom {
+		var source string
+		switch {
+		case envFrom.ConfigMapRef != nil:
+			source = "configmap " + envFrom.ConfigMapRef.Name
+		case envFrom.SecretRef != nil:
+			source = "secret " + envFrom.SecretRef.Name
+		default:
+			continue
+		}
+		if len(envFrom.Prefix) > 0 {
+			source += " prefix " + envFrom.Prefix
+		}
+		summary.EnvFrom = append(summary.EnvFrom, source)
+	}
+	for _, mount := range c.VolumeMounts {
+		m := mount.Name + " -> " + mount.MountPath
+		if len(mount.SubPath) > 0 {
+			m += " subPath " + mount.SubPath
+		}
+		if mount.ReadOnly {
+			m += " (ro)"
+		}
+		summary.Mounts = append(summary.Mounts, m)
+	}
+	return summary
+}
+
+func resourceListSummary(resources corev1.ResourceList) map[string]string {
+	if len(resources) == 0 {
+		return nil
+	}
+	summary := make(map[string]string, len(resources))
+	for name, quantity := range resources {
+		summary[string(name)] = quantity.String()
+	}
+	return summary
+}
+
+// envSourceSummary returns the source of the environment variable, empty for the literal values.
+func envSourceSummary(valueFrom *corev1.EnvVarSource) string {
+	switch {
+	case valueFrom == nil:
+		return ""
+	case valueFrom.ConfigMapKeyRef != nil:
+		return fmt.Sprintf("configmap %s key %s", valueFrom.ConfigMapKeyRef.Name, valueFrom.ConfigMapKeyRef.Key)
+	case valueFrom.SecretKeyRef != nil:
+		return fmt.Sprintf("secret %s key %s", valueFrom.SecretKeyRef.Name, valueFrom.SecretKeyRef.Key)
+	case valueFrom.FieldRef != nil:
+		return "field " + valueFrom.FieldRef.FieldPath
+	case valueFrom.ResourceFieldRef != nil:
+		return "resource " + valueFrom.ResourceFieldRef.Resource
+	default:
+		return "unknown"
+	}
+}
+
+// probeSummary returns the probe in the form of kubectl describe, e.g.
+// `http-get http://:8080/healthz delay=0s timeout=1s period=10s #success=1 #failure=3`.
+func probeSummary(probe *corev1.Probe) string {
+	if probe == nil {
+		return ""
+	}
+	var handler string
+	switch {
+	case probe.Exec != nil:
+		handler = "exec " + strings.Join(probe.Exec.Command, " ")
+	case probe.HTTPGet != nil:
+		scheme := strings.ToLower(string(probe.HTTPGet.Scheme))
+		if len(scheme) == 0 {
+			scheme = "http"
+		}
+		handler = fmt.Sprintf("http-get %s://%s:%s%s", scheme, probe.HTTPGet.Host, probe.HTTPGet.Port.String(), probe.HTTPGet.Path)
+	case probe.TCPSocket != nil:
+		handler = fmt.Sprintf("tcp-socket %s:%s", probe.TCPSocket.Host, probe.TCPSocket.Port.String())
+	case probe.GRPC != nil:
+		handler = fmt.Sprintf("grpc <pod>:%d", probe.GRPC.Port)
+		if probe.GRPC.Service != nil && len(*probe.GRPC.Service) > 0 {
+			handler += " " + *probe.GRPC.Service
+		}
+	default:
+		handler = "unknown"
+	}
+	return fmt.Sprintf("%s delay=%ds timeout=%ds period=%ds #success=%d #failure=%d", handler,
+		probe.InitialDelaySeconds, probe.TimeoutSeconds, probe.PeriodSeconds, probe.SuccessThreshold, probe.FailureThreshold)
+}
+
+// podSecurityContextSummary returns the set fields of the pod security context, e.g. runAsNonRoot=true.
+func podSecurityContextSummary(sc *corev1.PodSecurityContext) []string {
+	if sc == nil {
+		return nil
+	}
+	var fields []string
+	if sc.RunAsUser != nil {
+		fields = append(fields, fmt.Sprintf("runAsUser=%d", *sc.RunAsUser))
+	}
+	if sc.RunAsGroup != nil {
+		fields = append(fields, fmt.Sprintf("runAsGroup=%d", *sc.RunAsGroup))
+	}
+	if sc.RunAsNonRoot != nil {
+		fields = append(fields, fmt.Sprintf("runAsNonRoot=%t", *sc.RunAsNonRoot))
+	}
+	if sc.FSGroup != nil {
+		fields = append(fields, fmt.Sprintf("fsGroup=%d", *sc.FSGroup))
+	}
+	if sc.SeccompProfile != nil {
+		fields = append(fields, "seccompProfile="+string(sc.SeccompProfile.Type))
+	}
+	return fields
+}
+
+// containerSecurityContextSummary returns the set fields of the container security context, the capabilities are
+// in the form of capabilities.add=NET_ADMIN,SYS_TIME.
+func containerSecurityContextSummary(sc *corev1.SecurityContext) []string {
+	if sc == nil {
+		return nil
+	}
+	var fields []string
+	if sc.Privileged != nil {
+		fields = append(fields, fmt.Sprintf("privileged=%t", *sc.Privileged))
+	}
+	if sc.RunAsUser != nil {
+		fields = append(fields, fmt.Sprintf("runAsUser=%d", *sc.RunAsUser))
+	}
+	if sc.RunAsGroup != nil {
+		fields = append(fields, fmt.Sprintf("runAsGroup=%d", *sc.RunAsGroup))
+	}
+	if sc.RunAsNonRoot != nil {
+		fields = append(fields, fmt.Sprintf("runAsNonRoot=%t", *sc.RunAsNonRoot))
+	}
+	if sc.ReadOnlyRootFilesystem != nil {
+		fields = append(fields, fmt.Sprintf("readOnlyRootFilesystem=%t", *sc.ReadOnlyRootFilesystem))
+	}
+	if sc.AllowPrivilegeEscalation != nil {
+		fields = append(fields, fmt.Sprintf("allowPrivilegeEscalation=%t", *sc.AllowPrivilegeEscalation))
+	}
+	if sc.Capabilities != nil {
+		for field, capabilities := range map[string][]corev1.Capability{"add": sc.Capabilities.Add, "drop": sc.Capabilities.Drop} {
+			if len(capabilities) == 0 {
+				continue
+			}
+			names := make([]string, 0, len(capabilities))
+			for _, capability := range capabilities {
+				names = append(names, string(capability))
+			}
+			fields = append(fields, "capabilities."+field+"="+strings.Join(names, ","))
+		}
+	}
+	if sc.SeccompProfile != nil {
+		fields = append(fields, "seccompProfile="+string(sc.SeccompProfile.Type))
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// volumeSummaries returns the sources of the volumes by their names, e.g. `configmap nginx-conf` or `pvc data-0`.
+func volumeSummaries(volumes []corev1.Volume) map[string]string {
+	if len(volumes) == 0 {
+		return nil
+	}
+	summaries := make(map[string]string, len(volumes))
+	for _, volume := range volumes {
+		var source string
+		switch {
+		case volume.ConfigMap != nil:
+			source = "configmap " + volume.ConfigMap.Name
+		case volume.Secret != nil:
+			source = "secret " + volume.Secret.SecretName
+		case volume.PersistentVolumeClaim != nil:
+			source = "pvc " + volume.PersistentVolumeClaim.ClaimName
+		case volume.EmptyDir != nil:
+			source = "emptydir"
+			if volume.EmptyDir.Medium == corev1.StorageMediumMemory {
+				source += " memory"
+			}
+		case volume.HostPath != nil:
+			source = "hostpath " + volume.HostPath.Path
+		case volume.Projected != nil:
+			source = "projected"
+		case volume.DownwardAPI != nil:
+			source = "downwardapi"
+		case volume.Ephemeral != nil:
+			source = "ephemeral"
+		case volume.CSI != nil:
+			source = "csi " + volume.CSI.Driver
+		case volume.NFS != nil:
+			source = "nfs " + volume.NFS.Server + ":" + volume.NFS.Path
+		default:
+			source = "other"
+		}
+		summaries[volume.Name] = source
+	}
+	return summaries
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxControllerEvents is the maximum number of the recent warning events returned for a controller.
+const maxControllerEvents = 5
+
+// operatorNamespaces are the well-known namespaces of the controllers besides kube-system and the namespaces named
+// like *-system or *operator*.
+var operatorNamespaces = []string{metav1.NamespaceSystem, "cert-manager", "ingress-nginx", "argocd", "monitoring"}
+
+// ControllerHealth is the health of the controllers in the operator namespaces, the unhealthy ones first.
+type ControllerHealth struct {
+	Namespaces  []string           `json:"namespaces"`
+	Healthy     int                `json:"healthy"`
+	Unhealthy   int                `json:"unhealthy"`
+	Controllers []ControllerStatus `json:"controllers"`
+}
+
+// ControllerStatus is the readiness, the restarts and the recent warning events of a controller.
+type ControllerStatus struct {
+	Kind      string         `json:"kind"`
+	Namespace string         `json:"namespace"`
+	Name      string         `json:"name"`
+	Ready     string         `json:"ready"`
+	Restarts  int32          `json:"restarts"`
+	Healthy   bool           `json:"healthy"`
+	Issues    []string       `json:"issues,omitempty"`
+	Events    []EventSummary `json:"events,omitempty"`
+}
+
+// controllerWorkload is the fields of the Deployments, StatefulSets and DaemonSets checked for the health.
+type controllerWorkload struct {
+	kind     string
+	name     string
+	selector *metav1.LabelSelector
+	desired  int32
+	ready    int32
+	updated  int32
+	// stalled is the message of the Deployment which failed to progress.
+	stalled string
+}
+
+func (s *Server) ControllerHealth() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespaces := req.GetStringSlice("namespaces", nil)
+		unhealthyOnly := req.GetBool("unhealthyOnly", false)
+		since, err := time.ParseDuration(req.GetString("since", "1h"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+
+		slog.Info("Checking controller health", "namespaces", namespaces, "since", since, "unhealthyOnly", unhealthyOnly)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		if len(namespaces) == 0 {
+			if namespaces, err = discoverOperatorNamespaces(ctx, cli); err != nil {
+				return nil, err
+			}
+		}
+
+		health := &ControllerHealth{Namespaces: namespaces, Controllers: make([]ControllerStatus, 0)}
+		cutoff := time.Now().Add(-since)
+		for _, namespace := range namespaces {
+			statuses, err := namespaceControllers(ctx, cli, namespace, cutoff)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check controllers in namespace %s: %w", namespace, err)
+			}
+			for _, status := range statuses {
+				if status.Healthy {
+					health.Healthy++
+				} else {
+					health.Unhealthy++
+				}
+				if !unhealthyOnly || !status.Healthy {
+					health.Controllers = append(health.Controllers, status)
+				}
+			}
+		}
+		sort.SliceStable(health.Controllers, func(i, j int) bool {
+			return !health.Controllers[i].Healthy && health.Controllers[j].Healthy
+		})
+
+		resp, err := json.Marshal(health)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// discoverOperatorNamespaces returns the existing namespaces which are well-known or named like the namespaces of
+// the operators.
+func discoverOperatorNamespaces(ctx context.Context, cli kubernetes.Interface) ([]string, error) {
+	list, err := cli.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	namespaces := make([]string, 0)
+	for _, ns := range list.Items {
+		if slices.Contains(operatorNamespaces, ns.Name) || strings.HasSuffix(ns.Name, "-system") || strings.Contains(ns.Name, "operator") {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// namespaceControllers returns the status of the controllers in the namespace, the pods and the warning events of
+// the namespace are listed once and matched to the controllers.
+func namespaceControllers(ctx context.Context, cli kubernetes.Interface, namespace string, cutoff time.Time) ([]ControllerStatus, error) {
+	workloads, err := listControllerWorkloads(ctx, cli, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(workloads) == 0 {
+		return nil, nil
+	}
+	pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	events, err := cli.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=" + corev1.EventTypeWarning})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	sort.Slice(events.Items, func(i, j int) bool {
+		return eventTime(&events.Items[j]).Before(eventTime(&events.Items[i]))
+	})
+
+	statuses := make([]ControllerStatus, 0, len(workloads))
+	for _, w := range workloads {
+		status := ControllerStatus{
+			Kind:      w.kind,
+			Namespace: namespace,
+			Name:      w.name,
+			Ready:     fmt.Sprintf("%d/%d", w.ready, w.desired),
+		}
+		if w.ready < w.desired {
+			status.Issues = append(status.Issues, fmt.Sprintf("%d of %d replicas are not ready", w.desired-w.ready, w.desired))
+		}
+		if w.updated < w.desired {
+			status.Issues = append(status.Issues, fmt.Sprintf("%d of %d replicas are not updated", w.desired-w.updated, w.desired))
+		}
+		if len(w.stalled) > 0 {
+			status.Issues = append(status.Issues, w.stalled)
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(w.selector)
+		if err != nil {
+			selector = labels.Nothing()
+		}
+		involved := map[string]bool{w.name: true}
+		var recentRestarts int32
+		waiting := make(map[string]bool)
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			involved[pod.Name] = true
+			for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+				status.Restarts += cs.RestartCount
+				if t := cs.LastTerminationState.Terminated; t != nil && t.FinishedAt.After(cutoff) {
+					recentRestarts++
+				}
+				if state := cs.State.Waiting; state != nil && len(state.Reason) > 0 && state.Reason != "ContainerCreating" && state.Reason != "PodInitializing" {
+					waiting[state.Reason] = true
+				}
+			}
+		}
+		if recentRestarts > 0 {
+			status.Issues = append(status.Issues, fmt.Sprintf("%d container(s) restarted since %s", recentRestarts, cutoff.UTC().Format(time.RFC3339)))
+		}
+		reasons := make([]string, 0, len(waiting))
+		for reason := range waiting {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			status.Issues = append(status.Issues, fmt.Sprintf("container(s) waiting with %s", reason))
+		}
+
+		for i := range events.Items {
+			event := &events.Items[i]
+			if len(status.Events) >= maxControllerEvents || eventTime(event).Before(cutoff) {
+				break
+			}
+			// the events of the ReplicaSets are matched by the name prefix of the Deployment.
+			name := event.InvolvedObject.Name
+			if !involved[name] && !(w.kind == "Deployment" && strings.HasPrefix(name, w.name+"-") && event.InvolvedObject.Kind == "ReplicaSet") {
+				continue
+			}
+			status.Events = append(status.Events, EventSummary{
+				Type:     event.Type,
+				Reason:   event.Reason,
+				Message:  fmt.Sprintf("%s/%s: %s", event.InvolvedObject.Kind, name, event.Message),
+				Count:    event.Count,
+				LastSeen: eventTime(event).UTC().Format(time.RFC3339),
+			})
+		}
+		status.Healthy = len(status.Issues) == 0
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func listControllerWorkloads(ctx context.Context, cli kubernetes.Interface, namespace string) ([]controllerWorkload, error) {
+	workloads := make([]controllerWorkload, 0)
+	deployments, err := cli.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		w := controllerWorkload{kind: "Deployment", name: d.Name, selector: d.Spec.Selector, desired: 1, ready: d.Status.ReadyReplicas, updated: d.Status.UpdatedReplicas}
+		if d.Spec.Replicas != nil {
+			w.desired = *d.Spec.Replicas
+		}
+		for _, c := range d.Status.Conditions {
+			if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse {
+				w.stalled = fmt.Sprintf("rollout is stalled: %s", c.Message)
+			}
+		}
+		workloads = append(workloads, w)
+	}
+
+	statefulSets, err := cli.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, sts := range statefulSets.Items {
+		w := controllerWorkload{kind: "StatefulSet", name: sts.Name, selector: sts.Spec.Selector, desired: 1, ready: sts.Status.ReadyReplicas, updated: sts.Status.UpdatedReplicas}
+		if sts.Spec.Replicas != nil {
+			w.desired = *sts.Spec.Replicas
+		}
+		workloads = append(workloads, w)
+	}
+
+	daemonSets, err := cli.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		workloads = append(workloads, controllerWorkload{
+			kind:     "DaemonSet",
+			name:     ds.Name,
+			selector: ds.Spec.Selector,
+			desired:  ds.Status.DesiredNumberScheduled,
+			ready:    ds.Status.NumberReady,
+			updated:  ds.Status.UpdatedNumberScheduled,
+		})
+	}
+	return workloads, nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// defaultLeaderLeaseDuration is the default lease duration of the leader election of the control plane components.
+const defaultLeaderLeaseDuration = int32(15)
+
+// healthEndpoints are the verbose health endpoints of the apiserver, the healthz is deprecated but still served.
+var healthEndpoints = []string{"/livez", "/readyz", "/healthz"}
+
+// leaderComponents are the control plane components electing the leader with the leases in kube-system.
+var leaderComponents = []string{"kube-scheduler", "kube-controller-manager"}
+
+// ControlPlaneHealth is the health of the apiserver checks and the leader leases of the control plane components.
+type ControlPlaneHealth struct {
+	Healthy   bool             `json:"healthy"`
+	Endpoints []HealthEndpoint `json:"endpoints"`
+	Leaders   []LeaderLease    `json:"leaders"`
+	Warnings  []string         `json:"warnings,omitempty"`
+}
+
+// HealthEndpoint is the result of a verbose health endpoint of the apiserver.
+type HealthEndpoint struct {
+	Path    string        `json:"path"`
+	Healthy bool          `json:"healthy"`
+	Checks  []HealthCheck `json:"checks,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// HealthCheck is a check of the health endpoint, e.g. etcd or poststarthook/start-informers.
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// LeaderLease is the leader election lease of a control plane component, it's stale if the leader doesn't renew
+// it within the lease duration.
+type LeaderLease struct {
+	Component string `json:"component"`
+	Found     bool   `json:"found"`
+	Holder    string `json:"holder,omitempty"`
+	*NodeLease
+}
+
+func (s *Server) ControlPlaneHealth() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slog.Info("Checking control plane health")
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		health := &ControlPlaneHealth{Healthy: true, Endpoints: make([]HealthEndpoint, 0, len(healthEndpoints)), Leaders: make([]LeaderLease, 0, len(leaderComponents))}
+		for _, path := range healthEndpoints {
+			endpoint := checkHealthEndpoint(ctx, cli, path)
+			if !endpoint.Healthy {
+				health.Healthy = false
+				for _, check := range endpoint.Checks {
+					if !check.Healthy {
+						health.Warnings = append(health.Warnings, fmt.Sprintf("%s check %s failed: %s", path, check.Name, check.Message))
+					}
+				}
+				if len(endpoint.Error) > 0 {
+					health.Warnings = append(health.Warnings, fmt.Sprintf("%s failed: %s", path, endpoint.Error))
+				}
+			}
+			health.Endpoints = append(health.Endpoints, endpoint)
+		}
+
+		now := time.Now()
+		for _, component := range leaderComponents {
+			leader := LeaderLease{Component: component}
+			lease, err := cli.CoordinationV1().Leases(metav1.NamespaceSystem).Get(ctx, component, metav1.GetOptions{})
+			switch {
+			case apierrors.IsNotFound(err) || apierrors.IsForbidden(err):
+				// the managed control planes may hide the leases of the components.
+				health.Warnings = append(health.Warnings, fmt.Sprintf("the leader lease of %s is not visible, the control plane may be managed by the provider", component))
+			case err != nil:
+				return nil, fmt.Errorf("failed to get lease %s: %w", component, err)
+			default:
+				leader.Found = true
+				leader.Holder = ptr.Deref(lease.Spec.HolderIdentity, "")
+				leader.NodeLease = nodeLease(lease.Spec.RenewTime, ptr.To(ptr.Deref(lease.Spec.LeaseDurationSeconds, defaultLeaderLeaseDuration)), now)
+				if leader.Stale {
+					health.Healthy = false
+					health.Warnings = append(health.Warnings, fmt.Sprintf("the leader lease of %s held by %q is not renewed for %ds, no instance may be running", component, leader.Holder, leader.SecondsSince))
+				}
+			}
+			health.Leaders = append(health.Leaders, leader)
+		}
+
+		resp, err := json.Marshal(health)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// checkHealthEndpoint queries the verbose health endpoint, which returns a line of each check like "[+]ping ok" or
+// "[-]etcd failed: reason withheld", the body is returned with the status 500 if any check fails.
+func checkHealthEndpoint(ctx context.Context, cli kubernetes.Interface, path string) HealthEndpoint {
+	endpoint := HealthEndpoint{Path: path}
+	body, err := cli.Discovery().RESTClient().Get().AbsPath(path).Param("verbose", "true").DoRaw(ctx)
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		var check HealthCheck
+		switch {
+		case strings.HasPrefix(line, "[+]"):
+			check = HealthCheck{Name: strings.TrimSuffix(strings.TrimPrefix(line, "[+]"), " ok"), Healthy: true}
+		case strings.HasPrefix(line, "[-]"):
+			name, message, _ := strings.Cut(strings.TrimPrefix(line, "[-]"), " failed")
+			check = HealthCheck{Name: name, Message: strings.TrimSpace(strings.TrimPrefix(message, ":"))}
+		default:
+			continue
+		}
+		endpoint.Checks = append(endpoint.Checks, check)
+	}
+	endpoint.Healthy = err == nil
+	if err != nil && len(endpoint.Checks) == 0 {
+		endpoint.Error = err.Error()
+	}
+	return endpoint
+}
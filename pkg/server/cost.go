@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// hoursPerMonth is the average hours of a month used to estimate the monthly cost.
+	hoursPerMonth = 730
+	// defaultCostMaxResults is the default maximum items of the cost report.
+	defaultCostMaxResults = 50
+	// gpuResource is the extended resource of the NVIDIA GPUs priced by the price table.
+	gpuResource corev1.ResourceName = "nvidia.com/gpu"
+)
+
+// NodePricer prices the nodes of the cluster, e.g. by a static price table or a cloud pricing API, so that the
+// cost of the pods is estimated from the price of the nodes they run on.
+type NodePricer interface {
+	// NodePrice returns the hourly price of the node, false if the node is not priced.
+	NodePrice(ctx context.Context, node *corev1.Node) (float64, bool)
+	// Prices returns the hourly prices of the resources, which split the price of the node between its resources
+	// and price the pods on the unpriced nodes.
+	Prices() ResourcePrices
+}
+
+// ResourcePrices is the hourly prices of a vCPU, a GiB of memory and a GPU.
+type ResourcePrices struct {
+	Currency string  `json:"currency,omitempty"`
+	CPU      float64 `json:"cpu"`
+	Memory   float64 `json:"memory"`
+	GPU      float64 `json:"gpu,omitempty"`
+}
+
+// PriceTable is the static prices of the nodes loaded from a YAML file, e.g.
+//
+//	currency: USD
+//	cpu: 0.031611
+//	memory: 0.004237
+//	gpu: 0.95
+//	instanceTypes:
+//	  m5.large: 0.096
+//	  m5.xlarge: 0.192
+//
+// The instance types are matched by the node.kubernetes.io/instance-type label of the nodes.
+type PriceTable struct {
+	ResourcePrices
+	InstanceTypes map[string]float64 `json:"instanceTypes,omitempty"`
+}
+
+// DefaultPriceTable is the price table used if none is configured, the prices of a vCPU and a GiB of memory are
+// the typical on-demand prices of the public clouds.
+var DefaultPriceTable = &PriceTable{ResourcePrices: ResourcePrices{Currency: "USD", CPU: 0.031611, Memory: 0.004237, GPU: 0.95}}
+
+// LoadPriceTable loads and validates the price table from the YAML file.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	table := &PriceTable{}
+	if err = yaml.UnmarshalStrict(data, table); err != nil {
+		return nil, fmt.Errorf("failed to parse price table: %w", err)
+	}
+	if table.CPU <= 0 || table.Memory <= 0 {
+		return nil, fmt.Errorf("the cpu and memory prices of the price table must be positive")
+	}
+	if table.GPU < 0 {
+		return nil, fmt.Errorf("the gpu price of the price table must not be negative")
+	}
+	for instanceType, price := range table.InstanceTypes {
+		if price < 0 {
+			return nil, fmt.Errorf("the price of instance type %q must not be negative", instanceType)
+		}
+	}
+	if len(table.Currency) == 0 {
+		table.Currency = DefaultPriceTable.Currency
+	}
+	return table, nil
+}
+
+func (t *PriceTable) NodePrice(_ context.Context, node *corev1.Node) (float64, bool) {
+	price, ok := t.InstanceTypes[node.Labels[corev1.LabelInstanceTypeStable]]
+	return price, ok
+}
+
+func (t *PriceTable) Prices() ResourcePrices {
+	return t.ResourcePrices
+}
+
+// CostReport is the estimated cost of the running pods from their requests, grouped by the namespace or workload
+// and sorted by the cost descending.
+type CostReport struct {
+	Currency  string       `json:"currency"`
+	Namespace string       `json:"namespace,omitempty"`
+	GroupBy   string       `json:"groupBy"`
+	Hourly    float64      `json:"hourly"`
+	Monthly   float64      `json:"monthly"`
+	Cluster   *ClusterCost `json:"cluster,omitempty"`
+	Items     []CostItem   `json:"items"`
+	// Omitted is the number of the items beyond maxResults, which are still included in the totals.
+	Omitted int `json:"omitted,omitempty"`
+	// UnpricedNodes are the nodes priced by the resource prices since their instance types are not priced.
+	UnpricedNodes []string `json:"unpricedNodes,omitempty"`
+}
+
+// ClusterCost is the cost of all nodes, the idle cost is the part not requested by any pod.
+type ClusterCost struct {
+	Nodes   int     `json:"nodes"`
+	Hourly  float64 `json:"hourly"`
+	Monthly float64 `json:"monthly"`
+	Idle    float64 `json:"idleHourly"`
+}
+
+// CostItem is the estimated cost of a namespace or workload.
+type CostItem struct {
+	Name    string  `json:"name"`
+	Pods    int     `json:"pods"`
+	CPU     string  `json:"cpu"`
+	Memory  string  `json:"memory"`
+	Hourly  float64 `json:"hourly"`
+	Monthly float64 `json:"monthly"`
+}
+
+// nodeRates is the hourly prices of a vCPU, a GiB of memory and a GPU on a node.
+type nodeRates struct {
+	cpu, memory, gpu float64
+}
+
+func (s *Server) EstimateCost() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		groupBy := req.GetString("groupBy", "namespace")
+		if groupBy != "namespace" && groupBy != "workload" {
+			return nil, fmt.Errorf("invalid groupBy %q, must be namespace or workload", groupBy)
+		}
+		maxResults := req.GetInt("maxResults", defaultCostMaxResults)
+
+		slog.Info("Estimating cost", "namespace", namespace, "groupBy", groupBy)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		var owners map[string]string
+		if groupBy == "workload" {
+			if owners, err = workloadOwners(ctx, cli, namespace); err != nil {
+				return nil, err
+			}
+		}
+
+		prices := s.pricer.Prices()
+		report := &CostReport{Currency: prices.Currency, Namespace: namespace, GroupBy: groupBy, Items: make([]CostItem, 0)}
+		cpuPrice, memoryPrice, gpuPrice := prices.CPU, prices.Memory, prices.GPU
+		defaultRates := nodeRates{cpu: cpuPrice, memory: memoryPrice, gpu: gpuPrice}
+		rates := make(map[string]nodeRates, len(nodes.Items))
+		cluster := &ClusterCost{Nodes: len(nodes.Items)}
+		for i := range nodes.Items {
+			node := &nodes.Items[i]
+			cpu, memory, gpu := resourceAmounts(node.Status.Allocatable)
+			price, ok := s.pricer.NodePrice(ctx, node)
+			if !ok {
+				report.UnpricedNodes = append(report.UnpricedNodes, node.Name)
+				rates[node.Name] = defaultRates
+				cluster.Hourly += cpu*cpuPrice + memory*memoryPrice + gpu*gpuPrice
+				continue
+			}
+			// the price of the node is split between its resources in proportion to the resource prices.
+			rate := defaultRates
+			if weight := cpu*cpuPrice + memory*memoryPrice + gpu*gpuPrice; weight > 0 {
+				rate = nodeRates{cpu: price * cpuPrice / weight, memory: price * memoryPrice / weight, gpu: price * gpuPrice / weight}
+			}
+			rates[node.Name] = rate
+			cluster.Hourly += price
+		}
+
+		items := make(map[string]*CostItem)
+		cpuRequests := make(map[string]*resource.Quantity)
+		memoryRequests := make(map[string]*resource.Quantity)
+		var requested float64
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			name := pod.Namespace
+			if groupBy == "workload" {
+				name = podWorkload(pod, owners)
+			}
+			item, ok := items[name]
+			if !ok {
+				item = &CostItem{Name: name}
+				items[name] = item
+				cpuRequests[name] = resource.NewMilliQuantity(0, resource.DecimalSI)
+				memoryRequests[name] = resource.NewQuantity(0, resource.BinarySI)
+			}
+			requests := podRequests(pod)
+			rate, ok := rates[pod.Spec.NodeName]
+			if !ok {
+				rate = defaultRates
+			}
+			cpu, memory, gpu := resourceAmounts(requests)
+			hourly := cpu*rate.cpu + memory*rate.memory + gpu*rate.gpu
+			if len(pod.Spec.NodeName) > 0 {
+				requested += hourly
+			}
+			item.Pods++
+			item.Hourly += hourly
+			cpuRequests[name].Add(requests[corev1.ResourceCPU])
+			memoryRequests[name].Add(requests[corev1.ResourceMemory])
+		}
+
+		for name, item := range items {
+			item.CPU, item.Memory = cpuRequests[name].String(), memoryRequests[name].String()
+			report.Hourly += item.Hourly
+			item.Hourly, item.Monthly = roundCost(item.Hourly), roundCost(item.Hourly*hoursPerMonth)
+			report.Items = append(report.Items, *item)
+		}
+		sort.Slice(report.Items, func(i, j int) bool {
+			if report.Items[i].Hourly != report.Items[j].Hourly {
+				return report.Items[i].Hourly > report.Items[j].Hourly
+			}
+			return report.Items[i].Name < report.Items[j].Name
+		})
+		if maxResults > 0 && len(report.Items) > maxResults {
+			report.Omitted = len(report.Items) - maxResults
+			report.Items = report.Items[:maxResults]
+		}
+		report.Hourly, report.Monthly = roundCost(report.Hourly), roundCost(report.Hourly*hoursPerMonth)
+
+		// the idle cost is only meaningful if the pods of all namespaces are counted.
+		if len(namespace) == 0 {
+			cluster.Idle = roundCost(math.Max(cluster.Hourly-requested, 0))
+			cluster.Hourly, cluster.Monthly = roundCost(cluster.Hourly), roundCost(cluster.Hourly*hoursPerMonth)
+			report.Cluster = cluster
+		}
+		sort.Strings(report.UnpricedNodes)
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// resourceAmounts returns the vCPUs, the GiB of memory and the GPUs of the resources.
+func resourceAmounts(resources corev1.ResourceList) (cpu, memory, gpu float64) {
+	if q, ok := resources[corev1.ResourceCPU]; ok {
+		cpu = q.AsApproximateFloat64()
+	}
+	if q, ok := resources[corev1.ResourceMemory]; ok {
+		memory = q.AsApproximateFloat64() / (1 << 30)
+	}
+	if q, ok := resources[gpuResource]; ok {
+		gpu = q.AsApproximateFloat64()
+	}
+	return
+}
+
+// roundCost rounds the cost to 4 decimals, the smaller amounts are noise of the estimation.
+func roundCost(cost float64) float64 {
+	return math.Round(cost*1e4) / 1e4
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// crdCountConcurrency is the number of the custom resources counted concurrently.
+	crdCountConcurrency = 8
+	// maxSchemaDescription is the max length of the field description in the schema summary.
+	maxSchemaDescription = 120
+)
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// CRDInfo is the summary of a CustomResourceDefinition.
+type CRDInfo struct {
+	Name       string        `json:"name"`
+	Group      string        `json:"group"`
+	Kind       string        `json:"kind"`
+	Plural     string        `json:"plural"`
+	ShortNames []string      `json:"shortNames,omitempty"`
+	Scope      string        `json:"scope"`
+	Versions   []CRDVersion  `json:"versions"`
+	Spec       []SchemaField `json:"spec,omitempty"`
+	Count      *int64        `json:"count,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// CRDVersion is a version of the CustomResourceDefinition.
+type CRDVersion struct {
+	Name       string `json:"name"`
+	Served     bool   `json:"served"`
+	Storage    bool   `json:"storage"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+}
+
+// SchemaField is a top-level field of the spec in the structural schema.
+type SchemaField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// customResourceDefinition is a minimal copy of the apiextensions.k8s.io CustomResourceDefinition, only the
+// summarized fields are kept to avoid depending on the apiextensions-apiserver module.
+type customResourceDefinition struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind       string   `json:"kind"`
+			Plural     string   `json:"plural"`
+			ShortNames []string `json:"shortNames,omitempty"`
+		} `json:"names"`
+		Scope    string `json:"scope"`
+		Versions []struct {
+			Name       string `json:"name"`
+			Served     bool   `json:"served"`
+			Storage    bool   `json:"storage"`
+			Deprecated bool   `json:"deprecated,omitempty"`
+			Schema     *struct {
+				OpenAPIV3Schema map[string]any `json:"openAPIV3Schema,omitempty"`
+			} `json:"schema,omitempty"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+func (s *Server) ListCRDs() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		group := req.GetString("group", "")
+		kind := req.GetString("kind", "")
+		includeSchema := req.GetBool("includeSchema", true)
+		countResources := req.GetBool("countResources", true)
+
+		slog.Info("Listing crds", "group", group, "kind", kind, "includeSchema", includeSchema, "countResources", countResources)
+
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		list, err := dynamicClient.Resource(crdGVR).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list crds: %w", err)
+		}
+
+		crds := make([]CRDInfo, 0, len(list.Items))
+		for i := range list.Items {
+			crd := &customResourceDefinition{}
+			if err = runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].UnstructuredContent(), crd); err != nil {
+				slog.Warn("Failed to decode crd", "name", list.Items[i].GetName(), "err", err)
+				continue
+			}
+			if len(group) > 0 && !strings.EqualFold(crd.Spec.Group, group) {
+				continue
+			}
+			if len(kind) > 0 && !strings.EqualFold(crd.Spec.Names.Kind, kind) && !strings.EqualFold(crd.Spec.Names.Plural, kind) {
+				continue
+			}
+			crds = append(crds, crdInfo(crd, includeSchema))
+		}
+		sort.Slice(crds, func(i, j int) bool { return crds[i].Name < crds[j].Name })
+
+		if countResources {
+			countCustomResources(ctx, dynamicClient, crds)
+		}
+
+		resp, err := json.Marshal(crds)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// crdInfo summarizes the CustomResourceDefinition, the schema is summarized from the storage version.
+func crdInfo(crd *customResourceDefinition, includeSchema bool) CRDInfo {
+	info := CRDInfo{
+		Name:       crd.Name,
+		Group:      crd.Spec.Group,
+		Kind:       crd.Spec.Names.Kind,
+		Plural:     crd.Spec.Names.Plural,
+		ShortNames: crd.Spec.Names.ShortNames,
+		Scope:      crd.Spec.Scope,
+		Versions:   make([]CRDVersion, 0, len(crd.Spec.Versions)),
+	}
+	for _, version := range crd.Spec.Versions {
+		info.Versions = append(info.Versions, CRDVersion{
+			Name:       version.Name,
+			Served:     version.Served,
+			Storage:    version.Storage,
+			Deprecated: version.Deprecated,
+		})
+		if includeSchema && version.Storage && version.Schema != nil {
+			info.Spec = summarizeSpecSchema(version.Schema.OpenAPIV3Schema)
+		}
+	}
+	return info
+}
+
+// summarizeSpecSchema returns the top-level fields of the spec in the structural schema, sorted by name.
+func summarizeSpecSchema(openAPISchema map[string]any) []SchemaField {
+	properties, _ := openAPISchema["properties"].(map[string]any)
+	spec, _ := properties["spec"].(map[string]any)
+	fields, _ := spec["properties"].(map[string]any)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	required := make(map[string]bool)
+	if list, ok := spec["required"].([]any); ok {
+		for _, name := range list {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	summary := make([]SchemaField, 0, len(fields))
+	for name, field := range fields {
+		field, _ := field.(map[string]any)
+		description, _ := field["description"].(string)
+		if description, _, _ = strings.Cut(description, "\n"); len(description) > maxSchemaDescription {
+			description = description[:maxSchemaDescription] + "..."
+		}
+		summary = append(summary, SchemaField{Name: name, Type: schemaType(field), Required: required[name], Description: description})
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Name < summary[j].Name })
+	return summary
+}
+
+// schemaType returns the readable type of the schema, e.g. []string or map[string]object.
+func schemaType(field map[string]any) string {
+	if preserve, _ := field["x-kubernetes-preserve-unknown-fields"].(bool); preserve {
+		return "object (free-form)"
+	}
+	if intOrString, _ := field["x-kubernetes-int-or-string"].(bool); intOrString {
+		return "int-or-string"
+	}
+	typ, _ := field["type"].(string)
+	switch typ {
+	case "array":
+		items, _ := field["items"].(map[string]any)
+		return "[]" + schemaType(items)
+	case "object":
+		if additional, ok := field["additionalProperties"].(map[string]any); ok {
+			return "map[string]" + schemaType(additional)
+		}
+		return "object"
+	case "":
+		return "any"
+	default:
+		return typ
+	}
+}
+
+// countCustomResources counts the custom resources of the served storage version in all namespaces, only the first
+// item is listed and the remaining item count of the list is used to avoid loading all the resources.
+func countCustomResources(ctx context.Context, dynamicClient dynamic.Interface, crds []CRDInfo) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, crdCountConcurrency)
+	)
+	for i := range crds {
+		version := ""
+		for _, v := range crds[i].Versions {
+			if v.Served && (len(version) == 0 || v.Storage) {
+				version = v.Name
+			}
+		}
+		if len(version) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(crd *CRDInfo, gvr schema.GroupVersionResource) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+			if err != nil {
+				crd.Error = fmt.Sprintf("failed to count resources: %v", err)
+				return
+			}
+			count := int64(len(list.Items))
+			switch {
+			case list.GetRemainingItemCount() != nil:
+				count += *list.GetRemainingItemCount()
+			case len(list.GetContinue()) > 0:
+				// the remaining item count is unknown, e.g. the list is served from the cache.
+				return
+			}
+			crd.Count = &count
+		}(&crds[i], schema.GroupVersionResource{Group: crds[i].Group, Version: version, Resource: crds[i].Plural})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// CreatedObject is the summary of the object created by the generator tools, the data of the secrets is omitted.
+type CreatedObject struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type,omitempty"`
+	Keys      []string `json:"keys,omitempty"`
+	DryRun    bool     `json:"dryRun,omitempty"`
+}
+
+func (s *Server) CreateNamespace() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		dryRun := req.GetBool("dryRun", false)
+
+		slog.Info("Creating namespace", "name", name, "dryRun", dryRun)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: parseLabels(req.GetStringSlice("labels", nil))}}
+		if _, err = cli.CoreV1().Namespaces().Create(ctx, ns, createOptions(dryRun)); err != nil {
+			return nil, fmt.Errorf("failed to create namespace: %w", err)
+		}
+		return createdResult(CreatedObject{Kind: "Namespace", Name: name, DryRun: dryRun})
+	}
+}
+
+func (s *Server) CreateConfigMap() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		fromLiteral := req.GetStringSlice("fromLiteral", nil)
+		fromFile := req.GetStringSlice("fromFile", nil)
+		dryRun := req.GetBool("dryRun", false)
+
+		slog.Info("Creating configmap", "name", name, "namespace", namespace, "fromLiteral", len(fromLiteral), "fromFile", fromFile, "dryRun", dryRun)
+
+		data, err := loadKeyValues(fromLiteral, fromFile, s.readLocalFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: parseLabels(req.GetStringSlice("labels", nil))},
+			Data:       make(map[string]string),
+		}
+		for key, value := range data {
+			if utf8.Valid(value) {
+				cm.Data[key] = string(value)
+				continue
+			}
+			if cm.BinaryData == nil {
+				cm.BinaryData = make(map[string][]byte)
+			}
+			cm.BinaryData[key] = value
+		}
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = cli.CoreV1().ConfigMaps(namespace).Create(ctx, cm, createOptions(dryRun)); err != nil {
+			return nil, fmt.Errorf("failed to create configmap: %w", err)
+		}
+		return createdResult(CreatedObject{Kind: "ConfigMap", Namespace: namespace, Name: name, Keys: sortedKeys(data), DryRun: dryRun})
+	}
+}
+
+func (s *Server) CreateSecret() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		secretType := req.GetString("type", "generic")
+		dryRun := req.GetBool("dryRun", false)
+
+		slog.Info("Creating secret", "name", name, "namespace", namespace, "type", secretType, "dryRun", dryRun)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: parseLabels(req.GetStringSlice("labels", nil))},
+		}
+		switch secretType {
+		case "generic":
+			secret.Type = corev1.SecretTypeOpaque
+			if secret.Data, err = loadKeyValues(req.GetStringSlice("fromLiteral", nil), req.GetStringSlice("fromFile", nil), s.readLocalFile); err != nil {
+				return nil, err
+			}
+		case "docker-registry":
+			secret.Type = corev1.SecretTypeDockerConfigJson
+			if secret.Data, err = dockerConfigData(req); err != nil {
+				return nil, err
+			}
+		case "tls":
+			secret.Type = corev1.SecretTypeTLS
+			if secret.Data, err = tlsData(req, s.readLocalFile); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported secret type %q, must be one of generic, docker-registry or tls", secretType)
+		}
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = cli.CoreV1().Secrets(namespace).Create(ctx, secret, createOptions(dryRun)); err != nil {
+			return nil, fmt.Errorf("failed to create secret: %w", err)
+		}
+		return createdResult(CreatedObject{Kind: "Secret", Namespace: namespace, Name: name, Type: string(secret.Type), Keys: sortedKeys(secret.Data), DryRun: dryRun})
+	}
+}
+
+// readLocalFile reads the local file named by the client, the files are confined to the local file directory of the
+// server so that the other files readable by the server, e.g. the kubeconfig or the token of the ServiceAccount,
+// can't be copied into the objects. The local files are rejected if the directory is not set.
+func (s *Server) readLocalFile(path string) ([]byte, error) {
+	if len(s.localFileDir) == 0 {
+		return nil, fmt.Errorf("reading the local file %q is disabled, pass the content inline or start the server with --local-file-dir", path)
+	}
+	if filepath.IsAbs(path) {
+		dir, err := filepath.Abs(s.localFileDir)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, fmt.Errorf("file %q is not in the local file directory: %w", path, err)
+		}
+		path = rel
+	}
+	// the root rejects the paths escaping the directory, including by the symlinks.
+	root, err := os.OpenRoot(s.localFileDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	f, err := root.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the local file: %w", err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// loadKeyValues loads the data from the key=value literals and the [key=]path files like kubectl create, the key
+// of a file defaults to its base name.
+func loadKeyValues(fromLiteral, fromFile []string, readFile func(string) ([]byte, error)) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	add := func(key string, value []byte) error {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, ";"))
+		}
+		if _, ok := data[key]; ok {
+			return fmt.Errorf("duplicate key %q", key)
+		}
+		data[key] = value
+		return nil
+	}
+
+	for _, literal := range fromLiteral {
+		key, value, found := strings.Cut(literal, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid literal %q, must be in the form of key=value", literal)
+		}
+		if err := add(key, []byte(value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range fromFile {
+		key, path, found := strings.Cut(file, "=")
+		if !found {
+			key, path = filepath.Base(file), file
+		}
+		content, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+		}
+		if err = add(key, content); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) == 0 {
+		return nil, errors.New("at least one of fromLiteral or fromFile is required")
+	}
+	return data, nil
+}
+
+// dockerConfigData builds the .dockerconfigjson of the docker-registry secret.
+func dockerConfigData(req mcp.CallToolRequest) (map[string][]byte, error) {
+	server := req.GetString("dockerServer", "https://index.docker.io/v1/")
+	username := req.GetString("dockerUsername", "")
+	password := req.GetString("dockerPassword", "")
+	email := req.GetString("dockerEmail", "")
+	if len(username) == 0 || len(password) == 0 {
+		return nil, errors.New("dockerUsername and dockerPassword are required for the docker-registry secret")
+	}
+
+	entry := map[string]string{
+		"username": username,
+		"password": password,
+		"auth":     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+	}
+	if len(email) > 0 {
+		entry["email"] = email
+	}
+	config, err := json.Marshal(map[string]any{"auths": map[string]any{server: entry}})
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{corev1.DockerConfigJsonKey: config}, nil
+}
+
+// tlsData loads the certificate and key of the tls secret, each of them is either the PEM content or the path
+// of the PEM file.
+func tlsData(req mcp.CallToolRequest, readFile func(string) ([]byte, error)) (map[string][]byte, error) {
+	load := func(name string) ([]byte, error) {
+		value := req.GetString(name, "")
+		if len(value) == 0 {
+			return nil, fmt.Errorf("%s is required for the tls secret", name)
+		}
+		if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+			return []byte(value), nil
+		}
+		content, err := readFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s file %q: %w", name, value, err)
+		}
+		return content, nil
+	}
+
+	cert, err := load("cert")
+	if err != nil {
+		return nil, err
+	}
+	key, err := load("key")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{corev1.TLSCertKey: cert, corev1.TLSPrivateKeyKey: key}, nil
+}
+
+// parseLabels parses the key=value labels, the invalid entries are ignored.
+func parseLabels(entries []string) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if key, value, found := strings.Cut(entry, "="); found {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+func createOptions(dryRun bool) metav1.CreateOptions {
+	if dryRun {
+		return metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}
+	}
+	return metav1.CreateOptions{}
+}
+
+func sortedKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func createdResult(obj CreatedObject) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
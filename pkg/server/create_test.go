@@ -0,0 +1,92 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("debug: true"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(outside, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "relative path", dir: dir, path: "app.yaml", want: "debug: true"},
+		{name: "absolute path in the directory", dir: dir, path: filepath.Join(dir, "app.yaml"), want: "debug: true"},
+		{name: "disabled without the directory", path: filepath.Join(dir, "app.yaml"), wantErr: true},
+		{name: "absolute path outside the directory", dir: dir, path: outside, wantErr: true},
+		{name: "relative path escaping the directory", dir: dir, path: "../app.yaml", wantErr: true},
+		{name: "symlink escaping the directory", dir: dir, path: "link", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{localFileDir: tt.dir}
+			data, err := s.readLocalFile(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("got %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeyValues(t *testing.T) {
+	files := map[string]string{"config.yaml": "a: b"}
+	readFile := func(path string) ([]byte, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(data), nil
+	}
+
+	tests := []struct {
+		name        string
+		fromLiteral []string
+		fromFile    []string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{name: "literals", fromLiteral: []string{"A=1", "B=x=y"}, want: map[string]string{"A": "1", "B": "x=y"}},
+		{name: "file keyed by the base name", fromFile: []string{"config.yaml"}, want: map[string]string{"config.yaml": "a: b"}},
+		{name: "file with the key", fromFile: []string{"app.yaml=config.yaml"}, want: map[string]string{"app.yaml": "a: b"}},
+		{name: "missing file", fromFile: []string{"missing.yaml"}, wantErr: true},
+		{name: "invalid literal", fromLiteral: []string{"A"}, wantErr: true},
+		{name: "invalid key", fromLiteral: []string{"a/b=1"}, wantErr: true},
+		{name: "duplicate key", fromLiteral: []string{"config.yaml=1"}, fromFile: []string{"config.yaml"}, wantErr: true},
+		{name: "empty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := loadKeyValues(tt.fromLiteral, tt.fromFile, readFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if len(data) != len(tt.want) {
+				t.Fatalf("got %d keys, want %d", len(data), len(tt.want))
+			}
+			for key, value := range tt.want {
+				if string(data[key]) != value {
+					t.Fatalf("got %q of the key %s, want %q", data[key], key, value)
+				}
+			}
+		})
+	}
+}
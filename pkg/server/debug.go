@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	debugPodContainerName = "debug"
+	debugPodReadyTimeout  = 60 * time.Second
+	// debugPodUser is the user of the temporary pods, nobody, so that the images running as root by default are
+	// admitted by the namespaces enforcing the restricted Pod Security Standard.
+	debugPodUser = 65534
+)
+
+// newDebugPod returns the temporary pod with the specified image in the namespace, its security context passes the
+// restricted Pod Security Standard.
+func newDebugPod(namespace, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "koffee-debug-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "koffee",
+			},
+		},
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   ptr.To(true),
+				RunAsUser:      ptr.To(int64(debugPodUser)),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    debugPodContainerName,
+					Image:   image,
+					Command: []string{"sleep", "3600"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: ptr.To(false),
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					},
+				},
+			},
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: ptr.To(int64(0)),
+		},
+	}
+}
+
+// createDebugPod creates a temporary pod with the specified image in the namespace and waits until it's running,
+// the caller is responsible for deleting the pod with deleteDebugPod.
+func createDebugPod(ctx context.Context, cli kubernetes.Interface, namespace, image string) (*corev1.Pod, error) {
+	created, err := cli.CoreV1().Pods(namespace).Create(ctx, newDebugPod(namespace, image), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create debug pod: %w", err)
+	}
+	slog.Info("Created debug pod", "name", created.Name, "namespace", namespace, "image", image)
+
+	err = wait.PollUntilContextTimeout(ctx, time.Second, debugPodReadyTimeout, true, func(ctx context.Context) (bool, error) {
+		p, err := cli.CoreV1().Pods(namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if definition.IsPodPhaseTerminal(p.Status.Phase) {
+			return false, fmt.Errorf("debug pod %s exited with phase %s", p.Name, p.Status.Phase)
+		}
+		return p.Status.Phase == corev1.PodRunning, nil
+	})
+	if err != nil {
+		deleteDebugPod(cli, created)
+		return nil, fmt.Errorf("failed to wait for debug pod running: %w", err)
+	}
+	return created, nil
+}
+
+// deleteDebugPod deletes the temporary pod, it uses a fresh context because the request context may be canceled.
+func deleteDebugPod(cli kubernetes.Interface, pod *corev1.Pod) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := cli.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0))})
+	if err != nil {
+		slog.Error("Failed to delete debug pod", "name", pod.Name, "namespace", pod.Namespace, "err", err)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+
+	"cola.io/koffee/pkg/audit"
+)
+
+func TestDebugPodRestricted(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+	}{
+		{name: "network checks", image: defaultNetDebugImage},
+		{name: "service probe", image: defaultProbeImage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := newDebugPod("shop", tt.image)
+			if pod.Namespace != "shop" || pod.Spec.Containers[0].Image != tt.image {
+				t.Fatalf("got pod of %s with image %s, want of shop with %s", pod.Namespace, pod.Spec.Containers[0].Image, tt.image)
+			}
+			// the namespaces enforcing the restricted level admit the pod.
+			for _, rule := range audit.LevelRules(audit.LevelRestricted) {
+				if findings := rule.Check(&pod.Spec); len(findings) > 0 {
+					t.Fatalf("got findings %+v of %s, want none", findings, rule.ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// deltaIdleTimeout is the idle time of the watch after which the changes are considered caught up.
+	deltaIdleTimeout = time.Second
+	// deltaMaxDuration bounds the watch of the changes, the remaining changes are returned by the next call.
+	deltaMaxDuration = 10 * time.Second
+	// defaultDeltaMaxObjects is the default maximum changed objects returned by a call.
+	defaultDeltaMaxObjects = 500
+)
+
+// errResourceVersionExpired is returned if the changes since the resource version are compacted.
+var errResourceVersionExpired = errors.New("the changes since the resourceVersion are no longer available, list again without sinceResourceVersion to get the full list and its resourceVersion")
+
+// ListDelta is the objects changed since the resource version, the next call passes the resource version of the
+// delta to get the later changes.
+type ListDelta struct {
+	ResourceVersion string   `json:"resourceVersion"`
+	Added           []string `json:"added,omitempty"`
+	Modified        []string `json:"modified,omitempty"`
+	Deleted         []string `json:"deleted,omitempty"`
+	// Truncated is set if more changes remain, which are returned by the next call.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// watchDelta watches the changes since the resource version until the watch is idle, and returns the added and
+// modified objects in the order of the changes with the delta. The changes of an object are merged, e.g. an object
+// added and then modified is reported as added.
+func watchDelta(ctx context.Context, ri dynamic.ResourceInterface, options metav1.ListOptions, since string, maxObjects int) (*unstructured.UnstructuredList, *ListDelta, error) {
+	// the current resource version is returned if nothing has changed.
+	current, err := ri.List(ctx, metav1.ListOptions{LabelSelector: options.LabelSelector, FieldSelector: options.FieldSelector, Limit: 1})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	options.ResourceVersion = since
+	options.AllowWatchBookmarks = true
+	options.TimeoutSeconds = ptr.To(int64(deltaMaxDuration.Seconds()))
+	w, err := ri.Watch(ctx, options)
+	if err != nil {
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return nil, nil, errResourceVersionExpired
+		}
+		return nil, nil, fmt.Errorf("failed to watch resources: %w", err)
+	}
+	defer w.Stop()
+
+	delta := &ListDelta{}
+	var (
+		latest  string
+		keys    []string
+		changes = make(map[string]watch.EventType)
+		objects = make(map[string]*unstructured.Unstructured)
+	)
+	idle := time.NewTimer(deltaIdleTimeout)
+	defer idle.Stop()
+watchLoop:
+	for {
+		select {
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				// the watch is closed by the server timeout while the changes keep coming.
+				delta.Truncated = true
+				break watchLoop
+			}
+			switch event.Type {
+			case watch.Error:
+				err := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return nil, nil, errResourceVersionExpired
+				}
+				return nil, nil, fmt.Errorf("failed to watch resources: %w", err)
+			case watch.Bookmark:
+				if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+					latest = obj.GetResourceVersion()
+				}
+			default:
+				obj, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				key := obj.GetName()
+				if ns := obj.GetNamespace(); len(ns) > 0 {
+					key = ns + "/" + key
+				}
+				if _, seen := changes[key]; !seen {
+					if len(keys) >= maxObjects {
+						delta.Truncated = true
+						break watchLoop
+					}
+					keys = append(keys, key)
+				}
+				changes[key] = mergeEventType(changes[key], event.Type)
+				objects[key] = obj
+				latest = obj.GetResourceVersion()
+			}
+			idle.Reset(deltaIdleTimeout)
+		case <-idle.C:
+			break watchLoop
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	delta.ResourceVersion = latest
+	if len(latest) == 0 {
+		delta.ResourceVersion = current.GetResourceVersion()
+	}
+	items := &unstructured.UnstructuredList{Object: map[string]any{}}
+	items.SetResourceVersion(delta.ResourceVersion)
+	for _, key := range keys {
+		switch changes[key] {
+		case watch.Added:
+			delta.Added = append(delta.Added, key)
+		case watch.Modified:
+			delta.Modified = append(delta.Modified, key)
+		case watch.Deleted:
+			delta.Deleted = append(delta.Deleted, key)
+			continue
+		}
+		items.Items = append(items.Items, *objects[key])
+	}
+	return items, delta, nil
+}
+
+// mergeEventType returns the change of an object from its previous change and the new event.
+func mergeEventType(previous, next watch.EventType) watch.EventType {
+	switch {
+	case previous == watch.Added && next == watch.Modified:
+		return watch.Added
+	case previous == watch.Deleted && next == watch.Added:
+		// the object is recreated with the same name.
+		return watch.Modified
+	}
+	return next
+}
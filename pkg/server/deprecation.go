@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DeprecatedAPIFinding describes an object which uses a deprecated or removed API version.
+type DeprecatedAPIFinding struct {
+	Kind         string `json:"kind"`
+	Namespace    string `json:"namespace,omitempty"`
+	Name         string `json:"name"`
+	APIVersion   string `json:"apiVersion"`
+	Source       string `json:"source"`
+	Status       string `json:"status"`
+	DeprecatedIn string `json:"deprecatedIn"`
+	RemovedIn    string `json:"removedIn"`
+	Replacement  string `json:"replacement,omitempty"`
+}
+
+// DeprecatedAPIReport is the report of the deprecated API detection.
+type DeprecatedAPIReport struct {
+	ClusterVersion string                 `json:"clusterVersion,omitempty"`
+	TargetVersion  string                 `json:"targetVersion"`
+	Findings       []DeprecatedAPIFinding `json:"findings"`
+}
+
+func (s *Server) DetectDeprecatedAPIs() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		targetVersion := req.GetString("targetVersion", "")
+		manifest := req.GetString("manifest", "")
+		namespace := req.GetString("namespace", "")
+
+		slog.Info("Detecting deprecated apis", "targetVersion", targetVersion, "namespace", namespace, "manifest", len(manifest) > 0)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+
+		report := &DeprecatedAPIReport{Findings: make([]DeprecatedAPIFinding, 0)}
+		var targetMinor int
+		if len(targetVersion) > 0 {
+			if targetMinor, err = definition.ParseMinorVersion(targetVersion); err != nil {
+				return nil, fmt.Errorf("invalid target version %q: %w", targetVersion, err)
+			}
+		}
+
+		// the target version defaults to the next minor version of the cluster, it's required to be
+		// resolved from the cluster when scanning the live objects.
+		if len(targetVersion) == 0 || len(manifest) == 0 {
+			serverVersion, err := discoveryClient.ServerVersion()
+			if err != nil {
+				return nil, err
+			}
+			report.ClusterVersion = serverVersion.GitVersion
+
+			if len(targetVersion) == 0 {
+				clusterMinor, err := definition.ParseMinorVersion(serverVersion.Minor)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse cluster version %q: %w", serverVersion.GitVersion, err)
+				}
+				targetMinor = clusterMinor + 1
+			}
+		}
+		report.TargetVersion = fmt.Sprintf("1.%d", targetMinor)
+
+		if len(manifest) > 0 {
+			objs, err := decodeManifests(manifest)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objs {
+				report.addFinding(obj, obj.GetAPIVersion(), "manifest", targetMinor)
+			}
+		} else if err = s.scanDeprecatedAPIs(ctx, discoveryClient, namespace, targetMinor, report); err != nil {
+			return nil, err
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// scanDeprecatedAPIs scans the live objects for the API versions recorded in the managed fields and the
+// last applied configuration, which are the versions the clients used to write the objects.
+func (s *Server) scanDeprecatedAPIs(ctx context.Context, discoveryClient discovery.DiscoveryInterface, namespace string, targetMinor int, report *DeprecatedAPIReport) error {
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return err
+	}
+
+	scanned := make(map[schema.GroupKind]bool)
+	for _, api := range definition.DeprecatedAPIs(targetMinor) {
+		gk := api.GroupVersionKind.GroupKind()
+		if !api.Replacement.Empty() {
+			gk.Group = api.Replacement.Group
+		}
+		if scanned[gk] {
+			continue
+		}
+		scanned[gk] = true
+
+		gvr, namespaced, err := lookupGroupKindResource(discoveryClient, gk)
+		if err != nil {
+			slog.Debug("Skip the kind not served by the cluster", "groupKind", gk.String())
+			continue
+		}
+
+		var items *unstructured.UnstructuredList
+		if namespaced && len(namespace) > 0 {
+			items, err = dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		} else {
+			items, err = dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", gk.String(), err)
+		}
+
+		for i := range items.Items {
+			obj := &items.Items[i]
+			for _, entry := range obj.GetManagedFields() {
+				report.addFinding(obj, entry.APIVersion, fmt.Sprintf("managedFields/%s", entry.Manager), targetMinor)
+			}
+			if lastApplied, ok := obj.GetAnnotations()[lastAppliedConfigAnnotation]; ok {
+				applied := &unstructured.Unstructured{}
+				if err := json.Unmarshal([]byte(lastApplied), &applied.Object); err == nil {
+					report.addFinding(obj, applied.GetAPIVersion(), "lastAppliedConfiguration", targetMinor)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *DeprecatedAPIReport) addFinding(obj *unstructured.Unstructured, apiVersion, source string, targetMinor int) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return
+	}
+	api, ok := definition.LookupDeprecatedAPI(gv.WithKind(obj.GetKind()))
+	if !ok || api.DeprecatedIn > targetMinor {
+		return
+	}
+
+	status := "deprecated"
+	if api.RemovedIn <= targetMinor {
+		status = "removed"
+	}
+	finding := DeprecatedAPIFinding{
+		Kind:         obj.GetKind(),
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		APIVersion:   apiVersion,
+		Source:       source,
+		Status:       status,
+		DeprecatedIn: fmt.Sprintf("1.%d", api.DeprecatedIn),
+		RemovedIn:    fmt.Sprintf("1.%d", api.RemovedIn),
+	}
+	if !api.Replacement.Empty() {
+		finding.Replacement = api.Replacement.String()
+	}
+	r.Findings = append(r.Findings, finding)
+}
+
+// decodeManifests decodes the JSON or YAML manifests, multiple documents are supported.
+func decodeManifests(manifest string) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 4096)
+	objs := make([]*unstructured.Unstructured, 0)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// redactedValue replaces the values of the environment variables unless they are revealed.
+const redactedValue = "<redacted>"
+
+// EnvResolution is the environment variables of the containers resolved to their sources, the dangling references
+// to the missing ConfigMaps, Secrets or keys fail the container to start unless they are optional.
+type EnvResolution struct {
+	Kind       string         `json:"kind"`
+	Name       string         `json:"name"`
+	Namespace  string         `json:"namespace"`
+	Containers []ContainerEnv `json:"containers"`
+	Dangling   int            `json:"dangling"`
+}
+
+// ContainerEnv is the resolved environment variables of a container.
+type ContainerEnv struct {
+	Name string        `json:"name"`
+	Init bool          `json:"init,omitempty"`
+	Env  []ResolvedEnv `json:"env,omitempty"`
+	// EnvFrom is the ConfigMaps and Secrets whose keys are all imported.
+	EnvFrom []ResolvedEnvFrom `json:"envFrom,omitempty"`
+}
+
+// ResolvedEnv is an environment variable with its source, the value is redacted unless revealed.
+type ResolvedEnv struct {
+	Name     string `json:"name"`
+	Source   string `json:"source,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+	// Problem is the reason the reference is dangling, e.g. the key is not found in the ConfigMap.
+	Problem string `json:"problem,omitempty"`
+}
+
+// ResolvedEnvFrom is a ConfigMap or Secret imported by envFrom with the variables of its keys.
+type ResolvedEnvFrom struct {
+	Source   string        `json:"source"`
+	Prefix   string        `json:"prefix,omitempty"`
+	Optional bool          `json:"optional,omitempty"`
+	Problem  string        `json:"problem,omitempty"`
+	Env      []ResolvedEnv `json:"env,omitempty"`
+}
+
+// envSources caches the ConfigMaps and Secrets of the namespace referenced by the containers, nil for the missing.
+type envSources struct {
+	cli        kubernetes.Interface
+	namespace  string
+	configMaps map[string]map[string]string
+	secrets    map[string]map[string]string
+}
+
+func (e *envSources) configMap(ctx context.Context, name string) (map[string]string, error) {
+	if data, ok := e.configMaps[name]; ok {
+		return data, nil
+	}
+	cm, err := e.cli.CoreV1().ConfigMaps(e.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+	}
+	var data map[string]string
+	if err == nil {
+		data = make(map[string]string, len(cm.Data)+len(cm.BinaryData))
+		for key, value := range cm.Data {
+			data[key] = value
+		}
+		for key, value := range cm.BinaryData {
+			data[key] = string(value)
+		}
+	}
+	e.configMaps[name] = data
+	return data, nil
+}
+
+func (e *envSources) secret(ctx context.Context, name string) (map[string]string, error) {
+	if data, ok := e.secrets[name]; ok {
+		return data, nil
+	}
+	secret, err := e.cli.CoreV1().Secrets(e.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	var data map[string]string
+	if err == nil {
+		data = make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			data[key] = string(value)
+		}
+	}
+	e.secrets[name] = data
+	return data, nil
+}
+
+func (s *Server) ResolveEnv() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		kind := req.GetString("kind", "")
+		if len(kind) == 0 {
+			kind = "Pod"
+		}
+		kind, name, err := definition.ParseKindName(kind, resourceName)
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		containerName := req.GetString("container", "")
+		reveal := req.GetBool("revealValues", false)
+
+		slog.Info("Resolving env", "kind", kind, "name", name, "namespace", namespace, "container", containerName, "revealValues", reveal)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		spec, err := workloadPodSpec(ctx, cli, namespace, kind, name)
+		if err != nil {
+			return nil, err
+		}
+
+		sources := &envSources{
+			cli:        cli,
+			namespace:  namespace,
+			configMaps: make(map[string]map[string]string),
+			secrets:    make(map[string]map[string]string),
+		}
+		resolution := &EnvResolution{Kind: kind, Name: name, Namespace: namespace, Containers: make([]ContainerEnv, 0)}
+		for _, containers := range []struct {
+			init  bool
+			items []corev1.Container
+		}{{init: true, items: spec.InitContainers}, {items: spec.Containers}} {
+			for i := range containers.items {
+				c := &containers.items[i]
+				if len(containerName) > 0 && c.Name != containerName {
+					continue
+				}
+				env, err := resolveContainerEnv(ctx, sources, c, reveal)
+				if err != nil {
+					return nil, err
+				}
+				env.Init = containers.init
+				resolution.Containers = append(resolution.Containers, *env)
+			}
+		}
+		if len(containerName) > 0 && len(resolution.Containers) == 0 {
+			return nil, fmt.Errorf("container %q is not found in %s %s", containerName, kind, name)
+		}
+		for _, c := range resolution.Containers {
+			for _, env := range c.Env {
+				if len(env.Problem) > 0 && !env.Optional {
+					resolution.Dangling++
+				}
+			}
+			for _, envFrom := range c.EnvFrom {
+				if len(envFrom.Problem) > 0 && !envFrom.Optional {
+					resolution.Dangling++
+				}
+			}
+		}
+
+		resp, err := json.Marshal(resolution)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// resolveContainerEnv resolves the env and envFrom of the container to the keys of their ConfigMaps and Secrets.
+func resolveContainerEnv(ctx context.Context, sources *envSources, c *corev1.Container, reveal bool) (*ContainerEnv, error) {
+	redact := func(value string) string {
+		if reveal {
+			return value
+		}
+		return redactedValue
+	}
+
+	env := &ContainerEnv{Name: c.Name}
+	for _, v := range c.Env {
+		resolved := ResolvedEnv{Name: v.Name, Source: envSourceSummary(v.ValueFrom)}
+		var (
+			data     map[string]string
+			kind     string
+			name     string
+			key      string
+			optional *bool
+			err      error
+		)
+		switch {
+		case v.ValueFrom == nil:
+			resolved.Value = redact(v.Value)
+			env.Env = append(env.Env, resolved)
+			continue
+		case v.ValueFrom.ConfigMapKeyRef != nil:
+			ref := v.ValueFrom.ConfigMapKeyRef
+			kind, name, key, optional = "configmap", ref.Name, ref.Key, ref.Optional
+			data, err = sources.configMap(ctx, ref.Name)
+		case v.ValueFrom.SecretKeyRef != nil:
+			ref := v.ValueFrom.SecretKeyRef
+			kind, name, key, optional = "secret", ref.Name, ref.Key, ref.Optional
+			data, err = sources.secret(ctx, ref.Name)
+		default:
+			// the field and resource references are resolved by the kubelet from the pod itself.
+			env.Env = append(env.Env, resolved)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resolved.Optional = optional != nil && *optional
+		value, found := data[key]
+		switch {
+		case data == nil:
+			resolved.Problem = fmt.Sprintf("%s %s not found", kind, name)
+		case !found:
+			resolved.Problem = fmt.Sprintf("key %s not found in %s %s", key, kind, name)
+		default:
+			resolved.Value = redact(value)
+		}
+		env.Env = append(env.Env, resolved)
+	}
+
+	for _, from := range c.EnvFrom {
+		var (
+			data     map[string]string
+			kind     string
+			name     string
+			optional *bool
+			err      error
+		)
+		switch {
+		case from.ConfigMapRef != nil:
+			kind, name, optional = "configmap", from.ConfigMapRef.Name, from.ConfigMapRef.Optional
+			data, err = sources.configMap(ctx, name)
+		case from.SecretRef != nil:
+			kind, name, optional = "secret", from.SecretRef.Name, from.SecretRef.Optional
+			data, err = sources.secret(ctx, name)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resolved := ResolvedEnvFrom{Source: kind + " " + name, Prefix: from.Prefix, Optional: optional != nil && *optional}
+		if data == nil {
+			resolved.Problem = fmt.Sprintf("%s %s not found", kind, name)
+		}
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			resolved.Env = append(resolved.Env, ResolvedEnv{
+				Name:   from.Prefix + key,
+				Source: fmt.Sprintf("%s %s key %s", kind, name, key),
+				Value:  redact(data[key]),
+			})
+		}
+		env.EnvFrom = append(env.EnvFrom, resolved)
+	}
+	return env, nil
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ToolError is the structured error returned by the tools, the client decides whether to retry the tool call
+// by the retryable flag and the suggested retry duration.
+type ToolError struct {
+	Error       string       `json:"error"`
+	Message     string       `json:"message"`
+	Code        int32        `json:"code,omitempty"`
+	Causes      []ErrorCause `json:"causes,omitempty"`
+	Remediation string       `json:"remediation,omitempty"`
+	Retryable   bool         `json:"retryable"`
+	RetryAfter  string       `json:"retryAfter,omitempty"`
+	// Partial is the partial result produced before the tool call is interrupted.
+	Partial string `json:"partial,omitempty"`
+}
+
+// ErrorCause is the field level cause of an invalid request.
+type ErrorCause struct {
+	Field   string `json:"field,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message"`
+}
+
+// newToolErrorResult returns the tool result of the structured error.
+func newToolErrorResult(toolErr ToolError, retryAfter time.Duration) *mcp.CallToolResult {
+	if retryAfter > 0 {
+		toolErr.RetryAfter = retryAfter.Round(time.Millisecond).String()
+	}
+	data, _ := json.Marshal(toolErr)
+	return mcp.NewToolResultError(string(data))
+}
+
+// translateError translates the error into the structured error, the Kubernetes API errors are mapped by their
+// status reason with the suggested remediation.
+func translateError(err error) (ToolError, time.Duration) {
+	toolErr := ToolError{Error: "Error", Message: err.Error()}
+	var retryAfter time.Duration
+
+	var rejected *AdmissionRejectedError
+	if errors.As(err, &rejected) {
+		toolErr.Error = "AdmissionRejected"
+		for _, r := range rejected.Rejections {
+			for _, b := range r.Breaches {
+				toolErr.Causes = append(toolErr.Causes, ErrorCause{
+					Field:   fmt.Sprintf("%s/%s", r.Kind, r.Name),
+					Type:    b.Resource,
+					Message: fmt.Sprintf("exceeds the quota %s: used %s, requested %s, hard %s", b.Quota, b.Used, b.Requested, b.Hard),
+				})
+			}
+			for _, v := range r.Violations {
+				toolErr.Causes = append(toolErr.Causes, ErrorCause{Field: fmt.Sprintf("%s/%s", r.Kind, r.Name), Type: "LimitRange", Message: v})
+			}
+		}
+		toolErr.Remediation = "Lower the requests, limits or replicas of the objects, or raise the quota, use quota_headroom to check the usage"
+		return toolErr, retryAfter
+	}
+
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			toolErr.Error, toolErr.Retryable = "Timeout", true
+		}
+		return toolErr, retryAfter
+	}
+
+	s := status.Status()
+	toolErr.Error = string(s.Reason)
+	toolErr.Code = s.Code
+	if len(toolErr.Error) == 0 {
+		toolErr.Error = string(metav1.StatusReasonUnknown)
+	}
+	if s.Details != nil {
+		for _, cause := range s.Details.Causes {
+			toolErr.Causes = append(toolErr.Causes, ErrorCause{Field: cause.Field, Type: string(cause.Type), Message: cause.Message})
+		}
+	}
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	switch {
+	case apierrors.IsNotFound(err):
+		toolErr.Remediation = "Check the kind, name and namespace of the object, use find_resource or list_resources to look it up"
+	case apierrors.IsAlreadyExists(err):
+		toolErr.Remediation = "The object already exists, update it instead of creating it, or choose another name"
+	case apierrors.IsConflict(err):
+		toolErr.Remediation = "The object has been modified, get the latest version and apply the change again"
+		toolErr.Retryable = true
+	case apierrors.IsInvalid(err):
+		toolErr.Remediation = "Fix the fields listed in the causes, use explain_resource to check the field names and types"
+	case apierrors.IsBadRequest(err):
+		toolErr.Remediation = "Check the arguments of the tool, e.g. the label and field selectors"
+	case apierrors.IsUnauthorized(err):
+		toolErr.Remediation = "The credentials of the kube context are invalid or expired, refresh the kubeconfig"
+	case apierrors.IsForbidden(err):
+		toolErr.Remediation = "The user of the kube context lacks the RBAC permission, switch to a context with the permission or grant it"
+	case apierrors.IsMethodNotSupported(err):
+		toolErr.Remediation = "The operation is not supported by the resource"
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		toolErr.Remediation = "The API server timed out, retry later or narrow down the request"
+		toolErr.Retryable = true
+	case apierrors.IsTooManyRequests(err):
+		toolErr.Remediation = "The API server is throttling the requests, retry after the suggested duration"
+		toolErr.Retryable = true
+	case apierrors.IsServiceUnavailable(err), apierrors.IsInternalError(err), apierrors.IsUnexpectedServerError(err):
+		toolErr.Remediation = "The API server or the aggregated API is unavailable, retry later"
+		toolErr.Retryable = true
+	}
+	return toolErr, retryAfter
+}
+
+// translateErrors translates the error returned by the tool into the structured error result.
+func translateErrors(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, req)
+		if err == nil {
+			return result, nil
+		}
+		slog.Error("Tool call failed", "tool", req.Params.Name, "err", err)
+		return newToolErrorResult(translateError(err)), nil
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/utils/ptr"
+)
+
+// maxDigestEntries is the maximum number of the deduplicated events returned for each namespace and kind.
+const maxDigestEntries = 20
+
+// EventDigest is the digest of the Warning events deduplicated by the reason and the involved object, and grouped
+// by the namespace and the kind of the involved objects.
+type EventDigest struct {
+	Namespace   string       `json:"namespace,omitempty"`
+	Since       string       `json:"since"`
+	Watched     string       `json:"watched,omitempty"`
+	Occurrences int32        `json:"occurrences"`
+	Unique      int          `json:"unique"`
+	Groups      []EventGroup `json:"groups"`
+}
+
+// EventGroup is the deduplicated events of the objects of a kind in a namespace, the noisiest first.
+type EventGroup struct {
+	Namespace   string           `json:"namespace"`
+	Kind        string           `json:"kind"`
+	Occurrences int32            `json:"occurrences"`
+	Reasons     map[string]int32 `json:"reasons"`
+	Events      []DedupedEvent   `json:"events"`
+	Omitted     int              `json:"omitted,omitempty"`
+}
+
+// DedupedEvent is the occurrences of a reason of an object, with the latest message.
+type DedupedEvent struct {
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Count     int32  `json:"count"`
+	Message   string `json:"message"`
+	FirstSeen string `json:"firstSeen"`
+	LastSeen  string `json:"lastSeen"`
+	Source    string `json:"source,omitempty"`
+}
+
+type eventKey struct {
+	namespace, kind, name, reason string
+}
+
+// eventDigester dedupes the events, the counts of the events are tracked by the uid since an event is updated in
+// place with the increased count when it recurs.
+type eventDigester struct {
+	since   time.Time
+	counts  map[types.UID]int32
+	entries map[eventKey]*dedupedEntry
+}
+
+type dedupedEntry struct {
+	DedupedEvent
+	first, last time.Time
+}
+
+func newEventDigester(since time.Time) *eventDigester {
+	return &eventDigester{
+		since:   since,
+		counts:  make(map[types.UID]int32),
+		entries: make(map[eventKey]*dedupedEntry),
+	}
+}
+
+func (d *eventDigester) add(event *corev1.Event) {
+	last := eventTime(event)
+	if event.Type != corev1.EventTypeWarning || last.Before(d.since) {
+		return
+	}
+	count := max(event.Count, 1)
+	if event.Series != nil {
+		count = max(event.Series.Count, count)
+	}
+	delta := count - d.counts[event.UID]
+	if delta <= 0 {
+		return
+	}
+	d.counts[event.UID] = count
+
+	first := event.FirstTimestamp.Time
+	if first.IsZero() || first.Before(d.since) {
+		first = last
+	}
+	key := eventKey{event.InvolvedObject.Namespace, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason}
+	if len(key.namespace) == 0 {
+		key.namespace = event.Namespace
+	}
+	entry, ok := d.entries[key]
+	if !ok {
+		entry = &dedupedEntry{DedupedEvent: DedupedEvent{Name: key.name, Reason: key.reason}, first: first}
+		d.entries[key] = entry
+	}
+	entry.Count += delta
+	if first.Before(entry.first) {
+		entry.first = first
+	}
+	if !last.Before(entry.last) {
+		entry.last = last
+		entry.Message = event.Message
+		entry.Source = eventSource(event)
+	}
+}
+
+func (d *eventDigester) digest() *EventDigest {
+	digest := &EventDigest{Unique: len(d.entries), Groups: make([]EventGroup, 0)}
+	groups := make(map[[2]string]*EventGroup)
+	for key, entry := range d.entries {
+		group, ok := groups[[2]string{key.namespace, key.kind}]
+		if !ok {
+			group = &EventGroup{Namespace: key.namespace, Kind: key.kind, Reasons: make(map[string]int32)}
+			groups[[2]string{key.namespace, key.kind}] = group
+		}
+		entry.FirstSeen = entry.first.UTC().Format(time.RFC3339)
+		entry.LastSeen = entry.last.UTC().Format(time.RFC3339)
+		group.Occurrences += entry.Count
+		group.Reasons[key.reason] += entry.Count
+		group.Events = append(group.Events, entry.DedupedEvent)
+		digest.Occurrences += entry.Count
+	}
+
+	for _, group := range groups {
+		sort.Slice(group.Events, func(i, j int) bool {
+			if group.Events[i].Count != group.Events[j].Count {
+				return group.Events[i].Count > group.Events[j].Count
+			}
+			return group.Events[i].LastSeen > group.Events[j].LastSeen
+		})
+		if len(group.Events) > maxDigestEntries {
+			group.Omitted = len(group.Events) - maxDigestEntries
+			group.Events = group.Events[:maxDigestEntries]
+		}
+		digest.Groups = append(digest.Groups, *group)
+	}
+	sort.Slice(digest.Groups, func(i, j int) bool {
+		if digest.Groups[i].Occurrences != digest.Groups[j].Occurrences {
+			return digest.Groups[i].Occurrences > digest.Groups[j].Occurrences
+		}
+		if digest.Groups[i].Namespace != digest.Groups[j].Namespace {
+			return digest.Groups[i].Namespace < digest.Groups[j].Namespace
+		}
+		return digest.Groups[i].Kind < digest.Groups[j].Kind
+	})
+	return digest
+}
+
+func eventSource(event *corev1.Event) string {
+	switch {
+	case len(event.ReportingController) > 0:
+		return event.ReportingController
+	case len(event.Source.Component) > 0:
+		return event.Source.Component
+	default:
+		return ""
+	}
+}
+
+func (s *Server) DigestEvents() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		since, err := time.ParseDuration(req.GetString("since", "1h"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+		watchFor := time.Duration(req.GetInt("watchSeconds", 0)) * time.Second
+
+		slog.Info("Digesting warning events", "namespace", namespace, "since", since, "watch", watchFor)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		options := metav1.ListOptions{FieldSelector: "type=" + corev1.EventTypeWarning}
+		events, err := cli.CoreV1().Events(namespace).List(ctx, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+		digester := newEventDigester(time.Now().Add(-since))
+		for i := range events.Items {
+			digester.add(&events.Items[i])
+		}
+
+		if watchFor > 0 {
+			// the watch starts from the listed version, so the events recurring meanwhile are counted by the delta.
+			options.ResourceVersion = events.ResourceVersion
+			options.TimeoutSeconds = ptr.To(int64(watchFor.Seconds()))
+			watcher, err := cli.CoreV1().Events(namespace).Watch(ctx, options)
+			if err != nil {
+				return nil, fmt.Errorf("failed to watch events: %w", err)
+			}
+			timer := time.NewTimer(watchFor)
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					break loop
+				case <-timer.C:
+					break loop
+				case e, ok := <-watcher.ResultChan():
+					if !ok {
+						break loop
+					}
+					if event, ok := e.Object.(*corev1.Event); ok && (e.Type == watch.Added || e.Type == watch.Modified) {
+						digester.add(event)
+					}
+				}
+			}
+			timer.Stop()
+			watcher.Stop()
+		}
+
+		digest := digester.digest()
+		digest.Namespace = namespace
+		digest.Since = since.String()
+		if watchFor > 0 {
+			digest.Watched = watchFor.String()
+		}
+		resp, err := json.Marshal(digest)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxObjectEvents is the maximum number of the recent events returned for an object.
+const maxObjectEvents = 20
+
+// EventSummary is the summary of an event of the object.
+type EventSummary struct {
+	Type     string `json:"type"`
+	Reason   string `json:"reason"`
+	Message  string `json:"message"`
+	Count    int32  `json:"count,omitempty"`
+	LastSeen string `json:"lastSeen,omitempty"`
+}
+
+// listObjectEvents returns the recent events of the object, the newest first.
+func listObjectEvents(ctx context.Context, cli kubernetes.Interface, namespace, kind, name string) ([]EventSummary, error) {
+	selector := fields.Set{"involvedObject.kind": kind, "involvedObject.name": name}.AsSelector()
+	events, err := cli.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	items := events.Items
+	sort.Slice(items, func(i, j int) bool {
+		return eventTime(&items[j]).Before(eventTime(&items[i]))
+	})
+	if len(items) > maxObjectEvents {
+		items = items[:maxObjectEvents]
+	}
+
+	summaries := make([]EventSummary, 0, len(items))
+	for i := range items {
+		summary := EventSummary{
+			Type:    items[i].Type,
+			Reason:  items[i].Reason,
+			Message: items[i].Message,
+			Count:   items[i].Count,
+		}
+		if t := eventTime(&items[i]); !t.IsZero() {
+			summary.LastSeen = t.UTC().Format(time.RFC3339)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// eventTime returns the last time the event was observed, the events.k8s.io events only set the event time.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,8 +8,9 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/remotecommand"
 )
@@ -32,46 +32,44 @@ func (s *Server) RunInContainer() func(ctx context.Context, req mcp.CallToolRequ
 			return nil, err
 		}
 		containerName := req.GetString("container", "")
-
-		slog.Info("Executing command in container", "resourceName", resourceName, "namespace", namespace, "container", containerName, "command", command)
-
-		cli, err := s.cb.GetClient()
+		output := req.GetString("output", "inline")
+		if output != "inline" && output != "resource" {
+			return nil, fmt.Errorf("invalid output %q, must be one of inline or resource", output)
+		}
+		kind, name, err := parseWorkloadReference(req.GetString("kind", ""), resourceName)
 		if err != nil {
 			return nil, err
 		}
 
-		// Check if the Pod exists and is not completed
-		pod, err := cli.CoreV1().Pods(namespace).Get(ctx, resourceName, metav1.GetOptions{})
+		slog.Info("Executing command in container", "kind", kind, "resourceName", name, "namespace", namespace, "container", containerName, "command", command)
+
+		cli, err := s.builder(ctx).GetClient()
 		if err != nil {
 			return nil, err
 		}
 
-		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
-			return nil, fmt.Errorf("cannot exec into a container in a completed pod, current phase is %s", pod.Status.Phase)
-		}
-
-		executor, err := s.createExecutor(namespace, resourceName, &corev1.PodExecOptions{
-			Container: containerName,
-			Command:   command,
-			Stdin:     false,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       false,
-		})
+		pod, err := execTargetPod(ctx, cli, namespace, kind, name)
 		if err != nil {
 			return nil, err
 		}
 
-		var stdout = bytes.NewBuffer(make([]byte, 0))
-		var stderr = bytes.NewBuffer(make([]byte, 0))
-		if err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr, Tty: false}); err != nil {
+		stdout, stderr, err := s.streamInContainer(ctx, namespace, pod.Name, containerName, command)
+		if err != nil {
 			return nil, err
 		}
 
-		resp, err := json.Marshal(map[string]string{
-			"stdout": stdout.String(),
-			"stderr": stderr.String(),
-		})
+		result := &ExecOutput{Pod: pod.Name, StdoutBytes: stdout.total, StdoutTruncated: stdout.truncated(), StderrTruncated: stderr.truncated()}
+		result.Stderr, result.StderrEncoding = stderr.encode()
+		if output == "resource" {
+			result.ContentType = detectContentType(stdout.Bytes())
+			result.StdoutResource = s.outputs.put(sessionID(ctx), pod.Name, stdout.Bytes(), result.ContentType)
+		} else {
+			result.Stdout, result.StdoutEncoding = stdout.encode()
+			if len(result.StdoutEncoding) > 0 {
+				result.ContentType = detectContentType(stdout.Bytes())
+			}
+		}
+		resp, err := json.Marshal(result)
 		if err != nil {
 			return nil, err
 		}
@@ -79,16 +77,83 @@ func (s *Server) RunInContainer() func(ctx context.Context, req mcp.CallToolRequ
 	}
 }
 
-// createExecutor:
-// copy from
+// ExecOutput is the output of the command run in the container. The output which is not valid UTF-8, e.g. of tar or
+// pg_dump, is encoded in base64 with its detected content type, or saved as a resource read by its URI. The outputs
+// beyond the limit of the server are truncated, StdoutBytes is the bytes written by the command in total.
+type ExecOutput struct {
+	Pod             string `json:"pod"`
+	Stdout          string `json:"stdout"`
+	StdoutEncoding  string `json:"stdoutEncoding,omitempty"`
+	StdoutBytes     int64  `json:"stdoutBytes"`
+	StdoutTruncated bool   `json:"stdoutTruncated,omitempty"`
+	StdoutResource  string `json:"stdoutResource,omitempty"`
+	ContentType     string `json:"contentType,omitempty"`
+	Stderr          string `json:"stderr"`
+	StderrEncoding  string `json:"stderrEncoding,omitempty"`
+	StderrTruncated bool   `json:"stderrTruncated,omitempty"`
+}
+
+// execTargetPod resolves the workload to the pod to exec into like `kubectl exec deploy/foo`, i.e. the first
+// running pod preferring the ready and newer ones. The pod of the Pod kind must not be completed.
+func execTargetPod(ctx context.Context, cli kubernetes.Interface, namespace, kind, name string) (*corev1.Pod, error) {
+	pods, err := resolveWorkloadPods(ctx, cli, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "Pod" {
+		pod := &pods[0]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			return nil, fmt.Errorf("cannot exec into a container in a completed pod, current phase is %s", pod.Status.Phase)
+		}
+		return pod, nil
+	}
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning && pods[i].DeletionTimestamp == nil {
+			return &pods[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no running pods found for %s %s/%s", kind, namespace, name)
+}
+
+// execInContainer executes the command in the specified container and returns the captured stdout and stderr.
+func (s *Server) execInContainer(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+	stdout, stderr, err := s.streamInContainer(ctx, namespace, name, container, command)
+	if stdout == nil {
+		return "", "", err
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// streamInContainer executes the command in the specified container and returns the captured stdout and stderr
+// as they are, e.g. the binary output, up to the maximum output bytes of the server.
+func (s *Server) streamInContainer(ctx context.Context, namespace, name, container string, command []string) (*cappedBuffer, *cappedBuffer, error) {
+	executor, err := s.createExecutor(ctx, namespace, name, "exec", &corev1.PodExecOptions{
+		Container: container,
+		Command:   command,
+		Stdin:     false,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stdout, stderr := newCappedBuffer(s.maxExecBytes), newCappedBuffer(s.maxExecBytes)
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr, Tty: false})
+	return stdout, stderr, err
+}
+
+// createExecutor creates the executor streaming the exec or attach subresource of the pod with the options.
+// Copy from
 // https://github.com/kubernetes/kubernetes/blob/bd44685eadc64c8cd46a8259f027f57ba9724a85/staging/src/k8s.io/kubectl/pkg/cmd/exec/exec.go#L146-L166
-func (s *Server) createExecutor(namespace, name string, podExecOptions *corev1.PodExecOptions) (remotecommand.Executor, error) {
-	cli, err := s.cb.GetClient()
+func (s *Server) createExecutor(ctx context.Context, namespace, name, subresource string, options runtime.Object) (remotecommand.Executor, error) {
+	cli, err := s.builder(ctx).GetClient()
 	if err != nil {
 		return nil, err
 	}
 
-	cfg, err := s.cb.LoadRESTConfig()
+	cfg, err := s.builder(ctx).LoadRESTConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -99,8 +164,8 @@ func (s *Server) createExecutor(namespace, name string, podExecOptions *corev1.P
 		Resource("pods").
 		Namespace(namespace).
 		Name(name).
-		SubResource("exec").
-		VersionedParams(podExecOptions, scheme.ParameterCodec)
+		SubResource(subresource).
+		VersionedParams(options, scheme.ParameterCodec)
 
 	spdyExec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
 	if err != nil {
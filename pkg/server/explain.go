@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	explainv2 "k8s.io/kubectl/pkg/explain/v2"
+)
+
+// ExplainResource returns a function that describes the fields of a resource, like `kubectl explain <kind>.<field>`.
+func (s *Server) ExplainResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		field := req.GetString("field", "")
+		apiVersion := req.GetString("apiVersion", "")
+		recursive := req.GetBool("recursive", false)
+
+		slog.Info("Explaining resource", "kind", kind, "field", field, "apiVersion", apiVersion, "recursive", recursive)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+
+		gvr, err := lookupGroupVersionResource(discoveryClient, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(apiVersion) > 0 {
+			gv, err := schema.ParseGroupVersion(apiVersion)
+			if err != nil {
+				return nil, fmt.Errorf("invalid apiVersion %q: %w", apiVersion, err)
+			}
+			if gv.Group != gvr.Group {
+				return nil, fmt.Errorf("kind %q is served by group %q, not %q", kind, gvr.Group, gv.Group)
+			}
+			gvr.Version = gv.Version
+		}
+
+		var fieldsPath []string
+		if trimmed := strings.Trim(field, "."); len(trimmed) > 0 {
+			fieldsPath = strings.Split(trimmed, ".")
+		}
+
+		out := bytes.NewBuffer(make([]byte, 0))
+		if err = explainv2.PrintModelDescription(fieldsPath, out, discoveryClient.OpenAPIV3(), gvr, recursive, "plaintext"); err != nil {
+			return nil, fmt.Errorf("failed to explain resource: %w", err)
+		}
+		return mcp.NewToolResultText(out.String()), nil
+	}
+}
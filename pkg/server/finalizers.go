@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// ObjectFinalizers is the finalizers of an object and whether it's being deleted.
+type ObjectFinalizers struct {
+	Kind        string   `json:"kind"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Name        string   `json:"name"`
+	Finalizers  []string `json:"finalizers"`
+	Terminating string   `json:"terminating,omitempty"`
+	Removed     string   `json:"removed,omitempty"`
+	Warning     string   `json:"warning,omitempty"`
+}
+
+// StuckDeletion is an object marked for deletion longer than the threshold and the finalizers it waits for.
+type StuckDeletion struct {
+	Kind        string   `json:"kind"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Name        string   `json:"name"`
+	Finalizers  []string `json:"finalizers"`
+	Terminating string   `json:"terminating"`
+}
+
+// StuckDeletionReport is the objects stuck in the deletion across the kinds.
+type StuckDeletionReport struct {
+	OlderThan string            `json:"olderThan"`
+	Objects   []StuckDeletion   `json:"objects"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+func (s *Server) ManageFinalizers() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		remove := req.GetString("remove", "")
+		force := req.GetBool("force", false)
+
+		kind, resourceName, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
+		if err != nil {
+			return nil, err
+		}
+		if len(remove) > 0 && !force {
+			return nil, fmt.Errorf("removing the finalizer %q skips the cleanup of its controller and may leak the external resources, set force to confirm", remove)
+		}
+
+		slog.Info("Managing finalizers", "kind", kind, "name", resourceName, "namespace", namespace, "remove", remove)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		gvr, namespaced, err := lookupKindResource(discoveryClient, kind)
+		if err != nil {
+			return nil, err
+		}
+		if namespaced && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		if !namespaced {
+			namespace = ""
+		}
+
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		ri := dynamicClient.Resource(gvr).Namespace(namespace)
+		obj, err := ri.Get(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource: %w", err)
+		}
+
+		if len(remove) > 0 {
+			index := slices.Index(obj.GetFinalizers(), remove)
+			if index < 0 {
+				return nil, fmt.Errorf("finalizer %q not found on %s/%s, the finalizers are %v", remove, kind, resourceName, obj.GetFinalizers())
+			}
+			// the test operation fails the patch if the finalizers are changed since the object was read.
+			path := fmt.Sprintf("/metadata/finalizers/%d", index)
+			patch, err := json.Marshal([]map[string]any{
+				{"op": "test", "path": path, "value": remove},
+				{"op": "remove", "path": path},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if obj, err = ri.Patch(ctx, resourceName, types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+				return nil, fmt.Errorf("failed to remove finalizer: %w", err)
+			}
+		}
+
+		result := objectFinalizers(obj)
+		if len(remove) > 0 {
+			result.Removed = remove
+			if obj.GetDeletionTimestamp() == nil {
+				result.Warning = "the object is not being deleted, its controller may add the finalizer back"
+			}
+		}
+		resp, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) FindStuckDeletions() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kinds := req.GetStringSlice("kinds", slices.Concat(defaultSearchKinds, []string{"Namespace", "PersistentVolume"}))
+		namespace := req.GetString("namespace", "")
+		olderThan, err := time.ParseDuration(req.GetString("olderThan", "10m"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid olderThan: %w", err)
+		}
+
+		slog.Info("Finding stuck deletions", "kinds", kinds, "namespace", namespace, "olderThan", olderThan)
+
+		now := time.Now()
+		report := &StuckDeletionReport{OlderThan: olderThan.String(), Objects: make([]StuckDeletion, 0)}
+		// the match function is called with the lock of the search held, the stuck objects are collected there.
+		search, err := s.searchResources(ctx, kinds, namespace, metav1.ListOptions{}, func(obj *unstructured.Unstructured) bool {
+			ts := obj.GetDeletionTimestamp()
+			if ts == nil || now.Sub(ts.Time) < olderThan {
+				return false
+			}
+			report.Objects = append(report.Objects, StuckDeletion{
+				Kind:        obj.GetKind(),
+				Namespace:   obj.GetNamespace(),
+				Name:        obj.GetName(),
+				Finalizers:  obj.GetFinalizers(),
+				Terminating: duration.HumanDuration(now.Sub(ts.Time)),
+			})
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(search.Errors) > 0 {
+			report.Errors = search.Errors
+		}
+		sort.Slice(report.Objects, func(i, j int) bool {
+			a, b := report.Objects[i], report.Objects[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func objectFinalizers(obj *unstructured.Unstructured) ObjectFinalizers {
+	result := ObjectFinalizers{
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Finalizers: obj.GetFinalizers(),
+	}
+	if result.Finalizers == nil {
+		result.Finalizers = make([]string, 0)
+	}
+	if ts := obj.GetDeletionTimestamp(); ts != nil {
+		result.Terminating = duration.HumanDuration(time.Since(ts.Time))
+	}
+	return result
+}
@@ -0,0 +1,344 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/util/jsonpath"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	// maxFleetConcurrency is the maximum contexts queried concurrently by the fleet tools.
+	maxFleetConcurrency = 8
+	// defaultFleetMaxResults is the default maximum items listed per context.
+	defaultFleetMaxResults = 100
+)
+
+// FleetList is the objects of a kind listed across the contexts, grouped per context in the order of the contexts.
+type FleetList struct {
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Clusters  []ClusterList `json:"clusters"`
+}
+
+// ClusterList is the objects listed in a context, the error is set if the context failed rather than failing the
+// whole list.
+type ClusterList struct {
+	Context   string      `json:"context"`
+	Count     int         `json:"count"`
+	Items     []FleetItem `json:"items"`
+	Truncated bool        `json:"truncated,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// FleetItem is an object listed in a context, the value is the fields extracted by the JSONPath if specified.
+type FleetItem struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Age       string `json:"age"`
+	Value     any    `json:"value,omitempty"`
+}
+
+// fleetContexts returns the specified contexts after checking they exist in the kubeconfig, or all contexts sorted
+// by the name if none is specified.
+func (s *Server) fleetContexts(contexts []string) ([]string, error) {
+	cfg, err := s.cb.LoadRawConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		for name := range cfg.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+		return contexts, nil
+	}
+	for _, name := range contexts {
+		if _, ok := cfg.Contexts[name]; !ok {
+			return nil, fmt.Errorf("context %q is not found in the kubeconfig", name)
+		}
+	}
+	return contexts, nil
+}
+
+// fanOutContexts runs the function for each context concurrently with at most maxFleetConcurrency in flight.
+func fanOutContexts(contexts []string, fn func(i int, name string)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxFleetConcurrency)
+	for i, name := range contexts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i, name)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Server) ListResourcesMulti() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		kind = definition.ResolveKind(kind)
+		namespace := req.GetString("namespace", "")
+		name := req.GetString("name", "")
+		labelSelector := req.GetString("labelSelector", "")
+		fieldSelector := req.GetString("fieldSelector", "")
+		maxResults := req.GetInt("maxResults", defaultFleetMaxResults)
+		expr := req.GetString("jsonpath", "")
+		if len(expr) > 0 {
+			// the expression is parsed once to fail fast, each context parses its own since the parsed JSONPath
+			// is not safe for the concurrent use.
+			if _, err = parseJSONPath(expr); err != nil {
+				return nil, err
+			}
+		}
+		contexts, err := s.fleetContexts(req.GetStringSlice("contexts", nil))
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Listing resources across contexts", "kind", kind, "namespace", namespace, "name", name, "contexts", contexts)
+
+		options := metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}
+		if len(name) > 0 {
+			options.FieldSelector = "metadata.name=" + name
+			if len(fieldSelector) > 0 {
+				options.FieldSelector += "," + fieldSelector
+			}
+		}
+		if maxResults > 0 {
+			options.Limit = int64(maxResults)
+		}
+
+		result := &FleetList{Kind: kind, Namespace: namespace, Clusters: make([]ClusterList, len(contexts))}
+		now := time.Now()
+		fanOutContexts(contexts, func(i int, contextName string) {
+			cluster := ClusterList{Context: contextName, Items: make([]FleetItem, 0)}
+			defer func() { result.Clusters[i] = cluster }()
+
+			items, err := s.listContextResources(ctx, contextName, kind, namespace, options)
+			if err != nil {
+				cluster.Error = err.Error()
+				return
+			}
+			cluster.Truncated = len(items.GetContinue()) > 0
+			var j *jsonpath.JSONPath
+			if len(expr) > 0 {
+				j, _ = parseJSONPath(expr)
+			}
+			for k := range items.Items {
+				obj := &items.Items[k]
+				item := FleetItem{
+					Namespace: obj.GetNamespace(),
+					Name:      obj.GetName(),
+					Age:       duration.HumanDuration(now.Sub(obj.GetCreationTimestamp().Time)),
+				}
+				if j != nil {
+					if item.Value, err = projectJSONPath(j, obj.Object); err != nil {
+						cluster.Error = err.Error()
+						return
+					}
+				}
+				cluster.Items = append(cluster.Items, item)
+			}
+			cluster.Count = len(cluster.Items)
+		})
+
+		resp, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// listContextResources lists the objects of the kind in the context, in all namespaces if the namespace is empty.
+func (s *Server) listContextResources(ctx context.Context, contextName, kind, namespace string, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	cb := s.cb.WithContext(contextName)
+	discoveryClient, err := cb.GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := cb.GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	gvResource, namespaced, err := lookupKindResource(discoveryClient, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var items *unstructured.UnstructuredList
+	if namespaced && len(namespace) > 0 {
+		items, err = dynamicClient.Resource(gvResource).Namespace(namespace).List(ctx, options)
+	} else {
+		items, err = dynamicClient.Resource(gvResource).List(ctx, options)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return items, nil
+}
+
+// maxDriftFields is the maximum different fields reported per context.
+const maxDriftFields = 100
+
+// ResourceComparison is an object compared across the contexts, the first context is the baseline the others are
+// compared against.
+type ResourceComparison struct {
+	Kind      string         `json:"kind"`
+	Namespace string         `json:"namespace,omitempty"`
+	Name      string         `json:"name"`
+	Baseline  string         `json:"baseline"`
+	Contexts  []ContextDrift `json:"contexts"`
+	Identical bool           `json:"identical"`
+}
+
+// ContextDrift is the fields of the object in a context which differ from the baseline.
+type ContextDrift struct {
+	Context     string       `json:"context"`
+	Missing     bool         `json:"missing,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Differences []DriftField `json:"differences,omitempty"`
+	// Omitted is the number of the differences beyond maxDriftFields.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// DriftField is a field whose value in the context differs from the baseline, the missing values are omitted.
+type DriftField struct {
+	Path     string `json:"path"`
+	Baseline any    `json:"baseline,omitempty"`
+	Value    any    `json:"value,omitempty"`
+}
+
+func (s *Server) CompareResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		kind = definition.ResolveKind(kind)
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		includeStatus := req.GetBool("includeStatus", false)
+		contexts, err := s.fleetContexts(req.GetStringSlice("contexts", nil))
+		if err != nil {
+			return nil, err
+		}
+		if len(contexts) < 2 {
+			return nil, fmt.Errorf("at least two contexts are required to compare, got %d", len(contexts))
+		}
+
+		slog.Info("Comparing resource across contexts", "kind", kind, "namespace", namespace, "name", name, "contexts", contexts)
+
+		objs := make([]map[string]any, len(contexts))
+		drifts := make([]ContextDrift, len(contexts))
+		fanOutContexts(contexts, func(i int, contextName string) {
+			drifts[i].Context = contextName
+			obj, err := s.getContextResource(ctx, contextName, kind, namespace, name)
+			switch {
+			case apierrors.IsNotFound(err):
+				drifts[i].Missing = true
+			case err != nil:
+				drifts[i].Error = err.Error()
+			default:
+				objs[i] = comparableObject(obj, includeStatus)
+			}
+		})
+		if objs[0] == nil {
+			if len(drifts[0].Error) > 0 {
+				return nil, fmt.Errorf("failed to get %s %s in the baseline context %s: %s", kind, name, contexts[0], drifts[0].Error)
+			}
+			return nil, fmt.Errorf("%s %s is not found in the baseline context %s", kind, name, contexts[0])
+		}
+
+		comparison := &ResourceComparison{Kind: kind, Name: name, Baseline: contexts[0], Contexts: drifts[1:], Identical: true}
+		if ns, _, _ := unstructured.NestedString(objs[0], "metadata", "namespace"); len(ns) > 0 {
+			comparison.Namespace = ns
+		}
+		for i := 1; i < len(contexts); i++ {
+			drift := &drifts[i]
+			if objs[i] == nil {
+				comparison.Identical = false
+				continue
+			}
+			var diffs []FieldDiff
+			diffFields("", objs[0], objs[i], &diffs)
+			sort.Slice(diffs, func(a, b int) bool { return diffs[a].Path < diffs[b].Path })
+			for _, diff := range diffs {
+				if len(drift.Differences) >= maxDriftFields {
+					drift.Omitted++
+					continue
+				}
+				drift.Differences = append(drift.Differences, DriftField{Path: diff.Path, Baseline: diff.Latest, Value: diff.Desired})
+			}
+			if len(diffs) > 0 {
+				comparison.Identical = false
+			}
+		}
+
+		resp, err := json.Marshal(comparison)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// getContextResource gets the object of the kind in the context, the namespace is ignored for the cluster-scoped
+// kinds.
+func (s *Server) getContextResource(ctx context.Context, contextName, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	cb := s.cb.WithContext(contextName)
+	discoveryClient, err := cb.GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := cb.GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	gvResource, namespaced, err := lookupKindResource(discoveryClient, kind)
+	if err != nil {
+		return nil, err
+	}
+	if namespaced {
+		return dynamicClient.Resource(gvResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	return dynamicClient.Resource(gvResource).Get(ctx, name, metav1.GetOptions{})
+}
+
+// comparableObject returns the content of the object without the fields populated by the cluster, which always
+// differ between the clusters, the status is kept if included.
+func comparableObject(obj *unstructured.Unstructured, includeStatus bool) map[string]any {
+	status, hasStatus := obj.Object["status"]
+	exportObject(obj)
+	if includeStatus && hasStatus {
+		obj.Object["status"] = status
+	}
+	return obj.Object
+}
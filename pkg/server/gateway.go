@@ -0,0 +1,464 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// RouteTrace is the HTTPRoute rules matching the request, sorted by the precedence of the Gateway API. The first
+// match without the conditions handles the request.
+type RouteTrace struct {
+	Hostname string       `json:"hostname"`
+	Path     string       `json:"path"`
+	Matches  []RouteMatch `json:"matches"`
+}
+
+// RouteMatch is the rule of the HTTPRoute matching the request with the gateways and the backends handling it.
+type RouteMatch struct {
+	Route    string              `json:"route"`
+	Rule     int                 `json:"rule"`
+	Match    string              `json:"match"`
+	Gateways []GatewayAttachment `json:"gateways"`
+	Backends []RouteBackend      `json:"backends"`
+	// Conditions are the method, header and query parameter matches which depend on the request.
+	Conditions []string `json:"conditions,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// GatewayAttachment is the listener of the Gateway which the route is attached to.
+type GatewayAttachment struct {
+	Gateway    string   `json:"gateway"`
+	Class      string   `json:"class"`
+	Listener   string   `json:"listener"`
+	Addresses  []string `json:"addresses,omitempty"`
+	Programmed string   `json:"programmed,omitempty"`
+	Accepted   string   `json:"accepted,omitempty"`
+}
+
+// RouteBackend is the backend of the rule.
+type RouteBackend struct {
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	Port           int32  `json:"port,omitempty"`
+	Weight         int32  `json:"weight"`
+	ReadyEndpoints *int   `json:"readyEndpoints,omitempty"`
+	Warning        string `json:"warning,omitempty"`
+}
+
+// httpRoute is a minimal copy of the gateway.networking.k8s.io HTTPRoute, only the traced fields are kept to avoid
+// depending on the gateway-api module.
+type httpRoute struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec struct {
+		ParentRefs []parentReference `json:"parentRefs,omitempty"`
+		Hostnames  []string          `json:"hostnames,omitempty"`
+		Rules      []struct {
+			Matches     []httpRouteMatch `json:"matches,omitempty"`
+			BackendRefs []backendRef     `json:"backendRefs,omitempty"`
+		} `json:"rules,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Parents []struct {
+			ParentRef  parentReference    `json:"parentRef"`
+			Conditions []metav1.Condition `json:"conditions,omitempty"`
+		} `json:"parents,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+type parentReference struct {
+	Group       *string `json:"group,omitempty"`
+	Kind        *string `json:"kind,omitempty"`
+	Namespace   *string `json:"namespace,omitempty"`
+	Name        string  `json:"name"`
+	SectionName *string `json:"sectionName,omitempty"`
+	Port        *int32  `json:"port,omitempty"`
+}
+
+type httpRouteMatch struct {
+	Path *struct {
+		Type  *string `json:"type,omitempty"`
+		Value *string `json:"value,omitempty"`
+	} `json:"path,omitempty"`
+	Headers     []struct{} `json:"headers,omitempty"`
+	QueryParams []struct{} `json:"queryParams,omitempty"`
+	Method      *string    `json:"method,omitempty"`
+}
+
+type backendRef struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+	Port      *int32  `json:"port,omitempty"`
+	Weight    *int32  `json:"weight,omitempty"`
+}
+
+// gateway is a minimal copy of the gateway.networking.k8s.io Gateway.
+type gateway struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec struct {
+		GatewayClassName string `json:"gatewayClassName"`
+		Listeners        []struct {
+			Name          string  `json:"name"`
+			Hostname      *string `json:"hostname,omitempty"`
+			Port          int32   `json:"port"`
+			Protocol      string  `json:"protocol"`
+			AllowedRoutes *struct {
+				Namespaces *struct {
+					From     *string               `json:"from,omitempty"`
+					Selector *metav1.LabelSelector `json:"selector,omitempty"`
+				} `json:"namespaces,omitempty"`
+			} `json:"allowedRoutes,omitempty"`
+		} `json:"listeners"`
+	} `json:"spec"`
+	Status struct {
+		Addresses []struct {
+			Value string `json:"value"`
+		} `json:"addresses,omitempty"`
+		Conditions []metav1.Condition `json:"conditions,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+// routeCandidate is a match of a rule with the precedence keys of the Gateway API.
+type routeCandidate struct {
+	route         *httpRoute
+	rule          int
+	match         string
+	exactHostname bool
+	pathRank      int
+	pathLength    int
+	method        bool
+	headers       int
+	queryParams   int
+}
+
+func (s *Server) TraceRoute() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		hostname, err := req.RequireString("hostname")
+		if err != nil {
+			return nil, err
+		}
+		hostname = strings.ToLower(hostname)
+		path := req.GetString("path", "/")
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		namespace := req.GetString("namespace", "")
+
+		slog.Info("Tracing route", "hostname", hostname, "path", path, "namespace", namespace)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		routeGVR, _, err := lookupGroupKindResource(discoveryClient, schema.GroupKind{Group: definition.GatewayGroup, Kind: "HTTPRoute"})
+		if err != nil {
+			return nil, fmt.Errorf("the Gateway API is not installed in the cluster: %w", err)
+		}
+		gatewayGVR, _, err := lookupGroupKindResource(discoveryClient, schema.GroupKind{Group: definition.GatewayGroup, Kind: "Gateway"})
+		if err != nil {
+			return nil, fmt.Errorf("the Gateway API is not installed in the cluster: %w", err)
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		list, err := dynamicClient.Resource(routeGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list httproutes: %w", err)
+		}
+		var candidates []routeCandidate
+		for i := range list.Items {
+			route := &httpRoute{}
+			if err = runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].UnstructuredContent(), route); err != nil {
+				slog.Warn("Failed to decode httproute", "name", list.Items[i].GetName(), "err", err)
+				continue
+			}
+			candidates = append(candidates, matchHTTPRoute(route, hostname, path)...)
+		}
+		sortRouteCandidates(candidates)
+
+		trace := RouteTrace{Hostname: hostname, Path: path, Matches: make([]RouteMatch, 0, len(candidates))}
+		gateways := make(map[string]*gateway)
+		for _, candidate := range candidates {
+			match := RouteMatch{
+				Route: candidate.route.Namespace + "/" + candidate.route.Name,
+				Rule:  candidate.rule,
+				Match: candidate.match,
+			}
+			if candidate.method {
+				match.Conditions = append(match.Conditions, "the method of the request must match")
+			}
+			if candidate.headers > 0 {
+				match.Conditions = append(match.Conditions, fmt.Sprintf("%d header match(es) must match", candidate.headers))
+			}
+			if candidate.queryParams > 0 {
+				match.Conditions = append(match.Conditions, fmt.Sprintf("%d query parameter match(es) must match", candidate.queryParams))
+			}
+			match.Gateways, match.Warnings = attachedGateways(ctx, dynamicClient, gatewayGVR, gateways, candidate.route, hostname)
+			match.Backends = routeBackends(ctx, cli, candidate.route, candidate.rule)
+			trace.Matches = append(trace.Matches, match)
+		}
+
+		resp, err := json.Marshal(trace)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// matchHTTPRoute returns the matches of the route rules for the hostname and the path.
+func matchHTTPRoute(route *httpRoute, hostname, path string) []routeCandidate {
+	// the route without the hostnames matches the hostnames of the listeners.
+	exactHostname, matched := false, len(route.Spec.Hostnames) == 0
+	for _, h := range route.Spec.Hostnames {
+		if matchHostname(h, hostname) {
+			matched = true
+			exactHostname = exactHostname || !strings.HasPrefix(h, "*")
+		}
+	}
+	if !matched {
+		return nil
+	}
+
+	var candidates []routeCandidate
+	for i, rule := range route.Spec.Rules {
+		matches := rule.Matches
+		if len(matches) == 0 {
+			matches = []httpRouteMatch{{}}
+		}
+		for _, m := range matches {
+			pathType, value := "PathPrefix", "/"
+			if m.Path != nil {
+				pathType, value = ptr.Deref(m.Path.Type, pathType), ptr.Deref(m.Path.Value, value)
+			}
+			candidate := routeCandidate{
+				route:         route,
+				rule:          i,
+				match:         pathType + " " + value,
+				exactHostname: exactHostname,
+				pathLength:    len(value),
+				method:        m.Method != nil,
+				headers:       len(m.Headers),
+				queryParams:   len(m.QueryParams),
+			}
+			switch pathType {
+			case "Exact":
+				if path != value {
+					continue
+				}
+				candidate.pathRank = 2
+			case "PathPrefix":
+				prefix := strings.TrimSuffix(value, "/")
+				if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+					continue
+				}
+				candidate.pathRank = 1
+			case "RegularExpression":
+				// the regular expressions are implementation-specific, they are matched against the whole path.
+				re, err := regexp.Compile("^(?:" + value + ")$")
+				if err != nil || !re.MatchString(path) {
+					continue
+				}
+			default:
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// sortRouteCandidates sorts the matches by the precedence of the Gateway API: the exact hostname, the exact path,
+// the longest path prefix, the method, the number of the header and the query parameter matches, then the oldest
+// route, the route name and the rule order.
+func sortRouteCandidates(candidates []routeCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.exactHostname != b.exactHostname:
+			return a.exactHostname
+		case a.pathRank != b.pathRank:
+			return a.pathRank > b.pathRank
+		case a.pathLength != b.pathLength:
+			return a.pathLength > b.pathLength
+		case a.method != b.method:
+			return a.method
+		case a.headers != b.headers:
+			return a.headers > b.headers
+		case a.queryParams != b.queryParams:
+			return a.queryParams > b.queryParams
+		case !a.route.CreationTimestamp.Equal(&b.route.CreationTimestamp):
+			return a.route.CreationTimestamp.Before(&b.route.CreationTimestamp)
+		case a.route.Namespace+"/"+a.route.Name != b.route.Namespace+"/"+b.route.Name:
+			return a.route.Namespace+"/"+a.route.Name < b.route.Namespace+"/"+b.route.Name
+		default:
+			return a.rule < b.rule
+		}
+	})
+}
+
+// matchHostname matches the hostname against the exact or the wildcard hostname, e.g. *.example.com matches
+// foo.example.com and foo.bar.example.com but not example.com.
+func matchHostname(pattern, hostname string) bool {
+	pattern = strings.ToLower(pattern)
+	if suffix, found := strings.CutPrefix(pattern, "*"); found {
+		return strings.HasSuffix(hostname, suffix) && len(hostname) > len(suffix)
+	}
+	return pattern == hostname
+}
+
+// attachedGateways returns the listeners of the parent Gateways which accept the route for the hostname.
+func attachedGateways(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, cache map[string]*gateway, route *httpRoute, hostname string) ([]GatewayAttachment, []string) {
+	attachments := make([]GatewayAttachment, 0)
+	var warnings []string
+	for _, ref := range route.Spec.ParentRefs {
+		if ptr.Deref(ref.Group, definition.GatewayGroup) != definition.GatewayGroup || ptr.Deref(ref.Kind, "Gateway") != "Gateway" {
+			continue
+		}
+		namespace := ptr.Deref(ref.Namespace, route.Namespace)
+		key := namespace + "/" + ref.Name
+		gw, ok := cache[key]
+		if !ok {
+			obj, err := dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if err == nil {
+				gw = &gateway{}
+				err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), gw)
+			}
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					warnings = append(warnings, fmt.Sprintf("the parent gateway %s is not found", key))
+				} else {
+					warnings = append(warnings, fmt.Sprintf("failed to get the parent gateway %s: %v", key, err))
+				}
+				gw = nil
+			}
+			cache[key] = gw
+		}
+		if gw == nil {
+			continue
+		}
+
+		accepted := ""
+		for _, parent := range route.Status.Parents {
+			if parent.ParentRef.Name == ref.Name && ptr.Deref(parent.ParentRef.Namespace, route.Namespace) == namespace &&
+				ptr.Deref(parent.ParentRef.SectionName, "") == ptr.Deref(ref.SectionName, "") {
+				if c := meta.FindStatusCondition(parent.Conditions, "Accepted"); c != nil {
+					accepted = string(c.Status)
+					if c.Status != metav1.ConditionTrue {
+						warnings = append(warnings, fmt.Sprintf("the route is not accepted by the gateway %s (%s): %s", key, c.Reason, c.Message))
+					}
+				}
+			}
+		}
+		programmed := ""
+		if c := meta.FindStatusCondition(gw.Status.Conditions, "Programmed"); c != nil {
+			programmed = string(c.Status)
+		}
+		addresses := make([]string, 0, len(gw.Status.Addresses))
+		for _, address := range gw.Status.Addresses {
+			addresses = append(addresses, address.Value)
+		}
+
+		attached := false
+		for _, listener := range gw.Spec.Listeners {
+			if ref.SectionName != nil && *ref.SectionName != listener.Name {
+				continue
+			}
+			if ref.Port != nil && *ref.Port != listener.Port {
+				continue
+			}
+			if listener.Protocol != "HTTP" && listener.Protocol != "HTTPS" {
+				continue
+			}
+			if listener.Hostname != nil && !matchHostname(*listener.Hostname, hostname) {
+				continue
+			}
+			from := "Same"
+			if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil {
+				from = ptr.Deref(listener.AllowedRoutes.Namespaces.From, from)
+			}
+			switch {
+			case from == "Same" && gw.Namespace != route.Namespace:
+				continue
+			case from == "Selector":
+				warnings = append(warnings, fmt.Sprintf("the listener %s/%s only allows the routes from the namespaces matching its selector", key, listener.Name))
+			}
+			attached = true
+			attachments = append(attachments, GatewayAttachment{
+				Gateway:    key,
+				Class:      gw.Spec.GatewayClassName,
+				Listener:   fmt.Sprintf("%s (%s/%d)", listener.Name, listener.Protocol, listener.Port),
+				Addresses:  addresses,
+				Programmed: programmed,
+				Accepted:   accepted,
+			})
+		}
+		if !attached {
+			warnings = append(warnings, fmt.Sprintf("no listener of the gateway %s accepts the route for hostname %s", key, hostname))
+		}
+	}
+	if len(attachments) == 0 {
+		warnings = append(warnings, "the route is not attached to any gateway listener, it does not handle the request")
+	}
+	return attachments, warnings
+}
+
+// routeBackends returns the backends of the rule with the ready endpoints of the services.
+func routeBackends(ctx context.Context, cli kubernetes.Interface, route *httpRoute, rule int) []RouteBackend {
+	backends := make([]RouteBackend, 0, len(route.Spec.Rules[rule].BackendRefs))
+	for _, ref := range route.Spec.Rules[rule].BackendRefs {
+		namespace := ptr.Deref(ref.Namespace, route.Namespace)
+		backend := RouteBackend{
+			Kind:   ptr.Deref(ref.Kind, "Service"),
+			Name:   namespace + "/" + ref.Name,
+			Port:   ptr.Deref(ref.Port, 0),
+			Weight: ptr.Deref(ref.Weight, 1),
+		}
+		if ptr.Deref(ref.Group, "") != "" || backend.Kind != "Service" {
+			backends = append(backends, backend)
+			continue
+		}
+
+		if _, err := cli.CoreV1().Services(namespace).Get(ctx, ref.Name, metav1.GetOptions{}); err != nil {
+			backend.Warning = fmt.Sprintf("failed to get the service: %v", err)
+		} else if _, ready, _, err := serviceEndpoints(ctx, cli, namespace, ref.Name); err != nil {
+			backend.Warning = err.Error()
+		} else {
+			backend.ReadyEndpoints = &ready
+			if ready == 0 {
+				backend.Warning = "the service has no ready endpoint"
+			}
+		}
+		if namespace != route.Namespace && len(backend.Warning) == 0 {
+			backend.Warning = fmt.Sprintf("the backend in namespace %s requires a ReferenceGrant from the namespace %s", namespace, route.Namespace)
+		}
+		backends = append(backends, backend)
+	}
+	return backends
+}
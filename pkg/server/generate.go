@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+// manifestParams are the high-level parameters of the generated manifests.
+type manifestParams struct {
+	name        string
+	namespace   string
+	image       string
+	command     []string
+	port        int32
+	replicas    int32
+	schedule    string
+	serviceType string
+	host        string
+	path        string
+	service     string
+	labels      map[string]string
+}
+
+func (s *Server) GenerateManifest() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid name %q: %v", name, errs)
+		}
+		params := &manifestParams{
+			name:        name,
+			namespace:   req.GetString("namespace", ""),
+			image:       req.GetString("image", ""),
+			command:     req.GetStringSlice("command", nil),
+			port:        int32(req.GetInt("port", 0)),
+			replicas:    int32(req.GetInt("replicas", 1)),
+			schedule:    req.GetString("schedule", ""),
+			serviceType: req.GetString("serviceType", string(corev1.ServiceTypeClusterIP)),
+			host:        req.GetString("host", ""),
+			path:        req.GetString("path", "/"),
+			service:     req.GetString("service", name),
+			labels:      parseLabels(req.GetStringSlice("labels", nil)),
+		}
+		if len(params.labels) == 0 {
+			// the same selector label as kubectl create.
+			params.labels = map[string]string{"app": name}
+		}
+		if params.port < 0 || params.port > 65535 {
+			return nil, fmt.Errorf("invalid port %d, must be between 1 and 65535", params.port)
+		}
+		if params.replicas < 0 {
+			return nil, fmt.Errorf("invalid replicas %d, must not be negative", params.replicas)
+		}
+
+		slog.Info("Generating manifest", "kind", kind, "name", name, "namespace", params.namespace, "image", params.image)
+
+		var obj runtime.Object
+		switch kind {
+		case "Deployment":
+			obj, err = generateDeployment(params)
+		case "Service":
+			obj, err = generateService(params)
+		case "Ingress":
+			obj, err = generateIngress(params)
+		case "Job":
+			obj, err = generateJob(params)
+		case "CronJob":
+			obj, err = generateCronJob(params)
+		default:
+			return nil, fmt.Errorf("unsupported kind %q, must be one of Deployment, Service, Ingress, Job or CronJob", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		// the empty fields like status and the null creationTimestamp are noise in the skeleton.
+		pruneEmpty(content)
+		resp, err := yaml.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func generateObjectMeta(params *manifestParams) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: params.name, Namespace: params.namespace, Labels: params.labels}
+}
+
+func generatePodSpec(params *manifestParams, restartPolicy corev1.RestartPolicy) (corev1.PodSpec, error) {
+	if len(params.image) == 0 {
+		return corev1.PodSpec{}, fmt.Errorf("image is required to generate the pod template")
+	}
+	container := corev1.Container{Name: params.name, Image: params.image, Command: params.command}
+	if params.port > 0 {
+		container.Ports = []corev1.ContainerPort{{ContainerPort: params.port}}
+	}
+	return corev1.PodSpec{Containers: []corev1.Container{container}, RestartPolicy: restartPolicy}, nil
+}
+
+func generateDeployment(params *manifestParams) (runtime.Object, error) {
+	podSpec, err := generatePodSpec(params, "")
+	if err != nil {
+		return nil, err
+	}
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: generateObjectMeta(params),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(params.replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: params.labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: params.labels},
+				Spec:       podSpec,
+			},
+		},
+	}, nil
+}
+
+func generateService(params *manifestParams) (runtime.Object, error) {
+	if params.port == 0 {
+		return nil, fmt.Errorf("port is required to generate the Service")
+	}
+	serviceType := corev1.ServiceType(params.serviceType)
+	switch serviceType {
+	case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+	default:
+		return nil, fmt.Errorf("unsupported serviceType %q, must be one of ClusterIP, NodePort or LoadBalancer", params.serviceType)
+	}
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: generateObjectMeta(params),
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: params.labels,
+			Ports: []corev1.ServicePort{{
+				Name:       fmt.Sprintf("%d-%d", params.port, params.port),
+				Protocol:   corev1.ProtocolTCP,
+				Port:       params.port,
+				TargetPort: intstr.FromInt32(params.port),
+			}},
+		},
+	}, nil
+}
+
+func generateIngress(params *manifestParams) (runtime.Object, error) {
+	if params.port == 0 {
+		return nil, fmt.Errorf("port of the backend service is required to generate the Ingress")
+	}
+	pathType := networkingv1.PathTypePrefix
+	return &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: generateObjectMeta(params),
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: params.host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     params.path,
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: params.service,
+							Port: networkingv1.ServiceBackendPort{Number: params.port},
+						}},
+					}},
+				}},
+			}},
+		},
+	}, nil
+}
+
+func generateJobSpec(params *manifestParams, restartPolicy corev1.RestartPolicy) (batchv1.JobSpec, error) {
+	podSpec, err := generatePodSpec(params, restartPolicy)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+	return batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: podSpec}}, nil
+}
+
+func generateJob(params *manifestParams) (runtime.Object, error) {
+	spec, err := generateJobSpec(params, corev1.RestartPolicyNever)
+	if err != nil {
+		return nil, err
+	}
+	return &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: generateObjectMeta(params),
+		Spec:       spec,
+	}, nil
+}
+
+func generateCronJob(params *manifestParams) (runtime.Object, error) {
+	if len(params.schedule) == 0 {
+		return nil, fmt.Errorf("schedule is required to generate the CronJob, e.g. */5 * * * *")
+	}
+	spec, err := generateJobSpec(params, corev1.RestartPolicyOnFailure)
+	if err != nil {
+		return nil, err
+	}
+	return &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: generateObjectMeta(params),
+		Spec: batchv1.CronJobSpec{
+			Schedule: params.schedule,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: params.name},
+				Spec:       spec,
+			},
+		},
+	}, nil
+}
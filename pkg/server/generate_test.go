@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestGenerateManifest(t *testing.T) {
+	tests := []struct {
+		kind   string
+		args   map[string]any
+		want   []string
+		absent []string
+	}{
+		{
+			kind:   "Deployment",
+			args:   map[string]any{"image": "nginx:1.27", "port": 80},
+			want:   []string{"replicas: 1", "image: nginx:1.27", "containerPort: 80", "app: web"},
+			absent: []string{"status:", "creationTimestamp", "resources: {}", "strategy: {}"},
+		},
+		{
+			kind:   "Service",
+			args:   map[string]any{"port": 80},
+			want:   []string{"type: ClusterIP", "targetPort: 80"},
+			absent: []string{"status:", "loadBalancer", "creationTimestamp"},
+		},
+		{
+			kind:   "CronJob",
+			args:   map[string]any{"image": "busybox", "schedule": "*/5 * * * *"},
+			want:   []string{"schedule: '*/5 * * * *'", "restartPolicy: OnFailure"},
+			absent: []string{"status:", "creationTimestamp", "metadata: {}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = map[string]any{"kind": tt.kind, "name": "web"}
+			for key, value := range tt.args {
+				req.Params.Arguments.(map[string]any)[key] = value
+			}
+			result, err := (&Server{}).GenerateManifest()(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest := result.Content[0].(mcp.TextContent).Text
+			for _, want := range tt.want {
+				if !strings.Contains(manifest, want) {
+					t.Fatalf("got manifest %q, want %q in it", manifest, want)
+				}
+			}
+			// the empty fields are pruned from the skeleton.
+			for _, absent := range tt.absent {
+				if strings.Contains(manifest, absent) {
+					t.Fatalf("got manifest %q, want no %q in it", manifest, absent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	argoTrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
+	argoInstanceLabel        = "app.kubernetes.io/instance"
+	fluxKustomizationLabel   = "kustomize.toolkit.fluxcd.io/name"
+	fluxHelmReleaseLabel     = "helm.toolkit.fluxcd.io/name"
+	// maxOwnerDepth is the maximum owners followed from the object, e.g. Pod -> ReplicaSet -> Deployment.
+	maxOwnerDepth = 5
+)
+
+var (
+	argoApplication   = schema.GroupKind{Group: "argoproj.io", Kind: "Application"}
+	fluxKustomization = schema.GroupKind{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}
+	fluxHelmRelease   = schema.GroupKind{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"}
+)
+
+// GitOpsReport is the Argo CD Applications and the Flux Kustomizations or HelmReleases governing an object or
+// its owners, the changes made to them out of git are reverted by the reconciliations.
+type GitOpsReport struct {
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace,omitempty"`
+	Name      string          `json:"name"`
+	Managed   bool            `json:"managed"`
+	Owners    []string        `json:"owners,omitempty"`
+	Managers  []GitOpsManager `json:"managers"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// GitOpsManager is an Argo CD Application or a Flux Kustomization or HelmRelease governing the object.
+type GitOpsManager struct {
+	Tool      string `json:"tool"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Evidence is the label or annotation of the object referring to the manager.
+	Evidence  string `json:"evidence"`
+	Found     bool   `json:"found"`
+	Sync      string `json:"sync,omitempty"`
+	Health    string `json:"health,omitempty"`
+	Ready     string `json:"ready,omitempty"`
+	Message   string `json:"message,omitempty"`
+	AutoSync  bool   `json:"autoSync"`
+	SelfHeal  bool   `json:"selfHeal,omitempty"`
+	Prune     bool   `json:"prune,omitempty"`
+	Suspended bool   `json:"suspended,omitempty"`
+	Interval  string `json:"interval,omitempty"`
+}
+
+func (s *Server) GitOpsStatus() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+
+		kind, resourceName, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Getting gitops status", "kind", kind, "name", resourceName, "namespace", namespace)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		gvr, namespaced, err := lookupKindResource(discoveryClient, kind)
+		if err != nil {
+			return nil, err
+		}
+		if namespaced && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+
+		var obj *unstructured.Unstructured
+		if len(namespace) > 0 {
+			obj, err = dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, resourceName, metav1.GetOptions{})
+		} else {
+			obj, err = dynamicClient.Resource(gvr).Get(ctx, resourceName, metav1.GetOptions{})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource info: %w", err)
+		}
+
+		report := &GitOpsReport{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName(), Managers: make([]GitOpsManager, 0)}
+		chain := append([]*unstructured.Unstructured{obj}, controllerOwners(ctx, discoveryClient, dynamicClient, obj)...)
+		seen := make(map[string]bool)
+		for _, item := range chain {
+			if item != obj {
+				report.Owners = append(report.Owners, item.GetKind()+"/"+item.GetName())
+			}
+			for _, manager := range gitOpsReferences(item) {
+				key := manager.Kind + "/" + manager.Namespace + "/" + manager.Name
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				report.Managers = append(report.Managers, manager)
+			}
+		}
+
+		managers := report.Managers[:0]
+		for _, manager := range report.Managers {
+			// the instance label is also set by helm, it refers to an Application only if there is one.
+			if resolveGitOpsManager(ctx, discoveryClient, dynamicClient, &manager) || manager.Evidence != argoInstanceLabel {
+				managers = append(managers, manager)
+			}
+		}
+		report.Managers = managers
+		report.Managed = len(report.Managers) > 0
+		report.Warnings = gitOpsWarnings(report)
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// controllerOwners returns the controllers owning the object recursively, e.g. the ReplicaSet and the Deployment
+// of a pod, the owners failed to get are skipped.
+func controllerOwners(ctx context.Context, discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, obj *unstructured.Unstructured) []*unstructured.Unstructured {
+	owners := make([]*unstructured.Unstructured, 0)
+	for i := 0; i < maxOwnerDepth; i++ {
+		ref := metav1.GetControllerOfNoCopy(obj)
+		if ref == nil {
+			break
+		}
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			break
+		}
+		gvr, namespaced, err := lookupGroupKindResource(discoveryClient, gv.WithKind(ref.Kind).GroupKind())
+		if err != nil {
+			break
+		}
+		var ri dynamic.ResourceInterface = dynamicClient.Resource(gvr)
+		if namespaced {
+			ri = dynamicClient.Resource(gvr).Namespace(obj.GetNamespace())
+		}
+		owner, err := ri.Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			slog.Debug("Failed to get the owner", "kind", ref.Kind, "name", ref.Name, "err", err)
+			break
+		}
+		owners = append(owners, owner)
+		obj = owner
+	}
+	return owners
+}
+
+// gitOpsReferences returns the managers referred by the labels and the annotations of the object, the Argo CD
+// tracking id is in the form of <app>:<group>/<kind>:<namespace>/<name>, the app is prefixed with the namespace of
+// the Application like <namespace>_<app> if it is not in the control plane namespace.
+func gitOpsReferences(obj *unstructured.Unstructured) []GitOpsManager {
+	labels, annotations := obj.GetLabels(), obj.GetAnnotations()
+	managers := make([]GitOpsManager, 0)
+	if trackingID, ok := annotations[argoTrackingIDAnnotation]; ok {
+		app, _, _ := strings.Cut(trackingID, ":")
+		namespace, name, found := strings.Cut(app, "_")
+		if !found {
+			namespace, name = "", app
+		}
+		managers = append(managers, GitOpsManager{Tool: "ArgoCD", Kind: argoApplication.Kind, Namespace: namespace, Name: name, Evidence: argoTrackingIDAnnotation})
+	} else if app, ok := labels[argoInstanceLabel]; ok {
+		managers = append(managers, GitOpsManager{Tool: "ArgoCD", Kind: argoApplication.Kind, Name: app, Evidence: argoInstanceLabel})
+	}
+	if name, ok := labels[fluxKustomizationLabel]; ok {
+		managers = append(managers, GitOpsManager{Tool: "Flux", Kind: fluxKustomization.Kind, Namespace: labels["kustomize.toolkit.fluxcd.io/namespace"], Name: name, Evidence: fluxKustomizationLabel})
+	}
+	if name, ok := labels[fluxHelmReleaseLabel]; ok {
+		managers = append(managers, GitOpsManager{Tool: "Flux", Kind: fluxHelmRelease.Kind, Namespace: labels["helm.toolkit.fluxcd.io/namespace"], Name: name, Evidence: fluxHelmReleaseLabel})
+	}
+	return managers
+}
+
+// resolveGitOpsManager gets the manager and fills its status, the Applications without the namespace are looked up
+// by the name in all namespaces. It returns whether the manager is found.
+func resolveGitOpsManager(ctx context.Context, discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface, manager *GitOpsManager) bool {
+	gk := fluxKustomization
+	switch manager.Kind {
+	case argoApplication.Kind:
+		gk = argoApplication
+	case fluxHelmRelease.Kind:
+		gk = fluxHelmRelease
+	}
+	gvr, _, err := lookupGroupKindResource(discoveryClient, gk)
+	if err != nil {
+		return false
+	}
+
+	var obj *unstructured.Unstructured
+	if len(manager.Namespace) > 0 {
+		obj, err = dynamicClient.Resource(gvr).Namespace(manager.Namespace).Get(ctx, manager.Name, metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+	} else {
+		list, err := dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{FieldSelector: "metadata.name=" + manager.Name})
+		if err != nil || len(list.Items) == 0 {
+			return false
+		}
+		obj = &list.Items[0]
+	}
+	manager.Found, manager.Namespace = true, obj.GetNamespace()
+
+	if gk == argoApplication {
+		manager.Sync, _, _ = unstructured.NestedString(obj.Object, "status", "sync", "status")
+		manager.Health, _, _ = unstructured.NestedString(obj.Object, "status", "health", "status")
+		if automated, ok, _ := unstructured.NestedMap(obj.Object, "spec", "syncPolicy", "automated"); ok {
+			// the automated sync may be disabled explicitly since Argo CD 2.14.
+			enabled, found, _ := unstructured.NestedBool(automated, "enabled")
+			manager.AutoSync = !found || enabled
+			manager.SelfHeal, _, _ = unstructured.NestedBool(automated, "selfHeal")
+			manager.Prune, _, _ = unstructured.NestedBool(automated, "prune")
+		}
+		return true
+	}
+
+	manager.AutoSync = true
+	manager.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+	manager.Prune, _, _ = unstructured.NestedBool(obj.Object, "spec", "prune")
+	manager.Interval, _, _ = unstructured.NestedString(obj.Object, "spec", "interval")
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range conditions {
+		if condition, ok := item.(map[string]any); ok && condition["type"] == "Ready" {
+			manager.Ready, _ = condition["status"].(string)
+			manager.Message, _ = condition["message"].(string)
+		}
+	}
+	return true
+}
+
+// checkGitOps returns an error if the live object is managed by an Argo CD Application or an active Flux
+// Kustomization or HelmRelease, which would revert the change made out of git, unless override is set. The objects
+// failed to get are left to the change to report.
+func (s *Server) checkGitOps(ctx context.Context, ri dynamic.ResourceInterface, name string, override bool) error {
+	if override {
+		return nil
+	}
+	live, err := ri.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	managers := gitOpsReferences(live)
+	if len(managers) == 0 {
+		return nil
+	}
+	discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return err
+	}
+	for _, manager := range managers {
+		if !resolveGitOpsManager(ctx, discoveryClient, dynamicClient, &manager) || manager.Suspended {
+			continue
+		}
+		return fmt.Errorf("%s %s is managed by the %s %s %s/%s which reverts the changes made out of git, change the source in git instead, or set overrideGitOps to change it anyway",
+			live.GetKind(), live.GetName(), manager.Tool, manager.Kind, manager.Namespace, manager.Name)
+	}
+	return nil
+}
+
+func gitOpsWarnings(report *GitOpsReport) []string {
+	var warnings []string
+	for _, m := range report.Managers {
+		ref := fmt.Sprintf("%s %s %s/%s", m.Tool, m.Kind, m.Namespace, m.Name)
+		switch {
+		case !m.Found:
+			warnings = append(warnings, fmt.Sprintf("%s referred by %s is not found, the object may be orphaned", ref, m.Evidence))
+		case m.Tool == "ArgoCD" && m.AutoSync && m.SelfHeal:
+			warnings = append(warnings, fmt.Sprintf("%s syncs automatically with self-heal, the changes made out of git will be reverted immediately, change the source in git instead", ref))
+		case m.Tool == "ArgoCD" && m.AutoSync:
+			warnings = append(warnings, fmt.Sprintf("%s syncs automatically, the changes made out of git make it OutOfSync and will be reverted on the next change of the source", ref))
+		case m.Tool == "ArgoCD":
+			warnings = append(warnings, fmt.Sprintf("%s syncs manually, the changes made out of git make it OutOfSync and will be reverted on the next sync", ref))
+		case m.Suspended:
+			warnings = append(warnings, fmt.Sprintf("%s is suspended, the changes made out of git are kept until it is resumed", ref))
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s reconciles every %s, the changes made out of git will be reverted, change the source in git or suspend it first", ref, m.Interval))
+		}
+	}
+	if len(report.Owners) > 0 {
+		warnings = append(warnings, fmt.Sprintf("the object is controlled by %s, the changes to it are also reverted by the controller", report.Owners[0]))
+	}
+	return warnings
+}
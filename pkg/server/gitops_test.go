@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestGitOpsGuard(t *testing.T) {
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}}},
+		{GroupVersion: "argoproj.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "applications", Kind: "Application", Namespaced: true}}},
+		{GroupVersion: "kustomize.toolkit.fluxcd.io/v1", APIResources: []metav1.APIResource{{Name: "kustomizations", Kind: "Kustomization", Namespaced: true}}},
+	}
+	object := func(apiVersion, kind, namespace, name string, labels, annotations map[string]string, fields map[string]any) runtime.Object {
+		obj := &unstructured.Unstructured{Object: fields}
+		if obj.Object == nil {
+			obj.Object = make(map[string]any)
+		}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		obj.SetAnnotations(annotations)
+		return obj
+	}
+	objects := []runtime.Object{
+		object("apps/v1", "Deployment", "shop", "web", nil, map[string]string{argoTrackingIDAnnotation: "argocd_shop:apps/Deployment:shop/web"}, nil),
+		object("apps/v1", "Deployment", "shop", "db", map[string]string{fluxKustomizationLabel: "db", "kustomize.toolkit.fluxcd.io/namespace": "flux-system"}, nil, nil),
+		object("apps/v1", "Deployment", "shop", "orphan", nil, map[string]string{argoTrackingIDAnnotation: "argocd_gone:apps/Deployment:shop/orphan"}, nil),
+		object("apps/v1", "Deployment", "shop", "manual", nil, nil, nil),
+		object("argoproj.io/v1alpha1", "Application", "argocd", "shop", nil, nil, map[string]any{
+			"spec": map[string]any{"syncPolicy": map[string]any{"automated": map[string]any{"selfHeal": true}}},
+		}),
+		object("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "db", nil, nil, map[string]any{
+			"spec": map[string]any{"suspend": true},
+		}),
+	}
+
+	tests := []struct {
+		name      string
+		tool      string
+		args      map[string]any
+		wantError string
+	}{
+		{name: "delete of the object managed by Argo CD", tool: "delete", args: map[string]any{"name": "deploy/web"}, wantError: "managed by the ArgoCD Application argocd/shop"},
+		{name: "patch of the object managed by Argo CD", tool: "patch", args: map[string]any{"name": "deploy/web", "patch": `{"spec":{"replicas":2}}`}, wantError: "managed by the ArgoCD Application argocd/shop"},
+		{name: "patch of the status", tool: "patch", args: map[string]any{"name": "deploy/web", "patch": `{"status":{"replicas":2}}`, "subresource": "status"}},
+		{name: "delete overriding the gitops", tool: "delete", args: map[string]any{"name": "deploy/web", "overrideGitOps": true}},
+		{name: "object of the suspended Kustomization", tool: "delete", args: map[string]any{"name": "deploy/db"}},
+		{name: "object of the missing Application", tool: "delete", args: map[string]any{"name": "deploy/orphan"}},
+		{name: "unmanaged object", tool: "patch", args: map[string]any{"name": "deploy/manual", "patch": `{"spec":{"replicas":2}}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, b := newFakeServer(resources, nil, objects...)
+			handler := s.DeleteResource()
+			if tt.tool == "patch" {
+				handler = s.PatchResource()
+			}
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = map[string]any{"namespace": "shop"}
+			for k, v := range tt.args {
+				req.Params.Arguments.(map[string]any)[k] = v
+			}
+
+			_, err := handler(context.Background(), req)
+			if len(tt.wantError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("got error %v, want %q", err, tt.wantError)
+				}
+				// the guarded object is left as it is.
+				deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+				if _, err := b.dynamic.Resource(deployments).Namespace("shop").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+					t.Fatalf("the guarded object is changed: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// DefaultGitAllowedHosts are the git hosts the manifests are fetched from by default.
+var DefaultGitAllowedHosts = []string{"github.com", "gitlab.com"}
+
+// gitRepoRoot is the directory of the fetched repository in the in-memory file system.
+const gitRepoRoot = "/repo"
+
+// gitFetchWatchInterval is the interval the size of the repository is checked during the fetch.
+const gitFetchWatchInterval = 100 * time.Millisecond
+
+// manifestExtensions are the extensions of the manifest files read from the git repository.
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+func (s *Server) ApplyFromGit() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		repo, err := req.RequireString("repo")
+		if err != nil {
+			return nil, err
+		}
+		ref := req.GetString("ref", "HEAD")
+		dir := req.GetString("path", "")
+		namespace := req.GetString("namespace", "")
+		action := req.GetString("action", "diff")
+
+		slog.Info("Applying manifests from git", "repo", repo, "ref", ref, "path", dir, "namespace", namespace, "action", action)
+
+		switch action {
+		case "render", "diff", "apply":
+		default:
+			return nil, fmt.Errorf("unsupported action %q, must be one of render, diff or apply", action)
+		}
+		workdir, err := s.fetchGitSource(ctx, repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(workdir)
+
+		manifest, err := s.readGitManifests(ctx, workdir, dir)
+		if err != nil {
+			return nil, err
+		}
+		if action == "render" {
+			return mcp.NewToolResultText(manifest), nil
+		}
+
+		results, err := s.applyManifest(ctx, manifest, namespace, action == "diff", false, true)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := json.Marshal(results)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// checkGitRepo checks the repository is an HTTPS URL of the allowed hosts, the other protocols like ssh and file
+// are rejected since they may access the credentials or the files of the server.
+func (s *Server) checkGitRepo(repo string) error {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return fmt.Errorf("invalid git repository %q: %w", repo, err)
+	}
+	if u.Scheme != "https" || u.User != nil {
+		return fmt.Errorf("invalid git repository %q, only the https URLs without the credentials are supported", repo)
+	}
+	if !slices.Contains(s.gitAllowedHosts, strings.ToLower(u.Hostname())) {
+		return fmt.Errorf("git host %q is not allowed, the allowed hosts are %v", u.Hostname(), s.gitAllowedHosts)
+	}
+	return nil
+}
+
+// fetchGitSource fetches the ref of the allowed repository into a temporary directory, which is removed by the
+// caller.
+func (s *Server) fetchGitSource(ctx context.Context, repo, ref string) (string, error) {
+	if err := s.checkGitRepo(repo); err != nil {
+		return "", err
+	}
+	// the ref is passed to git as an argument, which must not be taken as an option.
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	workdir, err := os.MkdirTemp("", "koffee-git-")
+	if err != nil {
+		return "", err
+	}
+	if err = fetchGitRef(ctx, workdir, repo, ref, s.gitMaxFetchBytes); err != nil {
+		os.RemoveAll(workdir)
+		return "", err
+	}
+	return workdir, nil
+}
+
+// fetchGitRef fetches the ref of the repository into the directory without the history, the ref is a branch,
+// a tag or a commit. The fetch is canceled once the directory exceeds maxBytes, since git has no limit of the
+// fetched objects.
+func fetchGitRef(ctx context.Context, dir, repo, ref string, maxBytes int64) error {
+	errTooLarge := fmt.Errorf("the repository %s exceeds the limit of %d bytes", repo, maxBytes)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(gitFetchWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if dirSize(dir) > maxBytes {
+					cancel(errTooLarge)
+					return
+				}
+			}
+		}
+	}()
+
+	commands := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", repo},
+		{"fetch", "--quiet", "--depth", "1", "--no-tags", "origin", ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range commands {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		// the prompts of the credentials would block the tool call forever.
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_LFS_SKIP_SMUDGE=1")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if errors.Is(context.Cause(ctx), errTooLarge) {
+				return errTooLarge
+			}
+			return fmt.Errorf("failed to git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	if dirSize(dir) > maxBytes {
+		return errTooLarge
+	}
+	return nil
+}
+
+// dirSize returns the total bytes of the files under the directory, the files removed during the walk are ignored.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && !d.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// copyToFs copies the regular files of the directory into the file system, the git metadata and the symlinks,
+// which may point out of the directory, are skipped.
+func copyToFs(fSys filesys.FileSystem, src, dst string) error {
+	return filepath.WalkDir(src, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		target := path.Join(dst, filepath.ToSlash(rel))
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return fSys.MkdirAll(target)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return fSys.WriteFile(target, data)
+	})
+}
+
+// parseRemoteBase parses the remote base of a kustomization into the git repository, the path in it and the ref,
+// e.g. https://github.com/org/repo//deploy?ref=v1.0 or github.com/org/repo/deploy?ref=v1.0.
+func parseRemoteBase(base string) (repo, dir, ref string, err error) {
+	raw := strings.TrimPrefix(base, "git::")
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || len(u.Host) == 0 {
+		return "", "", "", fmt.Errorf("invalid remote base %q, only the https URLs of the git repositories are supported", base)
+	}
+	query := u.Query()
+	ref = cmp.Or(query.Get("ref"), query.Get("version"), "HEAD")
+
+	repoPath, dir, found := strings.Cut(strings.TrimPrefix(u.Path, "/"), "//")
+	if !found {
+		// the repository is the first two segments of the path without the separator, like kustomize.
+		segments := strings.SplitN(repoPath, "/", 3)
+		if len(segments) < 2 {
+			return "", "", "", fmt.Errorf("invalid remote base %q, the repository is not found", base)
+		}
+		repoPath = segments[0] + "/" + segments[1]
+		dir = ""
+		if len(segments) == 3 {
+			dir = segments[2]
+		}
+	}
+	repo = (&url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: "/" + repoPath}).String()
+	return repo, dir, ref, nil
+}
+
+// readGitManifests reads the manifests under the path of the repository, the kustomization is rendered if the
+// path has one, otherwise the manifest files are read recursively in the lexical order.
+func (s *Server) readGitManifests(ctx context.Context, root, dir string) (string, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.Clean("/"+dir)))
+	if err != nil {
+		return "", fmt.Errorf("failed to find path %q in the repository: %w", dir, err)
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is out of the repository", dir)
+	}
+
+	for _, name := range kustomizationFiles {
+		if _, err := os.Stat(filepath.Join(resolved, name)); err == nil {
+			// the repository is built in memory, so that the remote bases are only fetched by the allowlist.
+			fSys := filesys.MakeFsInMemory()
+			if err := copyToFs(fSys, root, gitRepoRoot); err != nil {
+				return "", err
+			}
+			rel, err := filepath.Rel(root, resolved)
+			if err != nil {
+				return "", err
+			}
+			manifest, err := s.newRemoteResolver(fSys).build(ctx, path.Join(gitRepoRoot, filepath.ToSlash(rel)))
+			if err != nil {
+				return "", err
+			}
+			if int64(len(manifest)) > s.gitMaxManifestBytes {
+				return "", fmt.Errorf("the rendered manifests exceed the limit of %d bytes", s.gitMaxManifestBytes)
+			}
+			return manifest, nil
+		}
+	}
+
+	files := make([]string, 0)
+	var size int64
+	err = filepath.WalkDir(resolved, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// the symlinks are skipped since they may point out of the repository.
+		if !d.Type().IsRegular() || !slices.Contains(manifestExtensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if size += info.Size(); size > s.gitMaxManifestBytes {
+			return fmt.Errorf("the manifests under %q exceed the limit of %d bytes", dir, s.gitMaxManifestBytes)
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no manifest found under path %q", dir)
+	}
+	sort.Strings(files)
+
+	var manifest strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		manifest.WriteString("---\n")
+		manifest.Write(data)
+		manifest.WriteString("\n")
+	}
+	return manifest.String(), nil
+}
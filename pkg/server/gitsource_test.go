@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func TestParseRemoteBase(t *testing.T) {
+	tests := []struct {
+		base      string
+		wantRepo  string
+		wantDir   string
+		wantRef   string
+		wantError bool
+	}{
+		{base: "https://github.com/org/repo//deploy/base?ref=v1.0", wantRepo: "https://github.com/org/repo", wantDir: "deploy/base", wantRef: "v1.0"},
+		{base: "github.com/org/repo/deploy?version=main", wantRepo: "https://github.com/org/repo", wantDir: "deploy", wantRef: "main"},
+		{base: "git::https://gitlab.com/org/repo.git//base", wantRepo: "https://gitlab.com/org/repo.git", wantDir: "base", wantRef: "HEAD"},
+		{base: "https://github.com/org/repo", wantRepo: "https://github.com/org/repo", wantRef: "HEAD"},
+		{base: "ssh://git@github.com/org/repo//base", wantRepo: "ssh://git@github.com/org/repo", wantDir: "base", wantRef: "HEAD"},
+		{base: "https://github.com/org", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			repo, dir, ref, err := parseRemoteBase(tt.base)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("got error %v, want error %t", err, tt.wantError)
+			}
+			if repo != tt.wantRepo || dir != tt.wantDir || ref != tt.wantRef {
+				t.Fatalf("got %s %q %s, want %s %q %s", repo, dir, ref, tt.wantRepo, tt.wantDir, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestResolveRemoteBases(t *testing.T) {
+	tests := []struct {
+		name          string
+		kustomization string
+		files         map[string]string
+		wantError     string
+	}{
+		{
+			name:          "local bases",
+			kustomization: "resources:\n- deployment.yaml\n- ../base\n",
+			files:         map[string]string{"/base/kustomization.yaml": "resources:\n- service.yaml\n"},
+		},
+		{
+			name:          "remote base of the host not allowed",
+			kustomization: "resources:\n- https://example.com/org/repo//deploy\n",
+			wantError:     `git host "example.com" is not allowed`,
+		},
+		{
+			name:          "nested remote base of the host not allowed",
+			kustomization: "resources:\n- ../base\n",
+			files:         map[string]string{"/base/kustomization.yaml": "components:\n- example.com/org/repo/component\n"},
+			wantError:     `git host "example.com" is not allowed`,
+		},
+		{
+			name:          "remote base over ssh",
+			kustomization: "bases:\n- ssh://git@github.com/org/repo//deploy\n",
+			wantError:     "only the https URLs without the credentials are supported",
+		},
+		{
+			name:          "remote patch",
+			kustomization: "patches:\n- path: https://raw.githubusercontent.com/org/repo/main/patch.yaml\n",
+			wantError:     "remote file",
+		},
+		{
+			name:          "remote file of the generator",
+			kustomization: "configMapGenerator:\n- name: app\n  files:\n  - config.yaml=https://example.com/config.yaml\n",
+			wantError:     "remote file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fSys := filesys.MakeFsInMemory()
+			if err := fSys.WriteFile("/overlay/kustomization.yaml", []byte(tt.kustomization)); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := fSys.WriteFile(name, []byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &Server{gitAllowedHosts: DefaultGitAllowedHosts, gitMaxFetchBytes: 1 << 20}
+			err := s.newRemoteResolver(fSys).resolve(context.Background(), "/overlay")
+			if len(tt.wantError) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("got error %v, want %q", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestFetchGitRefLimit(t *testing.T) {
+	origin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(origin, "large.yaml"), []byte(strings.Repeat("a", 256<<10)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = origin
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git is not available: %v: %s", err, out)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		maxBytes  int64
+		wantError bool
+	}{
+		{name: "within the limit", maxBytes: 4 << 20},
+		{name: "over the limit", maxBytes: 64 << 10, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fetchGitRef(context.Background(), t.TempDir(), origin, "HEAD", tt.maxBytes)
+			if tt.wantError {
+				if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+					t.Fatalf("got error %v, want the limit exceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -5,60 +5,110 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
 
 	"cola.io/koffee/pkg/definition"
 )
 
 func (s *Server) GetResourceDetailInfo() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		kind, err := req.RequireString("kind")
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		expr := req.GetString("jsonpath", "")
+		raw := req.GetBool("raw", false)
+		output := req.GetString("output", "json")
+		export := req.GetBool("export", false)
+		fuzzy := req.GetBool("fuzzy", false)
+		subresources, err := parseSubresource(req.GetString("subresource", ""))
 		if err != nil {
 			return nil, err
 		}
 
-		resourceName, err := req.RequireString("name")
+		kind, resourceName, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
 		if err != nil {
 			return nil, err
 		}
-		namespace := req.GetString("namespace", "")
 
-		slog.Info("Getting resource detail info", "kind", kind, "name", resourceName, "namespace", namespace)
+		slog.Info("Getting resource detail info", "kind", kind, "name", resourceName, "namespace", namespace, "subresource", subresources, "jsonpath", expr, "raw", raw, "output", output, "export", export)
 
-		discoveryClient, err := s.cb.GetDiscoveryClient()
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
 		if err != nil {
 			return nil, err
 		}
 
-		dynamicClient, err := s.cb.GetDynamicClient()
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
 		if err != nil {
 			return nil, err
 		}
 
-		gvResource, err := lookupGroupVersionResource(discoveryClient, kind)
+		gvResource, namespaced, err := lookupKindResource(discoveryClient, kind)
 		if err != nil {
 			return nil, err
 		}
+		if namespaced && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
 
-		var obj *unstructured.Unstructured
+		var ri dynamic.ResourceInterface = dynamicClient.Resource(gvResource)
 		if len(namespace) > 0 {
-			obj, err = dynamicClient.Resource(gvResource).Namespace(namespace).Get(ctx, resourceName, metav1.GetOptions{})
-		} else {
-			obj, err = dynamicClient.Resource(gvResource).Get(ctx, resourceName, metav1.GetOptions{})
+			ri = dynamicClient.Resource(gvResource).Namespace(namespace)
+		}
+		obj, err := ri.Get(ctx, resourceName, metav1.GetOptions{}, subresources...)
+		if apierrors.IsNotFound(err) && fuzzy {
+			options := metav1.ListOptions{LabelSelector: req.GetString("labelSelector", ""), FieldSelector: req.GetString("fieldSelector", "")}
+			candidates, err := nameCandidates(ctx, ri, resourceName, options)
+			if err != nil {
+				return nil, err
+			}
+			return newNameCandidatesResult(&NameCandidates{Kind: kind, Name: resourceName, Namespace: namespace, Candidates: candidates})
 		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to get resource info: %w", err)
 		}
-		obj.SetManagedFields(nil)
 
-		resp, err := json.Marshal(obj)
+		// the fields extracted by the jsonpath are returned as they are, even if they are noisy.
+		var value any = obj
+		switch {
+		case len(expr) > 0:
+			j, err := parseJSONPath(expr)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = projectJSONPath(j, obj.Object); err != nil {
+				return nil, err
+			}
+		case export:
+			exportObject(obj)
+			value = obj.Object
+		case !raw:
+			s.scrubber.scrub(obj.Object)
+		}
+
+		var resp []byte
+		switch output {
+		case definition.OutputJSON:
+			resp, err = json.Marshal(value)
+		case definition.OutputYAML:
+			resp, err = yaml.Marshal(value)
+		default:
+			return nil, fmt.Errorf("unsupported output %q, must be one of json or yaml", output)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -70,7 +120,7 @@ func (s *Server) GetApiResources() func(ctx context.Context, req mcp.CallToolReq
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		includeNamespaceScoped := req.GetBool("includeNamespaceScoped", true)
 
-		discoveryClient, err := s.cb.GetDiscoveryClient()
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
 		if err != nil {
 			return nil, err
 		}
@@ -94,27 +144,54 @@ func (s *Server) ListResources() func(ctx context.Context, req mcp.CallToolReque
 		if err != nil {
 			return nil, err
 		}
+		kind = definition.ResolveKind(kind)
 		namespace := req.GetString("namespace", "")
 		labelSelector := req.GetString("labelSelector", "")
 		fieldSelector := req.GetString("fieldSelector", "")
+		maxResults := req.GetInt("maxResults", 0)
+		continueToken := req.GetString("continue", "")
+		expr := req.GetString("jsonpath", "")
+		since := req.GetString("sinceResourceVersion", "")
+		generateOptions := definition.GenerateOptions{Wide: req.GetBool("wide", false)}
+		output := req.GetString("output", definition.OutputJSON)
+		if len(since) > 0 && len(continueToken) > 0 {
+			return nil, fmt.Errorf("continue can't be used with sinceResourceVersion")
+		}
+		if !slices.Contains(definition.TableOutputs, output) {
+			return nil, fmt.Errorf("invalid output %q, must be one of %s", output, strings.Join(definition.TableOutputs, ", "))
+		}
+		if len(expr) > 0 && output != definition.OutputJSON {
+			// the output defaulted by the session only applies to the tables, the jsonpath always renders json.
+			if !isSessionDefault(ctx, "output") {
+				return nil, fmt.Errorf("output can't be used with jsonpath")
+			}
+			output = definition.OutputJSON
+		}
 
-		slog.Info("Listing resources", "kind", kind, "namespace", namespace, "labelSelector", labelSelector, "fieldSelector", fieldSelector)
+		slog.Info("Listing resources", "kind", kind, "namespace", namespace, "labelSelector", labelSelector, "fieldSelector", fieldSelector, "maxResults", maxResults, "jsonpath", expr, "sinceResourceVersion", since)
 
-		discoveryClient, err := s.cb.GetDiscoveryClient()
-		if err != nil {
-			return nil, err
+		if definition.IsMetricValueKind(kind) {
+			if len(since) > 0 {
+				return nil, fmt.Errorf("sinceResourceVersion is not supported by %s", kind)
+			}
+			return s.listMetricValues(ctx, kind, req.GetString("metric", ""), namespace, labelSelector, expr, generateOptions, output)
 		}
 
-		if _, err = lookupGroupVersionResource(discoveryClient, kind); err != nil {
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
 			return nil, err
 		}
 
-		gvResource, err := lookupGroupVersionResource(discoveryClient, kind)
-		if err != nil {
-			return nil, err
+		// prefer the group version which has the table handler, fall back to the preferred group version
+		// of the cluster, e.g. the custom resources.
+		gvResource, obj, supported := definition.NegotiateKind(discoveryClient, kind)
+		if !supported {
+			if gvResource, err = lookupGroupVersionResource(discoveryClient, kind); err != nil {
+				return nil, err
+			}
 		}
 
-		dynamicClient, err := s.cb.GetDynamicClient()
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
 		if err != nil {
 			return nil, err
 		}
@@ -127,25 +204,55 @@ func (s *Server) ListResources() func(ctx context.Context, req mcp.CallToolReque
 			options.FieldSelector = fieldSelector
 		}
 
-		var items *unstructured.UnstructuredList
+		var ri dynamic.ResourceInterface = dynamicClient.Resource(gvResource)
 		if len(namespace) > 0 {
-			items, err = dynamicClient.Resource(gvResource).Namespace(namespace).List(ctx, options)
+			ri = dynamicClient.Resource(gvResource).Namespace(namespace)
+		}
+		var (
+			items *unstructured.UnstructuredList
+			delta *ListDelta
+		)
+		if len(since) > 0 {
+			if maxResults <= 0 {
+				maxResults = defaultDeltaMaxObjects
+			}
+			items, delta, err = watchDelta(ctx, ri, options, since, maxResults)
+			if err != nil {
+				return nil, err
+			}
 		} else {
-			items, err = dynamicClient.Resource(gvResource).List(ctx, options)
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to list resources: %w", err)
+			if maxResults > 0 {
+				options.Limit = int64(maxResults)
+				options.Continue = continueToken
+			}
+			if items, err = ri.List(ctx, options); err != nil {
+				return nil, fmt.Errorf("failed to list resources: %w", err)
+			}
 		}
 
 		slog.Info("Listing resources", "kind", kind, "namespace", namespace, "items", len(items.Items))
 
-		obj, supported := definition.IsSupportedKind(kind)
+		if len(expr) > 0 {
+			result, err := projectedList(expr, items)
+			if err != nil || delta == nil {
+				return result, err
+			}
+			return withListDelta(result, delta)
+		}
+
 		table := &metav1.Table{}
-		if supported {
+		gk := schema.GroupKind{Group: gvResource.Group, Kind: kind}
+		// the kinds whose handlers are invalid are printed by the generic columns.
+		if supported && s.generator.HasHandler(obj) {
 			if err = runtime.DefaultUnstructuredConverter.FromUnstructured(items.UnstructuredContent(), obj); err != nil {
 				return nil, err
 			}
-			table, err = s.generator.GenerateTable(obj)
+			table, err = s.generator.GenerateTable(obj, generateOptions)
+			if err != nil {
+				return nil, err
+			}
+		} else if s.generator.HasUnstructuredHandler(gk) {
+			table, err = s.generator.GenerateUnstructuredTable(gk, items, generateOptions)
 			if err != nil {
 				return nil, err
 			}
@@ -158,20 +265,94 @@ func (s *Server) ListResources() func(ctx context.Context, req mcp.CallToolReque
 			rows := make([]metav1.TableRow, 0)
 			for _, item := range items.Items {
 				row := metav1.TableRow{
-					Cells: make([]any, 0),
+					Cells:  make([]any, 0),
+					Object: runtime.RawExtension{Object: definition.ObjectReference(&item)},
 				}
 				row.Cells = append(row.Cells, item.GetName(), item.GetNamespace(), time.Since(item.GetCreationTimestamp().Time))
 				rows = append(rows, row)
 			}
 			table.Rows = rows
+			table.ResourceVersion = items.GetResourceVersion()
+			table.Continue, table.RemainingItemCount = items.GetContinue(), items.GetRemainingItemCount()
+		}
+
+		result, err := newTableResult(table, output)
+		if err != nil {
+			return nil, err
 		}
+		if summary := pagingSummary(table.Continue, table.RemainingItemCount); len(summary) > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent(summary))
+		}
+		if delta != nil {
+			return withListDelta(result, delta)
+		}
+		return result, nil
+	}
+}
 
+// newTableResult returns the table in the output format, the metav1.Table JSON or the rendered Markdown, CSV or text.
+func newTableResult(table *metav1.Table, output string) (*mcp.CallToolResult, error) {
+	if output == definition.OutputJSON {
 		out, err := json.Marshal(table)
 		if err != nil {
 			return nil, err
 		}
 		return mcp.NewToolResultText(string(out)), nil
 	}
+	out, err := definition.RenderTable(table, output)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(out), nil
+}
+
+// withListDelta appends the changes since the resource version to the result of the changed objects.
+func withListDelta(result *mcp.CallToolResult, delta *ListDelta) (*mcp.CallToolResult, error) {
+	out, err := json.Marshal(delta)
+	if err != nil {
+		return nil, err
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(string(out)))
+	return result, nil
+}
+
+// projectedList returns the fields of the listed objects extracted by the JSONPath instead of the table.
+func projectedList(expr string, items *unstructured.UnstructuredList) (*mcp.CallToolResult, error) {
+	j, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	projected := make([]ProjectedObject, 0, len(items.Items))
+	for i := range items.Items {
+		value, err := projectJSONPath(j, items.Items[i].Object)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, ProjectedObject{Namespace: items.Items[i].GetNamespace(), Name: items.Items[i].GetName(), Value: value})
+	}
+
+	out, err := json.Marshal(projected)
+	if err != nil {
+		return nil, err
+	}
+	result := mcp.NewToolResultText(string(out))
+	if summary := pagingSummary(items.GetContinue(), items.GetRemainingItemCount()); len(summary) > 0 {
+		result.Content = append(result.Content, mcp.NewTextContent(summary))
+	}
+	return result, nil
+}
+
+// parseSubresource returns the subresources of the request, only the status and scale subresources are accessible
+// by the get, update and patch tools.
+func parseSubresource(subresource string) ([]string, error) {
+	switch subresource {
+	case "":
+		return nil, nil
+	case "status", "scale":
+		return []string{subresource}, nil
+	default:
+		return nil, fmt.Errorf("unsupported subresource %q, must be one of status or scale", subresource)
+	}
 }
 
 func ListApiResources(discoveryClient discovery.DiscoveryInterface, includeNamespaceScoped bool) ([]map[string]any, error) {
@@ -218,114 +399,252 @@ func ListApiResources(discoveryClient discovery.DiscoveryInterface, includeNames
 
 func (s *Server) CreateResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		kind, err := req.RequireString("kind")
-		if err != nil {
-			return nil, err
+		kind := req.GetString("kind", "")
+		if len(kind) > 0 {
+			kind = definition.ResolveKind(kind)
 		}
 
 		manifest, err := req.RequireString("manifest")
 		if err != nil {
 			return nil, err
 		}
+		if err = checkManifestSize(manifest); err != nil {
+			return nil, err
+		}
 		namespace := req.GetString("namespace", "")
 
 		slog.Info("Loading create resource", "kind", kind, "namespace", namespace, "manifest", manifest)
 
-		discoveryClient, err := s.cb.GetDiscoveryClient()
+		objs, err := decodeManifestObjects(manifest, createIgnoredFields)
 		if err != nil {
 			return nil, err
 		}
 
-		gvr, err := lookupGroupVersionResource(discoveryClient, kind)
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
 		if err != nil {
 			return nil, err
 		}
 
-		obj := &unstructured.Unstructured{}
-		if err = json.Unmarshal([]byte(manifest), &obj.Object); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+		mappings, err := resolveDocumentResources(discoveryClient, kind, objs)
+		if err != nil {
+			return nil, err
 		}
 
-		dynamicClient, err := s.cb.GetDynamicClient()
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
 		if err != nil {
 			return nil, err
 		}
 
-		var result *unstructured.Unstructured
-		if len(namespace) > 0 || len(obj.GetNamespace()) > 0 {
-			targetNamespace := namespace
-			if targetNamespace == "" {
-				targetNamespace = obj.GetNamespace()
+		results := make([]DocumentResult, 0, len(objs))
+		for i, obj := range objs {
+			var result *unstructured.Unstructured
+			ri, err := s.documentResource(ctx, dynamicClient, mappings[i], namespace, obj)
+			if err == nil {
+				result, err = ri.Create(ctx, obj, metav1.CreateOptions{})
 			}
-			result, err = dynamicClient.Resource(gvr).Namespace(targetNamespace).Create(ctx, obj, metav1.CreateOptions{})
-		} else {
-			result, err = dynamicClient.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
+			if len(objs) == 1 {
+				if err != nil {
+					return nil, fmt.Errorf("failed to create resource: %w", err)
+				}
+				resp, err := json.Marshal(result.UnstructuredContent())
+				if err != nil {
+					return nil, err
+				}
+				return mcp.NewToolResultText(string(resp)), nil
+			}
+			results = append(results, newDocumentResult(i, obj, result, err))
+		}
+		return newDocumentResults(results)
+	}
+}
+
+func (s *Server) UpdateResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind := req.GetString("kind", "")
+		if len(kind) > 0 {
+			kind = definition.ResolveKind(kind)
 		}
+
+		manifest, err := req.RequireString("manifest")
+		if err != nil {
+			return nil, err
+		}
+		if err = checkManifestSize(manifest); err != nil {
+			return nil, err
+		}
+		resourceName := req.GetString("name", "")
+		namespace := req.GetString("namespace", "")
+		force := req.GetBool("force", false)
+		overrideGitOps := req.GetBool("overrideGitOps", false)
+		subresources, err := parseSubresource(req.GetString("subresource", ""))
 		if err != nil {
-			return nil, fmt.Errorf("failed to create resource: %w", err)
+			return nil, err
 		}
 
-		resp, err := json.Marshal(result.UnstructuredContent())
+		slog.Info("Loading update resource", "kind", kind, "namespace", namespace, "name", resourceName, "subresource", subresources, "force", force, "manifest", manifest)
+
+		ignoredFields := updateIgnoredFields
+		if slices.Contains(subresources, "status") {
+			// the status is what the update of the status subresource changes.
+			ignoredFields = ignoredFields[1:]
+		}
+		objs, err := decodeManifestObjects(manifest, ignoredFields)
 		if err != nil {
 			return nil, err
 		}
-		return mcp.NewToolResultText(string(resp)), nil
+		if len(resourceName) > 0 && len(objs) > 1 {
+			return nil, fmt.Errorf("name can't be used with the manifest of %d documents, the names of the documents are updated", len(objs))
+		}
+		if len(resourceName) > 0 && objs[0].GetName() != resourceName {
+			return nil, fmt.Errorf("failed to update resource due to the name is mismatch the object")
+		}
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+
+		mappings, err := resolveDocumentResources(discoveryClient, kind, objs)
+		if err != nil {
+			return nil, err
+		}
+
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+
+		results := make([]DocumentResult, 0, len(objs))
+		for i, obj := range objs {
+			var (
+				result   *unstructured.Unstructured
+				conflict *UpdateConflict
+			)
+			ri, err := s.documentResource(ctx, dynamicClient, mappings[i], namespace, obj)
+			if err == nil {
+				// the status is never reverted by the gitops tools.
+				err = s.checkGitOps(ctx, ri, obj.GetName(), overrideGitOps || slices.Contains(subresources, "status"))
+			}
+			if err == nil {
+				result, conflict, err = updateOnConflict(ctx, ri, obj, force, subresources...)
+			}
+			if len(objs) > 1 {
+				documentResult := newDocumentResult(i, obj, result, err)
+				documentResult.Conflict = conflict
+				results = append(results, documentResult)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to update resource: %w", err)
+			}
+			if conflict != nil {
+				data, err := json.Marshal(conflict)
+				if err != nil {
+					return nil, err
+				}
+				return mcp.NewToolResultError(string(data)), nil
+			}
+			resp, err := json.Marshal(result.UnstructuredContent())
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultText(string(resp)), nil
+		}
+		return newDocumentResults(results)
 	}
 }
 
-func (s *Server) UpdateResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// documentResource returns the resource interface of the object of the manifest. The namespace-scoped object is
+// created or updated in the namespace of the manifest, the one of the parameter, or the default one in order. The
+// namespace parameter passed by the client must not conflict with the one of the manifest, while the session
+// default never overrides it.
+func (s *Server) documentResource(ctx context.Context, dynamicClient dynamic.Interface, mapping documentMapping, namespace string, obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	if !mapping.namespaced {
+		return dynamicClient.Resource(mapping.gvr), nil
+	}
+	switch manifestNamespace := obj.GetNamespace(); {
+	case len(manifestNamespace) > 0:
+		if len(namespace) > 0 && namespace != manifestNamespace && !isSessionDefault(ctx, "namespace") {
+			return nil, fmt.Errorf("the namespace %q of %s %s conflicts with the namespace parameter %q", manifestNamespace, obj.GetKind(), obj.GetName(), namespace)
+		}
+		namespace = manifestNamespace
+	case len(namespace) == 0:
+		namespace = s.defaultNamespace(ctx)
+	}
+	obj.SetNamespace(namespace)
+	return dynamicClient.Resource(mapping.gvr).Namespace(namespace), nil
+}
+
+func (s *Server) PatchResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		kind, err := req.RequireString("kind")
+		resourceName, err := req.RequireString("name")
 		if err != nil {
 			return nil, err
 		}
-
-		resourceName, err := req.RequireString("name")
+		patch, err := req.RequireString("patch")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		patchType := req.GetString("patchType", "merge")
+		overrideGitOps := req.GetBool("overrideGitOps", false)
+		subresources, err := parseSubresource(req.GetString("subresource", ""))
 		if err != nil {
 			return nil, err
 		}
 
-		manifest, err := req.RequireString("manifest")
+		kind, resourceName, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
 		if err != nil {
 			return nil, err
 		}
-		namespace := req.GetString("namespace", "")
 
-		slog.Info("Loading update resource", "kind", kind, "namespace", namespace, "name", resourceName, "manifest", manifest)
+		slog.Info("Patching resource", "kind", kind, "name", resourceName, "namespace", namespace, "patchType", patchType, "subresource", subresources, "patch", patch)
 
-		obj := &unstructured.Unstructured{}
-		if err = json.Unmarshal([]byte(manifest), &obj.Object); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+		var pt types.PatchType
+		switch patchType {
+		case "merge":
+			pt = types.MergePatchType
+		case "json":
+			pt = types.JSONPatchType
+		case "strategic":
+			pt = types.StrategicMergePatchType
+		default:
+			return nil, fmt.Errorf("unsupported patch type %q, must be one of merge, json or strategic", patchType)
 		}
-
-		if obj.GetName() != resourceName {
-			return nil, fmt.Errorf("failed to update resource due to the name is mismatch the object")
+		data, err := yaml.YAMLToJSON([]byte(patch))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode patch: %w", err)
 		}
 
-		discoveryClient, err := s.cb.GetDiscoveryClient()
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
 		if err != nil {
 			return nil, err
 		}
-
-		gvr, err := lookupGroupVersionResource(discoveryClient, kind)
+		gvr, namespaced, err := lookupKindResource(discoveryClient, kind)
 		if err != nil {
 			return nil, err
 		}
+		if namespaced && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
 
-		dynamicClient, err := s.cb.GetDynamicClient()
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
 		if err != nil {
 			return nil, err
 		}
 
-		var result *unstructured.Unstructured
+		var ri dynamic.ResourceInterface = dynamicClient.Resource(gvr)
 		if len(namespace) > 0 {
-			result, err = dynamicClient.Resource(gvr).Namespace(namespace).Update(ctx, obj, metav1.UpdateOptions{})
-		} else {
-			result, err = dynamicClient.Resource(gvr).Update(ctx, obj, metav1.UpdateOptions{})
+			ri = dynamicClient.Resource(gvr).Namespace(namespace)
+		}
+		if err = s.checkGitOps(ctx, ri, resourceName, overrideGitOps || slices.Contains(subresources, "status")); err != nil {
+			return nil, err
 		}
+		result, err := ri.Patch(ctx, resourceName, pt, data, metav1.PatchOptions{}, subresources...)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update resource: %w", err)
+			return nil, fmt.Errorf("failed to patch resource: %w", err)
 		}
+		s.scrubber.scrub(result.Object)
 
 		resp, err := json.Marshal(result.UnstructuredContent())
 		if err != nil {
@@ -337,40 +656,46 @@ func (s *Server) UpdateResource() func(ctx context.Context, req mcp.CallToolRequ
 
 func (s *Server) DeleteResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		kind, err := req.RequireString("kind")
+		resourceName, err := req.RequireString("name")
 		if err != nil {
 			return nil, err
 		}
+		namespace := req.GetString("namespace", "")
+		overrideGitOps := req.GetBool("overrideGitOps", false)
 
-		resourceName, err := req.RequireString("name")
+		kind, resourceName, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
 		if err != nil {
 			return nil, err
 		}
-		namespace := req.GetString("namespace", "")
 
 		slog.Info("Loading delete resource", "kind", kind, "name", resourceName, "namespace", namespace)
 
-		discoveryClient, err := s.cb.GetDiscoveryClient()
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
 		if err != nil {
 			return nil, err
 		}
 
-		gvr, err := lookupGroupVersionResource(discoveryClient, kind)
+		gvr, namespaced, err := lookupKindResource(discoveryClient, kind)
 		if err != nil {
 			return nil, err
 		}
+		if namespaced && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
 
-		dynamicClient, err := s.cb.GetDynamicClient()
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
 		if err != nil {
 			return nil, err
 		}
 
+		var ri dynamic.ResourceInterface = dynamicClient.Resource(gvr)
 		if len(namespace) > 0 {
-			err = dynamicClient.Resource(gvr).Namespace(namespace).Delete(ctx, resourceName, metav1.DeleteOptions{})
-		} else {
-			err = dynamicClient.Resource(gvr).Delete(ctx, resourceName, metav1.DeleteOptions{})
+			ri = dynamicClient.Resource(gvr).Namespace(namespace)
 		}
-		if err != nil {
+		if err = s.checkGitOps(ctx, ri, resourceName, overrideGitOps); err != nil {
+			return nil, err
+		}
+		if err = ri.Delete(ctx, resourceName, metav1.DeleteOptions{}); err != nil {
 			return nil, fmt.Errorf("failed to delete resource: %w", err)
 		}
 		return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted resource %s/%s", kind, resourceName)), nil
@@ -378,9 +703,15 @@ func (s *Server) DeleteResource() func(ctx context.Context, req mcp.CallToolRequ
 }
 
 func lookupGroupVersionResource(discoveryClient discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, error) {
+	gvr, _, err := lookupKindResource(discoveryClient, kind)
+	return gvr, err
+}
+
+// lookupKindResource returns the preferred resource of the kind and whether it is namespace-scoped.
+func lookupKindResource(discoveryClient discovery.DiscoveryInterface, kind string) (schema.GroupVersionResource, bool, error) {
 	apiResources, err := discoveryClient.ServerPreferredResources()
 	if err != nil {
-		return schema.GroupVersionResource{}, err
+		return schema.GroupVersionResource{}, false, err
 	}
 
 	for _, apiResource := range apiResources {
@@ -397,8 +728,30 @@ func lookupGroupVersionResource(discoveryClient discovery.DiscoveryInterface, ki
 				Group:    gv.Group,
 				Version:  gv.Version,
 				Resource: resource.Name,
-			}, nil
+			}, resource.Namespaced, nil
+		}
+	}
+	return schema.GroupVersionResource{}, false, fmt.Errorf("not found resource for kind %q", kind)
+}
+
+func lookupGroupKindResource(discoveryClient discovery.DiscoveryInterface, gk schema.GroupKind) (schema.GroupVersionResource, bool, error) {
+	apiResources, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+
+	for _, apiResource := range apiResources {
+		gv, err := schema.ParseGroupVersion(apiResource.GroupVersion)
+		if err != nil || gv.Group != gk.Group {
+			continue
+		}
+
+		for _, resource := range apiResource.APIResources {
+			if resource.Kind != gk.Kind || strings.Contains(resource.Name, "/") {
+				continue
+			}
+			return gv.WithResource(resource.Name), resource.Namespaced, nil
 		}
 	}
-	return schema.GroupVersionResource{}, fmt.Errorf("not found resource for kind %q", kind)
+	return schema.GroupVersionResource{}, false, fmt.Errorf("not found resource for %q", gk.String())
 }
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+func TestDocumentResourceNamespace(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	sessionDefault := context.WithValue(context.Background(), sessionDefaultedKey{}, []string{"namespace"})
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		namespaced bool
+		manifest   string
+		param      string
+		want       string
+		wantErr    bool
+	}{
+		{name: "manifest namespace", ctx: context.Background(), namespaced: true, manifest: "prod", want: "prod"},
+		{name: "parameter for the manifest without one", ctx: context.Background(), namespaced: true, param: "dev", want: "dev"},
+		{name: "agreeing parameter", ctx: context.Background(), namespaced: true, manifest: "prod", param: "prod", want: "prod"},
+		{name: "conflicting parameter", ctx: context.Background(), namespaced: true, manifest: "prod", param: "dev", wantErr: true},
+		{name: "session default never overrides the manifest", ctx: sessionDefault, namespaced: true, manifest: "prod", param: "dev", want: "prod"},
+		{name: "session default for the manifest without one", ctx: sessionDefault, namespaced: true, param: "dev", want: "dev"},
+		{name: "cluster-scoped", ctx: context.Background(), param: "dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("ConfigMap")
+			obj.SetName("app")
+			obj.SetNamespace(tt.manifest)
+			if !tt.namespaced {
+				obj.SetNamespace("")
+			}
+
+			s := &Server{}
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			_, err := s.documentResource(tt.ctx, dynamicClient, documentMapping{gvr: configMaps, namespaced: tt.namespaced}, tt.param, obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && obj.GetNamespace() != tt.want {
+				t.Fatalf("got namespace %q, want %q", obj.GetNamespace(), tt.want)
+			}
+		})
+	}
+}
+
+func TestListResourcesJSONPathOutput(t *testing.T) {
+	resources := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}}},
+	}
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("shop")
+	configMap.SetName("app")
+
+	tests := []struct {
+		name      string
+		defaulted []string
+		output    string
+		wantError bool
+	}{
+		{name: "jsonpath", output: definition.OutputJSON},
+		{name: "explicit output", output: definition.OutputMarkdown, wantError: true},
+		{name: "output of the session defaults", defaulted: []string{"output"}, output: definition.OutputMarkdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newFakeServer(resources, nil, configMap)
+			ctx := context.WithValue(context.Background(), sessionDefaultedKey{}, tt.defaulted)
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = map[string]any{"kind": "ConfigMap", "namespace": "shop", "jsonpath": "{.items[*].metadata.name}", "output": tt.output}
+
+			result, err := s.ListResources()(ctx, req)
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("got no error, want output refused with jsonpath")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "app") {
+				t.Fatalf("got result %q, want the jsonpath of the names", text)
+			}
+		})
+	}
+}
+
+func TestCreateResource(t *testing.T) {
+	resources := []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"create", "get", "list"}},
+			{Name: "namespaces", Kind: "Namespace", Verbs: []string{"create", "get", "list"}},
+		},
+	}}
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion("v1")
+	existing.SetKind("ConfigMap")
+	existing.SetNamespace("shop")
+	existing.SetName("taken")
+
+	tests := []struct {
+		name      string
+		args      map[string]any
+		want      []string
+		wantError string
+	}{
+		{
+			name: "single document",
+			args: map[string]any{"manifest": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n", "namespace": "shop"},
+			want: []string{`"namespace":"shop"`, `"name":"app"`},
+		},
+		{
+			name: "multiple documents",
+			args: map[string]any{"manifest": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: dev\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: dev\n"},
+			want: []string{`"index":0,"kind":"Namespace"`, `"index":1,"kind":"ConfigMap","namespace":"dev"`},
+		},
+		{
+			name:      "existing object",
+			args:      map[string]any{"manifest": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: taken\n  namespace: shop\n"},
+			wantError: "already exists",
+		},
+		{
+			name:      "conflicting namespace",
+			args:      map[string]any{"manifest": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n  namespace: prod\n", "namespace": "shop"},
+			wantError: "conflicts with the namespace parameter",
+		},
+		{
+			name:      "disagreeing kind",
+			args:      map[string]any{"manifest": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n", "kind": "secret"},
+			wantError: "disagrees with the kind Secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newFakeServer(resources, nil, existing.DeepCopy())
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = tt.args
+			result, err := s.CreateResource()(context.Background(), req)
+			if len(tt.wantError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("got error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil || result.IsError {
+				t.Fatalf("got error %v with result %+v, want the objects created", err, result)
+			}
+			text := result.Content[0].(mcp.TextContent).Text
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Fatalf("got result %s, want %s in it", text, want)
+				}
+			}
+		})
+	}
+}
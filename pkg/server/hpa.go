@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// HPAReport is the structured state of the HorizontalPodAutoscaler.
+type HPAReport struct {
+	Namespace       string                 `json:"namespace"`
+	Name            string                 `json:"name"`
+	ScaleTargetRef  string                 `json:"scaleTargetRef"`
+	MinReplicas     int32                  `json:"minReplicas"`
+	MaxReplicas     int32                  `json:"maxReplicas"`
+	CurrentReplicas int32                  `json:"currentReplicas"`
+	DesiredReplicas int32                  `json:"desiredReplicas"`
+	LastScaleTime   string                 `json:"lastScaleTime,omitempty"`
+	Metrics         []definition.HPAMetric `json:"metrics"`
+	Conditions      []HPACondition         `json:"conditions,omitempty"`
+	Events          []EventSummary         `json:"events,omitempty"`
+	// Hints are the likely reasons why the HPA is not scaling, derived from the conditions and the replicas.
+	Hints []string `json:"hints,omitempty"`
+}
+
+// HPACondition is the condition of the HorizontalPodAutoscaler.
+type HPACondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+func (s *Server) InspectHPA() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Inspecting hpa", "name", name, "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		hpa, err := cli.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hpa: %w", err)
+		}
+
+		report := HPAReport{
+			Namespace:       namespace,
+			Name:            name,
+			ScaleTargetRef:  fmt.Sprintf("%s/%s", hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name),
+			MinReplicas:     ptr.Deref(hpa.Spec.MinReplicas, 1),
+			MaxReplicas:     hpa.Spec.MaxReplicas,
+			CurrentReplicas: hpa.Status.CurrentReplicas,
+			DesiredReplicas: hpa.Status.DesiredReplicas,
+			Metrics:         definition.HPAMetrics(hpa.Spec.Metrics, hpa.Status.CurrentMetrics),
+		}
+		if hpa.Status.LastScaleTime != nil {
+			report.LastScaleTime = hpa.Status.LastScaleTime.UTC().Format(time.RFC3339)
+		}
+		for _, c := range hpa.Status.Conditions {
+			report.Conditions = append(report.Conditions, HPACondition{
+				Type:               string(c.Type),
+				Status:             string(c.Status),
+				Reason:             c.Reason,
+				Message:            c.Message,
+				LastTransitionTime: c.LastTransitionTime.UTC().Format(time.RFC3339),
+			})
+		}
+		report.Hints = hpaHints(hpa)
+
+		events, err := listObjectEvents(ctx, cli, namespace, "HorizontalPodAutoscaler", name)
+		if err != nil {
+			slog.Warn("Failed to list hpa events", "name", name, "namespace", namespace, "err", err)
+		}
+		report.Events = events
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) ScaleHPA() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		minReplicas := req.GetInt("minReplicas", 0)
+		maxReplicas := req.GetInt("maxReplicas", 0)
+		if minReplicas <= 0 && maxReplicas <= 0 {
+			return nil, errors.New("at least one of minReplicas or maxReplicas is required")
+		}
+
+		slog.Info("Scaling hpa", "name", name, "namespace", namespace, "minReplicas", minReplicas, "maxReplicas", maxReplicas)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		hpa, err := cli.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hpa: %w", err)
+		}
+
+		spec := map[string]any{}
+		newMin, newMax := ptr.Deref(hpa.Spec.MinReplicas, 1), hpa.Spec.MaxReplicas
+		if minReplicas > 0 {
+			newMin = int32(minReplicas)
+			spec["minReplicas"] = newMin
+		}
+		if maxReplicas > 0 {
+			newMax = int32(maxReplicas)
+			spec["maxReplicas"] = newMax
+		}
+		if newMin > newMax {
+			return nil, fmt.Errorf("minReplicas %d must not be greater than maxReplicas %d", newMin, newMax)
+		}
+
+		// the resource version guards the min/max check against the concurrent updates.
+		patch, err := json.Marshal(map[string]any{
+			"metadata": map[string]any{"resourceVersion": hpa.ResourceVersion},
+			"spec":     spec,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = cli.AutoscalingV2().HorizontalPodAutoscalers(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to patch hpa: %w", err)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("HorizontalPodAutoscaler %s/%s scaled to minReplicas=%d, maxReplicas=%d", namespace, name, newMin, newMax)), nil
+	}
+}
+
+// hpaHints derives the likely reasons why the HPA is not scaling from its conditions and replicas.
+func hpaHints(hpa *autoscalingv2.HorizontalPodAutoscaler) []string {
+	var hints []string
+	for _, c := range hpa.Status.Conditions {
+		switch {
+		case c.Type == autoscalingv2.AbleToScale && c.Status == corev1.ConditionFalse:
+			hints = append(hints, fmt.Sprintf("the HPA is unable to scale the target (%s): %s", c.Reason, c.Message))
+		case c.Type == autoscalingv2.ScalingActive && c.Status == corev1.ConditionFalse:
+			hints = append(hints, fmt.Sprintf("the metrics are not available (%s), check the metrics server or adapter and the resource requests of the pods: %s", c.Reason, c.Message))
+		case c.Type == autoscalingv2.ScalingLimited && c.Status == corev1.ConditionTrue:
+			hints = append(hints, fmt.Sprintf("the desired replicas are capped by the replica bounds (%s): %s", c.Reason, c.Message))
+		case c.Type == autoscalingv2.AbleToScale && c.Reason == "ScaleDownStabilized":
+			hints = append(hints, "the scale down is delayed by the stabilization window of the scaling behavior")
+		}
+	}
+	if hpa.Status.CurrentReplicas == hpa.Spec.MaxReplicas && hpa.Status.DesiredReplicas >= hpa.Spec.MaxReplicas {
+		hints = append(hints, "the target runs at maxReplicas, raise maxReplicas if the metrics stay above the targets")
+	}
+	return hints
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultRegistry  = "docker.io"
+	defaultImageTag  = "latest"
+	maxImageWorkload = 20
+)
+
+// ImageUsage is the usage of an image reference in the cluster.
+type ImageUsage struct {
+	Image      string `json:"image"`
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	// Pinned is true if the image is referenced by digest rather than a mutable tag.
+	Pinned     bool `json:"pinned"`
+	Containers int  `json:"containers"`
+	Pods       int  `json:"pods"`
+	// RunningDigests are the digests the image was resolved to by the kubelets, more than one digest of a tag means
+	// the pods run different builds of the same tag.
+	RunningDigests []string `json:"runningDigests,omitempty"`
+	Namespaces     []string `json:"namespaces"`
+	Workloads      []string `json:"workloads"`
+}
+
+// ImageInventory is the images running in the cluster, grouped by the image reference.
+type ImageInventory struct {
+	Images       []ImageUsage   `json:"images"`
+	Registries   map[string]int `json:"registries"`
+	TotalImages  int            `json:"totalImages"`
+	PinnedImages int            `json:"pinnedImages"`
+	LatestImages int            `json:"latestImages"`
+}
+
+type imageAccumulator struct {
+	usage      ImageUsage
+	pods       sets.Set[string]
+	digests    sets.Set[string]
+	namespaces sets.Set[string]
+	workloads  sets.Set[string]
+}
+
+func (s *Server) ImageInventory() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		filter := req.GetString("filter", "")
+
+		slog.Info("Generating image inventory", "namespace", namespace, "filter", filter)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		owners, err := workloadOwners(ctx, cli, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		images := make(map[string]*imageAccumulator)
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			imageIDs := make(map[string]string)
+			for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+				for _, status := range statuses {
+					imageIDs[status.Name] = status.ImageID
+				}
+			}
+			workload := podWorkload(pod, owners)
+
+			containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+			for _, c := range containers {
+				if len(filter) > 0 && !strings.Contains(c.Image, filter) {
+					continue
+				}
+				acc, ok := images[c.Image]
+				if !ok {
+					acc = &imageAccumulator{
+						usage:      parseImageReference(c.Image),
+						pods:       sets.New[string](),
+						digests:    sets.New[string](),
+						namespaces: sets.New[string](),
+						workloads:  sets.New[string](),
+					}
+					images[c.Image] = acc
+				}
+				acc.usage.Containers++
+				acc.pods.Insert(pod.Namespace + "/" + pod.Name)
+				acc.namespaces.Insert(pod.Namespace)
+				acc.workloads.Insert(workload)
+				if _, digest, found := strings.Cut(imageIDs[c.Name], "@"); found {
+					acc.digests.Insert(digest)
+				}
+			}
+		}
+
+		inventory := ImageInventory{Images: make([]ImageUsage, 0, len(images)), Registries: make(map[string]int)}
+		for _, acc := range images {
+			usage := acc.usage
+			usage.Pods = acc.pods.Len()
+			usage.RunningDigests = sets.List(acc.digests)
+			usage.Namespaces = sets.List(acc.namespaces)
+			usage.Workloads = sets.List(acc.workloads)
+			if len(usage.Workloads) > maxImageWorkload {
+				usage.Workloads = append(usage.Workloads[:maxImageWorkload], fmt.Sprintf("... %d more", acc.workloads.Len()-maxImageWorkload))
+			}
+
+			inventory.Registries[usage.Registry]++
+			if usage.Pinned {
+				inventory.PinnedImages++
+			} else if usage.Tag == defaultImageTag {
+				inventory.LatestImages++
+			}
+			inventory.Images = append(inventory.Images, usage)
+		}
+		inventory.TotalImages = len(inventory.Images)
+		sort.Slice(inventory.Images, func(i, j int) bool {
+			a, b := inventory.Images[i], inventory.Images[j]
+			if a.Containers != b.Containers {
+				return a.Containers > b.Containers
+			}
+			return a.Image < b.Image
+		})
+
+		resp, err := json.Marshal(inventory)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// workloadOwners maps the ReplicaSets and Jobs to the Deployments and CronJobs owning them, keyed by
+// `<Kind>/<namespace>/<name>`, so that the pods are reported by their top level workloads.
+func workloadOwners(ctx context.Context, cli kubernetes.Interface, namespace string) (map[string]string, error) {
+	owners := make(map[string]string)
+	replicaSets, err := cli.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if ref := metav1.GetControllerOf(rs); ref != nil {
+			owners["ReplicaSet/"+rs.Namespace+"/"+rs.Name] = ref.Kind + "/" + rs.Namespace + "/" + ref.Name
+		}
+	}
+	jobs, err := cli.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if ref := metav1.GetControllerOf(job); ref != nil {
+			owners["Job/"+job.Namespace+"/"+job.Name] = ref.Kind + "/" + job.Namespace + "/" + ref.Name
+		}
+	}
+	return owners, nil
+}
+
+// podWorkload returns the top level workload of the pod like `Deployment/<namespace>/<name>`, the bare pods are
+// reported as themselves.
+func podWorkload(pod *corev1.Pod, owners map[string]string) string {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "Pod/" + pod.Namespace + "/" + pod.Name
+	}
+	workload := ref.Kind + "/" + pod.Namespace + "/" + ref.Name
+	if owner, ok := owners[workload]; ok {
+		return owner
+	}
+	return workload
+}
+
+// parseImageReference splits the image reference into the registry, repository, tag and digest with the docker
+// defaults, e.g. nginx is docker.io/library/nginx:latest.
+func parseImageReference(image string) ImageUsage {
+	usage := ImageUsage{Image: image, Registry: defaultRegistry}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, usage.Digest = name[:i], name[i+1:]
+		usage.Pinned = true
+	}
+	// the colon after the last slash separates the tag, a colon before it belongs to the registry port.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, usage.Tag = name[:i], name[i+1:]
+	}
+	if !usage.Pinned && len(usage.Tag) == 0 {
+		usage.Tag = defaultImageTag
+	}
+
+	// the first component is a registry if it looks like a host, e.g. gcr.io, localhost:5000 or localhost.
+	if first, rest, found := strings.Cut(name, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		usage.Registry, name = first, rest
+	}
+	if usage.Registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	usage.Repository = name
+	return usage
+}
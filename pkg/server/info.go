@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	koffeemcp "cola.io/koffee/pkg/mcp"
+	"cola.io/koffee/pkg/version"
+)
+
+// ServerInfo is the version, configuration and capabilities of the server, so that the clients and operators know
+// what the server allows before calling the tools.
+type ServerInfo struct {
+	Name    string       `json:"name"`
+	Version version.Info `json:"version"`
+	// Instructions is set if the server has the instructions, which the clients may not show to the agents.
+	Instructions string `json:"instructions,omitempty"`
+	Transport    string `json:"transport"`
+	// ReadOnly is true if none of the enabled tools modifies the cluster.
+	ReadOnly       bool        `json:"readOnly"`
+	Context        string      `json:"context,omitempty"`
+	InCluster      bool        `json:"inCluster"`
+	Namespace      string      `json:"namespace"`
+	ClusterVersion string      `json:"clusterVersion,omitempty"`
+	ClusterError   string      `json:"clusterError,omitempty"`
+	Limits         ServerLimit `json:"limits"`
+	// Cache is the counters of the result cache, nil if the cache is disabled.
+	Cache *CacheStats `json:"cache,omitempty"`
+	Tools []ToolInfo  `json:"tools"`
+}
+
+// ServerLimit is the limits of the tool calls, the zero values mean no limit.
+type ServerLimit struct {
+	MaxResultBytes      int               `json:"maxResultBytes"`
+	MaxLogTailLines     int               `json:"maxLogTailLines"`
+	MaxLogBytes         int64             `json:"maxLogBytes"`
+	MaxExecOutputBytes  int64             `json:"maxExecOutputBytes"`
+	MaxConcurrentTools  int               `json:"maxConcurrentTools"`
+	RateLimit           float64           `json:"rateLimit"`
+	RateBurst           int               `json:"rateBurst"`
+	ToolTimeout         string            `json:"toolTimeout"`
+	ToolTimeouts        map[string]string `json:"toolTimeouts,omitempty"`
+	MaxCallTimeout      string            `json:"maxCallTimeout"`
+	MaxCallRetries      int               `json:"maxCallRetries"`
+	GitMaxManifestBytes int64             `json:"gitMaxManifestBytes"`
+	GitMaxFetchBytes    int64             `json:"gitMaxFetchBytes"`
+	CacheTTL            string            `json:"cacheTTL,omitempty"`
+}
+
+// ToolInfo is an enabled tool with its behavior hints and the per-call options it accepts.
+type ToolInfo struct {
+	Name        string `json:"name"`
+	ReadOnly    bool   `json:"readOnly"`
+	Destructive bool   `json:"destructive,omitempty"`
+	Timeout     bool   `json:"timeout,omitempty"`
+	Retries     bool   `json:"retries,omitempty"`
+}
+
+func newToolInfo(tool mcp.Tool) ToolInfo {
+	info := ToolInfo{
+		Name:        tool.Name,
+		ReadOnly:    tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint,
+		Destructive: tool.Annotations.DestructiveHint != nil && *tool.Annotations.DestructiveHint,
+	}
+	info.Timeout, info.Retries = koffeemcp.CallOptions(tool)
+	return info
+}
+
+func (s *Server) ServerInfo() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		slog.Info("Getting server info")
+
+		cb := s.builder(ctx)
+		info := &ServerInfo{
+			Name:         s.name,
+			Version:      version.Get(),
+			Instructions: s.instructions,
+			Transport:    s.transport,
+			ReadOnly:     true,
+			InCluster:    cb.InCluster(),
+			Namespace:    s.defaultNamespace(ctx),
+			Limits: ServerLimit{
+				MaxResultBytes:      s.maxResultBytes,
+				MaxLogTailLines:     s.maxLogTailLines,
+				MaxLogBytes:         s.maxLogBytes,
+				MaxExecOutputBytes:  s.maxExecBytes,
+				MaxConcurrentTools:  s.maxConcurrent,
+				RateLimit:           s.rateLimit,
+				RateBurst:           s.rateBurst,
+				ToolTimeout:         s.toolTimeout.String(),
+				MaxCallTimeout:      maxCallTimeout.String(),
+				MaxCallRetries:      maxCallRetries,
+				GitMaxManifestBytes: s.gitMaxManifestBytes,
+				GitMaxFetchBytes:    s.gitMaxFetchBytes,
+			},
+			Tools: s.tools,
+		}
+		if len(s.toolTimeouts) > 0 {
+			info.Limits.ToolTimeouts = make(map[string]string, len(s.toolTimeouts))
+			for name, timeout := range s.toolTimeouts {
+				info.Limits.ToolTimeouts[name] = timeout.String()
+			}
+		}
+		if s.cache != nil {
+			stats := s.cache.snapshot()
+			info.Cache = &stats
+			info.Limits.CacheTTL = s.cacheTTL.String()
+		}
+		for _, tool := range s.tools {
+			info.ReadOnly = info.ReadOnly && tool.ReadOnly
+		}
+		if !info.InCluster {
+			if name, ok := s.sessions.context(sessionID(ctx)); ok {
+				info.Context = name
+			} else if cfg, err := s.cb.LoadRawConfig(); err == nil {
+				info.Context = cfg.CurrentContext
+			}
+		}
+
+		// the server info is still returned if the cluster is unreachable.
+		if discoveryClient, err := cb.GetDiscoveryClient(); err != nil {
+			info.ClusterError = err.Error()
+		} else if serverVersion, err := discoveryClient.ServerVersion(); err != nil {
+			info.ClusterError = err.Error()
+		} else {
+			info.ClusterVersion = serverVersion.GitVersion
+		}
+
+		resp, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
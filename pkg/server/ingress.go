@@ -0,0 +1,345 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// ingressClassAnnotation is the deprecated annotation of the ingress class, still honored by most controllers.
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	// defaultIngressClassAnnotation marks the IngressClass used by the ingresses without the class.
+	defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+)
+
+// IngressTrace is the Ingress rules matching the request, sorted by the precedence, the first match handles the
+// request.
+type IngressTrace struct {
+	Hostname string         `json:"hostname"`
+	Path     string         `json:"path"`
+	Matches  []IngressMatch `json:"matches"`
+}
+
+// IngressMatch is the Ingress rule matching the request with the controller, the backend and the TLS certificate
+// handling it.
+type IngressMatch struct {
+	Ingress    string         `json:"ingress"`
+	Class      string         `json:"class,omitempty"`
+	Controller string         `json:"controller,omitempty"`
+	Addresses  []string       `json:"addresses,omitempty"`
+	Host       string         `json:"host,omitempty"`
+	Path       string         `json:"path,omitempty"`
+	PathType   string         `json:"pathType"`
+	Backend    IngressBackend `json:"backend"`
+	TLS        *IngressTLS    `json:"tls,omitempty"`
+	Warnings   []string       `json:"warnings,omitempty"`
+}
+
+// IngressBackend is the backend of the Ingress rule.
+type IngressBackend struct {
+	Service        string `json:"service,omitempty"`
+	Port           string `json:"port,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	ReadyEndpoints *int   `json:"readyEndpoints,omitempty"`
+	Warning        string `json:"warning,omitempty"`
+}
+
+// IngressTLS is the TLS certificate serving the hostname.
+type IngressTLS struct {
+	Secret     string `json:"secret"`
+	Subject    string `json:"subject,omitempty"`
+	NotAfter   string `json:"notAfter,omitempty"`
+	DaysLeft   int    `json:"daysLeft,omitempty"`
+	CoversHost bool   `json:"coversHost"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ingressCandidate is a match of an Ingress path with the precedence keys.
+type ingressCandidate struct {
+	ingress  *networkingv1.Ingress
+	host     string
+	path     networkingv1.HTTPIngressPath
+	hostRank int
+	// isDefault is true for the default backend of the Ingress, which only handles the unmatched requests.
+	isDefault bool
+}
+
+func (s *Server) TraceIngress() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		hostname, err := req.RequireString("hostname")
+		if err != nil {
+			return nil, err
+		}
+		hostname = strings.ToLower(hostname)
+		path := req.GetString("path", "/")
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		namespace := req.GetString("namespace", "")
+
+		slog.Info("Tracing ingress", "hostname", hostname, "path", path, "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		ingresses, err := cli.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ingresses: %w", err)
+		}
+		classes, err := cli.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ingressclasses: %w", err)
+		}
+
+		var candidates []ingressCandidate
+		for i := range ingresses.Items {
+			candidates = append(candidates, matchIngress(&ingresses.Items[i], hostname, path)...)
+		}
+		sortIngressCandidates(candidates)
+
+		trace := IngressTrace{Hostname: hostname, Path: path, Matches: make([]IngressMatch, 0, len(candidates))}
+		now := time.Now()
+		for _, candidate := range candidates {
+			ing := candidate.ingress
+			match := IngressMatch{
+				Ingress:  ing.Namespace + "/" + ing.Name,
+				Host:     candidate.host,
+				Path:     candidate.path.Path,
+				PathType: string(ptr.Deref(candidate.path.PathType, networkingv1.PathTypeImplementationSpecific)),
+			}
+			if candidate.isDefault {
+				match.PathType = "DefaultBackend"
+			} else if match.PathType == string(networkingv1.PathTypeImplementationSpecific) {
+				match.Warnings = append(match.Warnings, "the ImplementationSpecific path is matched as a prefix, the controller may interpret it differently")
+			}
+			for _, lb := range ing.Status.LoadBalancer.Ingress {
+				if len(lb.IP) > 0 {
+					match.Addresses = append(match.Addresses, lb.IP)
+				} else if len(lb.Hostname) > 0 {
+					match.Addresses = append(match.Addresses, lb.Hostname)
+				}
+			}
+			if len(match.Addresses) == 0 {
+				match.Warnings = append(match.Warnings, "the ingress has no load balancer address, the controller may not have admitted it")
+			}
+
+			var warning string
+			match.Class, match.Controller, warning = ingressController(ing, classes.Items)
+			if len(warning) > 0 {
+				match.Warnings = append(match.Warnings, warning)
+			}
+			match.Backend = ingressBackend(ctx, cli, ing.Namespace, candidate.path.Backend)
+			match.TLS = ingressTLS(ctx, cli, ing, hostname, now)
+			trace.Matches = append(trace.Matches, match)
+		}
+
+		resp, err := json.Marshal(trace)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// matchIngress returns the paths of the Ingress matching the hostname and the path, and the default backend of the
+// Ingress which handles the requests matching none of the rules.
+func matchIngress(ing *networkingv1.Ingress, hostname, path string) []ingressCandidate {
+	var candidates []ingressCandidate
+	for _, rule := range ing.Spec.Rules {
+		hostRank := 0
+		switch {
+		case len(rule.Host) == 0:
+		case rule.Host == hostname:
+			hostRank = 2
+		case strings.HasPrefix(rule.Host, "*.") && matchIngressWildcard(rule.Host, hostname):
+			hostRank = 1
+		default:
+			continue
+		}
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			value := p.Path
+			if len(value) == 0 {
+				value = "/"
+			}
+			switch ptr.Deref(p.PathType, networkingv1.PathTypeImplementationSpecific) {
+			case networkingv1.PathTypeExact:
+				if path != value {
+					continue
+				}
+			default:
+				prefix := strings.TrimSuffix(value, "/")
+				if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+					continue
+				}
+			}
+			candidates = append(candidates, ingressCandidate{ingress: ing, host: rule.Host, path: p, hostRank: hostRank})
+		}
+	}
+	if ing.Spec.DefaultBackend != nil {
+		candidates = append(candidates, ingressCandidate{
+			ingress:   ing,
+			path:      networkingv1.HTTPIngressPath{Backend: *ing.Spec.DefaultBackend},
+			isDefault: true,
+		})
+	}
+	return candidates
+}
+
+// matchIngressWildcard matches the hostname against the wildcard host of the Ingress, which covers a single label,
+// e.g. *.example.com matches foo.example.com but not foo.bar.example.com.
+func matchIngressWildcard(host, hostname string) bool {
+	suffix := host[1:]
+	label, found := strings.CutSuffix(hostname, suffix)
+	return found && len(label) > 0 && !strings.Contains(label, ".")
+}
+
+// sortIngressCandidates sorts the matches by the precedence: the exact host, the wildcard host and the rules
+// without host, then the longest path with the Exact path first, the default backends come last.
+func sortIngressCandidates(candidates []ingressCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		aExact := ptr.Deref(a.path.PathType, "") == networkingv1.PathTypeExact
+		bExact := ptr.Deref(b.path.PathType, "") == networkingv1.PathTypeExact
+		switch {
+		case a.isDefault != b.isDefault:
+			return !a.isDefault
+		case a.hostRank != b.hostRank:
+			return a.hostRank > b.hostRank
+		case len(a.path.Path) != len(b.path.Path):
+			return len(a.path.Path) > len(b.path.Path)
+		case aExact != bExact:
+			return aExact
+		default:
+			return a.ingress.CreationTimestamp.Before(&b.ingress.CreationTimestamp)
+		}
+	})
+}
+
+// ingressController returns the class and the controller of the Ingress, the class falls back to the deprecated
+// annotation and the default IngressClass.
+func ingressController(ing *networkingv1.Ingress, classes []networkingv1.IngressClass) (string, string, string) {
+	className := ptr.Deref(ing.Spec.IngressClassName, ing.Annotations[ingressClassAnnotation])
+	if len(className) == 0 {
+		for _, class := range classes {
+			if class.Annotations[defaultIngressClassAnnotation] == "true" {
+				return class.Name, class.Spec.Controller, ""
+			}
+		}
+		return "", "", "the ingress has no class and there is no default IngressClass, it may not be served by any controller"
+	}
+	for _, class := range classes {
+		if class.Name == className {
+			return class.Name, class.Spec.Controller, ""
+		}
+	}
+	return className, "", fmt.Sprintf("the IngressClass %q is not found", className)
+}
+
+// ingressBackend checks the service and the port of the backend, and the ready endpoints of the service.
+func ingressBackend(ctx context.Context, cli kubernetes.Interface, namespace string, backend networkingv1.IngressBackend) IngressBackend {
+	if backend.Resource != nil {
+		return IngressBackend{Resource: fmt.Sprintf("%s/%s", backend.Resource.Kind, backend.Resource.Name)}
+	}
+	if backend.Service == nil {
+		return IngressBackend{Warning: "the backend has neither a service nor a resource"}
+	}
+
+	result := IngressBackend{Service: namespace + "/" + backend.Service.Name}
+	if len(backend.Service.Port.Name) > 0 {
+		result.Port = backend.Service.Port.Name
+	} else {
+		result.Port = fmt.Sprintf("%d", backend.Service.Port.Number)
+	}
+
+	svc, err := cli.CoreV1().Services(namespace).Get(ctx, backend.Service.Name, metav1.GetOptions{})
+	if err != nil {
+		result.Warning = fmt.Sprintf("failed to get the service, the controller responds 503: %v", err)
+		return result
+	}
+	if !serviceHasPort(svc, backend.Service.Port) {
+		result.Warning = fmt.Sprintf("the service has no port %s", result.Port)
+		return result
+	}
+	_, ready, _, err := serviceEndpoints(ctx, cli, namespace, svc.Name)
+	if err != nil {
+		result.Warning = err.Error()
+		return result
+	}
+	result.ReadyEndpoints = &ready
+	if ready == 0 && svc.Spec.Type != corev1.ServiceTypeExternalName {
+		result.Warning = "the service has no ready endpoint, the controller responds 503"
+	}
+	return result
+}
+
+func serviceHasPort(svc *corev1.Service, port networkingv1.ServiceBackendPort) bool {
+	for _, p := range svc.Spec.Ports {
+		if (len(port.Name) > 0 && p.Name == port.Name) || (len(port.Name) == 0 && p.Port == port.Number) {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressTLS returns the certificate of the TLS section covering the hostname, nil if the hostname is served
+// without TLS.
+func ingressTLS(ctx context.Context, cli kubernetes.Interface, ing *networkingv1.Ingress, hostname string, now time.Time) *IngressTLS {
+	for _, tls := range ing.Spec.TLS {
+		covered := len(tls.Hosts) == 0
+		for _, host := range tls.Hosts {
+			if host == hostname || (strings.HasPrefix(host, "*.") && matchIngressWildcard(host, hostname)) {
+				covered = true
+			}
+		}
+		if !covered {
+			continue
+		}
+
+		result := &IngressTLS{Secret: ing.Namespace + "/" + tls.SecretName}
+		if len(tls.SecretName) == 0 {
+			result.Error = "no secret is specified, the default certificate of the controller is served"
+			return result
+		}
+		secret, err := cli.CoreV1().Secrets(ing.Namespace).Get(ctx, tls.SecretName, metav1.GetOptions{})
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to get the secret, the default certificate of the controller is served: %v", err)
+			return result
+		}
+		block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+		if block == nil {
+			result.Error = "no PEM encoded certificate found in " + corev1.TLSCertKey
+			return result
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to parse certificate: %v", err)
+			return result
+		}
+		info := certificateInfo(cert, now)
+		result.Subject, result.NotAfter, result.DaysLeft = info.Subject, info.NotAfter, info.DaysLeft
+		result.CoversHost = cert.VerifyHostname(hostname) == nil
+		if info.Expired {
+			result.Error = "the certificate is expired"
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/cron"
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	defaultJobWaitTimeout = 5 * time.Minute
+	jobLogTailLines       = 200
+	maxScheduleCount      = 100
+)
+
+// JobResult is the status of the job created by run_job and trigger_cronjob, the logs are filled only if the
+// tool waits for the job to finish.
+type JobResult struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Active    int32  `json:"active"`
+	Succeeded int32  `json:"succeeded"`
+	Failed    int32  `json:"failed"`
+	Message   string `json:"message,omitempty"`
+	Logs      string `json:"logs,omitempty"`
+}
+
+func (s *Server) RunJob() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		image, err := req.RequireString("image")
+		if err != nil {
+			return nil, err
+		}
+		command := req.GetStringSlice("command", nil)
+		backoffLimit := req.GetInt("backoffLimit", 0)
+		ttl := req.GetInt("ttlSecondsAfterFinished", 3600)
+
+		slog.Info("Running job", "name", name, "namespace", namespace, "image", image, "command", command)
+
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "koffee",
+				},
+			},
+			Spec: batchv1.JobSpec{
+				BackoffLimit:            ptr.To(int32(backoffLimit)),
+				TTLSecondsAfterFinished: ptr.To(int32(ttl)),
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:    name,
+								Image:   image,
+								Command: command,
+							},
+						},
+						RestartPolicy: corev1.RestartPolicyNever,
+					},
+				},
+			},
+		}
+		return s.createJob(ctx, req, job)
+	}
+}
+
+func (s *Server) TriggerCronJob() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cronJobName, err := req.RequireString("cronJob")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		name := req.GetString("name", "")
+
+		slog.Info("Triggering cronjob", "cronJob", cronJobName, "namespace", namespace, "name", name)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		cronJob, err := cli.BatchV1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cronjob: %w", err)
+		}
+		if len(name) == 0 {
+			name = manualJobName(cronJob.Name, time.Now())
+		}
+
+		// the job is built like `kubectl create job --from=cronjob/<name>`.
+		annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+		for k, v := range cronJob.Spec.JobTemplate.Annotations {
+			annotations[k] = v
+		}
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      cronJob.Spec.JobTemplate.Labels,
+				Annotations: annotations,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+				},
+			},
+			Spec: cronJob.Spec.JobTemplate.Spec,
+		}
+		return s.createJob(ctx, req, job)
+	}
+}
+
+// SuspendResult is the state of the cronjob or job after suspend_job.
+type SuspendResult struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Suspended bool   `json:"suspended"`
+	Changed   bool   `json:"changed"`
+}
+
+// CronSchedule is the upcoming schedule times of the cron expression.
+type CronSchedule struct {
+	Schedule  string   `json:"schedule"`
+	TimeZone  string   `json:"timeZone"`
+	Suspended bool     `json:"suspended,omitempty"`
+	Times     []string `json:"times"`
+}
+
+func (s *Server) SuspendJob() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		kind, name, err := definition.ParseKindName(req.GetString("kind", "CronJob"), resourceName)
+		if err != nil {
+			return nil, err
+		}
+		suspend := req.GetBool("suspend", true)
+
+		slog.Info("Suspending job", "kind", kind, "name", name, "namespace", namespace, "suspend", suspend)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		result := SuspendResult{Kind: kind, Namespace: namespace, Name: name, Suspended: suspend}
+		patch := []byte(fmt.Sprintf(`{"spec":{"suspend":%t}}`, suspend))
+		switch kind {
+		case "CronJob":
+			cronJob, err := cli.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get cronjob: %w", err)
+			}
+			result.Changed = ptr.Deref(cronJob.Spec.Suspend, false) != suspend
+			if result.Changed {
+				if _, err = cli.BatchV1().CronJobs(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+					return nil, fmt.Errorf("failed to patch cronjob: %w", err)
+				}
+			}
+		case "Job":
+			job, err := cli.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get job: %w", err)
+			}
+			if status, _ := jobStatus(job); status != "Running" {
+				return nil, fmt.Errorf("job %s/%s is already %s", namespace, name, status)
+			}
+			result.Changed = ptr.Deref(job.Spec.Suspend, false) != suspend
+			if result.Changed {
+				if _, err = cli.BatchV1().Jobs(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+					return nil, fmt.Errorf("failed to patch job: %w", err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported kind %q, must be CronJob or Job", kind)
+		}
+
+		resp, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) ListCronSchedule() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cronJobName := req.GetString("cronJob", "")
+		namespace := req.GetString("namespace", "")
+		result := CronSchedule{
+			Schedule: req.GetString("schedule", ""),
+			TimeZone: req.GetString("timeZone", ""),
+		}
+		count := req.GetInt("count", 5)
+		if count <= 0 || count > maxScheduleCount {
+			return nil, fmt.Errorf("invalid count %d, must be between 1 and %d", count, maxScheduleCount)
+		}
+
+		slog.Info("Listing cron schedule", "cronJob", cronJobName, "namespace", namespace, "schedule", result.Schedule, "timeZone", result.TimeZone)
+
+		// the schedule and time zone of the cronjob are used unless they are specified explicitly.
+		if len(cronJobName) > 0 {
+			if len(namespace) == 0 {
+				return nil, errors.New("namespace is required with cronJob")
+			}
+			cli, err := s.builder(ctx).GetClient()
+			if err != nil {
+				return nil, err
+			}
+			cronJob, err := cli.BatchV1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get cronjob: %w", err)
+			}
+			if len(result.Schedule) == 0 {
+				result.Schedule = cronJob.Spec.Schedule
+			}
+			if len(result.TimeZone) == 0 {
+				result.TimeZone = ptr.Deref(cronJob.Spec.TimeZone, "")
+			}
+			result.Suspended = ptr.Deref(cronJob.Spec.Suspend, false)
+		}
+		if len(result.Schedule) == 0 {
+			return nil, errors.New("either schedule or cronJob is required")
+		}
+
+		location := time.UTC
+		if len(result.TimeZone) > 0 {
+			loc, err := time.LoadLocation(result.TimeZone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time zone %q: %w", result.TimeZone, err)
+			}
+			location = loc
+		}
+		schedule, err := cron.Parse(result.Schedule, location)
+		if err != nil {
+			return nil, err
+		}
+		result.TimeZone = schedule.Location().String()
+
+		next := time.Now()
+		for range count {
+			if next = schedule.Next(next); next.IsZero() {
+				break
+			}
+			result.Times = append(result.Times, next.Format(time.RFC3339))
+		}
+
+		resp, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// createJob creates the job and waits for it to finish if the wait argument is set, the logs of the job pods are
+// returned once the job finished.
+func (s *Server) createJob(ctx context.Context, req mcp.CallToolRequest, job *batchv1.Job) (*mcp.CallToolResult, error) {
+	waitForCompletion := req.GetBool("wait", false)
+	waitTimeout := waitTimeoutOf(ctx, req, defaultJobWaitTimeout)
+
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		return nil, err
+	}
+	created, err := cli.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	slog.Info("Created job", "name", created.Name, "namespace", created.Namespace)
+
+	if waitForCompletion {
+		err = wait.PollUntilContextTimeout(ctx, 2*time.Second, waitTimeout, true, func(ctx context.Context) (bool, error) {
+			j, err := cli.BatchV1().Jobs(created.Namespace).Get(ctx, created.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			created = j
+			status, _ := jobStatus(j)
+			return status != "Running", nil
+		})
+		if err != nil && !wait.Interrupted(err) {
+			return nil, fmt.Errorf("failed to wait for job: %w", err)
+		}
+	}
+
+	result := JobResult{
+		Namespace: created.Namespace,
+		Name:      created.Name,
+		Active:    created.Status.Active,
+		Succeeded: created.Status.Succeeded,
+		Failed:    created.Status.Failed,
+	}
+	result.Status, result.Message = jobStatus(created)
+	if waitForCompletion {
+		if result.Status == "Running" {
+			result.Message = fmt.Sprintf("job is still running after %s", waitTimeout)
+		}
+		result.Logs = s.jobLogs(ctx, cli, created)
+	}
+
+	resp, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+// jobLogs returns the tail of the logs of all the job pods, the errors are written inline because the logs are
+// best effort.
+func (s *Server) jobLogs(ctx context.Context, cli kubernetes.Interface, job *batchv1.Job) string {
+	pods, err := resolveWorkloadPods(ctx, cli, job.Namespace, "Job", job.Name)
+	if err != nil {
+		return fmt.Sprintf("failed to get logs: %v\n", err)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	for i := range pods {
+		logs, err := streamPodLogs(ctx, cli, job.Namespace, pods[i].Name, &corev1.PodLogOptions{
+			TailLines:  ptr.To(int64(min(jobLogTailLines, s.maxLogTailLines))),
+			LimitBytes: ptr.To(s.maxLogBytes),
+		})
+		if err != nil {
+			logs = fmt.Sprintf("failed to get logs: %v\n", err)
+		}
+		_, _ = fmt.Fprintf(buf, "==> pod/%s <==\n%s", pods[i].Name, logs)
+	}
+	return buf.String()
+}
+
+// jobStatus returns Complete or Failed with the message of the finished condition, otherwise Running.
+func jobStatus(job *batchv1.Job) (string, string) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete, batchv1.JobSuccessCriteriaMet:
+			return "Complete", c.Message
+		case batchv1.JobFailed, batchv1.JobFailureTarget:
+			return "Failed", c.Message
+		}
+	}
+	return "Running", ""
+}
+
+// manualJobName returns the name of the manually triggered job, the cronjob name is truncated so that the job name
+// is still a valid label value for the pods.
+func manualJobName(cronJob string, now time.Time) string {
+	suffix := fmt.Sprintf("-manual-%d", now.Unix())
+	if len(cronJob)+len(suffix) > validation.DNS1123LabelMaxLength {
+		cronJob = cronJob[:validation.DNS1123LabelMaxLength-len(suffix)]
+	}
+	return cronJob + suffix
+}
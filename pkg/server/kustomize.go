@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomizationRoot is the directory of the inline kustomization in the in-memory file system.
+const kustomizationRoot = "/kustomization"
+
+// remoteBaseRoot is the directory of the remote bases fetched into the in-memory file system.
+const remoteBaseRoot = "/remote"
+
+// maxRemoteBases is the maximum number of the remote bases fetched to build a kustomization.
+const maxRemoteBases = 8
+
+// kustomizationFiles are the names of the kustomization file in the order kustomize looks them up.
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomizationDirFields are the fields of the kustomization whose entries are the files or the directories, the
+// remote entries of which are fetched from the git repositories.
+var kustomizationDirFields = []string{"resources", "bases", "components", "generators", "transformers", "validators"}
+
+// kustomizationFileFields are the fields of the kustomization whose entries are loaded as the files, which
+// kustomize would download if they are URLs.
+var kustomizationFileFields = []string{"crds", "configurations", "patchesStrategicMerge"}
+
+func (s *Server) KustomizeBuild() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kustomization := req.GetString("kustomization", "")
+		target := req.GetString("target", "")
+		namespace := req.GetString("namespace", "")
+		action := req.GetString("action", "render")
+		files, _ := req.GetArguments()["files"].(map[string]any)
+
+		slog.Info("Building kustomization", "target", target, "files", len(files), "namespace", namespace, "action", action)
+
+		if (len(kustomization) == 0) == (len(target) == 0) {
+			return nil, fmt.Errorf("either kustomization or target must be specified")
+		}
+		switch action {
+		case "render", "preview", "apply":
+		default:
+			return nil, fmt.Errorf("unsupported action %q, must be one of render, preview or apply", action)
+		}
+
+		manifest, err := s.kustomizeBuild(ctx, kustomization, files, target)
+		if err != nil {
+			return nil, err
+		}
+		if action == "render" {
+			return mcp.NewToolResultText(manifest), nil
+		}
+
+		results, err := s.applyManifest(ctx, manifest, namespace, action == "preview", false, true)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := json.Marshal(results)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// kustomizeBuild renders the inline kustomization with the files, or the target which is the remote git URL of a
+// kustomization, e.g. https://github.com/org/repo//deploy?ref=v1.0. The kustomization is built in memory, so that
+// the files of the server are never read, and the remote bases are fetched by the git allowlist.
+func (s *Server) kustomizeBuild(ctx context.Context, kustomization string, files map[string]any, target string) (string, error) {
+	fSys := filesys.MakeFsInMemory()
+	resolver := s.newRemoteResolver(fSys)
+	if len(target) > 0 {
+		if !isRemotePath(target) {
+			return "", fmt.Errorf("target %q must be the remote git URL of the kustomization, the local directories are not supported", target)
+		}
+		dir, err := resolver.fetch(ctx, target)
+		if err != nil {
+			return "", err
+		}
+		return resolver.build(ctx, dir)
+	}
+
+	if err := fSys.WriteFile(path.Join(kustomizationRoot, "kustomization.yaml"), []byte(kustomization)); err != nil {
+		return "", err
+	}
+	for name, content := range files {
+		data, ok := content.(string)
+		if !ok {
+			return "", fmt.Errorf("the content of file %q must be a string", name)
+		}
+		// the files are kept in the kustomization root, the relative paths out of it are rejected.
+		file := path.Join(kustomizationRoot, path.Clean("/"+name))
+		if !strings.HasPrefix(file, kustomizationRoot+"/") {
+			return "", fmt.Errorf("invalid file name %q", name)
+		}
+		if err := fSys.MkdirAll(path.Dir(file)); err != nil {
+			return "", err
+		}
+		if err := fSys.WriteFile(file, []byte(data)); err != nil {
+			return "", err
+		}
+	}
+	return resolver.build(ctx, kustomizationRoot)
+}
+
+// remoteResolver replaces the remote bases of the kustomizations with the local copies of them.
+type remoteResolver struct {
+	s    *Server
+	fSys filesys.FileSystem
+	// fetched are the directories of the fetched repositories by the repository and the ref.
+	fetched map[string]string
+	visited map[string]bool
+}
+
+func (s *Server) newRemoteResolver(fSys filesys.FileSystem) *remoteResolver {
+	return &remoteResolver{s: s, fSys: fSys, fetched: make(map[string]string), visited: make(map[string]bool)}
+}
+
+// build renders the kustomization of the directory in the in-memory file system. The remote bases are fetched
+// from the allowed git repositories into the file system first, and the other remote references are rejected,
+// since kustomize would fetch them from any host otherwise.
+func (r *remoteResolver) build(ctx context.Context, dir string) (string, error) {
+	if err := r.resolve(ctx, dir); err != nil {
+		return "", err
+	}
+
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(r.fSys, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to build kustomization: %w", err)
+	}
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return "", fmt.Errorf("failed to render kustomization: %w", err)
+	}
+	return string(out), nil
+}
+
+// resolve rewrites the remote bases of the kustomization in the directory and the local bases recursively.
+func (r *remoteResolver) resolve(ctx context.Context, dir string) error {
+	if r.visited[dir] {
+		return nil
+	}
+	r.visited[dir] = true
+
+	var file string
+	for _, name := range kustomizationFiles {
+		if r.fSys.Exists(path.Join(dir, name)) {
+			file = path.Join(dir, name)
+			break
+		}
+	}
+	if len(file) == 0 {
+		return nil
+	}
+	data, err := r.fSys.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var kustomization map[string]any
+	if err = yaml.Unmarshal(data, &kustomization); err != nil {
+		return fmt.Errorf("invalid kustomization %s: %w", file, err)
+	}
+
+	for _, entry := range kustomizationFilePaths(kustomization) {
+		if isRemotePath(entry) && !r.fSys.Exists(path.Join(dir, entry)) {
+			return fmt.Errorf("remote file %q of kustomization %s is not supported, only the remote bases of the git repositories are fetched", entry, file)
+		}
+	}
+
+	var rewritten bool
+	for _, field := range kustomizationDirFields {
+		entries, _ := kustomization[field].([]any)
+		for i, entry := range entries {
+			// the generators and the transformers may be inline objects.
+			name, ok := entry.(string)
+			if !ok {
+				continue
+			}
+			local := path.Join(dir, name)
+			if !r.fSys.Exists(local) && isRemotePath(name) {
+				if local, err = r.fetch(ctx, name); err != nil {
+					return err
+				}
+				if entries[i], err = filepath.Rel(dir, local); err != nil {
+					return err
+				}
+				rewritten = true
+			}
+			if r.fSys.IsDir(local) {
+				if err = r.resolve(ctx, local); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !rewritten {
+		return nil
+	}
+	if data, err = yaml.Marshal(kustomization); err != nil {
+		return err
+	}
+	return r.fSys.WriteFile(file, data)
+}
+
+// fetch fetches the remote base into the file system and returns the directory of it.
+func (r *remoteResolver) fetch(ctx context.Context, base string) (string, error) {
+	repo, dir, ref, err := parseRemoteBase(base)
+	if err != nil {
+		return "", err
+	}
+	key := repo + "@" + ref
+	root, ok := r.fetched[key]
+	if !ok {
+		if len(r.fetched) >= maxRemoteBases {
+			return "", fmt.Errorf("the kustomization references more than %d remote repositories", maxRemoteBases)
+		}
+		workdir, err := r.s.fetchGitSource(ctx, repo, ref)
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(workdir)
+
+		root = path.Join(remoteBaseRoot, strconv.Itoa(len(r.fetched)))
+		if err = copyToFs(r.fSys, workdir, root); err != nil {
+			return "", err
+		}
+		r.fetched[key] = root
+	}
+
+	local := path.Join(root, path.Clean("/"+dir))
+	if !r.fSys.Exists(local) {
+		return "", fmt.Errorf("failed to find path %q in the repository %s", dir, repo)
+	}
+	return local, nil
+}
+
+// kustomizationFilePaths returns the paths of the files loaded by the kustomization.
+func kustomizationFilePaths(kustomization map[string]any) []string {
+	var paths []string
+	strs := func(value any) {
+		values, _ := value.([]any)
+		for _, v := range values {
+			if p, ok := v.(string); ok {
+				paths = append(paths, p)
+			}
+		}
+	}
+	for _, field := range kustomizationFileFields {
+		strs(kustomization[field])
+	}
+	for _, field := range []string{"patches", "patchesJson6902", "replacements"} {
+		items, _ := kustomization[field].([]any)
+		for _, item := range items {
+			if p, ok := item.(map[string]any)["path"].(string); ok {
+				paths = append(paths, p)
+			}
+		}
+	}
+	for _, field := range []string{"configMapGenerator", "secretGenerator"} {
+		items, _ := kustomization[field].([]any)
+		for _, item := range items {
+			generator, _ := item.(map[string]any)
+			files, _ := generator["files"].([]any)
+			for _, file := range files {
+				// the file may be named by the key, e.g. config.yaml=path/to/file.
+				if p, ok := file.(string); ok {
+					if _, source, found := strings.Cut(p, "="); found {
+						p = source
+					}
+					paths = append(paths, p)
+				}
+			}
+			strs(generator["envs"])
+			if p, ok := generator["env"].(string); ok {
+				paths = append(paths, p)
+			}
+		}
+	}
+	if openapi, ok := kustomization["openapi"].(map[string]any); ok {
+		if p, ok := openapi["path"].(string); ok {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// isRemotePath returns whether the path of the kustomization is a URL, e.g. https://github.com/org/repo//deploy,
+// git@github.com:org/repo or github.com/org/repo.
+func isRemotePath(p string) bool {
+	if strings.Contains(p, "://") || strings.HasPrefix(p, "git@") || strings.HasPrefix(p, "git::") {
+		return true
+	}
+	host, _, found := strings.Cut(p, "/")
+	return found && strings.Contains(host, ".") && host != "." && host != ".."
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestKustomizeBuild(t *testing.T) {
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  a: \"1\"\n"
+	tests := []struct {
+		name          string
+		kustomization string
+		files         map[string]any
+		target        string
+		want          string
+		wantError     string
+	}{
+		{
+			name:          "inline kustomization",
+			kustomization: "namespace: shop\nresources:\n- base/configmap.yaml\n",
+			files:         map[string]any{"base/configmap.yaml": configMap},
+			want:          "namespace: shop",
+		},
+		{
+			name:          "file of the server",
+			kustomization: "resources:\n- /etc/hostname\n",
+			wantError:     "failed to build kustomization",
+		},
+		{
+			name:          "file out of the kustomization",
+			kustomization: "resources:\n- ../configmap.yaml\n",
+			files:         map[string]any{"../configmap.yaml": configMap},
+			wantError:     "failed to build kustomization",
+		},
+		{
+			name:          "remote base of the host not allowed",
+			kustomization: "resources:\n- https://example.com/org/repo//deploy\n",
+			wantError:     `git host "example.com" is not allowed`,
+		},
+		{
+			name:      "local target",
+			target:    "/etc",
+			wantError: "the local directories are not supported",
+		},
+		{
+			name:      "remote target of the host not allowed",
+			target:    "https://example.com/org/repo//deploy?ref=v1.0",
+			wantError: `git host "example.com" is not allowed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{gitAllowedHosts: DefaultGitAllowedHosts, gitMaxFetchBytes: 1 << 20}
+			manifest, err := s.kustomizeBuild(context.Background(), tt.kustomization, tt.files, tt.target)
+			if len(tt.wantError) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("got error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(manifest, tt.want) {
+				t.Fatalf("got manifest %q, want %q in it", manifest, tt.want)
+			}
+		})
+	}
+}
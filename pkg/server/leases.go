@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/client"
+)
+
+const (
+	// sessionLeasePrefix is the name prefix of the session leases, followed by the hash of the session id so that
+	// the id itself, which authorizes the messages of the session, isn't exposed.
+	sessionLeasePrefix = "koffee-session-"
+	// sessionLeaseAddressAnnotation is the address of the replica holding the session, the messages of the session
+	// received by the other replicas are forwarded to it.
+	sessionLeaseAddressAnnotation = "koffee.cola.io/address"
+	// sessionLeaseLabel marks the session leases, the ones expired are collected by any replica.
+	sessionLeaseLabel = "koffee.cola.io/session-lease"
+	// replicaHeader is the response header of the replica serving the request, the hint of the session affinity
+	// for the load balancers and clients.
+	replicaHeader = "X-Koffee-Replica"
+	// forwardedHeader records the replica forwarding a message to the holder. It's never trusted, the forwarded
+	// messages are told apart by the listener of the advertised address receiving them, and the header is stripped
+	// from the requests of the public listener.
+	forwardedHeader = "X-Koffee-Forwarded-By"
+)
+
+// sessionLeases coordinates the replicas of koffee behind a load balancer in the sse mode. The replica serving the
+// event stream of a session holds the Lease of the session, since its state, e.g. the selected kube context, the
+// session defaults and the command outputs, lives in the memory of the replica. The messages of the session posted
+// to the other replicas are forwarded to the holder by the address of the lease.
+//
+// The advertised address is served by a separate listener, the requests it receives are served by the replica itself
+// rather than forwarded again, so it must be reachable only inside the cluster, e.g. the pod IP and a port never
+// exposed by the load balancer. The replicas need the Role of get, list, create, update and delete on the leases of
+// the coordination.k8s.io group in the namespace.
+type sessionLeases struct {
+	cb        client.ClientBuilder
+	namespace string
+	identity  string
+	address   string
+	duration  time.Duration
+
+	mu sync.Mutex
+	// held are the names of the leases of the sessions served by the replica, keyed by the session ids.
+	held map[string]string
+}
+
+func newSessionLeases(cb client.ClientBuilder, namespace, identity, address string, duration time.Duration) *sessionLeases {
+	return &sessionLeases{
+		cb:        cb,
+		namespace: namespace,
+		identity:  identity,
+		address:   address,
+		duration:  duration,
+		held:      make(map[string]string),
+	}
+}
+
+// sessionLeaseName returns the name of the lease of the session.
+func sessionLeaseName(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return sessionLeasePrefix + hex.EncodeToString(sum[:])[:20]
+}
+
+func (l *sessionLeases) holds(sessionID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.held[sessionID]
+	return ok
+}
+
+// acquire takes the lease of the session registered by the replica, the lease of an expired holder is taken over.
+func (l *sessionLeases) acquire(ctx context.Context, sessionID string) {
+	cli, err := l.cb.GetClient()
+	if err != nil {
+		slog.Warn("Failed to acquire the session lease", "err", err)
+		return
+	}
+	name := sessionLeaseName(sessionID)
+	now := metav1.NowMicro()
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   l.namespace,
+			Labels:      map[string]string{sessionLeaseLabel: "true"},
+			Annotations: map[string]string{sessionLeaseAddressAnnotation: l.address},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(l.identity),
+			LeaseDurationSeconds: ptr.To(int32(l.duration.Seconds())),
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+	_, err = cli.CoordinationV1().Leases(l.namespace).Create(ctx, lease, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		var existing *coordinationv1.Lease
+		existing, err = cli.CoordinationV1().Leases(l.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			if holder := ptr.Deref(existing.Spec.HolderIdentity, ""); holder != l.identity && !l.expired(existing, now.Time) {
+				slog.Warn("The session lease is held by another replica", "lease", name, "holder", holder)
+				return
+			}
+			lease.ResourceVersion = existing.ResourceVersion
+			_, err = cli.CoordinationV1().Leases(l.namespace).Update(ctx, lease, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to acquire the session lease", "lease", name, "err", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.held[sessionID] = name
+}
+
+// release deletes the lease of the session unregistered by the replica.
+func (l *sessionLeases) release(ctx context.Context, sessionID string) {
+	l.mu.Lock()
+	name, ok := l.held[sessionID]
+	delete(l.held, sessionID)
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+	cli, err := l.cb.GetClient()
+	if err == nil {
+		err = cli.CoordinationV1().Leases(l.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		slog.Warn("Failed to release the session lease", "lease", name, "err", err)
+	}
+}
+
+// run renews the leases of the sessions held by the replica and collects the expired leases of the replicas gone
+// until the context is done.
+func (l *sessionLeases) run(ctx context.Context) {
+	ticker := time.NewTicker(l.duration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cli, err := l.cb.GetClient()
+		if err != nil {
+			slog.Warn("Failed to renew the session leases", "err", err)
+			continue
+		}
+		l.renew(ctx, cli)
+		l.collect(ctx, cli)
+	}
+}
+
+func (l *sessionLeases) renew(ctx context.Context, cli kubernetes.Interface) {
+	l.mu.Lock()
+	held := make(map[string]string, len(l.held))
+	for sessionID, name := range l.held {
+		held[sessionID] = name
+	}
+	l.mu.Unlock()
+
+	for sessionID, name := range held {
+		lease, err := cli.CoordinationV1().Leases(l.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil && ptr.Deref(lease.Spec.HolderIdentity, "") != l.identity {
+			slog.Warn("The session lease is taken over by another replica", "lease", name, "holder", ptr.Deref(lease.Spec.HolderIdentity, ""))
+			l.mu.Lock()
+			delete(l.held, sessionID)
+			l.mu.Unlock()
+			continue
+		}
+		if err == nil {
+			lease.Spec.RenewTime = ptr.To(metav1.NowMicro())
+			_, err = cli.CoordinationV1().Leases(l.namespace).Update(ctx, lease, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			slog.Warn("Failed to renew the session lease", "lease", name, "err", err)
+		}
+	}
+}
+
+// collect deletes the session leases expired for a whole duration, the replicas holding them are gone without
+// releasing them.
+func (l *sessionLeases) collect(ctx context.Context, cli kubernetes.Interface) {
+	leases, err := cli.CoordinationV1().Leases(l.namespace).List(ctx, metav1.ListOptions{LabelSelector: sessionLeaseLabel + "=true"})
+	if err != nil {
+		slog.Warn("Failed to list the session leases", "err", err)
+		return
+	}
+	now := time.Now()
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if !l.expired(lease, now.Add(-l.duration)) {
+			continue
+		}
+		err = cli.CoordinationV1().Leases(l.namespace).Delete(ctx, lease.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: ptr.To(lease.ResourceVersion)},
+		})
+		if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			slog.Warn("Failed to delete the expired session lease", "lease", lease.Name, "err", err)
+		}
+	}
+}
+
+// expired reports whether the lease is not renewed within its duration before the time.
+func (l *sessionLeases) expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil {
+		return true
+	}
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, int32(l.duration.Seconds()))) * time.Second
+	return lease.Spec.RenewTime.Add(duration).Before(now)
+}
+
+// holder returns the address of the other replica holding the lease of the session, empty if the session is not
+// held by another live replica.
+func (l *sessionLeases) holder(ctx context.Context, sessionID string) string {
+	cli, err := l.cb.GetClient()
+	if err != nil {
+		return ""
+	}
+	lease, err := cli.CoordinationV1().Leases(l.namespace).Get(ctx, sessionLeaseName(sessionID), metav1.GetOptions{})
+	if err != nil || ptr.Deref(lease.Spec.HolderIdentity, "") == l.identity || l.expired(lease, time.Now()) {
+		return ""
+	}
+	return lease.Annotations[sessionLeaseAddressAnnotation]
+}
+
+// handler sets the replica header of the responses of the public listener, and forwards the messages of the sessions
+// held by the other replicas to them, so are the event streams resuming the sessions. The requests of the unknown
+// sessions are handled by the next handler.
+func (l *sessionLeases) handler(next http.Handler, ssePath, messagePath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(replicaHeader, l.identity)
+		// the header is set by the clients freely, e.g. along with a spoofed Host, it never skips the forwarding.
+		r.Header.Del(forwardedHeader)
+		var sessionID string
+		switch r.URL.Path {
+		case messagePath:
+			sessionID = r.URL.Query().Get("sessionId")
+		case ssePath:
+			sessionID, _ = resumedSessionID(r)
+		}
+		if len(sessionID) == 0 || l.holds(sessionID) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		address := l.holder(r.Context(), sessionID)
+		if len(address) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		slog.Debug("Forwarding the request of the session to the holder", "lease", sessionLeaseName(sessionID), "address", address, "path", r.URL.Path)
+		proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: address})
+		r.Header.Set(forwardedHeader, l.identity)
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// forwardedHandler sets the replica header of the responses of the listener of the advertised address, the requests
+// it receives are forwarded by the other replicas and handled by the next handler, never forwarded again.
+func (l *sessionLeases) forwardedHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(replicaHeader, l.identity)
+		slog.Debug("Serving the request forwarded by the replica", "replica", r.Header.Get(forwardedHeader), "path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
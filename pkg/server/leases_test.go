@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+func TestSessionLeasesHandler(t *testing.T) {
+	var forwardedBy string
+	holder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedBy = r.Header.Get(forwardedHeader)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer holder.Close()
+	holderAddress := strings.TrimPrefix(holder.URL, "http://")
+
+	now := metav1.NowMicro()
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        sessionLeaseName("held"),
+			Namespace:   "koffee",
+			Annotations: map[string]string{sessionLeaseAddressAnnotation: holderAddress},
+		},
+		Spec: coordinationv1.LeaseSpec{HolderIdentity: ptr.To("replica-b"), LeaseDurationSeconds: ptr.To[int32](30), RenewTime: &now},
+	}
+
+	tests := []struct {
+		name    string
+		host    string
+		session string
+		// forwardListener is whether the request is received by the listener of the advertised address.
+		forwardListener bool
+		forwardedBy     string
+		wantForwarded   bool
+		wantHeader      string
+	}{
+		{name: "session held by another replica", host: "koffee.example.com", session: "held", wantForwarded: true},
+		{name: "forged header from outside", host: "koffee.example.com", session: "held", forwardedBy: "replica-b", wantForwarded: true},
+		{name: "spoofed host of the advertised address", host: "10.0.0.1:8889", session: "held", forwardedBy: "replica-b", wantForwarded: true},
+		{name: "unknown session", host: "koffee.example.com", session: "unknown"},
+		{name: "forged header of an unknown session", host: "koffee.example.com", session: "unknown", forwardedBy: "replica-b"},
+		{name: "received by the forward listener", host: "10.0.0.1:8889", session: "held", forwardListener: true, forwardedBy: "replica-b", wantHeader: "replica-b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwardedBy = ""
+			_, b := newFakeServer(nil, []runtime.Object{lease})
+			leases := newSessionLeases(b, "koffee", "replica-a", "10.0.0.1:8889", 30*time.Second)
+			var nextHeader string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextHeader = r.Header.Get(forwardedHeader)
+				w.WriteHeader(http.StatusOK)
+			})
+			handler := leases.handler(next, "/sse", "/message")
+			if tt.forwardListener {
+				handler = leases.forwardedHandler(next)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/message?sessionId="+tt.session, nil)
+			req.Host = tt.host
+			if len(tt.forwardedBy) > 0 {
+				req.Header.Set(forwardedHeader, tt.forwardedBy)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Code == http.StatusAccepted; got != tt.wantForwarded {
+				t.Fatalf("got forwarded %t, want %t", got, tt.wantForwarded)
+			}
+			if tt.wantForwarded {
+				if forwardedBy != "replica-a" {
+					t.Fatalf("got the request forwarded by %q, want by replica-a", forwardedBy)
+				}
+				return
+			}
+			if nextHeader != tt.wantHeader {
+				t.Fatalf("got header %q handled, want %q", nextHeader, tt.wantHeader)
+			}
+		})
+	}
+}
@@ -3,11 +3,15 @@ package server
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
+	"regexp"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/ptr"
 )
 
@@ -23,34 +27,238 @@ func (s *Server) GetPodLogs() func(ctx context.Context, req mcp.CallToolRequest)
 			return nil, err
 		}
 
+		kind, name, err := parseWorkloadReference(req.GetString("kind", ""), resourceName)
+		if err != nil {
+			return nil, err
+		}
+
 		// If containerName is empty, the default container will be used by Kubernetes
 		containerName := req.GetString("container", "")
 		tailLines := req.GetInt("tail", 50)
+		headLines := req.GetInt("headLines", 0)
+		limitBytes := int64(req.GetInt("limitBytes", 0))
+		allPods := req.GetBool("allPods", false)
+		allContainers := req.GetBool("allContainers", false)
+		previous := req.GetBool("previous", false)
+		timestamps := req.GetBool("timestamps", false)
+		pattern := req.GetString("pattern", "")
+		before := req.GetInt("before", 0)
+		after := req.GetInt("after", 0)
 
-		slog.Info("Loading arguments", "resourceName", resourceName, "namespace", namespace, "container", containerName, "tailLines", tailLines)
+		// the head, tail and bytes are capped by the server, -1 means all lines are returned within the bytes limit.
+		// The tail may be 0 with the head lines to only return the beginning of the logs.
+		if tailLines < -1 || (tailLines == 0 && headLines <= 0) {
+			return nil, fmt.Errorf("invalid tail %d, must be -1 or a positive number", tailLines)
+		}
+		if headLines < 0 {
+			return nil, fmt.Errorf("invalid headLines %d, must not be negative", headLines)
+		}
+		if headLines > 0 && tailLines == -1 {
+			return nil, fmt.Errorf("headLines can not be used with all lines of tail -1")
+		}
+		if tailLines > s.maxLogTailLines {
+			tailLines = s.maxLogTailLines
+		}
+		if headLines > s.maxLogTailLines {
+			headLines = s.maxLogTailLines
+		}
+		if limitBytes <= 0 || limitBytes > s.maxLogBytes {
+			limitBytes = s.maxLogBytes
+		}
+
+		var filter *regexp.Regexp
+		if len(pattern) > 0 {
+			if filter, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
 
-		cli, err := s.cb.GetClient()
+		slog.Info("Loading arguments", "kind", kind, "resourceName", name, "namespace", namespace, "container", containerName, "tailLines", tailLines, "headLines", headLines, "limitBytes", limitBytes,
+			"allPods", allPods, "allContainers", allContainers, "previous", previous, "timestamps", timestamps, "pattern", pattern)
+
+		cli, err := s.builder(ctx).GetClient()
 		if err != nil {
 			return nil, err
 		}
 
-		podLogs, err := cli.CoreV1().Pods(namespace).GetLogs(resourceName, &corev1.PodLogOptions{
-			TailLines: ptr.To(int64(tailLines)),
-			Container: containerName,
-		}).Stream(ctx)
+		pods, err := resolveWorkloadPods(ctx, cli, namespace, kind, name)
 		if err != nil {
 			return nil, err
 		}
-		defer func() {
-			if err := podLogs.Close(); err != nil {
-				slog.Error("Failed to close pod logs", "err", err)
+
+		if !allPods {
+			pods = pods[:1]
+		}
+
+		type logTarget struct {
+			pod       string
+			container string
+		}
+		targets := make([]logTarget, 0, len(pods))
+		for i := range pods {
+			if !allContainers {
+				targets = append(targets, logTarget{pod: pods[i].Name, container: containerName})
+				continue
+			}
+			for _, c := range pods[i].Spec.InitContainers {
+				targets = append(targets, logTarget{pod: pods[i].Name, container: c.Name})
+			}
+			for _, c := range pods[i].Spec.Containers {
+				targets = append(targets, logTarget{pod: pods[i].Name, container: c.Name})
+			}
+		}
+
+		newOptions := func(container string) *corev1.PodLogOptions {
+			options := &corev1.PodLogOptions{
+				Container:  container,
+				Previous:   previous,
+				Timestamps: timestamps,
+				LimitBytes: ptr.To(limitBytes),
+			}
+			if tailLines > 0 {
+				options.TailLines = ptr.To(int64(tailLines))
+			}
+			return options
+		}
+		readLogs := func(target logTarget) (string, error) {
+			if headLines > 0 {
+				options := newOptions(target.container)
+				options.TailLines = nil
+				return headTailPodLogs(ctx, cli, namespace, target.pod, options, headLines, tailLines)
+			}
+			return streamPodLogs(ctx, cli, namespace, target.pod, newOptions(target.container))
+		}
+		if len(targets) == 1 {
+			logs, err := readLogs(targets[0])
+			if err != nil {
+				return nil, err
+			}
+			if filter != nil {
+				logs = grepLines(logs, filter, before, after)
 			}
-		}()
+			return mcp.NewToolResultText(logs), nil
+		}
 
 		buf := bytes.NewBuffer(make([]byte, 0))
-		if _, err = io.Copy(buf, podLogs); err != nil {
-			return nil, err
+		for _, target := range targets {
+			logs, err := readLogs(target)
+			if err != nil {
+				logs = fmt.Sprintf("failed to get logs: %v\n", err)
+			} else if filter != nil {
+				logs = grepLines(logs, filter, before, after)
+			}
+			header := "pod/" + target.pod
+			if len(target.container) > 0 {
+				header += "/" + target.container
+			}
+			_, _ = fmt.Fprintf(buf, "==> %s <==\n%s", header, logs)
 		}
 		return mcp.NewToolResultText(buf.String()), nil
 	}
 }
+
+// streamPodLogs reads the logs of the pod with the options.
+func streamPodLogs(ctx context.Context, cli kubernetes.Interface, namespace, name string, options *corev1.PodLogOptions) (string, error) {
+	podLogs, err := cli.CoreV1().Pods(namespace).GetLogs(name, options).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := podLogs.Close(); err != nil {
+			slog.Error("Failed to close pod logs", "err", err)
+		}
+	}()
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	if _, err = io.Copy(buf, podLogs); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// headTailPodLogs returns the first head lines and the last tail lines of the logs with a gap marker between them,
+// so that the startup errors are not hidden by the crash loop spam at the end of a large log. The logs are read
+// from the beginning within the bytes limit, and the tail is read separately only if the logs exceed it.
+func headTailPodLogs(ctx context.Context, cli kubernetes.Interface, namespace, name string, options *corev1.PodLogOptions, headLines, tailLines int) (string, error) {
+	logs, err := streamPodLogs(ctx, cli, namespace, name, options)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	if len(logs) == 0 {
+		lines = nil
+	}
+
+	// the whole logs are read if they are within the bytes limit, so the omitted lines are counted exactly.
+	if options.LimitBytes == nil || int64(len(logs)) < *options.LimitBytes {
+		if len(lines) <= headLines+tailLines {
+			return logs, nil
+		}
+		return joinLogWindows(lines[:headLines], lines[len(lines)-tailLines:], fmt.Sprintf("... [%d lines omitted] ...", len(lines)-headLines-tailLines)), nil
+	}
+
+	// the last line may be cut by the bytes limit.
+	if len(lines) > 0 && !strings.HasSuffix(logs, "\n") {
+		lines = lines[:len(lines)-1]
+	}
+	head := lines[:min(headLines, len(lines))]
+	var tail []string
+	if tailLines > 0 {
+		options = options.DeepCopy()
+		options.TailLines = ptr.To(int64(tailLines))
+		logs, err := streamPodLogs(ctx, cli, namespace, name, options)
+		if err != nil {
+			return "", err
+		}
+		if len(logs) > 0 {
+			tail = strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+		}
+	}
+	return joinLogWindows(head, tail, "... [the middle of the logs beyond the bytes limit is omitted] ..."), nil
+}
+
+// joinLogWindows joins the head and tail lines with the gap marker between them.
+func joinLogWindows(head, tail []string, marker string) string {
+	buf := bytes.NewBuffer(make([]byte, 0))
+	for _, line := range head {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	buf.WriteString(marker)
+	buf.WriteString("\n")
+	for _, line := range tail {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// grepLines returns the lines matching the pattern with the before and after context lines, the non-adjacent
+// groups of lines are separated by "--" like grep.
+func grepLines(logs string, pattern *regexp.Regexp, before, after int) string {
+	lines := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	selected := make([]bool, len(lines))
+	for i, line := range lines {
+		if !pattern.MatchString(line) {
+			continue
+		}
+		for j := max(0, i-before); j <= min(len(lines)-1, i+after); j++ {
+			selected[j] = true
+		}
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	last := -1
+	for i, line := range lines {
+		if !selected[i] {
+			continue
+		}
+		if last >= 0 && i > last+1 {
+			buf.WriteString("--\n")
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		last = i
+	}
+	return buf.String()
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// FieldOwnership is the fields of an object owned by the field managers, parsed from the managed fields.
+type FieldOwnership struct {
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace,omitempty"`
+	Name      string          `json:"name"`
+	Managers  []ManagedFields `json:"managers"`
+	// Shared are the fields owned by more than one manager, the server-side apply of any of them conflicts with
+	// the others unless forced.
+	Shared []SharedField `json:"shared"`
+}
+
+// ManagedFields is the fields owned by a field manager.
+type ManagedFields struct {
+	Manager     string   `json:"manager"`
+	Operation   string   `json:"operation"`
+	APIVersion  string   `json:"apiVersion,omitempty"`
+	Subresource string   `json:"subresource,omitempty"`
+	Time        string   `json:"time,omitempty"`
+	Fields      []string `json:"fields"`
+}
+
+// SharedField is a field owned by more than one manager.
+type SharedField struct {
+	Path     string   `json:"path"`
+	Managers []string `json:"managers"`
+}
+
+func (s *Server) FieldManagers() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		field := req.GetString("field", "")
+		manager := req.GetString("manager", "")
+
+		kind, resourceName, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
+		if err != nil {
+			return nil, err
+		}
+		if len(field) > 0 && !strings.HasPrefix(field, ".") {
+			field = "." + field
+		}
+
+		slog.Info("Getting field managers", "kind", kind, "name", resourceName, "namespace", namespace, "field", field, "manager", manager)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		gvr, namespaced, err := lookupKindResource(discoveryClient, kind)
+		if err != nil {
+			return nil, err
+		}
+		if namespaced && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+
+		var obj *unstructured.Unstructured
+		if len(namespace) > 0 {
+			obj, err = dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, resourceName, metav1.GetOptions{})
+		} else {
+			obj, err = dynamicClient.Resource(gvr).Get(ctx, resourceName, metav1.GetOptions{})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource info: %w", err)
+		}
+
+		resp, err := json.Marshal(fieldOwnership(obj, field, manager))
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// fieldOwnership returns the fields owned by the managers of the object, optionally only the fields overlapping
+// the field path and of the manager.
+func fieldOwnership(obj *unstructured.Unstructured, field, manager string) *FieldOwnership {
+	ownership := &FieldOwnership{
+		Kind:      obj.GetKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		Managers:  make([]ManagedFields, 0),
+		Shared:    make([]SharedField, 0),
+	}
+
+	owners := make(map[string][]string)
+	for _, entry := range obj.GetManagedFields() {
+		managed := ManagedFields{
+			Manager:     entry.Manager,
+			Operation:   string(entry.Operation),
+			APIVersion:  entry.APIVersion,
+			Subresource: entry.Subresource,
+			Fields:      make([]string, 0),
+		}
+		if entry.Time != nil {
+			managed.Time = entry.Time.UTC().Format(time.RFC3339)
+		}
+		if entry.FieldsV1 != nil {
+			for _, path := range managedFieldPaths(entry.FieldsV1.Raw) {
+				if len(field) > 0 && !overlapsFieldPath(path, field) {
+					continue
+				}
+				managed.Fields = append(managed.Fields, path)
+				owners[path] = append(owners[path], entry.Manager)
+			}
+		}
+		if (len(manager) > 0 && entry.Manager != manager) || (len(field) > 0 && len(managed.Fields) == 0) {
+			continue
+		}
+		ownership.Managers = append(ownership.Managers, managed)
+	}
+
+	for path, managers := range owners {
+		if len(managers) > 1 {
+			ownership.Shared = append(ownership.Shared, SharedField{Path: path, Managers: managers})
+		}
+	}
+	sort.Slice(ownership.Shared, func(i, j int) bool { return ownership.Shared[i].Path < ownership.Shared[j].Path })
+	return ownership
+}
+
+// managedFieldPaths returns the paths of the leaf fields in the FieldsV1 of the managed fields, the paths are in
+// the same format of diffFields, e.g. .spec.template.spec.containers[{"name":"nginx"}].image.
+func managedFieldPaths(raw []byte) []string {
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	paths := make([]string, 0)
+	var walk func(path string, fields map[string]any)
+	walk = func(path string, fields map[string]any) {
+		leaf := true
+		for key, value := range fields {
+			if key == "." {
+				continue
+			}
+			prefix, name, _ := strings.Cut(key, ":")
+			child := path
+			switch prefix {
+			case "f":
+				child = fieldPath(path, name)
+			case "k", "v":
+				child = path + "[" + name + "]"
+			case "i":
+				if _, err := strconv.Atoi(name); err != nil {
+					continue
+				}
+				child = path + "[" + name + "]"
+			default:
+				continue
+			}
+			leaf = false
+			if children, ok := value.(map[string]any); ok && len(children) > 0 {
+				walk(child, children)
+			} else {
+				paths = append(paths, child)
+			}
+		}
+		if leaf && len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	walk("", fields)
+	sort.Strings(paths)
+	return paths
+}
+
+// overlapsFieldPath returns whether a path is the same, the parent or the child of the other.
+func overlapsFieldPath(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return strings.HasPrefix(a, b) && (len(a) == len(b) || a[len(b)] == '.' || a[len(b)] == '[')
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// maxManifestBytes is the maximum size of the manifests, the larger ones are rejected by the API server anyway
+// since they exceed the size limit of the objects in etcd.
+const maxManifestBytes = 1 << 20
+
+// createIgnoredFields are the fields populated by the cluster which are stripped from the manifests to create or
+// apply, e.g. the manifests copied from the live objects, the API server rejects or ignores them.
+var createIgnoredFields = [][]string{
+	{"status"},
+	{"metadata", "uid"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "selfLink"},
+	{"metadata", "managedFields"},
+}
+
+// updateIgnoredFields are the fields stripped from the manifests to update, the uid and resourceVersion are kept
+// as the preconditions of the update, and the generation to merge the manifest into the latest object.
+var updateIgnoredFields = [][]string{
+	{"status"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "selfLink"},
+	{"metadata", "managedFields"},
+}
+
+// DocumentResult is the result of creating or updating an object of a multi-document manifest, the index is the
+// position of the document in the manifest starting from 0.
+type DocumentResult struct {
+	Index           int             `json:"index"`
+	Kind            string          `json:"kind"`
+	Namespace       string          `json:"namespace,omitempty"`
+	Name            string          `json:"name"`
+	ResourceVersion string          `json:"resourceVersion,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Conflict        *UpdateConflict `json:"conflict,omitempty"`
+}
+
+// decodeManifestObjects decodes the objects of the JSON or YAML manifest, which may be a stream of the YAML
+// documents, and sanitizes them. Nothing is sent to the API server if any object is invalid.
+func decodeManifestObjects(manifest string, ignoredFields [][]string) ([]*unstructured.Unstructured, error) {
+	objs, err := decodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no object found in the manifest")
+	}
+	for i, obj := range objs {
+		if err = sanitizeManifest(obj, ignoredFields); err != nil {
+			if len(objs) > 1 {
+				return nil, fmt.Errorf("invalid document %d of the manifest: %w", i, err)
+			}
+			return nil, err
+		}
+	}
+	return objs, nil
+}
+
+// documentMapping is the resource of an object of the manifest.
+type documentMapping struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// resolveDocumentResources resolves the resources of the objects by their apiVersion and kind, so that the objects
+// of the different kinds are created or updated by a manifest. The kind must agree with the kinds of the objects if
+// specified.
+func resolveDocumentResources(discoveryClient discovery.DiscoveryInterface, kind string, objs []*unstructured.Unstructured) ([]documentMapping, error) {
+	mappings := make([]documentMapping, 0, len(objs))
+	for i, obj := range objs {
+		document := "the manifest"
+		if len(objs) > 1 {
+			document = fmt.Sprintf("document %d of the manifest", i)
+		}
+		gvk := obj.GroupVersionKind()
+		if len(kind) > 0 && gvk.Kind != kind {
+			return nil, fmt.Errorf("the kind %s of %s disagrees with the kind %s, fix the manifest or omit the kind", gvk.Kind, document, kind)
+		}
+		gvr, namespaced, err := lookupGroupKindResource(discoveryClient, gvk.GroupKind())
+		if err != nil {
+			return nil, fmt.Errorf("invalid apiVersion %s and kind %s of %s: %w", obj.GetAPIVersion(), gvk.Kind, document, err)
+		}
+		// the resource is served in the version of the manifest rather than the preferred one.
+		gvr.Version = gvk.Version
+		mappings = append(mappings, documentMapping{gvr: gvr, namespaced: namespaced})
+	}
+	return mappings, nil
+}
+
+func newDocumentResult(index int, obj, result *unstructured.Unstructured, err error) DocumentResult {
+	documentResult := DocumentResult{Index: index, Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if err != nil {
+		documentResult.Error = err.Error()
+	} else if result != nil {
+		documentResult.Name, documentResult.ResourceVersion = result.GetName(), result.GetResourceVersion()
+	}
+	return documentResult
+}
+
+// newDocumentResults returns the results of the documents, the result is an error if any document failed so that
+// the failed ones are retried.
+func newDocumentResults(results []DocumentResult) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if len(result.Error) > 0 || result.Conflict != nil {
+			return mcp.NewToolResultError(string(resp)), nil
+		}
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+// checkManifestSize rejects the manifests exceeding the size limit before they are decoded.
+func checkManifestSize(manifest string) error {
+	if len(manifest) > maxManifestBytes {
+		return fmt.Errorf("the manifest of %s exceeds the limit of %s, split it into smaller manifests or drop the embedded data",
+			resource.NewQuantity(int64(len(manifest)), resource.BinarySI), resource.NewQuantity(maxManifestBytes, resource.BinarySI))
+	}
+	return nil
+}
+
+// sanitizeManifest validates the object of the manifest and strips the ignored fields from it, so that the common
+// mistakes of the hand-written manifests fail before reaching the API server with the messages to fix them.
+func sanitizeManifest(obj *unstructured.Unstructured, ignoredFields [][]string) error {
+	if err := validateManifest(obj); err != nil {
+		return err
+	}
+	var stripped []string
+	for _, field := range ignoredFields {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, field...); found {
+			unstructured.RemoveNestedField(obj.Object, field...)
+			stripped = append(stripped, "."+strings.Join(field, "."))
+		}
+	}
+	if len(stripped) > 0 {
+		slog.Info("Stripped the fields populated by the cluster from the manifest", "kind", obj.GetKind(), "name", obj.GetName(), "fields", stripped)
+	}
+	return nil
+}
+
+// validateManifest checks the object has the apiVersion, kind and name, and the metadata is well-formed.
+func validateManifest(obj *unstructured.Unstructured) error {
+	if len(obj.GetAPIVersion()) == 0 || len(obj.GetKind()) == 0 {
+		return fmt.Errorf("the manifest must have the apiVersion and kind, e.g. apiVersion: apps/v1 and kind: Deployment")
+	}
+	metadata, ok := obj.Object["metadata"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("the manifest of %s must have the metadata object with the name", obj.GetKind())
+	}
+	name, _ := metadata["name"].(string)
+	generateName, _ := metadata["generateName"].(string)
+	if len(name) == 0 && len(generateName) == 0 {
+		return fmt.Errorf("the manifest of %s must have the metadata.name", obj.GetKind())
+	}
+	if len(name) > 0 && name != strings.TrimSpace(name) {
+		return fmt.Errorf("the name %q of %s must not have the leading or trailing spaces", name, obj.GetKind())
+	}
+	for _, path := range [][]string{
+		{"metadata"},
+		{"spec", "template", "metadata"},
+		{"spec", "jobTemplate", "spec", "template", "metadata"},
+	} {
+		if err := validateStringMaps(obj, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStringMaps checks the values of the labels and annotations of the metadata are strings, the unquoted
+// YAML values like true or 8080 are decoded as the booleans and numbers.
+func validateStringMaps(obj *unstructured.Unstructured, path []string) error {
+	for _, field := range []string{"labels", "annotations"} {
+		values, found, _ := unstructured.NestedFieldNoCopy(obj.Object, append(slices.Clone(path), field)...)
+		if !found || values == nil {
+			continue
+		}
+		fieldPath := "." + strings.Join(append(slices.Clone(path), field), ".")
+		entries, ok := values.(map[string]any)
+		if !ok {
+			return fmt.Errorf("the %s of %s must be a map of strings", fieldPath, obj.GetKind())
+		}
+		for key, value := range entries {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("the value of %s[%q] of %s must be a string, quote the value %v in the manifest", fieldPath, key, obj.GetKind(), value)
+			}
+		}
+	}
+	return nil
+}
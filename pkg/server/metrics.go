@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// listMetricValues lists the values of the custom metric like pods/http_requests, or the external metric like
+// queue_length, the metrics APIs serve the values by the metric rather than as the listable resources.
+func (s *Server) listMetricValues(ctx context.Context, kind, metric, namespace, labelSelector, expr string, generateOptions definition.GenerateOptions, output string) (*mcp.CallToolResult, error) {
+	if len(metric) == 0 {
+		return nil, fmt.Errorf("metric is required to list %s, e.g. pods/http_requests for the custom metrics or queue_length for the external metrics", kind)
+	}
+	discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	list, group := definition.NewMetricValueList(kind)
+	path, err := metricValuesPath(discoveryClient, group, kind, metric, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	request := discoveryClient.RESTClient().Get().AbsPath(path)
+	if len(labelSelector) > 0 {
+		request = request.Param("labelSelector", labelSelector)
+	}
+	body, err := request.DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s %s: %w", kind, metric, err)
+	}
+
+	if len(expr) > 0 {
+		items := &unstructured.UnstructuredList{}
+		if err = items.UnmarshalJSON(body); err != nil {
+			return nil, err
+		}
+		return projectedList(expr, items)
+	}
+	if err = json.Unmarshal(body, list); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", kind, err)
+	}
+	table, err := s.generator.GenerateTable(list, generateOptions)
+	if err != nil {
+		return nil, err
+	}
+	return newTableResult(table, output)
+}
+
+// metricValuesPath returns the path of the metric values in the preferred version of the metrics API. The custom
+// metrics of the namespaced objects are served at namespaces/<ns>/<resource>/*/<metric>, the ones of the namespace
+// itself at namespaces/<ns>/metrics/<metric>, and the external metrics at namespaces/<ns>/<metric>.
+func metricValuesPath(discoveryClient discovery.DiscoveryInterface, group, kind, metric, namespace string) (string, error) {
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return "", fmt.Errorf("failed to list api groups: %w", err)
+	}
+	var groupVersion string
+	for _, g := range groups.Groups {
+		if g.Name == group {
+			groupVersion = g.PreferredVersion.GroupVersion
+		}
+	}
+	if len(groupVersion) == 0 {
+		return "", fmt.Errorf("the %s API is not served by the cluster, install an adapter like prometheus-adapter or KEDA", group)
+	}
+
+	if kind == definition.ExternalMetricValueKind {
+		if len(namespace) == 0 {
+			namespace = metav1.NamespaceDefault
+		}
+		return fmt.Sprintf("/apis/%s/namespaces/%s/%s", groupVersion, namespace, metric), nil
+	}
+
+	resource, name, found := strings.Cut(metric, "/")
+	if !found || len(resource) == 0 || len(name) == 0 {
+		return "", fmt.Errorf("invalid custom metric %q, must be in the form of resource/metric like pods/http_requests", metric)
+	}
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the resources of %s: %w", groupVersion, err)
+	}
+	for _, r := range resources.APIResources {
+		if r.Name != metric {
+			continue
+		}
+		switch {
+		case resource == "namespaces":
+			if len(namespace) == 0 {
+				return "", fmt.Errorf("namespace is required to get the metric %s of the namespace", name)
+			}
+			return fmt.Sprintf("/apis/%s/namespaces/%s/metrics/%s", groupVersion, namespace, name), nil
+		case r.Namespaced:
+			if len(namespace) == 0 {
+				return "", fmt.Errorf("namespace is required to get the metric %s of the %s", name, resource)
+			}
+			return fmt.Sprintf("/apis/%s/namespaces/%s/%s/*/%s", groupVersion, namespace, resource, name), nil
+		default:
+			return fmt.Sprintf("/apis/%s/%s/*/%s", groupVersion, resource, name), nil
+		}
+	}
+	return "", fmt.Errorf("not found custom metric %q in %s", metric, groupVersion)
+}
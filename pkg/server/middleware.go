@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/time/rate"
+
+	"cola.io/koffee/pkg/client"
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+// sessionLimiterIdleTimeout is the idle time after which the rate limiter of a session is released.
+const sessionLimiterIdleTimeout = 10 * time.Minute
+
+// newBusyResult returns the busy error when the tool call is rejected by the limits, the client is expected
+// to retry after the suggested duration.
+func newBusyResult(message string, retryAfter time.Duration) *mcp.CallToolResult {
+	return newToolErrorResult(ToolError{Error: "busy", Message: message, Retryable: true}, retryAfter)
+}
+
+type sessionLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// limiter limits the concurrent tool executions of the server and the rate of the tool calls per session.
+type limiter struct {
+	concurrency chan struct{}
+	limit       rate.Limit
+	burst       int
+
+	mu       sync.Mutex
+	sessions map[string]*sessionLimiter
+}
+
+func newLimiter(maxConcurrent int, limit float64, burst int) *limiter {
+	l := &limiter{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		sessions: make(map[string]*sessionLimiter),
+	}
+	if maxConcurrent > 0 {
+		l.concurrency = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// sessionLimiter returns the rate limiter of the session, the limiters of the idle sessions are released.
+func (l *limiter) sessionLimiter(sessionID string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for id, sl := range l.sessions {
+		if now.Sub(sl.lastSeen) > sessionLimiterIdleTimeout {
+			delete(l.sessions, id)
+		}
+	}
+
+	sl, ok := l.sessions[sessionID]
+	if !ok {
+		sl = &sessionLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.sessions[sessionID] = sl
+	}
+	sl.lastSeen = now
+	return sl.limiter
+}
+
+// middleware rejects the tool call with a busy error if the session exceeds the rate limit or the server is
+// running the maximum concurrent tool calls.
+func (l *limiter) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if l.limit > 0 {
+			id := sessionID(ctx)
+			reservation := l.sessionLimiter(id).Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				slog.Warn("Rejecting the tool call exceeding the rate limit", "tool", req.Params.Name, "session", id)
+				return newBusyResult(fmt.Sprintf("rate limit of %v calls per second exceeded", float64(l.limit)), delay), nil
+			}
+		}
+
+		if l.concurrency != nil {
+			select {
+			case l.concurrency <- struct{}{}:
+				defer func() { <-l.concurrency }()
+			default:
+				slog.Warn("Rejecting the tool call exceeding the concurrency limit", "tool", req.Params.Name)
+				return newBusyResult(fmt.Sprintf("too many concurrent tool calls, the limit is %d", cap(l.concurrency)), time.Second), nil
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// maxCallTimeout and maxCallRetries cap the per-call options of the tool calls, the timeout is also capped by the
+// timeout of the tool.
+const (
+	maxCallTimeout = 30 * time.Minute
+	maxCallRetries = 10
+)
+
+// callOptions applies the per-call timeout and retries of the tool call to the handler context, only the tools
+// advertising the parameters accept them.
+type callOptions struct {
+	timeout map[string]bool
+	retries map[string]bool
+}
+
+func newCallOptions() *callOptions {
+	return &callOptions{timeout: make(map[string]bool), retries: make(map[string]bool)}
+}
+
+// register adds the parameters of the per-call options to the tools accepting them.
+func (o *callOptions) register(tools []server.ServerTool) []server.ServerTool {
+	for i := range tools {
+		timeout, retries := koffeemcp.CallOptions(tools[i].Tool)
+		o.timeout[tools[i].Tool.Name], o.retries[tools[i].Tool.Name] = timeout, retries
+		tools[i].Tool = koffeemcp.WithCallOptions(tools[i].Tool)
+	}
+	return tools
+}
+
+func (o *callOptions) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if seconds := req.GetInt(koffeemcp.TimeoutSecondsParam, 0); seconds > 0 && o.timeout[req.Params.Name] {
+			ctx = client.WithRequestTimeout(ctx, min(time.Duration(seconds)*time.Second, maxCallTimeout))
+		}
+		if retries := req.GetInt(koffeemcp.RetriesParam, -1); retries >= 0 && o.retries[req.Params.Name] {
+			ctx = client.WithMaxRetries(ctx, min(retries, maxCallRetries))
+		}
+		return next(ctx, req)
+	}
+}
+
+// timeouts applies the timeout of the tool to the handler context, the tool call returns a timeout error with the
+// partial result once the deadline is exceeded or the client cancels the call. The handler runs on the calling
+// goroutine, so it holds the limits of the tool call until it returns on the canceled context. The per-call timeout
+// of the tool call may only shorten the timeout of the tool.
+type timeouts struct {
+	defaultTimeout time.Duration
+	overrides      map[string]time.Duration
+}
+
+func (t *timeouts) timeout(name string) time.Duration {
+	if d, ok := t.overrides[name]; ok {
+		return d
+	}
+	return t.defaultTimeout
+}
+
+func (t *timeouts) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		timeout := t.timeout(req.Params.Name)
+		if d, ok := client.RequestTimeoutFromContext(ctx); ok && (timeout <= 0 || d < timeout) {
+			timeout = d
+		}
+		if timeout <= 0 {
+			return next(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := next(ctx, req)
+		if ctx.Err() == nil {
+			return result, err
+		}
+
+		toolErr := ToolError{Error: "timeout", Retryable: true}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			toolErr.Message = fmt.Sprintf("tool %s did not complete within %s", req.Params.Name, timeout)
+		} else {
+			toolErr.Error, toolErr.Message = "canceled", fmt.Sprintf("tool %s was canceled", req.Params.Name)
+		}
+		if result != nil {
+			for _, content := range result.Content {
+				if text, ok := content.(mcp.TextContent); ok {
+					toolErr.Partial += text.Text
+				}
+			}
+		}
+		slog.Warn("Tool call interrupted", "tool", req.Params.Name, "timeout", timeout, "err", ctx.Err())
+		return newToolErrorResult(toolErr, 0), nil
+	}
+}
+
+// retryStatus tracks the retries of the requests to the API server made by the tool call, the retries and the
+// throttling of the API server are surfaced in the metadata of the result.
+func retryStatus(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = client.WithRetryTracking(ctx)
+		result, err := next(ctx, req)
+		if status, ok := client.RetryStatusFromContext(ctx); ok {
+			slog.Warn("Retried the requests to the API server", "tool", req.Params.Name, "retries", status.Retries, "throttled", status.Throttled, "waited", status.Waited)
+			if result != nil {
+				if result.Meta == nil {
+					result.Meta = make(map[string]any)
+				}
+				result.Meta["apiRetries"] = status
+			}
+		}
+		return result, err
+	}
+}
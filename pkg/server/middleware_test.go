@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"cola.io/koffee/pkg/client"
+)
+
+func newToolRequest(name string) mcp.CallToolRequest {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	return req
+}
+
+// toolErrorOf returns the structured error of the result, nil if the result is not an error.
+func toolErrorOf(t *testing.T, result *mcp.CallToolResult) *ToolError {
+	t.Helper()
+	if result == nil || !result.IsError {
+		return nil
+	}
+	toolErr := &ToolError{}
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), toolErr); err != nil {
+		t.Fatalf("unexpected error result: %v", err)
+	}
+	return toolErr
+}
+
+func TestTimeoutsMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		overrides   map[string]time.Duration
+		callTimeout time.Duration
+		handlerTime time.Duration
+		wantError   string
+		wantPartial string
+	}{
+		{
+			name:        "completed within the timeout",
+			timeout:     time.Second,
+			handlerTime: 0,
+		},
+		{
+			name:        "no timeout",
+			timeout:     0,
+			handlerTime: 50 * time.Millisecond,
+		},
+		{
+			name:        "deadline exceeded with the partial result",
+			timeout:     20 * time.Millisecond,
+			handlerTime: time.Hour,
+			wantError:   "timeout",
+			wantPartial: "partial",
+		},
+		{
+			name:        "overridden timeout of the tool",
+			timeout:     time.Hour,
+			overrides:   map[string]time.Duration{"test": 20 * time.Millisecond},
+			handlerTime: time.Hour,
+			wantError:   "timeout",
+			wantPartial: "partial",
+		},
+		{
+			name:        "per-call timeout shortening the timeout of the tool",
+			timeout:     time.Hour,
+			callTimeout: 20 * time.Millisecond,
+			handlerTime: time.Hour,
+			wantError:   "timeout",
+			wantPartial: "partial",
+		},
+		{
+			name:        "per-call timeout capped by the timeout of the tool",
+			timeout:     20 * time.Millisecond,
+			callTimeout: time.Hour,
+			handlerTime: 5 * time.Second,
+			wantError:   "timeout",
+			wantPartial: "partial",
+		},
+		{
+			name:        "per-call timeout without the timeout of the tool",
+			callTimeout: 20 * time.Millisecond,
+			handlerTime: 5 * time.Second,
+			wantError:   "timeout",
+			wantPartial: "partial",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var returned atomic.Bool
+			handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				defer returned.Store(true)
+				select {
+				case <-time.After(tt.handlerTime):
+					return mcp.NewToolResultText("done"), nil
+				case <-ctx.Done():
+					return mcp.NewToolResultText("partial"), ctx.Err()
+				}
+			}
+			ctx := context.Background()
+			if tt.callTimeout > 0 {
+				ctx = client.WithRequestTimeout(ctx, tt.callTimeout)
+			}
+			middleware := (&timeouts{defaultTimeout: tt.timeout, overrides: tt.overrides}).middleware(handler)
+			result, err := middleware(ctx, newToolRequest("test"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !returned.Load() {
+				t.Fatal("the middleware returned before the handler")
+			}
+			toolErr := toolErrorOf(t, result)
+			if len(tt.wantError) == 0 {
+				if toolErr != nil {
+					t.Fatalf("unexpected tool error: %+v", toolErr)
+				}
+				return
+			}
+			if toolErr == nil || toolErr.Error != tt.wantError || toolErr.Partial != tt.wantPartial {
+				t.Fatalf("got tool error %+v, want %s with the partial %q", toolErr, tt.wantError, tt.wantPartial)
+			}
+		})
+	}
+}
+
+// TestTimeoutsMiddlewareHoldsConcurrency checks the interrupted handler holds the concurrency limit until it returns.
+func TestTimeoutsMiddlewareHoldsConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		<-release
+		return nil, ctx.Err()
+	}
+	middleware := newLimiter(1, 0, 0).middleware((&timeouts{defaultTimeout: 10 * time.Millisecond}).middleware(handler))
+
+	done := make(chan *mcp.CallToolResult)
+	go func() {
+		result, _ := middleware(context.Background(), newToolRequest("test"))
+		done <- result
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	result, _ := middleware(context.Background(), newToolRequest("test"))
+	if toolErr := toolErrorOf(t, result); toolErr == nil || toolErr.Error != "busy" {
+		t.Fatalf("got %+v, want the busy error while the interrupted handler is running", toolErr)
+	}
+	close(release)
+	if toolErr := toolErrorOf(t, <-done); toolErr == nil || toolErr.Error != "timeout" {
+		t.Fatalf("got %+v, want the timeout error", toolErr)
+	}
+}
+
+// TestMiddlewareOrder calls the tools through the middlewares of the server, the per-call options are applied
+// before the timeouts, and the timeouts and the errors are translated to the tool errors.
+func TestMiddlewareOrder(t *testing.T) {
+	s := NewServer(nil, WithToolTimeout(time.Hour, map[string]time.Duration{"capped": 20 * time.Millisecond}))
+	wait := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		select {
+		case <-time.After(5 * time.Second):
+			return mcp.NewToolResultText("done"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	readOnly := func(name string) mcp.Tool {
+		return mcp.NewTool(name, mcp.WithReadOnlyHintAnnotation(true), mcp.WithIdempotentHintAnnotation(true))
+	}
+	s.addTools([]server.ServerTool{
+		{Tool: readOnly("slow"), Handler: wait},
+		{Tool: readOnly("capped"), Handler: wait},
+	})
+
+	tests := []struct {
+		name      string
+		tool      string
+		args      map[string]any
+		wantError string
+	}{
+		{name: "per-call timeout", tool: "slow", args: map[string]any{"timeoutSeconds": 1}, wantError: "timeout"},
+		{name: "per-call timeout capped by the tool", tool: "capped", args: map[string]any{"timeoutSeconds": 3600}, wantError: "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := json.Marshal(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"method":  "tools/call",
+				"params":  map[string]any{"name": tt.tool, "arguments": tt.args},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			started := time.Now()
+			resp, ok := s.svr.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+			if !ok {
+				t.Fatalf("got %T, want the result of the tool call", resp)
+			}
+			result, ok := resp.Result.(mcp.CallToolResult)
+			if !ok {
+				t.Fatalf("got %T, want the result of the tool call", resp.Result)
+			}
+			if toolErr := toolErrorOf(t, &result); toolErr == nil || toolErr.Error != tt.wantError {
+				t.Fatalf("got tool error %+v, want %s", toolErr, tt.wantError)
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Fatalf("the tool call took %s, want it interrupted", elapsed)
+			}
+		})
+	}
+}
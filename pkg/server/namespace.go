@@ -0,0 +1,405 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// protectedNamespaces are the namespaces never torn down.
+var protectedNamespaces = []string{metav1.NamespaceDefault, metav1.NamespaceSystem, metav1.NamespacePublic, corev1.NamespaceNodeLease}
+
+// teardownPhases are the kinds deleted in each phase, the consumers are deleted before the objects they depend on,
+// the kinds not listed like the custom resources are deleted after the workloads and before their configurations.
+var teardownPhases = [][]string{
+	{"Ingress", "Gateway", "HTTPRoute", "GRPCRoute", "TLSRoute", "Service", "HorizontalPodAutoscaler", "PodDisruptionBudget"},
+	{"CronJob", "Deployment", "StatefulSet", "DaemonSet", "Job", "ReplicaSet", "ReplicationController"},
+	{"Pod"},
+	nil,
+	{"ConfigMap", "Secret", "ServiceAccount", "RoleBinding", "Role", "NetworkPolicy", "LimitRange", "ResourceQuota"},
+	{"PersistentVolumeClaim"},
+}
+
+// customTeardownPhase is the phase of the kinds not listed in the teardown phases.
+const customTeardownPhase = 3
+
+// skippedTeardownKinds are the kinds removed with the namespace or recreated by the control plane.
+var skippedTeardownKinds = []string{"Event", "Endpoints", "EndpointSlice", "Lease", "ControllerRevision", "PodMetrics"}
+
+// maxStuckObjects is the maximum number of the remaining objects returned for a stuck namespace.
+const maxStuckObjects = 50
+
+// NamespaceTeardown is the objects deleted in the dependency order and the deletion of the namespace.
+type NamespaceTeardown struct {
+	Namespace        string           `json:"namespace"`
+	DryRun           bool             `json:"dryRun,omitempty"`
+	Deleted          int              `json:"deleted"`
+	Failed           int              `json:"failed"`
+	Objects          []TornDownObject `json:"objects"`
+	NamespaceDeleted bool             `json:"namespaceDeleted"`
+	Warnings         []string         `json:"warnings,omitempty"`
+}
+
+// TornDownObject is an object deleted by the teardown and the phase it's deleted in.
+type TornDownObject struct {
+	Phase      int    `json:"phase"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Error      string `json:"error,omitempty"`
+	// gvr is the resource the object is deleted by, the kinds of the different groups may have the same name.
+	gvr schema.GroupVersionResource
+}
+
+// NamespaceTermination is the diagnosis of a namespace stuck Terminating.
+type NamespaceTermination struct {
+	Namespace   string             `json:"namespace"`
+	Phase       string             `json:"phase"`
+	Terminating string             `json:"terminating,omitempty"`
+	Finalizers  []string           `json:"finalizers,omitempty"`
+	Conditions  []string           `json:"conditions,omitempty"`
+	Remaining   []RemainingObject  `json:"remaining"`
+	Unavailable []UnavailableGroup `json:"unavailableAPIs,omitempty"`
+	Hints       []string           `json:"hints,omitempty"`
+}
+
+// RemainingObject is an object left in the terminating namespace and the finalizers blocking its deletion.
+type RemainingObject struct {
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Finalizers  []string `json:"finalizers,omitempty"`
+	Terminating string   `json:"terminating,omitempty"`
+}
+
+// UnavailableGroup is an API group the namespace controller fails to discover or list, which blocks the deletion of
+// every namespace.
+type UnavailableGroup struct {
+	GroupVersion string `json:"groupVersion"`
+	Service      string `json:"service,omitempty"`
+	Message      string `json:"message"`
+}
+
+// namespacedTarget is a namespaced resource listed and deleted by the teardown and the diagnosis.
+type namespacedTarget struct {
+	kind string
+	gvr  schema.GroupVersionResource
+}
+
+func (s *Server) TeardownNamespace() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		dryRun := req.GetBool("dryRun", true)
+		deleteNamespace := req.GetBool("deleteNamespace", true)
+		if slices.Contains(protectedNamespaces, namespace) {
+			return nil, fmt.Errorf("namespace %q is protected and can't be torn down", namespace)
+		}
+		if !dryRun && req.GetString("confirm", "") != namespace {
+			return nil, fmt.Errorf("tearing down deletes all resources of namespace %q, review the dry run and set confirm to %q to proceed", namespace, namespace)
+		}
+
+		slog.Info("Tearing down namespace", "namespace", namespace, "dryRun", dryRun, "deleteNamespace", deleteNamespace)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = cli.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+
+		targets, failed, err := namespacedTargets(discoveryClient)
+		if err != nil {
+			return nil, err
+		}
+		teardown := &NamespaceTeardown{Namespace: namespace, DryRun: dryRun, Objects: make([]TornDownObject, 0)}
+		for gv := range failed {
+			teardown.Warnings = append(teardown.Warnings, fmt.Sprintf("the objects of %s are not deleted, the API is not available", gv))
+		}
+		sort.Strings(teardown.Warnings)
+
+		teardownObjects(ctx, dynamicClient, namespace, targets, teardown)
+
+		if deleteNamespace {
+			nsOptions := metav1.DeleteOptions{}
+			if dryRun {
+				nsOptions.DryRun = []string{metav1.DryRunAll}
+			}
+			if err = cli.CoreV1().Namespaces().Delete(ctx, namespace, nsOptions); err != nil && !apierrors.IsNotFound(err) {
+				teardown.Warnings = append(teardown.Warnings, fmt.Sprintf("failed to delete namespace: %v", err))
+			} else {
+				teardown.NamespaceDeleted = true
+			}
+		}
+
+		resp, err := json.Marshal(teardown)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) DiagnoseNamespaceTermination() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Diagnosing namespace termination", "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		ns, err := cli.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+
+		diagnosis := &NamespaceTermination{Namespace: namespace, Phase: string(ns.Status.Phase), Remaining: make([]RemainingObject, 0)}
+		if ns.DeletionTimestamp == nil {
+			diagnosis.Hints = append(diagnosis.Hints, "the namespace is not being deleted")
+		} else {
+			diagnosis.Terminating = duration.HumanDuration(time.Since(ns.DeletionTimestamp.Time))
+		}
+		for _, finalizer := range ns.Spec.Finalizers {
+			diagnosis.Finalizers = append(diagnosis.Finalizers, string(finalizer))
+		}
+		for _, c := range ns.Status.Conditions {
+			if c.Status == corev1.ConditionTrue {
+				diagnosis.Conditions = append(diagnosis.Conditions, fmt.Sprintf("%s: %s", c.Type, c.Message))
+			}
+		}
+
+		targets, failed, err := namespacedTargets(discoveryClient)
+		if err != nil {
+			return nil, err
+		}
+		unavailable, err := unavailableAPIServices(ctx, dynamicClient)
+		if err != nil {
+			return nil, err
+		}
+		for gv, message := range failed {
+			group := UnavailableGroup{GroupVersion: gv, Message: message}
+			if svc, ok := unavailable[gv]; ok {
+				group.Service = svc
+			}
+			diagnosis.Unavailable = append(diagnosis.Unavailable, group)
+		}
+		sort.Slice(diagnosis.Unavailable, func(i, j int) bool {
+			return diagnosis.Unavailable[i].GroupVersion < diagnosis.Unavailable[j].GroupVersion
+		})
+
+		finalizers := make(map[string]int)
+		for _, target := range targets {
+			items, err := dynamicClient.Resource(target.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				diagnosis.Unavailable = append(diagnosis.Unavailable, UnavailableGroup{GroupVersion: target.gvr.GroupVersion().String(), Message: err.Error()})
+				continue
+			}
+			for i := range items.Items {
+				obj := &items.Items[i]
+				// the default ServiceAccount and the root CA are recreated by the control plane until the namespace is gone.
+				if (target.kind == "ServiceAccount" && obj.GetName() == "default") || (target.kind == "ConfigMap" && obj.GetName() == "kube-root-ca.crt") {
+					continue
+				}
+				for _, finalizer := range obj.GetFinalizers() {
+					finalizers[finalizer]++
+				}
+				if len(diagnosis.Remaining) >= maxStuckObjects {
+					continue
+				}
+				remaining := RemainingObject{Kind: target.kind, Name: obj.GetName(), Finalizers: obj.GetFinalizers()}
+				if ts := obj.GetDeletionTimestamp(); ts != nil {
+					remaining.Terminating = duration.HumanDuration(time.Since(ts.Time))
+				}
+				diagnosis.Remaining = append(diagnosis.Remaining, remaining)
+			}
+		}
+		// the objects blocked by the finalizers come first.
+		sort.SliceStable(diagnosis.Remaining, func(i, j int) bool {
+			return len(diagnosis.Remaining[i].Finalizers) > 0 && len(diagnosis.Remaining[j].Finalizers) == 0
+		})
+		diagnosis.Hints = append(diagnosis.Hints, terminationHints(diagnosis, finalizers)...)
+
+		resp, err := json.Marshal(diagnosis)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// teardownObjects deletes the objects of the targets in the namespace phase by phase, the objects owned by the
+// controllers are left to the garbage collector.
+func teardownObjects(ctx context.Context, dynamicClient dynamic.Interface, namespace string, targets []namespacedTarget, teardown *NamespaceTeardown) {
+	phases := make([][]TornDownObject, len(teardownPhases))
+	for _, target := range targets {
+		items, err := dynamicClient.Resource(target.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			teardown.Warnings = append(teardown.Warnings, fmt.Sprintf("failed to list %s: %v", target.gvr.GroupResource(), err))
+			continue
+		}
+		phase := teardownPhase(target.kind)
+		for i := range items.Items {
+			if metav1.GetControllerOfNoCopy(&items.Items[i]) != nil {
+				continue
+			}
+			phases[phase] = append(phases[phase], TornDownObject{
+				Phase:      phase,
+				APIVersion: target.gvr.GroupVersion().String(),
+				Kind:       target.kind,
+				Name:       items.Items[i].GetName(),
+				gvr:        target.gvr,
+			})
+		}
+	}
+
+	options := metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)}
+	if teardown.DryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	for _, objs := range phases {
+		for _, obj := range objs {
+			err := dynamicClient.Resource(obj.gvr).Namespace(namespace).Delete(ctx, obj.Name, options)
+			switch {
+			case err == nil || apierrors.IsNotFound(err):
+				teardown.Deleted++
+			default:
+				obj.Error = err.Error()
+				teardown.Failed++
+			}
+			teardown.Objects = append(teardown.Objects, obj)
+		}
+	}
+}
+
+// namespacedTargets returns the namespaced resources which can be listed and deleted, and the messages of the group
+// versions failed to discover, the resources of the other groups are still returned.
+func namespacedTargets(discoveryClient discovery.DiscoveryInterface) ([]namespacedTarget, map[string]string, error) {
+	failed := make(map[string]string)
+	apiResources, err := discoveryClient.ServerPreferredNamespacedResources()
+	if err != nil {
+		var groupErr *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &groupErr) {
+			return nil, nil, fmt.Errorf("failed to list api resources: %w", err)
+		}
+		for gv, err := range groupErr.Groups {
+			failed[gv.String()] = err.Error()
+		}
+	}
+
+	targets := make([]namespacedTarget, 0)
+	for _, apiResource := range apiResources {
+		gv, err := schema.ParseGroupVersion(apiResource.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range apiResource.APIResources {
+			if strings.Contains(resource.Name, "/") || slices.Contains(skippedTeardownKinds, resource.Kind) ||
+				!slices.Contains(resource.Verbs, "list") || !slices.Contains(resource.Verbs, "delete") {
+				continue
+			}
+			targets = append(targets, namespacedTarget{kind: resource.Kind, gvr: gv.WithResource(resource.Name)})
+		}
+	}
+	return targets, failed, nil
+}
+
+// unavailableAPIServices returns the Services of the unavailable APIServices by the group versions.
+func unavailableAPIServices(ctx context.Context, dynamicClient dynamic.Interface) (map[string]string, error) {
+	items, err := dynamicClient.Resource(definition.APIServiceGroupVersion.WithResource("apiservices")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apiservices: %w", err)
+	}
+	services := make(map[string]string)
+	for i := range items.Items {
+		apiService := &definition.APIService{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items.Items[i].Object, apiService); err != nil || apiService.Spec.Service == nil {
+			continue
+		}
+		for _, c := range apiService.Status.Conditions {
+			if c.Type == "Available" && c.Status != string(metav1.ConditionTrue) {
+				gv := schema.GroupVersion{Group: apiService.Spec.Group, Version: apiService.Spec.Version}
+				services[gv.String()] = apiService.Spec.Service.Namespace + "/" + apiService.Spec.Service.Name
+			}
+		}
+	}
+	return services, nil
+}
+
+// terminationHints explains what blocks the deletion of the namespace.
+func terminationHints(diagnosis *NamespaceTermination, finalizers map[string]int) []string {
+	hints := make([]string, 0)
+	for _, group := range diagnosis.Unavailable {
+		if len(group.Service) > 0 {
+			hints = append(hints, fmt.Sprintf("the APIService of %s is unavailable, fix or delete the Service %s or the APIService, the namespace controller can't verify the namespace is empty", group.GroupVersion, group.Service))
+		} else {
+			hints = append(hints, fmt.Sprintf("the API %s is unavailable, the namespace controller can't verify the namespace is empty", group.GroupVersion))
+		}
+	}
+	names := make([]string, 0, len(finalizers))
+	for name := range finalizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hints = append(hints, fmt.Sprintf("%d object(s) wait for the finalizer %s, check that its controller is running before removing the finalizer", finalizers[name], name))
+	}
+	if len(diagnosis.Remaining) > 0 && len(names) == 0 && len(diagnosis.Unavailable) == 0 && len(diagnosis.Terminating) > 0 {
+		hints = append(hints, "the remaining objects have no finalizers, the namespace controller should remove them shortly")
+	}
+	if len(diagnosis.Remaining) == 0 && len(diagnosis.Unavailable) == 0 && slices.Contains(diagnosis.Finalizers, string(corev1.FinalizerKubernetes)) {
+		hints = append(hints, "the namespace is empty but the kubernetes finalizer remains, check the kube-controller-manager is healthy")
+	}
+	return hints
+}
+
+// teardownPhase returns the phase the kind is deleted in.
+func teardownPhase(kind string) int {
+	for phase, kinds := range teardownPhases {
+		if slices.Contains(kinds, kind) {
+			return phase
+		}
+	}
+	return customTeardownPhase
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestTeardownNamespaceConfirm(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{name: "protected namespace", args: map[string]any{"namespace": "kube-system", "dryRun": false, "confirm": "kube-system"}},
+		{name: "without confirm", args: map[string]any{"namespace": "shop", "dryRun": false}},
+		{name: "confirm of another namespace", args: map[string]any{"namespace": "shop", "dryRun": false, "confirm": "shop-dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = "teardown_namespace"
+			req.Params.Arguments = tt.args
+			// the call is refused before any client is built.
+			if _, err := (&Server{}).TeardownNamespace()(context.Background(), req); err == nil {
+				t.Fatal("got no error, want the teardown refused")
+			}
+		})
+	}
+}
+
+func TestTeardownObjects(t *testing.T) {
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	knativeServices := schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	object := func(gvr schema.GroupVersionResource, kind, name string, owned bool) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(gvr.GroupVersion().String())
+		obj.SetKind(kind)
+		obj.SetNamespace("shop")
+		obj.SetName(name)
+		if owned {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "1", Controller: ptr.To(true)}})
+		}
+		return obj
+	}
+	targets := []namespacedTarget{
+		{kind: "ConfigMap", gvr: configMaps},
+		{kind: "Service", gvr: services},
+		{kind: "Service", gvr: knativeServices},
+	}
+
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{name: "dry run", dryRun: true},
+		{name: "deletion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{services: "ServiceList", knativeServices: "ServiceList", configMaps: "ConfigMapList"},
+				object(services, "Service", "web", false),
+				object(knativeServices, "Service", "hello", false),
+				object(configMaps, "ConfigMap", "app", false),
+				object(configMaps, "ConfigMap", "generated", true),
+			)
+			teardown := &NamespaceTeardown{Namespace: "shop", DryRun: tt.dryRun}
+			teardownObjects(context.Background(), dynamicClient, "shop", targets, teardown)
+
+			if teardown.Deleted != 3 || teardown.Failed != 0 || len(teardown.Warnings) != 0 {
+				t.Fatalf("got %d deleted, %d failed, warnings %v, want 3 deleted", teardown.Deleted, teardown.Failed, teardown.Warnings)
+			}
+			// the Services are deleted before the configurations, each by the resource of its group.
+			want := []string{"v1 web", "serving.knative.dev/v1 hello", "v1 app"}
+			for i, obj := range teardown.Objects {
+				if got := obj.APIVersion + " " + obj.Name; got != want[i] || obj.Error != "" {
+					t.Fatalf("got object %d %s with error %q, want %s", i, got, obj.Error, want[i])
+				}
+			}
+			if tt.dryRun {
+				return
+			}
+			for _, gvr := range []schema.GroupVersionResource{services, knativeServices} {
+				items, err := dynamicClient.Resource(gvr).Namespace("shop").List(context.Background(), metav1.ListOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(items.Items) != 0 {
+					t.Fatalf("got %d %s left, want none", len(items.Items), gvr.GroupResource())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultNetDebugImage is pinned so that the checks don't change with the latest release of the image.
+const defaultNetDebugImage = "nicolaka/netshoot:v0.13"
+
+// NetCheckResult is the result of a single network check executed inside the cluster.
+type NetCheckResult struct {
+	Type    string `json:"type"`
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NetDebugReport is the report of the network checks.
+type NetDebugReport struct {
+	Pod       string           `json:"pod"`
+	Namespace string           `json:"namespace"`
+	Ephemeral bool             `json:"ephemeral"`
+	Results   []NetCheckResult `json:"results"`
+}
+
+func (s *Server) NetDebug() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		podName := req.GetString("pod", "")
+		containerName := req.GetString("container", "")
+		image := req.GetString("image", defaultNetDebugImage)
+		dnsTargets := req.GetStringSlice("dns", nil)
+		tcpTargets := req.GetStringSlice("tcp", nil)
+		httpTargets := req.GetStringSlice("http", nil)
+
+		if len(dnsTargets) == 0 && len(tcpTargets) == 0 && len(httpTargets) == 0 {
+			return nil, errors.New("at least one of dns, tcp or http targets is required")
+		}
+
+		slog.Info("Running network debug", "namespace", namespace, "pod", podName, "container", containerName, "dns", dnsTargets, "tcp", tcpTargets, "http", httpTargets)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		report := &NetDebugReport{
+			Pod:       podName,
+			Namespace: namespace,
+			Results:   make([]NetCheckResult, 0),
+		}
+		if len(podName) == 0 {
+			pod, err := createDebugPod(ctx, cli, namespace, image)
+			if err != nil {
+				return nil, err
+			}
+			defer deleteDebugPod(cli, pod)
+			report.Pod, report.Ephemeral = pod.Name, true
+			containerName = debugPodContainerName
+		}
+
+		run := func(checkType, target string, command []string) {
+			stdout, stderr, err := s.execInContainer(ctx, namespace, report.Pod, containerName, command)
+			result := NetCheckResult{
+				Type:    checkType,
+				Target:  target,
+				Success: err == nil,
+				Output:  strings.TrimSpace(stdout),
+			}
+			if err != nil {
+				result.Error = strings.TrimSpace(fmt.Sprintf("%v %s", err, stderr))
+			}
+			report.Results = append(report.Results, result)
+		}
+
+		for _, target := range dnsTargets {
+			run("dns", target, []string{"nslookup", target})
+		}
+		for _, target := range tcpTargets {
+			host, port, err := net.SplitHostPort(target)
+			if err != nil {
+				report.Results = append(report.Results, NetCheckResult{Type: "tcp", Target: target, Error: err.Error()})
+				continue
+			}
+			run("tcp", target, []string{"nc", "-z", "-v", "-w", "5", host, port})
+		}
+		for _, target := range httpTargets {
+			run("http", target, []string{"curl", "-sS", "-o", "/dev/null", "-w", "%{http_code} %{time_total}s", "--max-time", "10", target})
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
@@ -0,0 +1,403 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	// nodeLeaseNamespace is the namespace of the leases renewed by the kubelets as the heartbeats.
+	nodeLeaseNamespace = "kube-node-lease"
+	// nodeRoleLabelPrefix is the prefix of the labels of the node roles, e.g. node-role.kubernetes.io/control-plane.
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	// defaultNodeLeaseDuration is the lease duration of the kubelet if the lease doesn't specify it.
+	defaultNodeLeaseDuration = 40 * time.Second
+	// zoneLabel is the well-known label of the topology zone of the node.
+	zoneLabel = "topology.kubernetes.io/zone"
+)
+
+// NodeReport is the detailed report of a node like `kubectl describe node`.
+type NodeReport struct {
+	Name             string          `json:"name"`
+	Roles            []string        `json:"roles,omitempty"`
+	Unschedulable    bool            `json:"unschedulable,omitempty"`
+	KubeletVersion   string          `json:"kubeletVersion"`
+	ContainerRuntime string          `json:"containerRuntime"`
+	OS               string          `json:"os"`
+	Addresses        []string        `json:"addresses,omitempty"`
+	Conditions       []NodeCondition `json:"conditions"`
+	Taints           []string        `json:"taints,omitempty"`
+	Resources        []NodeResource  `json:"resources"`
+	Pods             int             `json:"pods"`
+	Images           int             `json:"images"`
+	Lease            *NodeLease      `json:"lease,omitempty"`
+	Warnings         []string        `json:"warnings,omitempty"`
+}
+
+// NodeCondition is a condition of the node, the healthy flag tells whether the status is the expected one.
+type NodeCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Healthy            bool   `json:"healthy"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastHeartbeat      string `json:"lastHeartbeat,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// NodeResource is the allocatable resource of the node and the requests and limits of the pods on it.
+type NodeResource struct {
+	Resource        string  `json:"resource"`
+	Capacity        string  `json:"capacity"`
+	Allocatable     string  `json:"allocatable"`
+	Requests        string  `json:"requests"`
+	RequestsPercent float64 `json:"requestsPercent"`
+	Limits          string  `json:"limits"`
+	LimitsPercent   float64 `json:"limitsPercent"`
+}
+
+// NodeLease is the heartbeat lease of the kubelet, it's stale if not renewed within the lease duration.
+type NodeLease struct {
+	RenewTime       string `json:"renewTime,omitempty"`
+	SecondsSince    int64  `json:"secondsSince"`
+	DurationSeconds int32  `json:"durationSeconds"`
+	Stale           bool   `json:"stale"`
+}
+
+// WorkloadPlacement is the distribution of the pods of a workload over the nodes and zones.
+type WorkloadPlacement struct {
+	Workload    string          `json:"workload"`
+	Pods        int             `json:"pods"`
+	Unscheduled int             `json:"unscheduled,omitempty"`
+	Nodes       []NodePlacement `json:"nodes"`
+	Zones       map[string]int  `json:"zones,omitempty"`
+	// NodeSkew is the difference between the most and the least pods on the nodes hosting the workload.
+	NodeSkew int      `json:"nodeSkew"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NodePlacement is the pods of the workload on a node.
+type NodePlacement struct {
+	Node          string   `json:"node"`
+	Zone          string   `json:"zone,omitempty"`
+	Unschedulable bool     `json:"unschedulable,omitempty"`
+	Pods          []string `json:"pods"`
+	Ready         int      `json:"ready"`
+}
+
+func (s *Server) DescribeNode() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Describing node", "name", name)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		node, err := cli.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node: %w", err)
+		}
+		selector := fields.AndSelectors(
+			fields.OneTermEqualSelector("spec.nodeName", name),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+			fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+		)
+		pods, err := cli.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		info := node.Status.NodeInfo
+		report := &NodeReport{
+			Name:             node.Name,
+			Unschedulable:    node.Spec.Unschedulable,
+			KubeletVersion:   info.KubeletVersion,
+			ContainerRuntime: info.ContainerRuntimeVersion,
+			OS:               fmt.Sprintf("%s/%s (%s, %s)", info.OperatingSystem, info.Architecture, info.OSImage, info.KernelVersion),
+			Pods:             len(pods.Items),
+			Images:           len(node.Status.Images),
+		}
+		for label := range node.Labels {
+			if role, ok := strings.CutPrefix(label, nodeRoleLabelPrefix); ok && len(role) > 0 {
+				report.Roles = append(report.Roles, role)
+			}
+		}
+		sort.Strings(report.Roles)
+		for _, address := range node.Status.Addresses {
+			report.Addresses = append(report.Addresses, fmt.Sprintf("%s=%s", address.Type, address.Address))
+		}
+		for _, taint := range node.Spec.Taints {
+			report.Taints = append(report.Taints, taint.ToString())
+		}
+		if node.Spec.Unschedulable {
+			report.Warnings = append(report.Warnings, "the node is cordoned, no new pods are scheduled to it")
+		}
+
+		for _, condition := range node.Status.Conditions {
+			// the Ready condition is expected to be true while the pressure conditions are expected to be false.
+			healthy := condition.Status == corev1.ConditionFalse
+			if condition.Type == corev1.NodeReady {
+				healthy = condition.Status == corev1.ConditionTrue
+			}
+			report.Conditions = append(report.Conditions, NodeCondition{
+				Type:               string(condition.Type),
+				Status:             string(condition.Status),
+				Healthy:            healthy,
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+				LastHeartbeat:      condition.LastHeartbeatTime.UTC().Format(time.RFC3339),
+				LastTransitionTime: condition.LastTransitionTime.UTC().Format(time.RFC3339),
+			})
+			if !healthy {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("the condition %s is %s: %s", condition.Type, condition.Status, condition.Message))
+			}
+		}
+
+		requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+		for i := range pods.Items {
+			for name, q := range podRequests(&pods.Items[i]) {
+				addQuantity(requests, name, q)
+			}
+			for name, q := range podLimits(&pods.Items[i]) {
+				addQuantity(limits, name, q)
+			}
+		}
+		addQuantity(requests, corev1.ResourcePods, *resource.NewQuantity(int64(len(pods.Items)), resource.DecimalSI))
+		report.Resources = nodeResources(node, requests, limits)
+
+		lease, err := cli.CoordinationV1().Leases(nodeLeaseNamespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			report.Lease = nodeLease(lease.Spec.RenewTime, lease.Spec.LeaseDurationSeconds, time.Now())
+			if report.Lease.Stale {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("the kubelet has not renewed the lease within %ds, the node may be unreachable", report.Lease.DurationSeconds))
+			}
+		case apierrors.IsNotFound(err):
+			report.Warnings = append(report.Warnings, "the node has no heartbeat lease")
+		default:
+			report.Warnings = append(report.Warnings, fmt.Sprintf("failed to get the node lease: %v", err))
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// nodeResources returns the allocatable resources of the node with the allocated requests and limits, sorted like
+// `kubectl describe node`.
+func nodeResources(node *corev1.Node, requests, limits corev1.ResourceList) []NodeResource {
+	names := make([]corev1.ResourceName, 0, len(node.Status.Allocatable))
+	for name := range node.Status.Allocatable {
+		names = append(names, name)
+	}
+	sort.Sort(definition.SortableResourceNames(names))
+
+	percent := func(q, allocatable resource.Quantity) float64 {
+		if allocatable.Sign() <= 0 {
+			return 0
+		}
+		return float64(q.MilliValue()) * 100 / float64(allocatable.MilliValue())
+	}
+	resources := make([]NodeResource, 0, len(names))
+	for _, name := range names {
+		allocatable, capacity := node.Status.Allocatable[name], node.Status.Capacity[name]
+		request, limit := requests[name], limits[name]
+		resources = append(resources, NodeResource{
+			Resource:        string(name),
+			Capacity:        capacity.String(),
+			Allocatable:     allocatable.String(),
+			Requests:        request.String(),
+			RequestsPercent: percent(request, allocatable),
+			Limits:          limit.String(),
+			LimitsPercent:   percent(limit, allocatable),
+		})
+	}
+	return resources
+}
+
+func nodeLease(renewTime *metav1.MicroTime, durationSeconds *int32, now time.Time) *NodeLease {
+	duration := int32(defaultNodeLeaseDuration.Seconds())
+	lease := &NodeLease{DurationSeconds: ptr.Deref(durationSeconds, duration), Stale: true}
+	if renewTime == nil {
+		return lease
+	}
+	since := now.Sub(renewTime.Time)
+	lease.RenewTime = renewTime.UTC().Format(time.RFC3339)
+	lease.SecondsSince = int64(since.Seconds())
+	lease.Stale = since > time.Duration(lease.DurationSeconds)*time.Second
+	return lease
+}
+
+func (s *Server) ListNodePods() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		includeTerminated := req.GetBool("includeTerminated", false)
+
+		slog.Info("Listing pods on node", "name", name, "includeTerminated", includeTerminated)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		selector := fields.OneTermEqualSelector("spec.nodeName", name)
+		if !includeTerminated {
+			selector = fields.AndSelectors(
+				selector,
+				fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+				fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+			)
+		}
+		pods, err := cli.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		table, err := s.generator.GenerateTable(pods, definition.GenerateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := json.Marshal(table)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) WorkloadPlacement() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		kind, name, err := parseWorkloadReference(req.GetString("kind", "Deployment"), resourceName)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Analyzing workload placement", "kind", kind, "name", name, "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pods, err := resolveWorkloadPods(ctx, cli, namespace, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		nodeByName := make(map[string]*corev1.Node, len(nodes.Items))
+		for i := range nodes.Items {
+			nodeByName[nodes.Items[i].Name] = &nodes.Items[i]
+		}
+
+		placement := &WorkloadPlacement{Workload: fmt.Sprintf("%s %s/%s", kind, namespace, name), Pods: len(pods), Zones: make(map[string]int)}
+		placements := make(map[string]*NodePlacement)
+		for i := range pods {
+			pod := &pods[i]
+			if len(pod.Spec.NodeName) == 0 {
+				placement.Unscheduled++
+				continue
+			}
+			p, ok := placements[pod.Spec.NodeName]
+			if !ok {
+				p = &NodePlacement{Node: pod.Spec.NodeName}
+				if node, ok := nodeByName[pod.Spec.NodeName]; ok {
+					p.Zone, p.Unschedulable = node.Labels[zoneLabel], node.Spec.Unschedulable
+				}
+				placements[pod.Spec.NodeName] = p
+			}
+			p.Pods = append(p.Pods, pod.Name)
+			if podReady(pod) {
+				p.Ready++
+			}
+			if len(p.Zone) > 0 {
+				placement.Zones[p.Zone]++
+			}
+		}
+
+		placement.Nodes = make([]NodePlacement, 0, len(placements))
+		for _, p := range placements {
+			sort.Strings(p.Pods)
+			placement.Nodes = append(placement.Nodes, *p)
+		}
+		sort.Slice(placement.Nodes, func(i, j int) bool {
+			if a, b := len(placement.Nodes[i].Pods), len(placement.Nodes[j].Pods); a != b {
+				return a > b
+			}
+			return placement.Nodes[i].Node < placement.Nodes[j].Node
+		})
+		if n := len(placement.Nodes); n > 0 {
+			placement.NodeSkew = len(placement.Nodes[0].Pods) - len(placement.Nodes[n-1].Pods)
+		}
+		placement.Warnings = placementWarnings(placement)
+
+		resp, err := json.Marshal(placement)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// placementWarnings reports the nodes and zones whose maintenance would take down the workload or most of it.
+func placementWarnings(placement *WorkloadPlacement) []string {
+	var warnings []string
+	scheduled := placement.Pods - placement.Unscheduled
+	if placement.Unscheduled > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d pods are not scheduled", placement.Unscheduled))
+	}
+	if scheduled > 1 && len(placement.Nodes) == 1 {
+		warnings = append(warnings, fmt.Sprintf("all %d pods run on the node %s, draining it takes down the workload", scheduled, placement.Nodes[0].Node))
+	} else {
+		for _, p := range placement.Nodes {
+			if len(p.Pods)*2 > scheduled {
+				warnings = append(warnings, fmt.Sprintf("the node %s runs %d of %d pods, draining it takes down most of the workload", p.Node, len(p.Pods), scheduled))
+			}
+		}
+	}
+	if scheduled > 1 && len(placement.Zones) == 1 {
+		for zone := range placement.Zones {
+			warnings = append(warnings, fmt.Sprintf("all pods run in the zone %s", zone))
+		}
+	}
+	for _, p := range placement.Nodes {
+		if p.Unschedulable {
+			warnings = append(warnings, fmt.Sprintf("the node %s is cordoned, its %d pods are rescheduled when drained", p.Node, len(p.Pods)))
+		}
+	}
+	return warnings
+}
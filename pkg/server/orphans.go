@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+
+	"cola.io/koffee/pkg/refgraph"
+)
+
+// orphanKinds are the kinds checked by find_orphans.
+var orphanKinds = []string{"ConfigMap", "Secret", "Service", "PersistentVolumeClaim", "ReplicaSet"}
+
+// unreferencedSecretTypes are the types of the Secrets used without the references of the pods.
+var unreferencedSecretTypes = []corev1.SecretType{corev1.SecretTypeServiceAccountToken, corev1.SecretTypeBootstrapToken, "helm.sh/release.v1"}
+
+// OrphanReport is the objects of a namespace which are likely orphaned.
+type OrphanReport struct {
+	Namespace string   `json:"namespace"`
+	Orphans   []Orphan `json:"orphans"`
+}
+
+// Orphan is an object which is likely orphaned and the reason.
+type Orphan struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Age    string `json:"age"`
+}
+
+func (s *Server) FindOrphans() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		kinds := req.GetStringSlice("kinds", orphanKinds)
+		for _, kind := range kinds {
+			if !slices.Contains(orphanKinds, kind) {
+				return nil, fmt.Errorf("unsupported kind %q, must be one of %v", kind, orphanKinds)
+			}
+		}
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+
+		slog.Info("Finding orphans", "namespace", namespace, "kinds", kinds)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		graph, pods, err := namespaceReferenceGraph(ctx, cli, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		report := &OrphanReport{Namespace: namespace, Orphans: make([]Orphan, 0)}
+		now := time.Now()
+		add := func(kind string, obj metav1.Object, reason string) {
+			report.Orphans = append(report.Orphans, Orphan{Kind: kind, Name: obj.GetName(), Reason: reason, Age: duration.HumanDuration(now.Sub(obj.GetCreationTimestamp().Time))})
+		}
+		for _, kind := range kinds {
+			switch kind {
+			case "ConfigMap":
+				configMaps, err := cli.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to list configmaps: %w", err)
+				}
+				for i := range configMaps.Items {
+					cm := &configMaps.Items[i]
+					// the root CA is published to every namespace, and the owned ones are managed by the owners.
+					if cm.Name == "kube-root-ca.crt" || len(cm.OwnerReferences) > 0 || graph.Referenced(refgraph.Node{Kind: kind, Name: cm.Name}) {
+						continue
+					}
+					add(kind, cm, "not referenced by any pod, workload template or volume")
+				}
+			case "Secret":
+				secrets, err := cli.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to list secrets: %w", err)
+				}
+				for i := range secrets.Items {
+					secret := &secrets.Items[i]
+					if slices.Contains(unreferencedSecretTypes, secret.Type) || len(secret.OwnerReferences) > 0 || graph.Referenced(refgraph.Node{Kind: kind, Name: secret.Name}) {
+						continue
+					}
+					add(kind, secret, "not referenced by any pod, workload template, ServiceAccount or Ingress")
+				}
+			case "Service":
+				if err = findServiceOrphans(ctx, cli, namespace, add); err != nil {
+					return nil, err
+				}
+			case "PersistentVolumeClaim":
+				pvcs, err := cli.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to list persistentvolumeclaims: %w", err)
+				}
+				for i := range pvcs.Items {
+					pvc := &pvcs.Items[i]
+					if pods.Referenced(refgraph.Node{Kind: kind, Name: pvc.Name}) {
+						continue
+					}
+					add(kind, pvc, "not mounted by any pod")
+				}
+			case "ReplicaSet":
+				replicaSets, err := cli.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("failed to list replicasets: %w", err)
+				}
+				for i := range replicaSets.Items {
+					rs := &replicaSets.Items[i]
+					if rs.Status.Replicas > 0 || (rs.Spec.Replicas != nil && *rs.Spec.Replicas > 0) || len(rs.OwnerReferences) > 0 {
+						continue
+					}
+					add(kind, rs, "scaled to zero without an owner")
+				}
+			}
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// namespaceReferenceGraph returns the references of the pods, the workload templates, the ServiceAccounts and the
+// Ingresses of the namespace, and the references of the running pods only.
+func namespaceReferenceGraph(ctx context.Context, cli kubernetes.Interface, namespace string) (*refgraph.Graph, *refgraph.Graph, error) {
+	graph, pods := refgraph.New(), refgraph.New()
+
+	podList, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		graph.AddPodSpec(refgraph.Node{Kind: "Pod", Name: pod.Name}, &pod.Spec)
+		pods.AddPodSpec(refgraph.Node{Kind: "Pod", Name: pod.Name}, &pod.Spec)
+	}
+
+	// the templates of the workloads scaled to zero or suspended still reference the objects.
+	deployments, err := cli.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		graph.AddPodSpec(refgraph.Node{Kind: "Deployment", Name: deployments.Items[i].Name}, &deployments.Items[i].Spec.Template.Spec)
+	}
+	statefulSets, err := cli.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		graph.AddPodSpec(refgraph.Node{Kind: "StatefulSet", Name: statefulSets.Items[i].Name}, &statefulSets.Items[i].Spec.Template.Spec)
+	}
+	daemonSets, err := cli.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		graph.AddPodSpec(refgraph.Node{Kind: "DaemonSet", Name: daemonSets.Items[i].Name}, &daemonSets.Items[i].Spec.Template.Spec)
+	}
+	cronJobs, err := cli.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for i := range cronJobs.Items {
+		graph.AddPodSpec(refgraph.Node{Kind: "CronJob", Name: cronJobs.Items[i].Name}, &cronJobs.Items[i].Spec.JobTemplate.Spec.Template.Spec)
+	}
+
+	serviceAccounts, err := cli.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list serviceaccounts: %w", err)
+	}
+	for i := range serviceAccounts.Items {
+		graph.AddServiceAccount(&serviceAccounts.Items[i])
+	}
+	ingresses, err := cli.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	for i := range ingresses.Items {
+		graph.AddIngress(&ingresses.Items[i])
+	}
+	return graph, pods, nil
+}
+
+// findServiceOrphans finds the Services without the ready endpoints, the ExternalName Services have no endpoints.
+func findServiceOrphans(ctx context.Context, cli kubernetes.Interface, namespace string, add func(string, metav1.Object, string)) error {
+	services, err := cli.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	endpointSlices, err := cli.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list endpointslices: %w", err)
+	}
+	ready := make(map[string]int)
+	for _, slice := range endpointSlices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready[slice.Labels[discoveryv1.LabelServiceName]] += len(endpoint.Addresses)
+			}
+		}
+	}
+	for i := range services.Items {
+		svc := &services.Items[i]
+		if svc.Spec.Type == corev1.ServiceTypeExternalName || ready[svc.Name] > 0 {
+			continue
+		}
+		if len(svc.Spec.Selector) == 0 {
+			add("Service", svc, "no ready endpoints and no selector, the endpoints are managed manually")
+		} else {
+			add("Service", svc, "no ready endpoints, the selector matches no ready pod")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+const (
+	// maxOutputs is the maximum number of the command outputs of a session kept as the resources, the oldest ones
+	// are evicted first.
+	maxOutputs = 20
+	// maxOutputsBytes is the maximum total bytes of the command outputs of a session kept as the resources.
+	maxOutputsBytes = 64 << 20
+)
+
+// cappedBuffer keeps the first bytes of a command output up to the limit, the rest are counted and discarded, so
+// that a large output like of pg_dump never exhausts the memory of the server.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int64
+	// total is the bytes written including the discarded ones.
+	total int64
+}
+
+func newCappedBuffer(limit int64) *cappedBuffer {
+	return &cappedBuffer{limit: limit}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.total += int64(len(p))
+	if remaining := b.limit - int64(b.buf.Len()); remaining > 0 {
+		b.buf.Write(p[:min(int64(len(p)), remaining)])
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// truncated returns whether some bytes of the output are discarded.
+func (b *cappedBuffer) truncated() bool {
+	return b.total > int64(b.buf.Len())
+}
+
+// String returns the output as text, the truncated output ends with a marker of the discarded bytes.
+func (b *cappedBuffer) String() string {
+	if !b.truncated() {
+		return b.buf.String()
+	}
+	return b.marked(b.buf.Bytes())
+}
+
+// encode encodes the output like encodeOutput, the truncated text ends with the marker while the encoded binary
+// output is left as it is.
+func (b *cappedBuffer) encode() (string, string) {
+	data := b.buf.Bytes()
+	if !b.truncated() {
+		return encodeOutput(data)
+	}
+	if utf8.Valid(data) {
+		return b.marked(data), ""
+	}
+	// the text cut in the middle of a rune is still text.
+	for i := len(data) - 1; i >= 0 && i > len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) && utf8.Valid(data[:i]) {
+				return b.marked(data[:i]), ""
+			}
+			break
+		}
+	}
+	return encodeOutput(data)
+}
+
+// marked returns the kept text of the truncated output with the marker of the discarded bytes.
+func (b *cappedBuffer) marked(text []byte) string {
+	return fmt.Sprintf("%s\n... [truncated, %d of %d bytes omitted]", text, b.total-int64(len(text)), b.total)
+}
+
+// storedOutput is a command output kept as a resource with its detected content type.
+type storedOutput struct {
+	data        []byte
+	contentType string
+}
+
+// outputStore keeps the outputs of the commands of each client session in memory, so that the large or binary
+// outputs are read as the resources instead of being inlined in the tool results. The outputs of a session are not
+// visible to the other sessions, and released once the session is closed.
+type outputStore struct {
+	mu sync.Mutex
+	// sessions are the outputs by the session id.
+	sessions map[string]*sessionOutputs
+}
+
+// sessionOutputs are the outputs of a session.
+type sessionOutputs struct {
+	outputs map[string]*storedOutput
+	// names are the names of the outputs, the oldest first.
+	names []string
+	size  int
+}
+
+func newOutputStore() *outputStore {
+	return &outputStore{sessions: make(map[string]*sessionOutputs)}
+}
+
+// put keeps the output of the session and returns the URI of its resource, the oldest outputs of the session are
+// evicted once the number or the total bytes of them exceed the limits, while the latest one is always kept.
+func (s *outputStore) put(session, prefix string, data []byte, contentType string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outputs, ok := s.sessions[session]
+	if !ok {
+		outputs = &sessionOutputs{outputs: make(map[string]*storedOutput)}
+		s.sessions[session] = outputs
+	}
+	name := prefix + "-" + utilrand.String(8)
+	outputs.names = append(outputs.names, name)
+	outputs.outputs[name] = &storedOutput{data: data, contentType: contentType}
+	outputs.size += len(data)
+	for len(outputs.names) > maxOutputs || (outputs.size > maxOutputsBytes && len(outputs.names) > 1) {
+		outputs.size -= len(outputs.outputs[outputs.names[0]].data)
+		delete(outputs.outputs, outputs.names[0])
+		outputs.names = outputs.names[1:]
+	}
+	return koffeemcp.ResourceScheme + "://" + koffeemcp.OutputsCollection + "/" + name
+}
+
+func (s *outputStore) get(session, name string) (*storedOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var output *storedOutput
+	if outputs, ok := s.sessions[session]; ok {
+		output = outputs.outputs[name]
+	}
+	if output == nil {
+		return nil, fmt.Errorf("output %q not found in this session, the outputs are kept in memory and the oldest ones are evicted", name)
+	}
+	return output, nil
+}
+
+// remove releases the outputs of the session.
+func (s *outputStore) remove(session string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, session)
+}
+
+// encodeOutput returns the output as it is if it's valid UTF-8, otherwise encoded in base64 with the encoding.
+func encodeOutput(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64"
+}
+
+// detectContentType returns the content type of the output, e.g. application/gzip, the unrecognized binary
+// output is application/octet-stream.
+func detectContentType(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCappedBuffer(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int64
+		writes        []string
+		want          string
+		wantTruncated bool
+		wantString    string
+	}{
+		{name: "within the limit", limit: 10, writes: []string{"abc", "def"}, want: "abcdef", wantString: "abcdef"},
+		{name: "exactly the limit", limit: 6, writes: []string{"abc", "def"}, want: "abcdef", wantString: "abcdef"},
+		{
+			name:          "truncated in a write",
+			limit:         4,
+			writes:        []string{"abc", "def"},
+			want:          "abcd",
+			wantTruncated: true,
+			wantString:    "abcd\n... [truncated, 2 of 6 bytes omitted]",
+		},
+		{
+			name:          "writes after the limit",
+			limit:         3,
+			writes:        []string{"abc", "def", "ghi"},
+			want:          "abc",
+			wantTruncated: true,
+			wantString:    "abc\n... [truncated, 6 of 9 bytes omitted]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCappedBuffer(tt.limit)
+			for _, w := range tt.writes {
+				// the discarded bytes are reported as written, so the command output keeps streaming.
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("got %d, %v, want %d", n, err, len(w))
+				}
+			}
+			if string(b.Bytes()) != tt.want || b.truncated() != tt.wantTruncated || b.String() != tt.wantString {
+				t.Fatalf("got %q truncated %v string %q, want %q truncated %v string %q", b.Bytes(), b.truncated(), b.String(), tt.want, tt.wantTruncated, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestOutputStoreEviction(t *testing.T) {
+	tests := []struct {
+		name      string
+		sizes     []int
+		wantKept  int
+		wantBytes int
+	}{
+		{name: "within the limits", sizes: []int{1, 2, 3}, wantKept: 3, wantBytes: 6},
+		{name: "evicted by the number", sizes: make([]int, maxOutputs+5), wantKept: maxOutputs},
+		{name: "evicted by the bytes", sizes: []int{maxOutputsBytes / 2, maxOutputsBytes / 2, 1}, wantKept: 2, wantBytes: maxOutputsBytes/2 + 1},
+		{name: "the latest output is kept", sizes: []int{1, maxOutputsBytes + 1}, wantKept: 1, wantBytes: maxOutputsBytes + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newOutputStore()
+			var uris []string
+			for _, size := range tt.sizes {
+				uris = append(uris, s.put("a", "pod", bytes.Repeat([]byte("x"), size), ""))
+			}
+			outputs := s.sessions["a"]
+			if len(outputs.names) != tt.wantKept || len(outputs.outputs) != tt.wantKept || outputs.size != tt.wantBytes {
+				t.Fatalf("got %d outputs of %d bytes, want %d of %d bytes", len(outputs.outputs), outputs.size, tt.wantKept, tt.wantBytes)
+			}
+			latest := uris[len(uris)-1]
+			if _, err := s.get("a", latest[strings.LastIndex(latest, "/")+1:]); err != nil {
+				t.Fatalf("the latest output is evicted: %v", err)
+			}
+		})
+	}
+}
+
+func TestOutputStoreSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		session string
+		removed bool
+		wantErr bool
+	}{
+		{name: "same session", session: "a"},
+		{name: "another session", session: "b", wantErr: true},
+		{name: "session closed", session: "a", removed: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newOutputStore()
+			uri := s.put("a", "pod", []byte("data"), "")
+			if tt.removed {
+				s.remove("a")
+			}
+			_, err := s.get(tt.session, uri[strings.LastIndex(uri, "/")+1:])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCappedBufferEncode(t *testing.T) {
+	tests := []struct {
+		name         string
+		limit        int64
+		data         []byte
+		want         string
+		wantEncoding string
+	}{
+		{name: "text", limit: 10, data: []byte("héllo"), want: "héllo"},
+		{name: "truncated text", limit: 3, data: []byte("hello"), want: "hel\n... [truncated, 2 of 5 bytes omitted]"},
+		{name: "text cut in a rune", limit: 2, data: []byte("héllo"), want: "h\n... [truncated, 5 of 6 bytes omitted]"},
+		{name: "binary", limit: 10, data: []byte{0xff, 0xfe, 0x00}, want: "//4A", wantEncoding: "base64"},
+		{name: "truncated binary", limit: 4, data: []byte{0x1f, 0x8b, 0xff, 0xfe, 0x00, 0x01}, want: "H4v//g==", wantEncoding: "base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCappedBuffer(tt.limit)
+			_, _ = b.Write(tt.data)
+			got, encoding := b.encode()
+			if got != tt.want || encoding != tt.wantEncoding {
+				t.Fatalf("got %q encoded %q, want %q encoded %q", got, encoding, tt.want, tt.wantEncoding)
+			}
+		})
+	}
+}
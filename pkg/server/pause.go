@@ -0,0 +1,262 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	// pausedReplicasAnnotation records the replicas of the workload before it is paused by scaling to zero.
+	pausedReplicasAnnotation = "koffee.cola.io/paused-replicas"
+	// pausedTargetAnnotation marks the HPA whose target is paused, the HPA stops scaling while its target has zero
+	// replicas and resumes once the target is scaled up.
+	pausedTargetAnnotation = "koffee.cola.io/paused-target"
+)
+
+// scalableWorkloads are the workload kinds paused and resumed by scaling the replicas.
+var scalableWorkloads = map[string]schema.GroupVersionResource{
+	"Deployment":  {Group: "apps", Version: "v1", Resource: "deployments"},
+	"StatefulSet": {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"ReplicaSet":  {Group: "apps", Version: "v1", Resource: "replicasets"},
+}
+
+// WorkloadPause is the result of pausing or resuming a workload, the replicas are the recorded replicas when paused
+// and the restored replicas when resumed.
+type WorkloadPause struct {
+	Kind      string           `json:"kind"`
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Paused    bool             `json:"paused"`
+	Replicas  int32            `json:"replicas"`
+	DryRun    bool             `json:"dryRun,omitempty"`
+	HPAs      []string         `json:"hpas,omitempty"`
+	Rollout   *WorkloadRollout `json:"rollout,omitempty"`
+	Message   string           `json:"message,omitempty"`
+}
+
+func (s *Server) PauseWorkload() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ri, obj, pause, err := s.getScalableWorkload(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Pausing workload", "kind", pause.Kind, "name", pause.Name, "namespace", pause.Namespace, "dryRun", pause.DryRun)
+
+		if recorded, ok := obj.GetAnnotations()[pausedReplicasAnnotation]; ok {
+			replicas, _ := strconv.ParseInt(recorded, 10, 32)
+			pause.Paused, pause.Replicas = true, int32(replicas)
+			pause.Message = fmt.Sprintf("%s %s is already paused", pause.Kind, pause.Name)
+			return newWorkloadPauseResult(pause)
+		}
+		replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			replicas = 1
+		}
+		if replicas == 0 {
+			return nil, fmt.Errorf("%s %s is already scaled to zero, there are no replicas to record", pause.Kind, pause.Name)
+		}
+		pause.Replicas = int32(replicas)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		hpas, err := workloadHPAs(ctx, cli, pause.Kind, pause.Namespace, pause.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// the resource version guards the recorded replicas against the concurrent scaling.
+		patch, err := json.Marshal(map[string]any{
+			"metadata": map[string]any{
+				"resourceVersion": obj.GetResourceVersion(),
+				"annotations":     map[string]any{pausedReplicasAnnotation: strconv.FormatInt(replicas, 10)},
+			},
+			"spec": map[string]any{"replicas": 0},
+		})
+		if err != nil {
+			return nil, err
+		}
+		options := metav1.PatchOptions{FieldManager: applyFieldManager}
+		if pause.DryRun {
+			options.DryRun = []string{metav1.DryRunAll}
+		}
+		if _, err = ri.Patch(ctx, pause.Name, types.MergePatchType, patch, options); err != nil {
+			return nil, fmt.Errorf("failed to scale %s to zero: %w", pause.Kind, err)
+		}
+		pause.Paused = true
+
+		for _, hpa := range hpas {
+			pause.HPAs = append(pause.HPAs, hpa.Name)
+			if err = annotateHPA(ctx, cli, hpa, fmt.Sprintf("%s/%s", pause.Kind, pause.Name), options); err != nil {
+				return nil, err
+			}
+		}
+		if len(hpas) > 0 {
+			pause.Message = "the HPAs stop scaling while the target has zero replicas"
+		}
+		return newWorkloadPauseResult(pause)
+	}
+}
+
+func (s *Server) ResumeWorkload() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ri, obj, pause, err := s.getScalableWorkload(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		waitForRollout := req.GetBool("wait", false)
+		waitTimeout := waitTimeoutOf(ctx, req, defaultRolloutWaitTimeout)
+
+		slog.Info("Resuming workload", "kind", pause.Kind, "name", pause.Name, "namespace", pause.Namespace, "dryRun", pause.DryRun)
+
+		recorded, ok := obj.GetAnnotations()[pausedReplicasAnnotation]
+		if !ok {
+			return nil, fmt.Errorf("%s %s is not paused, the %s annotation is not found", pause.Kind, pause.Name, pausedReplicasAnnotation)
+		}
+		replicas, err := strconv.ParseInt(recorded, 10, 32)
+		if err != nil || replicas <= 0 {
+			return nil, fmt.Errorf("invalid %s annotation %q of %s %s", pausedReplicasAnnotation, recorded, pause.Kind, pause.Name)
+		}
+		pause.Replicas = int32(replicas)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		hpas, err := workloadHPAs(ctx, cli, pause.Kind, pause.Namespace, pause.Name)
+		if err != nil {
+			return nil, err
+		}
+		// the HPA bounds may be changed while paused, the restored replicas are kept within them.
+		for _, hpa := range hpas {
+			pause.HPAs = append(pause.HPAs, hpa.Name)
+			pause.Replicas = max(pause.Replicas, ptr.Deref(hpa.Spec.MinReplicas, 1))
+			pause.Replicas = min(pause.Replicas, hpa.Spec.MaxReplicas)
+		}
+
+		patch, err := json.Marshal(map[string]any{
+			"metadata": map[string]any{
+				"resourceVersion": obj.GetResourceVersion(),
+				"annotations":     map[string]any{pausedReplicasAnnotation: nil},
+			},
+			"spec": map[string]any{"replicas": pause.Replicas},
+		})
+		if err != nil {
+			return nil, err
+		}
+		options := metav1.PatchOptions{FieldManager: applyFieldManager}
+		if pause.DryRun {
+			options.DryRun = []string{metav1.DryRunAll}
+		}
+		if _, err = ri.Patch(ctx, pause.Name, types.MergePatchType, patch, options); err != nil {
+			return nil, fmt.Errorf("failed to scale %s: %w", pause.Kind, err)
+		}
+		for _, hpa := range hpas {
+			if _, ok := hpa.Annotations[pausedTargetAnnotation]; !ok {
+				continue
+			}
+			if err = annotateHPA(ctx, cli, hpa, nil, options); err != nil {
+				return nil, err
+			}
+		}
+		if pause.DryRun || !waitForRollout {
+			return newWorkloadPauseResult(pause)
+		}
+
+		if pause.Rollout, pause.Message, err = waitWorkloadRollout(ctx, cli, pause.Kind, pause.Namespace, pause.Name, waitTimeout); err != nil {
+			return nil, err
+		}
+		return newWorkloadPauseResult(pause)
+	}
+}
+
+// getScalableWorkload gets the workload of the pause and resume tools, and returns the result filled with the
+// workload reference.
+func (s *Server) getScalableWorkload(ctx context.Context, req mcp.CallToolRequest) (dynamic.ResourceInterface, *unstructured.Unstructured, *WorkloadPause, error) {
+	resourceName, err := req.RequireString("name")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	kind, name, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gvr, ok := scalableWorkloads[kind]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unsupported workload kind %q, must be one of Deployment, StatefulSet or ReplicaSet", kind)
+	}
+	namespace := req.GetString("namespace", "")
+	if len(namespace) == 0 {
+		namespace = s.defaultNamespace(ctx)
+	}
+
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ri := dynamicClient.Resource(gvr).Namespace(namespace)
+	obj, err := ri.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get %s: %w", kind, err)
+	}
+	return ri, obj, &WorkloadPause{Kind: kind, Name: name, Namespace: namespace, DryRun: req.GetBool("dryRun", false)}, nil
+}
+
+// workloadHPAs returns the HorizontalPodAutoscalers scaling the workload.
+func workloadHPAs(ctx context.Context, cli kubernetes.Interface, kind, namespace, name string) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	list, err := cli.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hpas: %w", err)
+	}
+	var hpas []autoscalingv2.HorizontalPodAutoscaler
+	for _, hpa := range list.Items {
+		ref := hpa.Spec.ScaleTargetRef
+		gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+		if ref.Kind == kind && ref.Name == name && gv.Group == "apps" {
+			hpas = append(hpas, hpa)
+		}
+	}
+	return hpas, nil
+}
+
+// annotateHPA sets the paused target annotation of the HPA, the nil target removes it.
+func annotateHPA(ctx context.Context, cli kubernetes.Interface, hpa autoscalingv2.HorizontalPodAutoscaler, target any, options metav1.PatchOptions) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{pausedTargetAnnotation: target}},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = cli.AutoscalingV2().HorizontalPodAutoscalers(hpa.Namespace).Patch(ctx, hpa.Name, types.MergePatchType, patch, options); err != nil {
+		return fmt.Errorf("failed to annotate hpa %s: %w", hpa.Name, err)
+	}
+	return nil
+}
+
+func newWorkloadPauseResult(pause *WorkloadPause) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(pause)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
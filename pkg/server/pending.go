@@ -0,0 +1,403 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// NodeExclusion is the reasons why the pod can not be scheduled to the node.
+type NodeExclusion struct {
+	Node    string   `json:"node"`
+	Reasons []string `json:"reasons"`
+}
+
+// PendingReport explains why the pod is not scheduled.
+type PendingReport struct {
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace"`
+	Phase     string `json:"phase"`
+	Node      string `json:"node,omitempty"`
+	// SchedulerMessage is the message of the PodScheduled condition set by the scheduler.
+	SchedulerMessage string            `json:"schedulerMessage,omitempty"`
+	Requests         map[string]string `json:"requests,omitempty"`
+	Volumes          []CheckResult     `json:"volumes,omitempty"`
+	// Summary is the number of the excluded nodes per reason, like the failure message of the scheduler.
+	Summary       map[string]int  `json:"summary,omitempty"`
+	Nodes         []NodeExclusion `json:"nodes,omitempty"`
+	FeasibleNodes []string        `json:"feasibleNodes,omitempty"`
+	Events        []EventSummary  `json:"events,omitempty"`
+	// Notes are the constraints which are not evaluated by the tool, e.g. the inter-pod affinity.
+	Notes []string `json:"notes,omitempty"`
+}
+
+func (s *Server) WhyPending() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Explaining pending pod", "name", name, "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pod, err := cli.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod: %w", err)
+		}
+
+		report := &PendingReport{
+			Pod:       pod.Name,
+			Namespace: pod.Namespace,
+			Phase:     string(pod.Status.Phase),
+			Node:      pod.Spec.NodeName,
+			Summary:   make(map[string]int),
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodScheduled && c.Status != corev1.ConditionTrue {
+				report.SchedulerMessage = c.Message
+			}
+		}
+		if report.Events, err = listObjectEvents(ctx, cli, namespace, "Pod", name); err != nil {
+			slog.Warn("Failed to list pod events", "name", name, "namespace", namespace, "err", err)
+		}
+		if len(pod.Spec.NodeName) > 0 {
+			report.Notes = append(report.Notes, fmt.Sprintf("the pod is already scheduled to the node %s", pod.Spec.NodeName))
+			return pendingResult(report)
+		}
+
+		requests := podRequests(pod)
+		report.Requests = make(map[string]string, len(requests))
+		for name, q := range requests {
+			report.Requests[string(name)] = q.String()
+		}
+
+		volumeNodeAffinities, err := checkPodVolumes(ctx, cli, pod, report)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		allocated, err := allocatedResources(ctx, cli)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range nodes.Items {
+			node := &nodes.Items[i]
+			reasons := nodeExclusionReasons(pod, node, requests, allocated[node.Name], volumeNodeAffinities)
+			if len(reasons) == 0 {
+				report.FeasibleNodes = append(report.FeasibleNodes, node.Name)
+				continue
+			}
+			report.Nodes = append(report.Nodes, NodeExclusion{Node: node.Name, Reasons: reasons})
+			categories := make(map[string]bool)
+			for _, reason := range reasons {
+				category, _, _ := strings.Cut(reason, ":")
+				categories[category] = true
+			}
+			for category := range categories {
+				report.Summary[category]++
+			}
+		}
+
+		if affinity := pod.Spec.Affinity; affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil) {
+			report.Notes = append(report.Notes, "the inter-pod affinity and anti-affinity are not evaluated, check the scheduler message and events")
+		}
+		if len(pod.Spec.TopologySpreadConstraints) > 0 {
+			report.Notes = append(report.Notes, "the topology spread constraints are not evaluated, check the scheduler message and events")
+		}
+		if len(report.FeasibleNodes) > 0 && len(report.SchedulerMessage) == 0 {
+			report.Notes = append(report.Notes, "some nodes fit the pod, it may be waiting for the scheduler or a scheduling gate")
+		}
+		for _, gate := range pod.Spec.SchedulingGates {
+			report.Notes = append(report.Notes, fmt.Sprintf("the pod is blocked by the scheduling gate %s", gate.Name))
+		}
+		return pendingResult(report)
+	}
+}
+
+func pendingResult(report *PendingReport) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+// nodeExclusionReasons returns the reasons why the pod does not fit the node, each reason is prefixed with its
+// category followed by a colon.
+func nodeExclusionReasons(pod *corev1.Pod, node *corev1.Node, requests, allocated corev1.ResourceList, volumeNodeAffinities []*corev1.NodeSelector) []string {
+	var reasons []string
+	if node.Spec.Unschedulable && !tolerates(pod.Spec.Tolerations, &corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}) {
+		reasons = append(reasons, "unschedulable: the node is cordoned")
+	}
+	for i := range node.Status.Conditions {
+		if c := node.Status.Conditions[i]; c.Type == corev1.NodeReady && c.Status != corev1.ConditionTrue {
+			reasons = append(reasons, fmt.Sprintf("not ready: the node is not ready (%s)", c.Reason))
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		// the cordoned nodes are reported as unschedulable above.
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule || taint.Key == corev1.TaintNodeUnschedulable {
+			continue
+		}
+		if !tolerates(pod.Spec.Tolerations, taint) {
+			reasons = append(reasons, fmt.Sprintf("untolerated taint: %s", taint.ToString()))
+		}
+	}
+
+	if len(pod.Spec.NodeSelector) > 0 && !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		reasons = append(reasons, fmt.Sprintf("node selector: the node labels do not match %s", labels.FormatLabels(pod.Spec.NodeSelector)))
+	}
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if !matchNodeSelector(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, node) {
+			reasons = append(reasons, "node affinity: the node does not match the required node affinity")
+		}
+	}
+	for _, selector := range volumeNodeAffinities {
+		if !matchNodeSelector(selector, node) {
+			reasons = append(reasons, "volume node affinity: the node does not match the node affinity of a bound volume")
+			break
+		}
+	}
+
+	names := make([]corev1.ResourceName, 0, len(requests))
+	for name := range requests {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		request := requests[name]
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("insufficient %s: the node has no %s", name, name))
+			continue
+		}
+		free := allocatable.DeepCopy()
+		if used, ok := allocated[name]; ok {
+			free.Sub(used)
+		}
+		if free.Cmp(request) < 0 {
+			reasons = append(reasons, fmt.Sprintf("insufficient %s: requested %s, free %s of allocatable %s", name, request.String(), free.String(), allocatable.String()))
+		}
+	}
+	if maxPods, ok := node.Status.Allocatable[corev1.ResourcePods]; ok {
+		if used := allocated[corev1.ResourcePods]; used.Cmp(maxPods) >= 0 {
+			reasons = append(reasons, fmt.Sprintf("too many pods: the node runs %s of the maximum %s pods", used.String(), maxPods.String()))
+		}
+	}
+	return reasons
+}
+
+// checkPodVolumes checks the claims of the pod, and returns the node affinities of the bound volumes which
+// restrict the nodes of the pod.
+func checkPodVolumes(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod, report *PendingReport) ([]*corev1.NodeSelector, error) {
+	var affinities []*corev1.NodeSelector
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim == nil {
+			continue
+		}
+		claim := v.PersistentVolumeClaim.ClaimName
+		pvc, err := cli.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, claim, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			report.Volumes = append(report.Volumes, CheckResult{Name: claim, Passed: false, Message: "the claim does not exist"})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistentvolumeclaim: %w", err)
+		}
+
+		if pvc.Status.Phase != corev1.ClaimBound {
+			// the claims of the WaitForFirstConsumer classes are bound after the pod is scheduled.
+			message := fmt.Sprintf("the claim is %s, use diagnose_storage for details", pvc.Status.Phase)
+			passed := false
+			if className := ptr.Deref(pvc.Spec.StorageClassName, ""); len(className) > 0 {
+				class, err := cli.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+				if err == nil && ptr.Deref(class.VolumeBindingMode, storagev1.VolumeBindingImmediate) == storagev1.VolumeBindingWaitForFirstConsumer {
+					message, passed = "the claim waits for the pod to be scheduled", true
+				}
+			}
+			report.Volumes = append(report.Volumes, CheckResult{Name: claim, Passed: passed, Message: message})
+			continue
+		}
+
+		pv, err := cli.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			report.Volumes = append(report.Volumes, CheckResult{Name: claim, Passed: false, Message: fmt.Sprintf("failed to get the volume %s: %v", pvc.Spec.VolumeName, err)})
+			continue
+		}
+		report.Volumes = append(report.Volumes, CheckResult{Name: claim, Passed: true, Message: fmt.Sprintf("the claim is bound to the volume %s", pv.Name)})
+		if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+			affinities = append(affinities, pv.Spec.NodeAffinity.Required)
+		}
+	}
+	return affinities, nil
+}
+
+// allocatedResources returns the requests of the non-terminal pods per node, the pods are counted as the pods
+// resource.
+func allocatedResources(ctx context.Context, cli kubernetes.Interface) (map[string]corev1.ResourceList, error) {
+	selector := fields.AndSelectors(
+		fields.OneTermNotEqualSelector("spec.nodeName", ""),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+	)
+	pods, err := cli.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	allocated := make(map[string]corev1.ResourceList)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		list, ok := allocated[pod.Spec.NodeName]
+		if !ok {
+			list = corev1.ResourceList{}
+			allocated[pod.Spec.NodeName] = list
+		}
+		for name, q := range podRequests(pod) {
+			addQuantity(list, name, q)
+		}
+		addQuantity(list, corev1.ResourcePods, *resource.NewQuantity(1, resource.DecimalSI))
+	}
+	return allocated, nil
+}
+
+// podRequests returns the effective requests of the pod like the scheduler, i.e. the larger of the sum of the
+// containers and the maximum of the init containers, plus the pod overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := podResources(pod, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Requests })
+	for name, q := range pod.Spec.Overhead {
+		addQuantity(requests, name, q)
+	}
+	return requests
+}
+
+// podLimits returns the effective limits of the pod calculated the same way as the requests, the pod overhead is
+// only added to the limited resources.
+func podLimits(pod *corev1.Pod) corev1.ResourceList {
+	limits := podResources(pod, func(r corev1.ResourceRequirements) corev1.ResourceList { return r.Limits })
+	for name, q := range pod.Spec.Overhead {
+		if _, ok := limits[name]; ok {
+			addQuantity(limits, name, q)
+		}
+	}
+	return limits
+}
+
+func podResources(pod *corev1.Pod, get func(corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		for name, q := range get(c.Resources) {
+			addQuantity(result, name, q)
+		}
+	}
+	// the sidecar init containers keep running, so they are added to the resources of the regular containers.
+	sidecars := corev1.ResourceList{}
+	for _, c := range pod.Spec.InitContainers {
+		if ptr.Deref(c.RestartPolicy, "") == corev1.ContainerRestartPolicyAlways {
+			for name, q := range get(c.Resources) {
+				addQuantity(sidecars, name, q)
+			}
+			continue
+		}
+		for name, q := range get(c.Resources) {
+			init := q.DeepCopy()
+			if sidecar, ok := sidecars[name]; ok {
+				init.Add(sidecar)
+			}
+			if current, ok := result[name]; !ok || current.Cmp(init) < 0 {
+				result[name] = init
+			}
+		}
+	}
+	for name, q := range sidecars {
+		addQuantity(result, name, q)
+	}
+	return result
+}
+
+func tolerates(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	return slices.ContainsFunc(tolerations, func(t corev1.Toleration) bool {
+		return t.ToleratesTaint(taint)
+	})
+}
+
+// matchNodeSelector reports whether the node matches any of the terms of the node selector.
+func matchNodeSelector(selector *corev1.NodeSelector, node *corev1.Node) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		if matchRequirements(term.MatchExpressions, node.Labels) && matchRequirements(term.MatchFields, map[string]string{"metadata.name": node.Name}) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRequirements(requirements []corev1.NodeSelectorRequirement, values map[string]string) bool {
+	for _, r := range requirements {
+		value, exists := values[r.Key]
+		switch r.Operator {
+		case corev1.NodeSelectorOpIn:
+			if !exists || !slices.Contains(r.Values, value) {
+				return false
+			}
+		case corev1.NodeSelectorOpNotIn:
+			if exists && slices.Contains(r.Values, value) {
+				return false
+			}
+		case corev1.NodeSelectorOpExists:
+			if !exists {
+				return false
+			}
+		case corev1.NodeSelectorOpDoesNotExist:
+			if exists {
+				return false
+			}
+		case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+			if !exists || len(r.Values) != 1 {
+				return false
+			}
+			actual, err1 := strconv.ParseInt(value, 10, 64)
+			expected, err2 := strconv.ParseInt(r.Values[0], 10, 64)
+			if err1 != nil || err2 != nil {
+				return false
+			}
+			if (r.Operator == corev1.NodeSelectorOpGt && actual <= expected) || (r.Operator == corev1.NodeSelectorOpLt && actual >= expected) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
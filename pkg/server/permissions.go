@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// SubjectPermissions is the effective permissions of a subject aggregated from all the roles bound to it, each
+// permission is the verbs allowed on a resource in the namespaces, `*` for all the namespaces.
+type SubjectPermissions struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+	// Bindings are the bindings granting the permissions to the subject.
+	Bindings        []string                `json:"bindings"`
+	Permissions     []ResourcePermission    `json:"permissions"`
+	NonResourceURLs []NonResourcePermission `json:"nonResourceURLs,omitempty"`
+	// MissingRoles are the roles referenced by the bindings but not found, they grant nothing.
+	MissingRoles []string `json:"missingRoles,omitempty"`
+}
+
+// ResourcePermission is a row of the permission matrix, the resource names restrict the verbs to the named objects.
+type ResourcePermission struct {
+	APIGroup      string   `json:"apiGroup"`
+	Resource      string   `json:"resource"`
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	Verbs         []string `json:"verbs"`
+	Namespaces    []string `json:"namespaces"`
+}
+
+// NonResourcePermission is the verbs allowed on a non-resource URL like /healthz or /metrics.
+type NonResourcePermission struct {
+	URL   string   `json:"url"`
+	Verbs []string `json:"verbs"`
+}
+
+// allAuthenticatedGroup is the group of all the authenticated users including the ServiceAccounts.
+const allAuthenticatedGroup = "system:authenticated"
+
+// rbacSubject is the user name and groups of a subject as authenticated by the API server.
+type rbacSubject struct {
+	user   string
+	groups []string
+	// namespace and name of the ServiceAccount subject.
+	namespace string
+	name      string
+}
+
+func (r *rbacSubject) matches(subject rbacv1.Subject, bindingNamespace string) bool {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		return len(r.user) > 0 && subject.Name == r.user
+	case rbacv1.GroupKind:
+		return slices.Contains(r.groups, subject.Name)
+	case rbacv1.ServiceAccountKind:
+		subjectNamespace := subject.Namespace
+		if len(subjectNamespace) == 0 {
+			subjectNamespace = bindingNamespace
+		}
+		return len(r.name) > 0 && subject.Name == r.name && subjectNamespace == r.namespace
+	}
+	return false
+}
+
+func (s *Server) SubjectPermissions() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		groups := req.GetStringSlice("groups", nil)
+		scope := req.GetString("scope", "")
+
+		subject := &rbacSubject{}
+		switch kind {
+		case rbacv1.UserKind:
+			subject.user = name
+			subject.groups = append(slices.Clone(groups), allAuthenticatedGroup)
+			namespace = ""
+		case rbacv1.GroupKind:
+			subject.groups = []string{name}
+			namespace, groups = "", nil
+		case rbacv1.ServiceAccountKind:
+			if len(namespace) == 0 {
+				namespace = s.defaultNamespace(ctx)
+			}
+			subject.user = "system:serviceaccount:" + namespace + ":" + name
+			subject.groups = []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, allAuthenticatedGroup}
+			subject.namespace, subject.name = namespace, name
+			groups = subject.groups
+		default:
+			return nil, fmt.Errorf("unsupported subject kind %q, must be one of User, Group or ServiceAccount", kind)
+		}
+
+		slog.Info("Computing subject permissions", "kind", kind, "name", name, "namespace", namespace, "groups", groups, "scope", scope)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		index, err := loadRBACIndex(ctx, cli, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions := &SubjectPermissions{
+			Kind:        kind,
+			Name:        name,
+			Namespace:   namespace,
+			Groups:      groups,
+			Bindings:    make([]string, 0),
+			Permissions: make([]ResourcePermission, 0),
+		}
+		matrix := newPermissionMatrix()
+		grant := func(binding, bindingNamespace string, ref rbacv1.RoleRef) {
+			rules, ok := index.roleRules(bindingNamespace, ref)
+			if !ok {
+				role := ref.Kind + "/" + ref.Name
+				if len(bindingNamespace) > 0 && ref.Kind == "Role" {
+					role = ref.Kind + "/" + bindingNamespace + "/" + ref.Name
+				}
+				if !slices.Contains(permissions.MissingRoles, role) {
+					permissions.MissingRoles = append(permissions.MissingRoles, role)
+				}
+				return
+			}
+			permissions.Bindings = append(permissions.Bindings, binding)
+			ruleNamespace := bindingNamespace
+			if len(ruleNamespace) == 0 {
+				ruleNamespace = "*"
+			}
+			for _, rule := range rules {
+				matrix.add(rule, ruleNamespace)
+			}
+		}
+		for i := range index.clusterRoleBindings {
+			crb := &index.clusterRoleBindings[i]
+			if slices.ContainsFunc(crb.Subjects, func(s rbacv1.Subject) bool { return subject.matches(s, "") }) {
+				grant("ClusterRoleBinding/"+crb.Name, "", crb.RoleRef)
+			}
+		}
+		for i := range index.roleBindings {
+			rb := &index.roleBindings[i]
+			if slices.ContainsFunc(rb.Subjects, func(s rbacv1.Subject) bool { return subject.matches(s, rb.Namespace) }) {
+				grant("RoleBinding/"+rb.Namespace+"/"+rb.Name, rb.Namespace, rb.RoleRef)
+			}
+		}
+		permissions.Permissions, permissions.NonResourceURLs = matrix.rows()
+
+		resp, err := json.Marshal(permissions)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// permissionKey is a cell of the permission matrix, the resource names are joined by commas.
+type permissionKey struct {
+	apiGroup      string
+	resource      string
+	resourceNames string
+}
+
+// permissionMatrix aggregates the verbs and namespaces of the rules by the resources.
+type permissionMatrix struct {
+	resources    map[permissionKey]*ResourcePermission
+	nonResources map[string]*NonResourcePermission
+}
+
+func newPermissionMatrix() *permissionMatrix {
+	return &permissionMatrix{resources: make(map[permissionKey]*ResourcePermission), nonResources: make(map[string]*NonResourcePermission)}
+}
+
+func (m *permissionMatrix) add(rule rbacv1.PolicyRule, namespace string) {
+	// the non-resource URLs are only granted by the ClusterRoleBindings.
+	for _, url := range rule.NonResourceURLs {
+		if namespace != "*" {
+			break
+		}
+		permission, ok := m.nonResources[url]
+		if !ok {
+			permission = &NonResourcePermission{URL: url}
+			m.nonResources[url] = permission
+		}
+		permission.Verbs = mergeStrings(permission.Verbs, rule.Verbs)
+	}
+	resourceNames := slices.Sorted(slices.Values(rule.ResourceNames))
+	for _, apiGroup := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			key := permissionKey{apiGroup: apiGroup, resource: resource, resourceNames: strings.Join(resourceNames, ",")}
+			permission, ok := m.resources[key]
+			if !ok {
+				permission = &ResourcePermission{APIGroup: apiGroup, Resource: resource, ResourceNames: resourceNames}
+				m.resources[key] = permission
+			}
+			permission.Verbs = mergeStrings(permission.Verbs, rule.Verbs)
+			permission.Namespaces = mergeStrings(permission.Namespaces, []string{namespace})
+		}
+	}
+}
+
+// rows returns the permissions sorted by the API groups and resources.
+func (m *permissionMatrix) rows() ([]ResourcePermission, []NonResourcePermission) {
+	resources := make([]ResourcePermission, 0, len(m.resources))
+	for _, permission := range m.resources {
+		resources = append(resources, *permission)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].APIGroup != resources[j].APIGroup {
+			return resources[i].APIGroup < resources[j].APIGroup
+		}
+		if resources[i].Resource != resources[j].Resource {
+			return resources[i].Resource < resources[j].Resource
+		}
+		return strings.Join(resources[i].ResourceNames, ",") < strings.Join(resources[j].ResourceNames, ",")
+	})
+	var nonResources []NonResourcePermission
+	for _, permission := range m.nonResources {
+		nonResources = append(nonResources, *permission)
+	}
+	sort.Slice(nonResources, func(i, j int) bool { return nonResources[i].URL < nonResources[j].URL })
+	return resources, nonResources
+}
+
+// mergeStrings returns the sorted union of the values, the wildcard subsumes all the other values.
+func mergeStrings(values, added []string) []string {
+	if slices.Contains(values, "*") {
+		return values
+	}
+	if slices.Contains(added, "*") {
+		return []string{"*"}
+	}
+	for _, value := range added {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
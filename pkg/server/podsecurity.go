@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"cola.io/koffee/pkg/audit"
+)
+
+// the labels of the namespace configuring the PodSecurity admission.
+const (
+	podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	podSecurityAuditLabel   = "pod-security.kubernetes.io/audit"
+	podSecurityWarnLabel    = "pod-security.kubernetes.io/warn"
+)
+
+// PodSecurityReport is the evaluation of the pod specs against the Pod Security Standards levels of the namespace,
+// only the workloads violating any of the levels are listed.
+type PodSecurityReport struct {
+	Namespace string      `json:"namespace"`
+	Enforce   audit.Level `json:"enforce"`
+	Audit     audit.Level `json:"audit"`
+	Warn      audit.Level `json:"warn"`
+	// Override is true if the enforced level is specified instead of read from the namespace labels.
+	Override   bool                `json:"override,omitempty"`
+	Workloads  int                 `json:"workloads"`
+	Passed     int                 `json:"passed"`
+	Violations []PodSecurityResult `json:"violations,omitempty"`
+	Warnings   []string            `json:"warnings,omitempty"`
+}
+
+// PodSecurityResult is the violations of a workload, the pods are rejected by the enforced level, and only annotated
+// in the audit log or warned to the user by the audit and warn levels.
+type PodSecurityResult struct {
+	Workload string          `json:"workload"`
+	Enforce  []audit.Finding `json:"enforce,omitempty"`
+	Audit    []string        `json:"audit,omitempty"`
+	Warn     []string        `json:"warn,omitempty"`
+}
+
+// podSecurityLevels sets the enforce, audit and warn levels of the report from the namespace labels, the missing or
+// invalid labels are privileged like the PodSecurity admission defaults.
+func podSecurityLevels(ns *corev1.Namespace, report *PodSecurityReport) {
+	level := func(label string) audit.Level {
+		value, ok := ns.Labels[label]
+		if !ok {
+			return audit.LevelPrivileged
+		}
+		l, err := audit.ParseLevel(value)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("label %s: %v", label, err))
+			return audit.LevelPrivileged
+		}
+		return l
+	}
+	report.Enforce, report.Audit, report.Warn = level(podSecurityEnforceLabel), level(podSecurityAuditLabel), level(podSecurityWarnLabel)
+}
+
+// manifestPodSpec returns the pod spec of the pod or the template of the workload, nil if the object has no pod spec.
+func manifestPodSpec(obj *unstructured.Unstructured) (*corev1.PodSpec, error) {
+	var fields []string
+	switch obj.GetKind() {
+	case "Pod":
+		fields = []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		fields = []string{"spec", "template", "spec"}
+	case "CronJob":
+		fields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case "PodTemplate":
+		fields = []string{"template", "spec"}
+	default:
+		return nil, nil
+	}
+	raw, found, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil || !found {
+		return nil, fmt.Errorf("failed to get pod spec of %s %s", obj.GetKind(), obj.GetName())
+	}
+	spec := &corev1.PodSpec{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(raw, spec); err != nil {
+		return nil, fmt.Errorf("failed to convert pod spec of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return spec, nil
+}
+
+func (s *Server) CheckPodSecurity() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		manifest := req.GetString("manifest", "")
+		override := req.GetString("level", "")
+
+		type podSpec struct {
+			workload string
+			spec     *corev1.PodSpec
+		}
+		var specs []podSpec
+		if len(manifest) > 0 {
+			objs, err := decodeManifests(manifest)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objs {
+				spec, err := manifestPodSpec(obj)
+				if err != nil {
+					return nil, err
+				}
+				if spec == nil {
+					continue
+				}
+				if len(namespace) == 0 {
+					namespace = obj.GetNamespace()
+				}
+				specs = append(specs, podSpec{workload: obj.GetKind() + "/" + obj.GetName(), spec: spec})
+			}
+			if len(specs) == 0 {
+				return nil, fmt.Errorf("no pod or workload found in the manifest")
+			}
+		}
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+
+		slog.Info("Checking pod security", "namespace", namespace, "manifest", len(manifest) > 0, "level", override)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		report := &PodSecurityReport{Namespace: namespace}
+		ns, err := cli.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			podSecurityLevels(ns, report)
+		case apierrors.IsNotFound(err) && len(manifest) > 0:
+			report.Enforce, report.Audit, report.Warn = audit.LevelPrivileged, audit.LevelPrivileged, audit.LevelPrivileged
+			report.Warnings = append(report.Warnings, fmt.Sprintf("namespace %s is not found, the levels default to privileged", namespace))
+		default:
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+		if len(override) > 0 {
+			if report.Enforce, err = audit.ParseLevel(override); err != nil {
+				return nil, err
+			}
+			report.Override = true
+		}
+
+		if len(manifest) == 0 {
+			pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", err)
+			}
+			owners, err := workloadOwners(ctx, cli, namespace)
+			if err != nil {
+				return nil, err
+			}
+			evaluated := make(map[string]bool)
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+					continue
+				}
+				workload := podWorkload(pod, owners)
+				if evaluated[workload] {
+					continue
+				}
+				evaluated[workload] = true
+				specs = append(specs, podSpec{workload: workload, spec: &pod.Spec})
+			}
+		}
+
+		engines := make(map[audit.Level]*audit.Engine)
+		evaluate := func(level audit.Level, spec *corev1.PodSpec) []audit.Finding {
+			rules := audit.LevelRules(level)
+			if len(rules) == 0 {
+				return nil
+			}
+			engine, ok := engines[level]
+			if !ok {
+				engine = audit.NewEngine(rules...)
+				engines[level] = engine
+			}
+			return engine.Audit(spec, "")
+		}
+		ruleIDs := func(findings []audit.Finding) []string {
+			ids := make([]string, 0, len(findings))
+			for _, f := range findings {
+				ids = append(ids, f.Rule)
+			}
+			return ids
+		}
+
+		for _, ps := range specs {
+			report.Workloads++
+			result := PodSecurityResult{
+				Workload: ps.workload,
+				Enforce:  evaluate(report.Enforce, ps.spec),
+				Audit:    ruleIDs(evaluate(report.Audit, ps.spec)),
+				Warn:     ruleIDs(evaluate(report.Warn, ps.spec)),
+			}
+			if len(result.Enforce) == 0 && len(result.Audit) == 0 && len(result.Warn) == 0 {
+				report.Passed++
+				continue
+			}
+			report.Violations = append(report.Violations, result)
+		}
+		sort.Slice(report.Violations, func(i, j int) bool {
+			if len(report.Violations[i].Enforce) != len(report.Violations[j].Enforce) {
+				return len(report.Violations[i].Enforce) > len(report.Violations[j].Enforce)
+			}
+			return report.Violations[i].Workload < report.Violations[j].Workload
+		})
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
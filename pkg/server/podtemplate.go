@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// podTemplateWorkload is the resource of a workload kind and the path of its pod template.
+type podTemplateWorkload struct {
+	gvr  schema.GroupVersionResource
+	path []string
+}
+
+// podTemplateWorkloads are the workload kinds whose pod template is updated by set_image, set_env and set_resources.
+var podTemplateWorkloads = map[string]podTemplateWorkload{
+	"Deployment":  {gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, path: []string{"spec", "template"}},
+	"StatefulSet": {gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, path: []string{"spec", "template"}},
+	"DaemonSet":   {gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, path: []string{"spec", "template"}},
+	"ReplicaSet":  {gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, path: []string{"spec", "template"}},
+	"CronJob":     {gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, path: []string{"spec", "jobTemplate", "spec", "template"}},
+}
+
+// PodTemplateUpdate is the change of the pod template of a workload, with the rollout state if waited.
+type PodTemplateUpdate struct {
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Container string            `json:"container"`
+	DryRun    bool              `json:"dryRun,omitempty"`
+	Changes   []ContainerChange `json:"changes"`
+	Rollout   *WorkloadRollout  `json:"rollout,omitempty"`
+	Message   string            `json:"message,omitempty"`
+}
+
+// ContainerChange is a field of the container changed by the update, the missing values are omitted.
+type ContainerChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// containerMutation changes the container of the pod template and returns the changed fields.
+type containerMutation func(container map[string]any) ([]ContainerChange, error)
+
+func (s *Server) SetImage() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		image, err := req.RequireString("image")
+		if err != nil {
+			return nil, err
+		}
+		return s.updatePodTemplate(ctx, req, func(container map[string]any) ([]ContainerChange, error) {
+			before, _, _ := unstructured.NestedString(container, "image")
+			if before == image {
+				return nil, nil
+			}
+			container["image"] = image
+			return []ContainerChange{{Field: "image", Before: before, After: image}}, nil
+		})
+	}
+}
+
+func (s *Server) SetEnv() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		env, _ := req.GetArguments()["env"].(map[string]any)
+		remove := req.GetStringSlice("remove", nil)
+		if len(env) == 0 && len(remove) == 0 {
+			return nil, fmt.Errorf("either env or remove is required")
+		}
+		names := make([]string, 0, len(env))
+		for name := range env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return s.updatePodTemplate(ctx, req, func(container map[string]any) ([]ContainerChange, error) {
+			vars, _, _ := unstructured.NestedSlice(container, "env")
+			var changes []ContainerChange
+			for _, name := range names {
+				value := fmt.Sprint(env[name])
+				found := false
+				for i, v := range vars {
+					envVar, _ := v.(map[string]any)
+					if envVar["name"] != name {
+						continue
+					}
+					found = true
+					before, hasValue := envVar["value"]
+					if _, hasValueFrom := envVar["valueFrom"]; !hasValueFrom && hasValue && before == value {
+						break
+					}
+					if valueFrom, ok := envVar["valueFrom"]; ok {
+						before = valueFrom
+					}
+					vars[i] = map[string]any{"name": name, "value": value}
+					changes = append(changes, ContainerChange{Field: "env." + name, Before: before, After: value})
+					break
+				}
+				if !found {
+					vars = append(vars, map[string]any{"name": name, "value": value})
+					changes = append(changes, ContainerChange{Field: "env." + name, After: value})
+				}
+			}
+			for _, name := range remove {
+				for i, v := range vars {
+					envVar, _ := v.(map[string]any)
+					if envVar["name"] != name {
+						continue
+					}
+					before := envVar["value"]
+					if valueFrom, ok := envVar["valueFrom"]; ok {
+						before = valueFrom
+					}
+					vars = append(vars[:i], vars[i+1:]...)
+					changes = append(changes, ContainerChange{Field: "env." + name, Before: before})
+					break
+				}
+			}
+			if len(vars) == 0 {
+				delete(container, "env")
+			} else {
+				container["env"] = vars
+			}
+			return changes, nil
+		})
+	}
+}
+
+func (s *Server) SetResources() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		requests, _ := req.GetArguments()["requests"].(map[string]any)
+		limits, _ := req.GetArguments()["limits"].(map[string]any)
+		if len(requests) == 0 && len(limits) == 0 {
+			return nil, fmt.Errorf("either requests or limits is required")
+		}
+		// the quantities are validated before the update, the empty quantity removes the resource.
+		quantities := map[string]map[string]string{"requests": {}, "limits": {}}
+		for field, values := range map[string]map[string]any{"requests": requests, "limits": limits} {
+			for name, value := range values {
+				quantity := fmt.Sprint(value)
+				if len(quantity) > 0 {
+					q, err := resource.ParseQuantity(quantity)
+					if err != nil {
+						return nil, fmt.Errorf("invalid %s of %s %q: %w", field, name, quantity, err)
+					}
+					quantity = q.String()
+				}
+				quantities[field][name] = quantity
+			}
+		}
+
+		return s.updatePodTemplate(ctx, req, func(container map[string]any) ([]ContainerChange, error) {
+			var changes []ContainerChange
+			for _, field := range []string{"requests", "limits"} {
+				names := make([]string, 0, len(quantities[field]))
+				for name := range quantities[field] {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					quantity := quantities[field][name]
+					before, found, _ := unstructured.NestedFieldNoCopy(container, "resources", field, name)
+					if found && fmt.Sprint(before) == quantity {
+						continue
+					}
+					if len(quantity) == 0 {
+						if !found {
+							continue
+						}
+						unstructured.RemoveNestedField(container, "resources", field, name)
+						changes = append(changes, ContainerChange{Field: "resources." + field + "." + name, Before: before})
+						continue
+					}
+					if err := unstructured.SetNestedField(container, quantity, "resources", field, name); err != nil {
+						return nil, err
+					}
+					changes = append(changes, ContainerChange{Field: "resources." + field + "." + name, Before: before, After: quantity})
+				}
+			}
+			return changes, nil
+		})
+	}
+}
+
+// updatePodTemplate applies the mutation to a container of the pod template of the workload, the update fails if
+// the workload is modified concurrently. The rollout is waited if requested.
+func (s *Server) updatePodTemplate(ctx context.Context, req mcp.CallToolRequest, mutate containerMutation) (*mcp.CallToolResult, error) {
+	resourceName, err := req.RequireString("name")
+	if err != nil {
+		return nil, err
+	}
+	kind, name, err := definition.ParseKindName(req.GetString("kind", ""), resourceName)
+	if err != nil {
+		return nil, err
+	}
+	workload, ok := podTemplateWorkloads[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported workload kind %q, must be one of Deployment, StatefulSet, DaemonSet, ReplicaSet or CronJob", kind)
+	}
+	namespace := req.GetString("namespace", "")
+	if len(namespace) == 0 {
+		namespace = s.defaultNamespace(ctx)
+	}
+	containerName := req.GetString("container", "")
+	dryRun := req.GetBool("dryRun", false)
+	waitForRollout := req.GetBool("wait", false)
+	waitTimeout := waitTimeoutOf(ctx, req, defaultRolloutWaitTimeout)
+
+	slog.Info("Updating pod template", "tool", req.Params.Name, "kind", kind, "name", name, "namespace", namespace, "container", containerName, "dryRun", dryRun)
+
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	ri := dynamicClient.Resource(workload.gvr).Namespace(namespace)
+	obj, err := ri.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", kind, err)
+	}
+
+	// the container is looked up in the containers, then the init containers.
+	var (
+		list       string
+		containers []any
+		index      = -1
+		names      []string
+	)
+	for _, field := range []string{"containers", "initContainers"} {
+		items, _, _ := unstructured.NestedSlice(obj.Object, append(workload.path, "spec", field)...)
+		for i, item := range items {
+			c, _ := item.(map[string]any)
+			cName, _ := c["name"].(string)
+			names = append(names, cName)
+			if index < 0 && (cName == containerName || (len(containerName) == 0 && field == "containers" && len(items) == 1)) {
+				list, containers, index = field, items, i
+			}
+		}
+	}
+	if index < 0 {
+		if len(containerName) == 0 {
+			return nil, fmt.Errorf("container is required since %s %s has the containers %s", kind, name, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("container %q is not found in %s %s, the containers are %s", containerName, kind, name, strings.Join(names, ", "))
+	}
+	container, _ := containers[index].(map[string]any)
+
+	update := &PodTemplateUpdate{Kind: kind, Name: name, Namespace: namespace, DryRun: dryRun, Changes: make([]ContainerChange, 0)}
+	update.Container, _ = container["name"].(string)
+	changes, err := mutate(container)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		update.Message = "the container is unchanged"
+		return newPodTemplateUpdateResult(update)
+	}
+	update.Changes = changes
+
+	patch, err := json.Marshal([]map[string]any{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": obj.GetResourceVersion()},
+		{"op": "replace", "path": "/" + strings.Join(workload.path, "/") + "/spec/" + list, "value": containers},
+	})
+	if err != nil {
+		return nil, err
+	}
+	options := metav1.PatchOptions{FieldManager: applyFieldManager}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	if _, err = ri.Patch(ctx, name, types.JSONPatchType, patch, options); err != nil {
+		return nil, fmt.Errorf("failed to patch %s: %w", kind, err)
+	}
+	if dryRun || !waitForRollout {
+		return newPodTemplateUpdateResult(update)
+	}
+
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		return nil, err
+	}
+	if update.Rollout, update.Message, err = waitWorkloadRollout(ctx, cli, kind, namespace, name, waitTimeout); err != nil {
+		return nil, err
+	}
+	if update.Rollout == nil {
+		update.Message = fmt.Sprintf("%s has no rollout status, the change applies to the next pods", kind)
+	}
+	return newPodTemplateUpdateResult(update)
+}
+
+// workloadRollout returns the rollout state of the Deployment, StatefulSet or DaemonSet, nil for the other kinds.
+func workloadRollout(ctx context.Context, cli kubernetes.Interface, kind, namespace, name string) (*WorkloadRollout, error) {
+	var rollout WorkloadRollout
+	switch kind {
+	case "Deployment":
+		d, err := cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		rollout = deploymentRollout(d)
+	case "StatefulSet":
+		sts, err := cli.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		rollout = statefulSetRollout(sts)
+	case "DaemonSet":
+		ds, err := cli.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		rollout = daemonSetRollout(ds)
+	default:
+		return nil, nil
+	}
+	return &rollout, nil
+}
+
+func newPodTemplateUpdateResult(update *PodTemplateUpdate) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
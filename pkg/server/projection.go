@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// ProjectedObject is the fields of an object extracted by the JSONPath expression.
+type ProjectedObject struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Value     any    `json:"value"`
+}
+
+// parseJSONPath parses the JSONPath expression like kubectl -o jsonpath, the braces and the leading dot are
+// optional, e.g. .status.conditions[?(@.type=="Ready")] is the same as {.status.conditions[?(@.type=="Ready")]}.
+func parseJSONPath(expr string) (*jsonpath.JSONPath, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "{") {
+		if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "[") {
+			expr = "." + expr
+		}
+		expr = "{" + expr + "}"
+	}
+	j := jsonpath.New("projection").AllowMissingKeys(true)
+	if err := j.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %w", expr, err)
+	}
+	return j, nil
+}
+
+// projectJSONPath returns the values of the object matched by the JSONPath, a single value is returned as is and
+// the multiple values as a list, nil if nothing is matched.
+func projectJSONPath(j *jsonpath.JSONPath, obj map[string]any) (any, error) {
+	results, err := j.FindResults(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate jsonpath: %w", err)
+	}
+	values := make([]any, 0)
+	for _, result := range results {
+		for _, value := range result {
+			if value.IsValid() && value.CanInterface() {
+				values = append(values, value.Interface())
+			}
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
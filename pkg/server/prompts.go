@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+// RegisterPrompts registers the prompts of the common operational workflows.
+func (s *Server) RegisterPrompts(ctx context.Context) {
+	slog.Info("Registering prompts")
+	s.svr.AddPrompt(koffeemcp.MakeDiagnosePodPrompt(), s.DiagnosePodPrompt())
+	s.svr.AddPrompt(koffeemcp.MakeReviewManifestPrompt(), s.ReviewManifestPrompt())
+	s.svr.AddPrompt(koffeemcp.MakeClusterHealthPrompt(), s.ClusterHealthPrompt())
+}
+
+func (s *Server) DiagnosePodPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		name := req.Params.Arguments["name"]
+		namespace := req.Params.Arguments["namespace"]
+		if len(name) == 0 || len(namespace) == 0 {
+			return nil, errors.New("name and namespace are required")
+		}
+
+		text := fmt.Sprintf(`Diagnose why the pod %[1]q in the namespace %[2]q is failing and suggest a fix. Follow these steps:
+1. Call get_resource_detail with kind=Pod, name=%[1]q and namespace=%[2]q to inspect the phase, the container statuses,
+   the restart counts, the last termination reasons and the conditions. If the name is a workload like deploy/foo,
+   call get_pod_logs first to find the pod.
+2. Call list_resources with kind=Event, namespace=%[2]q and fieldSelector=involvedObject.name=<pod> to find the
+   scheduling, image pull, probe and OOM events.
+3. Call get_pod_logs with name=%[1]q, namespace=%[2]q and allContainers=true. If a container restarted, call it again
+   with previous=true to read the logs of the crashed instance, use pattern to narrow down huge logs.
+4. If the pod is pending, check the node capacity with top_node and the PersistentVolumeClaims it mounts.
+5. If the pod is not ready but running, check the readiness probe and the Service with check_service.
+Report the root cause, the evidence from the steps above and a concrete fix.`, name, namespace)
+
+		return mcp.NewGetPromptResult("Diagnose the failing pod", []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
+
+func (s *Server) ReviewManifestPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		manifest := req.Params.Arguments["manifest"]
+		if len(manifest) == 0 {
+			return nil, errors.New("manifest is required")
+		}
+
+		text := fmt.Sprintf(`Review the following manifest before it is applied to the cluster. Follow these steps:
+1. Call detect_deprecated_apis with the manifest to find the API versions which are deprecated or removed.
+2. Call explain_resource for the fields you are unsure of to verify the field names and types.
+3. For the objects which already exist, call get_resource_detail to compare the live state with the manifest.
+4. Check the risky settings: missing resource requests and limits, privileged or root containers, hostPath volumes,
+   the latest image tag, missing probes, and the selectors which do not match the pod template labels.
+Report the problems grouped by severity with the suggested changes, do not apply the manifest.
+
+%s`, manifest)
+
+		return mcp.NewGetPromptResult("Review the manifest before apply", []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
+
+func (s *Server) ClusterHealthPrompt() server.PromptHandlerFunc {
+	return func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		namespace := req.Params.Arguments["namespace"]
+		scope := "all namespaces"
+		if len(namespace) > 0 {
+			scope = fmt.Sprintf("the namespace %q", namespace)
+		}
+
+		text := fmt.Sprintf(`Produce a health report of the cluster for %[1]s. Follow these steps:
+1. Call get_cluster_version, and list_resources with kind=Node to check the node readiness, then top_node for the
+   CPU and memory pressure.
+2. Call list_resources with kind=Pod and fieldSelector=status.phase!=Running,status.phase!=Succeeded for %[1]s to
+   find the pending and failed pods.
+3. Call list_resources with kind=Deployment, StatefulSet and DaemonSet for %[1]s to find the workloads which are not
+   fully available.
+4. Call list_resources with kind=Event and fieldSelector=type=Warning for %[1]s to summarize the recent warnings.
+5. Call detect_deprecated_apis to find the API versions which block the next upgrade.
+Report a short summary first, then the problems ordered by impact with the suggested next steps.`, scope)
+
+		return mcp.NewGetPromptResult("Cluster health report", []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// quotaWarningPercent is the usage percentage from which the quota resource is reported as nearly exhausted.
+const quotaWarningPercent = 90
+
+// QuotaUsage is the usage of a resource of the ResourceQuota.
+type QuotaUsage struct {
+	Quota       string  `json:"quota"`
+	Resource    string  `json:"resource"`
+	Used        string  `json:"used"`
+	Hard        string  `json:"hard"`
+	Remaining   string  `json:"remaining"`
+	UsedPercent float64 `json:"usedPercent"`
+	// Warning is set if the resource is exhausted or nearly exhausted.
+	Warning string `json:"warning,omitempty"`
+}
+
+// WorkloadRejection is the reasons why the pods of the workload would be rejected by the admission.
+type WorkloadRejection struct {
+	Workload string   `json:"workload"`
+	Reasons  []string `json:"reasons"`
+}
+
+// NamespaceHeadroom is the quota headroom and the LimitRange violations of the namespace.
+type NamespaceHeadroom struct {
+	Namespace   string              `json:"namespace"`
+	Quotas      []QuotaUsage        `json:"quotas,omitempty"`
+	LimitRanges []string            `json:"limitRanges,omitempty"`
+	Rejected    []WorkloadRejection `json:"rejected,omitempty"`
+}
+
+func (s *Server) QuotaHeadroom() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+
+		slog.Info("Analyzing quota headroom", "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		quotas, err := cli.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resourcequotas: %w", err)
+		}
+		limitRanges, err := cli.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list limitranges: %w", err)
+		}
+
+		quotasByNamespace := make(map[string][]corev1.ResourceQuota)
+		for _, q := range quotas.Items {
+			quotasByNamespace[q.Namespace] = append(quotasByNamespace[q.Namespace], q)
+		}
+		rangesByNamespace := make(map[string][]corev1.LimitRange)
+		for _, lr := range limitRanges.Items {
+			rangesByNamespace[lr.Namespace] = append(rangesByNamespace[lr.Namespace], lr)
+		}
+		namespaces := make([]string, 0)
+		for ns := range quotasByNamespace {
+			namespaces = append(namespaces, ns)
+		}
+		for ns := range rangesByNamespace {
+			if _, ok := quotasByNamespace[ns]; !ok {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		sort.Strings(namespaces)
+
+		report := make([]NamespaceHeadroom, 0, len(namespaces))
+		for _, ns := range namespaces {
+			headroom := NamespaceHeadroom{Namespace: ns}
+			for i := range quotasByNamespace[ns] {
+				headroom.Quotas = append(headroom.Quotas, quotaUsages(&quotasByNamespace[ns][i])...)
+			}
+			for _, lr := range rangesByNamespace[ns] {
+				headroom.LimitRanges = append(headroom.LimitRanges, lr.Name)
+			}
+			if headroom.Rejected, err = rejectedWorkloads(ctx, cli, ns, rangesByNamespace[ns], quotasByNamespace[ns]); err != nil {
+				return nil, err
+			}
+			report = append(report, headroom)
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// quotaUsages returns the usage of the resources of the quota, sorted like `kubectl describe quota`.
+func quotaUsages(quota *corev1.ResourceQuota) []QuotaUsage {
+	resources := make([]corev1.ResourceName, 0, len(quota.Status.Hard))
+	for name := range quota.Status.Hard {
+		resources = append(resources, name)
+	}
+	sort.Sort(definition.SortableResourceNames(resources))
+
+	usages := make([]QuotaUsage, 0, len(resources))
+	for _, name := range resources {
+		hard := quota.Status.Hard[name]
+		used := quota.Status.Used[name]
+		remaining := hard.DeepCopy()
+		remaining.Sub(used)
+
+		usage := QuotaUsage{
+			Quota:     quota.Name,
+			Resource:  string(name),
+			Used:      used.String(),
+			Hard:      hard.String(),
+			Remaining: remaining.String(),
+		}
+		if hard.Sign() > 0 {
+			usage.UsedPercent = float64(used.MilliValue()) * 100 / float64(hard.MilliValue())
+		} else if used.Sign() >= 0 {
+			usage.UsedPercent = 100
+		}
+		switch {
+		case used.Cmp(hard) >= 0:
+			usage.Warning = "exhausted, new objects requesting the resource are rejected"
+		case usage.UsedPercent >= quotaWarningPercent:
+			usage.Warning = fmt.Sprintf("more than %d%% used", quotaWarningPercent)
+		}
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+// rejectedWorkloads checks the pod templates of the workloads in the namespace against the LimitRanges and the
+// quotas, after applying the LimitRange defaults like the admission does.
+func rejectedWorkloads(ctx context.Context, cli kubernetes.Interface, namespace string, limitRanges []corev1.LimitRange, quotas []corev1.ResourceQuota) ([]WorkloadRejection, error) {
+	templates := make(map[string]*corev1.PodSpec)
+	deployments, err := cli.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		templates["Deployment/"+deployments.Items[i].Name] = &deployments.Items[i].Spec.Template.Spec
+	}
+	statefulSets, err := cli.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		templates["StatefulSet/"+statefulSets.Items[i].Name] = &statefulSets.Items[i].Spec.Template.Spec
+	}
+	daemonSets, err := cli.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		templates["DaemonSet/"+daemonSets.Items[i].Name] = &daemonSets.Items[i].Spec.Template.Spec
+	}
+	cronJobs, err := cli.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for i := range cronJobs.Items {
+		templates["CronJob/"+cronJobs.Items[i].Name] = &cronJobs.Items[i].Spec.JobTemplate.Spec.Template.Spec
+	}
+
+	var rejected []WorkloadRejection
+	for workload, spec := range templates {
+		if reasons := admissionViolations(spec, limitRanges, quotas); len(reasons) > 0 {
+			rejected = append(rejected, WorkloadRejection{Workload: workload, Reasons: reasons})
+		}
+	}
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].Workload < rejected[j].Workload
+	})
+	return rejected, nil
+}
+
+// admissionViolations returns the reasons why the pod would be rejected by the LimitRanger and the ResourceQuota
+// admission plugins.
+func admissionViolations(spec *corev1.PodSpec, limitRanges []corev1.LimitRange, quotas []corev1.ResourceQuota) []string {
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+
+	var reasons []string
+	podRequests, podLimits := corev1.ResourceList{}, corev1.ResourceList{}
+	for i := range containers {
+		requests, limits := defaultedResources(&containers[i], limitRanges)
+		for _, lr := range limitRanges {
+			for _, item := range lr.Spec.Limits {
+				if item.Type == corev1.LimitTypeContainer {
+					reasons = append(reasons, checkLimitRangeItem(fmt.Sprintf("container %s", containers[i].Name), lr.Name, item, requests, limits)...)
+				}
+			}
+		}
+		for name, q := range requests {
+			addQuantity(podRequests, name, q)
+		}
+		for name, q := range limits {
+			addQuantity(podLimits, name, q)
+		}
+
+		// the quota tracking the compute resources rejects the containers without the requests or limits.
+		for _, quota := range quotas {
+			for name := range quota.Status.Hard {
+				resourceName, isLimit := strings.CutPrefix(string(name), "limits.")
+				if !isLimit {
+					resourceName = strings.TrimPrefix(string(name), "requests.")
+				}
+				if resourceName != string(corev1.ResourceCPU) && resourceName != string(corev1.ResourceMemory) {
+					continue
+				}
+				list, kind := requests, "request"
+				if isLimit {
+					list, kind = limits, "limit"
+				}
+				if _, ok := list[corev1.ResourceName(resourceName)]; !ok {
+					reasons = append(reasons, fmt.Sprintf("container %s: the quota %s tracks %s but the container has no %s %s", containers[i].Name, quota.Name, name, resourceName, kind))
+				}
+			}
+		}
+	}
+
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type == corev1.LimitTypePod {
+				reasons = append(reasons, checkLimitRangeItem("pod", lr.Name, item, podRequests, podLimits)...)
+			}
+		}
+	}
+	return reasons
+}
+
+// defaultedResources returns the requests and limits of the container after applying the defaults of the
+// LimitRanges, the request defaults to the limit if there is no default request.
+func defaultedResources(c *corev1.Container, limitRanges []corev1.LimitRange) (corev1.ResourceList, corev1.ResourceList) {
+	requests, limits := c.Resources.Requests.DeepCopy(), c.Resources.Limits.DeepCopy()
+	if requests == nil {
+		requests = corev1.ResourceList{}
+	}
+	if limits == nil {
+		limits = corev1.ResourceList{}
+	}
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for name, q := range item.Default {
+				if _, ok := limits[name]; !ok {
+					limits[name] = q.DeepCopy()
+				}
+			}
+			for name, q := range item.DefaultRequest {
+				if _, ok := requests[name]; !ok {
+					requests[name] = q.DeepCopy()
+				}
+			}
+		}
+	}
+	for name, q := range limits {
+		if _, ok := requests[name]; !ok {
+			requests[name] = q.DeepCopy()
+		}
+	}
+	return requests, limits
+}
+
+// checkLimitRangeItem checks the requests and limits against the min, max and the max limit/request ratio.
+func checkLimitRangeItem(target, limitRange string, item corev1.LimitRangeItem, requests, limits corev1.ResourceList) []string {
+	var reasons []string
+	for name, minimum := range item.Min {
+		request, hasRequest := requests[name]
+		limit, hasLimit := limits[name]
+		switch {
+		case !hasRequest:
+			reasons = append(reasons, fmt.Sprintf("%s: the limitrange %s requires the minimum %s %s but no request is specified", target, limitRange, name, minimum.String()))
+		case request.Cmp(minimum) < 0:
+			reasons = append(reasons, fmt.Sprintf("%s: the %s request %s is less than the minimum %s of the limitrange %s", target, name, request.String(), minimum.String(), limitRange))
+		case hasLimit && limit.Cmp(minimum) < 0:
+			reasons = append(reasons, fmt.Sprintf("%s: the %s limit %s is less than the minimum %s of the limitrange %s", target, name, limit.String(), minimum.String(), limitRange))
+		}
+	}
+	for name, maximum := range item.Max {
+		limit, hasLimit := limits[name]
+		switch {
+		case !hasLimit:
+			reasons = append(reasons, fmt.Sprintf("%s: the limitrange %s requires the maximum %s %s but no limit is specified", target, limitRange, name, maximum.String()))
+		case limit.Cmp(maximum) > 0:
+			reasons = append(reasons, fmt.Sprintf("%s: the %s limit %s exceeds the maximum %s of the limitrange %s", target, name, limit.String(), maximum.String(), limitRange))
+		}
+	}
+	for name, ratio := range item.MaxLimitRequestRatio {
+		request, hasRequest := requests[name]
+		limit, hasLimit := limits[name]
+		if !hasRequest || !hasLimit || request.IsZero() {
+			continue
+		}
+		if actual := float64(limit.MilliValue()) / float64(request.MilliValue()); actual > float64(ratio.MilliValue())/1000 {
+			reasons = append(reasons, fmt.Sprintf("%s: the %s limit/request ratio %.2f exceeds the maximum %s of the limitrange %s", target, name, actual, ratio.String(), limitRange))
+		}
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+func addQuantity(list corev1.ResourceList, name corev1.ResourceName, q resource.Quantity) {
+	if current, ok := list[name]; ok {
+		current.Add(q)
+		list[name] = current
+		return
+	}
+	list[name] = q.DeepCopy()
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// AdmissionRejection is the quota resources an object would exceed and the LimitRange rules it would violate.
+type AdmissionRejection struct {
+	Kind       string        `json:"kind"`
+	Namespace  string        `json:"namespace"`
+	Name       string        `json:"name"`
+	Breaches   []QuotaBreach `json:"breaches,omitempty"`
+	Violations []string      `json:"violations,omitempty"`
+}
+
+// QuotaBreach is a resource of the ResourceQuota exceeded by the object, the requested includes the objects before
+// it in the same manifest.
+type QuotaBreach struct {
+	Quota     string `json:"quota"`
+	Resource  string `json:"resource"`
+	Used      string `json:"used"`
+	Requested string `json:"requested"`
+	Hard      string `json:"hard"`
+}
+
+// AdmissionRejectedError is returned if the objects would be rejected by the ResourceQuota or LimitRanger admission.
+type AdmissionRejectedError struct {
+	Rejections []AdmissionRejection
+}
+
+func (e *AdmissionRejectedError) Error() string {
+	reasons := make([]string, 0)
+	for _, r := range e.Rejections {
+		for _, b := range r.Breaches {
+			reasons = append(reasons, fmt.Sprintf("%s/%s exceeds %s of the quota %s: used %s, requested %s, hard %s", r.Kind, r.Name, b.Resource, b.Quota, b.Used, b.Requested, b.Hard))
+		}
+		for _, v := range r.Violations {
+			reasons = append(reasons, fmt.Sprintf("%s/%s: %s", r.Kind, r.Name, v))
+		}
+	}
+	return fmt.Sprintf("the objects would be rejected by the admission: %s", strings.Join(reasons, "; "))
+}
+
+// admissionChecker simulates the ResourceQuota and LimitRanger admission of the objects before they are submitted,
+// the usage of the objects is accumulated so the objects of a manifest are checked together. The quotas with the
+// scopes are not checked since the scopes depend on the fields set by the other admission plugins.
+type admissionChecker struct {
+	cli         kubernetes.Interface
+	quotas      map[string][]corev1.ResourceQuota
+	limitRanges map[string][]corev1.LimitRange
+	// requested is the accumulated usage of the checked objects by the namespace/quota.
+	requested  map[string]corev1.ResourceList
+	rejections []AdmissionRejection
+}
+
+func newAdmissionChecker(cli kubernetes.Interface) *admissionChecker {
+	return &admissionChecker{
+		cli:         cli,
+		quotas:      make(map[string][]corev1.ResourceQuota),
+		limitRanges: make(map[string][]corev1.LimitRange),
+		requested:   make(map[string]corev1.ResourceList),
+	}
+}
+
+// check checks the object to be created or to replace the live object, only the increase of the usage counts.
+func (c *admissionChecker) check(ctx context.Context, gvr schema.GroupVersionResource, obj, live *unstructured.Unstructured) error {
+	namespace := obj.GetNamespace()
+	if len(namespace) == 0 {
+		return nil
+	}
+	if err := c.load(ctx, namespace); err != nil {
+		return err
+	}
+	quotas, limitRanges := c.quotas[namespace], c.limitRanges[namespace]
+	if len(quotas) == 0 && len(limitRanges) == 0 {
+		return nil
+	}
+
+	usage, violations, err := objectAdmission(gvr, obj, quotas, limitRanges)
+	if err != nil {
+		return err
+	}
+	if live != nil {
+		liveUsage, _, err := objectAdmission(gvr, live, nil, nil)
+		if err != nil {
+			return err
+		}
+		for name, q := range liveUsage {
+			current := usage[name]
+			current.Sub(q)
+			usage[name] = current
+		}
+	}
+
+	rejection := AdmissionRejection{Kind: obj.GetKind(), Namespace: namespace, Name: obj.GetName(), Violations: violations}
+	for _, quota := range quotas {
+		key := namespace + "/" + quota.Name
+		if c.requested[key] == nil {
+			c.requested[key] = corev1.ResourceList{}
+		}
+		names := make([]corev1.ResourceName, 0, len(quota.Status.Hard))
+		for name := range quota.Status.Hard {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+		for _, name := range names {
+			q, ok := usage[name]
+			if !ok || q.Sign() <= 0 {
+				continue
+			}
+			addQuantity(c.requested[key], name, q)
+			hard, used, requested := quota.Status.Hard[name], quota.Status.Used[name], c.requested[key][name]
+			total := used.DeepCopy()
+			total.Add(requested)
+			if total.Cmp(hard) > 0 {
+				rejection.Breaches = append(rejection.Breaches, QuotaBreach{
+					Quota:     quota.Name,
+					Resource:  string(name),
+					Used:      used.String(),
+					Requested: requested.String(),
+					Hard:      hard.String(),
+				})
+			}
+		}
+	}
+	if len(rejection.Breaches) > 0 || len(rejection.Violations) > 0 {
+		c.rejections = append(c.rejections, rejection)
+	}
+	return nil
+}
+
+// err returns the AdmissionRejectedError if any object is rejected.
+func (c *admissionChecker) err() error {
+	if len(c.rejections) == 0 {
+		return nil
+	}
+	return &AdmissionRejectedError{Rejections: c.rejections}
+}
+
+func (c *admissionChecker) load(ctx context.Context, namespace string) error {
+	if _, ok := c.quotas[namespace]; ok {
+		return nil
+	}
+	quotas, err := c.cli.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list resourcequotas: %w", err)
+	}
+	limitRanges, err := c.cli.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list limitranges: %w", err)
+	}
+	c.quotas[namespace] = make([]corev1.ResourceQuota, 0, len(quotas.Items))
+	for _, quota := range quotas.Items {
+		if len(quota.Spec.Scopes) == 0 && quota.Spec.ScopeSelector == nil {
+			c.quotas[namespace] = append(c.quotas[namespace], quota)
+		}
+	}
+	c.limitRanges[namespace] = limitRanges.Items
+	return nil
+}
+
+// objectAdmission returns the quota usage of the object, including the pods created by the workloads, and the
+// LimitRange violations of its pod template or claim.
+func objectAdmission(gvr schema.GroupVersionResource, obj *unstructured.Unstructured, quotas []corev1.ResourceQuota, limitRanges []corev1.LimitRange) (corev1.ResourceList, []string, error) {
+	usage := corev1.ResourceList{}
+	one := *resource.NewQuantity(1, resource.DecimalSI)
+	// the object count quota is named count/<resource>.<group>, or count/<resource> for the core group.
+	countName := "count/" + gvr.Resource
+	if len(gvr.Group) > 0 {
+		countName += "." + gvr.Group
+	}
+	addQuantity(usage, corev1.ResourceName(countName), one)
+
+	var (
+		spec     *corev1.PodSpec
+		replicas int32 = 1
+	)
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Pod"}:
+		pod := &corev1.Pod{}
+		if err := fromUnstructured(obj, pod); err != nil {
+			return nil, nil, err
+		}
+		spec = &pod.Spec
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		deploy := &appsv1.Deployment{}
+		if err := fromUnstructured(obj, deploy); err != nil {
+			return nil, nil, err
+		}
+		spec, replicas = &deploy.Spec.Template.Spec, ptr.Deref(deploy.Spec.Replicas, 1)
+	case schema.GroupKind{Group: "apps", Kind: "StatefulSet"}:
+		sts := &appsv1.StatefulSet{}
+		if err := fromUnstructured(obj, sts); err != nil {
+			return nil, nil, err
+		}
+		spec, replicas = &sts.Spec.Template.Spec, ptr.Deref(sts.Spec.Replicas, 1)
+	case schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}:
+		rs := &appsv1.ReplicaSet{}
+		if err := fromUnstructured(obj, rs); err != nil {
+			return nil, nil, err
+		}
+		spec, replicas = &rs.Spec.Template.Spec, ptr.Deref(rs.Spec.Replicas, 1)
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		// the number of the pods depends on the nodes, a single pod is counted.
+		ds := &appsv1.DaemonSet{}
+		if err := fromUnstructured(obj, ds); err != nil {
+			return nil, nil, err
+		}
+		spec = &ds.Spec.Template.Spec
+	case schema.GroupKind{Group: "batch", Kind: "Job"}:
+		job := &batchv1.Job{}
+		if err := fromUnstructured(obj, job); err != nil {
+			return nil, nil, err
+		}
+		spec, replicas = &job.Spec.Template.Spec, ptr.Deref(job.Spec.Parallelism, 1)
+	case schema.GroupKind{Group: "batch", Kind: "CronJob"}:
+		cronJob := &batchv1.CronJob{}
+		if err := fromUnstructured(obj, cronJob); err != nil {
+			return nil, nil, err
+		}
+		spec, replicas = &cronJob.Spec.JobTemplate.Spec.Template.Spec, ptr.Deref(cronJob.Spec.JobTemplate.Spec.Parallelism, 1)
+	case schema.GroupKind{Kind: "PersistentVolumeClaim"}:
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := fromUnstructured(obj, pvc); err != nil {
+			return nil, nil, err
+		}
+		return claimUsage(pvc, usage), claimViolations(pvc, limitRanges), nil
+	case schema.GroupKind{Kind: "Service"}:
+		svc := &corev1.Service{}
+		if err := fromUnstructured(obj, svc); err != nil {
+			return nil, nil, err
+		}
+		addQuantity(usage, corev1.ResourceServices, one)
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			addQuantity(usage, corev1.ResourceServicesLoadBalancers, one)
+		}
+		if svc.Spec.Type == corev1.ServiceTypeNodePort || svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			addQuantity(usage, corev1.ResourceServicesNodePorts, *resource.NewQuantity(int64(len(svc.Spec.Ports)), resource.DecimalSI))
+		}
+		return usage, nil, nil
+	case schema.GroupKind{Kind: "ConfigMap"}:
+		addQuantity(usage, corev1.ResourceConfigMaps, one)
+		return usage, nil, nil
+	case schema.GroupKind{Kind: "Secret"}:
+		addQuantity(usage, corev1.ResourceSecrets, one)
+		return usage, nil, nil
+	default:
+		return usage, nil, nil
+	}
+	if replicas <= 0 {
+		return usage, nil, nil
+	}
+
+	// the quota is charged by the pods after the LimitRange defaults are applied.
+	pod := &corev1.Pod{Spec: *spec.DeepCopy()}
+	for i := range pod.Spec.InitContainers {
+		pod.Spec.InitContainers[i].Resources.Requests, pod.Spec.InitContainers[i].Resources.Limits = defaultedResources(&pod.Spec.InitContainers[i], limitRanges)
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].Resources.Requests, pod.Spec.Containers[i].Resources.Limits = defaultedResources(&pod.Spec.Containers[i], limitRanges)
+	}
+	count := int64(replicas)
+	scaled := func(q resource.Quantity) resource.Quantity {
+		return *resource.NewMilliQuantity(q.MilliValue()*count, q.Format)
+	}
+	addQuantity(usage, corev1.ResourcePods, *resource.NewQuantity(count, resource.DecimalSI))
+	if obj.GetKind() != "Pod" {
+		addQuantity(usage, "count/pods", *resource.NewQuantity(count, resource.DecimalSI))
+	}
+	for name, q := range podRequests(pod) {
+		addQuantity(usage, corev1.ResourceName("requests."+string(name)), scaled(q))
+		// the cpu and memory quota are the aliases of the requests.
+		addQuantity(usage, name, scaled(q))
+	}
+	for name, q := range podLimits(pod) {
+		addQuantity(usage, corev1.ResourceName("limits."+string(name)), scaled(q))
+	}
+	return usage, admissionViolations(spec, limitRanges, quotas), nil
+}
+
+// claimUsage adds the storage requested by the claim, in total and by its StorageClass.
+func claimUsage(pvc *corev1.PersistentVolumeClaim, usage corev1.ResourceList) corev1.ResourceList {
+	one := *resource.NewQuantity(1, resource.DecimalSI)
+	storage := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	addQuantity(usage, corev1.ResourcePersistentVolumeClaims, one)
+	addQuantity(usage, corev1.ResourceRequestsStorage, storage)
+	if class := ptr.Deref(pvc.Spec.StorageClassName, ""); len(class) > 0 {
+		prefix := class + ".storageclass.storage.k8s.io/"
+		addQuantity(usage, corev1.ResourceName(prefix+string(corev1.ResourcePersistentVolumeClaims)), one)
+		addQuantity(usage, corev1.ResourceName(prefix+string(corev1.ResourceRequestsStorage)), storage)
+	}
+	return usage
+}
+
+// claimViolations checks the storage requested by the claim against the PersistentVolumeClaim LimitRanges, which
+// bound the requests rather than the limits.
+func claimViolations(pvc *corev1.PersistentVolumeClaim, limitRanges []corev1.LimitRange) []string {
+	var reasons []string
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type == corev1.LimitTypePersistentVolumeClaim {
+				reasons = append(reasons, checkLimitRangeItem("claim", lr.Name, item, pvc.Spec.Resources.Requests, pvc.Spec.Resources.Requests)...)
+			}
+		}
+	}
+	return reasons
+}
+
+func fromUnstructured(obj *unstructured.Unstructured, into any) error {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into); err != nil {
+		return fmt.Errorf("failed to convert %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rbacIndex is the roles and bindings of the cluster, the Roles and RoleBindings are of the namespace only if it's
+// specified.
+type rbacIndex struct {
+	roles               map[string]*rbacv1.Role
+	clusterRoles        map[string]*rbacv1.ClusterRole
+	roleBindings        []rbacv1.RoleBinding
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+}
+
+func loadRBACIndex(ctx context.Context, cli kubernetes.Interface, namespace string) (*rbacIndex, error) {
+	index := &rbacIndex{roles: make(map[string]*rbacv1.Role), clusterRoles: make(map[string]*rbacv1.ClusterRole)}
+	roles, err := cli.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	for i := range roles.Items {
+		index.roles[roles.Items[i].Namespace+"/"+roles.Items[i].Name] = &roles.Items[i]
+	}
+	clusterRoles, err := cli.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusterroles: %w", err)
+	}
+	for i := range clusterRoles.Items {
+		index.clusterRoles[clusterRoles.Items[i].Name] = &clusterRoles.Items[i]
+	}
+	roleBindings, err := cli.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rolebindings: %w", err)
+	}
+	index.roleBindings = roleBindings.Items
+	clusterRoleBindings, err := cli.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusterrolebindings: %w", err)
+	}
+	index.clusterRoleBindings = clusterRoleBindings.Items
+	return index, nil
+}
+
+// roleRules returns the rules of the role referenced by the binding in the namespace, nil if the role is missing.
+func (r *rbacIndex) roleRules(namespace string, ref rbacv1.RoleRef) ([]rbacv1.PolicyRule, bool) {
+	if ref.Kind == "ClusterRole" {
+		return r.clusterRoleRules(ref.Name)
+	}
+	role, ok := r.roles[namespace+"/"+ref.Name]
+	if !ok {
+		return nil, false
+	}
+	return role.Rules, true
+}
+
+// clusterRoleRules returns the rules of the ClusterRole, the rules of the aggregated ClusterRole are resolved by its
+// aggregation rule in case they are not yet aggregated by the controller.
+func (r *rbacIndex) clusterRoleRules(name string) ([]rbacv1.PolicyRule, bool) {
+	role, ok := r.clusterRoles[name]
+	if !ok {
+		return nil, false
+	}
+	if role.AggregationRule == nil {
+		return role.Rules, true
+	}
+	rules := slices.Clone(role.Rules)
+	for _, selector := range role.AggregationRule.ClusterRoleSelectors {
+		s, err := metav1.LabelSelectorAsSelector(&selector)
+		if err != nil || s.Empty() {
+			continue
+		}
+		for _, aggregatedName := range slices.Sorted(maps.Keys(r.clusterRoles)) {
+			aggregated := r.clusterRoles[aggregatedName]
+			if aggregated.Name == role.Name || !s.Matches(labels.Set(aggregated.Labels)) {
+				continue
+			}
+			for _, rule := range aggregated.Rules {
+				if !slices.ContainsFunc(rules, func(r rbacv1.PolicyRule) bool { return equality.Semantic.DeepEqual(r, rule) }) {
+					rules = append(rules, rule)
+				}
+			}
+		}
+	}
+	return rules, true
+}
+
+// serviceAccountSubject reports whether the subject matches the ServiceAccount, directly or by the groups of all the
+// ServiceAccounts and the ServiceAccounts of the namespace. The namespace of the subject defaults to the binding's.
+func serviceAccountSubject(subject rbacv1.Subject, bindingNamespace, namespace, name string) (bool, string) {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		subjectNamespace := subject.Namespace
+		if len(subjectNamespace) == 0 {
+			subjectNamespace = bindingNamespace
+		}
+		return subject.Name == name && subjectNamespace == namespace, ""
+	case rbacv1.GroupKind:
+		if subject.Name == "system:serviceaccounts" || subject.Name == "system:serviceaccounts:"+namespace {
+			return true, "group " + subject.Name
+		}
+	case rbacv1.UserKind:
+		if subject.Name == "system:serviceaccount:"+namespace+":"+name {
+			return true, "user " + subject.Name
+		}
+	}
+	return false, ""
+}
+
+// privilegedRules returns the reasons the rules are over-privileged: the wildcards, the verbs escalating the
+// privileges, and reading the Secrets.
+func privilegedRules(rules []rbacv1.PolicyRule) []string {
+	var reasons []string
+	add := func(reason string) {
+		if !slices.Contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+	}
+	for _, rule := range rules {
+		if len(rule.NonResourceURLs) > 0 {
+			continue
+		}
+		wildcardVerbs := slices.Contains(rule.Verbs, rbacv1.VerbAll)
+		wildcardResources := slices.Contains(rule.Resources, rbacv1.ResourceAll)
+		if wildcardVerbs && wildcardResources {
+			add("all verbs on all resources")
+			continue
+		}
+		if wildcardResources {
+			add("wildcard resources")
+		}
+		for _, verb := range []string{"escalate", "bind", "impersonate"} {
+			if slices.Contains(rule.Verbs, verb) {
+				add(verb + " verb")
+			}
+		}
+		allows := func(resource string, verbs ...string) bool {
+			if !wildcardResources && !slices.Contains(rule.Resources, resource) {
+				return false
+			}
+			return wildcardVerbs || slices.ContainsFunc(verbs, func(verb string) bool { return slices.Contains(rule.Verbs, verb) })
+		}
+		if allows("secrets", "get", "list", "watch") {
+			add("read secrets")
+		}
+		if allows("pods/exec", "create") {
+			add("exec into pods")
+		}
+		for _, resource := range []string{"roles", "clusterroles", "rolebindings", "clusterrolebindings"} {
+			if allows(resource, "create", "update", "patch") {
+				add("modify " + resource)
+			}
+		}
+	}
+	return reasons
+}
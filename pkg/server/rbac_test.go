@@ -0,0 +1,100 @@
+package server
+
+import (
+	"slices"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterRoleRules(t *testing.T) {
+	rule := func(verb, resource string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{APIGroups: []string{""}, Verbs: []string{verb}, Resources: []string{resource}}
+	}
+	clusterRole := func(name string, labels map[string]string, aggregation *rbacv1.AggregationRule, rules ...rbacv1.PolicyRule) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}, AggregationRule: aggregation, Rules: rules}
+	}
+	aggregateTo := func(role string) *rbacv1.AggregationRule {
+		return &rbacv1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"aggregate-to-" + role: "true"}}}}
+	}
+	index := &rbacIndex{
+		roles: map[string]*rbacv1.Role{"shop/reader": {Rules: []rbacv1.PolicyRule{rule("get", "configmaps")}}},
+		clusterRoles: map[string]*rbacv1.ClusterRole{
+			"plain":     clusterRole("plain", nil, nil, rule("get", "pods")),
+			"view":      clusterRole("view", nil, aggregateTo("view")),
+			"pods-view": clusterRole("pods-view", map[string]string{"aggregate-to-view": "true"}, nil, rule("get", "pods"), rule("list", "pods")),
+			"svc-view":  clusterRole("svc-view", map[string]string{"aggregate-to-view": "true"}, nil, rule("get", "pods"), rule("get", "services")),
+			"edit":      clusterRole("edit", map[string]string{"aggregate-to-admin": "true"}, aggregateTo("edit"), rule("create", "pods")),
+			// the rules already aggregated by the controller are not duplicated.
+			"admin": clusterRole("admin", nil, aggregateTo("admin"), rule("create", "pods")),
+			// the empty selector selects nothing rather than all the ClusterRoles.
+			"empty": clusterRole("empty", nil, &rbacv1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{{}}}),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		ref       rbacv1.RoleRef
+		want      []rbacv1.PolicyRule
+		wantFound bool
+	}{
+		{name: "role", namespace: "shop", ref: rbacv1.RoleRef{Kind: "Role", Name: "reader"}, want: []rbacv1.PolicyRule{rule("get", "configmaps")}, wantFound: true},
+		{name: "role of another namespace", namespace: "dev", ref: rbacv1.RoleRef{Kind: "Role", Name: "reader"}},
+		{name: "missing clusterrole", ref: rbacv1.RoleRef{Kind: "ClusterRole", Name: "missing"}},
+		{name: "clusterrole without aggregation", ref: rbacv1.RoleRef{Kind: "ClusterRole", Name: "plain"}, want: []rbacv1.PolicyRule{rule("get", "pods")}, wantFound: true},
+		{
+			name:      "aggregated clusterrole",
+			ref:       rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			want:      []rbacv1.PolicyRule{rule("get", "pods"), rule("list", "pods"), rule("get", "services")},
+			wantFound: true,
+		},
+		{name: "aggregated rules deduplicated", ref: rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"}, want: []rbacv1.PolicyRule{rule("create", "pods")}, wantFound: true},
+		{name: "empty selector", ref: rbacv1.RoleRef{Kind: "ClusterRole", Name: "empty"}, wantFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, found := index.roleRules(tt.namespace, tt.ref)
+			if found != tt.wantFound {
+				t.Fatalf("got found %t, want %t", found, tt.wantFound)
+			}
+			if !slices.EqualFunc(rules, tt.want, func(a, b rbacv1.PolicyRule) bool { return a.String() == b.String() }) {
+				t.Fatalf("got rules %v, want %v", rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivilegedRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []rbacv1.PolicyRule
+		want  []string
+	}{
+		{name: "read only", rules: []rbacv1.PolicyRule{{Verbs: []string{"get", "list"}, Resources: []string{"pods"}}}},
+		{name: "cluster admin", rules: []rbacv1.PolicyRule{{Verbs: []string{"*"}, Resources: []string{"*"}}}, want: []string{"all verbs on all resources"}},
+		{name: "wildcard resources", rules: []rbacv1.PolicyRule{{Verbs: []string{"get"}, Resources: []string{"*"}}}, want: []string{"wildcard resources", "read secrets"}},
+		{
+			name: "escalating verbs",
+			rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"bind", "escalate"}, Resources: []string{"clusterroles"}},
+				{Verbs: []string{"impersonate"}, Resources: []string{"users"}},
+			},
+			want: []string{"escalate verb", "bind verb", "impersonate verb"},
+		},
+		{
+			name:  "exec and bindings",
+			rules: []rbacv1.PolicyRule{{Verbs: []string{"create"}, Resources: []string{"pods/exec", "rolebindings"}}},
+			want:  []string{"exec into pods", "modify rolebindings"},
+		},
+		{name: "non resource urls", rules: []rbacv1.PolicyRule{{Verbs: []string{"*"}, NonResourceURLs: []string{"*"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := privilegedRules(tt.rules); !slices.Equal(got, tt.want) {
+				t.Fatalf("got reasons %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+
+	"cola.io/koffee/pkg/client"
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+// Workload is the summary of a workload exposed by the workloads resource.
+type Workload struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Ready string `json:"ready"`
+	Age   string `json:"age"`
+}
+
+// resourceURI is the parsed URI of the cluster resources.
+type resourceURI struct {
+	context   string
+	namespace string
+	kind      string
+	name      string
+	// collection is the collection name, e.g. namespaces or workloads
+	collection string
+}
+
+// parseResourceURI parses the URI like k8s://<context>/namespaces, k8s://<context>/<namespace>/workloads,
+// k8s://<context>/<namespace>/<kind>/<name>, k8s://snapshots/<name> or k8s://outputs/<name>.
+func parseResourceURI(uri string) (*resourceURI, error) {
+	rest, found := strings.CutPrefix(uri, koffeemcp.ResourceScheme+"://")
+	if !found {
+		return nil, fmt.Errorf("unsupported resource uri %q", uri)
+	}
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	switch {
+	case len(segments) == 2 && segments[1] == "namespaces":
+		return &resourceURI{context: segments[0], collection: segments[1]}, nil
+	case len(segments) == 2 && (segments[0] == koffeemcp.SnapshotsCollection || segments[0] == koffeemcp.OutputsCollection):
+		return &resourceURI{name: segments[1], collection: segments[0]}, nil
+	case len(segments) == 3 && segments[2] == "workloads":
+		return &resourceURI{context: segments[0], namespace: segments[1], collection: segments[2]}, nil
+	case len(segments) == 4:
+		r := &resourceURI{context: segments[0], namespace: segments[1], kind: segments[2], name: segments[3]}
+		if r.namespace == koffeemcp.ClusterScopedNamespace {
+			r.namespace = ""
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported resource uri %q", uri)
+}
+
+// RegisterResources registers the cluster state as the resources, the namespaces of every kube context are listed
+// and the workloads and manifests are addressable by the resource templates.
+func (s *Server) RegisterResources(ctx context.Context) {
+	slog.Info("Registering resources")
+
+	if cfg, err := s.cb.LoadRawConfig(); err == nil {
+		contexts := make([]string, 0, len(cfg.Contexts))
+		for name := range cfg.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+		for _, name := range contexts {
+			s.svr.AddResource(koffeemcp.MakeNamespacesResource(name), s.ReadResource())
+		}
+	} else {
+		slog.Warn("Failed to load the kube contexts for the resources", "err", err)
+	}
+
+	s.svr.AddResourceTemplate(koffeemcp.MakeNamespacesResourceTemplate(), server.ResourceTemplateHandlerFunc(s.ReadResource()))
+	s.svr.AddResourceTemplate(koffeemcp.MakeWorkloadsResourceTemplate(), server.ResourceTemplateHandlerFunc(s.ReadResource()))
+	s.svr.AddResourceTemplate(koffeemcp.MakeManifestResourceTemplate(), server.ResourceTemplateHandlerFunc(s.ReadResource()))
+	s.svr.AddResourceTemplate(koffeemcp.MakeSnapshotResourceTemplate(), server.ResourceTemplateHandlerFunc(s.ReadResource()))
+	s.svr.AddResourceTemplate(koffeemcp.MakeOutputResourceTemplate(), server.ResourceTemplateHandlerFunc(s.ReadResource()))
+}
+
+func (s *Server) ReadResource() server.ResourceHandlerFunc {
+	return func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		uri, err := parseResourceURI(req.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Reading resource", "uri", req.Params.URI)
+
+		if uri.collection == koffeemcp.OutputsCollection {
+			return s.readOutput(ctx, req.Params.URI, uri.name)
+		}
+
+		cb := s.cb.WithContext(uri.context)
+		var (
+			data     []byte
+			mimeType = "application/json"
+		)
+		switch uri.collection {
+		case "namespaces":
+			data, err = readNamespaces(ctx, cb)
+		case "workloads":
+			data, err = readWorkloads(ctx, cb, uri.namespace)
+		case koffeemcp.SnapshotsCollection:
+			var manifest string
+			manifest, err = s.snapshots.get(sessionID(ctx), uri.name)
+			data, mimeType = []byte(manifest), "application/yaml"
+		default:
+			data, err = readManifest(ctx, cb, uri)
+			mimeType = "application/yaml"
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      req.Params.URI,
+				MIMEType: mimeType,
+				Text:     string(data),
+			},
+		}, nil
+	}
+}
+
+// readOutput returns the command output saved in the session, the binary output is returned as a blob.
+func (s *Server) readOutput(ctx context.Context, uri, name string) ([]mcp.ResourceContents, error) {
+	output, err := s.outputs.get(sessionID(ctx), name)
+	if err != nil {
+		return nil, err
+	}
+	text, encoding := encodeOutput(output.data)
+	if len(encoding) > 0 {
+		return []mcp.ResourceContents{
+			mcp.BlobResourceContents{URI: uri, MIMEType: output.contentType, Blob: text},
+		}, nil
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: uri, MIMEType: output.contentType, Text: text},
+	}, nil
+}
+
+func readNamespaces(ctx context.Context, cb client.ClientBuilder) ([]byte, error) {
+	cli, err := cb.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := cli.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	items := make([]map[string]string, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		items = append(items, map[string]string{
+			"name":   ns.Name,
+			"status": string(ns.Status.Phase),
+			"age":    duration.HumanDuration(time.Since(ns.CreationTimestamp.Time)),
+		})
+	}
+	return json.Marshal(items)
+}
+
+func readWorkloads(ctx context.Context, cb client.ClientBuilder, namespace string) ([]byte, error) {
+	cli, err := cb.GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	workloads := make([]Workload, 0)
+	age := func(t metav1.Time) string {
+		return duration.HumanDuration(time.Since(t.Time))
+	}
+
+	deployments, err := cli.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		workloads = append(workloads, Workload{Kind: "Deployment", Name: d.Name, Ready: fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, d.Status.Replicas), Age: age(d.CreationTimestamp)})
+	}
+
+	statefulSets, err := cli.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, sts := range statefulSets.Items {
+		workloads = append(workloads, Workload{Kind: "StatefulSet", Name: sts.Name, Ready: fmt.Sprintf("%d/%d", sts.Status.ReadyReplicas, sts.Status.Replicas), Age: age(sts.CreationTimestamp)})
+	}
+
+	daemonSets, err := cli.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		workloads = append(workloads, Workload{Kind: "DaemonSet", Name: ds.Name, Ready: fmt.Sprintf("%d/%d", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled), Age: age(ds.CreationTimestamp)})
+	}
+
+	cronJobs, err := cli.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+	}
+	for _, cj := range cronJobs.Items {
+		workloads = append(workloads, Workload{Kind: "CronJob", Name: cj.Name, Ready: fmt.Sprintf("%d active", len(cj.Status.Active)), Age: age(cj.CreationTimestamp)})
+	}
+	return json.Marshal(workloads)
+}
+
+func readManifest(ctx context.Context, cb client.ClientBuilder, uri *resourceURI) ([]byte, error) {
+	discoveryClient, err := cb.GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := cb.GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	gvResource, err := lookupGroupVersionResource(discoveryClient, uri.kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj *unstructured.Unstructured
+	if len(uri.namespace) > 0 {
+		obj, err = dynamicClient.Resource(gvResource).Namespace(uri.namespace).Get(ctx, uri.name, metav1.GetOptions{})
+	} else {
+		obj, err = dynamicClient.Resource(gvResource).Get(ctx, uri.name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource info: %w", err)
+	}
+	obj.SetManagedFields(nil)
+	return yaml.Marshal(obj.Object)
+}
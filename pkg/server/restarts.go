@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// RestartLeaderboard is the pods ranked by the restarts, with the crash loops and the OOM kills since the window.
+type RestartLeaderboard struct {
+	Since        string        `json:"since"`
+	Scanned      int           `json:"scanned"`
+	Restarting   int           `json:"restarting"`
+	CrashLooping int           `json:"crashLooping"`
+	OOMKilled    int           `json:"oomKilled"`
+	Pods         []PodRestarts `json:"pods"`
+}
+
+// PodRestarts is the restarts of a pod and its containers.
+type PodRestarts struct {
+	Namespace   string              `json:"namespace"`
+	Name        string              `json:"name"`
+	Owner       string              `json:"owner,omitempty"`
+	Node        string              `json:"node,omitempty"`
+	Restarts    int                 `json:"restarts"`
+	LastRestart string              `json:"lastRestart,omitempty"`
+	CrashLoop   bool                `json:"crashLoop"`
+	RecentOOM   int                 `json:"recentOOMKills"`
+	Containers  []ContainerRestarts `json:"containers"`
+}
+
+// ContainerRestarts is the restarts of a container and the reason of its last termination.
+type ContainerRestarts struct {
+	Name           string `json:"name"`
+	Restarts       int32  `json:"restarts"`
+	Waiting        string `json:"waiting,omitempty"`
+	LastReason     string `json:"lastReason,omitempty"`
+	LastExitCode   int32  `json:"lastExitCode,omitempty"`
+	LastTerminated string `json:"lastTerminated,omitempty"`
+}
+
+func (s *Server) RestartLeaderboard() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", metav1.NamespaceAll)
+		labelSelector := req.GetString("labelSelector", "")
+		limit := req.GetInt("limit", 20)
+		since, err := time.ParseDuration(req.GetString("since", "24h"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+
+		slog.Info("Ranking pod restarts", "namespace", namespace, "labelSelector", labelSelector, "since", since, "limit", limit)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		cutoff := time.Now().Add(-since)
+		board := &RestartLeaderboard{Since: cutoff.UTC().Format(time.RFC3339), Scanned: len(pods.Items), Pods: make([]PodRestarts, 0)}
+		for i := range pods.Items {
+			restarts := podRestarts(&pods.Items[i], cutoff)
+			if restarts.Restarts == 0 && !restarts.CrashLoop {
+				continue
+			}
+			board.Restarting++
+			if restarts.CrashLoop {
+				board.CrashLooping++
+			}
+			if restarts.RecentOOM > 0 {
+				board.OOMKilled++
+			}
+			board.Pods = append(board.Pods, restarts)
+		}
+		sort.SliceStable(board.Pods, func(i, j int) bool {
+			a, b := board.Pods[i], board.Pods[j]
+			if a.Restarts != b.Restarts {
+				return a.Restarts > b.Restarts
+			}
+			if a.CrashLoop != b.CrashLoop {
+				return a.CrashLoop
+			}
+			return a.RecentOOM > b.RecentOOM
+		})
+		if limit > 0 && len(board.Pods) > limit {
+			board.Pods = board.Pods[:limit]
+		}
+
+		resp, err := json.Marshal(board)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// podRestarts returns the restarts of the pod counted like kubectl get pods, and the containers in the crash
+// loop or OOM killed since the cutoff.
+func podRestarts(pod *corev1.Pod, cutoff time.Time) PodRestarts {
+	restarts, lastRestart := definition.PodRestarts(pod)
+	result := PodRestarts{
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		Node:       pod.Spec.NodeName,
+		Restarts:   restarts,
+		Containers: make([]ContainerRestarts, 0),
+	}
+	if owner := metav1.GetControllerOfNoCopy(pod); owner != nil {
+		result.Owner = owner.Kind + "/" + owner.Name
+	}
+	if !lastRestart.IsZero() {
+		result.LastRestart = lastRestart.UTC().Format(time.RFC3339)
+	}
+
+	for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		container := ContainerRestarts{Name: cs.Name, Restarts: cs.RestartCount}
+		if cs.State.Waiting != nil {
+			container.Waiting = cs.State.Waiting.Reason
+			if container.Waiting == "CrashLoopBackOff" {
+				result.CrashLoop = true
+			}
+		}
+		if t := cs.LastTerminationState.Terminated; t != nil {
+			container.LastReason, container.LastExitCode = t.Reason, t.ExitCode
+			container.LastTerminated = t.FinishedAt.UTC().Format(time.RFC3339)
+			if t.Reason == "OOMKilled" && t.FinishedAt.After(cutoff) {
+				result.RecentOOM++
+			}
+		}
+		if container.Restarts > 0 || len(container.Waiting) > 0 {
+			result.Containers = append(result.Containers, container)
+		}
+	}
+	return result
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxReplayEvents is the maximum events of a session kept for the replay, the oldest ones are dropped first.
+	maxReplayEvents = 256
+	// maxReplayBytes is the maximum bytes of the events of a session kept for the replay.
+	maxReplayBytes = 4 << 20
+	// lastEventIDParam is the query parameter of the last event id for the clients unable to set the Last-Event-ID
+	// header when reconnecting.
+	lastEventIDParam = "lastEventId"
+)
+
+// sseRelays keeps the event streams of the sse sessions alive for the resume window after the clients disconnect,
+// the events are numbered by the ids of `<session id>:<sequence>` and buffered, so that a client reconnecting with
+// the Last-Event-ID resumes the same session and receives the events it missed, e.g. the results of the tool calls
+// in flight when the connection dropped.
+type sseRelays struct {
+	mu     sync.Mutex
+	relays map[string]*sseRelay
+	window time.Duration
+}
+
+func newSSERelays(window time.Duration) *sseRelays {
+	return &sseRelays{relays: make(map[string]*sseRelay), window: window}
+}
+
+// relayEvent is an event of the stream with its sequence.
+type relayEvent struct {
+	seq  uint64
+	data []byte
+}
+
+// sseRelay is the event stream of a session written by the sse server, relayed to the connection of the client
+// attached lately.
+type sseRelay struct {
+	sessionID string
+	header    http.Header
+	status    int
+	// pending is the event being written, the events are delimited by the flushes.
+	pending  bytes.Buffer
+	endpoint []byte
+
+	mu     sync.Mutex
+	seq    uint64
+	events []relayEvent
+	size   int
+	// updated is closed and replaced once the relay changes, e.g. an event is appended or a client is attached.
+	updated chan struct{}
+	// attached is the generation of the attached client, the connections of the superseded ones are closed.
+	attached uint64
+	clients  int
+	expiry   *time.Timer
+	ready    chan struct{}
+	done     chan struct{}
+	cancel   context.CancelFunc
+}
+
+func (r *sseRelay) Header() http.Header {
+	return r.header
+}
+
+func (r *sseRelay) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *sseRelay) Write(p []byte) (int, error) {
+	return r.pending.Write(p)
+}
+
+// Flush ends the event being written, the first event is the endpoint of the session.
+func (r *sseRelay) Flush() {
+	data := bytes.Clone(r.pending.Bytes())
+	r.pending.Reset()
+	if r.endpoint == nil {
+		r.endpoint = data
+		r.sessionID = endpointSessionID(data)
+		close(r.ready)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	r.events = append(r.events, relayEvent{seq: r.seq, data: data})
+	r.size += len(data)
+	for len(r.events) > maxReplayEvents || (r.size > maxReplayBytes && len(r.events) > 1) {
+		r.size -= len(r.events[0].data)
+		r.events = r.events[1:]
+	}
+	r.notify()
+}
+
+// notify wakes up the attached client, the caller must hold the lock.
+func (r *sseRelay) notify() {
+	close(r.updated)
+	r.updated = make(chan struct{})
+}
+
+// endpointSessionID returns the session id of the endpoint event like `event: endpoint\ndata: /message?sessionId=`.
+func endpointSessionID(event []byte) string {
+	_, data, _ := strings.Cut(string(event), "data: ")
+	u, err := url.Parse(strings.TrimSpace(data))
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("sessionId")
+}
+
+// resumedSessionID returns the session id and the sequence of the last event received by the reconnecting client,
+// the empty id is returned if the request doesn't resume a session.
+func resumedSessionID(r *http.Request) (string, uint64) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if len(lastEventID) == 0 {
+		lastEventID = r.URL.Query().Get(lastEventIDParam)
+	}
+	sessionID, seq, found := strings.Cut(lastEventID, ":")
+	if !found {
+		return "", 0
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", 0
+	}
+	return sessionID, n
+}
+
+// handler serves the event streams of the ssePath through the relays, the other requests are handled by the next
+// handler.
+func (s *sseRelays) handler(next http.Handler, ssePath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ssePath || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		if sessionID, seq := resumedSessionID(r); len(sessionID) > 0 {
+			s.mu.Lock()
+			relay, ok := s.relays[sessionID]
+			s.mu.Unlock()
+			if ok {
+				slog.Info("Resuming the sse session", "lastEvent", seq)
+				relay.serve(r.Context(), w, flusher, seq, true, s.window)
+				return
+			}
+			// the expired session is not resumed, the client starts a new session by the endpoint event.
+			slog.Info("The sse session to resume is expired, starting a new session")
+		}
+
+		relayCtx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		relay := &sseRelay{
+			header:  make(http.Header),
+			status:  http.StatusOK,
+			updated: make(chan struct{}),
+			ready:   make(chan struct{}),
+			done:    make(chan struct{}),
+			cancel:  cancel,
+		}
+		go func() {
+			defer close(relay.done)
+			defer cancel()
+			next.ServeHTTP(relay, r.Clone(relayCtx))
+		}()
+		select {
+		case <-relay.ready:
+		case <-relay.done:
+			// the session is rejected before the endpoint event, e.g. the registration failed.
+			for key, values := range relay.header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(relay.status)
+			_, _ = w.Write(relay.pending.Bytes())
+			return
+		}
+
+		s.mu.Lock()
+		s.relays[relay.sessionID] = relay
+		s.mu.Unlock()
+		go func() {
+			<-relay.done
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.relays, relay.sessionID)
+		}()
+		relay.serve(r.Context(), w, flusher, 0, false, s.window)
+	})
+}
+
+// serve relays the events after the sequence to the client until it disconnects or is superseded by another
+// connection of the session. The session ends if no client attaches again within the window.
+func (r *sseRelay) serve(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, last uint64, resumed bool, window time.Duration) {
+	r.mu.Lock()
+	r.attached++
+	generation := r.attached
+	r.clients++
+	if r.expiry != nil {
+		r.expiry.Stop()
+		r.expiry = nil
+	}
+	if len(r.events) > 0 && resumed && r.events[0].seq > last+1 {
+		slog.Warn("Some events of the resumed sse session are dropped from the buffer", "session", r.sessionID, "lastEvent", last, "firstBuffered", r.events[0].seq)
+	}
+	r.notify()
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.clients--
+		if r.clients == 0 {
+			r.expiry = time.AfterFunc(window, r.cancel)
+		}
+	}()
+
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(r.status)
+	// the endpoint event has no id so the last event id of the client is unchanged.
+	_, _ = w.Write(r.endpoint)
+	flusher.Flush()
+
+	for {
+		r.mu.Lock()
+		if r.attached != generation {
+			r.mu.Unlock()
+			return
+		}
+		var events []relayEvent
+		for _, event := range r.events {
+			if event.seq > last {
+				events = append(events, event)
+			}
+		}
+		updated := r.updated
+		r.mu.Unlock()
+
+		for _, event := range events {
+			if _, err := fmt.Fprintf(w, "id: %s:%d\n%s", r.sessionID, event.seq, event.data); err != nil {
+				return
+			}
+			last = event.seq
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.done:
+			return
+		case <-updated:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResumedSessionID(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		query       string
+		wantSession string
+		wantSeq     uint64
+	}{
+		{name: "header", header: "abc:12", wantSession: "abc", wantSeq: 12},
+		{name: "query parameter", query: "abc:3", wantSession: "abc", wantSeq: 3},
+		{name: "header over the query parameter", header: "abc:5", query: "def:6", wantSession: "abc", wantSeq: 5},
+		{name: "new session"},
+		{name: "id without the sequence", header: "abc"},
+		{name: "invalid sequence", header: "abc:x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/sse", nil)
+			if len(tt.header) > 0 {
+				r.Header.Set("Last-Event-ID", tt.header)
+			}
+			if len(tt.query) > 0 {
+				r.URL.RawQuery = lastEventIDParam + "=" + tt.query
+			}
+			if session, seq := resumedSessionID(r); session != tt.wantSession || seq != tt.wantSeq {
+				t.Fatalf("got %q:%d, want %q:%d", session, seq, tt.wantSession, tt.wantSeq)
+			}
+		})
+	}
+}
+
+func TestRelaySequence(t *testing.T) {
+	eventID := regexp.MustCompile(`(?m)^id: s1:(\d+)$`)
+	tests := []struct {
+		name    string
+		events  int
+		last    uint64
+		resumed bool
+		// wantFirst and wantLast are the sequences of the events relayed, none if zero.
+		wantFirst, wantLast uint64
+	}{
+		{name: "new stream", events: 3, wantFirst: 1, wantLast: 3},
+		{name: "resumed after the last event", events: 3, last: 2, resumed: true, wantFirst: 3, wantLast: 3},
+		{name: "resumed with nothing missed", events: 3, last: 3, resumed: true},
+		// the oldest events are dropped from the buffer, the client gets the ones still buffered.
+		{name: "resumed after the dropped events", events: maxReplayEvents + 4, last: 2, resumed: true, wantFirst: 5, wantLast: maxReplayEvents + 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay := &sseRelay{
+				header:  make(http.Header),
+				status:  http.StatusOK,
+				updated: make(chan struct{}),
+				ready:   make(chan struct{}),
+				done:    make(chan struct{}),
+				cancel:  func() {},
+			}
+			_, _ = fmt.Fprint(relay, "event: endpoint\ndata: /message?sessionId=s1\n\n")
+			relay.Flush()
+			for i := range tt.events {
+				_, _ = fmt.Fprintf(relay, "event: message\ndata: %d\n\n", i)
+				relay.Flush()
+			}
+			if relay.sessionID != "s1" || len(relay.events) != min(tt.events, maxReplayEvents) {
+				t.Fatalf("got session %q with %d events buffered, want s1 with %d", relay.sessionID, len(relay.events), min(tt.events, maxReplayEvents))
+			}
+
+			// the canceled client receives the buffered events once.
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			w := httptest.NewRecorder()
+			relay.serve(ctx, w, w, tt.last, tt.resumed, time.Minute)
+			relay.expiry.Stop()
+
+			var got []uint64
+			for _, match := range eventID.FindAllStringSubmatch(w.Body.String(), -1) {
+				seq, _ := strconv.ParseUint(match[1], 10, 64)
+				got = append(got, seq)
+			}
+			var want []uint64
+			for seq := tt.wantFirst; seq > 0 && seq <= tt.wantLast; seq++ {
+				want = append(want, seq)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("got events %v, want %d to %d", got, tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
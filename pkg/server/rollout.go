@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// defaultRolloutWaitTimeout is the default maximum duration to wait for the rollouts to finish, if the tool call
+// has no timeout.
+const defaultRolloutWaitTimeout = 5 * time.Minute
+
+// The rollout states of a workload.
+const (
+	rolloutComplete    = "Complete"
+	rolloutProgressing = "Progressing"
+	rolloutStalled     = "Stalled"
+	rolloutUnknown     = "Unknown"
+)
+
+// RolloutSummary is the rollout state of the workloads matching the selector, the release is finished once all
+// of them are complete.
+type RolloutSummary struct {
+	Namespace     string            `json:"namespace"`
+	LabelSelector string            `json:"labelSelector,omitempty"`
+	Finished      bool              `json:"finished"`
+	Total         int               `json:"total"`
+	Complete      int               `json:"complete"`
+	Progressing   int               `json:"progressing"`
+	Stalled       int               `json:"stalled"`
+	Unknown       int               `json:"unknown"`
+	Workloads     []WorkloadRollout `json:"workloads"`
+	Message       string            `json:"message,omitempty"`
+}
+
+// WorkloadRollout is the rollout state of a Deployment, StatefulSet or DaemonSet judged like kubectl rollout status.
+type WorkloadRollout struct {
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Desired    int32    `json:"desired"`
+	Ready      int32    `json:"ready"`
+	Updated    int32    `json:"updated"`
+	Available  int32    `json:"available"`
+	State      string   `json:"state"`
+	Message    string   `json:"message"`
+	Conditions []string `json:"conditions,omitempty"`
+}
+
+func (s *Server) RolloutStatus() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		labelSelector := req.GetString("labelSelector", "")
+		waitForRollout := req.GetBool("wait", false)
+		waitTimeout := waitTimeoutOf(ctx, req, defaultRolloutWaitTimeout)
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+
+		slog.Info("Checking rollout status", "namespace", namespace, "labelSelector", labelSelector, "wait", waitForRollout)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		summary, err := rolloutSummary(ctx, cli, namespace, labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		// the stalled rollouts won't finish without a change, and the paused or not rolling ones can't finish, so
+		// the wait stops unless some rollouts are in progress.
+		if waitForRollout && summary.Progressing > 0 && summary.Stalled == 0 {
+			err = wait.PollUntilContextTimeout(ctx, 2*time.Second, waitTimeout, false, func(ctx context.Context) (bool, error) {
+				if summary, err = rolloutSummary(ctx, cli, namespace, labelSelector); err != nil {
+					return false, err
+				}
+				return summary.Progressing == 0 || summary.Stalled > 0, nil
+			})
+			if err != nil && !wait.Interrupted(err) {
+				return nil, fmt.Errorf("failed to wait for rollouts: %w", err)
+			}
+			if summary.Progressing > 0 && summary.Stalled == 0 {
+				summary.Message = fmt.Sprintf("the rollouts are still in progress after %s", waitTimeout)
+			}
+		}
+		if waitForRollout && summary.Unknown > 0 && len(summary.Message) == 0 {
+			summary.Message = fmt.Sprintf("the rollouts of %d workloads are not waited since they are paused or not rolling updates", summary.Unknown)
+		}
+
+		resp, err := json.Marshal(summary)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// waitTimeoutOf returns the duration to wait in the tool call, waitTimeoutSeconds or the default, capped by the
+// time left of the call with a margin, so that the last state is returned rather than the timeout of the call.
+func waitTimeoutOf(ctx context.Context, req mcp.CallToolRequest, defaultTimeout time.Duration) time.Duration {
+	timeout := time.Duration(req.GetInt("waitTimeoutSeconds", int(defaultTimeout.Seconds()))) * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		timeout = min(timeout, left-left/10)
+	}
+	return max(timeout, 0)
+}
+
+// waitWorkloadRollout waits for the rollout of the workload to complete or stall, the rollouts which can't finish,
+// i.e. of the paused Deployments and the workloads without rolling updates, are returned at once. The message
+// explains the unfinished rollout, nil is returned for the kinds without the rollout status.
+func waitWorkloadRollout(ctx context.Context, cli kubernetes.Interface, kind, namespace, name string, timeout time.Duration) (*WorkloadRollout, string, error) {
+	var rollout *WorkloadRollout
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		if rollout, err = workloadRollout(ctx, cli, kind, namespace, name); err != nil || rollout == nil {
+			return true, err
+		}
+		return rollout.State != rolloutProgressing, nil
+	})
+	if err != nil && !wait.Interrupted(err) {
+		return nil, "", fmt.Errorf("failed to wait for rollout: %w", err)
+	}
+	switch {
+	case rollout == nil:
+		return nil, "", nil
+	case rollout.State == rolloutProgressing:
+		return rollout, fmt.Sprintf("the rollout is still in progress after %s", timeout), nil
+	case rollout.State == rolloutUnknown:
+		return rollout, fmt.Sprintf("the rollout is not waited since %s", rollout.Message), nil
+	}
+	return rollout, "", nil
+}
+
+func rolloutSummary(ctx context.Context, cli kubernetes.Interface, namespace, labelSelector string) (*RolloutSummary, error) {
+	options := metav1.ListOptions{LabelSelector: labelSelector}
+	summary := &RolloutSummary{Namespace: namespace, LabelSelector: labelSelector, Workloads: make([]WorkloadRollout, 0)}
+
+	deployments, err := cli.AppsV1().Deployments(namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		summary.Workloads = append(summary.Workloads, deploymentRollout(&deployments.Items[i]))
+	}
+	statefulSets, err := cli.AppsV1().StatefulSets(namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for i := range statefulSets.Items {
+		summary.Workloads = append(summary.Workloads, statefulSetRollout(&statefulSets.Items[i]))
+	}
+	daemonSets, err := cli.AppsV1().DaemonSets(namespace).List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for i := range daemonSets.Items {
+		summary.Workloads = append(summary.Workloads, daemonSetRollout(&daemonSets.Items[i]))
+	}
+
+	order := map[string]int{rolloutStalled: 0, rolloutProgressing: 1, rolloutUnknown: 2, rolloutComplete: 3}
+	sort.SliceStable(summary.Workloads, func(i, j int) bool {
+		return order[summary.Workloads[i].State] < order[summary.Workloads[j].State]
+	})
+	for _, w := range summary.Workloads {
+		switch w.State {
+		case rolloutComplete:
+			summary.Complete++
+		case rolloutStalled:
+			summary.Stalled++
+		case rolloutUnknown:
+			summary.Unknown++
+		default:
+			summary.Progressing++
+		}
+	}
+	summary.Total = len(summary.Workloads)
+	summary.Finished = summary.Complete == summary.Total
+	return summary, nil
+}
+
+func deploymentRollout(d *appsv1.Deployment) WorkloadRollout {
+	desired := ptr.Deref(d.Spec.Replicas, 1)
+	rollout := WorkloadRollout{
+		Kind:      "Deployment",
+		Name:      d.Name,
+		Desired:   desired,
+		Ready:     d.Status.ReadyReplicas,
+		Updated:   d.Status.UpdatedReplicas,
+		Available: d.Status.AvailableReplicas,
+		State:     rolloutProgressing,
+	}
+	for _, c := range d.Status.Conditions {
+		rollout.Conditions = append(rollout.Conditions, fmt.Sprintf("%s=%s (%s)", c.Type, c.Status, c.Reason))
+	}
+
+	switch {
+	case d.Spec.Paused:
+		rollout.State, rollout.Message = rolloutUnknown, "the rollout is paused"
+	case d.Generation > d.Status.ObservedGeneration:
+		rollout.Message = "waiting for the deployment spec update to be observed"
+	case deploymentStalled(d) != nil:
+		rollout.State, rollout.Message = rolloutStalled, deploymentStalled(d).Message
+	case d.Status.UpdatedReplicas < desired:
+		rollout.Message = fmt.Sprintf("%d out of %d new replicas have been updated", d.Status.UpdatedReplicas, desired)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		rollout.Message = fmt.Sprintf("%d old replicas are pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		rollout.Message = fmt.Sprintf("%d of %d updated replicas are available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	default:
+		rollout.State, rollout.Message = rolloutComplete, "successfully rolled out"
+	}
+	return rollout
+}
+
+// deploymentStalled returns the Progressing condition if the deployment exceeded its progress deadline.
+func deploymentStalled(d *appsv1.Deployment) *appsv1.DeploymentCondition {
+	for i := range d.Status.Conditions {
+		c := &d.Status.Conditions[i]
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return c
+		}
+	}
+	return nil
+}
+
+func statefulSetRollout(sts *appsv1.StatefulSet) WorkloadRollout {
+	desired := ptr.Deref(sts.Spec.Replicas, 1)
+	rollout := WorkloadRollout{
+		Kind:      "StatefulSet",
+		Name:      sts.Name,
+		Desired:   desired,
+		Ready:     sts.Status.ReadyReplicas,
+		Updated:   sts.Status.UpdatedReplicas,
+		Available: sts.Status.AvailableReplicas,
+		State:     rolloutProgressing,
+	}
+	for _, c := range sts.Status.Conditions {
+		rollout.Conditions = append(rollout.Conditions, fmt.Sprintf("%s=%s (%s)", c.Type, c.Status, c.Reason))
+	}
+
+	switch {
+	case sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType:
+		rollout.State, rollout.Message = rolloutUnknown, fmt.Sprintf("the %s update strategy has no rollout status", sts.Spec.UpdateStrategy.Type)
+	case sts.Generation > sts.Status.ObservedGeneration:
+		rollout.Message = "waiting for the statefulset spec update to be observed"
+	case sts.Status.ReadyReplicas < desired:
+		rollout.Message = fmt.Sprintf("%d of %d pods are ready", sts.Status.ReadyReplicas, desired)
+	case sts.Spec.UpdateStrategy.RollingUpdate != nil && sts.Spec.UpdateStrategy.RollingUpdate.Partition != nil && *sts.Spec.UpdateStrategy.RollingUpdate.Partition > 0:
+		partition := *sts.Spec.UpdateStrategy.RollingUpdate.Partition
+		if sts.Status.UpdatedReplicas < desired-partition {
+			rollout.Message = fmt.Sprintf("%d of %d new pods have been updated above the partition %d", sts.Status.UpdatedReplicas, desired-partition, partition)
+		} else {
+			rollout.State, rollout.Message = rolloutComplete, fmt.Sprintf("partitioned rollout complete: %d new pods have been updated", sts.Status.UpdatedReplicas)
+		}
+	case sts.Status.UpdateRevision != sts.Status.CurrentRevision:
+		rollout.Message = fmt.Sprintf("waiting for the pods to be updated to the revision %s, %d updated", sts.Status.UpdateRevision, sts.Status.UpdatedReplicas)
+	default:
+		rollout.State, rollout.Message = rolloutComplete, fmt.Sprintf("rolling update complete at the revision %s", sts.Status.CurrentRevision)
+	}
+	return rollout
+}
+
+func daemonSetRollout(ds *appsv1.DaemonSet) WorkloadRollout {
+	rollout := WorkloadRollout{
+		Kind:      "DaemonSet",
+		Name:      ds.Name,
+		Desired:   ds.Status.DesiredNumberScheduled,
+		Ready:     ds.Status.NumberReady,
+		Updated:   ds.Status.UpdatedNumberScheduled,
+		Available: ds.Status.NumberAvailable,
+		State:     rolloutProgressing,
+	}
+	for _, c := range ds.Status.Conditions {
+		rollout.Conditions = append(rollout.Conditions, fmt.Sprintf("%s=%s (%s)", c.Type, c.Status, c.Reason))
+	}
+
+	switch {
+	case ds.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType:
+		rollout.State, rollout.Message = rolloutUnknown, fmt.Sprintf("the %s update strategy has no rollout status", ds.Spec.UpdateStrategy.Type)
+	case ds.Generation > ds.Status.ObservedGeneration:
+		rollout.Message = "waiting for the daemonset spec update to be observed"
+	case ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled:
+		rollout.Message = fmt.Sprintf("%d out of %d new pods have been updated", ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	case ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled:
+		rollout.Message = fmt.Sprintf("%d of %d updated pods are available", ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled)
+	default:
+		rollout.State, rollout.Message = rolloutComplete, "successfully rolled out"
+	}
+	return rollout
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestWaitTimeoutOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]any
+		deadline time.Duration
+		want     time.Duration
+	}{
+		{name: "default without the deadline", want: 5 * time.Minute},
+		{name: "explicit without the deadline", args: map[string]any{"waitTimeoutSeconds": 600}, want: 10 * time.Minute},
+		{name: "default capped by the deadline", deadline: time.Minute, want: 54 * time.Second},
+		{name: "explicit capped by the deadline", args: map[string]any{"waitTimeoutSeconds": 300}, deadline: time.Minute, want: 54 * time.Second},
+		{name: "explicit within the deadline", args: map[string]any{"waitTimeoutSeconds": 10}, deadline: time.Minute, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = tt.args
+			// the time left of the call shrinks while the test runs.
+			if got := waitTimeoutOf(ctx, req, defaultRolloutWaitTimeout); got > tt.want || got < tt.want-time.Second {
+				t.Fatalf("got timeout %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitWorkloadRollout(t *testing.T) {
+	deployment := func(name string, paused bool, updated int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Generation: 1},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2), Paused: paused},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: updated, AvailableReplicas: updated},
+		}
+	}
+	onDelete := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "db"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       ptr.To[int32](1),
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		kind        string
+		object      runtime.Object
+		wantState   string
+		wantMessage string
+	}{
+		{name: "complete", kind: "Deployment", object: deployment("web", false, 2), wantState: rolloutComplete},
+		{name: "paused", kind: "Deployment", object: deployment("web", true, 1), wantState: rolloutUnknown, wantMessage: "the rollout is not waited since the rollout is paused"},
+		{name: "on delete", kind: "StatefulSet", object: onDelete, wantState: rolloutUnknown, wantMessage: "the OnDelete update strategy has no rollout status"},
+		{name: "in progress", kind: "Deployment", object: deployment("web", false, 1), wantState: rolloutProgressing, wantMessage: "still in progress"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := fake.NewClientset(tt.object)
+			name := tt.object.(metav1.Object).GetName()
+			started := time.Now()
+			rollout, message, err := waitWorkloadRollout(context.Background(), cli, tt.kind, "shop", name, 100*time.Millisecond)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rollout == nil || rollout.State != tt.wantState || !strings.Contains(message, tt.wantMessage) {
+				t.Fatalf("got rollout %+v with message %q, want %s with %q", rollout, message, tt.wantState, tt.wantMessage)
+			}
+			if elapsed := time.Since(started); tt.wantState != rolloutProgressing && elapsed > time.Second {
+				t.Fatalf("the wait took %s, want it returned at once", elapsed)
+			}
+		})
+	}
+}
+
+func TestRolloutStatusWait(t *testing.T) {
+	paused := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2), Paused: true},
+	}
+	s, _ := newFakeServer(nil, []runtime.Object{paused})
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"namespace": "shop", "wait": true}
+
+	started := time.Now()
+	result, err := s.RolloutStatus()(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("the wait took %s, want the paused rollout returned at once", elapsed)
+	}
+	var summary RolloutSummary
+	if err = json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Unknown != 1 || summary.Progressing != 0 || !strings.Contains(summary.Message, "not waited") {
+		t.Fatalf("got summary %+v, want the paused rollout not waited", summary)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultScrubFields are the JSON pointers of the noisy fields dropped from the returned objects.
+var DefaultScrubFields = []string{
+	"/metadata/managedFields",
+	"/metadata/annotations/kubectl.kubernetes.io~1last-applied-configuration",
+	"/metadata/resourceVersion",
+	"/metadata/uid",
+	"/metadata/generation",
+	"/metadata/selfLink",
+}
+
+// clusterPopulatedFields are the fields populated by the cluster, which are dropped from the exported manifests.
+var clusterPopulatedFields = [][]string{
+	{"status"},
+	{"metadata", "uid"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "selfLink"},
+	{"metadata", "managedFields"},
+	{"metadata", "annotations", lastAppliedConfigAnnotation},
+	{"metadata", "annotations", "deployment.kubernetes.io/revision"},
+}
+
+// scrubber drops the noisy fields from the objects returned to the client, which are rarely useful to the agents
+// but take a large part of the context, e.g. the managed fields and the last applied configuration.
+type scrubber struct {
+	fields [][]string
+}
+
+// newScrubber creates the scrubber of the fields in the JSON pointers like /metadata/uid, the ~1 and ~0 in the
+// pointers are unescaped to / and ~ like RFC 6901.
+func newScrubber(pointers []string) *scrubber {
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	s := &scrubber{fields: make([][]string, 0, len(pointers))}
+	for _, pointer := range pointers {
+		if pointer = strings.TrimPrefix(strings.TrimSpace(pointer), "/"); len(pointer) == 0 {
+			continue
+		}
+		segments := strings.Split(pointer, "/")
+		for i := range segments {
+			segments[i] = unescape.Replace(segments[i])
+		}
+		s.fields = append(s.fields, segments)
+	}
+	return s
+}
+
+// scrub drops the fields from the object, and the empty values of the status which are mostly the defaults.
+func (s *scrubber) scrub(obj map[string]any) {
+	for _, field := range s.fields {
+		unstructured.RemoveNestedField(obj, field...)
+	}
+	if metadata, ok := obj["metadata"].(map[string]any); ok {
+		for _, key := range []string{"annotations", "labels", "creationTimestamp"} {
+			if isEmptyValue(metadata[key]) {
+				delete(metadata, key)
+			}
+		}
+	}
+	if status, ok := obj["status"]; ok {
+		if status = pruneEmpty(status); status == nil {
+			delete(obj, "status")
+		} else {
+			obj["status"] = status
+		}
+	}
+}
+
+// pruneEmpty removes the null, the empty strings, the empty lists and the empty maps recursively, nil is returned
+// if nothing is left.
+func pruneEmpty(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if item = pruneEmpty(item); item == nil {
+				delete(v, key)
+			} else {
+				v[key] = item
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+		return v
+	case []any:
+		items := v[:0]
+		for _, item := range v {
+			if item = pruneEmpty(item); item != nil {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return items
+	default:
+		if isEmptyValue(v) {
+			return nil
+		}
+		return v
+	}
+}
+
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// exportObject strips the fields populated by the cluster from the object, so that the manifest is re-applyable
+// to the cluster or another one.
+func exportObject(obj *unstructured.Unstructured) {
+	for _, field := range clusterPopulatedFields {
+		unstructured.RemoveNestedField(obj.Object, field...)
+	}
+	switch obj.GetKind() {
+	case "Service":
+		// the allocated cluster IPs are immutable and conflict with the other clusters, except the headless ones.
+		if clusterIP, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); clusterIP != "None" {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "pv.kubernetes.io/bind-completed")
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "pv.kubernetes.io/bound-by-controller")
+	}
+	if metadata, ok := obj.Object["metadata"].(map[string]any); ok && isEmptyValue(metadata["annotations"]) {
+		delete(metadata, "annotations")
+	}
+}
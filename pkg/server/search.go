@@ -0,0 +1,234 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/discovery"
+)
+
+// defaultSearchKinds are the kinds searched when no kinds are specified.
+var defaultSearchKinds = []string{
+	"Pod", "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob", "Service", "Ingress",
+	"ConfigMap", "Secret", "PersistentVolumeClaim", "ServiceAccount",
+}
+
+// SearchResult is an object matched by the search.
+type SearchResult struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	Age       string            `json:"age"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// SearchReport is the report of the search across the kinds.
+type SearchReport struct {
+	Results []SearchResult    `json:"results"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// defaultLabelQueryKinds are the kinds queried by the label selector when no kinds are specified, they make up
+// the footprint of an application.
+var defaultLabelQueryKinds = []string{
+	"Pod", "ReplicaSet", "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob", "Service", "Ingress",
+	"ConfigMap", "Secret", "PersistentVolumeClaim", "ServiceAccount", "HorizontalPodAutoscaler",
+	"PodDisruptionBudget", "NetworkPolicy",
+}
+
+type searchTarget struct {
+	kind       string
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+func (s *Server) FindResource() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pattern, err := req.RequireString("pattern")
+		if err != nil {
+			return nil, err
+		}
+		kinds := req.GetStringSlice("kinds", defaultSearchKinds)
+		namespace := req.GetString("namespace", "")
+		useRegex := req.GetBool("regex", false)
+
+		slog.Info("Finding resources", "pattern", pattern, "regex", useRegex, "kinds", kinds, "namespace", namespace)
+
+		match := func(name string) bool {
+			return strings.Contains(strings.ToLower(name), strings.ToLower(pattern))
+		}
+		if useRegex {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			match = re.MatchString
+		}
+
+		report, err := s.searchResources(ctx, kinds, namespace, metav1.ListOptions{}, func(obj *unstructured.Unstructured) bool {
+			return match(obj.GetName())
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) QueryByLabels() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		labelSelector, err := req.RequireString("labelSelector")
+		if err != nil {
+			return nil, err
+		}
+		kinds := req.GetStringSlice("kinds", defaultLabelQueryKinds)
+		namespace := req.GetString("namespace", "")
+
+		if _, err = labels.Parse(labelSelector); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", labelSelector, err)
+		}
+
+		slog.Info("Querying resources by labels", "labelSelector", labelSelector, "kinds", kinds, "namespace", namespace)
+
+		report, err := s.searchResources(ctx, kinds, namespace, metav1.ListOptions{LabelSelector: labelSelector}, func(*unstructured.Unstructured) bool {
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// searchResources lists the objects of the kinds concurrently and returns the objects accepted by the match
+// function, the errors of the kinds are reported per kind rather than failing the whole search.
+func (s *Server) searchResources(ctx context.Context, kinds []string, namespace string, options metav1.ListOptions, match func(*unstructured.Unstructured) bool) (*SearchReport, error) {
+	discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SearchReport{Results: make([]SearchResult, 0), Errors: make(map[string]string)}
+	targets, err := resolveSearchTargets(discoveryClient, kinds, report)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		now = time.Now()
+	)
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target searchTarget) {
+			defer wg.Done()
+
+			var items *unstructured.UnstructuredList
+			var err error
+			if target.namespaced && len(namespace) > 0 {
+				items, err = dynamicClient.Resource(target.gvr).Namespace(namespace).List(ctx, options)
+			} else {
+				items, err = dynamicClient.Resource(target.gvr).List(ctx, options)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Errors[target.kind] = err.Error()
+				return
+			}
+			for i := range items.Items {
+				obj := &items.Items[i]
+				if !match(obj) {
+					continue
+				}
+				report.Results = append(report.Results, SearchResult{
+					Kind:      target.kind,
+					Namespace: obj.GetNamespace(),
+					Name:      obj.GetName(),
+					Age:       duration.HumanDuration(now.Sub(obj.GetCreationTimestamp().Time)),
+					Labels:    obj.GetLabels(),
+				})
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	sort.Slice(report.Results, func(i, j int) bool {
+		a, b := report.Results[i], report.Results[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// resolveSearchTargets resolves the kinds to the preferred resources with a single discovery, the kinds not
+// served by the cluster are reported as errors.
+func resolveSearchTargets(discoveryClient discovery.DiscoveryInterface, kinds []string, report *SearchReport) ([]searchTarget, error) {
+	apiResources, err := discoveryClient.ServerPreferredResources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api resources: %w", err)
+	}
+
+	served := make(map[string]searchTarget)
+	for _, apiResource := range apiResources {
+		gv, err := schema.ParseGroupVersion(apiResource.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range apiResource.APIResources {
+			if _, ok := served[resource.Kind]; ok || strings.Contains(resource.Name, "/") {
+				continue
+			}
+			served[resource.Kind] = searchTarget{
+				kind:       resource.Kind,
+				gvr:        gv.WithResource(resource.Name),
+				namespaced: resource.Namespaced,
+			}
+		}
+	}
+
+	targets := make([]searchTarget, 0, len(kinds))
+	for _, kind := range kinds {
+		target, ok := served[kind]
+		if !ok {
+			report.Errors[kind] = fmt.Sprintf("not found resource for kind %q", kind)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"cola.io/koffee/pkg/audit"
+)
+
+// WorkloadAudit is the audit result of a workload, the workload is audited once by the spec of any of its pods.
+type WorkloadAudit struct {
+	Workload string          `json:"workload"`
+	Score    int             `json:"score"`
+	Findings []audit.Finding `json:"findings"`
+}
+
+// NamespaceAudit is the audit result of the workloads in a namespace, only the workloads with findings are listed.
+type NamespaceAudit struct {
+	Namespace string          `json:"namespace"`
+	Score     int             `json:"score"`
+	Workloads int             `json:"workloads"`
+	Passed    int             `json:"passed"`
+	Findings  []WorkloadAudit `json:"findings,omitempty"`
+}
+
+// SecurityAuditReport is the security posture of the workloads, the namespaces are sorted by the lowest score.
+type SecurityAuditReport struct {
+	Score      int              `json:"score"`
+	Namespaces []NamespaceAudit `json:"namespaces"`
+	// Rules are the number of the findings per rule.
+	Rules map[string]int `json:"rules"`
+}
+
+func (s *Server) SecurityAudit() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		minSeverity := audit.Severity("")
+		if severity := req.GetString("minSeverity", ""); len(severity) > 0 {
+			var err error
+			if minSeverity, err = audit.ParseSeverity(severity); err != nil {
+				return nil, err
+			}
+		}
+
+		slog.Info("Auditing workload security", "namespace", namespace, "minSeverity", minSeverity)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		owners, err := workloadOwners(ctx, cli, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		engine := audit.NewEngine()
+		report := &SecurityAuditReport{Namespaces: make([]NamespaceAudit, 0), Rules: make(map[string]int)}
+		namespaces := make(map[string]*NamespaceAudit)
+		audited := make(map[string]bool)
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			workload := podWorkload(pod, owners)
+			if audited[workload] {
+				continue
+			}
+			audited[workload] = true
+
+			ns, ok := namespaces[pod.Namespace]
+			if !ok {
+				ns = &NamespaceAudit{Namespace: pod.Namespace}
+				namespaces[pod.Namespace] = ns
+			}
+			ns.Workloads++
+
+			findings := engine.Audit(&pod.Spec, minSeverity)
+			if len(findings) == 0 {
+				ns.Passed++
+				continue
+			}
+			for _, f := range findings {
+				report.Rules[f.Rule]++
+			}
+			ns.Findings = append(ns.Findings, WorkloadAudit{Workload: workload, Score: audit.Score(findings), Findings: findings})
+		}
+
+		// the score of a namespace or the cluster is the average of the scores of its workloads.
+		var totalScore, totalWorkloads int
+		for _, ns := range namespaces {
+			score := ns.Passed * 100
+			for _, w := range ns.Findings {
+				score += w.Score
+			}
+			totalScore += score
+			totalWorkloads += ns.Workloads
+			ns.Score = score / ns.Workloads
+
+			sort.Slice(ns.Findings, func(i, j int) bool {
+				if ns.Findings[i].Score != ns.Findings[j].Score {
+					return ns.Findings[i].Score < ns.Findings[j].Score
+				}
+				return ns.Findings[i].Workload < ns.Findings[j].Workload
+			})
+			report.Namespaces = append(report.Namespaces, *ns)
+		}
+		report.Score = 100
+		if totalWorkloads > 0 {
+			report.Score = totalScore / totalWorkloads
+		}
+		sort.Slice(report.Namespaces, func(i, j int) bool {
+			if report.Namespaces[i].Score != report.Namespaces[j].Score {
+				return report.Namespaces[i].Score < report.Namespaces[j].Score
+			}
+			return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+		})
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 
@@ -17,12 +19,67 @@ import (
 
 type ServerOption func(*Server)
 
+// DefaultServerName is the name of the server reported to the clients on the initialization.
+const DefaultServerName = "Kubernetes MCP Server"
+
 type Server struct {
-	svr       *server.MCPServer
-	generator *definition.HumanReadableGenerator
-	cb        client.ClientBuilder
-	transport string
-	port      int
+	svr             *server.MCPServer
+	generator       *definition.HumanReadableGenerator
+	cb              client.ClientBuilder
+	sessions        *sessionState
+	transport       string
+	port            int
+	maxLogTailLines int
+	maxLogBytes     int64
+	maxExecBytes    int64
+	maxResultBytes  int
+	maxConcurrent   int
+	rateLimit       float64
+	rateBurst       int
+	toolTimeout     time.Duration
+	toolTimeouts    map[string]time.Duration
+	scrubFields     []string
+	scrubber        *scrubber
+	// gitAllowedHosts are the hosts of the git repositories the manifests are fetched from.
+	gitAllowedHosts     []string
+	gitMaxManifestBytes int64
+	gitMaxFetchBytes    int64
+	snapshotDir         string
+	localFileDir        string
+	snapshots           *snapshotStore
+	outputs             *outputStore
+	// pricer prices the nodes to estimate the cost of the workloads.
+	pricer NodePricer
+	// cache caches the results of the read-only tools, nil if disabled.
+	cache           *resultCache
+	cacheTTL        time.Duration
+	cacheMaxEntries int
+	callOptions     *callOptions
+	// leases coordinates the sessions of the replicas in the sse mode, nil if disabled.
+	leases              *sessionLeases
+	sessionLeaseOptions *SessionLeaseOptions
+	// sseResumeWindow is how long the sse sessions are kept for the clients to resume them, 0 disables it.
+	sseResumeWindow time.Duration
+	// name and instructions are reported to the clients on the initialization, the instructions guide the agents
+	// on how to use the server, e.g. the allowed namespaces and the naming conventions of the clusters.
+	name         string
+	instructions string
+	// tools are the registered tools reported by the server info.
+	tools []ToolInfo
+	// toolProperties are the parameters of the registered tools, the session defaults only fill the parameters
+	// accepted by the tool.
+	toolProperties map[string]map[string]any
+}
+
+// WithServerIdentity sets the name and the instructions of the server reported to the clients, the default name is
+// kept if the name is empty.
+func WithServerIdentity(name, instructions string) func(*Server) {
+	return func(s *Server) {
+		if len(name) > 0 {
+			s.name = name
+		}
+		s.instructions = instructions
+	}
 }
 
 // WithTransport sets the transport type for the server.
@@ -39,32 +96,206 @@ func WithPort(p int) func(*Server) {
 	}
 }
 
+// WithMaxLogTailLines sets the maximum lines of the pod logs returned by the tail.
+func WithMaxLogTailLines(n int) func(*Server) {
+	return func(s *Server) {
+		s.maxLogTailLines = n
+	}
+}
+
+// WithMaxLogBytes sets the maximum bytes of the pod logs returned for each container.
+func WithMaxLogBytes(n int64) func(*Server) {
+	return func(s *Server) {
+		s.maxLogBytes = n
+	}
+}
+
+// WithMaxExecOutputBytes sets the maximum bytes of the stdout and the stderr kept for each command run in a container.
+func WithMaxExecOutputBytes(n int64) func(*Server) {
+	return func(s *Server) {
+		s.maxExecBytes = n
+	}
+}
+
+// WithMaxResultBytes sets the maximum bytes of each tool result, 0 means no limit.
+func WithMaxResultBytes(n int) func(*Server) {
+	return func(s *Server) {
+		s.maxResultBytes = n
+	}
+}
+
+// WithMaxConcurrentTools sets the maximum concurrent tool executions, 0 means no limit.
+func WithMaxConcurrentTools(n int) func(*Server) {
+	return func(s *Server) {
+		s.maxConcurrent = n
+	}
+}
+
+// WithRateLimit sets the token bucket rate limit of the tool calls per session, 0 means no limit.
+func WithRateLimit(limit float64, burst int) func(*Server) {
+	return func(s *Server) {
+		s.rateLimit = limit
+		s.rateBurst = burst
+	}
+}
+
+// WithToolTimeout sets the default timeout of the tool calls and the timeouts of the specified tools, 0 means
+// no timeout.
+func WithToolTimeout(timeout time.Duration, overrides map[string]time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.toolTimeout = timeout
+		s.toolTimeouts = overrides
+	}
+}
+
+// WithScrubFields sets the JSON pointers of the noisy fields dropped from the returned objects.
+func WithScrubFields(fields []string) func(*Server) {
+	return func(s *Server) {
+		s.scrubFields = fields
+	}
+}
+
+// WithGitSource sets the allowed hosts of the git repositories, the maximum bytes of the manifests read from them
+// and the maximum bytes of the repositories fetched.
+func WithGitSource(allowedHosts []string, maxManifestBytes, maxFetchBytes int64) func(*Server) {
+	return func(s *Server) {
+		s.gitAllowedHosts = allowedHosts
+		s.gitMaxManifestBytes = maxManifestBytes
+		s.gitMaxFetchBytes = maxFetchBytes
+	}
+}
+
+// WithSnapshotDir sets the directory the namespace snapshots are saved in, the snapshots are only kept in memory
+// if not set.
+func WithSnapshotDir(dir string) func(*Server) {
+	return func(s *Server) {
+		s.snapshotDir = dir
+	}
+}
+
+// WithLocalFileDir sets the directory the local files named by the clients are read from, e.g. the files of
+// create_configmap, the local files are rejected if not set.
+func WithLocalFileDir(dir string) func(*Server) {
+	return func(s *Server) {
+		s.localFileDir = dir
+	}
+}
+
+// WithNodePricer sets the pricer of the nodes used to estimate the cost, e.g. the price table loaded from a file.
+func WithNodePricer(pricer NodePricer) func(*Server) {
+	return func(s *Server) {
+		s.pricer = pricer
+	}
+}
+
+// WithResultCache sets the expiry and the maximum entries of the cached tool results, 0 disables the cache.
+func WithResultCache(ttl time.Duration, maxEntries int) func(*Server) {
+	return func(s *Server) {
+		s.cacheTTL = ttl
+		s.cacheMaxEntries = maxEntries
+	}
+}
+
+// WithSSEResumeWindow sets how long the sse sessions are kept after the clients disconnect, the clients reconnecting
+// with the Last-Event-ID within the window resume the sessions and receive the missed events, 0 disables it.
+func WithSSEResumeWindow(window time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.sseResumeWindow = window
+	}
+}
+
+// SessionLeaseOptions is the options of coordinating the sessions of the replicas behind a load balancer by the
+// leases, the address is where the other replicas forward the messages of the sessions held by the replica.
+type SessionLeaseOptions struct {
+	Namespace string
+	Identity  string
+	// Address is the address the other replicas forward the messages to, served by the listener of the forward
+	// port. It must be reachable only inside the cluster since the requests sent to it are never forwarded again.
+	Address string
+	// ForwardPort is the port of the listener of the forwarded messages, separate from the public one.
+	ForwardPort int
+	Duration    time.Duration
+}
+
+// WithSessionLeases enables coordinating the sessions of the replicas in the sse mode by the leases.
+func WithSessionLeases(options SessionLeaseOptions) func(*Server) {
+	return func(s *Server) {
+		s.sessionLeaseOptions = &options
+	}
+}
+
+// WithPrintHandlers adds the print handlers to the table generator, e.g. the handlers of the custom resources.
+func WithPrintHandlers(fns ...func(definition.PrintHandler)) func(*Server) {
+	return func(s *Server) {
+		s.generator.With(fns...)
+	}
+}
+
 // NewServer creates a new mcp server.
-func NewServer(kubeconfig string, opts ...ServerOption) *Server {
+func NewServer(kubeconfigs []string, opts ...ServerOption) *Server {
 	generator := definition.NewTableGenerator()
 	definition.AddHandlers(generator)
 	s := &Server{
-		transport: "stdio",
-		port:      8888,
-		svr: server.NewMCPServer(
-			"Kubernetes MCP Server",
-			version.Get().Version,
-			server.WithRecovery(),
-			server.WithLogging(),
-		),
-		generator: generator,
-		cb:        client.NewClientBuilder(kubeconfig),
+		name:                DefaultServerName,
+		transport:           "stdio",
+		port:                8888,
+		maxLogTailLines:     1000,
+		maxLogBytes:         1 << 20,
+		maxExecBytes:        4 << 20,
+		maxResultBytes:      256 << 10,
+		toolTimeout:         time.Minute,
+		scrubFields:         DefaultScrubFields,
+		gitAllowedHosts:     DefaultGitAllowedHosts,
+		gitMaxManifestBytes: 4 << 20,
+		gitMaxFetchBytes:    64 << 20,
+		pricer:              DefaultPriceTable,
+		generator:           generator,
+		cb:                  client.NewClientBuilder(kubeconfigs...),
+		sessions:            newSessionState(),
+		callOptions:         newCallOptions(),
+		toolProperties:      make(map[string]map[string]any),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	if err := s.generator.ValidateHandlers(); err != nil {
+		slog.Error("Removed the invalid table handlers, their kinds are listed with the generic columns", "err", err)
+	}
+	s.scrubber = newScrubber(s.scrubFields)
+	s.snapshots = newSnapshotStore(s.snapshotDir)
+	s.outputs = newOutputStore()
+	if o := s.sessionLeaseOptions; o != nil && s.transport == "sse" {
+		s.leases = newSessionLeases(s.cb, o.Namespace, o.Identity, o.Address, o.Duration)
+	}
+	if s.cacheTTL > 0 && s.cacheMaxEntries > 0 {
+		s.cache = newResultCache(s.cacheTTL, s.cacheMaxEntries, s.cacheScope)
+	}
+
+	s.svr = server.NewMCPServer(
+		s.name,
+		version.Get().Version,
+		server.WithInstructions(s.instructions),
+		server.WithRecovery(),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
+		server.WithLogging(),
+		server.WithHooks(s.sessionHooks()),
+		server.WithToolHandlerMiddleware(newLimiter(s.maxConcurrent, s.rateLimit, s.rateBurst).middleware),
+		server.WithToolHandlerMiddleware(s.callOptions.middleware),
+		server.WithToolHandlerMiddleware(s.applySessionDefaults),
+		server.WithToolHandlerMiddleware((&timeouts{defaultTimeout: s.toolTimeout, overrides: s.toolTimeouts}).middleware),
+		server.WithToolHandlerMiddleware(retryStatus),
+		server.WithToolHandlerMiddleware(s.cacheResults),
+		server.WithToolHandlerMiddleware((&resultBudget{maxBytes: s.maxResultBytes}).middleware),
+		server.WithToolHandlerMiddleware(translateErrors),
+	)
 	return s
 }
 
 // RegisterTools registers the tools for the server.
 func (s *Server) RegisterTools(ctx context.Context) {
 	slog.Info("Registering tools")
-	s.svr.AddTools([]server.ServerTool{
+	s.addTools([]server.ServerTool{
 		{
 			Tool:    mcp.MakeListClustersTool(),
 			Handler: s.ListClusters(),
@@ -73,6 +304,18 @@ func (s *Server) RegisterTools(ctx context.Context) {
 			Tool:    mcp.MakeSwitchContextTool(),
 			Handler: s.SwitchContexts(),
 		},
+		{
+			Tool:    mcp.MakeRenameContextTool(),
+			Handler: s.RenameContext(),
+		},
+		{
+			Tool:    mcp.MakeDeleteContextTool(),
+			Handler: s.DeleteContext(),
+		},
+		{
+			Tool:    mcp.MakeSetContextNamespaceTool(),
+			Handler: s.SetContextNamespace(),
+		},
 		{
 			Tool:    mcp.MakeGetClusterVersionTool(),
 			Handler: s.GetClusterVersion(),
@@ -93,6 +336,18 @@ func (s *Server) RegisterTools(ctx context.Context) {
 			Tool:    mcp.MakeApplyResourceTool(),
 			Handler: s.ApplyResource(),
 		},
+		{
+			Tool:    mcp.MakeCreateResourceTool(),
+			Handler: s.CreateResource(),
+		},
+		{
+			Tool:    mcp.MakeUpdateResourceTool(),
+			Handler: s.UpdateResource(),
+		},
+		{
+			Tool:    mcp.MakePatchResourceTool(),
+			Handler: s.PatchResource(),
+		},
 		{
 			Tool:    mcp.MakeDeleteResourceTool(),
 			Handler: s.DeleteResource(),
@@ -113,17 +368,317 @@ func (s *Server) RegisterTools(ctx context.Context) {
 			Tool:    mcp.MakeTopNodeTool(),
 			Handler: s.TopNode(),
 		},
-	}...)
+		{
+			Tool:    mcp.MakeCheckServiceTool(),
+			Handler: s.CheckService(),
+		},
+		{
+			Tool:    mcp.MakeNetDebugTool(),
+			Handler: s.NetDebug(),
+		},
+		{
+			Tool:    mcp.MakeExplainResourceTool(),
+			Handler: s.ExplainResource(),
+		},
+		{
+			Tool:    mcp.MakeDetectDeprecatedAPIsTool(),
+			Handler: s.DetectDeprecatedAPIs(),
+		},
+		{
+			Tool:    mcp.MakeFindResourceTool(),
+			Handler: s.FindResource(),
+		},
+		{
+			Tool:    mcp.MakeQueryByLabelsTool(),
+			Handler: s.QueryByLabels(),
+		},
+		{
+			Tool:    mcp.MakeCreateNamespaceTool(),
+			Handler: s.CreateNamespace(),
+		},
+		{
+			Tool:    mcp.MakeCreateConfigMapTool(),
+			Handler: s.CreateConfigMap(),
+		},
+		{
+			Tool:    mcp.MakeCreateSecretTool(),
+			Handler: s.CreateSecret(),
+		},
+		{
+			Tool:    mcp.MakeRunJobTool(),
+			Handler: s.RunJob(),
+		},
+		{
+			Tool:    mcp.MakeTriggerCronJobTool(),
+			Handler: s.TriggerCronJob(),
+		},
+		{
+			Tool:    mcp.MakeSuspendJobTool(),
+			Handler: s.SuspendJob(),
+		},
+		{
+			Tool:    mcp.MakeListCronScheduleTool(),
+			Handler: s.ListCronSchedule(),
+		},
+		{
+			Tool:    mcp.MakeInspectHPATool(),
+			Handler: s.InspectHPA(),
+		},
+		{
+			Tool:    mcp.MakeScaleHPATool(),
+			Handler: s.ScaleHPA(),
+		},
+		{
+			Tool:    mcp.MakeDiagnoseStorageTool(),
+			Handler: s.DiagnoseStorage(),
+		},
+		{
+			Tool:    mcp.MakeCertificateExpiryTool(),
+			Handler: s.CertificateExpiryReport(),
+		},
+		{
+			Tool:    mcp.MakeImageInventoryTool(),
+			Handler: s.ImageInventory(),
+		},
+		{
+			Tool:    mcp.MakeSecurityAuditTool(),
+			Handler: s.SecurityAudit(),
+		},
+		{
+			Tool:    mcp.MakeQuotaHeadroomTool(),
+			Handler: s.QuotaHeadroom(),
+		},
+		{
+			Tool:    mcp.MakeWhyPendingTool(),
+			Handler: s.WhyPending(),
+		},
+		{
+			Tool:    mcp.MakeWhoAmITool(),
+			Handler: s.WhoAmI(),
+		},
+		{
+			Tool:    mcp.MakeInspectAdmissionTool(),
+			Handler: s.InspectAdmission(),
+		},
+		{
+			Tool:    mcp.MakeTraceRouteTool(),
+			Handler: s.TraceRoute(),
+		},
+		{
+			Tool:    mcp.MakeTraceIngressTool(),
+			Handler: s.TraceIngress(),
+		},
+		{
+			Tool:    mcp.MakeListCRDsTool(),
+			Handler: s.ListCRDs(),
+		},
+		{
+			Tool:    mcp.MakeDescribeNodeTool(),
+			Handler: s.DescribeNode(),
+		},
+		{
+			Tool:    mcp.MakeListNodePodsTool(),
+			Handler: s.ListNodePods(),
+		},
+		{
+			Tool:    mcp.MakeWorkloadPlacementTool(),
+			Handler: s.WorkloadPlacement(),
+		},
+		{
+			Tool:    mcp.MakeFieldManagersTool(),
+			Handler: s.FieldManagers(),
+		},
+		{
+			Tool:    mcp.MakeKustomizeBuildTool(),
+			Handler: s.KustomizeBuild(),
+		},
+		{
+			Tool:    mcp.MakeApplyFromGitTool(),
+			Handler: s.ApplyFromGit(),
+		},
+		{
+			Tool:    mcp.MakeGitOpsStatusTool(),
+			Handler: s.GitOpsStatus(),
+		},
+		{
+			Tool:    mcp.MakeControllerHealthTool(),
+			Handler: s.ControllerHealth(),
+		},
+		{
+			Tool:    mcp.MakeControlPlaneHealthTool(),
+			Handler: s.ControlPlaneHealth(),
+		},
+		{
+			Tool:    mcp.MakeRestartLeaderboardTool(),
+			Handler: s.RestartLeaderboard(),
+		},
+		{
+			Tool:    mcp.MakeFindOrphansTool(),
+			Handler: s.FindOrphans(),
+		},
+		{
+			Tool:    mcp.MakeTeardownNamespaceTool(),
+			Handler: s.TeardownNamespace(),
+		},
+		{
+			Tool:    mcp.MakeDiagnoseNamespaceTerminationTool(),
+			Handler: s.DiagnoseNamespaceTermination(),
+		},
+		{
+			Tool:    mcp.MakeManageFinalizersTool(),
+			Handler: s.ManageFinalizers(),
+		},
+		{
+			Tool:    mcp.MakeFindStuckDeletionsTool(),
+			Handler: s.FindStuckDeletions(),
+		},
+		{
+			Tool:    mcp.MakeRolloutStatusTool(),
+			Handler: s.RolloutStatus(),
+		},
+		{
+			Tool:    mcp.MakeDigestEventsTool(),
+			Handler: s.DigestEvents(),
+		},
+		{
+			Tool:    mcp.MakeSwitchTrafficTool(),
+			Handler: s.SwitchTraffic(),
+		},
+		{
+			Tool:    mcp.MakeSnapshotNamespaceTool(),
+			Handler: s.SnapshotNamespace(),
+		},
+		{
+			Tool:    mcp.MakeRestoreNamespaceTool(),
+			Handler: s.RestoreNamespace(),
+		},
+		{
+			Tool:    mcp.MakeCreateVolumeSnapshotTool(),
+			Handler: s.CreateVolumeSnapshot(),
+		},
+		{
+			Tool:    mcp.MakeCreatePVCFromSnapshotTool(),
+			Handler: s.CreatePVCFromSnapshot(),
+		},
+		{
+			Tool:    mcp.MakeCheckPodSecurityTool(),
+			Handler: s.CheckPodSecurity(),
+		},
+		{
+			Tool:    mcp.MakeListResourcesMultiTool(),
+			Handler: s.ListResourcesMulti(),
+		},
+		{
+			Tool:    mcp.MakeCompareResourceTool(),
+			Handler: s.CompareResource(),
+		},
+		{
+			Tool:    mcp.MakeServerInfoTool(),
+			Handler: s.ServerInfo(),
+		},
+		{
+			Tool:    mcp.MakeSetDefaultsTool(),
+			Handler: s.SetDefaults(),
+		},
+		{
+			Tool:    mcp.MakeSetImageTool(),
+			Handler: s.SetImage(),
+		},
+		{
+			Tool:    mcp.MakeSetEnvTool(),
+			Handler: s.SetEnv(),
+		},
+		{
+			Tool:    mcp.MakeSetResourcesTool(),
+			Handler: s.SetResources(),
+		},
+		{
+			Tool:    mcp.MakePauseWorkloadTool(),
+			Handler: s.PauseWorkload(),
+		},
+		{
+			Tool:    mcp.MakeResumeWorkloadTool(),
+			Handler: s.ResumeWorkload(),
+		},
+		{
+			Tool:    mcp.MakeEstimateCostTool(),
+			Handler: s.EstimateCost(),
+		},
+		{
+			Tool:    mcp.MakeAttachPodTool(),
+			Handler: s.AttachPod(),
+		},
+		{
+			Tool:    mcp.MakeGenerateManifestTool(),
+			Handler: s.GenerateManifest(),
+		},
+		{
+			Tool:    mcp.MakeDescribeContainersTool(),
+			Handler: s.DescribeContainers(),
+		},
+		{
+			Tool:    mcp.MakeResolveEnvTool(),
+			Handler: s.ResolveEnv(),
+		},
+		{
+			Tool:    mcp.MakeServiceAccountUsageTool(),
+			Handler: s.ServiceAccountUsage(),
+		},
+		{
+			Tool:    mcp.MakeSubjectPermissionsTool(),
+			Handler: s.SubjectPermissions(),
+		},
+	})
+}
+
+// cacheResults serves the repeated calls of the read-only tools from the result cache if enabled.
+func (s *Server) cacheResults(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if s.cache == nil {
+		return next
+	}
+	return s.cache.middleware(next)
+}
+
+// addTools registers the tools with the parameters of the per-call options, and records them for the server info
+// and the session defaults.
+func (s *Server) addTools(tools []server.ServerTool) {
+	tools = s.callOptions.register(tools)
+	if s.cache != nil {
+		tools = s.cache.register(tools)
+	}
+	for _, tool := range tools {
+		s.tools = append(s.tools, newToolInfo(tool.Tool))
+		s.toolProperties[tool.Tool.Name] = tool.Tool.InputSchema.Properties
+	}
+	s.svr.AddTools(tools...)
 }
 
 // Start starts the mcp server.
 func (s *Server) Start(ctx context.Context) error {
 	s.RegisterTools(ctx)
+	s.RegisterResources(ctx)
+	s.RegisterPrompts(ctx)
 	switch s.transport {
 	case "sse":
 		slog.Info("Starting mcp server with sse mode and listening on", "port", s.port)
 		sseServer := server.NewSSEServer(s.svr, server.WithBaseURL(fmt.Sprintf("http://0.0.0.0:%d", s.port)))
-		return sseServer.Start(fmt.Sprintf(":%d", s.port))
+		var handler http.Handler = sseServer
+		if s.sseResumeWindow > 0 {
+			handler = newSSERelays(s.sseResumeWindow).handler(handler, sseServer.CompleteSsePath())
+		}
+		if s.leases == nil {
+			return (&http.Server{Addr: fmt.Sprintf(":%d", s.port), Handler: handler}).ListenAndServe()
+		}
+		slog.Info("Coordinating the sessions by the leases", "namespace", s.leases.namespace, "identity", s.leases.identity, "address", s.leases.address)
+		go s.leases.run(ctx)
+		// the forwarded messages are received by a separate listener, the trust of them is decided by the
+		// connection rather than by the headers the clients of the public listener set freely.
+		errCh := make(chan error, 2)
+		forwarded := &http.Server{Addr: fmt.Sprintf(":%d", s.sessionLeaseOptions.ForwardPort), Handler: s.leases.forwardedHandler(handler)}
+		go func() { errCh <- forwarded.ListenAndServe() }()
+		public := &http.Server{Addr: fmt.Sprintf(":%d", s.port), Handler: s.leases.handler(handler, sseServer.CompleteSsePath(), sseServer.CompleteMessagePath())}
+		go func() { errCh <- public.ListenAndServe() }()
+		return <-errCh
 	case "stdio":
 		slog.Info("Starting mcp server with STDIO mode")
 		stdioServer := server.NewStdioServer(s.svr)
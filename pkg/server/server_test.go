@@ -0,0 +1,91 @@
+package server
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"cola.io/koffee/pkg/client"
+)
+
+// fakeBuilder builds the fake clients, the methods loading the kubeconfig are not implemented.
+type fakeBuilder struct {
+	client.ClientBuilder
+	cli       *fake.Clientset
+	dynamic   *dynamicfake.FakeDynamicClient
+	discovery *fakeDiscovery
+}
+
+// newFakeServer returns the server of the fake clients, the resources are served by the discovery and the
+// dynamic client lists them by their list kinds.
+func newFakeServer(resources []*metav1.APIResourceList, typed []runtime.Object, objects ...runtime.Object) (*Server, *fakeBuilder) {
+	cli := fake.NewClientset(typed...)
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, list := range resources {
+		gv, _ := schema.ParseGroupVersion(list.GroupVersion)
+		for _, resource := range list.APIResources {
+			listKinds[gv.WithResource(resource.Name)] = resource.Kind + "List"
+		}
+	}
+	cli.Resources = resources
+	b := &fakeBuilder{
+		cli:       cli,
+		dynamic:   dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...),
+		discovery: &fakeDiscovery{FakeDiscovery: cli.Discovery().(*fakediscovery.FakeDiscovery)},
+	}
+	s := &Server{cb: b, sessions: newSessionState(), scrubber: newScrubber(nil)}
+	return s, b
+}
+
+func (b *fakeBuilder) GetClient() (kubernetes.Interface, error) {
+	return b.cli, nil
+}
+
+func (b *fakeBuilder) GetDynamicClient() (dynamic.Interface, error) {
+	return b.dynamic, nil
+}
+
+func (b *fakeBuilder) GetDiscoveryClient() (discovery.DiscoveryInterface, error) {
+	return b.discovery, nil
+}
+
+func (b *fakeBuilder) WithContext(string) client.ClientBuilder {
+	return b
+}
+
+func (b *fakeBuilder) Namespace() (string, error) {
+	return metav1.NamespaceDefault, nil
+}
+
+func (b *fakeBuilder) InCluster() bool {
+	return false
+}
+
+// fakeDiscovery serves the resources of the fake discovery as the preferred ones.
+type fakeDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (d *fakeDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.Resources, nil
+}
+
+func (d *fakeDiscovery) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	lists := make([]*metav1.APIResourceList, 0, len(d.Resources))
+	for _, list := range d.Resources {
+		namespaced := &metav1.APIResourceList{GroupVersion: list.GroupVersion}
+		for _, resource := range list.APIResources {
+			if resource.Namespaced {
+				namespaced.APIResources = append(namespaced.APIResources, resource)
+			}
+		}
+		lists = append(lists, namespaced)
+	}
+	return lists, nil
+}
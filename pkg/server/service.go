@@ -0,0 +1,261 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultProbeImage = "curlimages/curl:latest"
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// ServiceReport is the connectivity report of a service.
+type ServiceReport struct {
+	Service   string        `json:"service"`
+	Namespace string        `json:"namespace"`
+	Type      string        `json:"type"`
+	Passed    bool          `json:"passed"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+func (r *ServiceReport) add(name string, passed bool, format string, args ...any) {
+	r.Checks = append(r.Checks, CheckResult{Name: name, Passed: passed, Message: fmt.Sprintf(format, args...)})
+	if !passed {
+		r.Passed = false
+	}
+}
+
+func (s *Server) CheckService() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resourceName, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		probe := req.GetBool("probe", false)
+		probePod := req.GetString("probePod", "")
+		probeImage := req.GetString("probeImage", defaultProbeImage)
+		probePath := req.GetString("probePath", "/")
+
+		slog.Info("Checking service", "name", resourceName, "namespace", namespace, "probe", probe, "probePod", probePod)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		svc, err := cli.CoreV1().Services(namespace).Get(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+
+		report := &ServiceReport{
+			Service:   svc.Name,
+			Namespace: svc.Namespace,
+			Type:      string(svc.Spec.Type),
+			Passed:    true,
+			Checks:    make([]CheckResult, 0),
+		}
+
+		if svc.Spec.Type == corev1.ServiceTypeExternalName {
+			report.add("selector", true, "ExternalName service resolves to %s, pod and endpoint checks are skipped", svc.Spec.ExternalName)
+		} else if err = s.checkServiceBackends(ctx, svc, report); err != nil {
+			return nil, err
+		}
+
+		if probe {
+			s.probeService(ctx, svc, probePod, probeImage, probePath, report)
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// checkServiceBackends checks the selector, endpoints and target ports of the service.
+func (s *Server) checkServiceBackends(ctx context.Context, svc *corev1.Service, report *ServiceReport) error {
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		return err
+	}
+
+	var pods []corev1.Pod
+	if len(svc.Spec.Selector) == 0 {
+		report.add("selector", true, "service has no selector, endpoints are expected to be managed manually")
+	} else {
+		selector := labels.SelectorFromSet(svc.Spec.Selector).String()
+		podList, err := cli.CoreV1().Pods(svc.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list pods: %w", err)
+		}
+		pods = podList.Items
+
+		ready := 0
+		for i := range pods {
+			if podReady(&pods[i]) {
+				ready++
+			}
+		}
+		switch {
+		case len(pods) == 0:
+			report.add("selector", false, "selector %q does not match any pod", selector)
+		case ready == 0:
+			report.add("selector", false, "selector %q matches %d pod(s) but none of them is ready", selector, len(pods))
+		default:
+			report.add("selector", true, "selector %q matches %d pod(s), %d ready", selector, len(pods), ready)
+		}
+	}
+
+	slices, readyEndpoints, notReadyEndpoints, err := serviceEndpoints(ctx, cli, svc.Namespace, svc.Name)
+	if err != nil {
+		return err
+	}
+	if readyEndpoints == 0 {
+		report.add("endpoints", false, "%d endpointslice(s) found with no ready address (%d not ready)", slices, notReadyEndpoints)
+	} else {
+		report.add("endpoints", true, "%d endpointslice(s) found with %d ready address(es) (%d not ready)", slices, readyEndpoints, notReadyEndpoints)
+	}
+
+	if len(pods) > 0 {
+		for _, port := range svc.Spec.Ports {
+			checkTargetPort(port, pods, report)
+		}
+	}
+	return nil
+}
+
+// serviceEndpoints counts the endpointslices of the service and their ready and not ready addresses.
+func serviceEndpoints(ctx context.Context, cli kubernetes.Interface, namespace, name string) (slices, ready, notReady int, err error) {
+	endpointSlices, err := cli.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{discoveryv1.LabelServiceName: name}).String(),
+	})
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to list endpointslices: %w", err)
+	}
+	for _, slice := range endpointSlices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready += len(endpoint.Addresses)
+			} else {
+				notReady += len(endpoint.Addresses)
+			}
+		}
+	}
+	return len(endpointSlices.Items), ready, notReady, nil
+}
+
+// checkTargetPort checks whether the target port of the service port is exposed by the selected pods.
+func checkTargetPort(port corev1.ServicePort, pods []corev1.Pod, report *ServiceReport) {
+	name := fmt.Sprintf("targetPort/%s", servicePortName(port))
+	targetPort := port.TargetPort
+	if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+		targetPort = intstr.FromInt32(port.Port)
+	}
+
+	for i := range pods {
+		for _, container := range pods[i].Spec.Containers {
+			for _, containerPort := range container.Ports {
+				if protocolOrDefault(containerPort.Protocol) != protocolOrDefault(port.Protocol) {
+					continue
+				}
+				if (targetPort.Type == intstr.String && containerPort.Name == targetPort.StrVal) ||
+					(targetPort.Type == intstr.Int && containerPort.ContainerPort == targetPort.IntVal) {
+					report.add(name, true, "target port %s matches container %s port %d in pod %s", targetPort.String(), container.Name, containerPort.ContainerPort, pods[i].Name)
+					return
+				}
+			}
+		}
+	}
+
+	if targetPort.Type == intstr.String {
+		report.add(name, false, "named target port %q is not defined by any selected pod", targetPort.StrVal)
+		return
+	}
+	report.add(name, false, "target port %d is not declared by any selected pod, traffic only works if the process listens on it", targetPort.IntVal)
+}
+
+// probeService runs curl against every TCP port of the service from inside the cluster.
+func (s *Server) probeService(ctx context.Context, svc *corev1.Service, probePod, probeImage, probePath string, report *ServiceReport) {
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		report.add("probe", false, "failed to create client: %v", err)
+		return
+	}
+
+	podName, container := probePod, ""
+	if len(podName) == 0 {
+		pod, err := createDebugPod(ctx, cli, svc.Namespace, probeImage)
+		if err != nil {
+			report.add("probe", false, "%v", err)
+			return
+		}
+		defer deleteDebugPod(cli, pod)
+		podName, container = pod.Name, debugPodContainerName
+	}
+
+	host := fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
+	for _, port := range svc.Spec.Ports {
+		if protocolOrDefault(port.Protocol) != corev1.ProtocolTCP {
+			continue
+		}
+		url := fmt.Sprintf("http://%s:%d%s", host, port.Port, probePath)
+		command := []string{"curl", "-sS", "-o", "/dev/null", "-w", "%{http_code}", "--max-time", "5", url}
+		stdout, stderr, err := s.execInContainer(ctx, svc.Namespace, podName, container, command)
+		name := fmt.Sprintf("probe/%s", servicePortName(port))
+		if err != nil {
+			report.add(name, false, "curl %s from pod %s failed: %v %s", url, podName, err, strings.TrimSpace(stderr))
+			continue
+		}
+		report.add(name, true, "curl %s from pod %s returned HTTP %s", url, podName, strings.TrimSpace(stdout))
+	}
+}
+
+func servicePortName(port corev1.ServicePort) string {
+	if len(port.Name) > 0 {
+		return port.Name
+	}
+	return fmt.Sprintf("%d", port.Port)
+}
+
+func protocolOrDefault(protocol corev1.Protocol) corev1.Protocol {
+	if len(protocol) == 0 {
+		return corev1.ProtocolTCP
+	}
+	return protocol
+}
+
+// podReady returns true if the pod is running and has the ready condition.
+func podReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
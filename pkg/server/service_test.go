@@ -0,0 +1,49 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCheckTargetPort(t *testing.T) {
+	pods := []corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "web",
+			Ports: []corev1.ContainerPort{
+				{Name: "http", ContainerPort: 8080},
+				{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+			},
+		}}},
+	}}
+
+	tests := []struct {
+		name        string
+		port        corev1.ServicePort
+		wantPassed  bool
+		wantMessage string
+	}{
+		{name: "named target port", port: corev1.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromString("http")}, wantPassed: true, wantMessage: "matches container web port 8080"},
+		{name: "numeric target port", port: corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt32(8080)}, wantPassed: true, wantMessage: "matches container web port 8080"},
+		{name: "target port defaults to the port", port: corev1.ServicePort{Port: 8080}, wantPassed: true, wantMessage: "target port 8080 matches"},
+		{name: "protocol mismatch", port: corev1.ServicePort{Port: 53, TargetPort: intstr.FromInt32(53)}, wantMessage: "target port 53 is not declared"},
+		{name: "udp target port", port: corev1.ServicePort{Port: 53, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromString("dns")}, wantPassed: true, wantMessage: "port 53"},
+		{name: "undefined named target port", port: corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("metrics")}, wantMessage: `named target port "metrics" is not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &ServiceReport{Passed: true}
+			checkTargetPort(tt.port, pods, report)
+			if len(report.Checks) != 1 {
+				t.Fatalf("got checks %+v, want one check", report.Checks)
+			}
+			if check := report.Checks[0]; check.Passed != tt.wantPassed || !strings.Contains(check.Message, tt.wantMessage) {
+				t.Fatalf("got check %+v, want passed %t with %q", check, tt.wantPassed, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceAccountUsage is a ServiceAccount with the workloads running as it and the roles bound to it, the
+// over-privileged ServiceAccounts come first.
+type ServiceAccountUsage struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Missing is set if the ServiceAccount is used by the pods or bound to the roles but doesn't exist.
+	Missing        bool                    `json:"missing,omitempty"`
+	AutomountToken *bool                   `json:"automountToken,omitempty"`
+	Workloads      []string                `json:"workloads"`
+	Bindings       []ServiceAccountBinding `json:"bindings"`
+	// Privileged is the reasons the roles bound to the ServiceAccount are over-privileged.
+	Privileged []string `json:"privileged,omitempty"`
+}
+
+// ServiceAccountBinding is a role bound to a ServiceAccount, the namespace is empty for the ClusterRoleBindings which
+// grant the role in all the namespaces.
+type ServiceAccountBinding struct {
+	Binding   string `json:"binding"`
+	Role      string `json:"role"`
+	Namespace string `json:"namespace,omitempty"`
+	// Via is the group or user subject matching the ServiceAccount, empty if the ServiceAccount is the subject.
+	Via        string   `json:"via,omitempty"`
+	Missing    bool     `json:"missing,omitempty"`
+	Privileged []string `json:"privileged,omitempty"`
+}
+
+func (s *Server) ServiceAccountUsage() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace := req.GetString("namespace", "")
+		name := req.GetString("name", "")
+		if len(name) > 0 && len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		privilegedOnly := req.GetBool("privilegedOnly", false)
+		unusedOnly := req.GetBool("unusedOnly", false)
+
+		slog.Info("Mapping service account usage", "namespace", namespace, "name", name, "privilegedOnly", privilegedOnly, "unusedOnly", unusedOnly)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		serviceAccounts, err := cli.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list serviceaccounts: %w", err)
+		}
+		pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		owners, err := workloadOwners(ctx, cli, namespace)
+		if err != nil {
+			return nil, err
+		}
+		index, err := loadRBACIndex(ctx, cli, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		usages := make(map[string]*ServiceAccountUsage)
+		usage := func(namespace, name string) *ServiceAccountUsage {
+			key := namespace + "/" + name
+			u, ok := usages[key]
+			if !ok {
+				u = &ServiceAccountUsage{Namespace: namespace, Name: name, Missing: true, Workloads: make([]string, 0), Bindings: make([]ServiceAccountBinding, 0)}
+				usages[key] = u
+			}
+			return u
+		}
+		for i := range serviceAccounts.Items {
+			sa := &serviceAccounts.Items[i]
+			u := usage(sa.Namespace, sa.Name)
+			u.Missing, u.AutomountToken = false, sa.AutomountServiceAccountToken
+		}
+
+		// the ServiceAccounts bound to the roles are reported even if they are deleted, the bindings are left over.
+		addSubjects := func(subjects []rbacv1.Subject, bindingNamespace string) {
+			for _, subject := range subjects {
+				if subject.Kind != rbacv1.ServiceAccountKind {
+					continue
+				}
+				saNamespace := subject.Namespace
+				if len(saNamespace) == 0 {
+					saNamespace = bindingNamespace
+				}
+				if len(saNamespace) > 0 && (len(namespace) == 0 || saNamespace == namespace) {
+					usage(saNamespace, subject.Name)
+				}
+			}
+		}
+		for i := range index.roleBindings {
+			addSubjects(index.roleBindings[i].Subjects, index.roleBindings[i].Namespace)
+		}
+		for i := range index.clusterRoleBindings {
+			addSubjects(index.clusterRoleBindings[i].Subjects, "")
+		}
+
+		// the workloads are reported once by their running pods.
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			saName := pod.Spec.ServiceAccountName
+			if len(saName) == 0 {
+				saName = "default"
+			}
+			u := usage(pod.Namespace, saName)
+			workload := strings.Replace(podWorkload(pod, owners), "/"+pod.Namespace+"/", "/", 1)
+			if !slices.Contains(u.Workloads, workload) {
+				u.Workloads = append(u.Workloads, workload)
+			}
+		}
+
+		for key, u := range usages {
+			if len(name) > 0 && u.Name != name {
+				delete(usages, key)
+				continue
+			}
+			bindServiceAccountRoles(index, u)
+		}
+
+		report := make([]ServiceAccountUsage, 0, len(usages))
+		for _, u := range usages {
+			if privilegedOnly && len(u.Privileged) == 0 {
+				continue
+			}
+			if unusedOnly && len(u.Workloads) > 0 {
+				continue
+			}
+			sort.Strings(u.Workloads)
+			report = append(report, *u)
+		}
+		if len(name) > 0 && len(report) == 0 && !privilegedOnly && !unusedOnly {
+			return nil, fmt.Errorf("serviceaccount %s/%s is not found, nor used by any pod or binding", namespace, name)
+		}
+		sort.Slice(report, func(i, j int) bool {
+			if pi, pj := len(report[i].Privileged) > 0, len(report[j].Privileged) > 0; pi != pj {
+				return pi
+			}
+			if report[i].Namespace != report[j].Namespace {
+				return report[i].Namespace < report[j].Namespace
+			}
+			return report[i].Name < report[j].Name
+		})
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// bindServiceAccountRoles adds the roles bound to the ServiceAccount by the RoleBindings and ClusterRoleBindings,
+// and the reasons they are over-privileged.
+func bindServiceAccountRoles(index *rbacIndex, u *ServiceAccountUsage) {
+	add := func(binding ServiceAccountBinding, bindingNamespace string, ref rbacv1.RoleRef) {
+		rules, ok := index.roleRules(bindingNamespace, ref)
+		binding.Role = ref.Kind + "/" + ref.Name
+		binding.Missing = !ok
+		binding.Privileged = privilegedRules(rules)
+		for _, reason := range binding.Privileged {
+			if !slices.Contains(u.Privileged, reason) {
+				u.Privileged = append(u.Privileged, reason)
+			}
+		}
+		u.Bindings = append(u.Bindings, binding)
+	}
+	for i := range index.roleBindings {
+		rb := &index.roleBindings[i]
+		for _, subject := range rb.Subjects {
+			if matched, via := serviceAccountSubject(subject, rb.Namespace, u.Namespace, u.Name); matched {
+				add(ServiceAccountBinding{Binding: "RoleBinding/" + rb.Name, Namespace: rb.Namespace, Via: via}, rb.Namespace, rb.RoleRef)
+				break
+			}
+		}
+	}
+	for i := range index.clusterRoleBindings {
+		crb := &index.clusterRoleBindings[i]
+		for _, subject := range crb.Subjects {
+			if matched, via := serviceAccountSubject(subject, "", u.Namespace, u.Name); matched {
+				add(ServiceAccountBinding{Binding: "ClusterRoleBinding/" + crb.Name, Via: via}, "", crb.RoleRef)
+				break
+			}
+		}
+	}
+}
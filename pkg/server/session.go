@@ -0,0 +1,288 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"cola.io/koffee/pkg/client"
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+// sessionDefaultsCapability is the experimental client capability setting the session defaults on the
+// initialization, e.g. {"koffee/defaults": {"context": "prod", "namespace": "payments", "output": "yaml"}}.
+const sessionDefaultsCapability = "koffee/defaults"
+
+// SessionDefaults is the defaults of the parameters of the tool calls in a session.
+type SessionDefaults struct {
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+// sessionState is the state of the client sessions, e.g. the kube context selected by switch_context without
+// persisting it to the kubeconfig, and the defaults of the namespace and the output format set by set_defaults.
+type sessionState struct {
+	mu       sync.RWMutex
+	contexts map[string]string
+	defaults map[string]SessionDefaults
+}
+
+func newSessionState() *sessionState {
+	return &sessionState{contexts: make(map[string]string), defaults: make(map[string]SessionDefaults)}
+}
+
+// sessionID returns the id of the client session of the request, the empty id is returned if there is no session.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+func (s *sessionState) context(id string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name, ok := s.contexts[id]
+	return name, ok
+}
+
+func (s *sessionState) setContext(id, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contexts[id] = name
+}
+
+// unsetContext resets the session to the current context of the kubeconfig.
+func (s *sessionState) unsetContext(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.contexts, id)
+}
+
+// renameContext updates the sessions which selected the renamed context.
+func (s *sessionState) renameContext(name, newName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, selected := range s.contexts {
+		if selected == name {
+			s.contexts[id] = newName
+		}
+	}
+}
+
+// clearContext resets the sessions which selected the context to the current context of the kubeconfig.
+func (s *sessionState) clearContext(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, selected := range s.contexts {
+		if selected == name {
+			delete(s.contexts, id)
+		}
+	}
+}
+
+// defaultsOf returns the namespace and output defaults of the session, the context is returned by context.
+func (s *sessionState) defaultsOf(id string) SessionDefaults {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defaults[id]
+}
+
+func (s *sessionState) setDefaults(id string, defaults SessionDefaults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defaults.Context = ""
+	if defaults == (SessionDefaults{}) {
+		delete(s.defaults, id)
+		return
+	}
+	s.defaults[id] = defaults
+}
+
+func (s *sessionState) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.contexts, id)
+	delete(s.defaults, id)
+}
+
+// sessionHooks releases the state of the sessions once they are unregistered, and sets the session defaults of the
+// experimental capability of the client on the initialization. The sessions hold their leases if coordinated.
+func (s *Server) sessionHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.sessions.remove(session.SessionID())
+		s.snapshots.remove(session.SessionID())
+		s.outputs.remove(session.SessionID())
+	})
+	if s.leases != nil {
+		// the request of the event stream is canceled once the client disconnects, the lease is released anyway.
+		hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+			s.leases.acquire(context.WithoutCancel(ctx), session.SessionID())
+		})
+		hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+			s.leases.release(context.WithoutCancel(ctx), session.SessionID())
+		})
+	}
+	hooks.AddAfterInitialize(func(ctx context.Context, _ any, message *mcp.InitializeRequest, _ *mcp.InitializeResult) {
+		raw, ok := message.Params.Capabilities.Experimental[sessionDefaultsCapability]
+		if !ok {
+			return
+		}
+		var defaults SessionDefaults
+		data, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(data, &defaults)
+		}
+		if err == nil {
+			err = s.setSessionDefaults(ctx, defaults)
+		}
+		if err != nil {
+			slog.Warn("Ignoring the invalid session defaults of the client", "capability", sessionDefaultsCapability, "err", err)
+		}
+	})
+	return hooks
+}
+
+// builder returns the ClientBuilder of the kube context selected by the session of the request, it falls back to
+// the current context of the kubeconfig if the session selected none.
+func (s *Server) builder(ctx context.Context) client.ClientBuilder {
+	if name, ok := s.sessions.context(sessionID(ctx)); ok {
+		return s.cb.WithContext(name)
+	}
+	return s.cb
+}
+
+// defaultNamespace returns the namespace of the namespace-scoped calls without the namespace, that is the default
+// namespace of the session, the namespace of the mounted ServiceAccount in cluster or the namespace of the kube
+// context, falling back to default.
+func (s *Server) defaultNamespace(ctx context.Context) string {
+	if defaults := s.sessions.defaultsOf(sessionID(ctx)); len(defaults.Namespace) > 0 {
+		return defaults.Namespace
+	}
+	namespace, err := s.builder(ctx).Namespace()
+	if err != nil {
+		slog.Warn("Failed to detect the default namespace", "err", err)
+	}
+	if len(namespace) == 0 {
+		return metav1.NamespaceDefault
+	}
+	return namespace
+}
+
+// setSessionDefaults validates and sets the non-empty defaults of the session, the others are unchanged.
+func (s *Server) setSessionDefaults(ctx context.Context, defaults SessionDefaults) error {
+	id := sessionID(ctx)
+	if len(defaults.Context) > 0 {
+		cfg, err := s.cb.LoadRawConfig()
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.Contexts[defaults.Context]; !ok {
+			return fmt.Errorf("context %q not found in the specified kubeconfig", defaults.Context)
+		}
+	}
+	if len(defaults.Namespace) > 0 {
+		if errs := validation.IsDNS1123Label(defaults.Namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q: %s", defaults.Namespace, strings.Join(errs, ", "))
+		}
+	}
+	if len(defaults.Output) > 0 && !slices.Contains(koffeemcp.SessionOutputs, defaults.Output) {
+		return fmt.Errorf("invalid output %q, must be one of %s", defaults.Output, strings.Join(koffeemcp.SessionOutputs, ", "))
+	}
+
+	if len(defaults.Context) > 0 {
+		s.sessions.setContext(id, defaults.Context)
+	}
+	current := s.sessions.defaultsOf(id)
+	if len(defaults.Namespace) > 0 {
+		current.Namespace = defaults.Namespace
+	}
+	if len(defaults.Output) > 0 {
+		current.Output = defaults.Output
+	}
+	s.sessions.setDefaults(id, current)
+	return nil
+}
+
+type sessionDefaultedKey struct{}
+
+// isSessionDefault returns whether the parameter of the tool call is filled by the session defaults rather than
+// passed by the client.
+func isSessionDefault(ctx context.Context, param string) bool {
+	params, _ := ctx.Value(sessionDefaultedKey{}).([]string)
+	return slices.Contains(params, param)
+}
+
+// applySessionDefaults fills the namespace and output parameters missing in the tool call with the defaults of the
+// session, if the tool accepts them. The explicit empty namespace is kept, e.g. to list all namespaces.
+func (s *Server) applySessionDefaults(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		defaults := s.sessions.defaultsOf(sessionID(ctx))
+		properties := s.toolProperties[req.Params.Name]
+		if defaults == (SessionDefaults{}) || len(properties) == 0 || req.Params.Name == koffeemcp.SetDefaultsToolName {
+			return next(ctx, req)
+		}
+
+		args := maps.Clone(req.GetArguments())
+		if args == nil {
+			args = make(map[string]any)
+		}
+		var defaulted []string
+		if _, ok := properties["namespace"]; ok && len(defaults.Namespace) > 0 {
+			if _, set := args["namespace"]; !set {
+				args["namespace"] = defaults.Namespace
+				defaulted = append(defaulted, "namespace")
+			}
+		}
+		if property, ok := properties["output"].(map[string]any); ok && len(defaults.Output) > 0 {
+			enum, _ := property["enum"].([]string)
+			if _, set := args["output"]; !set && slices.Contains(enum, defaults.Output) {
+				args["output"] = defaults.Output
+				defaulted = append(defaulted, "output")
+			}
+		}
+		req.Params.Arguments = args
+		return next(context.WithValue(ctx, sessionDefaultedKey{}, defaulted), req)
+	}
+}
+
+func (s *Server) SetDefaults() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		defaults := SessionDefaults{
+			Context:   req.GetString("context", ""),
+			Namespace: req.GetString("namespace", ""),
+			Output:    req.GetString("output", ""),
+		}
+		reset := req.GetBool("reset", false)
+
+		slog.Info("Setting session defaults", "context", defaults.Context, "namespace", defaults.Namespace, "output", defaults.Output, "reset", reset)
+
+		id := sessionID(ctx)
+		if reset {
+			s.sessions.remove(id)
+		}
+		if err := s.setSessionDefaults(ctx, defaults); err != nil {
+			return nil, err
+		}
+
+		current := s.sessions.defaultsOf(id)
+		current.Context, _ = s.sessions.context(id)
+		resp, err := json.Marshal(current)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+func TestSessionOutputDefaults(t *testing.T) {
+	tools := []mcp.Tool{
+		koffeemcp.MakeGetResourceDetailTool(),
+		koffeemcp.MakeListResourcesTool(),
+		koffeemcp.MakeTopPodTool(),
+	}
+	// the formats of set_defaults are the formats of the tools.
+	enum, _ := koffeemcp.MakeSetDefaultsTool().InputSchema.Properties["output"].(map[string]any)["enum"].([]string)
+	if !slices.Equal(enum, koffeemcp.SessionOutputs) {
+		t.Fatalf("got set_defaults outputs %v, want %v", enum, koffeemcp.SessionOutputs)
+	}
+
+	tests := []struct {
+		output    string
+		want      map[string]string
+		wantError bool
+	}{
+		{output: "json", want: map[string]string{"get_resource_detail": "json", "list_resources": "json"}},
+		{output: "yaml", want: map[string]string{"get_resource_detail": "yaml"}},
+		{output: "markdown", want: map[string]string{"list_resources": "markdown"}},
+		{output: "csv", want: map[string]string{"list_resources": "csv"}},
+		{output: "text", want: map[string]string{"list_resources": "text", "top_pod": "text"}},
+		{output: "table", want: map[string]string{"top_pod": "table"}},
+		{output: "html", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			s := &Server{sessions: newSessionState(), toolProperties: make(map[string]map[string]any)}
+			for _, tool := range tools {
+				s.toolProperties[tool.Name] = tool.InputSchema.Properties
+			}
+			err := s.setSessionDefaults(context.Background(), SessionDefaults{Output: tt.output})
+			if (err != nil) != tt.wantError {
+				t.Fatalf("got error %v, want error %t", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+
+			for _, tool := range tools {
+				var got string
+				handler := s.applySessionDefaults(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					got = req.GetString("output", "")
+					return nil, nil
+				})
+				req := mcp.CallToolRequest{}
+				req.Params.Name = tool.Name
+				if _, err := handler(context.Background(), req); err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want[tool.Name] {
+					t.Fatalf("got output %q of %s, want %q", got, tool.Name, tt.want[tool.Name])
+				}
+			}
+		})
+	}
+}
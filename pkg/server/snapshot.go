@@ -0,0 +1,334 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+// maxSnapshots is the maximum number of the snapshots of a session kept in memory, the oldest ones are evicted
+// first.
+const maxSnapshots = 20
+
+// NamespaceSnapshot is the summary of a snapshot of the namespace, the manifest is read by the resource URI.
+type NamespaceSnapshot struct {
+	Name      string            `json:"name"`
+	URI       string            `json:"uri"`
+	Namespace string            `json:"namespace"`
+	Created   string            `json:"created"`
+	Objects   int               `json:"objects"`
+	Kinds     map[string]int    `json:"kinds"`
+	Bytes     int               `json:"bytes"`
+	Path      string            `json:"path,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// NamespaceRestore is the objects of a snapshot applied to the namespace.
+type NamespaceRestore struct {
+	Snapshot         string          `json:"snapshot"`
+	Namespace        string          `json:"namespace"`
+	Preview          bool            `json:"preview,omitempty"`
+	NamespaceCreated bool            `json:"namespaceCreated,omitempty"`
+	Objects          []AppliedObject `json:"objects"`
+}
+
+// snapshotStore keeps the snapshots of each client session in memory, and also in the directory if configured so
+// that they survive the restarts of the server. The snapshots of a session are not visible to the other sessions,
+// the ones in the directory are read back by the same session id, e.g. the stdio session after a restart.
+type snapshotStore struct {
+	mu  sync.Mutex
+	dir string
+	// manifests are the manifests of the snapshots by the session id and the name.
+	manifests map[string]map[string]string
+	// names are the names of the snapshots of each session in memory, the oldest first.
+	names map[string][]string
+}
+
+func newSnapshotStore(dir string) *snapshotStore {
+	return &snapshotStore{dir: dir, manifests: make(map[string]map[string]string), names: make(map[string][]string)}
+}
+
+// path returns the file of the snapshot of the session, the session id is hashed so that it's usable as the
+// directory name whatever the transport generates.
+func (s *snapshotStore) path(session, name string) string {
+	sum := sha256.Sum256([]byte(session))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8]), name+".yaml")
+}
+
+// put saves the manifest of the snapshot of the session and returns the path of the file if the directory is
+// configured.
+func (s *snapshotStore) put(session, name, manifest string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var path string
+	if len(s.dir) > 0 {
+		path = s.path(session, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+			return "", fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+	manifests, ok := s.manifests[session]
+	if !ok {
+		manifests = make(map[string]string)
+		s.manifests[session] = manifests
+	}
+	if _, ok := manifests[name]; !ok {
+		s.names[session] = append(s.names[session], name)
+	}
+	manifests[name] = manifest
+	for len(s.names[session]) > maxSnapshots {
+		delete(manifests, s.names[session][0])
+		s.names[session] = s.names[session][1:]
+	}
+	return path, nil
+}
+
+// get returns the manifest of the snapshot of the session from the memory, or from the directory if evicted or
+// taken before the restart.
+func (s *snapshotStore) get(session, name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if manifest, ok := s.manifests[session][name]; ok {
+		return manifest, nil
+	}
+	if len(s.dir) > 0 {
+		data, err := os.ReadFile(s.path(session, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read snapshot: %w", err)
+		}
+	}
+	return "", fmt.Errorf("snapshot %q not found in this session", name)
+}
+
+// remove releases the snapshots of the session in memory, the ones in the directory are kept.
+func (s *snapshotStore) remove(session string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.manifests, session)
+	delete(s.names, session)
+}
+
+// validateSnapshotName checks the name is usable as the file name and the resource URI.
+func validateSnapshotName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid snapshot name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// snapshotSkipped returns true if the object is recreated by the control plane or its owner, so that it's not
+// part of the snapshot.
+func snapshotSkipped(obj *unstructured.Unstructured) bool {
+	if metav1.GetControllerOfNoCopy(obj) != nil {
+		return true
+	}
+	switch obj.GetKind() {
+	case "ServiceAccount":
+		return obj.GetName() == "default"
+	case "ConfigMap":
+		return obj.GetName() == "kube-root-ca.crt"
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType == string(corev1.SecretTypeServiceAccountToken)
+	}
+	return false
+}
+
+func (s *Server) SnapshotNamespace() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		kinds := req.GetStringSlice("kinds", nil)
+		labelSelector := req.GetString("labelSelector", "")
+		// the Secrets are only kept in the bundle if asked for, by the flag or the kinds.
+		includeSecrets := req.GetBool("includeSecrets", false) || slices.Contains(kinds, "Secret")
+		now := time.Now()
+		name := req.GetString("name", fmt.Sprintf("%s-%s", namespace, now.UTC().Format("20060102-150405")))
+		if err = validateSnapshotName(name); err != nil {
+			return nil, err
+		}
+
+		slog.Info("Taking namespace snapshot", "namespace", namespace, "name", name, "kinds", kinds, "labelSelector", labelSelector)
+
+		discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		targets, failed, err := namespacedTargets(discoveryClient)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot := &NamespaceSnapshot{
+			Name:      name,
+			URI:       koffeemcp.ResourceScheme + "://" + koffeemcp.SnapshotsCollection + "/" + name,
+			Namespace: namespace,
+			Created:   now.UTC().Format(time.RFC3339),
+			Kinds:     make(map[string]int),
+			Errors:    failed,
+		}
+		phases := make([][]*unstructured.Unstructured, len(teardownPhases))
+		for _, target := range targets {
+			if (len(kinds) > 0 && !slices.Contains(kinds, target.kind)) || (!includeSecrets && target.kind == "Secret") {
+				continue
+			}
+			items, err := dynamicClient.Resource(target.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+			if err != nil {
+				if snapshot.Errors == nil {
+					snapshot.Errors = make(map[string]string)
+				}
+				snapshot.Errors[target.gvr.GroupResource().String()] = err.Error()
+				continue
+			}
+			phase := teardownPhase(target.kind)
+			for i := range items.Items {
+				obj := &items.Items[i]
+				if snapshotSkipped(obj) {
+					continue
+				}
+				exportObject(obj)
+				phases[phase] = append(phases[phase], obj)
+				snapshot.Kinds[target.kind]++
+			}
+		}
+
+		// the objects are restored in the reverse order of the teardown, the dependencies before the consumers.
+		var manifest strings.Builder
+		for phase := len(phases) - 1; phase >= 0; phase-- {
+			objs := phases[phase]
+			sort.SliceStable(objs, func(i, j int) bool {
+				if objs[i].GetKind() != objs[j].GetKind() {
+					return objs[i].GetKind() < objs[j].GetKind()
+				}
+				return objs[i].GetName() < objs[j].GetName()
+			})
+			for _, obj := range objs {
+				data, err := yaml.Marshal(obj.Object)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal %s %s: %w", obj.GetKind(), obj.GetName(), err)
+				}
+				manifest.WriteString("---\n")
+				manifest.Write(data)
+				snapshot.Objects++
+			}
+		}
+		if snapshot.Objects == 0 {
+			return nil, fmt.Errorf("no object found in namespace %s to snapshot", namespace)
+		}
+		snapshot.Bytes = manifest.Len()
+		if snapshot.Path, err = s.snapshots.put(sessionID(ctx), name, manifest.String()); err != nil {
+			return nil, err
+		}
+
+		resp, err := json.Marshal(snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) RestoreNamespace() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("snapshot")
+		if err != nil {
+			return nil, err
+		}
+		if err = validateSnapshotName(name); err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		preview := req.GetBool("preview", false)
+
+		slog.Info("Restoring namespace snapshot", "snapshot", name, "namespace", namespace, "preview", preview)
+
+		manifest, err := s.snapshots.get(sessionID(ctx), name)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := decodeManifests(manifest)
+		if err != nil {
+			return nil, err
+		}
+		if len(objs) == 0 {
+			return nil, fmt.Errorf("snapshot %q has no object", name)
+		}
+		// the objects are restored to the namespace they are taken from unless another one is specified.
+		if len(namespace) == 0 {
+			namespace = objs[0].GetNamespace()
+		} else {
+			var rewritten strings.Builder
+			for _, obj := range objs {
+				obj.SetNamespace(namespace)
+				data, err := yaml.Marshal(obj.Object)
+				if err != nil {
+					return nil, err
+				}
+				rewritten.WriteString("---\n")
+				rewritten.Write(data)
+			}
+			manifest = rewritten.String()
+		}
+
+		restore := &NamespaceRestore{Snapshot: name, Namespace: namespace, Preview: preview}
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = cli.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			if preview {
+				return nil, fmt.Errorf("namespace %s is not found, it is created by the restore without preview", namespace)
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			if _, err = cli.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{FieldManager: applyFieldManager}); err != nil {
+				return nil, fmt.Errorf("failed to create namespace: %w", err)
+			}
+			restore.NamespaceCreated = true
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+
+		if restore.Objects, err = s.applyManifest(ctx, manifest, namespace, preview, false, true); err != nil {
+			return nil, err
+		}
+		resp, err := json.Marshal(restore)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
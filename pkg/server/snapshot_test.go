@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeSession is the client session of the tool calls in the tests.
+type fakeSession struct {
+	id string
+}
+
+func (s *fakeSession) Initialize()                                         {}
+func (s *fakeSession) Initialized() bool                                   { return true }
+func (s *fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *fakeSession) SessionID() string                                   { return s.id }
+
+func TestSnapshotStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     bool
+		session string
+		removed bool
+		wantOK  bool
+	}{
+		{name: "same session", session: "a", wantOK: true},
+		{name: "another session", session: "b"},
+		{name: "removed session", session: "a", removed: true},
+		{name: "removed session read from the directory", dir: true, session: "a", removed: true, wantOK: true},
+		{name: "another session of the directory", dir: true, session: "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dir string
+			if tt.dir {
+				dir = t.TempDir()
+			}
+			store := newSnapshotStore(dir)
+			if _, err := store.put("a", "shop", "kind: ConfigMap\n"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.removed {
+				store.remove("a")
+			}
+			manifest, err := store.get(tt.session, "shop")
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("got manifest %q with error %v, want found %t", manifest, err, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("eviction by session", func(t *testing.T) {
+		store := newSnapshotStore("")
+		for i := range maxSnapshots + 1 {
+			if _, err := store.put("a", fmt.Sprintf("a-%d", i), "kind: ConfigMap\n"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := store.put("b", "b-0", "kind: ConfigMap\n"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.get("a", "a-0"); err == nil {
+			t.Fatal("got the oldest snapshot of the session kept, want it evicted")
+		}
+		for _, get := range [][2]string{{"a", "a-1"}, {"b", "b-0"}} {
+			if _, err := store.get(get[0], get[1]); err != nil {
+				t.Fatalf("got error %v of %s, want it kept", err, get[1])
+			}
+		}
+	})
+}
+
+func TestSnapshotNamespaceSecrets(t *testing.T) {
+	resources := []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list", "delete"}},
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: []string{"list", "delete"}},
+		},
+	}}
+	object := func(kind, name string) runtime.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind(kind)
+		obj.SetNamespace("shop")
+		obj.SetName(name)
+		return obj
+	}
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantSecrets int
+	}{
+		{name: "secrets excluded by default", args: map[string]any{}},
+		{name: "secrets asked by the flag", args: map[string]any{"includeSecrets": true}, wantSecrets: 1},
+		{name: "secrets asked by the kinds", args: map[string]any{"kinds": []any{"Secret"}}, wantSecrets: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newFakeServer(resources, nil, object("ConfigMap", "app"), object("Secret", "token"))
+			s.snapshots = newSnapshotStore("")
+			req := mcp.CallToolRequest{}
+			req.Params.Arguments = tt.args
+			req.Params.Arguments.(map[string]any)["namespace"] = "shop"
+			req.Params.Arguments.(map[string]any)["name"] = "shop"
+			ctx := server.NewMCPServer("test", "0").WithContext(context.Background(), &fakeSession{id: "a"})
+			result, err := s.SnapshotNamespace()(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var snapshot NamespaceSnapshot
+			if err = json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &snapshot); err != nil {
+				t.Fatal(err)
+			}
+			if snapshot.Kinds["Secret"] != tt.wantSecrets {
+				t.Fatalf("got kinds %v, want %d secrets", snapshot.Kinds, tt.wantSecrets)
+			}
+			// the snapshot is only readable in the session it's taken in.
+			if _, err = s.snapshots.get("b", "shop"); err == nil {
+				t.Fatal("got the snapshot of another session")
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	noProvisioner                 = "kubernetes.io/no-provisioner"
+)
+
+// StorageReport is the troubleshooting report of a PersistentVolumeClaim.
+type StorageReport struct {
+	PVC          string         `json:"pvc"`
+	Namespace    string         `json:"namespace"`
+	Phase        string         `json:"phase"`
+	StorageClass string         `json:"storageClass,omitempty"`
+	Volume       string         `json:"volume,omitempty"`
+	Passed       bool           `json:"passed"`
+	Checks       []CheckResult  `json:"checks"`
+	Events       []EventSummary `json:"events,omitempty"`
+	// RootCause is the most likely reason of the failure, e.g. why the claim is pending.
+	RootCause string `json:"rootCause,omitempty"`
+}
+
+func (r *StorageReport) add(name string, passed bool, format string, args ...any) {
+	r.Checks = append(r.Checks, CheckResult{Name: name, Passed: passed, Message: fmt.Sprintf(format, args...)})
+	if !passed {
+		r.Passed = false
+		if len(r.RootCause) == 0 {
+			r.RootCause = fmt.Sprintf(format, args...)
+		}
+	}
+}
+
+func (s *Server) DiagnoseStorage() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := req.RequireString("namespace")
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Diagnosing storage", "name", name, "namespace", namespace)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		pvc, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistentvolumeclaim: %w", err)
+		}
+
+		report := &StorageReport{
+			PVC:       pvc.Name,
+			Namespace: pvc.Namespace,
+			Phase:     string(pvc.Status.Phase),
+			Volume:    pvc.Spec.VolumeName,
+			Passed:    true,
+			Checks:    make([]CheckResult, 0),
+		}
+		if report.Events, err = listObjectEvents(ctx, cli, namespace, "PersistentVolumeClaim", name); err != nil {
+			slog.Warn("Failed to list persistentvolumeclaim events", "name", name, "namespace", namespace, "err", err)
+		}
+
+		class, err := checkStorageClass(ctx, cli, pvc, report)
+		if err != nil {
+			return nil, err
+		}
+		pods, err := claimConsumers(ctx, cli, pvc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		if pvc.Status.Phase == corev1.ClaimBound {
+			report.add("binding", true, "the claim is bound to the volume %s", pvc.Spec.VolumeName)
+			if err = checkBoundVolume(ctx, cli, pvc, pods, report); err != nil {
+				return nil, err
+			}
+		} else if err = checkPendingClaim(ctx, cli, pvc, class, pods, report); err != nil {
+			return nil, err
+		}
+
+		// the warning events usually carry the error of the provisioner or the attacher.
+		if len(report.RootCause) == 0 && !report.Passed {
+			for _, event := range report.Events {
+				if event.Type == corev1.EventTypeWarning {
+					report.RootCause = fmt.Sprintf("%s: %s", event.Reason, event.Message)
+					break
+				}
+			}
+		}
+
+		resp, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// checkStorageClass checks the storage class and the provisioner of the claim, the nil class is returned if the
+// claim uses static provisioning or the class does not exist.
+func checkStorageClass(ctx context.Context, cli kubernetes.Interface, pvc *corev1.PersistentVolumeClaim, report *StorageReport) (*storagev1.StorageClass, error) {
+	className := ptr.Deref(pvc.Spec.StorageClassName, "")
+	if pvc.Spec.StorageClassName == nil {
+		classes, err := cli.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list storageclasses: %w", err)
+		}
+		for i := range classes.Items {
+			if classes.Items[i].Annotations[defaultStorageClassAnnotation] == "true" {
+				className = classes.Items[i].Name
+				break
+			}
+		}
+		if len(className) == 0 {
+			report.add("storageclass", pvc.Status.Phase == corev1.ClaimBound,
+				"the claim has no storage class and there is no default storage class, only the static volumes can be bound")
+			return nil, nil
+		}
+	}
+	report.StorageClass = className
+	if len(className) == 0 {
+		report.add("storageclass", true, "the claim disables dynamic provisioning with an empty storage class")
+		return nil, nil
+	}
+
+	class, err := cli.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		report.add("storageclass", pvc.Status.Phase == corev1.ClaimBound, "the storage class %s does not exist", className)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storageclass: %w", err)
+	}
+	report.add("storageclass", true, "the storage class %s uses the provisioner %s with the binding mode %s",
+		className, class.Provisioner, ptr.Deref(class.VolumeBindingMode, storagev1.VolumeBindingImmediate))
+
+	if class.Provisioner == noProvisioner || strings.HasPrefix(class.Provisioner, "kubernetes.io/") {
+		return class, nil
+	}
+	if _, err = cli.StorageV1().CSIDrivers().Get(ctx, class.Provisioner, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+		report.add("provisioner", false, "the CSIDriver %s of the storage class is not installed", class.Provisioner)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get csidriver: %w", err)
+	} else {
+		report.add("provisioner", true, "the CSIDriver %s is installed", class.Provisioner)
+	}
+	return class, nil
+}
+
+// checkPendingClaim finds out why the claim is not bound.
+func checkPendingClaim(ctx context.Context, cli kubernetes.Interface, pvc *corev1.PersistentVolumeClaim, class *storagev1.StorageClass, pods []corev1.Pod, report *StorageReport) error {
+	if pvc.Status.Phase == corev1.ClaimLost {
+		report.add("binding", false, "the claim lost its volume %s, the volume was deleted or bound to another claim", pvc.Spec.VolumeName)
+		return nil
+	}
+
+	if class != nil && ptr.Deref(class.VolumeBindingMode, storagev1.VolumeBindingImmediate) == storagev1.VolumeBindingWaitForFirstConsumer {
+		if len(pods) == 0 {
+			report.add("binding", true, "the claim waits for the first pod using it to be scheduled because of the WaitForFirstConsumer binding mode")
+			return nil
+		}
+		for i := range pods {
+			if len(pods[i].Spec.NodeName) == 0 {
+				report.add("binding", false, "the pod %s using the claim is not scheduled, check its scheduling events", pods[i].Name)
+				return nil
+			}
+		}
+	}
+
+	if class != nil && class.Provisioner != noProvisioner {
+		for _, event := range report.Events {
+			if event.Type == corev1.EventTypeWarning {
+				report.add("binding", false, "the provisioner %s failed to provision the volume, %s: %s", class.Provisioner, event.Reason, event.Message)
+				return nil
+			}
+		}
+		report.add("binding", false, "the claim is %s, the volume is not provisioned by %s yet", pvc.Status.Phase, class.Provisioner)
+		return nil
+	}
+
+	// without a dynamic provisioner the claim is bound to an available volume which satisfies it.
+	pvs, err := cli.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list persistentvolumes: %w", err)
+	}
+	var candidates []string
+	for i := range pvs.Items {
+		if volumeSatisfiesClaim(&pvs.Items[i], pvc, report.StorageClass) {
+			candidates = append(candidates, pvs.Items[i].Name)
+		}
+	}
+	if len(candidates) == 0 {
+		report.add("volume", false, "no available volume of the storage class %q satisfies the requested capacity and access modes", report.StorageClass)
+	} else {
+		report.add("volume", true, "the available volumes %s satisfy the claim", strings.Join(candidates, ", "))
+	}
+	return nil
+}
+
+// checkBoundVolume checks the volume of the bound claim, the volume attachments and the CSI drivers on the nodes
+// of the pods using the claim.
+func checkBoundVolume(ctx context.Context, cli kubernetes.Interface, pvc *corev1.PersistentVolumeClaim, pods []corev1.Pod, report *StorageReport) error {
+	pv, err := cli.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		report.add("volume", false, "the volume %s of the claim does not exist", pvc.Spec.VolumeName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get persistentvolume: %w", err)
+	}
+	if ref := pv.Spec.ClaimRef; ref == nil || ref.Namespace != pvc.Namespace || ref.Name != pvc.Name {
+		report.add("volume", false, "the volume %s is not bound to the claim", pv.Name)
+		return nil
+	}
+	report.add("volume", pv.Status.Phase == corev1.VolumeBound, "the volume %s is %s", pv.Name, pv.Status.Phase)
+
+	if pv.Spec.CSI == nil {
+		return nil
+	}
+	driver := pv.Spec.CSI.Driver
+	attachments, err := cli.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list volumeattachments: %w", err)
+	}
+	attachedNodes := make(map[string]*storagev1.VolumeAttachment)
+	for i := range attachments.Items {
+		va := &attachments.Items[i]
+		if ptr.Deref(va.Spec.Source.PersistentVolumeName, "") == pv.Name {
+			attachedNodes[va.Spec.NodeName] = va
+		}
+	}
+
+	for i := range pods {
+		node := pods[i].Spec.NodeName
+		if len(node) == 0 {
+			continue
+		}
+		csiNode, err := cli.StorageV1().CSINodes().Get(ctx, node, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get csinode: %w", err)
+		}
+		registered := csiNode != nil && slices.ContainsFunc(csiNode.Spec.Drivers, func(d storagev1.CSINodeDriver) bool {
+			return d.Name == driver
+		})
+		if !registered {
+			report.add("csinode", false, "the CSI driver %s is not registered on the node %s of the pod %s", driver, node, pods[i].Name)
+			continue
+		}
+		report.add("csinode", true, "the CSI driver %s is registered on the node %s", driver, node)
+
+		va, ok := attachedNodes[node]
+		switch {
+		case !ok:
+			// the drivers without the attach step, e.g. the NFS drivers, have no VolumeAttachment.
+			report.add("attachment", true, "no VolumeAttachment of the volume on the node %s, the driver may not require attaching", node)
+		case va.Status.AttachError != nil:
+			report.add("attachment", false, "the volume failed to attach to the node %s: %s", node, va.Status.AttachError.Message)
+		case va.Status.DetachError != nil:
+			report.add("attachment", false, "the volume failed to detach from the node %s: %s", node, va.Status.DetachError.Message)
+		case !va.Status.Attached:
+			report.add("attachment", false, "the volume is not attached to the node %s yet", node)
+		default:
+			report.add("attachment", true, "the volume is attached to the node %s", node)
+		}
+	}
+	return nil
+}
+
+// claimConsumers returns the pods in the namespace which mount the claim.
+func claimConsumers(ctx context.Context, cli kubernetes.Interface, pvc *corev1.PersistentVolumeClaim) ([]corev1.Pod, error) {
+	pods, err := cli.CoreV1().Pods(pvc.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var consumers []corev1.Pod
+	for i := range pods.Items {
+		for _, v := range pods.Items[i].Spec.Volumes {
+			if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == pvc.Name {
+				consumers = append(consumers, pods.Items[i])
+				break
+			}
+		}
+	}
+	return consumers, nil
+}
+
+// volumeSatisfiesClaim reports whether the available volume can be bound to the claim.
+func volumeSatisfiesClaim(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim, className string) bool {
+	if pv.Status.Phase != corev1.VolumeAvailable || pv.Spec.StorageClassName != className {
+		return false
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil && (ref.Namespace != pvc.Namespace || ref.Name != pvc.Name) {
+		return false
+	}
+	if request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(request) < 0 {
+			return false
+		}
+	}
+	for _, mode := range pvc.Spec.AccessModes {
+		if !slices.Contains(pv.Spec.AccessModes, mode) {
+			return false
+		}
+	}
+	return ptr.Deref(pv.Spec.VolumeMode, corev1.PersistentVolumeFilesystem) == ptr.Deref(pvc.Spec.VolumeMode, corev1.PersistentVolumeFilesystem)
+}
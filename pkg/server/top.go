@@ -3,16 +3,54 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubectl/pkg/metricsutil"
 	metricsapi "k8s.io/metrics/pkg/apis/metrics"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"cola.io/koffee/pkg/definition"
+	koffeemcp "cola.io/koffee/pkg/mcp"
+)
+
+const (
+	// maxTopSamples is the maximum samples of the metrics taken by a top tool call.
+	maxTopSamples = 20
+	// trendThreshold is the relative change over the samples from which the usage is rising or falling.
+	trendThreshold = 0.05
 )
 
+// UsageTrend is the usage of a pod or node over the metric samples.
+type UsageTrend struct {
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Samples   int           `json:"samples"`
+	CPU       ResourceTrend `json:"cpu"`
+	Memory    ResourceTrend `json:"memory"`
+}
+
+// ResourceTrend is the min, average, max and last usage of a resource, the trend is one of rising, falling or
+// stable by the linear regression of the samples.
+type ResourceTrend struct {
+	Min   string `json:"min"`
+	Avg   string `json:"avg"`
+	Max   string `json:"max"`
+	Last  string `json:"last"`
+	Trend string `json:"trend"`
+}
+
 func (s *Server) TopPod() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		namespace := req.GetString("namespace", metav1.NamespaceAll)
@@ -20,40 +58,54 @@ func (s *Server) TopPod() func(ctx context.Context, req mcp.CallToolRequest) (*m
 		sortBy := req.GetString("sortBy", "")
 		labelSelector := req.GetString("labelSelector", "")
 		fieldSelector := req.GetString("fieldSelector", "")
+		output := req.GetString("output", definition.OutputText)
+		samples, interval, err := sampleOptions(req)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(koffeemcp.TopOutputs, output) {
+			return nil, fmt.Errorf("unsupported output %q, must be one of %s", output, strings.Join(koffeemcp.TopOutputs, ", "))
+		}
 
-		slog.Info("Loading top pod argument", "namespace", namespace, "resourceName", resourceName, "sortBy", sortBy, "labelSelector", labelSelector, "fieldSelector", fieldSelector)
+		slog.Info("Loading top pod argument", "namespace", namespace, "resourceName", resourceName, "sortBy", sortBy, "labelSelector", labelSelector, "fieldSelector", fieldSelector, "output", output, "samples", samples, "interval", interval)
 
-		metricClient, err := s.cb.GetMetricsClient()
+		metricClient, err := s.builder(ctx).GetMetricsClient()
 		if err != nil {
 			return nil, err
 		}
 
-		versionedMetrics := &metricsv1beta1.PodMetricsList{}
-		if resourceName != "" {
-			m, err := metricClient.MetricsV1beta1().PodMetricses(namespace).Get(ctx, resourceName, metav1.GetOptions{})
-			if err != nil {
-				return nil, err
-			}
-			versionedMetrics.Items = []metricsv1beta1.PodMetrics{*m}
-		} else {
-			options := metav1.ListOptions{}
-			if len(labelSelector) > 0 {
-				options.LabelSelector = labelSelector
-			}
-			if len(fieldSelector) > 0 {
-				options.FieldSelector = fieldSelector
-			}
-			versionedMetrics, err = metricClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, options)
+		options := metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}
+		if samples > 1 {
+			trends := newUsageSampler()
+			err = sampleMetrics(ctx, samples, interval, func() error {
+				metrics, err := podMetrics(ctx, metricClient, namespace, resourceName, options)
+				if err != nil {
+					return err
+				}
+				for _, m := range metrics.Items {
+					usage := corev1.ResourceList{}
+					for _, c := range m.Containers {
+						for name, q := range c.Usage {
+							addQuantity(usage, name, q)
+						}
+					}
+					trends.add(m.Namespace, m.Name, usage)
+				}
+				return nil
+			})
 			if err != nil {
 				return nil, err
 			}
+			return trendsResult(trends.trends(sortBy))
 		}
 
-		metrics := &metricsapi.PodMetricsList{}
-		if err = metricsv1beta1.Convert_v1beta1_PodMetricsList_To_metrics_PodMetricsList(versionedMetrics, metrics, nil); err != nil {
+		metrics, err := podMetrics(ctx, metricClient, namespace, resourceName, options)
+		if err != nil {
 			return nil, err
 		}
-
+		if output == definition.OutputTable {
+			return tableResult(podMetricsTable(metrics.Items, sortBy))
+		}
 		out := bytes.NewBuffer(make([]byte, 0))
 		if err := metricsutil.NewTopCmdPrinter(out).PrintPodMetrics(metrics.Items, true, true, false, sortBy, true); err != nil {
 			return nil, err
@@ -67,44 +119,59 @@ func (s *Server) TopNode() func(ctx context.Context, req mcp.CallToolRequest) (*
 		resourceName := req.GetString("name", "")
 		sortBy := req.GetString("sortBy", "")
 		labelSelector := req.GetString("labelSelector", "")
+		output := req.GetString("output", definition.OutputText)
+		samples, interval, err := sampleOptions(req)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(koffeemcp.TopOutputs, output) {
+			return nil, fmt.Errorf("unsupported output %q, must be one of %s", output, strings.Join(koffeemcp.TopOutputs, ", "))
+		}
 
-		slog.Info("Loading top node argument", "resourceName", resourceName, "sortBy", sortBy, "labelSelector", labelSelector)
+		slog.Info("Loading top node argument", "resourceName", resourceName, "sortBy", sortBy, "labelSelector", labelSelector, "output", output, "samples", samples, "interval", interval)
 
-		cli, err := s.cb.GetClient()
+		cli, err := s.builder(ctx).GetClient()
 		if err != nil {
 			return nil, err
 		}
 
-		metricClient, err := s.cb.GetMetricsClient()
+		metricClient, err := s.builder(ctx).GetMetricsClient()
 		if err != nil {
 			return nil, err
 		}
 
-		versionedMetrics := &metricsv1beta1.NodeMetricsList{}
-		var nodes []corev1.Node
-		if resourceName != "" {
-			m, err := metricClient.MetricsV1beta1().NodeMetricses().Get(ctx, resourceName, metav1.GetOptions{})
+		options := metav1.ListOptions{LabelSelector: labelSelector}
+		if samples > 1 {
+			trends := newUsageSampler()
+			err = sampleMetrics(ctx, samples, interval, func() error {
+				metrics, err := nodeMetrics(ctx, metricClient, resourceName, options)
+				if err != nil {
+					return err
+				}
+				for _, m := range metrics.Items {
+					trends.add("", m.Name, m.Usage)
+				}
+				return nil
+			})
 			if err != nil {
 				return nil, err
 			}
-			versionedMetrics.Items = []metricsv1beta1.NodeMetrics{*m}
+			return trendsResult(trends.trends(sortBy))
+		}
+
+		metrics, err := nodeMetrics(ctx, metricClient, resourceName, options)
+		if err != nil {
+			return nil, err
+		}
 
+		var nodes []corev1.Node
+		if resourceName != "" {
 			node, err := cli.CoreV1().Nodes().Get(ctx, resourceName, metav1.GetOptions{})
 			if err != nil {
 				return nil, err
 			}
 			nodes = append(nodes, *node)
 		} else {
-			options := metav1.ListOptions{}
-			if len(labelSelector) > 0 {
-				options.LabelSelector = labelSelector
-			}
-
-			versionedMetrics, err = metricClient.MetricsV1beta1().NodeMetricses().List(ctx, options)
-			if err != nil {
-				return nil, err
-			}
-
 			nodeList, err := cli.CoreV1().Nodes().List(ctx, options)
 			if err != nil {
 				return nil, err
@@ -112,16 +179,14 @@ func (s *Server) TopNode() func(ctx context.Context, req mcp.CallToolRequest) (*
 			nodes = append(nodes, nodeList.Items...)
 		}
 
-		metrics := &metricsapi.NodeMetricsList{}
-		if err = metricsv1beta1.Convert_v1beta1_NodeMetricsList_To_metrics_NodeMetricsList(versionedMetrics, metrics, nil); err != nil {
-			return nil, err
-		}
-
 		availableResources := make(map[string]corev1.ResourceList)
 		for _, n := range nodes {
 			availableResources[n.Name] = n.Status.Capacity
 		}
 
+		if output == definition.OutputTable {
+			return tableResult(nodeMetricsTable(metrics.Items, availableResources, sortBy))
+		}
 		out := bytes.NewBuffer(make([]byte, 0))
 		if err := metricsutil.NewTopCmdPrinter(out).PrintNodeMetrics(metrics.Items, availableResources, false, sortBy); err != nil {
 			return nil, err
@@ -129,3 +194,270 @@ func (s *Server) TopNode() func(ctx context.Context, req mcp.CallToolRequest) (*
 		return mcp.NewToolResultText(out.String()), nil
 	}
 }
+
+func podMetrics(ctx context.Context, metricClient metricsclientset.Interface, namespace, name string, options metav1.ListOptions) (*metricsapi.PodMetricsList, error) {
+	versionedMetrics := &metricsv1beta1.PodMetricsList{}
+	if name != "" {
+		m, err := metricClient.MetricsV1beta1().PodMetricses(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		versionedMetrics.Items = []metricsv1beta1.PodMetrics{*m}
+	} else {
+		var err error
+		if versionedMetrics, err = metricClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, options); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := &metricsapi.PodMetricsList{}
+	if err := metricsv1beta1.Convert_v1beta1_PodMetricsList_To_metrics_PodMetricsList(versionedMetrics, metrics, nil); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func nodeMetrics(ctx context.Context, metricClient metricsclientset.Interface, name string, options metav1.ListOptions) (*metricsapi.NodeMetricsList, error) {
+	versionedMetrics := &metricsv1beta1.NodeMetricsList{}
+	if name != "" {
+		m, err := metricClient.MetricsV1beta1().NodeMetricses().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		versionedMetrics.Items = []metricsv1beta1.NodeMetrics{*m}
+	} else {
+		var err error
+		if versionedMetrics, err = metricClient.MetricsV1beta1().NodeMetricses().List(ctx, options); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := &metricsapi.NodeMetricsList{}
+	if err := metricsv1beta1.Convert_v1beta1_NodeMetricsList_To_metrics_NodeMetricsList(versionedMetrics, metrics, nil); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// podMetricsTable returns the usage of the pods as a table, the CPU is in millicores and the memory in bytes.
+func podMetricsTable(metrics []metricsapi.PodMetrics, sortBy string) *metav1.Table {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Namespace", Type: "string"},
+			{Name: "Name", Type: "string"},
+			{Name: "CPU(millicores)", Type: "integer"},
+			{Name: "Memory(bytes)", Type: "integer"},
+		},
+		Rows: make([]metav1.TableRow, 0, len(metrics)),
+	}
+	for _, m := range metrics {
+		usage := corev1.ResourceList{}
+		for _, c := range m.Containers {
+			for name, q := range c.Usage {
+				addQuantity(usage, name, q)
+			}
+		}
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells: []any{m.Namespace, m.Name, usage.Cpu().MilliValue(), usage.Memory().Value()},
+		})
+	}
+	sortMetricsRows(table.Rows, 2, 3, sortBy)
+	return table
+}
+
+// nodeMetricsTable returns the usage of the nodes as a table, the percentages are of the node capacity like
+// kubectl top node.
+func nodeMetricsTable(metrics []metricsapi.NodeMetrics, capacity map[string]corev1.ResourceList, sortBy string) *metav1.Table {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string"},
+			{Name: "CPU(millicores)", Type: "integer"},
+			{Name: "CPU%", Type: "integer"},
+			{Name: "Memory(bytes)", Type: "integer"},
+			{Name: "Memory%", Type: "integer"},
+		},
+		Rows: make([]metav1.TableRow, 0, len(metrics)),
+	}
+	percent := func(used, total int64) any {
+		if total == 0 {
+			return nil
+		}
+		return used * 100 / total
+	}
+	for _, m := range metrics {
+		cpu, memory := m.Usage.Cpu().MilliValue(), m.Usage.Memory().Value()
+		available := capacity[m.Name]
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Cells: []any{m.Name, cpu, percent(cpu, available.Cpu().MilliValue()), memory, percent(memory, available.Memory().Value())},
+		})
+	}
+	sortMetricsRows(table.Rows, 1, 3, sortBy)
+	return table
+}
+
+// sortMetricsRows sorts the rows by the usage of cpu or memory in descending order, the rows are kept in the
+// order of the metrics server otherwise.
+func sortMetricsRows(rows []metav1.TableRow, cpuColumn, memoryColumn int, sortBy string) {
+	column := cpuColumn
+	switch sortBy {
+	case "cpu":
+	case "memory":
+		column = memoryColumn
+	default:
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Cells[column].(int64) > rows[j].Cells[column].(int64)
+	})
+}
+
+// sampleOptions returns the number of the samples and the interval between them, the samples over the interval
+// must fit into the timeout of the tool call.
+func sampleOptions(req mcp.CallToolRequest) (int, time.Duration, error) {
+	samples := req.GetInt("samples", 1)
+	if samples < 1 || samples > maxTopSamples {
+		return 0, 0, fmt.Errorf("samples must be between 1 and %d", maxTopSamples)
+	}
+	interval, err := time.ParseDuration(req.GetString("interval", "15s"))
+	if err != nil || interval < time.Second {
+		return 0, 0, fmt.Errorf("invalid interval %q, must be a duration of at least 1s", req.GetString("interval", ""))
+	}
+	return samples, interval, nil
+}
+
+// sampleMetrics takes the samples of the metrics at the interval, the metrics server refreshes the metrics every
+// 15s by default, so the shorter interval may take the same metrics repeatedly.
+func sampleMetrics(ctx context.Context, samples int, interval time.Duration, sample func() error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+		if err := sample(); err != nil {
+			return fmt.Errorf("failed to take sample %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+type usageSamples struct {
+	namespace string
+	name      string
+	cpu       []float64
+	memory    []float64
+}
+
+// usageSampler collects the CPU in millicores and the memory in bytes of the pods or nodes over the samples.
+type usageSampler struct {
+	samples map[string]*usageSamples
+}
+
+func newUsageSampler() *usageSampler {
+	return &usageSampler{samples: make(map[string]*usageSamples)}
+}
+
+func (u *usageSampler) add(namespace, name string, usage corev1.ResourceList) {
+	key := namespace + "/" + name
+	samples, ok := u.samples[key]
+	if !ok {
+		samples = &usageSamples{namespace: namespace, name: name}
+		u.samples[key] = samples
+	}
+	samples.cpu = append(samples.cpu, float64(usage.Cpu().MilliValue()))
+	samples.memory = append(samples.memory, float64(usage.Memory().Value()))
+}
+
+// trends returns the usage trends sorted by the last usage of cpu or memory, or by the name.
+func (u *usageSampler) trends(sortBy string) []UsageTrend {
+	keys := make([]string, 0, len(u.samples))
+	for key := range u.samples {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := u.samples[keys[i]], u.samples[keys[j]]
+		switch sortBy {
+		case "cpu":
+			return a.cpu[len(a.cpu)-1] > b.cpu[len(b.cpu)-1]
+		case "memory":
+			return a.memory[len(a.memory)-1] > b.memory[len(b.memory)-1]
+		}
+		return keys[i] < keys[j]
+	})
+
+	trends := make([]UsageTrend, 0, len(keys))
+	for _, key := range keys {
+		samples := u.samples[key]
+		trends = append(trends, UsageTrend{
+			Namespace: samples.namespace,
+			Name:      samples.name,
+			Samples:   len(samples.cpu),
+			CPU: resourceTrend(samples.cpu, func(v float64) string {
+				return resource.NewMilliQuantity(int64(v), resource.DecimalSI).String()
+			}),
+			Memory: resourceTrend(samples.memory, func(v float64) string {
+				return fmt.Sprintf("%dMi", int64(v)/(1024*1024))
+			}),
+		})
+	}
+	return trends
+}
+
+func resourceTrend(values []float64, format func(float64) string) ResourceTrend {
+	lowest, highest, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, v := range values {
+		lowest, highest, sum = math.Min(lowest, v), math.Max(highest, v), sum+v
+	}
+	avg := sum / float64(len(values))
+	return ResourceTrend{
+		Min:   format(lowest),
+		Avg:   format(avg),
+		Max:   format(highest),
+		Last:  format(values[len(values)-1]),
+		Trend: trendDirection(values, avg),
+	}
+}
+
+// trendDirection returns the direction of the linear regression of the values, the change over the samples
+// below the threshold of the average is stable.
+func trendDirection(values []float64, avg float64) string {
+	n := float64(len(values))
+	if n < 2 || avg == 0 {
+		return "stable"
+	}
+	meanX := (n - 1) / 2
+	var num, den float64
+	for i, v := range values {
+		num += (float64(i) - meanX) * (v - avg)
+		den += (float64(i) - meanX) * (float64(i) - meanX)
+	}
+	change := num / den * (n - 1) / avg
+	switch {
+	case change > trendThreshold:
+		return "rising"
+	case change < -trendThreshold:
+		return "falling"
+	default:
+		return "stable"
+	}
+}
+
+func tableResult(table *metav1.Table) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(table)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+func trendsResult(trends []UsageTrend) (*mcp.CallToolResult, error) {
+	resp, err := json.Marshal(trends)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
@@ -0,0 +1,312 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// TrafficSwitch is the switch of the traffic between the workload versions, either by the selector of a Service
+// or by the backend weights of an HTTPRoute.
+type TrafficSwitch struct {
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace"`
+	Name      string          `json:"name"`
+	DryRun    bool            `json:"dryRun"`
+	Before    *SelectedPods   `json:"before,omitempty"`
+	After     *SelectedPods   `json:"after,omitempty"`
+	Added     []string        `json:"added,omitempty"`
+	Removed   []string        `json:"removed,omitempty"`
+	Backends  []BackendWeight `json:"backends,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// SelectedPods is the pods selected by the selector of the service, only the ready pods receive the traffic.
+type SelectedPods struct {
+	Selector string   `json:"selector"`
+	Pods     int      `json:"pods"`
+	Ready    []string `json:"ready"`
+}
+
+// BackendWeight is the weight of a backend of an HTTPRoute rule before and after the switch, the share is the
+// percentage of the traffic of the rule the backend receives after the switch.
+type BackendWeight struct {
+	Rule           int    `json:"rule"`
+	Name           string `json:"name"`
+	Weight         int32  `json:"weight"`
+	NewWeight      int32  `json:"newWeight"`
+	Share          string `json:"share"`
+	ReadyEndpoints *int   `json:"readyEndpoints,omitempty"`
+}
+
+func (s *Server) SwitchTraffic() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kind, err := req.RequireString("kind")
+		if err != nil {
+			return nil, err
+		}
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		dryRun := req.GetBool("dryRun", false)
+		force := req.GetBool("force", false)
+
+		slog.Info("Switching traffic", "kind", kind, "name", name, "namespace", namespace, "dryRun", dryRun)
+
+		var result *TrafficSwitch
+		switch kind {
+		case "Service":
+			selector, _ := req.GetArguments()["selector"].(map[string]any)
+			if len(selector) == 0 {
+				return nil, fmt.Errorf("selector is required to switch the traffic of a service")
+			}
+			result, err = s.switchServiceSelector(ctx, namespace, name, selector, dryRun, force)
+		case "HTTPRoute":
+			weights, _ := req.GetArguments()["weights"].(map[string]any)
+			if len(weights) == 0 {
+				return nil, fmt.Errorf("weights are required to switch the traffic of an httproute")
+			}
+			result, err = s.switchRouteWeights(ctx, namespace, name, req.GetInt("rule", -1), weights, dryRun, force)
+		default:
+			return nil, fmt.Errorf("unsupported kind %s, must be Service or HTTPRoute", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// switchServiceSelector sets the labels of the selector of the service, the empty values remove the labels. The
+// selector is replaced by a single patch guarded by the resource version, so the service never selects a mix of
+// the labels of the concurrent changes.
+func (s *Server) switchServiceSelector(ctx context.Context, namespace, name string, labelValues map[string]any, dryRun, force bool) (*TrafficSwitch, error) {
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		return nil, err
+	}
+	svc, err := cli.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s/%s has no selector, its endpoints are managed manually", namespace, name)
+	}
+
+	selector := maps.Clone(svc.Spec.Selector)
+	for key, value := range labelValues {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value of the selector label %s, must be a string", key)
+		}
+		if len(str) == 0 {
+			delete(selector, key)
+		} else {
+			selector[key] = str
+		}
+	}
+	if len(selector) == 0 {
+		return nil, fmt.Errorf("the selector of service %s/%s can not be empty", namespace, name)
+	}
+
+	result := &TrafficSwitch{Kind: "Service", Namespace: namespace, Name: name, DryRun: dryRun}
+	if result.Before, err = selectedPods(ctx, cli, namespace, svc.Spec.Selector); err != nil {
+		return nil, err
+	}
+	if result.After, err = selectedPods(ctx, cli, namespace, selector); err != nil {
+		return nil, err
+	}
+	for _, pod := range result.After.Ready {
+		if !slices.Contains(result.Before.Ready, pod) {
+			result.Added = append(result.Added, pod)
+		}
+	}
+	for _, pod := range result.Before.Ready {
+		if !slices.Contains(result.After.Ready, pod) {
+			result.Removed = append(result.Removed, pod)
+		}
+	}
+	if maps.Equal(selector, svc.Spec.Selector) {
+		result.Warnings = append(result.Warnings, "the selector is unchanged")
+		return result, nil
+	}
+	if len(result.After.Ready) == 0 {
+		warning := fmt.Sprintf("the selector %q matches no ready pod, the service would drop all the traffic", result.After.Selector)
+		if !dryRun && !force {
+			return nil, fmt.Errorf("%s, set force to switch anyway", warning)
+		}
+		result.Warnings = append(result.Warnings, warning)
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	patch, err := json.Marshal([]map[string]any{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": svc.ResourceVersion},
+		{"op": "replace", "path": "/spec/selector", "value": selector},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = cli.CoreV1().Services(namespace).Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to patch service: %w", err)
+	}
+	return result, nil
+}
+
+// selectedPods returns the pods selected by the labels and the names of the ready ones.
+func selectedPods(ctx context.Context, cli kubernetes.Interface, namespace string, set map[string]string) (*SelectedPods, error) {
+	selector := labels.SelectorFromSet(set).String()
+	pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	selected := &SelectedPods{Selector: selector, Pods: len(pods.Items), Ready: make([]string, 0)}
+	for i := range pods.Items {
+		if podReady(&pods.Items[i]) {
+			selected.Ready = append(selected.Ready, pods.Items[i].Name)
+		}
+	}
+	sort.Strings(selected.Ready)
+	return selected, nil
+}
+
+// switchRouteWeights sets the weights of the backends of the HTTPRoute rules keyed by the backend name, all the
+// rules referencing the backends are changed unless a rule is specified. The weights are changed by a single patch
+// guarded by the resource version.
+func (s *Server) switchRouteWeights(ctx context.Context, namespace, name string, rule int, weights map[string]any, dryRun, force bool) (*TrafficSwitch, error) {
+	discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	routeGVR, _, err := lookupGroupKindResource(discoveryClient, schema.GroupKind{Group: definition.GatewayGroup, Kind: "HTTPRoute"})
+	if err != nil {
+		return nil, fmt.Errorf("the Gateway API is not installed in the cluster: %w", err)
+	}
+	dynamicClient, err := s.builder(ctx).GetDynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	cli, err := s.builder(ctx).GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	newWeights := make(map[string]int32, len(weights))
+	for backend, value := range weights {
+		weight, ok := value.(float64)
+		if !ok || weight < 0 || weight > 1000000 || weight != float64(int32(weight)) {
+			return nil, fmt.Errorf("invalid weight of the backend %s, must be an integer between 0 and 1000000", backend)
+		}
+		newWeights[backend] = int32(weight)
+	}
+
+	obj, err := dynamicClient.Resource(routeGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get httproute: %w", err)
+	}
+	route := &httpRoute{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), route); err != nil {
+		return nil, fmt.Errorf("failed to decode httproute: %w", err)
+	}
+	if rule >= len(route.Spec.Rules) {
+		return nil, fmt.Errorf("httproute %s/%s has %d rule(s), rule %d is out of range", namespace, name, len(route.Spec.Rules), rule)
+	}
+
+	result := &TrafficSwitch{Kind: "HTTPRoute", Namespace: namespace, Name: name, DryRun: dryRun}
+	ops := []map[string]any{{"op": "test", "path": "/metadata/resourceVersion", "value": obj.GetResourceVersion()}}
+	found := make(map[string]bool)
+	for i, r := range route.Spec.Rules {
+		if rule >= 0 && i != rule {
+			continue
+		}
+		if !slices.ContainsFunc(r.BackendRefs, func(ref backendRef) bool { _, ok := newWeights[ref.Name]; return ok }) {
+			continue
+		}
+
+		var total int32
+		backends := make([]BackendWeight, 0, len(r.BackendRefs))
+		for j, ref := range r.BackendRefs {
+			backend := BackendWeight{Rule: i, Name: ref.Name, Weight: ptr.Deref(ref.Weight, 1)}
+			backend.NewWeight = backend.Weight
+			if weight, ok := newWeights[ref.Name]; ok {
+				found[ref.Name] = true
+				backend.NewWeight = weight
+				if weight != backend.Weight {
+					ops = append(ops, map[string]any{"op": "add", "path": fmt.Sprintf("/spec/rules/%d/backendRefs/%d/weight", i, j), "value": weight})
+				}
+			}
+			if ptr.Deref(ref.Group, "") == "" && ptr.Deref(ref.Kind, "Service") == "Service" {
+				if _, ready, _, err := serviceEndpoints(ctx, cli, ptr.Deref(ref.Namespace, namespace), ref.Name); err == nil {
+					backend.ReadyEndpoints = &ready
+				}
+			}
+			total += backend.NewWeight
+			backends = append(backends, backend)
+		}
+
+		for j := range backends {
+			if total > 0 {
+				backends[j].Share = fmt.Sprintf("%.1f%%", float64(backends[j].NewWeight)*100/float64(total))
+			} else {
+				backends[j].Share = "0%"
+			}
+			if backends[j].NewWeight > 0 && backends[j].ReadyEndpoints != nil && *backends[j].ReadyEndpoints == 0 {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("the backend %s of rule %d has no ready endpoint, its share of the traffic would fail", backends[j].Name, i))
+			}
+		}
+		if total == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("all the backends of rule %d have the weight 0, the rule would fail all the requests", i))
+		}
+		result.Backends = append(result.Backends, backends...)
+	}
+	for backend := range newWeights {
+		if !found[backend] {
+			return nil, fmt.Errorf("backend %s is not referenced by the selected rules of httproute %s/%s", backend, namespace, name)
+		}
+	}
+	if len(ops) == 1 {
+		result.Warnings = append(result.Warnings, "the weights are unchanged")
+		return result, nil
+	}
+	if len(result.Warnings) > 0 && !dryRun && !force {
+		return nil, fmt.Errorf("%s, set force to switch anyway", result.Warnings[0])
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dynamicClient.Resource(routeGVR).Namespace(namespace).Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to patch httproute: %w", err)
+	}
+	return result, nil
+}
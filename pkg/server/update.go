@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// maxConflictFields is the maximum number of the conflicting fields reported.
+const maxConflictFields = 50
+
+// UpdateConflict is returned when the object has been modified since the resourceVersion of the manifest and the
+// change can not be merged safely, the fields changed differently by the manifest and by others are listed.
+type UpdateConflict struct {
+	Error                 string      `json:"error"`
+	Message               string      `json:"message"`
+	ResourceVersion       string      `json:"resourceVersion"`
+	LatestResourceVersion string      `json:"latestResourceVersion"`
+	Fields                []FieldDiff `json:"fields"`
+	Remediation           string      `json:"remediation"`
+}
+
+// FieldDiff is a field which differs between the latest object and the manifest, the original value at the
+// resourceVersion of the manifest is set for the merge conflicts.
+type FieldDiff struct {
+	Path     string `json:"path"`
+	Original any    `json:"original,omitempty"`
+	Latest   any    `json:"latest,omitempty"`
+	Desired  any    `json:"desired,omitempty"`
+}
+
+// updateOnConflict updates the object and retries on the conflicts with the latest resourceVersion.
+//
+// The manifest without resourceVersion always updates the latest object. The manifest with resourceVersion is
+// merged into the latest object by a three-way merge: the original object at the resourceVersion of the manifest
+// is read from the API server, the changes of the manifest from it are patched onto the latest object, and the
+// fields changed differently by the manifest and by others are returned as the conflict. The conflict is also
+// returned if the original object is no longer available, unless force is set, which overwrites the concurrent
+// changes.
+func updateOnConflict(ctx context.Context, ri dynamic.ResourceInterface, obj *unstructured.Unstructured, force bool, subresources ...string) (*unstructured.Unstructured, *UpdateConflict, error) {
+	var (
+		result   *unstructured.Unstructured
+		conflict *UpdateConflict
+		original *unstructured.Unstructured
+		attempt  int
+	)
+	resourceVersion := obj.GetResourceVersion()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		desired := obj.DeepCopy()
+		if attempt++; attempt > 1 || len(resourceVersion) == 0 {
+			latest, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{}, subresources...)
+			if err != nil {
+				return err
+			}
+			if len(resourceVersion) > 0 && latest.GetResourceVersion() != resourceVersion && !force {
+				if original == nil {
+					if original, err = originalObject(ctx, ri, obj.GetName(), resourceVersion); err != nil {
+						conflict = newUpdateConflict(obj, latest, subresources)
+						conflict.Message += fmt.Sprintf(", and the object at it is not available to merge the change: %v", err)
+						return nil
+					}
+				}
+				patch, conflicts, err := threeWayMerge(original, obj, latest, subresources)
+				if err != nil {
+					return err
+				}
+				if len(conflicts) > 0 {
+					conflict = newMergeConflict(obj, latest, conflicts)
+					return nil
+				}
+				result, err = ri.Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}, subresources...)
+				return err
+			}
+			desired.SetResourceVersion(latest.GetResourceVersion())
+		}
+
+		var err error
+		result, err = ri.Update(ctx, desired, metav1.UpdateOptions{}, subresources...)
+		return err
+	})
+	return result, conflict, err
+}
+
+// originalObject reads the object as it was at the resourceVersion, which is served by the API server until the
+// revision is compacted.
+func originalObject(ctx context.Context, ri dynamic.ResourceInterface, name, resourceVersion string) (*unstructured.Unstructured, error) {
+	list, err := ri.List(ctx, metav1.ListOptions{
+		FieldSelector:        fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion:      resourceVersion,
+		ResourceVersionMatch: metav1.ResourceVersionMatchExact,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].GetName() == name {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("the object %s does not exist at resourceVersion %s", name, resourceVersion)
+}
+
+// threeWayMerge returns the merge patch of the changes from the original object to the manifest, which is guarded
+// by the resourceVersion of the latest object, and the fields changed differently by the manifest and the latest
+// object.
+func threeWayMerge(original, desired, latest *unstructured.Unstructured, subresources []string) ([]byte, []FieldDiff, error) {
+	originalContent, desiredContent, latestContent := normalizeObject(original, subresources), normalizeObject(desired, subresources), normalizeObject(latest, subresources)
+
+	var conflicts []FieldDiff
+	mergeConflicts("", originalContent, desiredContent, latestContent, &conflicts)
+	if len(conflicts) > 0 {
+		return nil, conflicts, nil
+	}
+
+	patch := mergePatch(originalContent, desiredContent)
+	if err := unstructured.SetNestedField(patch, latest.GetResourceVersion(), "metadata", "resourceVersion"); err != nil {
+		return nil, nil, err
+	}
+	data, err := json.Marshal(patch)
+	return data, nil, err
+}
+
+// mergeConflicts collects the fields changed by both the manifest and others since the original object to the
+// different values, the maps are merged recursively while the lists and the scalar values are compared as a whole.
+func mergeConflicts(path string, original, desired, latest any, conflicts *[]FieldDiff) {
+	// the field changed by one side only, or the same way by both is merged.
+	if reflect.DeepEqual(original, desired) || reflect.DeepEqual(original, latest) || reflect.DeepEqual(desired, latest) {
+		return
+	}
+	desiredMap, ok1 := desired.(map[string]any)
+	latestMap, ok2 := latest.(map[string]any)
+	if !ok1 || !ok2 {
+		*conflicts = append(*conflicts, FieldDiff{Path: path, Original: original, Latest: latest, Desired: desired})
+		return
+	}
+
+	originalMap, _ := original.(map[string]any)
+	keys := make(map[string]bool, len(desiredMap)+len(latestMap))
+	for key := range desiredMap {
+		keys[key] = true
+	}
+	for key := range latestMap {
+		keys[key] = true
+	}
+	for key := range keys {
+		mergeConflicts(fieldPath(path, key), originalMap[key], desiredMap[key], latestMap[key], conflicts)
+	}
+}
+
+// mergePatch returns the JSON merge patch from the original to the desired content.
+func mergePatch(original, desired map[string]any) map[string]any {
+	patch := make(map[string]any)
+	for key := range original {
+		if _, ok := desired[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, value := range desired {
+		originalMap, ok1 := original[key].(map[string]any)
+		desiredMap, ok2 := value.(map[string]any)
+		switch {
+		case ok1 && ok2:
+			if nested := mergePatch(originalMap, desiredMap); len(nested) > 0 {
+				patch[key] = nested
+			}
+		case !reflect.DeepEqual(original[key], value):
+			patch[key] = value
+		}
+	}
+	return patch
+}
+
+func newUpdateConflict(desired, latest *unstructured.Unstructured, subresources []string) *UpdateConflict {
+	conflict := &UpdateConflict{
+		Error:                 "Conflict",
+		Message:               fmt.Sprintf("the object has been modified since resourceVersion %s", desired.GetResourceVersion()),
+		ResourceVersion:       desired.GetResourceVersion(),
+		LatestResourceVersion: latest.GetResourceVersion(),
+		Fields:                make([]FieldDiff, 0),
+		Remediation:           "Review the fields changed by others, apply the change to the latest object, or set force to overwrite them",
+	}
+	diffFields("", normalizeObject(latest, subresources), normalizeObject(desired, subresources), &conflict.Fields)
+	conflict.Fields = sortConflictFields(conflict.Fields)
+	return conflict
+}
+
+func newMergeConflict(desired, latest *unstructured.Unstructured, fields []FieldDiff) *UpdateConflict {
+	return &UpdateConflict{
+		Error:                 "Conflict",
+		Message:               fmt.Sprintf("the object has been modified since resourceVersion %s, and %d fields are changed differently by others", desired.GetResourceVersion(), len(fields)),
+		ResourceVersion:       desired.GetResourceVersion(),
+		LatestResourceVersion: latest.GetResourceVersion(),
+		Fields:                sortConflictFields(fields),
+		Remediation:           "Review the conflicting fields, resolve them in the manifest of the latest resourceVersion, or set force to overwrite them",
+	}
+}
+
+func sortConflictFields(fields []FieldDiff) []FieldDiff {
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	if len(fields) > maxConflictFields {
+		fields = fields[:maxConflictFields]
+	}
+	return fields
+}
+
+// normalizeObject returns the content of the object without the fields populated by the cluster.
+func normalizeObject(obj *unstructured.Unstructured, subresources []string) map[string]any {
+	content := obj.DeepCopy().Object
+	for _, field := range clusterPopulatedFields {
+		// the status is what the update of the status subresource changes.
+		if field[0] == "status" && slices.Contains(subresources, "status") {
+			continue
+		}
+		unstructured.RemoveNestedField(content, field...)
+	}
+	// the numbers are compared after the round trip since the manifest is decoded into float64.
+	var normalized map[string]any
+	if data, err := json.Marshal(content); err == nil {
+		_ = json.Unmarshal(data, &normalized)
+	}
+	return normalized
+}
+
+// diffFields collects the paths of the different fields, the maps are compared recursively while the lists and
+// the scalar values are compared as a whole.
+func diffFields(path string, latest, desired any, diffs *[]FieldDiff) {
+	latestMap, ok1 := latest.(map[string]any)
+	desiredMap, ok2 := desired.(map[string]any)
+	if !ok1 || !ok2 {
+		if !reflect.DeepEqual(latest, desired) {
+			*diffs = append(*diffs, FieldDiff{Path: path, Latest: latest, Desired: desired})
+		}
+		return
+	}
+
+	keys := make(map[string]bool, len(latestMap)+len(desiredMap))
+	for key := range latestMap {
+		keys[key] = true
+	}
+	for key := range desiredMap {
+		keys[key] = true
+	}
+	for key := range keys {
+		diffFields(fieldPath(path, key), latestMap[key], desiredMap[key], diffs)
+	}
+}
+
+// fieldPath returns the path of the child field, the keys with dots or slashes like the labels are quoted.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	return path + "." + key
+}
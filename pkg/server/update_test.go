@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestUpdateOnConflict(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMap := func(resourceVersion string, data map[string]any) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]any{"data": data}}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace("shop")
+		obj.SetName("app")
+		obj.SetResourceVersion(resourceVersion)
+		return obj
+	}
+	// the object is changed by others from resourceVersion 2 to 3, the revisions before 2 are compacted.
+	original := configMap("2", map[string]any{"a": "1", "b": "1", "c": "1"})
+	latest := configMap("3", map[string]any{"a": "1", "b": "others", "c": "1"})
+
+	tests := []struct {
+		name         string
+		manifest     *unstructured.Unstructured
+		force        bool
+		wantData     map[string]any
+		wantConflict []FieldDiff
+		wantMessage  string
+	}{
+		{
+			name:     "change of another field is merged",
+			manifest: configMap("2", map[string]any{"a": "mine", "b": "1", "c": "1"}),
+			wantData: map[string]any{"a": "mine", "b": "others", "c": "1"},
+		},
+		{
+			name:     "removal of another field is merged",
+			manifest: configMap("2", map[string]any{"a": "1", "b": "1"}),
+			wantData: map[string]any{"a": "1", "b": "others"},
+		},
+		{
+			name:     "same change by both",
+			manifest: configMap("2", map[string]any{"a": "1", "b": "others", "c": "1"}),
+			wantData: map[string]any{"a": "1", "b": "others", "c": "1"},
+		},
+		{
+			name:         "different change of the same field",
+			manifest:     configMap("2", map[string]any{"a": "mine", "b": "mine", "c": "1"}),
+			wantConflict: []FieldDiff{{Path: ".data.b", Original: "1", Latest: "others", Desired: "mine"}},
+			wantMessage:  "1 fields are changed differently by others",
+		},
+		{
+			name:         "original object compacted",
+			manifest:     configMap("1", map[string]any{"a": "mine", "b": "1", "c": "1"}),
+			wantConflict: []FieldDiff{{Path: ".data.a", Latest: "1", Desired: "mine"}, {Path: ".data.b", Latest: "others", Desired: "1"}},
+			wantMessage:  "not available to merge the change",
+		},
+		{
+			name:     "force overwrites the changes of others",
+			manifest: configMap("2", map[string]any{"a": "mine", "b": "mine", "c": "1"}),
+			force:    true,
+			wantData: map[string]any{"a": "mine", "b": "mine", "c": "1"},
+		},
+		{
+			name:     "latest resourceVersion",
+			manifest: configMap("3", map[string]any{"a": "mine"}),
+			wantData: map[string]any{"a": "mine"},
+		},
+		{
+			name:     "manifest without resourceVersion",
+			manifest: configMap("", map[string]any{"b": "mine"}),
+			wantData: map[string]any{"b": "mine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{configMaps: "ConfigMapList"}, latest.DeepCopy())
+			// the fake tracker checks neither the resourceVersion nor serves the old revisions.
+			dynamicClient.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				obj := action.(clienttesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+				stored, err := dynamicClient.Tracker().Get(configMaps, "shop", obj.GetName())
+				if err != nil {
+					return true, nil, err
+				}
+				if obj.GetResourceVersion() != stored.(*unstructured.Unstructured).GetResourceVersion() {
+					return true, nil, apierrors.NewConflict(configMaps.GroupResource(), obj.GetName(), nil)
+				}
+				return false, nil, nil
+			})
+			// the fake drops the resourceVersion of the list, the original object is listed by the name only.
+			dynamicClient.PrependReactor("list", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.(clienttesting.ListActionImpl).ListOptions.FieldSelector != "metadata.name=app" {
+					return false, nil, nil
+				}
+				if tt.manifest.GetResourceVersion() != original.GetResourceVersion() {
+					return true, nil, apierrors.NewResourceExpired("too old resource version")
+				}
+				return true, &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*original.DeepCopy()}}, nil
+			})
+
+			ri := dynamicClient.Resource(configMaps).Namespace("shop")
+			_, conflict, err := updateOnConflict(context.Background(), ri, tt.manifest, tt.force)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantConflict != nil {
+				if conflict == nil {
+					t.Fatal("got no conflict")
+				}
+				if !reflect.DeepEqual(conflict.Fields, tt.wantConflict) || !strings.Contains(conflict.Message, tt.wantMessage) {
+					t.Fatalf("got conflict %q of fields %+v, want %q of %+v", conflict.Message, conflict.Fields, tt.wantMessage, tt.wantConflict)
+				}
+			} else if conflict != nil {
+				t.Fatalf("unexpected conflict: %+v", conflict)
+			}
+
+			obj, err := ri.Get(context.Background(), "app", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.wantData
+			if want == nil {
+				// the conflicting update leaves the object as it is.
+				want = latest.Object["data"].(map[string]any)
+			}
+			if !reflect.DeepEqual(obj.Object["data"], want) {
+				t.Fatalf("got data %v, want %v", obj.Object["data"], want)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		original map[string]any
+		desired  map[string]any
+		want     map[string]any
+	}{
+		{name: "unchanged", original: map[string]any{"a": "1"}, desired: map[string]any{"a": "1"}, want: map[string]any{}},
+		{name: "changed and added", original: map[string]any{"a": "1"}, desired: map[string]any{"a": "2", "b": "1"}, want: map[string]any{"a": "2", "b": "1"}},
+		{name: "removed", original: map[string]any{"a": "1", "b": "1"}, desired: map[string]any{"a": "1"}, want: map[string]any{"b": nil}},
+		{
+			name:     "nested map",
+			original: map[string]any{"spec": map[string]any{"replicas": 1.0, "paused": true}},
+			desired:  map[string]any{"spec": map[string]any{"replicas": 2.0, "paused": true}},
+			want:     map[string]any{"spec": map[string]any{"replicas": 2.0}},
+		},
+		{
+			name:     "list as a whole",
+			original: map[string]any{"args": []any{"a", "b"}},
+			desired:  map[string]any{"args": []any{"a"}},
+			want:     map[string]any{"args": []any{"a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePatch(tt.original, tt.desired); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got patch %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+const (
+	defaultSnapshotWaitTimeout = 5 * time.Minute
+	// defaultSnapshotClassAnnotation marks the default VolumeSnapshotClass of a CSI driver.
+	defaultSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+// VolumeSnapshotResult is the status of the VolumeSnapshot created by create_volume_snapshot.
+type VolumeSnapshotResult struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	SourcePVC     string `json:"sourcePVC"`
+	SnapshotClass string `json:"snapshotClass,omitempty"`
+	ReadyToUse    bool   `json:"readyToUse"`
+	RestoreSize   string `json:"restoreSize,omitempty"`
+	Content       string `json:"content,omitempty"`
+	Error         string `json:"error,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// RestoredClaim is the PersistentVolumeClaim created from a VolumeSnapshot by create_pvc_from_snapshot.
+type RestoredClaim struct {
+	Namespace    string   `json:"namespace"`
+	Name         string   `json:"name"`
+	Snapshot     string   `json:"snapshot"`
+	StorageClass string   `json:"storageClass,omitempty"`
+	Size         string   `json:"size"`
+	AccessModes  []string `json:"accessModes"`
+	Phase        string   `json:"phase"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// volumeSnapshot is a minimal copy of the snapshot.storage.k8s.io VolumeSnapshot, only the used fields are kept to
+// avoid depending on the external-snapshotter module.
+type volumeSnapshot struct {
+	Spec struct {
+		Source struct {
+			PersistentVolumeClaimName *string `json:"persistentVolumeClaimName,omitempty"`
+		} `json:"source"`
+		VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+	} `json:"spec"`
+	Status *struct {
+		BoundVolumeSnapshotContentName *string            `json:"boundVolumeSnapshotContentName,omitempty"`
+		ReadyToUse                     *bool              `json:"readyToUse,omitempty"`
+		RestoreSize                    *resource.Quantity `json:"restoreSize,omitempty"`
+		Error                          *struct {
+			Message *string `json:"message,omitempty"`
+		} `json:"error,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+func (s *Server) CreateVolumeSnapshot() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pvcName, err := req.RequireString("pvc")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		name := req.GetString("name", fmt.Sprintf("%s-%s", pvcName, time.Now().UTC().Format("20060102-150405")))
+		snapshotClass := req.GetString("snapshotClass", "")
+		waitForReady := req.GetBool("wait", false)
+		waitTimeout := waitTimeoutOf(ctx, req, defaultSnapshotWaitTimeout)
+
+		slog.Info("Creating volume snapshot", "pvc", pvcName, "namespace", namespace, "name", name, "snapshotClass", snapshotClass)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		snapshotGVR, err := s.snapshotResource(ctx, "VolumeSnapshot")
+		if err != nil {
+			return nil, err
+		}
+
+		pvc, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistentvolumeclaim: %w", err)
+		}
+		if pvc.Status.Phase != corev1.ClaimBound {
+			return nil, fmt.Errorf("persistentvolumeclaim %s/%s is %s, only the bound claims can be snapshotted", namespace, pvcName, pvc.Status.Phase)
+		}
+		pv, err := cli.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistentvolume: %w", err)
+		}
+		if pv.Spec.CSI == nil {
+			return nil, fmt.Errorf("persistentvolume %s is not provisioned by a CSI driver, it can't be snapshotted", pv.Name)
+		}
+		if len(snapshotClass) == 0 {
+			if snapshotClass, err = s.defaultSnapshotClass(ctx, dynamicClient, pv.Spec.CSI.Driver); err != nil {
+				return nil, err
+			}
+		}
+
+		snapshot := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": snapshotGVR.GroupVersion().String(),
+			"kind":       "VolumeSnapshot",
+			"metadata": map[string]any{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]any{
+				"source": map[string]any{"persistentVolumeClaimName": pvcName},
+			},
+		}}
+		if len(snapshotClass) > 0 {
+			_ = unstructured.SetNestedField(snapshot.Object, snapshotClass, "spec", "volumeSnapshotClassName")
+		}
+		created, err := dynamicClient.Resource(snapshotGVR).Namespace(namespace).Create(ctx, snapshot, metav1.CreateOptions{FieldManager: applyFieldManager})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create volumesnapshot: %w", err)
+		}
+
+		result, err := newVolumeSnapshotResult(created)
+		if err != nil {
+			return nil, err
+		}
+		if waitForReady && !result.ReadyToUse {
+			err = wait.PollUntilContextTimeout(ctx, 2*time.Second, waitTimeout, false, func(ctx context.Context) (bool, error) {
+				obj, err := dynamicClient.Resource(snapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				if result, err = newVolumeSnapshotResult(obj); err != nil {
+					return false, err
+				}
+				return result.ReadyToUse || len(result.Error) > 0, nil
+			})
+			if err != nil && !wait.Interrupted(err) {
+				return nil, fmt.Errorf("failed to wait for volumesnapshot: %w", err)
+			}
+			if !result.ReadyToUse && len(result.Error) == 0 {
+				result.Message = fmt.Sprintf("the snapshot is not ready to use after %s", waitTimeout)
+			}
+		}
+
+		resp, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+func (s *Server) CreatePVCFromSnapshot() func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		snapshotName, err := req.RequireString("snapshot")
+		if err != nil {
+			return nil, err
+		}
+		name, err := req.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		namespace := req.GetString("namespace", "")
+		if len(namespace) == 0 {
+			namespace = s.defaultNamespace(ctx)
+		}
+		storageClass := req.GetString("storageClass", "")
+		size := req.GetString("size", "")
+		accessModes := req.GetStringSlice("accessModes", nil)
+
+		slog.Info("Creating persistentvolumeclaim from snapshot", "snapshot", snapshotName, "namespace", namespace, "name", name)
+
+		cli, err := s.builder(ctx).GetClient()
+		if err != nil {
+			return nil, err
+		}
+		dynamicClient, err := s.builder(ctx).GetDynamicClient()
+		if err != nil {
+			return nil, err
+		}
+		snapshotGVR, err := s.snapshotResource(ctx, "VolumeSnapshot")
+		if err != nil {
+			return nil, err
+		}
+		obj, err := dynamicClient.Resource(snapshotGVR).Namespace(namespace).Get(ctx, snapshotName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volumesnapshot: %w", err)
+		}
+		snapshot := &volumeSnapshot{}
+		if err = fromUnstructured(obj, snapshot); err != nil {
+			return nil, err
+		}
+
+		restored := &RestoredClaim{Namespace: namespace, Name: name, Snapshot: snapshotName}
+		if snapshot.Status == nil || !ptr.Deref(snapshot.Status.ReadyToUse, false) {
+			restored.Warnings = append(restored.Warnings, "the snapshot is not ready to use yet, the claim stays pending until it is")
+		}
+
+		// the storage class and the access modes default to the ones of the source claim if it still exists.
+		var source *corev1.PersistentVolumeClaim
+		if claimName := ptr.Deref(snapshot.Spec.Source.PersistentVolumeClaimName, ""); len(claimName) > 0 {
+			source, err = cli.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, claimName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				source = nil
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to get the source persistentvolumeclaim: %w", err)
+			}
+		}
+		if len(storageClass) == 0 && source != nil {
+			storageClass = ptr.Deref(source.Spec.StorageClassName, "")
+		}
+		if len(accessModes) == 0 {
+			accessModes = []string{string(corev1.ReadWriteOnce)}
+			if source != nil && len(source.Spec.AccessModes) > 0 {
+				accessModes = accessModes[:0]
+				for _, mode := range source.Spec.AccessModes {
+					accessModes = append(accessModes, string(mode))
+				}
+			}
+		}
+
+		var request resource.Quantity
+		switch {
+		case len(size) > 0:
+			if request, err = resource.ParseQuantity(size); err != nil {
+				return nil, fmt.Errorf("invalid size: %w", err)
+			}
+			if snapshot.Status != nil && snapshot.Status.RestoreSize != nil && request.Cmp(*snapshot.Status.RestoreSize) < 0 {
+				return nil, fmt.Errorf("size %s is smaller than the restore size %s of the snapshot", size, snapshot.Status.RestoreSize.String())
+			}
+		case snapshot.Status != nil && snapshot.Status.RestoreSize != nil:
+			request = *snapshot.Status.RestoreSize
+		case source != nil:
+			request = source.Spec.Resources.Requests[corev1.ResourceStorage]
+		default:
+			return nil, fmt.Errorf("size is required, the restore size of the snapshot is unknown")
+		}
+
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				DataSource: &corev1.TypedLocalObjectReference{
+					APIGroup: ptr.To(definition.SnapshotGroup),
+					Kind:     "VolumeSnapshot",
+					Name:     snapshotName,
+				},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: request},
+				},
+			},
+		}
+		for _, mode := range accessModes {
+			pvc.Spec.AccessModes = append(pvc.Spec.AccessModes, corev1.PersistentVolumeAccessMode(mode))
+		}
+		if len(storageClass) > 0 {
+			pvc.Spec.StorageClassName = ptr.To(storageClass)
+			class, err := cli.StorageV1().StorageClasses().Get(ctx, storageClass, metav1.GetOptions{})
+			if err == nil && ptr.Deref(class.VolumeBindingMode, storagev1.VolumeBindingImmediate) == storagev1.VolumeBindingWaitForFirstConsumer {
+				restored.Warnings = append(restored.Warnings, "the storage class binds the volume on the first consumer, the claim stays pending until a pod uses it")
+			}
+		}
+
+		created, err := cli.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{FieldManager: applyFieldManager})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create persistentvolumeclaim: %w", err)
+		}
+		restored.StorageClass = ptr.Deref(created.Spec.StorageClassName, "")
+		restored.Size = request.String()
+		restored.AccessModes = accessModes
+		restored.Phase = string(created.Status.Phase)
+
+		resp, err := json.Marshal(restored)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(resp)), nil
+	}
+}
+
+// snapshotResource returns the resource of the volume snapshot kind, the snapshot CRDs are installed with the CSI
+// snapshot controller.
+func (s *Server) snapshotResource(ctx context.Context, kind string) (schema.GroupVersionResource, error) {
+	discoveryClient, err := s.builder(ctx).GetDiscoveryClient()
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	gvr, _, err := lookupGroupKindResource(discoveryClient, schema.GroupKind{Group: definition.SnapshotGroup, Kind: kind})
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("the volume snapshot CRDs are not installed in the cluster: %w", err)
+	}
+	return gvr, nil
+}
+
+// defaultSnapshotClass returns the default VolumeSnapshotClass of the CSI driver, or the only class of the driver.
+func (s *Server) defaultSnapshotClass(ctx context.Context, dynamicClient dynamic.Interface, driver string) (string, error) {
+	classGVR, err := s.snapshotResource(ctx, "VolumeSnapshotClass")
+	if err != nil {
+		return "", err
+	}
+	classes, err := dynamicClient.Resource(classGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list volumesnapshotclasses: %w", err)
+	}
+	var matched []string
+	for _, class := range classes.Items {
+		if classDriver, _, _ := unstructured.NestedString(class.Object, "driver"); classDriver != driver {
+			continue
+		}
+		if class.GetAnnotations()[defaultSnapshotClassAnnotation] == "true" {
+			return class.GetName(), nil
+		}
+		matched = append(matched, class.GetName())
+	}
+	switch len(matched) {
+	case 0:
+		return "", fmt.Errorf("no VolumeSnapshotClass found for the CSI driver %s", driver)
+	case 1:
+		return matched[0], nil
+	default:
+		return "", fmt.Errorf("multiple VolumeSnapshotClasses %v found for the CSI driver %s without a default, specify snapshotClass", matched, driver)
+	}
+}
+
+func newVolumeSnapshotResult(obj *unstructured.Unstructured) (*VolumeSnapshotResult, error) {
+	snapshot := &volumeSnapshot{}
+	if err := fromUnstructured(obj, snapshot); err != nil {
+		return nil, err
+	}
+	result := &VolumeSnapshotResult{
+		Namespace:     obj.GetNamespace(),
+		Name:          obj.GetName(),
+		SourcePVC:     ptr.Deref(snapshot.Spec.Source.PersistentVolumeClaimName, ""),
+		SnapshotClass: ptr.Deref(snapshot.Spec.VolumeSnapshotClassName, ""),
+	}
+	if status := snapshot.Status; status != nil {
+		result.ReadyToUse = ptr.Deref(status.ReadyToUse, false)
+		result.Content = ptr.Deref(status.BoundVolumeSnapshotContentName, "")
+		if status.RestoreSize != nil {
+			result.RestoreSize = status.RestoreSize.String()
+		}
+		if status.Error != nil {
+			result.Error = ptr.Deref(status.Error.Message, "")
+		}
+	}
+	return result, nil
+}
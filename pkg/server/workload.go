@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"cola.io/koffee/pkg/definition"
+)
+
+// workloadKinds are the kinds whose pods are resolved by the selector.
+var workloadKinds = sets.New("Pod", "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "Service")
+
+// parseWorkloadReference parses the workload reference like `deploy/foo`, the kind defaults to Pod when the name
+// has no kind prefix and the kind is empty.
+func parseWorkloadReference(kind, name string) (string, string, error) {
+	if len(kind) == 0 {
+		kind = "Pod"
+	}
+	workloadKind, name, err := definition.ParseKindName(kind, name)
+	if err != nil {
+		return "", "", err
+	}
+	if !workloadKinds.Has(workloadKind) {
+		return "", "", fmt.Errorf("unsupported workload kind %q, must be one of Pod, Deployment, StatefulSet, DaemonSet, ReplicaSet, Job or Service", workloadKind)
+	}
+	return workloadKind, name, nil
+}
+
+// resolveWorkloadPods resolves the workload to the pods selected by its selector, the pods are sorted by the
+// preference of reading logs, i.e. the ready and running pods come first, then the newer ones.
+func resolveWorkloadPods(ctx context.Context, cli kubernetes.Interface, namespace, kind, name string) ([]corev1.Pod, error) {
+	var selector *metav1.LabelSelector
+	switch kind {
+	case "Pod":
+		pod, err := cli.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	case "Deployment":
+		deploy, err := cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = deploy.Spec.Selector
+	case "StatefulSet":
+		sts, err := cli.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = sts.Spec.Selector
+	case "DaemonSet":
+		ds, err := cli.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = ds.Spec.Selector
+	case "ReplicaSet":
+		rs, err := cli.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = rs.Spec.Selector
+	case "Job":
+		job, err := cli.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = job.Spec.Selector
+	case "Service":
+		svc, err := cli.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = &metav1.LabelSelector{MatchLabels: svc.Spec.Selector}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of %s %s/%s: %w", kind, namespace, name, err)
+	}
+	// the empty selector, e.g. of the Services with the manual endpoints, and labels.Nothing for the missing one are
+	// both empty strings, which would list all the pods of the namespace.
+	if len(s.String()) == 0 {
+		return nil, fmt.Errorf("%s %s/%s has no selector, its pods can't be resolved", kind, namespace, name)
+	}
+	pods, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: s.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s %s/%s: %w", kind, namespace, name, err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for %s %s/%s", kind, namespace, name)
+	}
+
+	items := pods.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		if ri, rj := podReady(&items[i]), podReady(&items[j]); ri != rj {
+			return ri
+		}
+		return items[j].CreationTimestamp.Before(&items[i].CreationTimestamp)
+	})
+	return items, nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveWorkloadPods(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, labels map[string]string, ready bool, age time.Duration) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		}
+	}
+	service := func(name string, selector map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Spec: corev1.ServiceSpec{Selector: selector}}
+	}
+	cli := fake.NewClientset(
+		pod("web-old", map[string]string{"app": "web"}, true, time.Hour),
+		pod("web-new", map[string]string{"app": "web"}, true, time.Minute),
+		pod("web-unready", map[string]string{"app": "web"}, false, 0),
+		pod("db", map[string]string{"app": "db"}, true, time.Hour),
+		service("web", map[string]string{"app": "web"}),
+		service("external", nil),
+		service("nothing", map[string]string{"app": "none"}),
+	)
+
+	tests := []struct {
+		name    string
+		kind    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "db", kind: "Pod", want: []string{"db"}},
+		{name: "web", kind: "Service", want: []string{"web-new", "web-old", "web-unready"}},
+		{name: "external", kind: "Service", wantErr: true},
+		{name: "nothing", kind: "Service", wantErr: true},
+		{name: "missing", kind: "Service", wantErr: true},
+		{name: "web", kind: "CronJob", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.name, func(t *testing.T) {
+			pods, err := resolveWorkloadPods(context.Background(), cli, "default", tt.kind, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			got := make([]string, 0, len(pods))
+			for _, p := range pods {
+				got = append(got, p.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got pods %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got pods %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}